@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
@@ -17,20 +18,24 @@ func Register(cfg *sdkconfig.SDKConfig) {
 	}
 
 	keys := normalizeKeys(cfg.APIKeys)
-	if len(keys) == 0 {
+	proxyKeys := activeProxyKeys(cfg.ProxyKeys)
+	if len(keys) == 0 && len(proxyKeys) == 0 {
 		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeConfigAPIKey)
 		return
 	}
 
-	sdkaccess.RegisterProvider(
-		sdkaccess.AccessProviderTypeConfigAPIKey,
-		newProvider(sdkaccess.DefaultAccessProviderName, keys),
-	)
+	p := newProvider(sdkaccess.DefaultAccessProviderName, keys)
+	for _, entry := range proxyKeys {
+		p.keys[entry.Key] = entry
+	}
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeConfigAPIKey, p)
 }
 
+// provider authenticates api-keys, which map to nil, and proxy-keys, which map to their entry.
 type provider struct {
 	name string
-	keys map[string]struct{}
+	keys map[string]*sdkconfig.ProxyKey
+	now  func() time.Time
 }
 
 func newProvider(name string, keys []string) *provider {
@@ -38,11 +43,23 @@ func newProvider(name string, keys []string) *provider {
 	if providerName == "" {
 		providerName = sdkaccess.DefaultAccessProviderName
 	}
-	keySet := make(map[string]struct{}, len(keys))
+	keySet := make(map[string]*sdkconfig.ProxyKey, len(keys))
 	for _, key := range keys {
-		keySet[key] = struct{}{}
+		keySet[key] = nil
+	}
+	return &provider{name: providerName, keys: keySet, now: time.Now}
+}
+
+// activeProxyKeys returns copies of the proxy keys that have not been revoked.
+func activeProxyKeys(entries []sdkconfig.ProxyKey) []*sdkconfig.ProxyKey {
+	active := make([]*sdkconfig.ProxyKey, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Revoked || strings.TrimSpace(entry.Key) == "" {
+			continue
+		}
+		active = append(active, &entry)
 	}
-	return &provider{name: providerName, keys: keySet}
+	return active
 }
 
 func (p *provider) Identifier() string {
@@ -89,15 +106,27 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 		if candidate.value == "" {
 			continue
 		}
-		if _, ok := p.keys[candidate.value]; ok {
-			return &sdkaccess.Result{
-				Provider:  p.Identifier(),
-				Principal: candidate.value,
-				Metadata: map[string]string{
-					"source": candidate.source,
-				},
-			}, nil
+		entry, ok := p.keys[candidate.value]
+		if !ok {
+			continue
+		}
+		metadata := map[string]string{
+			"source": candidate.source,
+		}
+		if entry != nil {
+			if entry.Expired(p.now()) {
+				return nil, sdkaccess.NewExpiredCredentialError()
+			}
+			metadata["key-id"] = entry.ID
+			if entry.Name != "" {
+				metadata["key-name"] = entry.Name
+			}
 		}
+		return &sdkaccess.Result{
+			Provider:  p.Identifier(),
+			Principal: candidate.value,
+			Metadata:  metadata,
+		}, nil
 	}
 
 	return nil, sdkaccess.NewInvalidCredentialError()
@@ -0,0 +1,51 @@
+package configaccess
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestProviderAuthenticateProxyKeys(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newProvider("", []string{"plain-key"})
+	p.now = func() time.Time { return now }
+	for _, entry := range activeProxyKeys([]sdkconfig.ProxyKey{
+		{ID: "key-a", Key: "proxy-key", Name: "team-a"},
+		{ID: "key-b", Key: "expired-key", ExpiresAt: now.Add(-time.Minute)},
+		{ID: "key-c", Key: "revoked-key", Revoked: true},
+	}) {
+		p.keys[entry.Key] = entry
+	}
+
+	authenticate := func(key string) (*sdkaccess.Result, *sdkaccess.AuthError) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		return p.Authenticate(context.Background(), req)
+	}
+
+	result, errAuth := authenticate("proxy-key")
+	if errAuth != nil {
+		t.Fatalf("proxy key rejected: %v", errAuth)
+	}
+	if result.Principal != "proxy-key" || result.Metadata["key-id"] != "key-a" || result.Metadata["key-name"] != "team-a" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	result, errAuth = authenticate("plain-key")
+	if errAuth != nil || result.Metadata["key-id"] != "" {
+		t.Fatalf("plain key: result = %+v, err = %v", result, errAuth)
+	}
+
+	if _, errAuth = authenticate("expired-key"); errAuth == nil || errAuth.Message != "API key expired" {
+		t.Fatalf("expired key: err = %v", errAuth)
+	}
+	if _, errAuth = authenticate("revoked-key"); errAuth == nil || errAuth.Code != sdkaccess.AuthErrorCodeInvalidCredential {
+		t.Fatalf("revoked key: err = %v", errAuth)
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// clientCertProvider names the access provider and metadata source of requests authenticated
+// by a client certificate.
+const clientCertProvider = "client-cert"
+
+// configureClientAuth enables mutual TLS on tlsConfig when a client CA bundle is configured.
+func configureClientAuth(tlsConfig *tls.Config, cfg config.TLSConfig) error {
+	caPath := strings.TrimSpace(cfg.ClientCA)
+	if caPath == "" {
+		return nil
+	}
+	pemData, errRead := os.ReadFile(caPath)
+	if errRead != nil {
+		return fmt.Errorf("read tls.client-ca: %w", errRead)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return fmt.Errorf("tls.client-ca %s contains no PEM certificates", caPath)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	if cfg.ClientAuth == "verify-if-given" {
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return nil
+}
+
+// clientCertIdentity returns the identity of the client certificate verified during the TLS
+// handshake, "client-cert:<common name>", or "" when the client presented none. The prefix keeps
+// a common name that equals an API key from sharing that key's quota, limits and cache.
+func clientCertIdentity(r *http.Request) string {
+	if r == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	commonName := strings.TrimSpace(r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	if commonName == "" {
+		return ""
+	}
+	return clientCertProvider + ":" + commonName
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+func TestAuthMiddlewareUsesClientCertCommonName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := sdkaccess.NewManager()
+	manager.SetProviders([]sdkaccess.Provider{rejectAllProvider{}})
+
+	engine := gin.New()
+	engine.Use(AuthMiddleware(manager))
+	engine.GET("/v1/models", func(c *gin.Context) {
+		c.String(http.StatusOK, "%s|%s", c.GetString("apiKey"), c.GetString("accessProvider"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "team-a"}}}}}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "client-cert:team-a|client-cert" {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unverified client status = %d, want 401", rec.Code)
+	}
+}
+
+func TestAuthMiddlewareNamespacesClientCertIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := sdkaccess.NewManager()
+	manager.SetProviders([]sdkaccess.Provider{rejectAllProvider{}})
+
+	engine := gin.New()
+	engine.Use(AuthMiddleware(manager))
+	engine.GET("/v1/models", func(c *gin.Context) {
+		c.String(http.StatusOK, "%s", c.GetString("apiKey"))
+	})
+
+	// A certificate whose common name equals a configured API key must not act as that key.
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "test-key"}}}}}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Body.String(); got != "client-cert:test-key" {
+		t.Fatalf("apiKey = %q, want %q", got, "client-cert:test-key")
+	}
+}
+
+func TestConfigureClientAuth(t *testing.T) {
+	tlsConfig := &tls.Config{}
+	if err := configureClientAuth(tlsConfig, config.TLSConfig{}); err != nil || tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Fatalf("expected no client auth without a CA, got %v (%v)", tlsConfig.ClientAuth, err)
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := configureClientAuth(tlsConfig, config.TLSConfig{ClientCA: caPath}); err == nil {
+		t.Fatal("expected an error for a CA file without certificates")
+	}
+}
+
+type rejectAllProvider struct{}
+
+func (rejectAllProvider) Identifier() string { return "reject-all" }
+
+func (rejectAllProvider) Authenticate(_ context.Context, _ *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	return nil, sdkaccess.NewNoCredentialsError()
+}
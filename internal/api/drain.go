@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// drainPollInterval is how often Drain checks whether in-flight requests have finished.
+const drainPollInterval = 100 * time.Millisecond
+
+// drainState counts requests in flight and, once draining starts, turns new ones away so
+// running requests and streams can finish before the server shuts down.
+type drainState struct {
+	draining atomic.Bool
+	active   atomic.Int64
+}
+
+func (d *drainState) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d.draining.Load() {
+			c.Header("Connection", "close")
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: "Server is shutting down, retry the request.",
+					Type:    "server_error",
+					Code:    "server_shutting_down",
+				},
+			})
+			return
+		}
+		d.active.Add(1)
+		defer d.active.Add(-1)
+		c.Next()
+	}
+}
+
+// Drain stops accepting new requests and waits until every in-flight request, including SSE
+// streams and websocket sessions, has finished or ctx ends. Listeners stay open so clients and
+// load balancers receive a 503 instead of a refused connection. It returns the number of
+// requests still running when it gave up.
+func (s *Server) Drain(ctx context.Context) int64 {
+	if s == nil || s.drain == nil {
+		return 0
+	}
+	s.drain.draining.Store(true)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		active := s.drain.active.Load()
+		if active == 0 {
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return active
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gin "github.com/gin-gonic/gin"
+)
+
+func TestDrainWaitsForInFlightRequestsAndRejectsNewOnes(t *testing.T) {
+	server := newTestServer(t)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	server.engine.GET("/test/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	slow := httptest.NewRecorder()
+	finished := make(chan struct{})
+	go func() {
+		server.engine.ServeHTTP(slow, httptest.NewRequest(http.MethodGet, "/test/slow", nil))
+		close(finished)
+	}()
+	<-started
+
+	drained := make(chan int64, 1)
+	go func() { drained <- server.Drain(context.Background()) }()
+
+	// Drain must flip to rejecting before the slow request completes.
+	deadline := time.Now().Add(2 * time.Second)
+	for !server.drain.draining.Load() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	rejected := httptest.NewRecorder()
+	server.engine.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rejected.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status while draining = %d, want 503", rejected.Code)
+	}
+
+	select {
+	case <-drained:
+		t.Fatal("Drain returned while a request was still in flight")
+	case <-time.After(3 * drainPollInterval):
+	}
+	close(release)
+	<-finished
+	if remaining := <-drained; remaining != 0 || slow.Code != http.StatusOK {
+		t.Fatalf("remaining = %d, slow status = %d", remaining, slow.Code)
+	}
+}
+
+func TestDrainGivesUpWhenContextEnds(t *testing.T) {
+	server := newTestServer(t)
+	server.drain.active.Add(1)
+	defer server.drain.active.Add(-1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if remaining := server.Drain(ctx); remaining != 1 {
+		t.Fatalf("remaining = %d, want 1", remaining)
+	}
+}
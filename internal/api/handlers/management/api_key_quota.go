@@ -0,0 +1,29 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// GetAPIKeyQuota returns limits, usage and remaining allowance per client API key.
+// With ?api-key=<key> only that key is returned.
+func (h *Handler) GetAPIKeyQuota(c *gin.Context) {
+	stats := usage.GetRequestStatistics()
+	if key := strings.TrimSpace(c.Query("api-key")); key != "" {
+		status, _ := stats.CheckQuota(key)
+		c.JSON(http.StatusOK, gin.H{"api-key-quota": []usage.QuotaStatus{status}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api-key-quota": stats.QuotaStatuses()})
+}
+
+// ResetAPIKeyQuota clears recorded quota usage for ?api-key=<key>, or for every key
+// when no key is given.
+func (h *Handler) ResetAPIKeyQuota(c *gin.Context) {
+	key := strings.TrimSpace(c.Query("api-key"))
+	reset := usage.GetRequestStatistics().ResetQuota(key)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "reset": reset})
+}
@@ -1522,12 +1522,17 @@ func (h *Handler) RequestAnthropicToken(c *gin.Context) {
 			return
 		}
 
+		// Register the account with the running manager right away, as uploaded auth files are.
+		if errRegister := h.registerAuthFromFile(ctx, savedPath, nil); errRegister != nil {
+			log.Warnf("claude login: failed to register %s with the running proxy: %v", savedPath, errRegister)
+		}
+
 		fmt.Printf("Authentication successful! Token saved to %s\n", savedPath)
 		if bundle.APIKey != "" {
 			fmt.Println("API key obtained and saved")
 		}
 		fmt.Println("You can now use Claude services through this CLI")
-		CompleteOAuthSession(state)
+		FinishOAuthSession(state, tokenStorage.Email)
 		CompleteOAuthSessionsByProvider("anthropic")
 	}()
 
@@ -2587,6 +2592,10 @@ func (h *Handler) GetAuthStatus(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		return
 	}
+	if account := OAuthSessionAccount(state); account != "" {
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "account": account})
+		return
+	}
 	if status != "" {
 		c.JSON(http.StatusOK, gin.H{"status": "error", "error": status})
 		return
@@ -0,0 +1,29 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCircuitBreakers returns the circuit breaker state of every credential with recorded
+// upstream failures. Credentials that are not listed are closed.
+func (h *Handler) GetCircuitBreakers(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"circuit-breakers": h.authManager.CircuitBreakerStatuses()})
+}
+
+// ResetCircuitBreakers closes the circuit of ?auth-id=<id>, or of every credential when no
+// id is given.
+func (h *Handler) ResetCircuitBreakers(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	reset := h.authManager.ResetCircuitBreaker(strings.TrimSpace(c.Query("auth-id")))
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "reset": reset})
+}
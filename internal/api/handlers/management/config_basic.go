@@ -12,7 +12,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -141,13 +143,23 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 	defer func() {
 		_ = os.Remove(tempFile)
 	}()
-	_, err = config.LoadConfigOptional(tempFile, false)
+	validated, err := config.LoadConfigOptional(tempFile, false)
+	if err == nil {
+		err = validated.Validate()
+	}
 	if err != nil {
 		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_config", "message": err.Error()})
 		return
 	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	// External provider commands run programs on the host; they are only taken from the config
+	// file on disk, never from the management API.
+	if names := config.ExternalProviderCommandsChanged(h.cfg, validated); len(names) > 0 {
+		log.Warnf("management: rejected config.yaml update changing the command of external providers: %s", strings.Join(names, ", "))
+		c.JSON(http.StatusForbidden, gin.H{"error": "external_provider_command_locked", "message": "external provider commands can only be changed in the config file on disk"})
+		return
+	}
 	if WriteConfig(h.configFilePath, body) != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "write_failed", "message": "failed to write config"})
 		return
@@ -162,6 +174,11 @@ func (h *Handler) PutConfigYAML(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"ok": true, "changed": []string{"config"}})
 }
 
+// GetConfigReloadStatus returns the outcome of the most recent config hot reload.
+func (h *Handler) GetConfigReloadStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"reload": watcher.LastConfigReload()})
+}
+
 // GetConfigYAML returns the raw config.yaml file bytes without re-encoding.
 // It preserves comments and original formatting/styles.
 func (h *Handler) GetConfigYAML(c *gin.Context) {
@@ -193,6 +210,11 @@ func (h *Handler) PutUsageStatisticsEnabled(c *gin.Context) {
 	h.updateBoolField(c, func(v bool) { h.cfg.UsageStatisticsEnabled = v })
 }
 
+// GetUsageStatistics returns the in-memory usage totals and archived daily totals.
+func (h *Handler) GetUsageStatistics(c *gin.Context) {
+	c.JSON(200, gin.H{"usage": usage.GetRequestStatistics().Snapshot()})
+}
+
 // UsageStatisticsEnabled
 func (h *Handler) GetLoggingToFile(c *gin.Context) {
 	c.JSON(200, gin.H{"logging-to-file": h.cfg.LoggingToFile})
@@ -286,6 +308,8 @@ func normalizeRoutingStrategy(strategy string) (string, bool) {
 		return "round-robin", true
 	case "fill-first", "fillfirst", "ff":
 		return "fill-first", true
+	case "least-used", "leastused", "lu":
+		return "least-used", true
 	default:
 		return "", false
 	}
@@ -0,0 +1,55 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestPutConfigYAML_RejectsExternalProviderCommandChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	current := &config.Config{ExternalProviders: []config.ExternalProvider{{
+		Name:    "scripted",
+		Command: "/opt/provider/run",
+		Timeout: config.DefaultExternalProviderTimeout,
+	}}}
+	cases := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "changed command", body: "external-providers:\n  - name: scripted\n    command: /bin/sh\n    args: [\"-c\", \"id\"]\n", status: http.StatusForbidden},
+		{name: "added command", body: "external-providers:\n  - name: scripted\n    command: /opt/provider/run\n  - name: other\n    command: /bin/sh\n", status: http.StatusForbidden},
+		{name: "unchanged command", body: "external-providers:\n  - name: scripted\n    command: /opt/provider/run\n    priority: 2\n", status: http.StatusOK},
+		{name: "removed command", body: "debug: true\n", status: http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeTestConfigFile(t)
+			h := &Handler{cfg: current, configFilePath: path}
+
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPut, "/v0/management/config.yaml", strings.NewReader(tc.body))
+
+			h.PutConfigYAML(c)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d; body=%s", rec.Code, tc.status, rec.Body.String())
+			}
+			written, errRead := os.ReadFile(path)
+			if errRead != nil {
+				t.Fatalf("read config: %v", errRead)
+			}
+			if tc.status != http.StatusOK && string(written) != "{}\n" {
+				t.Fatalf("rejected update was written: %s", written)
+			}
+		})
+	}
+}
@@ -46,6 +46,7 @@ type Handler struct {
 	envSecret           string
 	logDir              string
 	postAuthHook        coreauth.PostAuthHook
+	replayHandler       http.Handler
 }
 
 // NewHandler creates a new management handler instance.
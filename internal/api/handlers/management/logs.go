@@ -2,6 +2,7 @@ package management
 
 import (
 	"bufio"
+	"compress/gzip"
 	"fmt"
 	"math"
 	"net/http"
@@ -20,6 +21,8 @@ const (
 	defaultLogFileName      = "main.log"
 	logScannerInitialBuffer = 64 * 1024
 	logScannerMaxBuffer     = 8 * 1024 * 1024
+	// defaultRequestLogListLimit caps the request log listing when no limit is given.
+	defaultRequestLogListLimit = 100
 )
 
 // GetLogs returns log lines with optional incremental loading.
@@ -189,7 +192,7 @@ func (h *Handler) GetRequestErrorLogs(c *gin.Context) {
 			continue
 		}
 		name := entry.Name()
-		if !strings.HasPrefix(name, "error-") || !strings.HasSuffix(name, ".log") {
+		if !strings.HasPrefix(name, "error-") || !isRequestLogName(name) {
 			continue
 		}
 		info, errInfo := entry.Info()
@@ -211,20 +214,36 @@ func (h *Handler) GetRequestErrorLogs(c *gin.Context) {
 
 // GetRequestLogByID finds and downloads a request log file by its request ID.
 // The ID is matched against the suffix of log file names (format: *-{requestID}.log).
+// Compressed logs (*.log.gz) are decompressed on the fly.
 func (h *Handler) GetRequestLogByID(c *gin.Context) {
+	fullPath, matchedFile, ok := h.locateRequestLog(c)
+	if !ok {
+		return
+	}
+
+	if strings.HasSuffix(matchedFile, ".gz") {
+		serveCompressedLog(c, fullPath, strings.TrimSuffix(matchedFile, ".gz"))
+		return
+	}
+	c.FileAttachment(fullPath, matchedFile)
+}
+
+// locateRequestLog resolves the request log named by the :id path parameter (or id query) to its
+// path inside the log directory. It writes an error response and returns false when none matches.
+func (h *Handler) locateRequestLog(c *gin.Context) (string, string, bool) {
 	if h == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
-		return
+		return "", "", false
 	}
 	if h.cfg == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
-		return
+		return "", "", false
 	}
 
 	dir := h.logDirectory()
 	if strings.TrimSpace(dir) == "" {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
-		return
+		return "", "", false
 	}
 
 	requestID := strings.TrimSpace(c.Param("id"))
@@ -233,21 +252,21 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 	}
 	if requestID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing request ID"})
-		return
+		return "", "", false
 	}
 	if strings.ContainsAny(requestID, "/\\") {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
-		return
+		return "", "", false
 	}
 
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "log directory not found"})
-			return
+			return "", "", false
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list log directory: %v", err)})
-		return
+		return "", "", false
 	}
 
 	suffix := "-" + requestID + ".log"
@@ -257,7 +276,7 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 			continue
 		}
 		name := entry.Name()
-		if strings.HasSuffix(name, suffix) {
+		if strings.HasSuffix(name, suffix) || strings.HasSuffix(name, suffix+".gz") {
 			matchedFile = name
 			break
 		}
@@ -265,36 +284,100 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 
 	if matchedFile == "" {
 		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found for the given request ID"})
-		return
+		return "", "", false
 	}
 
 	dirAbs, errAbs := filepath.Abs(dir)
 	if errAbs != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to resolve log directory: %v", errAbs)})
-		return
+		return "", "", false
 	}
 	fullPath := filepath.Clean(filepath.Join(dirAbs, matchedFile))
 	prefix := dirAbs + string(os.PathSeparator)
 	if !strings.HasPrefix(fullPath, prefix) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file path"})
-		return
+		return "", "", false
 	}
 
 	info, errStat := os.Stat(fullPath)
 	if errStat != nil {
 		if os.IsNotExist(errStat) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "log file not found"})
-			return
+			return "", "", false
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log file: %v", errStat)})
-		return
+		return "", "", false
 	}
 	if info.IsDir() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file"})
+		return "", "", false
+	}
+	return fullPath, matchedFile, true
+}
+
+// ListRequestLogs lists recent request log files, newest first, with the request ID parsed from
+// each file name. The optional limit query parameter caps the result (default 100, 0 for all).
+func (h *Handler) ListRequestLogs(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	if h.cfg == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
 		return
 	}
 
-	c.FileAttachment(fullPath, matchedFile)
+	limit := defaultRequestLogListLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, errParse := strconv.Atoi(raw)
+		if errParse != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	dir := h.logDirectory()
+	if strings.TrimSpace(dir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
+		return
+	}
+	files, errList := logging.ListRequestLogs(dir)
+	if errList != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list request logs: %v", errList)})
+		return
+	}
+	total := len(files)
+	if limit > 0 && len(files) > limit {
+		files = files[:limit]
+	}
+	if files == nil {
+		files = []logging.RequestLogFile{}
+	}
+	c.JSON(http.StatusOK, gin.H{"files": files, "total": total})
+}
+
+// serveCompressedLog streams the decompressed content of a gzipped log file as an attachment.
+func serveCompressedLog(c *gin.Context, path, name string) {
+	file, errOpen := os.Open(path)
+	if errOpen != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to open log file: %v", errOpen)})
+		return
+	}
+	defer func() { _ = file.Close() }()
+	reader, errGzip := gzip.NewReader(file)
+	if errGzip != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read compressed log file: %v", errGzip)})
+		return
+	}
+	defer func() { _ = reader.Close() }()
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.DataFromReader(http.StatusOK, -1, "text/plain; charset=utf-8", reader, nil)
+}
+
+// isRequestLogName reports whether name is a plain or compressed log file.
+func isRequestLogName(name string) bool {
+	return strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")
 }
 
 // DownloadRequestErrorLog downloads a specific error request log file by name.
@@ -319,7 +402,7 @@ func (h *Handler) DownloadRequestErrorLog(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file name"})
 		return
 	}
-	if !strings.HasPrefix(name, "error-") || !strings.HasSuffix(name, ".log") {
+	if !strings.HasPrefix(name, "error-") || !isRequestLogName(name) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found"})
 		return
 	}
@@ -1,10 +1,12 @@
 package management
 
 import (
+	"encoding/json"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 )
 
@@ -31,3 +33,100 @@ func (h *Handler) GetStaticModelDefinitions(c *gin.Context) {
 		"models":  models,
 	})
 }
+
+// GetModelOverrides returns the configured model catalog overrides.
+func (h *Handler) GetModelOverrides(c *gin.Context) {
+	overrides := h.cfg.ModelOverrides
+	if overrides == nil {
+		overrides = []registry.ModelOverride{}
+	}
+	c.JSON(http.StatusOK, gin.H{"model-overrides": overrides})
+}
+
+// PutModelOverrides replaces every model catalog override.
+func (h *Handler) PutModelOverrides(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	var entries []registry.ModelOverride
+	if err = json.Unmarshal(data, &entries); err != nil {
+		var wrapper struct {
+			Items []registry.ModelOverride `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &wrapper); err2 != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+		entries = wrapper.Items
+	}
+	for _, entry := range entries {
+		if errValidate := validateModelOverride(entry); errValidate != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errValidate, "id": entry.ID})
+			return
+		}
+	}
+	h.cfg.ModelOverrides = config.NormalizeModelOverrides(entries)
+	h.persistModelOverrides(c)
+}
+
+// PatchModelOverride adds a model catalog override or replaces the one with the same provider
+// and ID.
+func (h *Handler) PatchModelOverride(c *gin.Context) {
+	var entry registry.ModelOverride
+	if errBindJSON := c.ShouldBindJSON(&entry); errBindJSON != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if errValidate := validateModelOverride(entry); errValidate != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errValidate})
+		return
+	}
+	h.cfg.ModelOverrides = config.NormalizeModelOverrides(append(h.cfg.ModelOverrides, entry))
+	h.persistModelOverrides(c)
+}
+
+// DeleteModelOverride removes the override identified by the provider and id query parameters.
+func (h *Handler) DeleteModelOverride(c *gin.Context) {
+	provider := strings.ToLower(strings.TrimSpace(c.Query("provider")))
+	id := strings.TrimSpace(c.Query("id"))
+	if provider == "" || id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider and id are required"})
+		return
+	}
+	out := make([]registry.ModelOverride, 0, len(h.cfg.ModelOverrides))
+	for _, entry := range h.cfg.ModelOverrides {
+		if entry.Provider == provider && strings.EqualFold(entry.ID, id) {
+			continue
+		}
+		out = append(out, entry)
+	}
+	if len(out) == len(h.cfg.ModelOverrides) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "model override not found"})
+		return
+	}
+	h.cfg.ModelOverrides = config.NormalizeModelOverrides(out)
+	h.persistModelOverrides(c)
+}
+
+// persistModelOverrides saves the config and applies the overrides to the registry without
+// waiting for the config watcher.
+func (h *Handler) persistModelOverrides(c *gin.Context) {
+	if h.persist(c) {
+		registry.SetModelOverrides(h.cfg.ModelOverrides)
+	}
+}
+
+func validateModelOverride(entry registry.ModelOverride) string {
+	if strings.TrimSpace(entry.ID) == "" {
+		return "id is required"
+	}
+	if !registry.IsModelOverrideProvider(entry.Provider) {
+		return "unknown provider"
+	}
+	if entry.ContextLength < 0 || entry.MaxCompletionTokens < 0 {
+		return "token limits must not be negative"
+	}
+	return ""
+}
@@ -23,8 +23,10 @@ var (
 )
 
 type oauthSession struct {
-	Provider  string
-	Status    string
+	Provider string
+	Status   string
+	// Account is set once the login finished and names the account that was added.
+	Account   string
 	CreatedAt time.Time
 	ExpiresAt time.Time
 }
@@ -111,6 +113,34 @@ func (s *oauthSessionStore) Complete(state string) {
 	delete(s.sessions, state)
 }
 
+// Finish marks the session as successful and keeps it until its TTL so pollers can read the
+// account that was added.
+func (s *oauthSessionStore) Finish(state, account string) {
+	state = strings.TrimSpace(state)
+	account = strings.TrimSpace(account)
+	if state == "" {
+		return
+	}
+	if account == "" {
+		s.Complete(state)
+		return
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purgeExpiredLocked(now)
+	session, ok := s.sessions[state]
+	if !ok {
+		return
+	}
+	session.Status = ""
+	session.Account = account
+	session.ExpiresAt = now.Add(s.ttl)
+	s.sessions[state] = session
+}
+
 func (s *oauthSessionStore) CompleteProvider(provider string) int {
 	provider = strings.ToLower(strings.TrimSpace(provider))
 	if provider == "" {
@@ -124,6 +154,9 @@ func (s *oauthSessionStore) CompleteProvider(provider string) int {
 	s.purgeExpiredLocked(now)
 	removed := 0
 	for state, session := range s.sessions {
+		if session.Account != "" {
+			continue
+		}
 		if strings.EqualFold(session.Provider, provider) {
 			delete(s.sessions, state)
 			removed++
@@ -157,7 +190,7 @@ func (s *oauthSessionStore) IsPending(state, provider string) bool {
 	if !ok {
 		return false
 	}
-	if session.Status != "" {
+	if session.Status != "" || session.Account != "" {
 		return false
 	}
 	if provider == "" {
@@ -174,6 +207,8 @@ func SetOAuthSessionError(state, message string) { oauthSessions.SetError(state,
 
 func CompleteOAuthSession(state string) { oauthSessions.Complete(state) }
 
+func FinishOAuthSession(state, account string) { oauthSessions.Finish(state, account) }
+
 func CompleteOAuthSessionsByProvider(provider string) int {
 	return oauthSessions.CompleteProvider(provider)
 }
@@ -186,6 +221,15 @@ func GetOAuthSession(state string) (provider string, status string, ok bool) {
 	return session.Provider, session.Status, true
 }
 
+// OAuthSessionAccount returns the account added by a finished login session, or "".
+func OAuthSessionAccount(state string) string {
+	session, ok := oauthSessions.Get(state)
+	if !ok {
+		return ""
+	}
+	return session.Account
+}
+
 func IsOAuthSessionPending(state, provider string) bool {
 	return oauthSessions.IsPending(state, provider)
 }
@@ -0,0 +1,49 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetAuthStatus_ReportsFinishedAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	poll := func(state string) map[string]string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rec)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/get-auth-status?state="+state, nil)
+		h.GetAuthStatus(ctx)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status code = %d, body=%s", rec.Code, rec.Body.String())
+		}
+		var body map[string]string
+		if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &body); errUnmarshal != nil {
+			t.Fatalf("decode body: %v", errUnmarshal)
+		}
+		return body
+	}
+
+	RegisterOAuthSession("finish-state", "anthropic")
+	RegisterOAuthSession("other-state", "anthropic")
+	if body := poll("finish-state"); body["status"] != "wait" {
+		t.Fatalf("pending poll = %v, want wait", body)
+	}
+
+	FinishOAuthSession("finish-state", "user@example.com")
+	CompleteOAuthSessionsByProvider("anthropic")
+	if IsOAuthSessionPending("finish-state", "anthropic") {
+		t.Fatal("finished session still pending")
+	}
+	if body := poll("finish-state"); body["status"] != "ok" || body["account"] != "user@example.com" {
+		t.Fatalf("finished poll = %v, want ok with account", body)
+	}
+	if _, _, ok := GetOAuthSession("other-state"); ok {
+		t.Fatal("other pending session of the provider was not completed")
+	}
+	CompleteOAuthSession("finish-state")
+}
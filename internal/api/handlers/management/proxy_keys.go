@@ -0,0 +1,239 @@
+package management
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	// proxyKeyPrefix starts every generated proxy key.
+	proxyKeyPrefix = "sk-proxy-"
+	// proxyKeyBytes is the amount of randomness in a generated proxy key.
+	proxyKeyBytes = 24
+)
+
+// proxyKeyView is a proxy key as listed by the management API, with the secret masked.
+type proxyKeyView struct {
+	config.ProxyKey
+	Status string `json:"status"`
+}
+
+// proxyKeyRequest carries the editable proxy key fields; nil fields are left unchanged.
+type proxyKeyRequest struct {
+	Name              *string            `json:"name"`
+	Models            *[]string          `json:"models"`
+	ThinkingSuffixes  *[]string          `json:"thinking-suffixes"`
+	ModelAliases      *map[string]string `json:"model-aliases"`
+	RequestsPerMinute *int               `json:"requests-per-minute"`
+	ExpiresAt         *time.Time         `json:"expires-at"`
+}
+
+// GetProxyKeys lists the proxy keys with masked secrets and their status: active, expired or revoked.
+func (h *Handler) GetProxyKeys(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	keys := make([]proxyKeyView, 0, len(h.cfg.ProxyKeys))
+	for _, entry := range h.cfg.ProxyKeys {
+		status := "active"
+		switch {
+		case entry.Revoked:
+			status = "revoked"
+		case entry.Expired(now):
+			status = "expired"
+		}
+		entry.Key = maskProxyKey(entry.Key)
+		keys = append(keys, proxyKeyView{ProxyKey: entry, Status: status})
+	}
+	c.JSON(http.StatusOK, gin.H{"proxy-keys": keys})
+}
+
+// CreateProxyKey generates a new proxy key. The secret is only returned in this response.
+func (h *Handler) CreateProxyKey(c *gin.Context) {
+	var body proxyKeyRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	key, errKey := generateProxyKey()
+	if errKey != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate key: %v", errKey)})
+		return
+	}
+	id, errID := generateProxyKeyID()
+	if errID != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate key: %v", errID)})
+		return
+	}
+	entry := config.ProxyKey{ID: id, Key: key, CreatedAt: time.Now().UTC()}
+	if errApply := body.apply(&entry); errApply != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errApply.Error()})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cfg.ProxyKeys = append(h.cfg.ProxyKeys, entry)
+	h.cfg.SanitizeProxyKeys()
+	if h.saveProxyKeysLocked(c) {
+		c.JSON(http.StatusCreated, gin.H{"proxy-key": entry})
+	}
+}
+
+// PatchProxyKey updates the name, allowed models and suffixes, model aliases, rate limit or
+// expiration of a proxy key.
+func (h *Handler) PatchProxyKey(c *gin.Context) {
+	var body proxyKeyRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry := h.findProxyKeyLocked(c)
+	if entry == nil {
+		return
+	}
+	updated := *entry
+	if errApply := body.apply(&updated); errApply != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errApply.Error()})
+		return
+	}
+	*entry = updated
+	h.cfg.SanitizeProxyKeys()
+	h.persistLocked(c)
+}
+
+// RotateProxyKey replaces the secret of a proxy key, keeping its ID and policy. The new secret
+// is only returned in this response; the old one stops working once the config is reloaded.
+func (h *Handler) RotateProxyKey(c *gin.Context) {
+	key, errKey := generateProxyKey()
+	if errKey != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate key: %v", errKey)})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry := h.findProxyKeyLocked(c)
+	if entry == nil {
+		return
+	}
+	entry.Key = key
+	entry.CreatedAt = time.Now().UTC()
+	rotated := *entry
+	if h.saveProxyKeysLocked(c) {
+		c.JSON(http.StatusOK, gin.H{"proxy-key": rotated})
+	}
+}
+
+// RevokeProxyKey disables a proxy key while keeping it listed.
+func (h *Handler) RevokeProxyKey(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry := h.findProxyKeyLocked(c)
+	if entry == nil {
+		return
+	}
+	entry.Revoked = true
+	h.persistLocked(c)
+}
+
+// DeleteProxyKey removes a proxy key.
+func (h *Handler) DeleteProxyKey(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := strings.TrimSpace(c.Param("id"))
+	for i := range h.cfg.ProxyKeys {
+		if h.cfg.ProxyKeys[i].ID == id {
+			h.cfg.ProxyKeys = append(h.cfg.ProxyKeys[:i], h.cfg.ProxyKeys[i+1:]...)
+			h.persistLocked(c)
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+}
+
+// findProxyKeyLocked returns the proxy key named by the :id path parameter, or writes a 404.
+// It expects the caller to hold h.mu.
+func (h *Handler) findProxyKeyLocked(c *gin.Context) *config.ProxyKey {
+	id := strings.TrimSpace(c.Param("id"))
+	for i := range h.cfg.ProxyKeys {
+		if h.cfg.ProxyKeys[i].ID == id {
+			return &h.cfg.ProxyKeys[i]
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+	return nil
+}
+
+// saveProxyKeysLocked saves the config without writing a success response, so the caller can
+// return the new secret. It expects the caller to hold h.mu.
+func (h *Handler) saveProxyKeysLocked(c *gin.Context) bool {
+	if err := config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
+		return false
+	}
+	return true
+}
+
+func (r *proxyKeyRequest) apply(entry *config.ProxyKey) error {
+	if r.Name != nil {
+		entry.Name = strings.TrimSpace(*r.Name)
+	}
+	if r.Models != nil {
+		entry.Models = append([]string(nil), (*r.Models)...)
+	}
+	if r.ThinkingSuffixes != nil {
+		entry.ThinkingSuffixes = append([]string(nil), (*r.ThinkingSuffixes)...)
+	}
+	if r.ModelAliases != nil {
+		entry.ModelAliases = maps.Clone(*r.ModelAliases)
+	}
+	if r.RequestsPerMinute != nil {
+		if *r.RequestsPerMinute < 0 {
+			return fmt.Errorf("requests-per-minute must not be negative")
+		}
+		entry.RequestsPerMinute = *r.RequestsPerMinute
+	}
+	if r.ExpiresAt != nil {
+		entry.ExpiresAt = r.ExpiresAt.UTC()
+	}
+	return nil
+}
+
+func generateProxyKey() (string, error) {
+	buf := make([]byte, proxyKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return proxyKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+func generateProxyKeyID() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "key-" + hex.EncodeToString(buf), nil
+}
+
+// maskProxyKey keeps the prefix and last four characters of a proxy key.
+func maskProxyKey(key string) string {
+	if len(key) <= len(proxyKeyPrefix)+4 {
+		return strings.Repeat("*", len(key))
+	}
+	if strings.HasPrefix(key, proxyKeyPrefix) {
+		return proxyKeyPrefix + "..." + key[len(key)-4:]
+	}
+	return "..." + key[len(key)-4:]
+}
@@ -0,0 +1,74 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func proxyKeyRequestContext(method, target, body, id string) (*gin.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if id != "" {
+		c.Params = gin.Params{{Key: "id", Value: id}}
+	}
+	return c, rec
+}
+
+func TestProxyKeyLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cfg: &config.Config{}, configFilePath: writeTestConfigFile(t)}
+
+	c, rec := proxyKeyRequestContext(http.MethodPost, "/v0/management/proxy-keys", `{"name":"team-a","models":["claude-*"],"requests-per-minute":30}`, "")
+	h.CreateProxyKey(c)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ProxyKey config.ProxyKey `json:"proxy-key"`
+	}
+	if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &created); errUnmarshal != nil {
+		t.Fatalf("decode create response: %v", errUnmarshal)
+	}
+	key := created.ProxyKey
+	if !strings.HasPrefix(key.Key, proxyKeyPrefix) || key.ID == "" || key.Name != "team-a" || key.RequestsPerMinute != 30 {
+		t.Fatalf("unexpected created key: %+v", key)
+	}
+
+	c, rec = proxyKeyRequestContext(http.MethodPost, "/v0/management/proxy-keys/"+key.ID+"/rotate", "", key.ID)
+	h.RotateProxyKey(c)
+	if rec.Code != http.StatusOK || h.cfg.ProxyKeys[0].Key == key.Key || h.cfg.ProxyKeys[0].ID != key.ID {
+		t.Fatalf("rotate status = %d, keys = %+v", rec.Code, h.cfg.ProxyKeys)
+	}
+
+	c, rec = proxyKeyRequestContext(http.MethodPost, "/v0/management/proxy-keys/"+key.ID+"/revoke", "", key.ID)
+	h.RevokeProxyKey(c)
+	if rec.Code != http.StatusOK || !h.cfg.ProxyKeys[0].Revoked {
+		t.Fatalf("revoke status = %d, keys = %+v", rec.Code, h.cfg.ProxyKeys)
+	}
+
+	c, rec = proxyKeyRequestContext(http.MethodGet, "/v0/management/proxy-keys", "", "")
+	h.GetProxyKeys(c)
+	body := rec.Body.String()
+	if strings.Contains(body, h.cfg.ProxyKeys[0].Key) || !strings.Contains(body, `"status":"revoked"`) {
+		t.Fatalf("list must mask secrets and report status: %s", body)
+	}
+
+	c, rec = proxyKeyRequestContext(http.MethodDelete, "/v0/management/proxy-keys/missing", "", "missing")
+	h.DeleteProxyKey(c)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("delete missing status = %d", rec.Code)
+	}
+	c, rec = proxyKeyRequestContext(http.MethodDelete, "/v0/management/proxy-keys/"+key.ID, "", key.ID)
+	h.DeleteProxyKey(c)
+	if rec.Code != http.StatusOK || len(h.cfg.ProxyKeys) != 0 {
+		t.Fatalf("delete status = %d, keys = %+v", rec.Code, h.cfg.ProxyKeys)
+	}
+}
@@ -0,0 +1,174 @@
+package management
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// replayDroppedHeaders are not copied from the logged request: credentials are masked in request
+// logs, and the rest describe the original connection rather than the request.
+var replayDroppedHeaders = []string{
+	"Authorization", "X-Api-Key", "X-Goog-Api-Key", "Cookie",
+	"Content-Length", "Accept-Encoding", "Connection", "Upgrade",
+}
+
+// replayRequest carries the optional replay overrides.
+type replayRequest struct {
+	Model string `json:"model"`
+}
+
+// SetReplayHandler sets the HTTP handler that replayed requests are dispatched to.
+func (h *Handler) SetReplayHandler(handler http.Handler) {
+	h.replayHandler = handler
+}
+
+// ReplayRequestLog re-executes the request recorded in a request log through the current
+// translation pipeline and returns the translated upstream traffic along with the final response.
+// An optional {"model": "..."} body replays the request against a different model.
+func (h *Handler) ReplayRequestLog(c *gin.Context) {
+	if h.replayHandler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "request replay unavailable"})
+		return
+	}
+	var body replayRequest
+	raw, errRead := io.ReadAll(c.Request.Body)
+	if errRead != nil || (len(bytes.TrimSpace(raw)) > 0 && json.Unmarshal(raw, &body) != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	fullPath, name, ok := h.locateRequestLog(c)
+	if !ok {
+		return
+	}
+	data, errLoad := readLogFile(fullPath, strings.HasSuffix(name, ".gz"))
+	if errLoad != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log file: %v", errLoad)})
+		return
+	}
+	logged, errParse := logging.ParseRequestLog(data)
+	if errParse != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("cannot replay request log: %v", errParse)})
+		return
+	}
+
+	target, errURL := url.Parse(logged.URL)
+	if errURL != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("invalid logged URL: %v", errURL)})
+		return
+	}
+	query := target.Query()
+	query.Del("key")
+	query.Del("auth_token")
+	target.RawQuery = query.Encode()
+	payload := logged.Body
+	model := strings.TrimSpace(body.Model)
+	if model != "" {
+		payload, target.Path = overrideReplayModel(payload, target.Path, model)
+	}
+	if model == "" {
+		model = gjson.GetBytes(payload, "model").String()
+	}
+
+	ctx, capture := logging.WithReplay(c.Request.Context())
+	req, errReq := http.NewRequestWithContext(ctx, logged.Method, target.RequestURI(), bytes.NewReader(payload))
+	if errReq != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("invalid logged request: %v", errReq)})
+		return
+	}
+	req.Header = logged.Headers.Clone()
+	for _, header := range replayDroppedHeaders {
+		req.Header.Del(header)
+	}
+	req.RemoteAddr = c.Request.RemoteAddr
+	recorder := newReplayRecorder()
+	h.replayHandler.ServeHTTP(recorder, req)
+
+	c.JSON(http.StatusOK, gin.H{
+		"request-id":        strings.TrimSpace(c.Param("id")),
+		"method":            logged.Method,
+		"url":               target.RequestURI(),
+		"model":             model,
+		"status":            recorder.status,
+		"response-headers":  recorder.header,
+		"upstream-request":  string(capture.UpstreamRequest()),
+		"upstream-response": string(capture.UpstreamResponse()),
+		"response":          recorder.body.String(),
+	})
+}
+
+// overrideReplayModel points the request at model: the body's model field when present, otherwise
+// the model segment of a Gemini-style path such as /v1beta/models/{model}:generateContent.
+func overrideReplayModel(payload []byte, path, model string) ([]byte, string) {
+	if gjson.GetBytes(payload, "model").Exists() {
+		if updated, errSet := sjson.SetBytes(payload, "model", model); errSet == nil {
+			return updated, path
+		}
+		return payload, path
+	}
+	prefix, rest, found := strings.Cut(path, "/models/")
+	if !found {
+		return payload, path
+	}
+	if _, action, hasAction := strings.Cut(rest, ":"); hasAction {
+		return payload, prefix + "/models/" + model + ":" + action
+	}
+	return payload, prefix + "/models/" + model
+}
+
+// readLogFile reads a log file, decompressing gzipped logs.
+func readLogFile(path string, compressed bool) ([]byte, error) {
+	file, errOpen := os.Open(path)
+	if errOpen != nil {
+		return nil, errOpen
+	}
+	defer func() { _ = file.Close() }()
+	if !compressed {
+		return io.ReadAll(file)
+	}
+	reader, errGzip := gzip.NewReader(file)
+	if errGzip != nil {
+		return nil, errGzip
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
+
+// replayRecorder buffers the response of a replayed request.
+type replayRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newReplayRecorder() *replayRecorder {
+	return &replayRecorder{header: make(http.Header)}
+}
+
+func (r *replayRecorder) Header() http.Header { return r.header }
+
+func (r *replayRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *replayRecorder) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(data)
+}
+
+// Flush implements http.Flusher so streaming handlers can run against the recorder.
+func (r *replayRecorder) Flush() {}
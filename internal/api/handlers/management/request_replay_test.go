@@ -0,0 +1,72 @@
+package management
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+func TestReplayRequestLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	logData := "=== REQUEST INFO ===\nURL: /v1/chat/completions\nMethod: POST\n\n" +
+		"=== HEADERS ===\nContent-Type: application/json\nAuthorization: Bearer sk-...abcd\n\n" +
+		"=== REQUEST BODY ===\n{\"model\":\"gpt-4o\",\"messages\":[]}\n\n=== RESPONSE ===\nStatus: 500\n"
+	if err := os.WriteFile(filepath.Join(dir, "v1-chat-completions-2025-01-01T000000-abcd1234.log"), []byte(logData), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := gin.New()
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		capture := logging.ReplayCaptureFrom(c.Request.Context())
+		if capture == nil || c.GetHeader("Authorization") != "" {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		capture.SetUpstreamRequest([]byte("upstream " + string(body)))
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	h := &Handler{cfg: &config.Config{}, logDir: dir}
+	h.SetReplayHandler(engine)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/request-logs/abcd1234/replay", strings.NewReader(`{"model":"claude-sonnet-4"}`))
+	c.Params = gin.Params{{Key: "id", Value: "abcd1234"}}
+	h.ReplayRequestLog(c)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Status          int    `json:"status"`
+		Model           string `json:"model"`
+		UpstreamRequest string `json:"upstream-request"`
+		Response        string `json:"response"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if result.Status != http.StatusOK || result.Model != "claude-sonnet-4" || result.Response != `{"ok":true}` {
+		t.Fatalf("unexpected replay result: %+v", result)
+	}
+	if result.UpstreamRequest != `upstream {"model":"claude-sonnet-4","messages":[]}` {
+		t.Fatalf("unexpected upstream request: %q", result.UpstreamRequest)
+	}
+}
+
+func TestOverrideReplayModelGeminiPath(t *testing.T) {
+	_, path := overrideReplayModel([]byte(`{"contents":[]}`), "/v1beta/models/gemini-2.5-pro:streamGenerateContent", "gemini-2.5-flash")
+	if path != "/v1beta/models/gemini-2.5-flash:streamGenerateContent" {
+		t.Fatalf("path = %q", path)
+	}
+}
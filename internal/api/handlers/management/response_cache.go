@@ -0,0 +1,27 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+)
+
+// GetResponseCache returns the response cache counters, hit rate and entry count.
+// Entries is -1 for stores that cannot count their entries.
+func (h *Handler) GetResponseCache(c *gin.Context) {
+	store := "memory"
+	if h.cfg != nil && h.cfg.ResponseCache.Type != "" {
+		store = h.cfg.ResponseCache.Type
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"store": store,
+		"stats": cache.GetResponseCacheStats(),
+	})
+}
+
+// DeleteResponseCache purges every cached response.
+func (h *Handler) DeleteResponseCache(c *gin.Context) {
+	cache.ClearResponseCache()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,38 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+)
+
+// GetThinkingCache returns the thinking signature cache counters and per model group entry
+// counts with memory estimates. Groups are omitted for stores that cannot enumerate entries.
+func (h *Handler) GetThinkingCache(c *gin.Context) {
+	store := "memory"
+	if h.cfg != nil && h.cfg.SignatureCacheStore.Type != "" {
+		store = h.cfg.SignatureCacheStore.Type
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"store":   store,
+		"enabled": cache.SignatureCacheEnabled(),
+		"stats":   cache.GetSignatureCacheStats(),
+	})
+}
+
+// DeleteThinkingCache purges cached signatures. ?key=<group>:<hash> removes one entry,
+// ?model=<name> removes the entries of the model's group, and no parameter purges everything.
+func (h *Handler) DeleteThinkingCache(c *gin.Context) {
+	if key := strings.TrimSpace(c.Query("key")); key != "" {
+		if !cache.DeleteSignatureCacheEntry(key) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "entry not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		return
+	}
+	cache.ClearSignatureCache(strings.TrimSpace(c.Query("model")))
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,20 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTokenRefresh returns the token expiry and refresh failures of every OAuth credential,
+// together with the refresh outcomes counted since startup.
+func (h *Handler) GetTokenRefresh(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token-refresh": h.authManager.TokenRefreshStatuses(),
+		"counters":      h.authManager.TokenRefreshCounters(),
+	})
+}
@@ -0,0 +1,110 @@
+package management
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/traffic"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// trafficAccount is the live rate-limit view of one credential.
+type trafficAccount struct {
+	AuthID        string                `json:"auth_id"`
+	AuthIndex     string                `json:"auth_index,omitempty"`
+	Provider      string                `json:"provider"`
+	Label         string                `json:"label,omitempty"`
+	Status        coreauth.Status       `json:"status"`
+	Disabled      bool                  `json:"disabled"`
+	RateLimited   bool                  `json:"rate_limited"`
+	Reason        string                `json:"reason,omitempty"`
+	RetryAt       time.Time             `json:"retry_at,omitzero"`
+	BackoffLevel  int                   `json:"backoff_level,omitempty"`
+	LimitedModels []trafficLimitedModel `json:"limited_models,omitempty"`
+}
+
+// trafficLimitedModel is a model that is cooling down on one credential.
+type trafficLimitedModel struct {
+	Model   string    `json:"model"`
+	Reason  string    `json:"reason,omitempty"`
+	RetryAt time.Time `json:"retry_at,omitzero"`
+}
+
+// GetTraffic returns the live traffic counters: requests in flight, active streams, totals and
+// the rolling request rate over the last 10 and 60 seconds.
+func (h *Handler) GetTraffic(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"traffic": traffic.GetTracker().Snapshot()})
+}
+
+// GetTrafficErrors returns the most recent failed client requests, newest first.
+func (h *Handler) GetTrafficErrors(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"errors": traffic.GetTracker().RecentErrors()})
+}
+
+// GetTrafficAccounts returns the rate-limit state of every credential. Pass ?limited=true to
+// list only credentials or models that are currently cooling down.
+func (h *Handler) GetTrafficAccounts(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	onlyLimited := c.Query("limited") == "true"
+	now := time.Now()
+	auths := h.authManager.List()
+	accounts := make([]trafficAccount, 0, len(auths))
+	for _, auth := range auths {
+		account := trafficAccountFor(auth, now)
+		if onlyLimited && !account.RateLimited && len(account.LimitedModels) == 0 {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Provider != accounts[j].Provider {
+			return accounts[i].Provider < accounts[j].Provider
+		}
+		return accounts[i].AuthID < accounts[j].AuthID
+	})
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+func trafficAccountFor(auth *coreauth.Auth, now time.Time) trafficAccount {
+	account := trafficAccount{
+		AuthID:    auth.ID,
+		AuthIndex: auth.Index,
+		Provider:  auth.Provider,
+		Label:     auth.Label,
+		Status:    auth.Status,
+		Disabled:  auth.Disabled,
+	}
+	if auth.Quota.Exceeded && auth.Quota.NextRecoverAt.After(now) {
+		account.RateLimited = true
+		account.Reason = auth.Quota.Reason
+		account.RetryAt = auth.Quota.NextRecoverAt
+		account.BackoffLevel = auth.Quota.BackoffLevel
+	} else if auth.Unavailable && auth.NextRetryAfter.After(now) {
+		account.RateLimited = true
+		account.Reason = auth.StatusMessage
+		account.RetryAt = auth.NextRetryAfter
+	}
+	for model, state := range auth.ModelStates {
+		if state == nil || !state.Unavailable || !state.NextRetryAfter.After(now) {
+			continue
+		}
+		reason := state.StatusMessage
+		if state.Quota.Exceeded && state.Quota.Reason != "" {
+			reason = state.Quota.Reason
+		}
+		account.LimitedModels = append(account.LimitedModels, trafficLimitedModel{
+			Model:   model,
+			Reason:  reason,
+			RetryAt: state.NextRetryAfter,
+		})
+	}
+	sort.Slice(account.LimitedModels, func(i, j int) bool {
+		return account.LimitedModels[i].Model < account.LimitedModels[j].Model
+	})
+	return account
+}
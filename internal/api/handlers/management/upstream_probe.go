@@ -0,0 +1,17 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUpstreamProbe returns the last upstream probe result of every probed credential.
+func (h *Handler) GetUpstreamProbe(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	enabled := h.cfg != nil && h.cfg.UpstreamProbe.Enabled
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "upstream-probe": h.authManager.UpstreamProbeStatuses()})
+}
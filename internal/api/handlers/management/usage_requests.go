@@ -0,0 +1,132 @@
+package management
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagereport"
+)
+
+// GetUsageRequests returns persisted per-request usage rows, newest first.
+//
+// Query parameters:
+//   - from, to: RFC3339 timestamps or YYYY-MM-DD dates (UTC); to is exclusive, a bare date
+//     in to includes that whole day
+//   - api-key, model: exact match filters
+//   - page (1-based, default 1), page-size (default 100, max 1000)
+func (h *Handler) GetUsageRequests(c *gin.Context) {
+	store := usage.GetStore()
+	if store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage store disabled; set usage-store.type"})
+		return
+	}
+
+	var q usage.Query
+	var errParse error
+	if q.From, errParse = parseUsageTime(c.Query("from"), false); errParse != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	if q.To, errParse = parseUsageTime(c.Query("to"), true); errParse != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+	q.APIKey = c.Query("api-key")
+	q.Model = c.Query("model")
+
+	page := 1
+	if raw := strings.TrimSpace(c.Query("page")); raw != "" {
+		if page, errParse = strconv.Atoi(raw); errParse != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+			return
+		}
+	}
+	if raw := strings.TrimSpace(c.Query("page-size")); raw != "" {
+		if q.Limit, errParse = strconv.Atoi(raw); errParse != nil || q.Limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page-size"})
+			return
+		}
+	}
+	q = q.Normalize()
+	q.Offset = (page - 1) * q.Limit
+
+	result, errQuery := store.Query(q)
+	if errQuery != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errQuery.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rows":      result.Rows,
+		"total":     result.Total,
+		"page":      page,
+		"page-size": q.Limit,
+	})
+}
+
+// GetUsageSpend returns the spend recorded per API key and day, with totals.
+//
+// Query parameters:
+//   - from, to: inclusive YYYY-MM-DD dates
+//   - api-key: exact match filter
+func (h *Handler) GetUsageSpend(c *gin.Context) {
+	from := strings.TrimSpace(c.Query("from"))
+	to := strings.TrimSpace(c.Query("to"))
+	for name, value := range map[string]string{"from": from, "to": to} {
+		if value == "" {
+			continue
+		}
+		if _, errParse := time.Parse(time.DateOnly, value); errParse != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return
+		}
+	}
+	report := usage.GetRequestStatistics().SpendReport(from, to, strings.TrimSpace(c.Query("api-key")))
+	c.JSON(http.StatusOK, gin.H{"spend": report})
+}
+
+// GetUsageReport previews the daily usage report of the date query parameter (YYYY-MM-DD,
+// default today) as data and rendered text.
+func (h *Handler) GetUsageReport(c *gin.Context) {
+	day := strings.TrimSpace(c.Query("date"))
+	if day == "" {
+		now := time.Now().UTC()
+		if strings.EqualFold(h.cfg.UsageStatisticsDailyReset, usage.DailyResetLocal) {
+			now = time.Now().Local()
+		}
+		day = now.Format(time.DateOnly)
+	} else if _, errParse := time.Parse(time.DateOnly, day); errParse != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+		return
+	}
+	report := usage.GetRequestStatistics().DailyReport(day, h.cfg.UsageReport.TopN)
+	text, errRender := usagereport.Render(h.cfg.UsageReport.Template, report)
+	if errRender != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errRender.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"report": report, "text": text})
+}
+
+// parseUsageTime parses an RFC3339 timestamp or a YYYY-MM-DD date. With endOfDay, a bare
+// date resolves to the start of the following day so the whole day is included.
+func parseUsageTime(raw string, endOfDay bool) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, errParse := time.Parse(time.RFC3339, raw); errParse == nil {
+		return t, nil
+	}
+	day, errParse := time.Parse(time.DateOnly, raw)
+	if errParse != nil {
+		return time.Time{}, errParse
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
@@ -0,0 +1,127 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the client IP allow/deny list middleware.
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// IPAccessRules are the parsed IP access lists enforced by IPAccessMiddleware.
+type IPAccessRules struct {
+	Allow          []netip.Prefix
+	Deny           []netip.Prefix
+	TrustedProxies []netip.Prefix
+}
+
+var ipAccessRules atomic.Pointer[IPAccessRules]
+
+// SetIPAccessRules installs the rules used by IPAccessMiddleware. nil or empty rules allow every client.
+func SetIPAccessRules(rules *IPAccessRules) {
+	if rules != nil && len(rules.Allow) == 0 && len(rules.Deny) == 0 {
+		rules = nil
+	}
+	ipAccessRules.Store(rules)
+}
+
+// ParseIPAccessRules parses CIDR lists as normalized by the config sanitizer. Invalid entries are skipped.
+func ParseIPAccessRules(allow, deny, trustedProxies []string) *IPAccessRules {
+	return &IPAccessRules{
+		Allow:          parsePrefixes(allow),
+		Deny:           parsePrefixes(deny),
+		TrustedProxies: parsePrefixes(trustedProxies),
+	}
+}
+
+// IPAccessMiddleware rejects clients whose IP is denied, or not allowed when an allow list is set,
+// with an OpenAI-style 403.
+func IPAccessMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules := ipAccessRules.Load()
+		if rules == nil {
+			c.Next()
+			return
+		}
+		if rules.Allows(rules.ClientAddr(c.Request)) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Access from your IP address is not allowed.",
+				Type:    "invalid_request_error",
+				Code:    "ip_not_allowed",
+			},
+		})
+	}
+}
+
+// Allows reports whether addr passes the lists. Deny entries win; a non-empty allow list is exclusive.
+func (r *IPAccessRules) Allows(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return len(r.Allow) == 0
+	}
+	if containsAddr(r.Deny, addr) {
+		return false
+	}
+	return len(r.Allow) == 0 || containsAddr(r.Allow, addr)
+}
+
+// ClientAddr returns the client IP of req. X-Forwarded-For is only honored when the peer is a
+// trusted proxy, and is walked from the right, skipping further trusted proxies, so clients cannot
+// spoof their address by prepending entries.
+func (r *IPAccessRules) ClientAddr(req *http.Request) netip.Addr {
+	addr := remoteAddr(req.RemoteAddr)
+	if !addr.IsValid() || !containsAddr(r.TrustedProxies, addr) {
+		return addr
+	}
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, errParse := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if errParse != nil {
+			return addr
+		}
+		addr = hop.Unmap()
+		if !containsAddr(r.TrustedProxies, addr) {
+			return addr
+		}
+	}
+	return addr
+}
+
+func remoteAddr(remote string) netip.Addr {
+	if addrPort, errParse := netip.ParseAddrPort(remote); errParse == nil {
+		return addrPort.Addr().Unmap()
+	}
+	if addr, errParse := netip.ParseAddr(remote); errParse == nil {
+		return addr.Unmap()
+	}
+	return netip.Addr{}
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePrefixes(entries []string) []netip.Prefix {
+	if len(entries) == 0 {
+		return nil
+	}
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if prefix, errParse := netip.ParsePrefix(entry); errParse == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
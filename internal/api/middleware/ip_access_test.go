@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIPAccessMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetIPAccessRules(ParseIPAccessRules(
+		[]string{"10.0.0.0/8", "2001:db8::/32"},
+		[]string{"10.0.0.13/32"},
+		[]string{"192.168.1.1/32"},
+	))
+	t.Cleanup(func() { SetIPAccessRules(nil) })
+
+	engine := gin.New()
+	engine.Use(IPAccessMiddleware())
+	engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      int
+	}{
+		{"allowed", "10.1.2.3:5000", "", http.StatusOK},
+		{"allowed ipv6", "[2001:db8::1]:5000", "", http.StatusOK},
+		{"denied wins over allow", "10.0.0.13:5000", "", http.StatusForbidden},
+		{"not in allow list", "172.16.0.1:5000", "", http.StatusForbidden},
+		{"forwarded from untrusted peer ignored", "172.16.0.1:5000", "10.1.2.3", http.StatusForbidden},
+		{"forwarded from trusted proxy", "192.168.1.1:5000", "10.1.2.3", http.StatusOK},
+		{"spoofed leftmost entry ignored", "192.168.1.1:5000", "10.1.2.3, 172.16.0.1", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+			req.RemoteAddr = tc.remote
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetIPAccessRulesDisablesEmptyLists(t *testing.T) {
+	SetIPAccessRules(ParseIPAccessRules(nil, nil, []string{"192.168.1.1/32"}))
+	t.Cleanup(func() { SetIPAccessRules(nil) })
+	if ipAccessRules.Load() != nil {
+		t.Fatal("expected rules without allow or deny entries to be disabled")
+	}
+}
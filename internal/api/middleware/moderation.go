@@ -0,0 +1,91 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the pre-flight content moderation middleware.
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/moderation"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+// moderationResultHeader reports flagged decisions to the client and in the request logs.
+const moderationResultHeader = "X-Moderation-Result"
+
+// ModerationMiddleware runs the user prompt of each request through the configured moderation
+// backends. Flagged requests are rejected with 400 or, with action "flag", let through with an
+// X-Moderation-Result response header. Decisions are counted in the usage statistics.
+func ModerationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings := moderation.Active()
+		if settings == nil || c.Request.Method != http.MethodPost || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		body, errRead := io.ReadAll(c.Request.Body)
+		if errRead != nil {
+			c.Next()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		text := moderation.PromptText(body)
+		if text == "" {
+			c.Next()
+			return
+		}
+
+		decision, errCheck := settings.Check(c.Request.Context(), text)
+		if errCheck != nil {
+			log.WithError(errCheck).WithField("path", c.Request.URL.Path).Warn("moderation check failed")
+			if settings.FailOpen {
+				c.Next()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: "Content moderation is unavailable.",
+					Type:    "server_error",
+					Code:    "moderation_unavailable",
+				},
+			})
+			return
+		}
+		if !decision.Flagged {
+			c.Next()
+			return
+		}
+
+		rejected := settings.Reject()
+		if usage.StatisticsEnabled() {
+			usage.GetRequestStatistics().RecordModeration(rejected)
+		}
+		result := "flagged; source=" + decision.Source
+		if len(decision.Categories) > 0 {
+			result += "; categories=" + strings.Join(decision.Categories, ",")
+		}
+		c.Header(moderationResultHeader, result)
+		log.WithFields(log.Fields{
+			"path":       c.Request.URL.Path,
+			"source":     decision.Source,
+			"categories": decision.Categories,
+			"rejected":   rejected,
+		}).Info("moderation flagged request")
+		if !rejected {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Your request was rejected by the content moderation policy.",
+				Type:    "invalid_request_error",
+				Code:    "content_policy_violation",
+			},
+		})
+	}
+}
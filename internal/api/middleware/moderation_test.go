@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/moderation"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestModerationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	usage.SetStatisticsEnabled(true)
+	t.Cleanup(func() {
+		moderation.SetSettings(nil)
+		usage.SetStatisticsEnabled(false)
+	})
+
+	var gotBody string
+	engine := gin.New()
+	engine.Use(ModerationMiddleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		raw, _ := c.GetRawData()
+		gotBody = string(raw)
+		c.Status(http.StatusOK)
+	})
+	serve := func(prompt string) *httptest.ResponseRecorder {
+		body := `{"messages":[{"role":"user","content":"` + prompt + `"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	moderation.SetSettings(&moderation.Settings{Keywords: []string{"forbidden"}})
+	before := usage.GetRequestStatistics().Snapshot().Current
+
+	rec := serve("this is forbidden")
+	if rec.Code != http.StatusBadRequest || gjson.Get(rec.Body.String(), "error.code").String() != "content_policy_violation" {
+		t.Fatalf("status = %d body = %s, want 400 content_policy_violation", rec.Code, rec.Body.String())
+	}
+	if rec = serve("allowed"); rec.Code != http.StatusOK || !strings.Contains(gotBody, "allowed") {
+		t.Fatalf("status = %d body seen = %q, want 200 with body restored", rec.Code, gotBody)
+	}
+
+	moderation.SetSettings(&moderation.Settings{Action: moderation.ActionFlag, Keywords: []string{"forbidden"}})
+	rec = serve("forbidden again")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get(moderationResultHeader), "flagged; source=keyword") {
+		t.Fatalf("status = %d header = %q, want 200 with flag header", rec.Code, rec.Header().Get(moderationResultHeader))
+	}
+
+	after := usage.GetRequestStatistics().Snapshot().Current
+	if after.ModerationFlagged-before.ModerationFlagged != 2 || after.ModerationRejected-before.ModerationRejected != 1 {
+		t.Fatalf("moderation counters delta = %d/%d, want 2 flagged, 1 rejected", after.ModerationFlagged-before.ModerationFlagged, after.ModerationRejected-before.ModerationRejected)
+	}
+}
@@ -0,0 +1,93 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the PII scrubbing middleware.
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pii"
+	log "github.com/sirupsen/logrus"
+)
+
+// piiScrubbedHeader reports how many distinct values were replaced with tokens.
+const piiScrubbedHeader = "X-PII-Scrubbed"
+
+// PIIScrubberMiddleware replaces personal data in the prompt of each request with tokens before
+// the request is handled, and restores the original values in the response body, streamed or
+// not. Requests without personal data pass through untouched.
+func PIIScrubberMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings := pii.Active()
+		if settings == nil || c.Request.Method != http.MethodPost || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		body, errRead := io.ReadAll(c.Request.Body)
+		if errRead != nil {
+			c.Next()
+			return
+		}
+		scrubbed, vault := settings.Scrub(body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(scrubbed))
+		c.Request.ContentLength = int64(len(scrubbed))
+		if vault.Len() == 0 {
+			c.Next()
+			return
+		}
+		log.WithField("path", c.Request.URL.Path).Debugf("pii scrubber replaced %d value(s)", vault.Len())
+		c.Header(piiScrubbedHeader, strconv.Itoa(vault.Len()))
+		writer := &piiRestoreWriter{ResponseWriter: c.Writer, vault: vault}
+		c.Writer = writer
+		c.Next()
+		if writer.restorer == nil {
+			return
+		}
+		if rest := writer.restorer.Flush(); len(rest) > 0 {
+			_, _ = writer.ResponseWriter.Write(rest)
+		}
+	}
+}
+
+// piiRestorer restores tokens in a response body written in chunks.
+type piiRestorer interface {
+	Write(chunk []byte) []byte
+	Flush() []byte
+}
+
+// piiRestoreWriter restores scrubbed values in the response body. Event streams are restored
+// event by event so tokens split across events are rejoined; other bodies byte-wise. The body
+// length changes, so any Content-Length set by the handler is dropped.
+type piiRestoreWriter struct {
+	gin.ResponseWriter
+	vault    *pii.Vault
+	restorer piiRestorer
+}
+
+func (w *piiRestoreWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *piiRestoreWriter) Write(data []byte) (int, error) {
+	w.Header().Del("Content-Length")
+	if w.restorer == nil {
+		if strings.Contains(w.Header().Get("Content-Type"), "text/event-stream") {
+			w.restorer = pii.NewStreamRestorer(w.vault)
+		} else {
+			w.restorer = pii.NewRestorer(w.vault)
+		}
+	}
+	if _, errWrite := w.ResponseWriter.Write(w.restorer.Write(data)); errWrite != nil {
+		return 0, errWrite
+	}
+	return len(data), nil
+}
+
+func (w *piiRestoreWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
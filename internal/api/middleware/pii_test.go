@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pii"
+)
+
+func TestPIIScrubberMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pii.SetSettings(pii.NewSettings(nil, nil))
+	t.Cleanup(func() { pii.SetSettings(nil) })
+
+	var upstream string
+	engine := gin.New()
+	engine.Use(PIIScrubberMiddleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		raw, _ := c.GetRawData()
+		upstream = string(raw)
+		c.Header("Content-Length", "999")
+		c.Status(http.StatusOK)
+		// Echo the token split across two writes, as a stream would.
+		_, _ = c.Writer.WriteString(`data: {"content":"Mail [PII_EM`)
+		c.Writer.Flush()
+		_, _ = c.Writer.WriteString(`AIL_1] now"}` + "\n\n")
+	})
+
+	body := `{"model":"m","messages":[{"role":"user","content":"I am jane.doe@example.com"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	if strings.Contains(upstream, "jane.doe@example.com") || !strings.Contains(upstream, "[PII_EMAIL_1]") {
+		t.Fatalf("upstream body = %s, want the email tokenized", upstream)
+	}
+	if got := rec.Body.String(); got != `data: {"content":"Mail jane.doe@example.com now"}`+"\n\n" {
+		t.Fatalf("response = %q, want the email restored", got)
+	}
+	if rec.Header().Get(piiScrubbedHeader) != "1" {
+		t.Fatalf("%s = %q, want 1", piiScrubbedHeader, rec.Header().Get(piiScrubbedHeader))
+	}
+}
+
+func TestPIIScrubberMiddlewareRestoresTokenAcrossEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pii.SetSettings(pii.NewSettings(nil, nil))
+	t.Cleanup(func() { pii.SetSettings(nil) })
+
+	engine := gin.New()
+	engine.Use(PIIScrubberMiddleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		// Upstreams split the token across separate delta events.
+		_, _ = c.Writer.WriteString(`data: {"choices":[{"index":0,"delta":{"content":"Mail [PII_EM"}}]}` + "\n\n")
+		c.Writer.Flush()
+		_, _ = c.Writer.WriteString(`data: {"choices":[{"index":0,"delta":{"content":"AIL_1] now"}}]}` + "\n\n")
+		_, _ = c.Writer.WriteString("data: [DONE]\n\n")
+	})
+
+	body := `{"model":"m","stream":true,"messages":[{"role":"user","content":"I am jane.doe@example.com"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	got := rec.Body.String()
+	if strings.Contains(got, "PII_") || !strings.Contains(got, `"content":"jane.doe@example.com now"`) {
+		t.Fatalf("response = %q, want the email restored across events", got)
+	}
+}
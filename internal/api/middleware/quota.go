@@ -0,0 +1,67 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the per-API-key quota enforcement middleware.
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// QuotaMiddleware rejects requests from client API keys whose quota is exhausted with an
+// OpenAI-style 429 error. It must run after authentication so the API key is known.
+// Metadata requests (plain GET, e.g. model listings) are never rejected.
+func QuotaMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet && !isWebsocketUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+		stats := usage.GetRequestStatistics()
+		if !stats.QuotaEnabled() {
+			c.Next()
+			return
+		}
+		apiKey := quotaAPIKey(c)
+		if apiKey == "" {
+			c.Next()
+			return
+		}
+		status, allowed := stats.CheckQuota(apiKey)
+		if allowed {
+			c.Next()
+			return
+		}
+		if !status.ResetAt.IsZero() {
+			retryAfter := int64(math.Ceil(time.Until(status.ResetAt).Seconds()))
+			c.Header("Retry-After", strconv.FormatInt(max(retryAfter, 1), 10))
+		}
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("You exceeded your current quota: %s.", status.ExceededMessage()),
+				Type:    "insufficient_quota",
+				Code:    "insufficient_quota",
+			},
+		})
+	}
+}
+
+func quotaAPIKey(c *gin.Context) string {
+	value, exists := c.Get("apiKey")
+	if !exists {
+		return ""
+	}
+	apiKey, _ := value.(string)
+	return strings.TrimSpace(apiKey)
+}
+
+func isWebsocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get("Upgrade")), "websocket")
+}
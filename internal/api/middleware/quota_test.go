@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestQuotaMiddlewareRejectsExhaustedKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stats := usage.GetRequestStatistics()
+	stats.SetQuotas(usage.QuotaSettings{Keys: map[string]usage.QuotaLimits{"limited": {DailyRequests: 1}}})
+	t.Cleanup(func() {
+		stats.SetQuotas(usage.QuotaSettings{})
+		stats.ResetQuota("")
+	})
+	stats.RecordQuota(coreusage.Record{APIKey: "limited"})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("apiKey", c.GetHeader("X-Test-Key"))
+		c.Next()
+	}, QuotaMiddleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(method, key string) *httptest.ResponseRecorder {
+		path := "/v1/chat/completions"
+		if method == http.MethodGet {
+			path = "/v1/models"
+		}
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Test-Key", key)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "limited")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := gjson.Get(rec.Body.String(), "error.type").String(); got != "insufficient_quota" {
+		t.Fatalf("error.type = %q, want insufficient_quota; body=%s", got, rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After header")
+	}
+	if rec = serve(http.MethodGet, "limited"); rec.Code != http.StatusOK {
+		t.Fatalf("model listing status = %d, want 200", rec.Code)
+	}
+	if rec = serve(http.MethodPost, "unlimited"); rec.Code != http.StatusOK {
+		t.Fatalf("unlimited key status = %d, want 200", rec.Code)
+	}
+}
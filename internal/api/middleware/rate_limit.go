@@ -0,0 +1,105 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the request and token rate limit middleware.
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/apikeys"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRateLimitBodyBytes bounds how much of a request body is read to estimate its tokens;
+// larger bodies exceed any token bucket anyway, so the rest is passed on unread.
+const maxRateLimitBodyBytes = 4 << 20
+
+// RateLimitMiddleware throttles clients with the process-wide limiter: the global rate-limit
+// settings, identified by API key and/or client IP, and the requests-per-minute of proxy keys.
+// Limited requests are rejected with an OpenAI-style 429 and Retry-After; every counted response
+// carries x-ratelimit-* headers. It must run after authentication so the API key is known.
+// Metadata requests (plain GET, e.g. model listings) are not counted, and requests pass when the
+// bucket store fails.
+func RateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet && !isWebsocketUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+		limiter := apikeys.GetLimiter()
+		key, limits := limiter.Resolve(quotaAPIKey(c), c.ClientIP())
+		if limits.RequestsPerMinute <= 0 && limits.TokensPerMinute <= 0 {
+			c.Next()
+			return
+		}
+		var tokens int64
+		if limits.TokensPerMinute > 0 && c.Request.Body != nil {
+			tokens = estimateRequestTokens(c.Request)
+		}
+		decision, errTake := limiter.Take(key, limits, tokens)
+		if errTake != nil {
+			log.WithError(errTake).Warn("rate limit check failed; allowing request")
+			c.Next()
+			return
+		}
+		setRateLimitHeaders(c, limits, decision)
+		if decision.Allowed {
+			c.Next()
+			return
+		}
+		seconds := max(int64(math.Ceil(decision.RetryAfter.Seconds())), 1)
+		c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Rate limit reached, retry in %ds.", seconds),
+				Type:    "requests",
+				Code:    "rate_limit_exceeded",
+			},
+		})
+	}
+}
+
+// estimateRequestTokens estimates the tokens of the request from at most maxRateLimitBodyBytes
+// of its body and leaves the body readable in full for the handlers.
+func estimateRequestTokens(req *http.Request) int64 {
+	head, errRead := io.ReadAll(io.LimitReader(req.Body, maxRateLimitBodyBytes))
+	req.Body = &rateLimitBody{Reader: io.MultiReader(bytes.NewReader(head), req.Body), Closer: req.Body}
+	if errRead != nil {
+		return 0
+	}
+	return apikeys.EstimateTokens(head)
+}
+
+// rateLimitBody replays the bytes read for token estimation before the unread rest of the body.
+type rateLimitBody struct {
+	io.Reader
+	io.Closer
+}
+
+func setRateLimitHeaders(c *gin.Context, limits apikeys.Limits, decision apikeys.Decision) {
+	if limits.RequestsPerMinute > 0 {
+		c.Header("x-ratelimit-limit-requests", strconv.FormatInt(limits.RequestsPerMinute, 10))
+		c.Header("x-ratelimit-remaining-requests", strconv.FormatInt(decision.RemainingRequests, 10))
+		c.Header("x-ratelimit-reset-requests", formatRateLimitReset(decision.ResetRequests))
+	}
+	if limits.TokensPerMinute > 0 {
+		c.Header("x-ratelimit-limit-tokens", strconv.FormatInt(limits.TokensPerMinute, 10))
+		c.Header("x-ratelimit-remaining-tokens", strconv.FormatInt(decision.RemainingTokens, 10))
+		c.Header("x-ratelimit-reset-tokens", formatRateLimitReset(decision.ResetTokens))
+	}
+}
+
+// formatRateLimitReset formats a reset delay like OpenAI does, e.g. "1s", "6m0s" or "120ms".
+func formatRateLimitReset(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/apikeys"
+	"github.com/tidwall/gjson"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := apikeys.GetLimiter()
+	limiter.SetDefaults("api-key", apikeys.Limits{RequestsPerMinute: 1, TokensPerMinute: 1000}, nil)
+	t.Cleanup(func() { limiter.SetDefaults("api-key", apikeys.Limits{}, nil) })
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set("apiKey", key)
+		}
+		c.Next()
+	}, RateLimitMiddleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/v1/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"model":"m","max_tokens":100}`))
+		req.Header.Set("X-Test-Key", key)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/v1/chat/completions", "a")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d", rec.Code)
+	}
+	if rec.Header().Get("x-ratelimit-remaining-requests") != "0" || rec.Header().Get("x-ratelimit-remaining-tokens") != "892" {
+		t.Fatalf("unexpected rate limit headers: %v", rec.Header())
+	}
+
+	rec = serve(http.MethodPost, "/v1/chat/completions", "a")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("second request status = %d, Retry-After = %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if got := gjson.Get(rec.Body.String(), "error.code").String(); got != "rate_limit_exceeded" {
+		t.Fatalf("error.code = %q; body=%s", got, rec.Body.String())
+	}
+
+	if rec = serve(http.MethodPost, "/v1/chat/completions", "b"); rec.Code != http.StatusOK {
+		t.Fatalf("other key status = %d", rec.Code)
+	}
+	if rec = serve(http.MethodGet, "/v1/models", "a"); rec.Code != http.StatusOK {
+		t.Fatalf("model listing status = %d", rec.Code)
+	}
+}
+
+func TestRateLimitMiddlewareProxyKeyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := apikeys.GetLimiter()
+	limiter.SetDefaults("api-key", apikeys.Limits{TokensPerMinute: 1 << 30}, nil)
+	limiter.SetLimits(map[string]int{"proxy-key": 1})
+	t.Cleanup(func() {
+		limiter.SetDefaults("api-key", apikeys.Limits{}, nil)
+		limiter.SetLimits(nil)
+	})
+
+	var received int
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("apiKey", "proxy-key")
+		c.Next()
+	}, RateLimitMiddleware())
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = len(body)
+		c.Status(http.StatusOK)
+	})
+
+	// Bodies larger than the estimation cap still reach the handler intact.
+	large := `{"model":"m","messages":"` + strings.Repeat("x", maxRateLimitBodyBytes) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(large))
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || received != len(large) {
+		t.Fatalf("first request status = %d, handler read %d of %d bytes", rec.Code, received, len(large))
+	}
+	if rec.Header().Get("x-ratelimit-limit-requests") != "1" {
+		t.Fatalf("expected the proxy key request limit in headers: %v", rec.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m"}`))
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", rec.Code)
+	}
+}
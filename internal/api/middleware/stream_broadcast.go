@@ -0,0 +1,82 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the stream broadcast middleware.
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/broadcast"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// StreamIDHeader tags a request whose response is broadcast to GET /v1/streams/{id} subscribers.
+const StreamIDHeader = "X-Stream-ID"
+
+var streamIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// StreamBroadcastMiddleware publishes the response of POST requests carrying X-Stream-ID to
+// the broadcast hub for the lifetime of the request. Stream IDs are scoped to the API key, and
+// only clients using the same key can join the stream. It must run after authentication so the API key is known.
+func StreamBroadcastMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.GetHeader(StreamIDHeader))
+		if id == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+		if !streamIDPattern.MatchString(id) {
+			abortStreamBroadcast(c, http.StatusBadRequest, "invalid_stream_id", "Stream IDs must be 1-128 letters, digits, '.', '_', ':' or '-'.")
+			return
+		}
+		stream, errOpen := broadcast.GetHub().Open(id, quotaAPIKey(c))
+		if errors.Is(errOpen, broadcast.ErrStreamExists) {
+			abortStreamBroadcast(c, http.StatusConflict, "stream_id_in_use", "Another request with this API key is already streaming with this stream ID.")
+			return
+		}
+		defer stream.Close()
+		c.Writer = &broadcastWriter{ResponseWriter: c.Writer, stream: stream}
+		c.Header(StreamIDHeader, id)
+		c.Next()
+	}
+}
+
+func abortStreamBroadcast(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+			Code:    code,
+		},
+	})
+}
+
+// broadcastWriter copies the response body to a broadcast stream.
+type broadcastWriter struct {
+	gin.ResponseWriter
+	stream        *broadcast.Stream
+	announcedType bool
+}
+
+func (w *broadcastWriter) Write(data []byte) (int, error) {
+	n, errWrite := w.ResponseWriter.Write(data)
+	w.publish(data[:n])
+	return n, errWrite
+}
+
+func (w *broadcastWriter) WriteString(data string) (int, error) {
+	n, errWrite := w.ResponseWriter.WriteString(data)
+	w.publish([]byte(data[:n]))
+	return n, errWrite
+}
+
+func (w *broadcastWriter) publish(data []byte) {
+	if !w.announcedType {
+		w.announcedType = true
+		w.stream.SetContentType(w.Header().Get("Content-Type"))
+	}
+	w.stream.Write(data)
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/broadcast"
+	"github.com/tidwall/gjson"
+)
+
+func TestStreamBroadcastMiddlewarePublishesResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("apiKey", "client-key")
+		c.Next()
+	}, StreamBroadcastMiddleware())
+
+	serve := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		req.Header.Set(StreamIDHeader, id)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	other, errOther := broadcast.GetHub().Open("run-1", "other-key")
+	if errOther != nil {
+		t.Fatalf("Open() by another key error = %v", errOther)
+	}
+	defer other.Close()
+
+	var sub *broadcast.Subscription
+	var conflict *httptest.ResponseRecorder
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		_, _ = c.Writer.WriteString("data: 1\n\n")
+		var errSub error
+		if sub, errSub = broadcast.GetHub().Subscribe("run-1", "client-key"); errSub != nil {
+			t.Errorf("Subscribe() error = %v", errSub)
+			return
+		}
+		conflict = serve("run-1")
+		_, _ = c.Writer.Write([]byte("data: 2\n\n"))
+	})
+
+	rec := serve("run-1")
+	if rec.Body.String() != "data: 1\n\ndata: 2\n\n" || rec.Header().Get(StreamIDHeader) != "run-1" {
+		t.Fatalf("publisher response = %q, headers = %v", rec.Body.String(), rec.Header())
+	}
+	if sub == nil {
+		t.Fatal("expected subscription")
+	}
+	defer sub.Close()
+	if sub.ContentType != "text/event-stream" || string(sub.History) != "data: 1\n\n" {
+		t.Fatalf("subscription = %q/%q", sub.ContentType, sub.History)
+	}
+	if chunk := <-sub.C; string(chunk) != "data: 2\n\n" {
+		t.Fatalf("live chunk = %q", chunk)
+	}
+	if _, ok := <-sub.C; ok {
+		t.Fatal("expected stream to end with the request")
+	}
+	if conflict.Code != http.StatusConflict {
+		t.Fatalf("duplicate stream status = %d, want 409", conflict.Code)
+	}
+
+	rec = serve("bad id")
+	if rec.Code != http.StatusBadRequest || gjson.Get(rec.Body.String(), "error.code").String() != "invalid_stream_id" {
+		t.Fatalf("invalid id response = %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,28 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the live traffic tracking middleware.
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/traffic"
+)
+
+// TrafficMiddleware counts client API requests in the live traffic tracker and records failed
+// ones as recent errors.
+func TrafficMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tracker := traffic.GetTracker()
+		start := time.Now()
+		tracker.BeginRequest()
+		defer func() {
+			message := c.GetString(traffic.ErrorMessageKey)
+			if message == "" && len(c.Errors) > 0 {
+				message = c.Errors.Last().Error()
+			}
+			tracker.EndRequest(c.Request.Method, c.Request.URL.Path, c.Writer.Status(), message, time.Since(start))
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/traffic"
+)
+
+func TestTrafficMiddlewareRecordsErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker := traffic.GetTracker()
+	before := tracker.Snapshot()
+
+	engine := gin.New()
+	engine.Use(TrafficMiddleware())
+	engine.POST("/v1/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.POST("/v1/fail", func(c *gin.Context) {
+		c.Set(traffic.ErrorMessageKey, "upstream overloaded")
+		c.Status(http.StatusServiceUnavailable)
+	})
+	for _, path := range []string{"/v1/ok", "/v1/fail"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	after := tracker.Snapshot()
+	if after.TotalRequests-before.TotalRequests != 2 || after.TotalErrors-before.TotalErrors != 1 || after.InFlight != before.InFlight {
+		t.Fatalf("before=%+v after=%+v", before, after)
+	}
+	latest := tracker.RecentErrors()[0]
+	if latest.Path != "/v1/fail" || latest.StatusCode != http.StatusServiceUnavailable || latest.Message != "upstream overloaded" {
+		t.Fatalf("latest error = %+v", latest)
+	}
+}
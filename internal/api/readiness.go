@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+)
+
+// readinessCheck is the outcome of one /readyz check.
+type readinessCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// readyzHandler reports whether the server can serve traffic: the configuration is loaded, at
+// least one credential is usable and the cache backends are reachable. Unlike /healthz it
+// returns 503 while any check fails or the server is draining.
+func (s *Server) readyzHandler(c *gin.Context) {
+	checks := map[string]readinessCheck{
+		"config":   {OK: s.cfg != nil},
+		"accounts": {},
+		"cache":    {OK: true},
+	}
+	if s.handlers != nil && s.handlers.AuthManager != nil {
+		ready := s.handlers.AuthManager.ReadyAuthCount(time.Now())
+		checks["accounts"] = readinessCheck{OK: ready > 0}
+		if ready == 0 {
+			checks["accounts"] = readinessCheck{Detail: "no usable upstream account"}
+		}
+	} else {
+		checks["accounts"] = readinessCheck{Detail: "core auth manager unavailable"}
+	}
+	if errPing := cache.PingBackends(); errPing != nil {
+		checks["cache"] = readinessCheck{Detail: errPing.Error()}
+	}
+
+	status := http.StatusOK
+	for _, check := range checks {
+		if !check.OK {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if c.Request.Method == http.MethodHead {
+		c.Status(status)
+		return
+	}
+	result := "ready"
+	if status != http.StatusOK {
+		result = "not ready"
+	}
+	c.JSON(status, gin.H{"status": result, "checks": checks})
+}
@@ -15,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,16 +27,29 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/middleware"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules"
 	ampmodule "github.com/router-for-me/CLIProxyAPI/v6/internal/api/modules/amp"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/apikeys"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/imagefetch"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/moderation"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pii"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redisclient"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/redisqueue"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/scheduler"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/transcription"
+	claudeopenai "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/openai/chat-completions"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagereport"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usagewebhook"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/mcp"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -186,6 +200,9 @@ type Server struct {
 	keepAliveOnTimeout func()
 	keepAliveHeartbeat chan struct{}
 	keepAliveStop      chan struct{}
+
+	// drain rejects new requests and counts in-flight ones during graceful shutdown.
+	drain *drainState
 }
 
 // NewServer creates and initializes a new API server instance.
@@ -219,6 +236,9 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Add middleware
 	engine.Use(logging.GinLogrusLogger())
 	engine.Use(logging.GinLogrusRecovery())
+	drain := &drainState{}
+	engine.Use(drain.middleware())
+	engine.Use(middleware.IPAccessMiddleware())
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
@@ -236,6 +256,9 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 			if setter, ok := requestLogger.(interface{ SetEnabled(bool) }); ok {
 				toggle = setter.SetEnabled
 			}
+			if setter, ok := requestLogger.(interface{ SetRedaction(config.RedactConfig) }); ok {
+				setter.SetRedaction(cfg.Redact)
+			}
 		}
 	}
 
@@ -261,6 +284,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		currentPath:         wd,
 		envManagementSecret: envManagementSecret,
 		wsRoutes:            make(map[string]struct{}),
+		drain:               drain,
 	}
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	// Save initial YAML snapshot
@@ -272,6 +296,22 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	applySignatureCacheConfig(nil, cfg)
+	applyResponseCacheConfig(nil, cfg)
+	applyRateLimitConfig(nil, cfg)
+	applyRequestSchedulerConfig(nil, cfg)
+	applyClaudeResponseConfig(cfg)
+	claudeopenai.SetDisabledRequestStages(cfg.ClaudeRequest.DisabledOpenAIStages)
+	applyThinkingDefaults(cfg)
+	applyAPIKeyQuotaConfig(cfg)
+	applyModerationConfig(cfg)
+	applyPIIScrubberConfig(cfg)
+	applyProxyKeyConfig(cfg)
+	applyIPAccessConfig(cfg)
+	applyAudioTranscriptionConfig(cfg)
+	applyImageFetchConfig(cfg)
+	applyUsageStore(cfg)
+	usagewebhook.Configure(cfg.UsageWebhooks)
+	usagereport.Configure(cfg)
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
 	if optionState.localPassword != "" {
@@ -282,6 +322,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	if optionState.postAuthHook != nil {
 		s.mgmt.SetPostAuthHook(optionState.postAuthHook)
 	}
+	s.mgmt.SetReplayHandler(engine)
 	s.localPassword = optionState.localPassword
 
 	// Setup routes
@@ -339,6 +380,8 @@ func (s *Server) setupRoutes() {
 	}
 	s.engine.GET("/healthz", healthzHandler)
 	s.engine.HEAD("/healthz", healthzHandler)
+	s.engine.GET("/readyz", s.readyzHandler)
+	s.engine.HEAD("/readyz", s.readyzHandler)
 
 	s.engine.GET("/management.html", s.serveManagementControlPanel)
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
@@ -346,26 +389,42 @@ func (s *Server) setupRoutes() {
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
 	openaiResponsesHandlers := openai.NewOpenAIResponsesAPIHandler(s.handlers)
+	mcpHandlers := mcp.NewMCPAPIHandler(s.handlers)
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(middleware.TrafficMiddleware(), AuthMiddleware(s.accessManager), middleware.RateLimitMiddleware(), middleware.QuotaMiddleware(), middleware.PIIScrubberMiddleware(), middleware.ModerationMiddleware(), middleware.StreamBroadcastMiddleware())
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
+		v1.GET("/models/*action", openaiHandlers.ModelCapabilitiesHandler)
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
+		v1.POST("/chat/completions/count_tokens", openaiHandlers.ChatCompletionsCountTokens)
 		v1.POST("/completions", openaiHandlers.Completions)
 		v1.POST("/images/generations", openaiHandlers.ImagesGenerations)
 		v1.POST("/images/edits", openaiHandlers.ImagesEdits)
+		v1.POST("/files", openaiHandlers.UploadFile)
+		v1.GET("/files/:id", openaiHandlers.GetFile)
+		v1.GET("/files/:id/content", openaiHandlers.GetFileContent)
+		v1.DELETE("/files/:id", openaiHandlers.DeleteFile)
+		v1.POST("/batches", openaiHandlers.CreateBatch)
+		v1.GET("/batches", openaiHandlers.ListBatches)
+		v1.GET("/batches/:id", openaiHandlers.GetBatch)
+		v1.POST("/batches/:id/cancel", openaiHandlers.CancelBatch)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/messages/batches", claudeCodeHandlers.ClaudeCreateBatch)
+		v1.GET("/messages/batches/:id", claudeCodeHandlers.ClaudeGetBatch)
+		v1.GET("/messages/batches/:id/results", claudeCodeHandlers.ClaudeBatchResults)
+		v1.POST("/messages/batches/:id/cancel", claudeCodeHandlers.ClaudeCancelBatch)
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.GET("/streams/:id", streamSubscribeHandler)
 	}
 
 	// Codex CLI direct route aliases (chatgpt_base_url compatible)
 	codexDirect := s.engine.Group("/backend-api/codex")
-	codexDirect.Use(AuthMiddleware(s.accessManager))
+	codexDirect.Use(middleware.TrafficMiddleware(), AuthMiddleware(s.accessManager), middleware.RateLimitMiddleware(), middleware.QuotaMiddleware(), middleware.PIIScrubberMiddleware(), middleware.ModerationMiddleware(), middleware.StreamBroadcastMiddleware())
 	{
 		codexDirect.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		codexDirect.POST("/responses", openaiResponsesHandlers.Responses)
@@ -374,13 +433,22 @@ func (s *Server) setupRoutes() {
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(middleware.TrafficMiddleware(), AuthMiddleware(s.accessManager), middleware.RateLimitMiddleware(), middleware.QuotaMiddleware(), middleware.PIIScrubberMiddleware(), middleware.ModerationMiddleware(), middleware.StreamBroadcastMiddleware())
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
 		v1beta.GET("/models/*action", geminiHandlers.GeminiGetHandler)
 	}
 
+	// Model Context Protocol endpoint (enabled by mcp-server)
+	mcpGroup := s.engine.Group("/mcp")
+	mcpGroup.Use(middleware.TrafficMiddleware(), AuthMiddleware(s.accessManager), middleware.RateLimitMiddleware(), middleware.QuotaMiddleware(), middleware.PIIScrubberMiddleware(), middleware.ModerationMiddleware())
+	{
+		mcpGroup.POST("", mcpHandlers.Handle)
+		mcpGroup.GET("", mcpHandlers.MethodNotAllowed)
+		mcpGroup.DELETE("", mcpHandlers.MethodNotAllowed)
+	}
+
 	// Root endpoint
 	s.engine.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -508,6 +576,7 @@ func (s *Server) registerManagementRoutes() {
 	{
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
+		mgmt.GET("/config/reload-status", s.mgmt.GetConfigReloadStatus)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
 
@@ -530,6 +599,10 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/usage-statistics-enabled", s.mgmt.GetUsageStatisticsEnabled)
 		mgmt.PUT("/usage-statistics-enabled", s.mgmt.PutUsageStatisticsEnabled)
 		mgmt.PATCH("/usage-statistics-enabled", s.mgmt.PutUsageStatisticsEnabled)
+		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
+		mgmt.GET("/usage/requests", s.mgmt.GetUsageRequests)
+		mgmt.GET("/usage/spend", s.mgmt.GetUsageSpend)
+		mgmt.GET("/usage/report", s.mgmt.GetUsageReport)
 
 		mgmt.GET("/proxy-url", s.mgmt.GetProxyURL)
 		mgmt.PUT("/proxy-url", s.mgmt.PutProxyURL)
@@ -550,7 +623,27 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
+		mgmt.GET("/proxy-keys", s.mgmt.GetProxyKeys)
+		mgmt.POST("/proxy-keys", s.mgmt.CreateProxyKey)
+		mgmt.PATCH("/proxy-keys/:id", s.mgmt.PatchProxyKey)
+		mgmt.POST("/proxy-keys/:id/rotate", s.mgmt.RotateProxyKey)
+		mgmt.POST("/proxy-keys/:id/revoke", s.mgmt.RevokeProxyKey)
+		mgmt.DELETE("/proxy-keys/:id", s.mgmt.DeleteProxyKey)
 		mgmt.GET("/api-key-usage", s.mgmt.GetAPIKeyUsage)
+		mgmt.GET("/api-key-quota", s.mgmt.GetAPIKeyQuota)
+		mgmt.DELETE("/api-key-quota", s.mgmt.ResetAPIKeyQuota)
+
+		mgmt.GET("/circuit-breakers", s.mgmt.GetCircuitBreakers)
+		mgmt.DELETE("/circuit-breakers", s.mgmt.ResetCircuitBreakers)
+		mgmt.GET("/upstream-probe", s.mgmt.GetUpstreamProbe)
+		mgmt.GET("/token-refresh", s.mgmt.GetTokenRefresh)
+		mgmt.GET("/cache/thinking", s.mgmt.GetThinkingCache)
+		mgmt.DELETE("/cache/thinking", s.mgmt.DeleteThinkingCache)
+		mgmt.GET("/cache/responses", s.mgmt.GetResponseCache)
+		mgmt.DELETE("/cache/responses", s.mgmt.DeleteResponseCache)
+		mgmt.GET("/traffic", s.mgmt.GetTraffic)
+		mgmt.GET("/traffic/accounts", s.mgmt.GetTrafficAccounts)
+		mgmt.GET("/traffic/errors", s.mgmt.GetTrafficErrors)
 
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
@@ -562,6 +655,9 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
 		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.GET("/request-logs", s.mgmt.ListRequestLogs)
+		mgmt.GET("/request-logs/:id", s.mgmt.GetRequestLogByID)
+		mgmt.POST("/request-logs/:id/replay", s.mgmt.ReplayRequestLog)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
@@ -641,6 +737,10 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
 		mgmt.GET("/model-definitions/:channel", s.mgmt.GetStaticModelDefinitions)
+		mgmt.GET("/model-overrides", s.mgmt.GetModelOverrides)
+		mgmt.PUT("/model-overrides", s.mgmt.PutModelOverrides)
+		mgmt.PATCH("/model-overrides", s.mgmt.PatchModelOverride)
+		mgmt.DELETE("/model-overrides", s.mgmt.DeleteModelOverride)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
@@ -833,6 +933,12 @@ func (s *Server) Start() error {
 			Certificates: []tls.Certificate{certPair},
 			NextProtos:   []string{"h2", "http/1.1"},
 		}
+		if errClientAuth := configureClientAuth(tlsConfig, s.cfg.TLS); errClientAuth != nil {
+			if errClose := listener.Close(); errClose != nil {
+				log.Errorf("failed to close listener after client CA load failure: %v", errClose)
+			}
+			return fmt.Errorf("failed to start HTTPS server: %v", errClientAuth)
+		}
 		s.server.TLSConfig = tlsConfig
 		if errHTTP2 := http2.ConfigureServer(s.server, &http2.Server{}); errHTTP2 != nil {
 			log.Warnf("failed to configure HTTP/2: %v", errHTTP2)
@@ -926,11 +1032,15 @@ func (s *Server) Stop(ctx context.Context) error {
 		}
 	}
 
-	// Shutdown the HTTP server.
+	// Shutdown the HTTP server, dropping connections that are still active when ctx ends.
 	if err := s.server.Shutdown(ctx); err != nil {
+		_ = s.server.Close()
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
 
+	// Flush and close the signature cache store so persisted entries survive the restart.
+	cache.SetStore(nil)
+
 	log.Debug("API server stopped")
 	return nil
 }
@@ -990,7 +1100,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
-	if oldCfg == nil || oldCfg.LoggingToFile != cfg.LoggingToFile || oldCfg.LogsMaxTotalSizeMB != cfg.LogsMaxTotalSizeMB {
+	if oldCfg == nil || oldCfg.LoggingToFile != cfg.LoggingToFile || oldCfg.LogsMaxTotalSizeMB != cfg.LogsMaxTotalSizeMB || oldCfg.LogRotation != cfg.LogRotation {
 		if err := logging.ConfigureLogOutput(cfg); err != nil {
 			log.Errorf("failed to reconfigure log output: %v", err)
 		}
@@ -998,6 +1108,23 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 
 	if oldCfg == nil || oldCfg.UsageStatisticsEnabled != cfg.UsageStatisticsEnabled {
 		redisqueue.SetUsageStatisticsEnabled(cfg.UsageStatisticsEnabled)
+		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
+	}
+
+	if oldCfg == nil || oldCfg.UsageStatisticsDailyReset != cfg.UsageStatisticsDailyReset {
+		usage.GetRequestStatistics().SetDailyReset(cfg.UsageStatisticsDailyReset)
+	}
+
+	if oldCfg == nil || oldCfg.UsageStore != cfg.UsageStore {
+		applyUsageStore(cfg)
+	}
+
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.UsageReport, cfg.UsageReport) || oldCfg.UsageStatisticsDailyReset != cfg.UsageStatisticsDailyReset {
+		usagereport.Configure(cfg)
+	}
+
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.UsageWebhooks, cfg.UsageWebhooks) {
+		usagewebhook.Configure(cfg.UsageWebhooks)
 	}
 
 	if oldCfg == nil || oldCfg.RedisUsageQueueRetentionSeconds != cfg.RedisUsageQueueRetentionSeconds {
@@ -1010,6 +1137,12 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if s.requestLogger != nil && (oldCfg == nil || !reflect.DeepEqual(oldCfg.Redact, cfg.Redact)) {
+		if setter, ok := s.requestLogger.(interface{ SetRedaction(config.RedactConfig) }); ok {
+			setter.SetRedaction(cfg.Redact)
+		}
+	}
+
 	if oldCfg == nil || oldCfg.DisableCooling != cfg.DisableCooling {
 		auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	}
@@ -1019,6 +1152,19 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	applySignatureCacheConfig(oldCfg, cfg)
+	applyResponseCacheConfig(oldCfg, cfg)
+	applyRateLimitConfig(oldCfg, cfg)
+	applyRequestSchedulerConfig(oldCfg, cfg)
+	applyClaudeResponseConfig(cfg)
+	claudeopenai.SetDisabledRequestStages(cfg.ClaudeRequest.DisabledOpenAIStages)
+	applyThinkingDefaults(cfg)
+	applyAPIKeyQuotaConfig(cfg)
+	applyModerationConfig(cfg)
+	applyPIIScrubberConfig(cfg)
+	applyProxyKeyConfig(cfg)
+	applyIPAccessConfig(cfg)
+	applyAudioTranscriptionConfig(cfg)
+	applyImageFetchConfig(cfg)
 
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second, cfg.MaxRetryCredentials)
@@ -1132,9 +1278,15 @@ func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 
 // (management handlers moved to internal/api/handlers/management)
 
+// replayPrincipal identifies requests replayed through the management API.
+const replayPrincipal = "management-replay"
+
 // AuthMiddleware returns a Gin middleware handler that authenticates requests
 // using the configured authentication providers. When no providers are available,
-// it allows all requests (legacy behaviour).
+// it allows all requests (legacy behaviour). Requests replayed through the management
+// API are already authenticated. Clients that presented a certificate
+// verified during the mutual TLS handshake are identified by its common name and
+// need no further credentials.
 func AuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if manager == nil {
@@ -1142,6 +1294,21 @@ func AuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc {
 			return
 		}
 
+		if logging.ReplayCaptureFrom(c.Request.Context()) != nil {
+			// Replays are dispatched internally by the management API, which authenticated the caller.
+			c.Set("apiKey", replayPrincipal)
+			c.Set("accessProvider", replayPrincipal)
+			c.Next()
+			return
+		}
+		if identity := clientCertIdentity(c.Request); identity != "" {
+			c.Set("apiKey", identity)
+			c.Set("accessProvider", clientCertProvider)
+			c.Set("accessMetadata", map[string]string{"source": clientCertProvider})
+			c.Next()
+			return
+		}
+
 		result, err := manager.Authenticate(c.Request.Context(), c.Request)
 		if err == nil {
 			if result != nil {
@@ -1176,6 +1343,10 @@ func applySignatureCacheConfig(oldCfg, cfg *config.Config) {
 	if oldCfg == nil {
 		cache.SetSignatureCacheEnabled(newVal)
 		cache.SetSignatureBypassStrictMode(newStrict)
+		cache.SetMaxThinkingTextBytes(cfg.SignatureCacheMaxThinkingBytes)
+		applySignatureCacheLimits(cfg)
+		applySignatureCacheEncryption(cfg)
+		applySignatureCacheStore(cfg)
 		return
 	}
 
@@ -1188,6 +1359,376 @@ func applySignatureCacheConfig(oldCfg, cfg *config.Config) {
 	if oldStrict != newStrict {
 		cache.SetSignatureBypassStrictMode(newStrict)
 	}
+
+	if oldCfg.SignatureCacheMaxThinkingBytes != cfg.SignatureCacheMaxThinkingBytes {
+		cache.SetMaxThinkingTextBytes(cfg.SignatureCacheMaxThinkingBytes)
+	}
+
+	applySignatureCacheLimits(cfg)
+	if oldCfg.SignatureCacheStore.EncryptionKey != cfg.SignatureCacheStore.EncryptionKey {
+		applySignatureCacheEncryption(cfg)
+	}
+	// Limits and the encryption key apply in place; only a backend change reopens the store.
+	oldStore := oldCfg.SignatureCacheStore
+	oldStore.CleanupInterval, oldStore.MaxEntries = cfg.SignatureCacheStore.CleanupInterval, cfg.SignatureCacheStore.MaxEntries
+	oldStore.EncryptionKey = cfg.SignatureCacheStore.EncryptionKey
+	if oldStore != cfg.SignatureCacheStore {
+		applySignatureCacheStore(cfg)
+	}
+}
+
+// applySignatureCacheLimits applies the sweep interval and entry cap of process-local
+// signature stores.
+func applySignatureCacheLimits(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	cache.SetCleanupInterval(time.Duration(cfg.SignatureCacheStore.CleanupInterval) * time.Second)
+	cache.SetMaxEntries(cfg.SignatureCacheStore.MaxEntries)
+}
+
+// applySignatureCacheEncryption sets the key encrypting cached signatures, from the config or
+// the SIGNATURE_CACHE_ENCRYPTION_KEY environment variable.
+func applySignatureCacheEncryption(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	secret := cfg.SignatureCacheStore.EncryptionKey
+	if secret == "" {
+		secret = strings.TrimSpace(os.Getenv("SIGNATURE_CACHE_ENCRYPTION_KEY"))
+	}
+	if errKey := cache.SetEncryptionKey(secret); errKey != nil {
+		log.Errorf("failed to enable signature cache encryption: %v", errKey)
+		return
+	}
+	if secret != "" {
+		log.Info("signature cache: encrypting cached signatures")
+	}
+}
+
+// applySignatureCacheStore switches the signature cache to the configured backend,
+// falling back to the in-memory store when the backend cannot be opened.
+func applySignatureCacheStore(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	// Close the current store first so a file store reopened at the same path loads the
+	// entries the previous one flushed on close.
+	cache.SetStore(nil)
+	switch cfg.SignatureCacheStore.Type {
+	case "file":
+		path := cfg.SignatureCacheStore.Path
+		if resolved, errResolve := util.ResolveAuthDir(path); errResolve == nil && resolved != "" {
+			path = resolved
+		}
+		store, errStore := cache.NewFileStore(path)
+		if errStore != nil {
+			log.Errorf("failed to open signature cache file store, using memory: %v", errStore)
+			return
+		}
+		log.Infof("signature cache: using file store at %s", path)
+		cache.SetStore(store)
+	case "redis":
+		store, errStore := cache.NewRedisStore(redisclient.Options{
+			Addr:      cfg.SignatureCacheStore.RedisAddr,
+			Password:  cfg.SignatureCacheStore.RedisPassword,
+			DB:        cfg.SignatureCacheStore.RedisDB,
+			KeyPrefix: cfg.SignatureCacheStore.RedisKeyPrefix,
+			Timeout:   time.Duration(cfg.SignatureCacheStore.RedisTimeout) * time.Second,
+		})
+		if errStore != nil {
+			log.Errorf("failed to connect signature cache redis store, using memory: %v", errStore)
+			return
+		}
+		log.Infof("signature cache: using redis store at %s", cfg.SignatureCacheStore.RedisAddr)
+		cache.SetStore(store)
+	}
+}
+
+// applyResponseCacheConfig installs the configured response cache backend, reopening it only
+// when the settings change. A redis backend that cannot be reached falls back to memory.
+func applyResponseCacheConfig(oldCfg, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	if oldCfg != nil && oldCfg.ResponseCache == cfg.ResponseCache {
+		return
+	}
+	rc := cfg.ResponseCache
+	if !rc.Enabled {
+		cache.SetResponseCache(nil, cache.ResponseCacheSettings{})
+		return
+	}
+	settings := cache.ResponseCacheSettings{TTL: time.Duration(rc.TTL) * time.Second, MaxEntryBytes: rc.MaxEntryBytes}
+	if rc.Type == "redis" {
+		store, errStore := cache.NewRedisResponseStore(redisclient.Options{
+			Addr:      rc.RedisAddr,
+			Password:  rc.RedisPassword,
+			DB:        rc.RedisDB,
+			KeyPrefix: rc.RedisKeyPrefix,
+		})
+		if errStore == nil {
+			log.Infof("response cache: using redis store at %s", rc.RedisAddr)
+			cache.SetResponseCache(store, settings)
+			return
+		}
+		log.Errorf("failed to connect response cache redis store, using memory: %v", errStore)
+	}
+	cache.SetResponseCache(cache.NewMemoryResponseStore(rc.MaxEntries), settings)
+}
+
+// applyRateLimitConfig installs the global limits and bucket store used by RateLimitMiddleware,
+// reconnecting the redis store only when the rate limit settings changed. Proxy key limits
+// (applyProxyKeyConfig) share the same store.
+func applyRateLimitConfig(oldCfg, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	if oldCfg != nil && oldCfg.RateLimit == cfg.RateLimit {
+		return
+	}
+	rl := cfg.RateLimit
+	limiter := apikeys.GetLimiter()
+	if !rl.Enabled {
+		limiter.SetDefaults(rl.KeyBy, apikeys.Limits{}, nil)
+		return
+	}
+	limits := apikeys.Limits{
+		RequestsPerMinute: int64(rl.RequestsPerMinute),
+		TokensPerMinute:   int64(rl.TokensPerMinute),
+	}
+	if rl.Type == "redis" {
+		store, errStore := apikeys.NewRedisStore(redisclient.Options{
+			Addr:      rl.RedisAddr,
+			Password:  rl.RedisPassword,
+			DB:        rl.RedisDB,
+			KeyPrefix: rl.RedisKeyPrefix,
+		})
+		if errStore == nil {
+			log.Infof("rate limit: using redis store at %s", rl.RedisAddr)
+			limiter.SetDefaults(rl.KeyBy, limits, store)
+			return
+		}
+		log.Errorf("failed to connect rate limit redis store, using memory: %v", errStore)
+	}
+	limiter.SetDefaults(rl.KeyBy, limits, nil)
+}
+
+// applyRequestSchedulerConfig installs the scheduler bounding concurrent upstream requests,
+// replacing it only when its settings changed so in-flight requests keep their slots.
+func applyRequestSchedulerConfig(oldCfg, cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	if oldCfg != nil && reflect.DeepEqual(oldCfg.RequestScheduler, cfg.RequestScheduler) {
+		return
+	}
+	rs := cfg.RequestScheduler
+	if !rs.Enabled {
+		scheduler.SetActive(nil)
+		return
+	}
+	policies := make(map[string]scheduler.KeyPolicy, len(rs.Keys))
+	for _, entry := range rs.Keys {
+		policies[entry.APIKey] = scheduler.KeyPolicy{Weight: rs.Classes[entry.Class], MaxConcurrent: entry.MaxConcurrent}
+	}
+	defaultPolicy := scheduler.KeyPolicy{Weight: rs.Classes[rs.DefaultClass]}
+	scheduler.SetActive(scheduler.New(scheduler.Limits{
+		MaxConcurrent:       rs.MaxConcurrent,
+		MaxConcurrentPerKey: rs.MaxConcurrentPerKey,
+		MaxQueueWait:        time.Duration(rs.MaxQueueWaitSeconds) * time.Second,
+	}, func(key string) scheduler.KeyPolicy {
+		if policy, ok := policies[key]; ok {
+			return policy
+		}
+		return defaultPolicy
+	}))
+}
+
+// applyUsageStore switches usage row persistence to the configured backend,
+// disabling it when the backend cannot be opened.
+func applyUsageStore(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	switch cfg.UsageStore.Type {
+	case "memory":
+		usage.SetStore(usage.NewMemoryStore(cfg.UsageStore.MaxRows))
+	case "file":
+		path := cfg.UsageStore.Path
+		if resolved, errResolve := util.ResolveAuthDir(path); errResolve == nil && resolved != "" {
+			path = resolved
+		}
+		store, errStore := usage.NewFileStore(path, cfg.UsageStore.RetentionDays)
+		if errStore != nil {
+			log.Errorf("failed to open usage file store, disabling usage store: %v", errStore)
+			usage.SetStore(nil)
+			return
+		}
+		log.Infof("usage store: using file store at %s", path)
+		usage.SetStore(store)
+	default:
+		usage.SetStore(nil)
+	}
+}
+
+func applyClaudeResponseConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	claudeopenai.SetResponseOptions(claudeopenai.ResponseOptions{
+		ToolCallContent:   cfg.ClaudeResponse.ToolCallContent,
+		CreatedSource:     cfg.ClaudeResponse.CreatedSource,
+		ToolCallStreaming: cfg.ClaudeResponse.ToolCallStreaming,
+		ThinkingOutput:    cfg.ClaudeResponse.ThinkingOutput,
+	})
+}
+
+// applyThinkingDefaults installs the per-model thinking defaults consulted by ApplyThinking.
+func applyThinkingDefaults(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	defaults := make(map[string]thinking.ThinkingConfig, len(cfg.Thinking.Defaults))
+	for model, def := range cfg.Thinking.Defaults {
+		if parsed, ok := thinking.ParseDefaultConfig(def.Mode, def.Effort, def.Budget); ok {
+			defaults[model] = parsed
+		}
+	}
+	thinking.SetModelDefaults(defaults)
+}
+
+func applyAPIKeyQuotaConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	toLimits := func(l config.QuotaLimits) usage.QuotaLimits {
+		return usage.QuotaLimits{
+			DailyRequests:   l.DailyRequests,
+			MonthlyRequests: l.MonthlyRequests,
+			DailyTokens:     l.DailyTokens,
+			MonthlyTokens:   l.MonthlyTokens,
+			MonthlyBudget:   l.MonthlyBudget,
+		}
+	}
+	settings := usage.QuotaSettings{
+		Default: toLimits(cfg.APIKeyQuota.Default),
+		Keys:    make(map[string]usage.QuotaLimits, len(cfg.APIKeyQuota.Keys)),
+	}
+	for _, entry := range cfg.APIKeyQuota.Keys {
+		settings.Keys[entry.APIKey] = toLimits(entry.QuotaLimits)
+	}
+	settings.Prices = toUsagePrices(cfg.APIKeyQuota.ModelPrices)
+	usage.GetRequestStatistics().SetQuotas(settings)
+	usage.GetRequestStatistics().SetPrices(toUsagePrices(cfg.ModelPrices))
+}
+
+func toUsagePrices(prices []config.ModelPrice) []usage.ModelPrice {
+	out := make([]usage.ModelPrice, 0, len(prices))
+	for _, price := range prices {
+		out = append(out, usage.ModelPrice{
+			Model:                 price.Model,
+			InputPerMillion:       price.Input,
+			OutputPerMillion:      price.Output,
+			CachedInputPerMillion: price.CachedInput,
+			CacheWritePerMillion:  price.CacheWrite,
+		})
+	}
+	return out
+}
+
+// applyImageFetchConfig installs the remote image fetcher used to inline image URLs.
+func applyImageFetchConfig(cfg *config.Config) {
+	if cfg == nil || !cfg.ImageFetch.Enabled {
+		imagefetch.SetSettings(nil)
+		return
+	}
+	f := cfg.ImageFetch
+	imagefetch.SetSettings(&imagefetch.Settings{
+		MaxBytes:             f.MaxBytes,
+		Timeout:              time.Duration(f.Timeout) * time.Second,
+		AllowedHosts:         f.AllowedHosts,
+		AllowPrivateNetworks: f.AllowPrivateNetworks,
+	})
+}
+
+// applyAudioTranscriptionConfig installs the speech-to-text backend used for audio input sent
+// to text-only providers.
+func applyAudioTranscriptionConfig(cfg *config.Config) {
+	if cfg == nil || (cfg.AudioTranscription.BaseURL == "" && cfg.AudioTranscription.APIKey == "") {
+		transcription.SetSettings(nil)
+		return
+	}
+	at := cfg.AudioTranscription
+	transcription.SetSettings(&transcription.Settings{
+		BaseURL:  at.BaseURL,
+		APIKey:   at.APIKey,
+		Model:    at.Model,
+		Language: at.Language,
+		Timeout:  time.Duration(at.Timeout) * time.Second,
+	})
+}
+
+// applyIPAccessConfig installs the IP allow and deny lists used by IPAccessMiddleware.
+func applyIPAccessConfig(cfg *config.Config) {
+	if cfg == nil {
+		middleware.SetIPAccessRules(nil)
+		return
+	}
+	middleware.SetIPAccessRules(middleware.ParseIPAccessRules(cfg.IPAccess.Allow, cfg.IPAccess.Deny, cfg.IPAccess.TrustedProxies))
+}
+
+// applyProxyKeyConfig installs the proxy key rate limits used by RateLimitMiddleware.
+func applyProxyKeyConfig(cfg *config.Config) {
+	limits := make(map[string]int)
+	if cfg != nil {
+		for _, key := range cfg.ProxyKeys {
+			if !key.Revoked && key.RequestsPerMinute > 0 {
+				limits[key.Key] = key.RequestsPerMinute
+			}
+		}
+	}
+	apikeys.GetLimiter().SetLimits(limits)
+}
+
+// applyModerationConfig installs the moderation settings used by ModerationMiddleware.
+func applyModerationConfig(cfg *config.Config) {
+	if cfg == nil || !cfg.Moderation.Enabled {
+		moderation.SetSettings(nil)
+		return
+	}
+	m := cfg.Moderation
+	settings := &moderation.Settings{Action: m.Action, FailOpen: m.FailOpen, Keywords: m.Keywords}
+	for _, pattern := range m.Patterns {
+		if compiled, errCompile := regexp.Compile(pattern); errCompile == nil {
+			settings.Patterns = append(settings.Patterns, compiled)
+		}
+	}
+	if m.OpenAI.APIKey != "" {
+		settings.OpenAI = &moderation.OpenAISettings{
+			BaseURL:    m.OpenAI.BaseURL,
+			APIKey:     m.OpenAI.APIKey,
+			Model:      m.OpenAI.Model,
+			Categories: m.OpenAI.Categories,
+			Timeout:    time.Duration(m.OpenAI.Timeout) * time.Second,
+		}
+	}
+	moderation.SetSettings(settings)
+}
+
+// applyPIIScrubberConfig installs the detectors used by PIIScrubberMiddleware.
+func applyPIIScrubberConfig(cfg *config.Config) {
+	if cfg == nil || !cfg.PIIScrubber.Enabled {
+		pii.SetSettings(nil)
+		return
+	}
+	var custom []pii.Detector
+	for _, pattern := range cfg.PIIScrubber.Patterns {
+		if compiled, errCompile := regexp.Compile(pattern.Pattern); errCompile == nil {
+			custom = append(custom, pii.Detector{Name: pattern.Name, Pattern: compiled})
+		}
+	}
+	pii.SetSettings(pii.NewSettings(cfg.PIIScrubber.Detectors, custom))
 }
 
 func configuredSignatureBypassStrict(cfg *config.Config) bool {
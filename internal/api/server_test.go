@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	gin "github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	internallogging "github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -248,3 +250,60 @@ func TestDefaultRequestLoggerFactory_UsesResolvedLogDirectory(t *testing.T) {
 		}
 	}
 }
+
+func TestReadyz(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status code without accounts: got %d want %d; body=%s", rr.Code, http.StatusServiceUnavailable, rr.Body.String())
+	}
+
+	if _, err := server.handlers.AuthManager.Register(context.Background(), &auth.Auth{ID: "ready-auth", Provider: "claude"}); err != nil {
+		t.Fatalf("failed to register auth: %v", err)
+	}
+	rr = httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: got %d want %d; body=%s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp struct {
+		Status string `json:"status"`
+		Checks map[string]struct {
+			OK bool `json:"ok"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse response JSON: %v; body=%s", err, rr.Body.String())
+	}
+	if resp.Status != "ready" || len(resp.Checks) != 3 {
+		t.Fatalf("unexpected response: %s", rr.Body.String())
+	}
+}
+
+func TestStop_FlushesSignatureCacheStore(t *testing.T) {
+	server := newTestServer(t)
+
+	path := filepath.Join(t.TempDir(), "signatures.json")
+	store, err := cache.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	cache.SetStore(store)
+	t.Cleanup(func() { cache.SetStore(nil) })
+	store.Set("claude:stop-test", cache.SignatureEntry{Signature: "stop-test-signature", Timestamp: time.Now()})
+
+	if err := server.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read store file: %v", err)
+	}
+	if !strings.Contains(string(data), "stop-test-signature") {
+		t.Fatalf("expected Stop to flush pending entries, got %s", data)
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/broadcast"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// streamSubscribeHandler joins a live response published with X-Stream-ID and relays its
+// chunks, starting with those already sent, until the publishing request ends.
+func streamSubscribeHandler(c *gin.Context) {
+	apiKey, _ := c.Get("apiKey")
+	owner, _ := apiKey.(string)
+	subscription, errSubscribe := broadcast.GetHub().Subscribe(c.Param("id"), strings.TrimSpace(owner))
+	if errSubscribe != nil {
+		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "No live stream with this ID.",
+				Type:    "invalid_request_error",
+				Code:    "stream_not_found",
+			},
+		})
+		return
+	}
+	defer subscription.Close()
+
+	contentType := subscription.ContentType
+	if contentType == "" {
+		contentType = "text/event-stream"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = c.Writer.Write(subscription.History)
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case chunk, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+			_, _ = c.Writer.Write(chunk)
+			c.Writer.Flush()
+		}
+	}
+}
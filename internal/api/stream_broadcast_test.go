@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/broadcast"
+)
+
+func TestStreamSubscribeHandlerHidesStreamsOfOtherKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("apiKey", "client-key")
+		c.Next()
+	})
+	engine.GET("/v1/streams/:id", streamSubscribeHandler)
+
+	stream, errOpen := broadcast.GetHub().Open("foreign-run", "other-key")
+	if errOpen != nil {
+		t.Fatalf("Open() error = %v", errOpen)
+	}
+	defer stream.Close()
+
+	get := func(id string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/streams/"+id, nil))
+		return rec
+	}
+	foreign, missing := get("foreign-run"), get("missing-run")
+	if foreign.Code != http.StatusNotFound || foreign.Code != missing.Code || foreign.Body.String() != missing.Body.String() {
+		t.Fatalf("foreign stream = %d %s, missing stream = %d %s, want identical 404s", foreign.Code, foreign.Body.String(), missing.Code, missing.Body.String())
+	}
+}
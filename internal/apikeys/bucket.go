@@ -0,0 +1,157 @@
+package apikeys
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// refillWindow is the time an empty bucket takes to refill; bucket capacity equals the
+// per-minute limit, so clients may burst up to one minute of their limits.
+const refillWindow = time.Minute
+
+// Limits are the per-minute bucket sizes. Zero disables the corresponding bucket.
+type Limits struct {
+	RequestsPerMinute int64
+	TokensPerMinute   int64
+}
+
+// Decision is the outcome of taking a request from a client's buckets.
+type Decision struct {
+	Allowed bool
+	// RetryAfter is how long until the request would fit; zero when allowed.
+	RetryAfter time.Duration
+	// RemainingRequests and RemainingTokens are the bucket levels after the request.
+	RemainingRequests int64
+	RemainingTokens   int64
+	// ResetRequests and ResetTokens are how long until the buckets are full again.
+	ResetRequests time.Duration
+	ResetTokens   time.Duration
+}
+
+// Store keeps the buckets of every client.
+type Store interface {
+	// Take removes one request and tokens estimated tokens from the buckets of key when both
+	// fit, and reports the resulting levels either way.
+	Take(key string, limits Limits, tokens int64) (Decision, error)
+	Close() error
+}
+
+// bucketState is the level of one bucket at a point in time.
+type bucketState struct {
+	level   float64
+	updated time.Time
+}
+
+// take refills the bucket up to now and reports whether cost fits. Costs above the capacity are
+// capped so an oversized request waits for a full bucket instead of never passing.
+func (b *bucketState) take(capacity, cost int64, now time.Time) (fits bool, wait time.Duration, capped int64) {
+	rate := float64(capacity) / float64(refillWindow)
+	if b.updated.IsZero() {
+		b.level = float64(capacity)
+	} else {
+		b.level = math.Min(float64(capacity), b.level+float64(now.Sub(b.updated))*rate)
+	}
+	b.updated = now
+	capped = min(cost, capacity)
+	if b.level >= float64(capped) {
+		return true, 0, capped
+	}
+	return false, time.Duration(math.Ceil((float64(capped) - b.level) / rate)), capped
+}
+
+// reset is how long until the bucket is full again.
+func (b *bucketState) reset(capacity int64) time.Duration {
+	rate := float64(capacity) / float64(refillWindow)
+	return time.Duration(math.Ceil((float64(capacity) - b.level) / rate))
+}
+
+// MemoryStore keeps buckets in process memory.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*[2]bucketState
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory bucket store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*[2]bucketState), now: time.Now}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(key string, limits Limits, tokens int64) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweepLocked(now)
+	pair := s.buckets[key]
+	if pair == nil {
+		pair = &[2]bucketState{}
+		s.buckets[key] = pair
+	}
+	capacities := [2]int64{limits.RequestsPerMinute, limits.TokensPerMinute}
+	costs := [2]int64{1, tokens}
+	decision := Decision{Allowed: true}
+	var charged [2]int64
+	for i := range pair {
+		if capacities[i] <= 0 {
+			continue
+		}
+		fits, wait, capped := pair[i].take(capacities[i], costs[i], now)
+		charged[i] = capped
+		if !fits {
+			decision.Allowed = false
+			decision.RetryAfter = max(decision.RetryAfter, wait)
+		}
+	}
+	for i := range pair {
+		if capacities[i] <= 0 {
+			continue
+		}
+		if decision.Allowed {
+			pair[i].level -= float64(charged[i])
+		}
+		remaining, reset := int64(pair[i].level), pair[i].reset(capacities[i])
+		if i == 0 {
+			decision.RemainingRequests, decision.ResetRequests = remaining, reset
+		} else {
+			decision.RemainingTokens, decision.ResetTokens = remaining, reset
+		}
+	}
+	return decision, nil
+}
+
+// sweepLocked drops buckets untouched for a full refill window; they would be full again.
+func (s *MemoryStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < refillWindow {
+		return
+	}
+	s.lastSweep = now
+	for key, pair := range s.buckets {
+		if now.Sub(pair[0].updated) >= refillWindow && now.Sub(pair[1].updated) >= refillWindow {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Close implements Store.
+func (s *MemoryStore) Close() error { return nil }
+
+// maxOutputTokenPaths are the request fields, across the OpenAI, Claude, Responses and Gemini
+// formats, that bound the output length.
+var maxOutputTokenPaths = []string{"max_tokens", "max_completion_tokens", "max_output_tokens", "generationConfig.maxOutputTokens"}
+
+// EstimateTokens estimates the tokens a request consumes: roughly four bytes of request body per
+// prompt token plus the requested maximum output tokens.
+func EstimateTokens(body []byte) int64 {
+	estimate := int64(len(body)+3) / 4
+	for _, path := range maxOutputTokenPaths {
+		if value := gjson.GetBytes(body, path); value.Type == gjson.Number && value.Int() > 0 {
+			return estimate + value.Int()
+		}
+	}
+	return estimate
+}
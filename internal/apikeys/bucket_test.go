@@ -0,0 +1,60 @@
+package apikeys
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limits := Limits{RequestsPerMinute: 2, TokensPerMinute: 600}
+
+	decision, _ := store.Take("client", limits, 400)
+	if !decision.Allowed || decision.RemainingRequests != 1 || decision.RemainingTokens != 200 {
+		t.Fatalf("first request: %+v", decision)
+	}
+	if decision.ResetTokens != 40*time.Second {
+		t.Fatalf("ResetTokens = %v, want 40s", decision.ResetTokens)
+	}
+
+	decision, _ = store.Take("client", limits, 300)
+	if decision.Allowed || decision.RetryAfter != 10*time.Second {
+		t.Fatalf("token-limited request: %+v", decision)
+	}
+	if decision.RemainingRequests != 1 || decision.RemainingTokens != 200 {
+		t.Fatalf("rejected request must not be charged: %+v", decision)
+	}
+
+	now = now.Add(10 * time.Second)
+	if decision, _ = store.Take("client", limits, 300); !decision.Allowed {
+		t.Fatalf("request after refill: %+v", decision)
+	}
+	if decision, _ = store.Take("client", limits, 0); decision.Allowed {
+		t.Fatalf("request-limited request: %+v", decision)
+	}
+
+	if decision, _ = store.Take("other", limits, 5000); !decision.Allowed || decision.RemainingTokens != 0 {
+		t.Fatalf("oversized request must be capped at the bucket size: %+v", decision)
+	}
+	if decision, _ = store.Take("other", Limits{RequestsPerMinute: 5}, 5000); !decision.Allowed {
+		t.Fatalf("disabled token limit: %+v", decision)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		body string
+		want int64
+	}{
+		{body: `{"model":"m"}`, want: 4},
+		{body: `{"max_tokens":100}`, want: 105},
+		{body: `{"generationConfig":{"maxOutputTokens":50}}`, want: 61},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens([]byte(tt.body)); got != tt.want {
+			t.Fatalf("EstimateTokens(%s) = %d, want %d", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package apikeys enforces the rate limits of client API keys: the global requests and estimated
+// tokens per minute of every client and the per-minute request limits of proxy keys, counted in
+// token buckets kept in memory or shared across proxy replicas through Redis.
+package apikeys
+
+import (
+	"sync"
+)
+
+// Limiter applies the global limits and the proxy key limits to clients through a Store.
+type Limiter struct {
+	mu        sync.RWMutex
+	keyBy     string
+	defaults  Limits
+	keyLimits map[string]int64
+	store     Store
+}
+
+// NewLimiter creates a limiter without limits, backed by an in-memory store.
+func NewLimiter() *Limiter {
+	return &Limiter{keyLimits: make(map[string]int64), store: NewMemoryStore()}
+}
+
+var defaultLimiter = NewLimiter()
+
+// GetLimiter returns the process-wide limiter.
+func GetLimiter() *Limiter { return defaultLimiter }
+
+// SetLimits replaces the per-minute request limits, keyed by client API key. Keys without a
+// positive limit fall back to the global limits.
+func (l *Limiter) SetLimits(limits map[string]int) {
+	keyLimits := make(map[string]int64, len(limits))
+	for key, limit := range limits {
+		if limit > 0 {
+			keyLimits[key] = int64(limit)
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keyLimits = keyLimits
+}
+
+// SetDefaults sets the limits applied to every client, identified according to keyBy ("api-key",
+// "ip" or "api-key+ip"), and the store holding the buckets. A nil store selects a new in-memory
+// store; the previous store is closed when it is replaced.
+func (l *Limiter) SetDefaults(keyBy string, limits Limits, store Store) {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	l.mu.Lock()
+	previous := l.store
+	l.keyBy, l.defaults, l.store = keyBy, limits, store
+	l.mu.Unlock()
+	if previous != nil && previous != store {
+		_ = previous.Close()
+	}
+}
+
+// Resolve returns the bucket key and the limits of a client. Proxy keys with their own request
+// limit are always counted per key; other clients are identified according to keyBy, and
+// requests without an API key by client IP. Zero limits mean the client is not limited.
+func (l *Limiter) Resolve(apiKey, clientIP string) (string, Limits) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	limits := l.defaults
+	if limit, ok := l.keyLimits[apiKey]; ok && apiKey != "" {
+		limits.RequestsPerMinute = limit
+		return "key:" + apiKey, limits
+	}
+	switch {
+	case l.keyBy == "ip" || apiKey == "":
+		return "ip:" + clientIP, limits
+	case l.keyBy == "api-key+ip":
+		return "key:" + apiKey + "|ip:" + clientIP, limits
+	default:
+		return "key:" + apiKey, limits
+	}
+}
+
+// Take charges one request and tokens estimated tokens to the buckets of key.
+func (l *Limiter) Take(key string, limits Limits, tokens int64) (Decision, error) {
+	l.mu.RLock()
+	store := l.store
+	l.mu.RUnlock()
+	return store.Take(key, limits, tokens)
+}
@@ -0,0 +1,56 @@
+package apikeys
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterResolve(t *testing.T) {
+	limiter := NewLimiter()
+	limiter.SetDefaults("api-key", Limits{RequestsPerMinute: 60, TokensPerMinute: 1000}, nil)
+	limiter.SetLimits(map[string]int{"limited": 2, "zero": 0})
+
+	key, limits := limiter.Resolve("limited", "10.0.0.1")
+	if key != "key:limited" || limits != (Limits{RequestsPerMinute: 2, TokensPerMinute: 1000}) {
+		t.Fatalf("proxy key: %q %+v", key, limits)
+	}
+	if key, limits = limiter.Resolve("zero", "10.0.0.1"); key != "key:zero" || limits.RequestsPerMinute != 60 {
+		t.Fatalf("key without its own limit must use the global limits: %q %+v", key, limits)
+	}
+	if key, _ = limiter.Resolve("", "10.0.0.1"); key != "ip:10.0.0.1" {
+		t.Fatalf("anonymous client key = %q", key)
+	}
+
+	limiter.SetDefaults("ip", Limits{}, nil)
+	if key, limits = limiter.Resolve("other", "10.0.0.1"); key != "ip:10.0.0.1" || limits != (Limits{}) {
+		t.Fatalf("disabled global limits: %q %+v", key, limits)
+	}
+	if key, limits = limiter.Resolve("limited", "10.0.0.1"); key != "key:limited" || limits.RequestsPerMinute != 2 {
+		t.Fatalf("proxy key limits must apply without global limits: %q %+v", key, limits)
+	}
+}
+
+func TestLimiterTakeProxyKeyLimit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limiter := NewLimiter()
+	limiter.SetDefaults("api-key", Limits{}, store)
+	limiter.SetLimits(map[string]int{"limited": 2})
+
+	key, limits := limiter.Resolve("limited", "")
+	for i := range 2 {
+		if decision, _ := limiter.Take(key, limits, 0); !decision.Allowed {
+			t.Fatalf("request %d rejected", i)
+		}
+	}
+	decision, _ := limiter.Take(key, limits, 0)
+	if decision.Allowed || decision.RetryAfter != 30*time.Second {
+		t.Fatalf("third request: %+v", decision)
+	}
+
+	limiter.SetLimits(nil)
+	if _, limits = limiter.Resolve("limited", ""); limits != (Limits{}) {
+		t.Fatalf("removed limit: %+v", limits)
+	}
+}
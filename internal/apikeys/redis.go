@@ -0,0 +1,110 @@
+package apikeys
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redisclient"
+)
+
+// DefaultRedisKeyPrefix namespaces bucket keys inside a shared Redis database.
+const DefaultRedisKeyPrefix = "cliproxy:ratelimit:"
+
+// takeScript atomically refills and charges the request (KEYS[1]) and token (KEYS[2]) buckets
+// using the Redis server clock. ARGV holds capacity and cost for each bucket; a capacity of 0
+// skips the bucket. It returns {allowed, retry_ms, remaining_requests, reset_requests_ms,
+// remaining_tokens, reset_tokens_ms}.
+const takeScript = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local window = tonumber(ARGV[5])
+local allowed = 1
+local retry = 0
+local state = {}
+for i = 1, 2 do
+  local capacity = tonumber(ARGV[i * 2 - 1])
+  if capacity > 0 then
+    local cost = math.min(tonumber(ARGV[i * 2]), capacity)
+    local rate = capacity / window
+    local saved = redis.call('HMGET', KEYS[i], 'level', 'ts')
+    local level = tonumber(saved[1])
+    if level == nil then
+      level = capacity
+    else
+      level = math.min(capacity, level + (now - tonumber(saved[2])) * rate)
+    end
+    if level < cost then
+      allowed = 0
+      retry = math.max(retry, math.ceil((cost - level) / rate))
+    end
+    state[i] = {level, cost, capacity, rate}
+  end
+end
+local out = {allowed, retry, 0, 0, 0, 0}
+for i = 1, 2 do
+  local s = state[i]
+  if s then
+    local level = s[1]
+    if allowed == 1 then
+      level = level - s[2]
+    end
+    redis.call('HSET', KEYS[i], 'level', tostring(level), 'ts', tostring(now))
+    redis.call('PEXPIRE', KEYS[i], window)
+    out[i * 2 + 1] = math.floor(level)
+    out[i * 2 + 2] = math.ceil((s[3] - level) / s[4])
+  end
+end
+return out
+`
+
+// RedisStore shares buckets across proxy replicas through Redis.
+type RedisStore struct {
+	client *redisclient.Client
+}
+
+// NewRedisStore connects to Redis and returns a bucket store backed by it.
+// DefaultRedisKeyPrefix is used when opts has no key prefix.
+func NewRedisStore(opts redisclient.Options) (*RedisStore, error) {
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = DefaultRedisKeyPrefix
+	}
+	client, errClient := redisclient.New(opts)
+	if errClient != nil {
+		return nil, errClient
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Take implements Store.
+func (s *RedisStore) Take(key string, limits Limits, tokens int64) (Decision, error) {
+	reply, errDo := s.client.Eval(takeScript, []string{key + ":requests", key + ":tokens"},
+		strconv.FormatInt(limits.RequestsPerMinute, 10), "1",
+		strconv.FormatInt(limits.TokensPerMinute, 10), strconv.FormatInt(tokens, 10),
+		strconv.FormatInt(refillWindow.Milliseconds(), 10),
+	)
+	if errDo != nil {
+		return Decision{}, errDo
+	}
+	values, ok := reply.([]any)
+	if !ok || len(values) != 6 {
+		return Decision{}, errors.New("rate limit: unexpected redis reply")
+	}
+	ints := make([]int64, len(values))
+	for i, value := range values {
+		ints[i], _ = value.(int64)
+	}
+	return Decision{
+		Allowed:           ints[0] == 1,
+		RetryAfter:        time.Duration(ints[1]) * time.Millisecond,
+		RemainingRequests: ints[2],
+		ResetRequests:     time.Duration(ints[3]) * time.Millisecond,
+		RemainingTokens:   ints[4],
+		ResetTokens:       time.Duration(ints[5]) * time.Millisecond,
+	}, nil
+}
+
+// Close closes the Redis connections.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,190 @@
+// Package broadcast fans a live streaming response out to additional subscribers, so a second
+// client can join an in-flight request by its stream ID and observe the same chunks.
+package broadcast
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	// maxHistoryBytes bounds the chunks replayed to late subscribers.
+	maxHistoryBytes = 4 << 20
+	// subscriberBuffer is the number of chunks queued per subscriber before it is dropped.
+	subscriberBuffer = 256
+)
+
+var (
+	// ErrStreamExists reports that another live request of the same owner already uses the
+	// stream ID.
+	ErrStreamExists = errors.New("broadcast: stream id already in use")
+	// ErrStreamNotFound reports that no live stream with the ID is visible to the subscriber.
+	ErrStreamNotFound = errors.New("broadcast: stream not found")
+)
+
+// Hub tracks the live broadcast streams by owner and ID. The zero value is not usable; use
+// NewHub.
+type Hub struct {
+	mu      sync.Mutex
+	streams map[streamKey]*Stream
+}
+
+// streamKey scopes stream IDs to their owner, so owners cannot collide with or probe the IDs
+// of each other.
+type streamKey struct {
+	owner string
+	id    string
+}
+
+// NewHub creates an empty hub.
+func NewHub() *Hub {
+	return &Hub{streams: make(map[streamKey]*Stream)}
+}
+
+var defaultHub = NewHub()
+
+// GetHub returns the process-wide broadcast hub.
+func GetHub() *Hub { return defaultHub }
+
+// Open registers a live stream published by owner, typically the client API key. IDs are
+// scoped to the owner, so different owners may use the same ID. Every opened stream must be
+// closed.
+func (h *Hub) Open(id, owner string) (*Stream, error) {
+	key := streamKey{owner: owner, id: id}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, exists := h.streams[key]; exists {
+		return nil, ErrStreamExists
+	}
+	stream := &Stream{
+		hub:         h,
+		key:         key,
+		subscribers: make(map[chan []byte]struct{}),
+	}
+	h.streams[key] = stream
+	return stream, nil
+}
+
+// Subscribe joins the live stream id of owner. Streams of other owners are reported as
+// ErrStreamNotFound, the same as missing ones.
+func (h *Hub) Subscribe(id, owner string) (*Subscription, error) {
+	h.mu.Lock()
+	stream, exists := h.streams[streamKey{owner: owner, id: id}]
+	h.mu.Unlock()
+	if !exists {
+		return nil, ErrStreamNotFound
+	}
+	return stream.subscribe()
+}
+
+// Stream is a live response published to subscribers.
+type Stream struct {
+	hub *Hub
+	key streamKey
+
+	mu          sync.Mutex
+	contentType string
+	history     []byte
+	truncated   bool
+	subscribers map[chan []byte]struct{}
+	closed      bool
+}
+
+// SetContentType records the content type announced to subscribers.
+func (s *Stream) SetContentType(contentType string) {
+	s.mu.Lock()
+	s.contentType = contentType
+	s.mu.Unlock()
+}
+
+// Write publishes chunk to every subscriber. It never blocks: subscribers that fall behind
+// are disconnected.
+func (s *Stream) Write(chunk []byte) {
+	if len(chunk) == 0 {
+		return
+	}
+	data := append([]byte(nil), chunk...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if !s.truncated && len(s.history)+len(data) <= maxHistoryBytes {
+		s.history = append(s.history, data...)
+	} else {
+		s.truncated = true
+		s.history = nil
+	}
+	for ch := range s.subscribers {
+		select {
+		case ch <- data:
+		default:
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends the stream, disconnecting its subscribers and releasing its ID.
+func (s *Stream) Close() {
+	s.hub.mu.Lock()
+	if s.hub.streams[s.key] == s {
+		delete(s.hub.streams, s.key)
+	}
+	s.hub.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for ch := range s.subscribers {
+		close(ch)
+	}
+	s.subscribers = nil
+}
+
+func (s *Stream) subscribe() (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrStreamNotFound
+	}
+	ch := make(chan []byte, subscriberBuffer)
+	s.subscribers[ch] = struct{}{}
+	return &Subscription{
+		ContentType: s.contentType,
+		History:     append([]byte(nil), s.history...),
+		Truncated:   s.truncated,
+		C:           ch,
+		stream:      s,
+		ch:          ch,
+	}, nil
+}
+
+// Subscription receives the chunks of a stream. C is closed when the stream ends or the
+// subscriber falls behind.
+type Subscription struct {
+	// ContentType is the content type of the published response, if known when joining.
+	ContentType string
+	// History holds the chunks published before the subscription started.
+	History []byte
+	// Truncated reports that the stream outgrew the history buffer, so History is empty.
+	Truncated bool
+	// C delivers the chunks published after the subscription started.
+	C <-chan []byte
+
+	stream *Stream
+	ch     chan []byte
+}
+
+// Close leaves the stream.
+func (s *Subscription) Close() {
+	s.stream.mu.Lock()
+	defer s.stream.mu.Unlock()
+	if _, ok := s.stream.subscribers[s.ch]; ok {
+		delete(s.stream.subscribers, s.ch)
+		close(s.ch)
+	}
+}
@@ -0,0 +1,99 @@
+package broadcast
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHubFansOutChunksToSubscribers(t *testing.T) {
+	hub := NewHub()
+	stream, errOpen := hub.Open("run-1", "key-a")
+	if errOpen != nil {
+		t.Fatalf("Open() error = %v", errOpen)
+	}
+	if _, errDup := hub.Open("run-1", "key-a"); !errors.Is(errDup, ErrStreamExists) {
+		t.Fatalf("duplicate Open() error = %v, want ErrStreamExists", errDup)
+	}
+	if _, errOther := hub.Subscribe("run-1", "key-b"); !errors.Is(errOther, ErrStreamNotFound) {
+		t.Fatalf("Subscribe() by another key error = %v, want ErrStreamNotFound", errOther)
+	}
+
+	stream.SetContentType("text/event-stream")
+	stream.Write([]byte("data: 1\n\n"))
+	sub, errSub := hub.Subscribe("run-1", "key-a")
+	if errSub != nil {
+		t.Fatalf("Subscribe() error = %v", errSub)
+	}
+	if sub.ContentType != "text/event-stream" || string(sub.History) != "data: 1\n\n" {
+		t.Fatalf("subscription = %q/%q, want content type and history", sub.ContentType, sub.History)
+	}
+
+	stream.Write([]byte("data: 2\n\n"))
+	if chunk := <-sub.C; string(chunk) != "data: 2\n\n" {
+		t.Fatalf("live chunk = %q", chunk)
+	}
+
+	stream.Close()
+	if _, ok := <-sub.C; ok {
+		t.Fatal("expected subscription channel to close with the stream")
+	}
+	sub.Close()
+	if _, errGone := hub.Subscribe("run-1", "key-a"); !errors.Is(errGone, ErrStreamNotFound) {
+		t.Fatalf("Subscribe() after Close() error = %v, want ErrStreamNotFound", errGone)
+	}
+	if _, errReopen := hub.Open("run-1", "key-a"); errReopen != nil {
+		t.Fatalf("Open() after Close() error = %v", errReopen)
+	}
+}
+
+func TestHubScopesStreamIDsToOwner(t *testing.T) {
+	hub := NewHub()
+	streamA, errA := hub.Open("run-1", "key-a")
+	if errA != nil {
+		t.Fatalf("Open() error = %v", errA)
+	}
+	defer streamA.Close()
+	streamB, errB := hub.Open("run-1", "key-b")
+	if errB != nil {
+		t.Fatalf("Open() of the same ID by another owner error = %v", errB)
+	}
+	defer streamB.Close()
+
+	streamA.Write([]byte("a"))
+	streamB.Write([]byte("b"))
+	sub, errSub := hub.Subscribe("run-1", "key-b")
+	if errSub != nil {
+		t.Fatalf("Subscribe() error = %v", errSub)
+	}
+	defer sub.Close()
+	if string(sub.History) != "b" {
+		t.Fatalf("history = %q, want the stream of key-b", sub.History)
+	}
+
+	streamB.Close()
+	if _, errGone := hub.Subscribe("run-1", "key-b"); !errors.Is(errGone, ErrStreamNotFound) {
+		t.Fatalf("Subscribe() after Close() error = %v, want ErrStreamNotFound", errGone)
+	}
+	if _, errKept := hub.Subscribe("run-1", "key-a"); errKept != nil {
+		t.Fatalf("closing another owner's stream removed key-a's stream: %v", errKept)
+	}
+}
+
+func TestStreamDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	stream, _ := hub.Open("run-1", "")
+	defer stream.Close()
+	sub, _ := hub.Subscribe("run-1", "")
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		stream.Write([]byte("x"))
+	}
+	received := 0
+	for range sub.C {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Fatalf("received %d chunks before disconnect, want %d", received, subscriberBuffer)
+	}
+	sub.Close()
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// fileStoreFlushInterval controls how often dirty file-store contents are written to disk.
+const fileStoreFlushInterval = 30 * time.Second
+
+// FileStore is an in-memory signature store that is snapshotted to a JSON file so
+// cached signatures survive process restarts.
+type FileStore struct {
+	*MemoryStore
+	path      string
+	dirty     atomic.Bool
+	flushMu   sync.Mutex
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+type fileStoreEntry struct {
+	Signature string    `json:"signature"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewFileStore opens (or creates) a file-backed signature store at path, loading any
+// non-expired entries persisted by a previous run.
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, errors.New("signature cache: file store path is empty")
+	}
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return nil, fmt.Errorf("signature cache: create store directory: %w", errMkdir)
+	}
+	s := &FileStore{MemoryStore: NewMemoryStore(), path: path, stop: make(chan struct{})}
+	if errLoad := s.load(); errLoad != nil {
+		return nil, errLoad
+	}
+	go s.flushLoop()
+	return s, nil
+}
+
+// Set stores or replaces the entry under key.
+func (s *FileStore) Set(key string, entry SignatureEntry) {
+	s.MemoryStore.Set(key, entry)
+	s.dirty.Store(true)
+}
+
+// Delete removes the entry under key.
+func (s *FileStore) Delete(key string) {
+	s.MemoryStore.Delete(key)
+	s.dirty.Store(true)
+}
+
+// DeletePrefix removes every entry whose key starts with prefix.
+func (s *FileStore) DeletePrefix(prefix string) {
+	s.MemoryStore.DeletePrefix(prefix)
+	s.dirty.Store(true)
+}
+
+// Flush writes the current entries to disk when they changed since the last flush.
+func (s *FileStore) Flush() error {
+	if !s.dirty.Swap(false) {
+		return nil
+	}
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	snapshot := s.snapshot(time.Now())
+	persisted := make(map[string]fileStoreEntry, len(snapshot))
+	for key, entry := range snapshot {
+		persisted[key] = fileStoreEntry{Signature: entry.Signature, Timestamp: entry.Timestamp}
+	}
+	data, errMarshal := json.Marshal(persisted)
+	if errMarshal != nil {
+		s.dirty.Store(true)
+		return fmt.Errorf("signature cache: encode store: %w", errMarshal)
+	}
+	tmp := s.path + ".tmp"
+	if errWrite := os.WriteFile(tmp, data, 0o600); errWrite != nil {
+		s.dirty.Store(true)
+		return fmt.Errorf("signature cache: write store: %w", errWrite)
+	}
+	if errRename := os.Rename(tmp, s.path); errRename != nil {
+		s.dirty.Store(true)
+		return fmt.Errorf("signature cache: replace store: %w", errRename)
+	}
+	return nil
+}
+
+// Close stops the background flusher and cleanup and writes pending entries to disk.
+func (s *FileStore) Close() error {
+	var errFlush error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		_ = s.MemoryStore.Close()
+		errFlush = s.Flush()
+	})
+	return errFlush
+}
+
+func (s *FileStore) load() error {
+	data, errRead := os.ReadFile(s.path)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("signature cache: read store: %w", errRead)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var persisted map[string]fileStoreEntry
+	if errUnmarshal := json.Unmarshal(data, &persisted); errUnmarshal != nil {
+		log.Warnf("signature cache: ignoring unreadable store file %s: %v", s.path, errUnmarshal)
+		return nil
+	}
+	now := time.Now()
+	for key, entry := range persisted {
+		if now.Sub(entry.Timestamp) > SignatureCacheTTL {
+			continue
+		}
+		s.MemoryStore.Set(key, SignatureEntry{Signature: entry.Signature, Timestamp: entry.Timestamp})
+	}
+	return nil
+}
+
+func (s *FileStore) flushLoop() {
+	ticker := time.NewTicker(fileStoreFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if errFlush := s.Flush(); errFlush != nil {
+				log.Warn(errFlush)
+			}
+		}
+	}
+}
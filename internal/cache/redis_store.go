@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redisclient"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultRedisKeyPrefix namespaces signature entries inside a shared Redis database.
+const DefaultRedisKeyPrefix = "cliproxy:signature:"
+
+// RedisStore shares signature entries across proxy replicas through Redis.
+// Entries are written with a TTL of SignatureCacheTTL so expiry propagates to every replica.
+type RedisStore struct {
+	client *redisclient.Client
+}
+
+type redisStoreEntry struct {
+	Signature string    `json:"signature"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewRedisStore connects to Redis and returns a signature store backed by it.
+// DefaultRedisKeyPrefix is used when opts has no key prefix.
+func NewRedisStore(opts redisclient.Options) (*RedisStore, error) {
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = DefaultRedisKeyPrefix
+	}
+	client, errClient := redisclient.New(opts)
+	if errClient != nil {
+		return nil, errClient
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Get returns the entry stored under key.
+func (s *RedisStore) Get(key string) (SignatureEntry, bool) {
+	raw, ok, errGet := s.client.Get(key)
+	if errGet != nil {
+		log.Warnf("signature cache: redis GET failed: %v", errGet)
+		return SignatureEntry{}, false
+	}
+	if !ok {
+		return SignatureEntry{}, false
+	}
+	var entry redisStoreEntry
+	if errUnmarshal := json.Unmarshal([]byte(raw), &entry); errUnmarshal != nil {
+		return SignatureEntry{}, false
+	}
+	return SignatureEntry{Signature: entry.Signature, Timestamp: entry.Timestamp}, true
+}
+
+// Set stores or replaces the entry under key with the remaining TTL of the entry.
+func (s *RedisStore) Set(key string, entry SignatureEntry) {
+	ttl := SignatureCacheTTL - time.Since(entry.Timestamp)
+	if ttl <= 0 {
+		s.Delete(key)
+		return
+	}
+	data, errMarshal := json.Marshal(redisStoreEntry{Signature: entry.Signature, Timestamp: entry.Timestamp})
+	if errMarshal != nil {
+		return
+	}
+	if errSet := s.client.Set(key, string(data), ttl); errSet != nil {
+		log.Warnf("signature cache: redis SET failed: %v", errSet)
+	}
+}
+
+// Delete removes the entry under key.
+func (s *RedisStore) Delete(key string) {
+	if errDel := s.client.Del(key); errDel != nil {
+		log.Warnf("signature cache: redis DEL failed: %v", errDel)
+	}
+}
+
+// DeletePrefix removes every entry whose key starts with prefix.
+func (s *RedisStore) DeletePrefix(prefix string) {
+	if errDel := s.client.DeletePrefix(prefix); errDel != nil {
+		log.Warnf("signature cache: redis delete by prefix failed: %v", errDel)
+	}
+}
+
+// Ping checks that the Redis server is reachable.
+func (s *RedisStore) Ping() error {
+	return s.client.Ping()
+}
+
+// Close closes the Redis connections.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redisclient"
+)
+
+// fakeRedis is a minimal in-process RESP server supporting the commands used by RedisStore.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttl  map[string]int64
+}
+
+func startFakeRedis(t *testing.T) (string, *fakeRedis) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	fake := &fakeRedis{data: make(map[string]string), ttl: make(map[string]int64)}
+	go func() {
+		for {
+			conn, errAccept := ln.Accept()
+			if errAccept != nil {
+				return
+			}
+			go fake.serve(conn)
+		}
+	}()
+	return ln.Addr().String(), fake
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	for {
+		reply, err := redisclient.ReadReply(reader)
+		if err != nil {
+			return
+		}
+		parts, _ := reply.([]any)
+		args := make([]string, 0, len(parts))
+		for _, p := range parts {
+			s, _ := p.(string)
+			args = append(args, s)
+		}
+		f.handle(writer, args)
+		if errFlush := writer.Flush(); errFlush != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) handle(w *bufio.Writer, args []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		value, ok := f.data[args[1]]
+		if !ok {
+			_, _ = w.WriteString("$-1\r\n")
+			return
+		}
+		_, _ = w.WriteString("$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n")
+	case "SET":
+		f.data[args[1]] = args[2]
+		if len(args) == 5 && strings.EqualFold(args[3], "PX") {
+			ms, _ := strconv.ParseInt(args[4], 10, 64)
+			f.ttl[args[1]] = ms
+		}
+		_, _ = w.WriteString("+OK\r\n")
+	case "DEL":
+		removed := 0
+		for _, key := range args[1:] {
+			if _, ok := f.data[key]; ok {
+				delete(f.data, key)
+				removed++
+			}
+		}
+		_, _ = w.WriteString(":" + strconv.Itoa(removed) + "\r\n")
+	case "SCAN":
+		prefix := strings.TrimSuffix(strings.ReplaceAll(args[3], "\\", ""), "*")
+		var keys []string
+		for key := range f.data {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		_, _ = w.WriteString("*2\r\n$1\r\n0\r\n*" + strconv.Itoa(len(keys)) + "\r\n")
+		for _, key := range keys {
+			_, _ = w.WriteString("$" + strconv.Itoa(len(key)) + "\r\n" + key + "\r\n")
+		}
+	default:
+		_, _ = w.WriteString("-ERR unknown command\r\n")
+	}
+}
+
+func TestRedisStore_SharesSignaturesAcrossInstances(t *testing.T) {
+	addr, fake := startFakeRedis(t)
+
+	storeA, err := NewRedisStore(redisclient.Options{Addr: addr})
+	if err != nil {
+		t.Fatalf("NewRedisStore A: %v", err)
+	}
+	defer func() { _ = storeA.Close() }()
+	storeB, err := NewRedisStore(redisclient.Options{Addr: addr})
+	if err != nil {
+		t.Fatalf("NewRedisStore B: %v", err)
+	}
+	defer func() { _ = storeB.Close() }()
+
+	entry := SignatureEntry{Signature: "shared_signature_123456789012345678901234567890123456789", Timestamp: time.Now()}
+	storeA.Set("claude:abc", entry)
+
+	got, ok := storeB.Get("claude:abc")
+	if !ok || got.Signature != entry.Signature {
+		t.Fatalf("expected replica B to read the signature written by A, got %+v (found=%v)", got, ok)
+	}
+
+	fake.mu.Lock()
+	ttl := fake.ttl[DefaultRedisKeyPrefix+"claude:abc"]
+	fake.mu.Unlock()
+	if ttl <= 0 || ttl > SignatureCacheTTL.Milliseconds() {
+		t.Fatalf("expected TTL within (0, %d] ms, got %d", SignatureCacheTTL.Milliseconds(), ttl)
+	}
+
+	storeA.Set("gemini:def", entry)
+	storeB.DeletePrefix("claude:")
+	if _, ok = storeA.Get("claude:abc"); ok {
+		t.Fatal("expected claude entry to be removed")
+	}
+	if _, ok = storeA.Get("gemini:def"); !ok {
+		t.Fatal("expected gemini entry to remain")
+	}
+}
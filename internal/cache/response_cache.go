@@ -0,0 +1,282 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/redisclient"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultResponseCacheKeyPrefix namespaces cached responses inside a shared Redis database.
+const DefaultResponseCacheKeyPrefix = "cliproxy:response:"
+
+// ResponseStore persists cached non-streaming responses.
+type ResponseStore interface {
+	// Get returns the unexpired response stored under key.
+	Get(key string) ([]byte, bool)
+	// Set stores the response under key for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+	// Clear removes every cached response.
+	Clear()
+	// Len returns the number of cached responses, or -1 when the store cannot count them.
+	Len() int
+	// Close releases the store's resources.
+	Close() error
+}
+
+// ResponseCacheSettings bounds what the response cache keeps.
+type ResponseCacheSettings struct {
+	// TTL is how long a response stays cached.
+	TTL time.Duration
+	// MaxEntryBytes skips responses larger than this; 0 means no limit.
+	MaxEntryBytes int
+}
+
+type responseCacheState struct {
+	store    ResponseStore
+	settings ResponseCacheSettings
+}
+
+var (
+	responseCacheMu     sync.Mutex
+	activeResponseCache atomic.Pointer[responseCacheState]
+)
+
+var responseCacheCounters struct {
+	hits    atomic.Int64
+	misses  atomic.Int64
+	stores  atomic.Int64
+	skipped atomic.Int64
+}
+
+// ResponseCacheStats reports response cache activity since process start.
+// Skipped counts responses not cached because they exceeded the size limit.
+type ResponseCacheStats struct {
+	Enabled bool    `json:"enabled"`
+	Entries int     `json:"entries"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+	Stores  int64   `json:"stores"`
+	Skipped int64   `json:"skipped"`
+}
+
+// SetResponseCache installs store as the response cache; a nil store disables caching.
+// The previously installed store is closed.
+func SetResponseCache(store ResponseStore, settings ResponseCacheSettings) {
+	responseCacheMu.Lock()
+	defer responseCacheMu.Unlock()
+	var next *responseCacheState
+	if store != nil {
+		next = &responseCacheState{store: store, settings: settings}
+	}
+	if prev := activeResponseCache.Swap(next); prev != nil && (next == nil || prev.store != next.store) {
+		if errClose := prev.store.Close(); errClose != nil {
+			log.Warnf("response cache: failed to close previous store: %v", errClose)
+		}
+	}
+}
+
+// ResponseCacheEnabled reports whether a response cache is installed.
+func ResponseCacheEnabled() bool {
+	return activeResponseCache.Load() != nil
+}
+
+// GetCachedResponse returns the response cached under key and counts the lookup.
+func GetCachedResponse(key string) ([]byte, bool) {
+	state := activeResponseCache.Load()
+	if state == nil {
+		return nil, false
+	}
+	value, ok := state.store.Get(key)
+	if !ok {
+		responseCacheCounters.misses.Add(1)
+		return nil, false
+	}
+	responseCacheCounters.hits.Add(1)
+	return value, true
+}
+
+// CacheResponse stores value under key unless it exceeds the configured size limit.
+func CacheResponse(key string, value []byte) {
+	state := activeResponseCache.Load()
+	if state == nil || len(value) == 0 {
+		return
+	}
+	if state.settings.MaxEntryBytes > 0 && len(value) > state.settings.MaxEntryBytes {
+		responseCacheCounters.skipped.Add(1)
+		return
+	}
+	state.store.Set(key, append([]byte(nil), value...), state.settings.TTL)
+	responseCacheCounters.stores.Add(1)
+}
+
+// ClearResponseCache removes every cached response.
+func ClearResponseCache() {
+	if state := activeResponseCache.Load(); state != nil {
+		state.store.Clear()
+	}
+}
+
+// GetResponseCacheStats returns the response cache counters and entry count.
+func GetResponseCacheStats() ResponseCacheStats {
+	stats := ResponseCacheStats{
+		Hits:    responseCacheCounters.hits.Load(),
+		Misses:  responseCacheCounters.misses.Load(),
+		Stores:  responseCacheCounters.stores.Load(),
+		Skipped: responseCacheCounters.skipped.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	if state := activeResponseCache.Load(); state != nil {
+		stats.Enabled = true
+		stats.Entries = state.store.Len()
+	}
+	return stats
+}
+
+// MemoryResponseStore keeps responses in process memory, evicting the least recently used
+// entry beyond its capacity.
+type MemoryResponseStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+}
+
+type memoryResponseEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryResponseStore returns an in-memory response store holding at most maxEntries
+// responses; maxEntries <= 0 means unlimited.
+func NewMemoryResponseStore(maxEntries int) *MemoryResponseStore {
+	return &MemoryResponseStore{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the unexpired response stored under key.
+func (s *MemoryResponseStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryResponseEntry)
+	if time.Now().After(entry.expires) {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return append([]byte(nil), entry.value...), true
+}
+
+// Set stores the response under key for ttl, evicting the least recently used entries
+// beyond capacity.
+func (s *MemoryResponseStore) Set(key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := &memoryResponseEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	for s.maxEntries > 0 && s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryResponseEntry).key)
+	}
+}
+
+// Clear removes every cached response.
+func (s *MemoryResponseStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.order.Init()
+	s.entries = make(map[string]*list.Element)
+}
+
+// Len returns the number of cached responses, including expired ones not yet looked up.
+func (s *MemoryResponseStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Close is a no-op for the in-memory store.
+func (s *MemoryResponseStore) Close() error { return nil }
+
+// RedisResponseStore shares cached responses across proxy replicas through Redis; expiry is
+// delegated to Redis key TTLs.
+type RedisResponseStore struct {
+	client *redisclient.Client
+}
+
+// NewRedisResponseStore connects to Redis and returns a response store backed by it.
+// DefaultResponseCacheKeyPrefix is used when opts has no key prefix.
+func NewRedisResponseStore(opts redisclient.Options) (*RedisResponseStore, error) {
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = DefaultResponseCacheKeyPrefix
+	}
+	client, errClient := redisclient.New(opts)
+	if errClient != nil {
+		return nil, errClient
+	}
+	return &RedisResponseStore{client: client}, nil
+}
+
+// Get returns the response stored under key.
+func (s *RedisResponseStore) Get(key string) ([]byte, bool) {
+	raw, ok, errGet := s.client.Get(key)
+	if errGet != nil {
+		log.Warnf("response cache: redis GET failed: %v", errGet)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return []byte(raw), true
+}
+
+// Set stores the response under key for ttl.
+func (s *RedisResponseStore) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	if errSet := s.client.Set(key, string(value), ttl); errSet != nil {
+		log.Warnf("response cache: redis SET failed: %v", errSet)
+	}
+}
+
+// Clear removes every cached response under the key prefix.
+func (s *RedisResponseStore) Clear() {
+	if errDel := s.client.DeletePrefix(""); errDel != nil {
+		log.Warnf("response cache: redis clear failed: %v", errDel)
+	}
+}
+
+// Len returns -1; counting would require scanning the shared database.
+func (s *RedisResponseStore) Len() int { return -1 }
+
+// Ping checks that the Redis server is reachable.
+func (s *RedisResponseStore) Ping() error {
+	return s.client.Ping()
+}
+
+// Close closes the Redis connections.
+func (s *RedisResponseStore) Close() error {
+	return s.client.Close()
+}
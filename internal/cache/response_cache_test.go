@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryResponseStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewMemoryResponseStore(2)
+	store.Set("a", []byte("1"), time.Minute)
+	store.Set("b", []byte("2"), time.Minute)
+	if _, ok := store.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	store.Set("c", []byte("3"), time.Minute)
+
+	if _, ok := store.Get("b"); ok {
+		t.Fatal("expected least recently used entry b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := store.Get(key); !ok {
+			t.Fatalf("expected %s to be cached", key)
+		}
+	}
+	if got := store.Len(); got != 2 {
+		t.Fatalf("Len() = %d, want 2", got)
+	}
+}
+
+func TestMemoryResponseStoreExpiresEntries(t *testing.T) {
+	store := NewMemoryResponseStore(0)
+	store.Set("a", []byte("1"), -time.Second)
+	if _, ok := store.Get("a"); ok {
+		t.Fatal("expected expired entry to be dropped")
+	}
+	if got := store.Len(); got != 0 {
+		t.Fatalf("Len() = %d, want 0", got)
+	}
+}
+
+func TestResponseCacheCountsAndSkipsOversizedResponses(t *testing.T) {
+	SetResponseCache(NewMemoryResponseStore(10), ResponseCacheSettings{TTL: time.Minute, MaxEntryBytes: 4})
+	t.Cleanup(func() { SetResponseCache(nil, ResponseCacheSettings{}) })
+	before := GetResponseCacheStats()
+
+	if _, ok := GetCachedResponse("k"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+	CacheResponse("k", []byte("tiny"))
+	CacheResponse("big", []byte("too large"))
+	if got, ok := GetCachedResponse("k"); !ok || string(got) != "tiny" {
+		t.Fatalf("GetCachedResponse(k) = %q, %v", got, ok)
+	}
+	if _, ok := GetCachedResponse("big"); ok {
+		t.Fatal("expected oversized response to be skipped")
+	}
+
+	stats := GetResponseCacheStats()
+	if !stats.Enabled || stats.Entries != 1 {
+		t.Fatalf("stats = %+v, want enabled with 1 entry", stats)
+	}
+	if stats.Hits-before.Hits != 1 || stats.Misses-before.Misses != 2 || stats.Skipped-before.Skipped != 1 {
+		t.Fatalf("unexpected counters: before %+v after %+v", before, stats)
+	}
+
+	ClearResponseCache()
+	if got := GetResponseCacheStats().Entries; got != 0 {
+		t.Fatalf("entries after clear = %d, want 0", got)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
@@ -31,69 +30,15 @@ const (
 	CacheCleanupInterval = 10 * time.Minute
 )
 
-// signatureCache stores signatures by model group -> textHash -> SignatureEntry
-var signatureCache sync.Map
-
-// cacheCleanupOnce ensures the background cleanup goroutine starts only once
-var cacheCleanupOnce sync.Once
-
-// groupCache is the inner map type
-type groupCache struct {
-	mu      sync.RWMutex
-	entries map[string]SignatureEntry
-}
-
 // hashText creates a stable, Unicode-safe key from text content
 func hashText(text string) string {
 	h := sha256.Sum256([]byte(text))
 	return hex.EncodeToString(h[:])[:SignatureTextHashLen]
 }
 
-// getOrCreateGroupCache gets or creates a cache bucket for a model group
-func getOrCreateGroupCache(groupKey string) *groupCache {
-	// Start background cleanup on first access
-	cacheCleanupOnce.Do(startCacheCleanup)
-
-	if val, ok := signatureCache.Load(groupKey); ok {
-		return val.(*groupCache)
-	}
-	sc := &groupCache{entries: make(map[string]SignatureEntry)}
-	actual, _ := signatureCache.LoadOrStore(groupKey, sc)
-	return actual.(*groupCache)
-}
-
-// startCacheCleanup launches a background goroutine that periodically
-// removes caches where all entries have expired.
-func startCacheCleanup() {
-	go func() {
-		ticker := time.NewTicker(CacheCleanupInterval)
-		defer ticker.Stop()
-		for range ticker.C {
-			purgeExpiredCaches()
-		}
-	}()
-}
-
-// purgeExpiredCaches removes caches with no valid (non-expired) entries.
-func purgeExpiredCaches() {
-	now := time.Now()
-	signatureCache.Range(func(key, value any) bool {
-		sc := value.(*groupCache)
-		sc.mu.Lock()
-		// Remove expired entries
-		for k, entry := range sc.entries {
-			if now.Sub(entry.Timestamp) > SignatureCacheTTL {
-				delete(sc.entries, k)
-			}
-		}
-		isEmpty := len(sc.entries) == 0
-		sc.mu.Unlock()
-		// Remove cache bucket if empty
-		if isEmpty {
-			signatureCache.Delete(key)
-		}
-		return true
-	})
+// signatureKey builds the store key for a model group and text hash.
+func signatureKey(groupKey, textHash string) string {
+	return groupKey + ":" + textHash
 }
 
 // CacheSignature stores a thinking signature for a given model group and text.
@@ -105,80 +50,69 @@ func CacheSignature(modelName, text, signature string) {
 	if len(signature) < MinValidSignatureLen {
 		return
 	}
+	if maxBytes := maxThinkingTextBytes.Load(); maxBytes > 0 && int64(len(text)) > maxBytes {
+		log.Debugf("signature cache: skipping thinking text of %d bytes (limit %d)", len(text), maxBytes)
+		return
+	}
 
-	groupKey := GetModelGroup(modelName)
-	textHash := hashText(text)
-	sc := getOrCreateGroupCache(groupKey)
-	sc.mu.Lock()
-	defer sc.mu.Unlock()
-
-	sc.entries[textHash] = SignatureEntry{
-		Signature: signature,
+	sealer := activeSealer.Load()
+	key := signatureKey(GetModelGroup(modelName), sealer.textHash(text))
+	currentStore().Set(key, SignatureEntry{
+		Signature: sealer.seal(key, signature),
 		Timestamp: time.Now(),
-	}
+	})
 }
 
 // GetCachedSignature retrieves a cached signature for a given model group and text.
 // Returns empty string if not found or expired.
 func GetCachedSignature(modelName, text string) string {
 	groupKey := GetModelGroup(modelName)
+	fallback := ""
+	if groupKey == "gemini" {
+		fallback = "skip_thought_signature_validator"
+	}
 
 	if text == "" {
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
+		return fallback
 	}
-	val, ok := signatureCache.Load(groupKey)
-	if !ok {
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
-	}
-	sc := val.(*groupCache)
-
-	textHash := hashText(text)
-
-	now := time.Now()
-
-	sc.mu.Lock()
-	entry, exists := sc.entries[textHash]
+	store := currentStore()
+	sealer := activeSealer.Load()
+	key := signatureKey(groupKey, sealer.textHash(text))
+	entry, exists := store.Get(key)
 	if !exists {
-		sc.mu.Unlock()
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
+		signatureCacheCounters.misses.Add(1)
+		return fallback
 	}
+	now := time.Now()
 	if now.Sub(entry.Timestamp) > SignatureCacheTTL {
-		delete(sc.entries, textHash)
-		sc.mu.Unlock()
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
+		store.Delete(key)
+		signatureCacheCounters.misses.Add(1)
+		signatureCacheCounters.expirations.Add(1)
+		return fallback
 	}
+	signature, ok := sealer.open(key, entry.Signature)
+	if !ok {
+		// Sealed under a key that is no longer configured.
+		store.Delete(key)
+		signatureCacheCounters.misses.Add(1)
+		return fallback
+	}
+	signatureCacheCounters.hits.Add(1)
 
 	// Refresh TTL on access (sliding expiration).
 	entry.Timestamp = now
-	sc.entries[textHash] = entry
-	sc.mu.Unlock()
+	store.Set(key, entry)
 
-	return entry.Signature
+	return signature
 }
 
 // ClearSignatureCache clears signature cache for a specific model group or all groups.
 func ClearSignatureCache(modelName string) {
 	if modelName == "" {
-		signatureCache.Range(func(key, _ any) bool {
-			signatureCache.Delete(key)
-			return true
-		})
+		currentStore().DeletePrefix("")
 		return
 	}
-	groupKey := GetModelGroup(modelName)
-	signatureCache.Delete(groupKey)
+	currentStore().DeletePrefix(GetModelGroup(modelName) + ":")
 }
 
 // HasValidSignature checks if a signature is valid (non-empty and long enough)
@@ -199,6 +133,7 @@ func GetModelGroup(modelName string) string {
 
 var signatureCacheEnabled atomic.Bool
 var signatureBypassStrictMode atomic.Bool
+var maxThinkingTextBytes atomic.Int64
 
 func init() {
 	signatureCacheEnabled.Store(true)
@@ -238,3 +173,17 @@ func SetSignatureBypassStrictMode(strict bool) {
 func SignatureBypassStrictMode() bool {
 	return signatureBypassStrictMode.Load()
 }
+
+// SetMaxThinkingTextBytes limits the size of thinking text eligible for signature caching.
+// Thinking blocks larger than the limit are not cached. Values <= 0 disable the limit.
+func SetMaxThinkingTextBytes(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	maxThinkingTextBytes.Store(int64(limit))
+}
+
+// MaxThinkingTextBytes returns the configured thinking text size limit (0 means unlimited).
+func MaxThinkingTextBytes() int {
+	return int(maxThinkingTextBytes.Load())
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// signatureEntryOverhead approximates the per-entry bookkeeping cost (map slot, timestamp and
+// string headers) added to the key and signature lengths in memory estimates.
+const signatureEntryOverhead = 64
+
+var signatureCacheCounters struct {
+	hits        atomic.Int64
+	misses      atomic.Int64
+	expirations atomic.Int64
+	evictions   atomic.Int64
+}
+
+// SignatureCacheGroupStats describes the entries cached for one model group.
+type SignatureCacheGroupStats struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// SignatureCacheStats reports signature cache activity since process start.
+// Expirations count entries dropped after SignatureCacheTTL; evictions count entries removed
+// before expiry by purges. Groups is nil when the active store cannot enumerate its entries.
+type SignatureCacheStats struct {
+	Hits        int64                               `json:"hits"`
+	Misses      int64                               `json:"misses"`
+	Expirations int64                               `json:"expirations"`
+	Evictions   int64                               `json:"evictions"`
+	Groups      map[string]SignatureCacheGroupStats `json:"groups,omitempty"`
+}
+
+// groupStatser is implemented by stores that can enumerate their entries.
+type groupStatser interface {
+	groupStats(now time.Time) map[string]SignatureCacheGroupStats
+}
+
+// GetSignatureCacheStats returns the signature cache counters and, for process-local stores,
+// per model group entry counts and memory estimates.
+func GetSignatureCacheStats() SignatureCacheStats {
+	stats := SignatureCacheStats{
+		Hits:        signatureCacheCounters.hits.Load(),
+		Misses:      signatureCacheCounters.misses.Load(),
+		Expirations: signatureCacheCounters.expirations.Load(),
+		Evictions:   signatureCacheCounters.evictions.Load(),
+	}
+	if store, ok := currentStore().(groupStatser); ok {
+		stats.Groups = store.groupStats(time.Now())
+	}
+	return stats
+}
+
+// DeleteSignatureCacheEntry removes the entry stored under key ("<model group>:<text hash>")
+// and reports whether it existed.
+func DeleteSignatureCacheEntry(key string) bool {
+	store := currentStore()
+	if _, exists := store.Get(key); !exists {
+		return false
+	}
+	store.Delete(key)
+	signatureCacheCounters.evictions.Add(1)
+	return true
+}
+
+// signatureKeyGroup returns the model group part of a store key.
+func signatureKeyGroup(key string) string {
+	if idx := strings.LastIndex(key, ":"); idx >= 0 {
+		return key[:idx]
+	}
+	return key
+}
+
+func (s *MemoryStore) groupStats(now time.Time) map[string]SignatureCacheGroupStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	groups := make(map[string]SignatureCacheGroupStats)
+	for key, entry := range s.entries {
+		if now.Sub(entry.Timestamp) > SignatureCacheTTL {
+			continue
+		}
+		group := signatureKeyGroup(key)
+		stats := groups[group]
+		stats.Entries++
+		stats.Bytes += int64(len(key) + len(entry.Signature) + signatureEntryOverhead)
+		groups[group] = stats
+	}
+	return groups
+}
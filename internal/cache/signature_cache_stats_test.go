@@ -0,0 +1,39 @@
+package cache
+
+import "testing"
+
+func TestSignatureCacheStats(t *testing.T) {
+	ClearSignatureCache("")
+	before := GetSignatureCacheStats()
+
+	text := "stats thinking text"
+	signature := "statsSignature_123456789012345678901234567890123456789012345"
+	CacheSignature(testModelName, text, signature)
+	CacheSignature("gemini-3-pro-preview", text, signature)
+	GetCachedSignature(testModelName, text)
+	GetCachedSignature(testModelName, "unknown text")
+
+	stats := GetSignatureCacheStats()
+	if stats.Hits-before.Hits != 1 || stats.Misses-before.Misses != 1 {
+		t.Fatalf("hits/misses delta = %d/%d, want 1/1", stats.Hits-before.Hits, stats.Misses-before.Misses)
+	}
+	claude := stats.Groups["claude"]
+	if claude.Entries != 1 || claude.Bytes <= int64(len(signature)) {
+		t.Fatalf("claude group = %+v, want one entry with a memory estimate", claude)
+	}
+	if stats.Groups["gemini"].Entries != 1 {
+		t.Fatalf("gemini group = %+v, want one entry", stats.Groups["gemini"])
+	}
+
+	if !DeleteSignatureCacheEntry(signatureKey("claude", hashText(text))) {
+		t.Fatal("DeleteSignatureCacheEntry() = false, want true for a cached entry")
+	}
+	if DeleteSignatureCacheEntry(signatureKey("claude", hashText(text))) {
+		t.Fatal("DeleteSignatureCacheEntry() = true, want false for a removed entry")
+	}
+	ClearSignatureCache("gemini-3-pro-preview")
+	stats = GetSignatureCacheStats()
+	if stats.Evictions-before.Evictions != 2 || len(stats.Groups) != 0 {
+		t.Fatalf("evictions delta = %d groups = %v, want 2 and no groups", stats.Evictions-before.Evictions, stats.Groups)
+	}
+}
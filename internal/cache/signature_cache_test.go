@@ -87,6 +87,33 @@ func TestCacheSignature_ShortSignatureRejected(t *testing.T) {
 	}
 }
 
+func TestCacheSignature_ThinkingTextSizeLimit(t *testing.T) {
+	ClearSignatureCache("")
+	previous := MaxThinkingTextBytes()
+	SetMaxThinkingTextBytes(32)
+	t.Cleanup(func() { SetMaxThinkingTextBytes(previous) })
+
+	sig := "validSig1234567890123456789012345678901234567890123456"
+	underCap := strings.Repeat("a", 32)
+	overCap := strings.Repeat("b", 33)
+
+	CacheSignature(testModelName, underCap, sig)
+	CacheSignature(testModelName, overCap, sig)
+
+	if got := GetCachedSignature(testModelName, underCap); got != sig {
+		t.Errorf("expected thinking text within limit to be cached, got '%s'", got)
+	}
+	if got := GetCachedSignature(testModelName, overCap); got != "" {
+		t.Errorf("expected thinking text over limit to be rejected, got '%s'", got)
+	}
+
+	SetMaxThinkingTextBytes(0)
+	CacheSignature(testModelName, overCap, sig)
+	if got := GetCachedSignature(testModelName, overCap); got != sig {
+		t.Errorf("expected unlimited cache to accept large thinking text, got '%s'", got)
+	}
+}
+
 func TestClearSignatureCache_ModelGroup(t *testing.T) {
 	ClearSignatureCache("")
 
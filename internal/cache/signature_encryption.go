@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// sealedSignaturePrefix marks signatures encrypted with the cache encryption key.
+const sealedSignaturePrefix = "enc1:"
+
+// signatureSealer encrypts cached signatures and keys text hashes with a secret.
+type signatureSealer struct {
+	aead   cipher.AEAD
+	macKey []byte
+}
+
+var activeSealer atomic.Pointer[signatureSealer]
+
+// SetEncryptionKey enables AES-GCM encryption of cached signatures with a key derived from
+// secret. Thinking text hashes become HMACs under the same secret, so cache keys cannot be
+// matched against known text either. An empty secret disables encryption. Entries cached under
+// another key, or without one, are no longer found and expire as usual.
+func SetEncryptionKey(secret string) error {
+	if secret == "" {
+		activeSealer.Store(nil)
+		return nil
+	}
+	encKey, errKey := hkdf.Key(sha256.New, []byte(secret), nil, "cliproxy signature cache encryption", 32)
+	if errKey != nil {
+		return fmt.Errorf("signature cache: derive encryption key: %w", errKey)
+	}
+	macKey, errKey := hkdf.Key(sha256.New, []byte(secret), nil, "cliproxy signature cache text hash", 32)
+	if errKey != nil {
+		return fmt.Errorf("signature cache: derive hash key: %w", errKey)
+	}
+	block, errCipher := aes.NewCipher(encKey)
+	if errCipher != nil {
+		return fmt.Errorf("signature cache: create cipher: %w", errCipher)
+	}
+	aead, errGCM := cipher.NewGCM(block)
+	if errGCM != nil {
+		return fmt.Errorf("signature cache: create cipher: %w", errGCM)
+	}
+	activeSealer.Store(&signatureSealer{aead: aead, macKey: macKey})
+	return nil
+}
+
+// EncryptionEnabled reports whether cached signatures are encrypted.
+func EncryptionEnabled() bool {
+	return activeSealer.Load() != nil
+}
+
+// textHash returns the store key part for text; without a sealer it is the plain hash.
+func (s *signatureSealer) textHash(text string) string {
+	if s == nil {
+		return hashText(text)
+	}
+	mac := hmac.New(sha256.New, s.macKey)
+	mac.Write([]byte(text))
+	return hex.EncodeToString(mac.Sum(nil))[:SignatureTextHashLen]
+}
+
+// seal encrypts signature for storage under key. The key is authenticated with the ciphertext
+// so a sealed signature cannot be moved to another entry.
+func (s *signatureSealer) seal(key, signature string) string {
+	if s == nil {
+		return signature
+	}
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(signature)+s.aead.Overhead())
+	_, _ = rand.Read(nonce)
+	sealed := s.aead.Seal(nonce, nonce, []byte(signature), []byte(key))
+	return sealedSignaturePrefix + base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+// open decrypts a signature stored under key and reports false when it cannot be decrypted.
+// Without a sealer, sealed entries left from when encryption was enabled are rejected too.
+func (s *signatureSealer) open(key, stored string) (string, bool) {
+	if s == nil {
+		if strings.HasPrefix(stored, sealedSignaturePrefix) {
+			return "", false
+		}
+		return stored, true
+	}
+	encoded, ok := strings.CutPrefix(stored, sealedSignaturePrefix)
+	if !ok {
+		return "", false
+	}
+	sealed, errDecode := base64.RawStdEncoding.DecodeString(encoded)
+	if errDecode != nil || len(sealed) < s.aead.NonceSize() {
+		return "", false
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plain, errOpen := s.aead.Open(nil, nonce, ciphertext, []byte(key))
+	if errOpen != nil {
+		return "", false
+	}
+	return string(plain), true
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignatureCacheEncryption(t *testing.T) {
+	store := NewMemoryStore()
+	SetStore(store)
+	t.Cleanup(func() {
+		_ = SetEncryptionKey("")
+		SetStore(nil)
+	})
+	if err := SetEncryptionKey("first secret"); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+
+	text := "thinking about the answer"
+	signature := "sig_" + strings.Repeat("x", MinValidSignatureLen)
+	CacheSignature(testModelName, text, signature)
+
+	if _, exists := store.Get(signatureKey("claude", hashText(text))); exists {
+		t.Fatal("expected the text hash to be keyed by the secret")
+	}
+	for key, entry := range store.snapshot(time.Now()) {
+		if strings.Contains(entry.Signature, signature) || !strings.HasPrefix(entry.Signature, sealedSignaturePrefix) {
+			t.Fatalf("entry %s stores the signature in the clear: %q", key, entry.Signature)
+		}
+	}
+	if got := GetCachedSignature(testModelName, text); got != signature {
+		t.Fatalf("GetCachedSignature = %q, want the decrypted signature", got)
+	}
+
+	if err := SetEncryptionKey("second secret"); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+	if got := GetCachedSignature(testModelName, text); got != "" {
+		t.Fatalf("GetCachedSignature under another key = %q, want a miss", got)
+	}
+}
+
+func TestSignatureSealerRejectsMovedEntries(t *testing.T) {
+	if err := SetEncryptionKey("secret"); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+	t.Cleanup(func() { _ = SetEncryptionKey("") })
+	sealer := activeSealer.Load()
+
+	sealed := sealer.seal("claude:aaaa", "signature")
+	if got, ok := sealer.open("claude:aaaa", sealed); !ok || got != "signature" {
+		t.Fatalf("open = %q, %v", got, ok)
+	}
+	if _, ok := sealer.open("claude:bbbb", sealed); ok {
+		t.Fatal("expected a sealed signature to be bound to its key")
+	}
+	if _, ok := sealer.open("claude:aaaa", "signature"); ok {
+		t.Fatal("expected plaintext entries to be rejected")
+	}
+}
+
+func TestSignatureCacheDropsSealedEntriesAfterDisablingEncryption(t *testing.T) {
+	store := NewMemoryStore()
+	SetStore(store)
+	t.Cleanup(func() {
+		_ = SetEncryptionKey("")
+		SetStore(nil)
+	})
+	if err := SetEncryptionKey("secret"); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+
+	// A sealed entry under the plain text hash, as a shared store may hold one.
+	text := "thinking about the answer"
+	key := signatureKey("claude", hashText(text))
+	signature := "sig_" + strings.Repeat("x", MinValidSignatureLen)
+	store.Set(key, SignatureEntry{Signature: activeSealer.Load().seal(key, signature), Timestamp: time.Now()})
+
+	if err := SetEncryptionKey(""); err != nil {
+		t.Fatalf("SetEncryptionKey: %v", err)
+	}
+	if got := GetCachedSignature(testModelName, text); got != "" {
+		t.Fatalf("GetCachedSignature = %q, want a miss instead of the sealed value", got)
+	}
+	if _, exists := store.Get(key); exists {
+		t.Fatal("expected the sealed entry to be deleted")
+	}
+
+	CacheSignature(testModelName, text, signature)
+	if got := GetCachedSignature(testModelName, text); got != signature {
+		t.Fatalf("GetCachedSignature after re-caching = %q, want %q", got, signature)
+	}
+}
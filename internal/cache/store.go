@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Store persists signature cache entries. Keys have the form "<model group>:<text hash>".
+// Implementations must be safe for concurrent use. Expiry is driven by SignatureEntry.Timestamp
+// and SignatureCacheTTL; stores may drop expired entries at any time.
+type Store interface {
+	// Get returns the entry stored under key.
+	Get(key string) (SignatureEntry, bool)
+	// Set stores or replaces the entry under key.
+	Set(key string, entry SignatureEntry)
+	// Delete removes the entry under key.
+	Delete(key string)
+	// DeletePrefix removes every entry whose key starts with prefix ("" removes all entries).
+	DeletePrefix(prefix string)
+}
+
+// storeHolder wraps the active store so atomic.Value always sees the same concrete type.
+type storeHolder struct {
+	store Store
+}
+
+var (
+	activeStore        atomic.Value
+	defaultMemoryStore = NewMemoryStore()
+)
+
+func init() {
+	activeStore.Store(storeHolder{store: defaultMemoryStore})
+}
+
+// currentStore returns the store backing the signature cache.
+func currentStore() Store {
+	return activeStore.Load().(storeHolder).store
+}
+
+// SetStore replaces the store backing the signature cache. A nil store restores the
+// default in-memory store. The previous store is closed when it implements io.Closer, which
+// flushes persistent stores and stops their background goroutines; the default in-memory
+// store is kept open so it can be restored later.
+func SetStore(store Store) {
+	if store == nil {
+		store = defaultMemoryStore
+	}
+	previous := activeStore.Swap(storeHolder{store: store}).(storeHolder).store
+	if previous == store || previous == Store(defaultMemoryStore) {
+		return
+	}
+	if closer, ok := previous.(io.Closer); ok {
+		if errClose := closer.Close(); errClose != nil {
+			log.Warnf("signature cache: failed to close previous store: %v", errClose)
+		}
+	}
+}
+
+// pinger is implemented by stores backed by a remote server.
+type pinger interface {
+	Ping() error
+}
+
+// PingBackends checks that the remote servers behind the signature cache and the response
+// cache are reachable. In-memory stores always pass.
+func PingBackends() error {
+	if p, ok := currentStore().(pinger); ok {
+		if errPing := p.Ping(); errPing != nil {
+			return fmt.Errorf("signature cache: %w", errPing)
+		}
+	}
+	if state := activeResponseCache.Load(); state != nil {
+		if p, ok := state.store.(pinger); ok {
+			if errPing := p.Ping(); errPing != nil {
+				return fmt.Errorf("response cache: %w", errPing)
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	cleanupInterval atomic.Int64
+	maxEntries      atomic.Int64
+)
+
+// SetCleanupInterval sets how often process-local stores sweep expired entries and enforce
+// the entry cap. Values <= 0 restore CacheCleanupInterval; the new interval applies after the
+// current sweep period.
+func SetCleanupInterval(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	cleanupInterval.Store(int64(interval))
+}
+
+// CleanupInterval returns the sweep interval of process-local stores.
+func CleanupInterval() time.Duration {
+	if interval := time.Duration(cleanupInterval.Load()); interval > 0 {
+		return interval
+	}
+	return CacheCleanupInterval
+}
+
+// SetMaxEntries caps the number of entries held by process-local stores. When a write exceeds
+// the cap, the least recently used entries are evicted. Values <= 0 disable the cap.
+func SetMaxEntries(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	maxEntries.Store(int64(limit))
+}
+
+// MemoryStore is the default process-local signature store.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	entries     map[string]SignatureEntry
+	cleanupOnce sync.Once
+	stop        chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewMemoryStore creates an empty in-memory signature store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]SignatureEntry), stop: make(chan struct{})}
+}
+
+// Close stops the background cleanup goroutine. Entries stay readable.
+func (s *MemoryStore) Close() error {
+	s.closeOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// Get returns the entry stored under key.
+func (s *MemoryStore) Get(key string) (SignatureEntry, bool) {
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+	return entry, ok
+}
+
+// Set stores or replaces the entry under key.
+func (s *MemoryStore) Set(key string, entry SignatureEntry) {
+	// Start background cleanup on first write
+	s.cleanupOnce.Do(s.startCleanup)
+	s.mu.Lock()
+	s.entries[key] = entry
+	if limit := int(maxEntries.Load()); limit > 0 && len(s.entries) > limit {
+		// Evict a tenth of the cap at once so steady writes at the cap do not scan every time.
+		s.evictLocked(limit - limit/10)
+	}
+	s.mu.Unlock()
+}
+
+// Delete removes the entry under key.
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+}
+
+// DeletePrefix removes every entry whose key starts with prefix.
+func (s *MemoryStore) DeletePrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prefix == "" {
+		signatureCacheCounters.evictions.Add(int64(len(s.entries)))
+		s.entries = make(map[string]SignatureEntry)
+		return
+	}
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+			signatureCacheCounters.evictions.Add(1)
+		}
+	}
+}
+
+// snapshot returns a copy of all non-expired entries.
+func (s *MemoryStore) snapshot(now time.Time) map[string]SignatureEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]SignatureEntry, len(s.entries))
+	for key, entry := range s.entries {
+		if now.Sub(entry.Timestamp) <= SignatureCacheTTL {
+			out[key] = entry
+		}
+	}
+	return out
+}
+
+// startCleanup launches a background goroutine that periodically removes expired entries
+// and enforces the entry cap.
+func (s *MemoryStore) startCleanup() {
+	go func() {
+		timer := time.NewTimer(CleanupInterval())
+		defer timer.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-timer.C:
+			}
+			s.purgeExpired(time.Now())
+			if limit := int(maxEntries.Load()); limit > 0 {
+				s.mu.Lock()
+				s.evictLocked(limit)
+				s.mu.Unlock()
+			}
+			timer.Reset(CleanupInterval())
+		}
+	}()
+}
+
+// purgeExpired removes entries older than SignatureCacheTTL.
+func (s *MemoryStore) purgeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.entries {
+		if now.Sub(entry.Timestamp) > SignatureCacheTTL {
+			delete(s.entries, key)
+			signatureCacheCounters.expirations.Add(1)
+		}
+	}
+}
+
+// evictLocked removes the least recently used entries until at most target remain. Entry
+// timestamps are refreshed on every hit, so the oldest timestamp is the least recently used.
+func (s *MemoryStore) evictLocked(target int) {
+	excess := len(s.entries) - target
+	if excess <= 0 {
+		return
+	}
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.entries[keys[i]].Timestamp.Before(s.entries[keys[j]].Timestamp)
+	})
+	for _, key := range keys[:excess] {
+		delete(s.entries, key)
+	}
+	signatureCacheCounters.evictions.Add(int64(excess))
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signatures.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	SetStore(store)
+	t.Cleanup(func() { SetStore(nil) })
+
+	text := "persisted thinking text"
+	sig := "persisted_signature_1234567890123456789012345678901234567890"
+	CacheSignature(testModelName, text, sig)
+	expired := SignatureEntry{Signature: "expired_signature_12345678901234567890123456789012345678901", Timestamp: time.Now().Add(-SignatureCacheTTL - time.Minute)}
+	store.Set(signatureKey("claude", "expired"), expired)
+
+	// Swapping the store closes (and flushes) the file store.
+	SetStore(nil)
+	if got := GetCachedSignature(testModelName, text); got != "" {
+		t.Fatalf("memory store should not see file store entries, got %q", got)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("reopen NewFileStore: %v", err)
+	}
+	SetStore(reopened)
+	if got := GetCachedSignature(testModelName, text); got != sig {
+		t.Fatalf("expected persisted signature after reopen, got %q", got)
+	}
+	if _, ok := reopened.Get(signatureKey("claude", "expired")); ok {
+		t.Fatal("expired entries should not be reloaded")
+	}
+}
+
+func TestMemoryStore_DeletePrefix(t *testing.T) {
+	store := NewMemoryStore()
+	store.Set("claude:a", SignatureEntry{Signature: "a", Timestamp: time.Now()})
+	store.Set("gemini:b", SignatureEntry{Signature: "b", Timestamp: time.Now()})
+
+	store.DeletePrefix("claude:")
+	if _, ok := store.Get("claude:a"); ok {
+		t.Fatal("expected claude entry to be removed")
+	}
+	if _, ok := store.Get("gemini:b"); !ok {
+		t.Fatal("expected gemini entry to remain")
+	}
+
+	store.DeletePrefix("")
+	if _, ok := store.Get("gemini:b"); ok {
+		t.Fatal("expected all entries to be removed")
+	}
+}
+
+func TestMemoryStore_MaxEntriesEvictsLeastRecentlyUsed(t *testing.T) {
+	SetMaxEntries(20)
+	t.Cleanup(func() { SetMaxEntries(0) })
+
+	store := NewMemoryStore()
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 20; i++ {
+		store.Set(fmt.Sprintf("claude:%02d", i), SignatureEntry{Signature: "s", Timestamp: base.Add(time.Duration(i) * time.Second)})
+	}
+	// Refresh the oldest entry so it is no longer the least recently used.
+	store.Set("claude:00", SignatureEntry{Signature: "s", Timestamp: time.Now()})
+	store.Set("claude:new", SignatureEntry{Signature: "s", Timestamp: time.Now()})
+
+	if got := len(store.snapshot(time.Now())); got != 18 {
+		t.Fatalf("entries after eviction = %d, want 18", got)
+	}
+	for _, key := range []string{"claude:00", "claude:new", "claude:19"} {
+		if _, ok := store.Get(key); !ok {
+			t.Fatalf("expected recently used entry %s to remain", key)
+		}
+	}
+	for _, key := range []string{"claude:01", "claude:02", "claude:03"} {
+		if _, ok := store.Get(key); ok {
+			t.Fatalf("expected least recently used entry %s to be evicted", key)
+		}
+	}
+}
+
+func TestCleanupInterval(t *testing.T) {
+	t.Cleanup(func() { SetCleanupInterval(0) })
+	if got := CleanupInterval(); got != CacheCleanupInterval {
+		t.Fatalf("default CleanupInterval() = %v, want %v", got, CacheCleanupInterval)
+	}
+	SetCleanupInterval(time.Minute)
+	if got := CleanupInterval(); got != time.Minute {
+		t.Fatalf("CleanupInterval() = %v, want 1m", got)
+	}
+}
+
+func TestMemoryStore_CloseStopsCleanup(t *testing.T) {
+	SetCleanupInterval(10 * time.Millisecond)
+	t.Cleanup(func() { SetCleanupInterval(0) })
+
+	store := NewMemoryStore()
+	expired := SignatureEntry{Signature: "expired", Timestamp: time.Now().Add(-SignatureCacheTTL - time.Minute)}
+	store.Set("claude:expired", expired)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := store.Get("claude:expired"); !ok {
+		t.Fatal("expected closed store to stop sweeping expired entries")
+	}
+}
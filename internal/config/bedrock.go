@@ -0,0 +1,100 @@
+package config
+
+import "strings"
+
+// BedrockKey represents an AWS Bedrock credential serving Anthropic Claude models.
+// Requests are authenticated with SigV4 using an access key pair, or with a Bedrock API key
+// sent as a bearer token.
+type BedrockKey struct {
+	// AccessKeyID is the AWS access key ID used for SigV4 signing.
+	AccessKeyID string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+
+	// SecretAccessKey is the AWS secret access key used for SigV4 signing.
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+
+	// SessionToken is the optional AWS session token of temporary credentials.
+	SessionToken string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// APIKey is a Bedrock API key used instead of an access key pair.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Region is the AWS region hosting the Bedrock runtime endpoint (e.g. "us-east-1").
+	Region string `yaml:"region" json:"region"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "aws/claude-sonnet-4-5").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL optionally overrides the Bedrock runtime endpoint, e.g. for VPC endpoints.
+	// When empty, https://bedrock-runtime.<region>.amazonaws.com is used.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps Bedrock model or inference profile IDs to client-visible aliases.
+	Models []ClaudeModel `yaml:"models" json:"models"`
+
+	// Headers optionally adds extra HTTP headers for requests sent with this credential.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// GetAPIKey returns the credential identity: the Bedrock API key, or the access key ID.
+func (k BedrockKey) GetAPIKey() string {
+	if k.APIKey != "" {
+		return k.APIKey
+	}
+	return k.AccessKeyID
+}
+
+func (k BedrockKey) GetBaseURL() string { return k.BaseURL }
+
+// SanitizeBedrockKeys normalizes Bedrock credentials and drops entries without credentials,
+// region or models.
+func (cfg *Config) SanitizeBedrockKeys() {
+	if cfg == nil {
+		return
+	}
+
+	out := cfg.BedrockKey[:0]
+	for i := range cfg.BedrockKey {
+		entry := cfg.BedrockKey[i]
+		entry.AccessKeyID = strings.TrimSpace(entry.AccessKeyID)
+		entry.SecretAccessKey = strings.TrimSpace(entry.SecretAccessKey)
+		entry.SessionToken = strings.TrimSpace(entry.SessionToken)
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.Region = strings.TrimSpace(entry.Region)
+		if entry.APIKey == "" && (entry.AccessKeyID == "" || entry.SecretAccessKey == "") {
+			continue
+		}
+		if entry.Region == "" {
+			continue
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.BaseURL = strings.TrimSpace(entry.BaseURL)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]ClaudeModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				models = append(models, model)
+			}
+		}
+		entry.Models = models
+		if len(entry.Models) == 0 {
+			continue
+		}
+		out = append(out, entry)
+	}
+	cfg.BedrockKey = out
+}
@@ -6,14 +6,21 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/netip"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
@@ -22,6 +29,7 @@ import (
 const (
 	DefaultPanelGitHubRepository = "https://github.com/router-for-me/Cli-Proxy-API-Management-Center"
 	DefaultPprofAddr             = "127.0.0.1:8316"
+	DefaultShutdownDrainTimeout  = 30
 )
 
 // Config represents the application's configuration, loaded from a YAML file.
@@ -36,6 +44,13 @@ type Config struct {
 	// TLS config controls HTTPS server settings.
 	TLS TLSConfig `yaml:"tls" json:"tls"`
 
+	// IPAccess enforces client IP allow and deny lists on every request.
+	IPAccess IPAccessConfig `yaml:"ip-access,omitempty" json:"ip-access,omitempty"`
+
+	// ShutdownDrainTimeout is how long, in seconds, shutdown waits for in-flight requests and
+	// streams to finish before closing connections. Default: 30; 0 closes them immediately.
+	ShutdownDrainTimeout int `yaml:"shutdown-drain-timeout" json:"shutdown-drain-timeout"`
+
 	// RemoteManagement nests management-related options under 'remote-management'.
 	RemoteManagement RemoteManagement `yaml:"remote-management" json:"-"`
 
@@ -58,13 +73,33 @@ type Config struct {
 	// When exceeded, the oldest log files are deleted until within the limit. Set to 0 to disable.
 	LogsMaxTotalSizeMB int `yaml:"logs-max-total-size-mb" json:"logs-max-total-size-mb"`
 
+	// LogRotation controls rotation of the application log and retention of request logs.
+	LogRotation LogRotationConfig `yaml:"log-rotation,omitempty" json:"log-rotation,omitempty"`
+
 	// ErrorLogsMaxFiles limits the number of error log files retained when request logging is disabled.
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
 
+	// Redact scrubs headers and bodies in request logs before they are written to disk.
+	Redact RedactConfig `yaml:"redact,omitempty" json:"redact,omitempty"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageStatisticsDailyReset rolls in-memory usage counters over at a day boundary,
+	// archiving the previous day's totals. Supported values: "" (default, never reset), "local", "utc".
+	UsageStatisticsDailyReset string `yaml:"usage-statistics-daily-reset,omitempty" json:"usage-statistics-daily-reset,omitempty"`
+
+	// UsageStore persists one row per request so usage can be queried with filters and
+	// pagination through the management API. Rows are written while usage statistics are enabled.
+	UsageStore UsageStoreConfig `yaml:"usage-store,omitempty" json:"usage-store,omitempty"`
+
+	// UsageReport sends a daily usage summary to Slack and/or email. Requires usage statistics.
+	UsageReport UsageReportConfig `yaml:"usage-report,omitempty" json:"usage-report,omitempty"`
+
+	// UsageWebhooks receive every usage record as signed, batched HTTP POSTs.
+	UsageWebhooks []UsageWebhook `yaml:"usage-webhooks,omitempty" json:"usage-webhooks,omitempty"`
+
 	// RedisUsageQueueRetentionSeconds controls how long (in seconds) usage queue items
 	// are retained in memory for the Redis RESP interface (LPOP/RPOP).
 	// Default: 60. Max: 3600.
@@ -77,6 +112,11 @@ type Config struct {
 	// When <= 0, the default worker count is used.
 	AuthAutoRefreshWorkers int `yaml:"auth-auto-refresh-workers" json:"auth-auto-refresh-workers"`
 
+	// AuthRefreshJitter spreads expiry-based OAuth token refreshes over this many seconds before
+	// the provider refresh lead, so credentials issued together do not refresh in the same
+	// instant. Each credential gets a stable offset within the window. When <= 0, no jitter.
+	AuthRefreshJitter int `yaml:"auth-refresh-jitter,omitempty" json:"auth-refresh-jitter,omitempty"`
+
 	// RequestRetry defines the retry times when the request failed.
 	RequestRetry int `yaml:"request-retry" json:"request-retry"`
 	// MaxRetryCredentials defines the maximum number of credentials to try for a failed request.
@@ -84,6 +124,19 @@ type Config struct {
 	MaxRetryCredentials int `yaml:"max-retry-credentials" json:"max-retry-credentials"`
 	// MaxRetryInterval defines the maximum wait time in seconds before retrying a cooled-down credential.
 	MaxRetryInterval int `yaml:"max-retry-interval" json:"max-retry-interval"`
+	// RetryBackoff retries transient upstream failures (5xx, 529 overloaded, connection resets)
+	// with jittered exponential backoff. Disabled by default.
+	RetryBackoff RetryBackoffConfig `yaml:"retry-backoff,omitempty" json:"retry-backoff,omitempty"`
+	// CircuitBreaker stops routing to a credential after consecutive upstream failures.
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit-breaker,omitempty" json:"circuit-breaker,omitempty"`
+
+	// UpstreamProbe periodically sends a minimal request through every credential and skips the
+	// ones that fail while healthy credentials remain.
+	UpstreamProbe UpstreamProbeConfig `yaml:"upstream-probe,omitempty" json:"upstream-probe,omitempty"`
+
+	// HedgedRequests sends a second copy of a slow request to another credential and keeps
+	// whichever answers first.
+	HedgedRequests HedgedRequestsConfig `yaml:"hedged-requests,omitempty" json:"hedged-requests,omitempty"`
 
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
@@ -101,6 +154,23 @@ type Config struct {
 
 	AntigravitySignatureBypassStrict *bool `yaml:"antigravity-signature-bypass-strict,omitempty" json:"antigravity-signature-bypass-strict,omitempty"`
 
+	// SignatureCacheMaxThinkingBytes caps the size of thinking text eligible for signature caching.
+	// Thinking blocks larger than this are not cached. <= 0 disables the limit (default).
+	SignatureCacheMaxThinkingBytes int `yaml:"signature-cache-max-thinking-bytes,omitempty" json:"signature-cache-max-thinking-bytes,omitempty"`
+
+	// SignatureCacheStore selects the backend that persists cached thinking signatures.
+	SignatureCacheStore SignatureCacheStoreConfig `yaml:"signature-cache-store,omitempty" json:"signature-cache-store,omitempty"`
+
+	// ResponseCache caches non-streaming responses of deterministic requests.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache,omitempty" json:"response-cache,omitempty"`
+
+	// RateLimit throttles clients by requests and estimated tokens per minute.
+	RateLimit RateLimitConfig `yaml:"rate-limit,omitempty" json:"rate-limit,omitempty"`
+
+	// RequestScheduler bounds concurrent upstream requests and queues the excess fairly by
+	// priority class.
+	RequestScheduler RequestSchedulerConfig `yaml:"request-scheduler,omitempty" json:"request-scheduler,omitempty"`
+
 	// GeminiKey defines Gemini API key configurations with optional routing overrides.
 	GeminiKey []GeminiKey `yaml:"gemini-api-key" json:"gemini-api-key"`
 
@@ -125,6 +195,21 @@ type Config struct {
 	// Used for services that use Vertex AI-style paths but with simple API key authentication.
 	VertexCompatAPIKey []VertexCompatKey `yaml:"vertex-api-key" json:"vertex-api-key"`
 
+	// BedrockKey defines AWS Bedrock credentials serving Anthropic Claude models.
+	BedrockKey []BedrockKey `yaml:"bedrock-api-key" json:"bedrock-api-key"`
+
+	// OpenRouterKey defines OpenRouter API keys.
+	OpenRouterKey []OpenRouterKey `yaml:"openrouter-api-key" json:"openrouter-api-key"`
+
+	// Ollama defines local Ollama servers reached through their OpenAI-compatible API.
+	Ollama []OllamaServer `yaml:"ollama" json:"ollama"`
+
+	// ExternalProviders defines providers implemented by an executable or HTTP endpoint.
+	ExternalProviders []ExternalProvider `yaml:"external-providers,omitempty" json:"external-providers,omitempty"`
+
+	// VertexClaude serves Anthropic Claude models through Vertex AI service account credentials.
+	VertexClaude VertexClaudeConfig `yaml:"vertex-claude" json:"vertex-claude"`
+
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
@@ -139,9 +224,48 @@ type Config struct {
 	// gemini-api-key, codex-api-key, claude-api-key, openai-compatibility, vertex-api-key, and ampcode.
 	OAuthModelAlias map[string][]OAuthModelAlias `yaml:"oauth-model-alias,omitempty" json:"oauth-model-alias,omitempty"`
 
+	// OAuthProxies routes the upstream traffic of OAuth/file-backed accounts, including token
+	// refreshes, through per-account proxies. A proxy_url in the auth file takes precedence.
+	OAuthProxies []OAuthProxy `yaml:"oauth-proxies,omitempty" json:"oauth-proxies,omitempty"`
+
+	// ModelOverrides adds models to, or overrides metadata in, the static provider model catalogs.
+	ModelOverrides []registry.ModelOverride `yaml:"model-overrides,omitempty" json:"model-overrides,omitempty"`
+
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
+	// ClaudeRequest configures normalization applied to requests sent to Claude upstreams.
+	ClaudeRequest ClaudeRequestConfig `yaml:"claude-request" json:"claude-request"`
+
+	// PromptCache configures automatic cache_control breakpoint placement for Claude requests.
+	PromptCache PromptCacheConfig `yaml:"prompt-cache" json:"prompt-cache"`
+
+	// Thinking configures thinking defaults for requests that carry no thinking config.
+	Thinking ThinkingSettings `yaml:"thinking,omitempty" json:"thinking,omitempty"`
+
+	// ClaudeResponse configures how Claude responses are rendered for OpenAI-compatible clients.
+	ClaudeResponse ClaudeResponseConfig `yaml:"claude-response" json:"claude-response"`
+
+	// ModelPrices prices token usage for the spend tracked in the usage statistics. Costs
+	// reported by the upstream take precedence.
+	ModelPrices []ModelPrice `yaml:"model-prices,omitempty" json:"model-prices,omitempty"`
+
+	// APIKeyQuota enforces per-client-API-key request, token and spend limits.
+	APIKeyQuota APIKeyQuotaConfig `yaml:"api-key-quota,omitempty" json:"api-key-quota,omitempty"`
+
+	// Moderation runs client prompts through moderation backends before proxying them.
+	Moderation ModerationConfig `yaml:"moderation,omitempty" json:"moderation,omitempty"`
+
+	// PIIScrubber replaces personal data in prompts with tokens before proxying them and restores
+	// the original values in responses.
+	PIIScrubber PIIScrubberConfig `yaml:"pii-scrubber,omitempty" json:"pii-scrubber,omitempty"`
+
+	// AudioTranscription transcribes OpenAI input_audio parts for models whose providers only accept text.
+	AudioTranscription AudioTranscriptionConfig `yaml:"audio-transcription,omitempty" json:"audio-transcription,omitempty"`
+
+	// ImageFetch downloads remote image URLs in requests and inlines them as base64 data.
+	ImageFetch ImageFetchConfig `yaml:"image-fetch,omitempty" json:"image-fetch,omitempty"`
+
 	legacyMigrationPending bool `yaml:"-" json:"-"`
 }
 
@@ -176,6 +300,41 @@ type TLSConfig struct {
 	Cert string `yaml:"cert" json:"cert"`
 	// Key is the path to the TLS private key file.
 	Key string `yaml:"key" json:"key"`
+	// ClientCA is the path to a PEM bundle of CAs that sign client certificates. When set, the
+	// server performs mutual TLS and uses the certificate common name as the client identity.
+	ClientCA string `yaml:"client-ca,omitempty" json:"client-ca,omitempty"`
+	// ClientAuth is "require" (default) to reject clients without a valid certificate, or
+	// "verify-if-given" to also accept clients that present none.
+	ClientAuth string `yaml:"client-auth,omitempty" json:"client-auth,omitempty"`
+}
+
+// LogRotationConfig controls rotation of main.log and compression and retention of request logs.
+type LogRotationConfig struct {
+	// MaxSizeMB rotates main.log once it reaches this size. Default: 10.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+	// RotateIntervalHours also rotates main.log after this many hours. 0 disables time-based rotation.
+	RotateIntervalHours int `yaml:"rotate-interval-hours,omitempty" json:"rotate-interval-hours,omitempty"`
+	// MaxBackups limits the number of rotated main.log files kept. 0 keeps all.
+	MaxBackups int `yaml:"max-backups,omitempty" json:"max-backups,omitempty"`
+	// Compress gzips rotated main.log files and request logs older than CompressAfterMinutes.
+	Compress bool `yaml:"compress,omitempty" json:"compress,omitempty"`
+	// CompressAfterMinutes is the age at which request logs are compressed. Default: 60.
+	CompressAfterMinutes int `yaml:"compress-after-minutes,omitempty" json:"compress-after-minutes,omitempty"`
+	// MaxAgeDays deletes rotated main.log files and request logs older than this many days. 0 keeps them.
+	MaxAgeDays int `yaml:"max-age-days,omitempty" json:"max-age-days,omitempty"`
+	// RequestLogsMaxFiles keeps only the newest request logs, error logs included. 0 keeps all.
+	RequestLogsMaxFiles int `yaml:"request-logs-max-files,omitempty" json:"request-logs-max-files,omitempty"`
+}
+
+// IPAccessConfig restricts which client networks may reach the server.
+type IPAccessConfig struct {
+	// Allow lists the CIDRs or IPs that may connect. When non-empty, all other clients are rejected.
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+	// Deny lists the CIDRs or IPs that are rejected. Deny entries win over allow entries.
+	Deny []string `yaml:"deny,omitempty" json:"deny,omitempty"`
+	// TrustedProxies lists the CIDRs or IPs of reverse proxies whose X-Forwarded-For header is
+	// honored when determining the client IP.
+	TrustedProxies []string `yaml:"trusted-proxies,omitempty" json:"trusted-proxies,omitempty"`
 }
 
 // PprofConfig holds pprof HTTP server settings.
@@ -220,7 +379,8 @@ type QuotaExceeded struct {
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first".
+	// Supported values: "round-robin" (default), "fill-first", "least-used" (fewest requests in
+	// the recent-requests window).
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
 
 	// ClaudeCodeSessionAffinity enables session-sticky routing for Claude Code clients.
@@ -252,6 +412,19 @@ type OAuthModelAlias struct {
 	Fork  bool   `yaml:"fork,omitempty" json:"fork,omitempty"`
 }
 
+// OAuthProxy assigns a proxy to the OAuth/file-backed accounts it matches.
+type OAuthProxy struct {
+	// Provider limits the entry to one OAuth channel (e.g. "claude", "gemini-cli"); empty
+	// matches every channel.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Account is the account email or the auth file name; empty matches every account of the
+	// provider.
+	Account string `yaml:"account,omitempty" json:"account,omitempty"`
+	// ProxyURL is the proxy to use: socks5://, socks5h://, http:// or https://, with optional
+	// user:password credentials, or "direct" to bypass the global proxy.
+	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
+}
+
 // AmpModelMapping defines a model name mapping for Amp CLI requests.
 // When Amp requests a model that isn't available locally, this mapping
 // allows routing to an alternative model that IS available.
@@ -348,6 +521,677 @@ type PayloadModelRule struct {
 	Protocol string `yaml:"protocol" json:"protocol"`
 }
 
+// UsageStoreConfig configures the per-request usage row store.
+type UsageStoreConfig struct {
+	// Type selects the backend: "" (default, disabled), "memory" (most recent MaxRows rows)
+	// or "file" (JSON lines under Path, one file per UTC day).
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+
+	// Path is the directory used by the "file" backend.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// RetentionDays removes day files older than this many days; 0 keeps them forever.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+
+	// MaxRows bounds the "memory" backend. Default: 10000.
+	MaxRows int `yaml:"max-rows,omitempty" json:"max-rows,omitempty"`
+}
+
+const (
+	// DefaultUsageReportTime is when the daily usage report is sent.
+	DefaultUsageReportTime = "00:05"
+	// DefaultUsageReportTopN is how many models and API keys the daily usage report lists.
+	DefaultUsageReportTopN = 5
+)
+
+// UsageReportConfig configures the daily usage report. The report covers the previous day of
+// the usage statistics calendar (UTC, or local time when usage-statistics-daily-reset is "local").
+type UsageReportConfig struct {
+	// Enabled turns the daily report on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Time is when the report is sent, as "HH:MM" in the statistics calendar. Default: "00:05".
+	Time string `yaml:"time,omitempty" json:"time,omitempty"`
+
+	// TopN is how many models and API keys are listed. Default: 5.
+	TopN int `yaml:"top-n,omitempty" json:"top-n,omitempty"`
+
+	// Template overrides the report body, a Go text/template rendered with usage.DailyReport.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+
+	// SlackWebhookURL is a Slack incoming webhook receiving the report.
+	SlackWebhookURL string `yaml:"slack-webhook-url,omitempty" json:"slack-webhook-url,omitempty"`
+
+	// Email sends the report over SMTP when Host and To are set.
+	Email UsageReportEmail `yaml:"email,omitempty" json:"email,omitempty"`
+}
+
+// UsageReportEmail configures SMTP delivery of the daily usage report.
+type UsageReportEmail struct {
+	Host     string   `yaml:"host,omitempty" json:"host,omitempty"`
+	Port     int      `yaml:"port,omitempty" json:"port,omitempty"`
+	Username string   `yaml:"username,omitempty" json:"username,omitempty"`
+	Password string   `yaml:"password,omitempty" json:"password,omitempty"`
+	From     string   `yaml:"from,omitempty" json:"from,omitempty"`
+	To       []string `yaml:"to,omitempty" json:"to,omitempty"`
+	// Subject is a Go text/template rendered with usage.DailyReport. Default: "Usage report {{.Date}}".
+	Subject string `yaml:"subject,omitempty" json:"subject,omitempty"`
+}
+
+const (
+	// DefaultUsageWebhookBatchSize is the number of usage events sent per webhook request.
+	DefaultUsageWebhookBatchSize = 50
+	// DefaultUsageWebhookFlushInterval is how often, in seconds, partial batches are sent.
+	DefaultUsageWebhookFlushInterval = 5
+	// DefaultUsageWebhookQueueSize bounds the events buffered per webhook.
+	DefaultUsageWebhookQueueSize = 10000
+	// DefaultUsageWebhookTimeout is the webhook request timeout in seconds.
+	DefaultUsageWebhookTimeout = 10
+)
+
+// UsageWebhook configures an endpoint that receives usage records for external accounting.
+// Events are POSTed as {"events": [...]} batches. When Secret is set, requests carry an
+// X-Webhook-Signature header "sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">" where the
+// timestamp is the X-Webhook-Timestamp header.
+type UsageWebhook struct {
+	// URL is the endpoint receiving the batches.
+	URL string `yaml:"url" json:"url"`
+
+	// Secret signs the request bodies; unsigned when empty.
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+
+	// Headers adds extra HTTP headers to webhook requests.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// BatchSize is the maximum number of events per request. Default: 50.
+	BatchSize int `yaml:"batch-size,omitempty" json:"batch-size,omitempty"`
+
+	// FlushInterval is how often, in seconds, partial batches are sent. Default: 5.
+	FlushInterval int `yaml:"flush-interval,omitempty" json:"flush-interval,omitempty"`
+
+	// MaxRetries is how many times a failed batch is retried with exponential backoff before it
+	// is dropped. 0 disables retries.
+	MaxRetries int `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
+
+	// QueueSize bounds the buffered events; the oldest events are dropped when it is full.
+	// Default: 10000.
+	QueueSize int `yaml:"queue-size,omitempty" json:"queue-size,omitempty"`
+
+	// Timeout is the request timeout in seconds. Default: 10.
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// RedactConfig configures request log redaction.
+type RedactConfig struct {
+	// Enabled turns redaction on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// DisableDefaults drops the built-in rules: authorization, proxy-authorization, x-api-key,
+	// x-goog-api-key and cookie headers, and base64 image data in bodies.
+	DisableDefaults bool `yaml:"disable-defaults,omitempty" json:"disable-defaults,omitempty"`
+
+	// Headers lists additional header names whose values are replaced, case-insensitively.
+	Headers []string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// JSONPaths lists dot-separated paths into JSON bodies whose values are replaced.
+	// "*" matches every array element or object key, e.g. "messages.*.content".
+	JSONPaths []string `yaml:"json-paths,omitempty" json:"json-paths,omitempty"`
+
+	// Patterns lists regular expressions whose matches are replaced in every logged section.
+	Patterns []RedactPattern `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+
+	// MaxBodyBytes truncates each logged body section to this many bytes; 0 keeps full bodies.
+	MaxBodyBytes int `yaml:"max-body-bytes,omitempty" json:"max-body-bytes,omitempty"`
+}
+
+// RedactPattern replaces regular expression matches in logged content.
+type RedactPattern struct {
+	// Pattern is a Go regular expression.
+	Pattern string `yaml:"pattern" json:"pattern"`
+
+	// Replacement supports $1-style group references. Default: "[REDACTED]".
+	Replacement string `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+}
+
+// RetryBackoffConfig configures jittered exponential backoff for transient upstream failures.
+// Zero values select the documented defaults.
+type RetryBackoffConfig struct {
+	// Enabled turns backoff retries on. While enabled, transient errors no longer cool the
+	// credential down for a minute; the request backs off and retries instead.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// MaxAttempts bounds the attempts per request, the first one included. Default: 3.
+	MaxAttempts int `yaml:"max-attempts,omitempty" json:"max-attempts,omitempty"`
+
+	// MaxElapsed bounds the seconds spent on one request including waits; 0 means no limit.
+	MaxElapsed int `yaml:"max-elapsed,omitempty" json:"max-elapsed,omitempty"`
+
+	// BaseDelayMs is the delay before the first retry; it doubles for every further retry. Default: 500.
+	BaseDelayMs int `yaml:"base-delay-ms,omitempty" json:"base-delay-ms,omitempty"`
+
+	// MaxDelayMs caps a single backoff delay. Default: 10000.
+	MaxDelayMs int `yaml:"max-delay-ms,omitempty" json:"max-delay-ms,omitempty"`
+
+	// Providers overrides MaxAttempts and MaxElapsed per provider key (e.g. "claude", "codex").
+	Providers map[string]RetryBackoffPolicy `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// RetryBackoffPolicy overrides the retry budget for one provider. Zero values inherit the global setting.
+type RetryBackoffPolicy struct {
+	MaxAttempts int `yaml:"max-attempts,omitempty" json:"max-attempts,omitempty"`
+	MaxElapsed  int `yaml:"max-elapsed,omitempty" json:"max-elapsed,omitempty"`
+}
+
+// CircuitBreakerConfig configures the per-credential circuit breaker. Zero values select the
+// documented defaults.
+type CircuitBreakerConfig struct {
+	// Enabled turns the circuit breaker on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// FailureThreshold is the number of consecutive upstream failures (auth errors, timeouts,
+	// 5xx, overload, connection errors) that opens a credential's circuit. Default: 5.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+
+	// Cooldown is the number of seconds an open circuit rejects the credential before one
+	// probe request is let through. Default: 30.
+	Cooldown int `yaml:"cooldown,omitempty" json:"cooldown,omitempty"`
+}
+
+// UpstreamProbeConfig configures the periodic upstream health probe. Zero values select the
+// documented defaults.
+type UpstreamProbeConfig struct {
+	// Enabled turns the probe on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Interval is the number of seconds between probe rounds. Default: 300.
+	Interval int `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// Timeout is the number of seconds one probe request may take. Default: 30.
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// Models maps a provider (e.g. "claude") to the model used to probe its credentials. By
+	// default the first model registered for the credential is used.
+	Models map[string]string `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// HedgedRequestsConfig configures hedged requests: when the first upstream attempt has not
+// answered after Delay, the same translated request goes to a second credential, the first
+// response wins and the other attempt is cancelled. Both attempts count against quota.
+type HedgedRequestsConfig struct {
+	// Enabled turns hedging on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Delay is the number of milliseconds to wait for the first attempt before the second one
+	// is sent. Default: 2000.
+	Delay int `yaml:"delay-ms,omitempty" json:"delay-ms,omitempty"`
+
+	// Models lists model names or wildcard patterns (e.g., "claude-sonnet-*"); empty matches any
+	// model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// Matches reports whether requests for model are hedged. Model patterns match
+// case-insensitively, with or without the model's thinking suffix.
+func (h *HedgedRequestsConfig) Matches(model string) bool {
+	if !h.Enabled {
+		return false
+	}
+	if len(h.Models) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	return matchesAnyPattern(h.Models, model) || matchesAnyPattern(h.Models, thinking.ParseSuffix(model).ModelName)
+}
+
+// SignatureCacheStoreConfig configures the signature cache persistence backend.
+type SignatureCacheStoreConfig struct {
+	// Type selects the backend: "memory" (default, lost on restart), "file"
+	// (JSON snapshot at Path, reloaded on startup) or "redis" (shared across replicas).
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+
+	// Path is the snapshot file used by the "file" backend.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// RedisAddr is the host:port of the Redis server used by the "redis" backend.
+	RedisAddr string `yaml:"redis-addr,omitempty" json:"redis-addr,omitempty"`
+
+	// RedisPassword authenticates against the Redis server when set.
+	RedisPassword string `yaml:"redis-password,omitempty" json:"redis-password,omitempty"`
+
+	// RedisDB selects the Redis logical database.
+	RedisDB int `yaml:"redis-db,omitempty" json:"redis-db,omitempty"`
+
+	// RedisKeyPrefix namespaces signature keys in Redis. Default: "cliproxy:signature:".
+	RedisKeyPrefix string `yaml:"redis-key-prefix,omitempty" json:"redis-key-prefix,omitempty"`
+
+	// RedisTimeout bounds, in seconds, dialing Redis and each command sent to it. Default: 5.
+	RedisTimeout int `yaml:"redis-timeout,omitempty" json:"redis-timeout,omitempty"`
+
+	// CleanupInterval is how often, in seconds, the "memory" and "file" backends sweep
+	// expired entries. Default: 600.
+	CleanupInterval int `yaml:"cleanup-interval,omitempty" json:"cleanup-interval,omitempty"`
+
+	// MaxEntries caps the entries held by the "memory" and "file" backends, evicting the least
+	// recently used ones beyond it. 0 means unlimited.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// EncryptionKey enables AES-GCM encryption of cached signatures, with a key derived from
+	// this secret, for every backend. Falls back to the SIGNATURE_CACHE_ENCRYPTION_KEY
+	// environment variable.
+	EncryptionKey string `yaml:"encryption-key,omitempty" json:"encryption-key,omitempty"`
+}
+
+// ClaudeRequestConfig configures normalization applied to translated Claude requests
+// before they are sent upstream.
+type ClaudeRequestConfig struct {
+	// DefaultToolChoice sets tool_choice when tools are present and the client specified none.
+	// Supported values: "" (default, leave unset so Claude decides), "auto", "none".
+	DefaultToolChoice string `yaml:"default-tool-choice,omitempty" json:"default-tool-choice,omitempty"`
+
+	// TemperatureMode controls how temperatures from 0-2 range formats (OpenAI, Gemini)
+	// are mapped onto Anthropic's 0-1 range.
+	// Supported values: "" or "passthrough" (default, copy as-is), "clamp" (cap at 1), "scale" (divide by 2).
+	TemperatureMode string `yaml:"temperature-mode,omitempty" json:"temperature-mode,omitempty"`
+
+	// KeepSamplingWithThinking preserves top_p/top_k when thinking is enabled.
+	// By default they are removed because Anthropic rejects them alongside extended thinking.
+	KeepSamplingWithThinking bool `yaml:"keep-sampling-with-thinking,omitempty" json:"keep-sampling-with-thinking,omitempty"`
+
+	// ThinkingBudgetMaxRatio caps thinking.budget_tokens at this fraction of max_tokens,
+	// reserving the rest of the output allowance for the answer (e.g. 0.5 for 50%).
+	// 0 (default) disables the cap; valid values are in (0, 1).
+	ThinkingBudgetMaxRatio float64 `yaml:"thinking-budget-max-ratio,omitempty" json:"thinking-budget-max-ratio,omitempty"`
+
+	// MaxTokens chooses max_tokens per model and client API key. By default the client's
+	// value is kept.
+	MaxTokens MaxTokensPolicy `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+
+	// Betas tunes the anthropic-beta header: the flags sent for request features and
+	// per-model additions and removals.
+	Betas ClaudeBetasPolicy `yaml:"betas,omitempty" json:"betas,omitempty"`
+
+	// NonStream controls how non-streaming client requests are executed against Claude.
+	NonStream ClaudeNonStreamConfig `yaml:"non-stream,omitempty" json:"non-stream,omitempty"`
+
+	// DisabledOpenAIStages lists OpenAI Chat Completions → Claude conversion stages to skip:
+	// "params", "messages", "tools", "thinking" and "cache_control".
+	DisabledOpenAIStages []string `yaml:"disabled-openai-stages,omitempty" json:"disabled-openai-stages,omitempty"`
+}
+
+// ClaudeNonStreamConfig controls the execution of non-streaming client requests. Requests
+// translated from other formats stream from the upstream by default and the response is
+// assembled server-side; the limits below guard that assembly.
+type ClaudeNonStreamConfig struct {
+	// Upstream is "stream" (default) to stream from the upstream and assemble the response, or
+	// "non-stream" to use the upstream non-streaming API. Native Claude requests always use the
+	// non-streaming API.
+	Upstream string `yaml:"upstream,omitempty" json:"upstream,omitempty"`
+
+	// IdleTimeoutSeconds aborts an assembled stream that received no data for this long.
+	// 0 (default) disables the watchdog.
+	IdleTimeoutSeconds int `yaml:"idle-timeout-seconds,omitempty" json:"idle-timeout-seconds,omitempty"`
+
+	// MaxDurationSeconds aborts an assembled stream still running after this long. 0 (default)
+	// disables the limit.
+	MaxDurationSeconds int `yaml:"max-duration-seconds,omitempty" json:"max-duration-seconds,omitempty"`
+
+	// MaxResponseBytes fails a request whose upstream response grows beyond this size.
+	// 0 (default) leaves it unbounded.
+	MaxResponseBytes int64 `yaml:"max-response-bytes,omitempty" json:"max-response-bytes,omitempty"`
+
+	// ProgressLogSeconds logs the progress of assembled streams at this interval. 0 (default)
+	// disables progress logging.
+	ProgressLogSeconds int `yaml:"progress-log-seconds,omitempty" json:"progress-log-seconds,omitempty"`
+}
+
+// Claude non-stream upstream modes.
+const (
+	ClaudeNonStreamUpstreamStream    = "stream"
+	ClaudeNonStreamUpstreamNonStream = "non-stream"
+)
+
+// ClaudeBetaFeatureFlags maps the request features detected in Claude requests to the
+// anthropic-beta flag each one needs.
+var ClaudeBetaFeatureFlags = map[string]string{
+	"fast-mode":            "fast-mode-2026-02-01",
+	"extended-cache-ttl":   "extended-cache-ttl-2025-04-11",
+	"interleaved-thinking": "interleaved-thinking-2025-05-14",
+	"context-management":   "context-management-2025-06-27",
+}
+
+// ClaudeBetasPolicy configures the anthropic-beta header sent to Claude upstreams.
+type ClaudeBetasPolicy struct {
+	// Features overrides the flag sent for a feature of ClaudeBetaFeatureFlags ("fast-mode",
+	// "extended-cache-ttl", "interleaved-thinking", "context-management"). An empty flag stops
+	// adding a beta for the feature.
+	Features map[string]string `yaml:"features,omitempty" json:"features,omitempty"`
+
+	// Rules add and remove flags for matching models, in order, after all other betas are merged.
+	Rules []ClaudeBetaRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// ClaudeBetaRule adds and removes anthropic-beta flags for matching models.
+type ClaudeBetaRule struct {
+	// Models lists model names or wildcard patterns (e.g., "claude-opus-*"); empty matches any model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// Add lists flags to send.
+	Add []string `yaml:"add,omitempty" json:"add,omitempty"`
+	// Remove lists flags to drop, including ones sent by the client.
+	Remove []string `yaml:"remove,omitempty" json:"remove,omitempty"`
+}
+
+// MaxTokensPolicy chooses the max_tokens sent to Claude upstreams.
+type MaxTokensPolicy struct {
+	// Mode is one of:
+	//   - "honor-client" or "" (default): keep the client's value.
+	//   - "clamp-to-model-max": cap the client's value at the model maximum from the model
+	//     registry, using that maximum when the client sent none.
+	//   - "fixed": always send Value.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// Value is the max_tokens used by "fixed" and, in the other modes, when the client sent
+	// none. 0 keeps the translator default.
+	Value int `yaml:"value,omitempty" json:"value,omitempty"`
+
+	// Rules override Mode and Value for matching models and client API keys (first match wins).
+	Rules []MaxTokensRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// MaxTokensRule overrides the max_tokens policy for matching requests.
+type MaxTokensRule struct {
+	// Models lists model names or wildcard patterns (e.g., "claude-opus-*"); empty matches any model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// APIKeys lists client API keys; empty matches any key.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+	// Mode and Value have the same meaning as in MaxTokensPolicy.
+	Mode  string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Value int    `yaml:"value,omitempty" json:"value,omitempty"`
+}
+
+// PromptCacheConfig configures automatic cache_control breakpoints injected into Claude
+// requests that carry none from the client.
+type PromptCacheConfig struct {
+	// Strategy selects the breakpoint placement:
+	//   - "default" or "": last tool, last system block and the second-to-last user turn.
+	//   - "system-only": last system block only (falls back to the last tool without a system prompt).
+	//   - "rolling-window": last tool, last system block and the last two user turns.
+	//   - "disabled": never inject breakpoints.
+	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// Breakpoints caps the number of injected breakpoints (1-4). 0 uses Anthropic's limit of 4.
+	Breakpoints int `yaml:"breakpoints,omitempty" json:"breakpoints,omitempty"`
+
+	// MinContentLength skips breakpoints whose cached prefix is shorter than this many
+	// characters, avoiding cache writes below the minimum cacheable size. 0 disables the check.
+	MinContentLength int `yaml:"min-content-length,omitempty" json:"min-content-length,omitempty"`
+
+	// TTL sets the cache lifetime for breakpoints that do not specify one:
+	// "" or "5m" (default, Anthropic's 5 minute cache) or "1h" (extended cache, sends the
+	// extended-cache-ttl beta header). A model suffix like "claude-sonnet-4-5(cache:1h)"
+	// overrides it per request.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// Models overrides the strategy for matching models (first match wins).
+	Models []PromptCacheModelRule `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// ThinkingSettings configures thinking defaults applied by the proxy.
+type ThinkingSettings struct {
+	// Defaults maps model names to the thinking config applied when neither a model suffix
+	// nor the request body sets one.
+	Defaults map[string]ThinkingDefault `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+}
+
+// ThinkingDefault is the thinking config applied to one model by default.
+type ThinkingDefault struct {
+	// Mode is "none", "auto", "budget" or "level". When empty it is inferred from Effort or Budget.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// Effort is the thinking level (e.g., "low", "high", "max"). With mode "auto" it selects
+	// the adaptive thinking effort.
+	Effort string `yaml:"effort,omitempty" json:"effort,omitempty"`
+	// Budget is the thinking token budget used by mode "budget".
+	Budget int `yaml:"budget,omitempty" json:"budget,omitempty"`
+}
+
+// PromptCacheModelRule overrides the prompt cache strategy for models matching Name.
+type PromptCacheModelRule struct {
+	// Name is the model name or wildcard pattern (e.g., "claude-haiku-*").
+	Name string `yaml:"name" json:"name"`
+	// Strategy is the strategy applied to matching models.
+	Strategy string `yaml:"strategy" json:"strategy"`
+	// TTL overrides the cache lifetime for matching models ("5m" or "1h").
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+}
+
+// ClaudeResponseConfig configures how Claude responses are translated to the OpenAI
+// Chat Completions format.
+type ClaudeResponseConfig struct {
+	// ToolCallContent selects message.content for assistant turns that contain tool calls.
+	// Supported values: "" or "text" (default, the text emitted before the tool calls, or ""),
+	// "null" (content is null when the turn has tool calls but no text).
+	ToolCallContent string `yaml:"tool-call-content,omitempty" json:"tool-call-content,omitempty"`
+
+	// CreatedSource selects the source of the `created` timestamp.
+	// Supported values: "" or "local" (default, proxy clock), "upstream" (upstream Date header
+	// when available, falling back to the proxy clock).
+	CreatedSource string `yaml:"created-source,omitempty" json:"created-source,omitempty"`
+
+	// ToolCallStreaming selects how streamed tool calls are emitted.
+	// Supported values: "" or "buffered" (default, one chunk per complete tool call),
+	// "incremental" (function.arguments fragments as they arrive). Requests may override it
+	// with a top-level "tool_call_streaming" field.
+	ToolCallStreaming string `yaml:"tool-call-streaming,omitempty" json:"tool-call-streaming,omitempty"`
+
+	// ThinkingOutput selects where Claude thinking is emitted.
+	// Supported values: "" (default, delta.reasoning_content when streaming and message.reasoning
+	// otherwise), "reasoning_content", "reasoning", "hidden". Requests may override it with a
+	// top-level "thinking_output" field.
+	ThinkingOutput string `yaml:"thinking-output,omitempty" json:"thinking-output,omitempty"`
+}
+
+// QuotaLimits bounds the usage of a client API key. Zero values mean unlimited.
+// Daily limits reset at midnight and monthly limits on the first of the month, in UTC unless
+// usage-statistics-daily-reset is "local".
+type QuotaLimits struct {
+	DailyRequests   int64 `yaml:"daily-requests,omitempty" json:"daily-requests,omitempty"`
+	MonthlyRequests int64 `yaml:"monthly-requests,omitempty" json:"monthly-requests,omitempty"`
+	DailyTokens     int64 `yaml:"daily-tokens,omitempty" json:"daily-tokens,omitempty"`
+	MonthlyTokens   int64 `yaml:"monthly-tokens,omitempty" json:"monthly-tokens,omitempty"`
+	// MonthlyBudget is the monthly spend limit in USD, priced with APIKeyQuotaConfig.ModelPrices.
+	MonthlyBudget float64 `yaml:"monthly-budget,omitempty" json:"monthly-budget,omitempty"`
+}
+
+// APIKeyQuotaEntry overrides the default quota for one client API key.
+type APIKeyQuotaEntry struct {
+	APIKey      string `yaml:"api-key" json:"api-key"`
+	QuotaLimits `yaml:",inline"`
+}
+
+// ModelPrice is the USD price per million tokens for models matching Model.
+type ModelPrice struct {
+	// Model is an exact model name, or a prefix when it ends with "*".
+	Model  string  `yaml:"model" json:"model"`
+	Input  float64 `yaml:"input" json:"input"`
+	Output float64 `yaml:"output" json:"output"`
+	// CachedInput prices cache reads; defaults to Input.
+	CachedInput float64 `yaml:"cached-input,omitempty" json:"cached-input,omitempty"`
+	// CacheWrite prices prompt cache writes; defaults to Input.
+	CacheWrite float64 `yaml:"cache-write,omitempty" json:"cache-write,omitempty"`
+}
+
+// APIKeyQuotaConfig configures per-client-API-key quotas. Requests from a key whose quota is
+// exhausted are rejected with 429 until the period resets.
+type APIKeyQuotaConfig struct {
+	// Default applies to every client API key without an entry in Keys.
+	Default QuotaLimits `yaml:"default,omitempty" json:"default,omitempty"`
+	// Keys overrides Default for specific client API keys.
+	Keys []APIKeyQuotaEntry `yaml:"keys,omitempty" json:"keys,omitempty"`
+	// ModelPrices prices token usage for monthly-budget, replacing the top-level model-prices.
+	// Unpriced models cost nothing.
+	ModelPrices []ModelPrice `yaml:"model-prices,omitempty" json:"model-prices,omitempty"`
+}
+
+// ResponseCacheConfig configures the cache of non-streaming responses. Requests are cached when
+// they set temperature 0 or send "X-Proxy-Cache: true"; "X-Proxy-Cache: false" bypasses the cache.
+type ResponseCacheConfig struct {
+	// Enabled turns the response cache on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Type selects the backend: "memory" (default) or "redis" (shared across replicas).
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// TTL is how long, in seconds, a response stays cached. Default: 300.
+	TTL int `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	// MaxEntries caps the responses held by the "memory" backend. Default: 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// MaxEntryBytes skips responses larger than this. Default: 1048576; a negative value disables the limit.
+	MaxEntryBytes int `yaml:"max-entry-bytes,omitempty" json:"max-entry-bytes,omitempty"`
+	// RedisAddr is the host:port of the Redis server used by the "redis" backend.
+	RedisAddr string `yaml:"redis-addr,omitempty" json:"redis-addr,omitempty"`
+	// RedisPassword authenticates against the Redis server when set.
+	RedisPassword string `yaml:"redis-password,omitempty" json:"redis-password,omitempty"`
+	// RedisDB selects the Redis logical database.
+	RedisDB int `yaml:"redis-db,omitempty" json:"redis-db,omitempty"`
+	// RedisKeyPrefix namespaces response keys in Redis. Default: "cliproxy:response:".
+	RedisKeyPrefix string `yaml:"redis-key-prefix,omitempty" json:"redis-key-prefix,omitempty"`
+}
+
+// RateLimitConfig configures the token-bucket rate limiter applied to client requests. Each
+// client may burst up to a full minute of its limits; buckets refill continuously.
+type RateLimitConfig struct {
+	// Enabled turns rate limiting on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// KeyBy selects what identifies a client: "api-key" (default; unauthenticated requests fall
+	// back to the client IP), "ip" or "api-key+ip".
+	KeyBy string `yaml:"key-by,omitempty" json:"key-by,omitempty"`
+	// RequestsPerMinute caps requests per client. 0 disables the request limit.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+	// TokensPerMinute caps estimated tokens per client: the request body size in bytes / 4 plus
+	// the requested maximum output tokens. 0 disables the token limit.
+	TokensPerMinute int `yaml:"tokens-per-minute,omitempty" json:"tokens-per-minute,omitempty"`
+	// Type selects the bucket store: "memory" (default) or "redis" (shared across replicas).
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// RedisAddr is the host:port of the Redis server used by the "redis" store.
+	RedisAddr string `yaml:"redis-addr,omitempty" json:"redis-addr,omitempty"`
+	// RedisPassword authenticates against the Redis server when set.
+	RedisPassword string `yaml:"redis-password,omitempty" json:"redis-password,omitempty"`
+	// RedisDB selects the Redis logical database.
+	RedisDB int `yaml:"redis-db,omitempty" json:"redis-db,omitempty"`
+	// RedisKeyPrefix namespaces bucket keys in Redis. Default: "cliproxy:ratelimit:".
+	RedisKeyPrefix string `yaml:"redis-key-prefix,omitempty" json:"redis-key-prefix,omitempty"`
+}
+
+// Built-in request scheduler priority classes.
+const (
+	SchedulerClassInteractive = "interactive"
+	SchedulerClassBatch       = "batch"
+)
+
+// RequestSchedulerConfig configures the concurrency limits and weighted fair queueing of upstream
+// requests.
+type RequestSchedulerConfig struct {
+	// Enabled turns the scheduler on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxConcurrent caps upstream requests in flight across all clients. 0 disables the cap.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+	// MaxConcurrentPerKey caps upstream requests in flight per client API key. 0 disables the cap.
+	MaxConcurrentPerKey int `yaml:"max-concurrent-per-key,omitempty" json:"max-concurrent-per-key,omitempty"`
+	// MaxQueueWaitSeconds rejects requests that waited longer for a slot with 429. 0 waits until
+	// the client gives up.
+	MaxQueueWaitSeconds int `yaml:"max-queue-wait-seconds,omitempty" json:"max-queue-wait-seconds,omitempty"`
+	// Classes maps priority class names to weights; a class with weight 8 is served eight times
+	// as often as one with weight 1 while requests queue. Default: interactive 8, batch 1.
+	Classes map[string]int `yaml:"classes,omitempty" json:"classes,omitempty"`
+	// DefaultClass is the class of keys without an entry in Keys. Default: "interactive".
+	DefaultClass string `yaml:"default-class,omitempty" json:"default-class,omitempty"`
+	// Keys assigns classes and concurrency caps to client API keys.
+	Keys []RequestSchedulerKey `yaml:"keys,omitempty" json:"keys,omitempty"`
+}
+
+// RequestSchedulerKey is the scheduling policy of one client API key.
+type RequestSchedulerKey struct {
+	APIKey string `yaml:"api-key" json:"api-key"`
+	// Class is the priority class of the key.
+	Class string `yaml:"class,omitempty" json:"class,omitempty"`
+	// MaxConcurrent overrides max-concurrent-per-key for this key when positive.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+}
+
+// ImageFetchConfig configures downloading of remote image URLs sent by clients.
+type ImageFetchConfig struct {
+	// Enabled turns remote image fetching on. Default is false.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MaxBytes rejects images larger than this. Default: 5242880.
+	MaxBytes int64 `yaml:"max-bytes,omitempty" json:"max-bytes,omitempty"`
+	// Timeout is the per-image download timeout in seconds. Default: 10.
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// AllowedHosts limits fetching to these hosts and their subdomains; empty allows any public host.
+	AllowedHosts []string `yaml:"allowed-hosts,omitempty" json:"allowed-hosts,omitempty"`
+	// AllowPrivateNetworks permits URLs resolving to loopback, private or link-local addresses.
+	AllowPrivateNetworks bool `yaml:"allow-private-networks,omitempty" json:"allow-private-networks,omitempty"`
+}
+
+// AudioTranscriptionConfig configures the OpenAI-compatible speech-to-text backend. It is active
+// when BaseURL or APIKey is set.
+type AudioTranscriptionConfig struct {
+	// BaseURL is the API root serving /audio/transcriptions. Default: https://api.openai.com/v1.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+	// APIKey authenticates against the backend.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+	// Model is the transcription model. Default: whisper-1.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+	// Language is an optional ISO-639-1 hint for the backend.
+	Language string `yaml:"language,omitempty" json:"language,omitempty"`
+	// Timeout is the per-call timeout in seconds. Default: 60.
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// ModerationConfig configures the pre-flight content moderation stage.
+type ModerationConfig struct {
+	// Enabled turns moderation on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Action is "reject" (default) to refuse flagged requests or "flag" to only record them.
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+	// FailOpen lets requests through when a moderation backend fails. Default: false.
+	FailOpen bool `yaml:"fail-open,omitempty" json:"fail-open,omitempty"`
+	// Keywords flag prompts containing any of them (case-insensitive).
+	Keywords []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+	// Patterns flag prompts matching any of these regular expressions.
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+	// OpenAI enables the OpenAI moderation API backend when its api-key is set.
+	OpenAI ModerationOpenAIConfig `yaml:"openai,omitempty" json:"openai,omitempty"`
+}
+
+// ModerationOpenAIConfig configures the OpenAI moderation API backend.
+type ModerationOpenAIConfig struct {
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+	// BaseURL defaults to https://api.openai.com/v1.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+	// Model defaults to omni-moderation-latest.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+	// Categories limits flagging to these categories; empty uses the API's flagged verdict.
+	Categories []string `yaml:"categories,omitempty" json:"categories,omitempty"`
+	// Timeout is the moderation call timeout in seconds. Default: 10.
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// PIIScrubberConfig configures the PII scrubbing stage.
+type PIIScrubberConfig struct {
+	// Enabled turns scrubbing on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Detectors selects the built-in detectors: "email", "phone" and "credit-card". Empty
+	// enables all of them.
+	Detectors []string `yaml:"detectors,omitempty" json:"detectors,omitempty"`
+	// Patterns adds detectors for custom regular expressions.
+	Patterns []PIIPattern `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+}
+
+// PIIPattern is a custom PII detector.
+type PIIPattern struct {
+	// Name labels the tokens that replace matches, e.g. "employee-id" issues [PII_EMPLOYEE_ID_1].
+	Name string `yaml:"name" json:"name"`
+	// Pattern is the regular expression matching the data.
+	Pattern string `yaml:"pattern" json:"pattern"`
+}
+
 // CloakConfig configures request cloaking for non-Claude-Code clients.
 // Cloaking disguises API requests to appear as originating from the official Claude Code CLI.
 type CloakConfig struct {
@@ -543,6 +1387,36 @@ type OpenAICompatibility struct {
 
 	// Headers optionally adds extra HTTP headers for requests sent to this provider.
 	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Azure switches the provider to Azure OpenAI request conventions when set.
+	Azure *AzureOpenAIConfig `yaml:"azure,omitempty" json:"azure,omitempty"`
+}
+
+// DefaultAzureOpenAIAPIVersion is the Azure OpenAI API version used when none is configured.
+const DefaultAzureOpenAIAPIVersion = "2024-10-21"
+
+// AzureOpenAIConfig describes an Azure OpenAI resource behind an OpenAI compatibility provider.
+// Requests use the deployment routes of the resource, authenticate with the api-key header
+// and carry the api-version query parameter.
+type AzureOpenAIConfig struct {
+	// APIVersion is the api-version query parameter; defaults to DefaultAzureOpenAIAPIVersion.
+	APIVersion string `yaml:"api-version,omitempty" json:"api-version,omitempty"`
+
+	// Deployments maps upstream model names to deployment names. Models without an entry
+	// use their name as the deployment name.
+	Deployments map[string]string `yaml:"deployments,omitempty" json:"deployments,omitempty"`
+}
+
+// Deployment returns the deployment serving the given upstream model name.
+func (a *AzureOpenAIConfig) Deployment(model string) string {
+	if a != nil {
+		for name, deployment := range a.Deployments {
+			if strings.EqualFold(name, model) {
+				return deployment
+			}
+		}
+	}
+	return model
 }
 
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
@@ -613,6 +1487,7 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	cfg.LoggingToFile = false
 	cfg.LogsMaxTotalSizeMB = 0
 	cfg.ErrorLogsMaxFiles = 10
+	cfg.ShutdownDrainTimeout = DefaultShutdownDrainTimeout
 	cfg.UsageStatisticsEnabled = false
 	cfg.RedisUsageQueueRetentionSeconds = 60
 	cfg.DisableCooling = false
@@ -673,10 +1548,18 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		cfg.LogsMaxTotalSizeMB = 0
 	}
 
+	// Normalize log rotation and request log retention settings.
+	cfg.SanitizeLogRotation()
+
 	if cfg.ErrorLogsMaxFiles < 0 {
 		cfg.ErrorLogsMaxFiles = 10
 	}
 
+	if cfg.ShutdownDrainTimeout < 0 {
+		log.WithField("value", cfg.ShutdownDrainTimeout).Warn("shutdown-drain-timeout is negative; closing connections immediately on shutdown")
+		cfg.ShutdownDrainTimeout = 0
+	}
+
 	if cfg.RedisUsageQueueRetentionSeconds <= 0 {
 		cfg.RedisUsageQueueRetentionSeconds = 60
 	} else if cfg.RedisUsageQueueRetentionSeconds > 3600 {
@@ -688,12 +1571,53 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		cfg.MaxRetryCredentials = 0
 	}
 
+	if cfg.SignatureCacheMaxThinkingBytes < 0 {
+		cfg.SignatureCacheMaxThinkingBytes = 0
+	}
+	cfg.SanitizeSignatureCacheStore()
+	cfg.SanitizeResponseCache()
+	cfg.SanitizeRateLimit()
+	cfg.SanitizeRequestScheduler()
+	cfg.SanitizeTLS()
+	cfg.SanitizeIPAccess()
+	cfg.SanitizeUsageStore()
+	cfg.SanitizeUsageWebhooks()
+	cfg.SanitizeUsageReport()
+
+	// Drop invalid redaction rules.
+	cfg.SanitizeRedact()
+
+	// Normalize retry backoff limits and provider keys.
+	cfg.SanitizeRetryBackoff()
+
+	// Clamp circuit breaker limits.
+	cfg.CircuitBreaker.FailureThreshold = max(cfg.CircuitBreaker.FailureThreshold, 0)
+	cfg.CircuitBreaker.Cooldown = max(cfg.CircuitBreaker.Cooldown, 0)
+
+	// Clamp the hedge delay.
+	cfg.HedgedRequests.Delay = max(cfg.HedgedRequests.Delay, 0)
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
 	// Sanitize Vertex-compatible API keys.
 	cfg.SanitizeVertexCompatKeys()
 
+	// Sanitize Bedrock credentials.
+	cfg.SanitizeBedrockKeys()
+
+	// Sanitize OpenRouter keys.
+	cfg.SanitizeOpenRouterKeys()
+
+	// Sanitize Ollama servers.
+	cfg.SanitizeOllama()
+
+	// Sanitize external providers.
+	cfg.SanitizeExternalProviders()
+
+	// Sanitize Vertex Claude routing.
+	cfg.SanitizeVertexClaude()
+
 	// Sanitize Codex keys: drop entries without base-url
 	cfg.SanitizeCodexKeys()
 
@@ -706,6 +1630,42 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Claude key headers
 	cfg.SanitizeClaudeKeys()
 
+	// Normalize Claude request policy values.
+	cfg.SanitizeClaudeRequest()
+
+	// Normalize Claude response rendering values.
+	cfg.SanitizeClaudeResponse()
+
+	// Normalize prompt cache strategy values.
+	cfg.SanitizePromptCache()
+
+	// Drop invalid per-model thinking defaults.
+	cfg.SanitizeThinking()
+
+	// Normalize model fallback chains.
+	cfg.SanitizeModelFallbacks()
+	cfg.SanitizeUnsupportedParams()
+	cfg.SanitizeContextOverflow()
+	cfg.SanitizeAutoCompact()
+	cfg.SanitizeSystemPromptRules()
+
+	// Normalize proxy keys and assign missing IDs.
+	cfg.SanitizeProxyKeys()
+
+	// Drop invalid API key quota entries.
+	cfg.SanitizeModelPrices()
+	cfg.SanitizeAPIKeyQuota()
+
+	// Normalize moderation settings and drop invalid patterns.
+	cfg.SanitizeModeration()
+	cfg.SanitizePIIScrubber()
+
+	// Normalize audio transcription settings.
+	cfg.SanitizeAudioTranscription()
+
+	// Normalize remote image fetching settings.
+	cfg.SanitizeImageFetch()
+
 	// Sanitize OpenAI compatibility providers: drop entries without base-url
 	cfg.SanitizeOpenAICompatibility()
 
@@ -715,6 +1675,12 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize global OAuth model name aliases.
 	cfg.SanitizeOAuthModelAlias()
 
+	// Normalize per-account OAuth proxies.
+	cfg.SanitizeOAuthProxies()
+
+	// Normalize model catalog overrides.
+	cfg.ModelOverrides = NormalizeModelOverrides(cfg.ModelOverrides)
+
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
@@ -816,62 +1782,1174 @@ func (cfg *Config) SanitizeClaudeHeaderDefaults() {
 	cfg.ClaudeHeaderDefaults.Timeout = strings.TrimSpace(cfg.ClaudeHeaderDefaults.Timeout)
 }
 
-// SanitizeOAuthModelAlias normalizes and deduplicates global OAuth model name aliases.
-// It trims whitespace, normalizes channel keys to lower-case, drops empty entries,
-// allows multiple aliases per upstream name, and ensures aliases are unique within each channel.
-func (cfg *Config) SanitizeOAuthModelAlias() {
-	if cfg == nil || len(cfg.OAuthModelAlias) == 0 {
+// SanitizeUsageStore normalizes the usage row store selection.
+// Unknown backends and a file backend without a path disable the store.
+func (cfg *Config) SanitizeUsageStore() {
+	if cfg == nil {
 		return
 	}
-	out := make(map[string][]OAuthModelAlias, len(cfg.OAuthModelAlias))
-	for rawChannel, aliases := range cfg.OAuthModelAlias {
-		channel := strings.ToLower(strings.TrimSpace(rawChannel))
-		if channel == "" || len(aliases) == 0 {
+	store := &cfg.UsageStore
+	store.Type = strings.ToLower(strings.TrimSpace(store.Type))
+	store.Path = strings.TrimSpace(store.Path)
+	store.RetentionDays = max(store.RetentionDays, 0)
+	store.MaxRows = max(store.MaxRows, 0)
+	switch store.Type {
+	case "", "memory":
+	case "file":
+		if store.Path == "" {
+			log.Warn("usage-store.path is required for the file backend; disabling usage store")
+			store.Type = ""
+		}
+	default:
+		log.WithField("value", store.Type).Warn("usage-store.type is invalid; disabling usage store")
+		store.Type = ""
+	}
+}
+
+// SanitizeUsageReport validates the report schedule and applies defaults.
+func (cfg *Config) SanitizeUsageReport() {
+	if cfg == nil {
+		return
+	}
+	report := &cfg.UsageReport
+	report.Time = strings.TrimSpace(report.Time)
+	if report.Time == "" {
+		report.Time = DefaultUsageReportTime
+	} else if _, errParse := time.Parse("15:04", report.Time); errParse != nil {
+		log.WithField("value", report.Time).Warnf("usage-report.time is invalid; using %s", DefaultUsageReportTime)
+		report.Time = DefaultUsageReportTime
+	}
+	if report.TopN <= 0 {
+		report.TopN = DefaultUsageReportTopN
+	}
+	report.SlackWebhookURL = strings.TrimSpace(report.SlackWebhookURL)
+	email := &report.Email
+	email.Host = strings.TrimSpace(email.Host)
+	if email.Port <= 0 {
+		email.Port = 587
+	}
+	email.From = strings.TrimSpace(email.From)
+	to := make([]string, 0, len(email.To))
+	for _, addr := range email.To {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	email.To = to
+	if email.Subject == "" {
+		email.Subject = "Usage report {{.Date}}"
+	}
+}
+
+// SanitizeUsageWebhooks trims webhook endpoints, drops entries without a URL and applies defaults.
+func (cfg *Config) SanitizeUsageWebhooks() {
+	if cfg == nil || len(cfg.UsageWebhooks) == 0 {
+		return
+	}
+	out := make([]UsageWebhook, 0, len(cfg.UsageWebhooks))
+	for _, hook := range cfg.UsageWebhooks {
+		hook.URL = strings.TrimSpace(hook.URL)
+		if hook.URL == "" {
+			log.Warn("usage-webhooks entry without url; ignoring")
 			continue
 		}
-		seenAlias := make(map[string]struct{}, len(aliases))
-		clean := make([]OAuthModelAlias, 0, len(aliases))
-		for _, entry := range aliases {
-			name := strings.TrimSpace(entry.Name)
-			alias := strings.TrimSpace(entry.Alias)
-			if name == "" || alias == "" {
-				continue
-			}
-			if strings.EqualFold(name, alias) {
-				continue
-			}
-			aliasKey := strings.ToLower(alias)
-			if _, ok := seenAlias[aliasKey]; ok {
-				continue
-			}
-			seenAlias[aliasKey] = struct{}{}
-			clean = append(clean, OAuthModelAlias{Name: name, Alias: alias, Fork: entry.Fork})
+		hook.Secret = strings.TrimSpace(hook.Secret)
+		hook.Headers = NormalizeHeaders(hook.Headers)
+		if hook.BatchSize <= 0 {
+			hook.BatchSize = DefaultUsageWebhookBatchSize
 		}
-		if len(clean) > 0 {
-			out[channel] = clean
+		if hook.FlushInterval <= 0 {
+			hook.FlushInterval = DefaultUsageWebhookFlushInterval
 		}
+		if hook.MaxRetries < 0 {
+			hook.MaxRetries = 0
+		}
+		if hook.QueueSize <= 0 {
+			hook.QueueSize = DefaultUsageWebhookQueueSize
+		}
+		if hook.Timeout <= 0 {
+			hook.Timeout = DefaultUsageWebhookTimeout
+		}
+		out = append(out, hook)
 	}
-	cfg.OAuthModelAlias = out
+	cfg.UsageWebhooks = out
 }
 
-// SanitizeOpenAICompatibility removes OpenAI-compatibility provider entries that are
-// not actionable, specifically those missing a BaseURL. It trims whitespace before
-// evaluation and preserves the relative order of remaining entries.
-func (cfg *Config) SanitizeOpenAICompatibility() {
-	if cfg == nil || len(cfg.OpenAICompatibility) == 0 {
+// SanitizeRedact trims redaction rules and drops empty entries and patterns that do not compile.
+func (cfg *Config) SanitizeRedact() {
+	if cfg == nil {
 		return
 	}
-	out := make([]OpenAICompatibility, 0, len(cfg.OpenAICompatibility))
-	for i := range cfg.OpenAICompatibility {
-		e := cfg.OpenAICompatibility[i]
-		e.Name = strings.TrimSpace(e.Name)
-		e.Prefix = normalizeModelPrefix(e.Prefix)
-		e.BaseURL = strings.TrimSpace(e.BaseURL)
+	redact := &cfg.Redact
+	redact.MaxBodyBytes = max(redact.MaxBodyBytes, 0)
+	headers := redact.Headers[:0]
+	for _, header := range redact.Headers {
+		if header = strings.ToLower(strings.TrimSpace(header)); header != "" {
+			headers = append(headers, header)
+		}
+	}
+	redact.Headers = headers
+	paths := redact.JSONPaths[:0]
+	for _, path := range redact.JSONPaths {
+		if path = strings.Trim(strings.TrimSpace(path), "."); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	redact.JSONPaths = paths
+	patterns := redact.Patterns[:0]
+	for _, pattern := range redact.Patterns {
+		if strings.TrimSpace(pattern.Pattern) == "" {
+			continue
+		}
+		if _, errCompile := regexp.Compile(pattern.Pattern); errCompile != nil {
+			log.WithField("value", pattern.Pattern).Warnf("redact.patterns entry is invalid; ignoring: %v", errCompile)
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	redact.Patterns = patterns
+}
+
+// SanitizeRetryBackoff clamps negative retry backoff limits to zero (the default) and
+// normalizes provider keys to lower case.
+func (cfg *Config) SanitizeRetryBackoff() {
+	if cfg == nil {
+		return
+	}
+	backoff := &cfg.RetryBackoff
+	backoff.MaxAttempts = max(backoff.MaxAttempts, 0)
+	backoff.MaxElapsed = max(backoff.MaxElapsed, 0)
+	backoff.BaseDelayMs = max(backoff.BaseDelayMs, 0)
+	backoff.MaxDelayMs = max(backoff.MaxDelayMs, 0)
+	if len(backoff.Providers) == 0 {
+		backoff.Providers = nil
+		return
+	}
+	providers := make(map[string]RetryBackoffPolicy, len(backoff.Providers))
+	for key, policy := range backoff.Providers {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			log.Warn("retry-backoff.providers contains an empty provider key; ignoring entry")
+			continue
+		}
+		policy.MaxAttempts = max(policy.MaxAttempts, 0)
+		policy.MaxElapsed = max(policy.MaxElapsed, 0)
+		providers[key] = policy
+	}
+	backoff.Providers = providers
+}
+
+// SanitizeRequestScheduler normalizes enabled request scheduler settings: class names are
+// lower-cased, weights below 1 become 1, the built-in interactive and batch classes are added
+// when no class is configured, and unknown classes fall back to the default class.
+func (cfg *Config) SanitizeRequestScheduler() {
+	if cfg == nil || !cfg.RequestScheduler.Enabled {
+		return
+	}
+	rs := &cfg.RequestScheduler
+	rs.MaxConcurrent = max(rs.MaxConcurrent, 0)
+	rs.MaxConcurrentPerKey = max(rs.MaxConcurrentPerKey, 0)
+	rs.MaxQueueWaitSeconds = max(rs.MaxQueueWaitSeconds, 0)
+	classes := make(map[string]int, len(rs.Classes))
+	for name, weight := range rs.Classes {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			classes[name] = max(weight, 1)
+		}
+	}
+	if len(classes) == 0 {
+		classes = map[string]int{SchedulerClassInteractive: 8, SchedulerClassBatch: 1}
+	}
+	rs.Classes = classes
+	rs.DefaultClass = strings.ToLower(strings.TrimSpace(rs.DefaultClass))
+	if rs.DefaultClass == "" {
+		rs.DefaultClass = SchedulerClassInteractive
+	}
+	if _, ok := rs.Classes[rs.DefaultClass]; !ok {
+		log.WithField("value", rs.DefaultClass).Warn("request-scheduler.default-class is not a configured class; giving it weight 1")
+		rs.Classes[rs.DefaultClass] = 1
+	}
+	keys := make([]RequestSchedulerKey, 0, len(rs.Keys))
+	for _, entry := range rs.Keys {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		entry.Class = strings.ToLower(strings.TrimSpace(entry.Class))
+		if _, ok := rs.Classes[entry.Class]; !ok {
+			if entry.Class != "" {
+				log.WithField("value", entry.Class).Warn("request-scheduler.keys class is not a configured class; using the default class")
+			}
+			entry.Class = rs.DefaultClass
+		}
+		entry.MaxConcurrent = max(entry.MaxConcurrent, 0)
+		keys = append(keys, entry)
+	}
+	rs.Keys = keys
+}
+
+// SanitizeRateLimit normalizes the rate limiter settings and falls back to the memory store when
+// the redis store is misconfigured.
+func (cfg *Config) SanitizeRateLimit() {
+	if cfg == nil {
+		return
+	}
+	rl := &cfg.RateLimit
+	rl.KeyBy = strings.ToLower(strings.TrimSpace(rl.KeyBy))
+	rl.Type = strings.ToLower(strings.TrimSpace(rl.Type))
+	rl.RedisAddr = strings.TrimSpace(rl.RedisAddr)
+	rl.RedisKeyPrefix = strings.TrimSpace(rl.RedisKeyPrefix)
+	rl.RedisDB = max(rl.RedisDB, 0)
+	rl.RequestsPerMinute = max(rl.RequestsPerMinute, 0)
+	rl.TokensPerMinute = max(rl.TokensPerMinute, 0)
+	switch rl.KeyBy {
+	case "", "api-key":
+		rl.KeyBy = "api-key"
+	case "ip", "api-key+ip":
+	default:
+		log.WithField("value", rl.KeyBy).Warn("rate-limit.key-by is invalid; using api-key")
+		rl.KeyBy = "api-key"
+	}
+	switch rl.Type {
+	case "", "memory":
+		rl.Type = "memory"
+	case "redis":
+		if rl.RedisAddr == "" {
+			log.Warn("rate-limit.redis-addr is required for the redis store; using memory")
+			rl.Type = "memory"
+		}
+	default:
+		log.WithField("value", rl.Type).Warn("rate-limit.type is invalid; using memory")
+		rl.Type = "memory"
+	}
+}
+
+// SanitizeLogRotation clamps negative log rotation values and applies the defaults.
+func (cfg *Config) SanitizeLogRotation() {
+	if cfg == nil {
+		return
+	}
+	r := &cfg.LogRotation
+	r.MaxSizeMB = max(r.MaxSizeMB, 0)
+	if r.MaxSizeMB == 0 {
+		r.MaxSizeMB = 10
+	}
+	r.RotateIntervalHours = max(r.RotateIntervalHours, 0)
+	r.MaxBackups = max(r.MaxBackups, 0)
+	r.CompressAfterMinutes = max(r.CompressAfterMinutes, 0)
+	if r.CompressAfterMinutes == 0 {
+		r.CompressAfterMinutes = 60
+	}
+	r.MaxAgeDays = max(r.MaxAgeDays, 0)
+	r.RequestLogsMaxFiles = max(r.RequestLogsMaxFiles, 0)
+}
+
+// SanitizeTLS normalizes the client certificate settings.
+func (cfg *Config) SanitizeTLS() {
+	if cfg == nil {
+		return
+	}
+	t := &cfg.TLS
+	t.ClientCA = strings.TrimSpace(t.ClientCA)
+	t.ClientAuth = strings.ToLower(strings.TrimSpace(t.ClientAuth))
+	switch t.ClientAuth {
+	case "", "require":
+		t.ClientAuth = "require"
+	case "verify-if-given":
+	default:
+		log.WithField("value", t.ClientAuth).Warn("tls.client-auth is invalid; using require")
+		t.ClientAuth = "require"
+	}
+	if t.ClientCA == "" {
+		t.ClientAuth = ""
+	}
+}
+
+// SanitizeIPAccess normalizes the IP access lists to CIDR notation and drops invalid entries.
+func (cfg *Config) SanitizeIPAccess() {
+	if cfg == nil {
+		return
+	}
+	ipAccess := &cfg.IPAccess
+	ipAccess.Allow = normalizeIPPrefixes("ip-access.allow", ipAccess.Allow)
+	ipAccess.Deny = normalizeIPPrefixes("ip-access.deny", ipAccess.Deny)
+	ipAccess.TrustedProxies = normalizeIPPrefixes("ip-access.trusted-proxies", ipAccess.TrustedProxies)
+}
+
+// normalizeIPPrefixes converts CIDRs and bare IPs to canonical CIDRs, dropping duplicates and
+// logging invalid entries.
+func normalizeIPPrefixes(field string, entries []string) []string {
+	if len(entries) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(entries))
+	out := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		trimmed := strings.TrimSpace(entry)
+		if trimmed == "" {
+			continue
+		}
+		prefix, errParse := netip.ParsePrefix(trimmed)
+		if errParse != nil {
+			addr, errAddr := netip.ParseAddr(trimmed)
+			if errAddr != nil {
+				log.WithField("value", trimmed).Warnf("%s entry is not a valid IP or CIDR; ignoring", field)
+				continue
+			}
+			addr = addr.Unmap()
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		normalized := prefix.Masked().String()
+		if _, exists := seen[normalized]; exists {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		out = append(out, normalized)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// SanitizeSignatureCacheStore normalizes the signature cache backend selection.
+// Unknown backends and backends missing their location fall back to "memory".
+func (cfg *Config) SanitizeResponseCache() {
+	if cfg == nil {
+		return
+	}
+	rc := &cfg.ResponseCache
+	rc.Type = strings.ToLower(strings.TrimSpace(rc.Type))
+	rc.RedisAddr = strings.TrimSpace(rc.RedisAddr)
+	rc.RedisKeyPrefix = strings.TrimSpace(rc.RedisKeyPrefix)
+	rc.RedisDB = max(rc.RedisDB, 0)
+	if rc.TTL <= 0 {
+		rc.TTL = 300
+	}
+	if rc.MaxEntries <= 0 {
+		rc.MaxEntries = 1000
+	}
+	if rc.MaxEntryBytes == 0 {
+		rc.MaxEntryBytes = 1 << 20
+	}
+	rc.MaxEntryBytes = max(rc.MaxEntryBytes, 0)
+	switch rc.Type {
+	case "", "memory":
+		rc.Type = "memory"
+	case "redis":
+		if rc.RedisAddr == "" {
+			log.Warn("response-cache.redis-addr is required for the redis backend; using memory")
+			rc.Type = "memory"
+		}
+	default:
+		log.WithField("value", rc.Type).Warn("response-cache.type is invalid; using memory")
+		rc.Type = "memory"
+	}
+}
+
+// SanitizeSignatureCacheStore normalizes the signature cache backend selection.
+func (cfg *Config) SanitizeSignatureCacheStore() {
+	if cfg == nil {
+		return
+	}
+	store := &cfg.SignatureCacheStore
+	store.Type = strings.ToLower(strings.TrimSpace(store.Type))
+	store.Path = strings.TrimSpace(store.Path)
+	store.RedisAddr = strings.TrimSpace(store.RedisAddr)
+	store.RedisKeyPrefix = strings.TrimSpace(store.RedisKeyPrefix)
+	store.EncryptionKey = strings.TrimSpace(store.EncryptionKey)
+	if store.RedisDB < 0 {
+		store.RedisDB = 0
+	}
+	store.RedisTimeout = max(store.RedisTimeout, 0)
+	store.CleanupInterval = max(store.CleanupInterval, 0)
+	store.MaxEntries = max(store.MaxEntries, 0)
+	switch store.Type {
+	case "", "memory":
+		store.Type = "memory"
+	case "file":
+		if store.Path == "" {
+			log.Warn("signature-cache-store.path is required for the file backend; using memory")
+			store.Type = "memory"
+		}
+	case "redis":
+		if store.RedisAddr == "" {
+			log.Warn("signature-cache-store.redis-addr is required for the redis backend; using memory")
+			store.Type = "memory"
+		}
+	default:
+		log.WithField("value", store.Type).Warn("signature-cache-store.type is invalid; using memory")
+		store.Type = "memory"
+	}
+}
+
+// SanitizeClaudeRequest normalizes Claude request policy values and drops unsupported ones.
+func (cfg *Config) SanitizeClaudeRequest() {
+	if cfg == nil {
+		return
+	}
+	choice := strings.ToLower(strings.TrimSpace(cfg.ClaudeRequest.DefaultToolChoice))
+	switch choice {
+	case "", "auto", "none":
+	default:
+		log.WithField("value", cfg.ClaudeRequest.DefaultToolChoice).Warn("claude-request.default-tool-choice is invalid; ignoring")
+		choice = ""
+	}
+	cfg.ClaudeRequest.DefaultToolChoice = choice
+
+	mode := strings.ToLower(strings.TrimSpace(cfg.ClaudeRequest.TemperatureMode))
+	switch mode {
+	case "", "passthrough", "clamp", "scale":
+	default:
+		log.WithField("value", cfg.ClaudeRequest.TemperatureMode).Warn("claude-request.temperature-mode is invalid; ignoring")
+		mode = ""
+	}
+	cfg.ClaudeRequest.TemperatureMode = mode
+
+	stages := cfg.ClaudeRequest.DisabledOpenAIStages[:0]
+	for _, stage := range cfg.ClaudeRequest.DisabledOpenAIStages {
+		if stage = strings.ToLower(strings.TrimSpace(stage)); stage != "" && !slices.Contains(stages, stage) {
+			stages = append(stages, stage)
+		}
+	}
+	cfg.ClaudeRequest.DisabledOpenAIStages = stages
+
+	if ratio := cfg.ClaudeRequest.ThinkingBudgetMaxRatio; ratio < 0 || ratio >= 1 {
+		log.WithField("value", ratio).Warn("claude-request.thinking-budget-max-ratio must be between 0 and 1; ignoring")
+		cfg.ClaudeRequest.ThinkingBudgetMaxRatio = 0
+	}
+
+	policy := &cfg.ClaudeRequest.MaxTokens
+	policy.Mode, policy.Value = sanitizeMaxTokensMode("claude-request.max-tokens", policy.Mode, policy.Value)
+	rules := policy.Rules[:0]
+	for i, rule := range policy.Rules {
+		rule.Models = NormalizeExcludedModels(rule.Models)
+		keys := rule.APIKeys[:0]
+		for _, key := range rule.APIKeys {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		rule.APIKeys = keys
+		rule.Mode, rule.Value = sanitizeMaxTokensMode(fmt.Sprintf("claude-request.max-tokens.rules[%d]", i), rule.Mode, rule.Value)
+		rules = append(rules, rule)
+	}
+	policy.Rules = rules
+
+	betas := &cfg.ClaudeRequest.Betas
+	if len(betas.Features) > 0 {
+		features := make(map[string]string, len(betas.Features))
+		for feature, flag := range betas.Features {
+			feature = strings.ToLower(strings.TrimSpace(feature))
+			if _, ok := ClaudeBetaFeatureFlags[feature]; !ok {
+				log.WithField("feature", feature).Warn("claude-request.betas.features has an unknown feature; ignoring")
+				continue
+			}
+			features[feature] = strings.TrimSpace(flag)
+		}
+		betas.Features = features
+	}
+	betaRules := betas.Rules[:0]
+	for _, rule := range betas.Rules {
+		rule.Models = NormalizeExcludedModels(rule.Models)
+		rule.Add = trimNonEmpty(rule.Add)
+		rule.Remove = trimNonEmpty(rule.Remove)
+		if len(rule.Add) == 0 && len(rule.Remove) == 0 {
+			continue
+		}
+		betaRules = append(betaRules, rule)
+	}
+	betas.Rules = betaRules
+
+	nonStream := &cfg.ClaudeRequest.NonStream
+	upstream := strings.ToLower(strings.TrimSpace(nonStream.Upstream))
+	switch upstream {
+	case "", ClaudeNonStreamUpstreamStream, ClaudeNonStreamUpstreamNonStream:
+	default:
+		log.WithField("value", nonStream.Upstream).Warn("claude-request.non-stream.upstream is invalid; ignoring")
+		upstream = ""
+	}
+	nonStream.Upstream = upstream
+	nonStream.IdleTimeoutSeconds = max(nonStream.IdleTimeoutSeconds, 0)
+	nonStream.MaxDurationSeconds = max(nonStream.MaxDurationSeconds, 0)
+	nonStream.MaxResponseBytes = max(nonStream.MaxResponseBytes, 0)
+	nonStream.ProgressLogSeconds = max(nonStream.ProgressLogSeconds, 0)
+}
+
+// trimNonEmpty trims values in place and drops the empty ones.
+func trimNonEmpty(values []string) []string {
+	out := values[:0]
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// sanitizeMaxTokensMode normalizes a max_tokens policy mode, falling back to "honor-client"
+// when the mode is unknown or "fixed" has no positive value.
+func sanitizeMaxTokensMode(field, mode string, value int) (string, int) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if value < 0 {
+		log.WithField("value", value).Warnf("%s.value must not be negative; ignoring", field)
+		value = 0
+	}
+	switch mode {
+	case "", "honor-client":
+		return "honor-client", value
+	case "clamp-to-model-max":
+		return mode, value
+	case "fixed":
+		if value == 0 {
+			log.Warnf("%s.mode is fixed without a value; honoring the client value", field)
+			return "honor-client", value
+		}
+		return mode, value
+	default:
+		log.WithField("value", mode).Warnf("%s.mode is invalid; honoring the client value", field)
+		return "honor-client", value
+	}
+}
+
+// SanitizeClaudeResponse normalizes Claude response rendering values and drops unsupported ones.
+func (cfg *Config) SanitizeClaudeResponse() {
+	if cfg == nil {
+		return
+	}
+	content := strings.ToLower(strings.TrimSpace(cfg.ClaudeResponse.ToolCallContent))
+	switch content {
+	case "", "text", "null":
+	default:
+		log.WithField("value", cfg.ClaudeResponse.ToolCallContent).Warn("claude-response.tool-call-content is invalid; ignoring")
+		content = ""
+	}
+	cfg.ClaudeResponse.ToolCallContent = content
+
+	source := strings.ToLower(strings.TrimSpace(cfg.ClaudeResponse.CreatedSource))
+	switch source {
+	case "", "local", "upstream":
+	default:
+		log.WithField("value", cfg.ClaudeResponse.CreatedSource).Warn("claude-response.created-source is invalid; ignoring")
+		source = ""
+	}
+	cfg.ClaudeResponse.CreatedSource = source
+
+	streaming := strings.ToLower(strings.TrimSpace(cfg.ClaudeResponse.ToolCallStreaming))
+	switch streaming {
+	case "", "buffered", "incremental":
+	default:
+		log.WithField("value", cfg.ClaudeResponse.ToolCallStreaming).Warn("claude-response.tool-call-streaming is invalid; ignoring")
+		streaming = ""
+	}
+	cfg.ClaudeResponse.ToolCallStreaming = streaming
+
+	thinking := strings.ToLower(strings.TrimSpace(cfg.ClaudeResponse.ThinkingOutput))
+	switch thinking {
+	case "", "reasoning_content", "reasoning", "hidden":
+	default:
+		log.WithField("value", cfg.ClaudeResponse.ThinkingOutput).Warn("claude-response.thinking-output is invalid; ignoring")
+		thinking = ""
+	}
+	cfg.ClaudeResponse.ThinkingOutput = thinking
+}
+
+// SanitizePromptCache normalizes prompt cache strategies and limits, dropping invalid values.
+func (cfg *Config) SanitizePromptCache() {
+	if cfg == nil {
+		return
+	}
+	normalize := func(field, value string) string {
+		strategy := strings.ToLower(strings.TrimSpace(value))
+		switch strategy {
+		case "", "default", "system-only", "rolling-window", "disabled":
+			return strategy
+		default:
+			log.WithField("value", value).Warnf("%s is invalid; using default", field)
+			return ""
+		}
+	}
+	normalizeTTL := func(field, value string) string {
+		ttl := strings.ToLower(strings.TrimSpace(value))
+		switch ttl {
+		case "", "5m", "1h":
+			return ttl
+		default:
+			log.WithField("value", value).Warnf("%s is invalid; using default", field)
+			return ""
+		}
+	}
+	pc := &cfg.PromptCache
+	pc.Strategy = normalize("prompt-cache.strategy", pc.Strategy)
+	pc.TTL = normalizeTTL("prompt-cache.ttl", pc.TTL)
+	if pc.Breakpoints < 0 || pc.Breakpoints > 4 {
+		log.WithField("value", pc.Breakpoints).Warn("prompt-cache.breakpoints must be between 0 and 4; using 4")
+		pc.Breakpoints = 0
+	}
+	if pc.MinContentLength < 0 {
+		pc.MinContentLength = 0
+	}
+	if len(pc.Models) == 0 {
+		return
+	}
+	rules := make([]PromptCacheModelRule, 0, len(pc.Models))
+	for _, rule := range pc.Models {
+		rule.Name = strings.TrimSpace(rule.Name)
+		if rule.Name == "" {
+			continue
+		}
+		rule.Strategy = normalize("prompt-cache.models.strategy", rule.Strategy)
+		rule.TTL = normalizeTTL("prompt-cache.models.ttl", rule.TTL)
+		rules = append(rules, rule)
+	}
+	pc.Models = rules
+}
+
+// SanitizeThinking normalizes per-model thinking defaults and drops invalid entries.
+func (cfg *Config) SanitizeThinking() {
+	if cfg == nil || len(cfg.Thinking.Defaults) == 0 {
+		return
+	}
+	defaults := make(map[string]ThinkingDefault, len(cfg.Thinking.Defaults))
+	for model, def := range cfg.Thinking.Defaults {
+		model = strings.TrimSpace(model)
+		def.Mode = strings.ToLower(strings.TrimSpace(def.Mode))
+		def.Effort = strings.ToLower(strings.TrimSpace(def.Effort))
+		if model == "" {
+			continue
+		}
+		if _, ok := thinking.ParseDefaultConfig(def.Mode, def.Effort, def.Budget); !ok {
+			log.WithField("model", model).Warn("thinking.defaults entry is invalid; ignoring")
+			continue
+		}
+		defaults[model] = def
+	}
+	cfg.Thinking.Defaults = defaults
+}
+
+// SanitizeModelFallbacks trims fallback chains and drops empty, repeated or self-referencing entries.
+func (cfg *Config) SanitizeModelFallbacks() {
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return
+	}
+	chains := make(map[string][]string, len(cfg.ModelFallbacks))
+	for model, fallbacks := range cfg.ModelFallbacks {
+		model = strings.TrimSpace(model)
+		if model == "" {
+			continue
+		}
+		seen := map[string]struct{}{strings.ToLower(model): {}}
+		chain := make([]string, 0, len(fallbacks))
+		for _, fallback := range fallbacks {
+			fallback = strings.TrimSpace(fallback)
+			key := strings.ToLower(fallback)
+			if _, dup := seen[key]; dup || fallback == "" {
+				continue
+			}
+			seen[key] = struct{}{}
+			chain = append(chain, fallback)
+		}
+		if len(chain) == 0 {
+			log.WithField("model", model).Warn("model-fallbacks entry has no usable fallback models; ignoring")
+			continue
+		}
+		chains[model] = chain
+	}
+	cfg.ModelFallbacks = chains
+}
+
+// SanitizeUnsupportedParams lowercases unsupported-params entries and drops unknown parameters
+// and actions. "approximate" is only kept for the penalties; other parameters fall back to "drop".
+func (cfg *Config) SanitizeUnsupportedParams() {
+	if cfg == nil || len(cfg.UnsupportedParams) == 0 {
+		return
+	}
+	policies := make(map[string]string, len(cfg.UnsupportedParams))
+	for param, action := range cfg.UnsupportedParams {
+		param = strings.ToLower(strings.TrimSpace(param))
+		action = strings.ToLower(strings.TrimSpace(action))
+		switch param {
+		case "logit_bias", "frequency_penalty", "presence_penalty", "seed":
+		default:
+			log.WithField("param", param).Warn("unsupported-params: unknown parameter; ignoring")
+			continue
+		}
+		switch action {
+		case "drop", "reject":
+		case "approximate":
+			if param != "frequency_penalty" && param != "presence_penalty" {
+				log.WithField("param", param).Warn("unsupported-params: approximate is only available for penalties; dropping instead")
+				action = "drop"
+			}
+		default:
+			log.WithFields(log.Fields{"param": param, "action": action}).Warn("unsupported-params: invalid action; dropping instead")
+			action = "drop"
+		}
+		policies[param] = action
+	}
+	cfg.UnsupportedParams = policies
+}
+
+// SanitizeContextOverflow lowercases context-overflow modes and disables unknown ones.
+func (cfg *Config) SanitizeContextOverflow() {
+	if cfg == nil {
+		return
+	}
+	cfg.ContextOverflow = normalizeContextOverflow(cfg.ContextOverflow, "context-overflow", false)
+	for i := range cfg.ProxyKeys {
+		cfg.ProxyKeys[i].ContextOverflow = normalizeContextOverflow(cfg.ProxyKeys[i].ContextOverflow, "proxy-keys.context-overflow", true)
+	}
+}
+
+// SanitizeSystemPromptRules lowercases actions, trims patterns and keys, and drops rules without
+// text or with an unknown action.
+func (cfg *Config) SanitizeSystemPromptRules() {
+	if cfg == nil || len(cfg.SystemPromptRules) == 0 {
+		return
+	}
+	rules := make([]SystemPromptRule, 0, len(cfg.SystemPromptRules))
+	for i, rule := range cfg.SystemPromptRules {
+		if strings.TrimSpace(rule.Text) == "" {
+			log.Warnf("system-prompt-rules[%d] has no text; ignoring it", i)
+			continue
+		}
+		rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+		switch rule.Action {
+		case "":
+			rule.Action = SystemPromptPrepend
+		case SystemPromptPrepend, SystemPromptAppend, SystemPromptReplace:
+		default:
+			log.WithField("action", rule.Action).Warnf("system-prompt-rules[%d] has an invalid action; ignoring it", i)
+			continue
+		}
+		rule.Models = NormalizeExcludedModels(rule.Models)
+		keys := rule.APIKeys[:0]
+		for _, key := range rule.APIKeys {
+			if key = strings.TrimSpace(key); key != "" {
+				keys = append(keys, key)
+			}
+		}
+		rule.APIKeys = keys
+		rules = append(rules, rule)
+	}
+	cfg.SystemPromptRules = rules
+}
+
+// SanitizeAutoCompact trims the summary model and disables auto-compact without one.
+func (cfg *Config) SanitizeAutoCompact() {
+	if cfg == nil {
+		return
+	}
+	cfg.AutoCompact.Model = strings.TrimSpace(cfg.AutoCompact.Model)
+	if cfg.AutoCompact.Enabled && cfg.AutoCompact.Model == "" {
+		log.Warn("auto-compact is enabled without a model; disabling it")
+		cfg.AutoCompact.Enabled = false
+	}
+}
+
+func normalizeContextOverflow(mode, field string, allowOff bool) string {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "", "truncate", "reject":
+		return mode
+	case "off":
+		if allowOff {
+			return mode
+		}
+		return ""
+	default:
+		log.WithField("value", mode).Warnf("%s is invalid; disabling the context guard", field)
+		if allowOff {
+			return "off"
+		}
+		return ""
+	}
+}
+
+// SanitizeProxyKeys trims proxy keys, drops entries without a key or repeating one, drops empty
+// or self-referencing model aliases, clamps negative rate limits and derives an ID from the key
+// for entries without one.
+func (cfg *Config) SanitizeProxyKeys() {
+	if cfg == nil || len(cfg.ProxyKeys) == 0 {
+		return
+	}
+	keys := make([]ProxyKey, 0, len(cfg.ProxyKeys))
+	seenKeys := make(map[string]struct{}, len(cfg.ProxyKeys))
+	seenIDs := make(map[string]struct{}, len(cfg.ProxyKeys))
+	for _, entry := range cfg.ProxyKeys {
+		entry.Key = strings.TrimSpace(entry.Key)
+		entry.ID = strings.TrimSpace(entry.ID)
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.Key == "" {
+			continue
+		}
+		if _, dup := seenKeys[entry.Key]; dup {
+			log.WithField("id", entry.ID).Warn("proxy-keys: duplicate key; ignoring entry")
+			continue
+		}
+		if entry.ID == "" {
+			sum := sha256.Sum256([]byte(entry.Key))
+			entry.ID = "key-" + hex.EncodeToString(sum[:6])
+		}
+		if _, dup := seenIDs[entry.ID]; dup {
+			log.WithField("id", entry.ID).Warn("proxy-keys: duplicate id; ignoring entry")
+			continue
+		}
+		var models []string
+		for _, model := range entry.Models {
+			if model = strings.TrimSpace(model); model != "" {
+				models = append(models, model)
+			}
+		}
+		entry.Models = models
+		var suffixes []string
+		for _, suffix := range entry.ThinkingSuffixes {
+			if suffix = strings.TrimSpace(suffix); suffix != "" {
+				suffixes = append(suffixes, suffix)
+			}
+		}
+		entry.ThinkingSuffixes = suffixes
+		var aliases map[string]string
+		for alias, target := range entry.ModelAliases {
+			alias, target = strings.TrimSpace(alias), strings.TrimSpace(target)
+			if alias == "" || target == "" || strings.EqualFold(alias, target) {
+				continue
+			}
+			if aliases == nil {
+				aliases = make(map[string]string, len(entry.ModelAliases))
+			}
+			aliases[alias] = target
+		}
+		entry.ModelAliases = aliases
+		if entry.RequestsPerMinute < 0 {
+			entry.RequestsPerMinute = 0
+		}
+		seenKeys[entry.Key] = struct{}{}
+		seenIDs[entry.ID] = struct{}{}
+		keys = append(keys, entry)
+	}
+	cfg.ProxyKeys = keys
+}
+
+// SanitizeAudioTranscription trims the transcription backend settings and clamps the timeout.
+func (cfg *Config) SanitizeAudioTranscription() {
+	if cfg == nil {
+		return
+	}
+	at := &cfg.AudioTranscription
+	at.BaseURL = strings.TrimRight(strings.TrimSpace(at.BaseURL), "/")
+	at.APIKey = strings.TrimSpace(at.APIKey)
+	at.Model = strings.TrimSpace(at.Model)
+	at.Language = strings.ToLower(strings.TrimSpace(at.Language))
+	at.Timeout = max(at.Timeout, 0)
+}
+
+// SanitizeImageFetch clamps the image fetch limits and normalizes the host allowlist.
+func (cfg *Config) SanitizeImageFetch() {
+	if cfg == nil {
+		return
+	}
+	f := &cfg.ImageFetch
+	f.MaxBytes = max(f.MaxBytes, 0)
+	f.Timeout = max(f.Timeout, 0)
+	if len(f.AllowedHosts) == 0 {
+		return
+	}
+	hosts := make([]string, 0, len(f.AllowedHosts))
+	for _, host := range f.AllowedHosts {
+		host = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(host), "."))
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	f.AllowedHosts = hosts
+}
+
+// SanitizeAPIKeyQuota clamps negative limits and drops quota entries without a key or model.
+func (cfg *Config) SanitizeAPIKeyQuota() {
+	if cfg == nil {
+		return
+	}
+	clamp := func(l *QuotaLimits) {
+		l.DailyRequests = max(l.DailyRequests, 0)
+		l.MonthlyRequests = max(l.MonthlyRequests, 0)
+		l.DailyTokens = max(l.DailyTokens, 0)
+		l.MonthlyTokens = max(l.MonthlyTokens, 0)
+		l.MonthlyBudget = max(l.MonthlyBudget, 0)
+	}
+	q := &cfg.APIKeyQuota
+	clamp(&q.Default)
+	if len(q.Keys) > 0 {
+		keys := make([]APIKeyQuotaEntry, 0, len(q.Keys))
+		for _, entry := range q.Keys {
+			entry.APIKey = strings.TrimSpace(entry.APIKey)
+			if entry.APIKey == "" {
+				log.Warn("api-key-quota.keys entry without api-key; ignoring")
+				continue
+			}
+			clamp(&entry.QuotaLimits)
+			keys = append(keys, entry)
+		}
+		q.Keys = keys
+	}
+	q.ModelPrices = sanitizeModelPrices(q.ModelPrices)
+}
+
+// SanitizeModelPrices clamps negative prices and drops price entries without a model.
+func (cfg *Config) SanitizeModelPrices() {
+	if cfg == nil {
+		return
+	}
+	cfg.ModelPrices = sanitizeModelPrices(cfg.ModelPrices)
+}
+
+func sanitizeModelPrices(in []ModelPrice) []ModelPrice {
+	if len(in) == 0 {
+		return in
+	}
+	prices := make([]ModelPrice, 0, len(in))
+	for _, price := range in {
+		price.Model = strings.TrimSpace(price.Model)
+		if price.Model == "" {
+			continue
+		}
+		price.Input = max(price.Input, 0)
+		price.Output = max(price.Output, 0)
+		price.CachedInput = max(price.CachedInput, 0)
+		price.CacheWrite = max(price.CacheWrite, 0)
+		prices = append(prices, price)
+	}
+	return prices
+}
+
+// SanitizeModeration normalizes the moderation action and drops empty keywords and
+// patterns that are not valid regular expressions.
+func (cfg *Config) SanitizeModeration() {
+	if cfg == nil {
+		return
+	}
+	m := &cfg.Moderation
+	m.Action = strings.ToLower(strings.TrimSpace(m.Action))
+	switch m.Action {
+	case "", "reject", "flag":
+	default:
+		log.WithField("value", m.Action).Warn("moderation.action is invalid; using reject")
+		m.Action = ""
+	}
+	keywords := make([]string, 0, len(m.Keywords))
+	for _, keyword := range m.Keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	m.Keywords = keywords
+	patterns := make([]string, 0, len(m.Patterns))
+	for _, pattern := range m.Patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		if _, errCompile := regexp.Compile(pattern); errCompile != nil {
+			log.WithField("value", pattern).Warnf("moderation.patterns entry is invalid; ignoring: %v", errCompile)
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	m.Patterns = patterns
+	m.OpenAI.APIKey = strings.TrimSpace(m.OpenAI.APIKey)
+	m.OpenAI.BaseURL = strings.TrimSpace(m.OpenAI.BaseURL)
+	m.OpenAI.Model = strings.TrimSpace(m.OpenAI.Model)
+	m.OpenAI.Timeout = max(m.OpenAI.Timeout, 0)
+}
+
+// SanitizePIIScrubber lowercases detector names, drops unknown detectors and drops custom
+// patterns without a name or with an invalid regular expression.
+func (cfg *Config) SanitizePIIScrubber() {
+	if cfg == nil {
+		return
+	}
+	p := &cfg.PIIScrubber
+	detectors := make([]string, 0, len(p.Detectors))
+	for _, detector := range p.Detectors {
+		detector = strings.ToLower(strings.TrimSpace(detector))
+		switch detector {
+		case "email", "phone", "credit-card":
+			detectors = append(detectors, detector)
+		case "":
+		default:
+			log.WithField("value", detector).Warn("pii-scrubber.detectors entry is unknown; ignoring")
+		}
+	}
+	p.Detectors = detectors
+	patterns := make([]PIIPattern, 0, len(p.Patterns))
+	for _, pattern := range p.Patterns {
+		pattern.Name = strings.TrimSpace(pattern.Name)
+		if pattern.Name == "" || strings.TrimSpace(pattern.Pattern) == "" {
+			log.Warn("pii-scrubber.patterns entry needs a name and a pattern; ignoring")
+			continue
+		}
+		if _, errCompile := regexp.Compile(pattern.Pattern); errCompile != nil {
+			log.WithField("value", pattern.Pattern).Warnf("pii-scrubber.patterns entry is invalid; ignoring: %v", errCompile)
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	p.Patterns = patterns
+}
+
+// SanitizeOAuthModelAlias normalizes and deduplicates global OAuth model name aliases.
+// It trims whitespace, normalizes channel keys to lower-case, drops empty entries,
+// allows multiple aliases per upstream name, and ensures aliases are unique within each channel.
+func (cfg *Config) SanitizeOAuthModelAlias() {
+	if cfg == nil || len(cfg.OAuthModelAlias) == 0 {
+		return
+	}
+	out := make(map[string][]OAuthModelAlias, len(cfg.OAuthModelAlias))
+	for rawChannel, aliases := range cfg.OAuthModelAlias {
+		channel := strings.ToLower(strings.TrimSpace(rawChannel))
+		if channel == "" || len(aliases) == 0 {
+			continue
+		}
+		seenAlias := make(map[string]struct{}, len(aliases))
+		clean := make([]OAuthModelAlias, 0, len(aliases))
+		for _, entry := range aliases {
+			name := strings.TrimSpace(entry.Name)
+			alias := strings.TrimSpace(entry.Alias)
+			if name == "" || alias == "" {
+				continue
+			}
+			if strings.EqualFold(name, alias) {
+				continue
+			}
+			aliasKey := strings.ToLower(alias)
+			if _, ok := seenAlias[aliasKey]; ok {
+				continue
+			}
+			seenAlias[aliasKey] = struct{}{}
+			clean = append(clean, OAuthModelAlias{Name: name, Alias: alias, Fork: entry.Fork})
+		}
+		if len(clean) > 0 {
+			out[channel] = clean
+		}
+	}
+	cfg.OAuthModelAlias = out
+}
+
+// SanitizeOAuthProxies trims OAuth proxy entries, lower-cases providers, and drops entries
+// without a proxy URL.
+func (cfg *Config) SanitizeOAuthProxies() {
+	if cfg == nil || len(cfg.OAuthProxies) == 0 {
+		return
+	}
+	out := make([]OAuthProxy, 0, len(cfg.OAuthProxies))
+	for _, entry := range cfg.OAuthProxies {
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		entry.Account = strings.TrimSpace(entry.Account)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		if entry.ProxyURL == "" {
+			continue
+		}
+		out = append(out, entry)
+	}
+	cfg.OAuthProxies = out
+}
+
+// OAuthProxyURL returns the proxy of the first OAuthProxies entry matching an account of
+// provider, identified by its email and auth file name, or "" when none matches.
+func (cfg *Config) OAuthProxyURL(provider, email, fileName string) string {
+	if cfg == nil {
+		return ""
+	}
+	for _, entry := range cfg.OAuthProxies {
+		if entry.Provider != "" && !strings.EqualFold(entry.Provider, provider) {
+			continue
+		}
+		if entry.Account != "" && !strings.EqualFold(entry.Account, email) && !strings.EqualFold(entry.Account, fileName) {
+			continue
+		}
+		return entry.ProxyURL
+	}
+	return ""
+}
+
+// NormalizeModelOverrides trims model overrides, lower-cases providers, and drops entries with
+// an empty ID or an unknown provider. A later entry for the same provider and ID replaces the
+// earlier one in place.
+func NormalizeModelOverrides(overrides []registry.ModelOverride) []registry.ModelOverride {
+	if len(overrides) == 0 {
+		return nil
+	}
+	out := make([]registry.ModelOverride, 0, len(overrides))
+	index := make(map[string]int, len(overrides))
+	for _, entry := range overrides {
+		entry.ID = strings.TrimSpace(entry.ID)
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		entry.DisplayName = strings.TrimSpace(entry.DisplayName)
+		if entry.ID == "" {
+			continue
+		}
+		if !registry.IsModelOverrideProvider(entry.Provider) {
+			log.WithField("value", entry.Provider).Warnf("model-overrides: ignoring %s with unknown provider", entry.ID)
+			continue
+		}
+		entry.ContextLength = max(entry.ContextLength, 0)
+		entry.MaxCompletionTokens = max(entry.MaxCompletionTokens, 0)
+		aliases := make([]string, 0, len(entry.Aliases))
+		for _, alias := range entry.Aliases {
+			if alias = strings.TrimSpace(alias); alias != "" && !strings.EqualFold(alias, entry.ID) {
+				aliases = append(aliases, alias)
+			}
+		}
+		entry.Aliases = nil
+		if len(aliases) > 0 {
+			entry.Aliases = aliases
+		}
+		key := entry.Provider + "\x00" + strings.ToLower(entry.ID)
+		if i, exists := index[key]; exists {
+			out[i] = entry
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, entry)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// SanitizeOpenAICompatibility removes OpenAI-compatibility provider entries that are
+// not actionable, specifically those missing a BaseURL. It trims whitespace before
+// evaluation and preserves the relative order of remaining entries.
+func (cfg *Config) SanitizeOpenAICompatibility() {
+	if cfg == nil || len(cfg.OpenAICompatibility) == 0 {
+		return
+	}
+	out := make([]OpenAICompatibility, 0, len(cfg.OpenAICompatibility))
+	for i := range cfg.OpenAICompatibility {
+		e := cfg.OpenAICompatibility[i]
+		e.Name = strings.TrimSpace(e.Name)
+		e.Prefix = normalizeModelPrefix(e.Prefix)
+		e.BaseURL = strings.TrimSpace(e.BaseURL)
 		e.Headers = NormalizeHeaders(e.Headers)
 		if e.BaseURL == "" {
 			// Skip providers with no base-url; treated as removed
 			continue
 		}
+		if e.Azure != nil {
+			azure := *e.Azure
+			azure.APIVersion = strings.TrimSpace(azure.APIVersion)
+			if azure.APIVersion == "" {
+				azure.APIVersion = DefaultAzureOpenAIAPIVersion
+			}
+			deployments := make(map[string]string, len(azure.Deployments))
+			for name, deployment := range azure.Deployments {
+				name, deployment = strings.TrimSpace(name), strings.TrimSpace(deployment)
+				if name != "" && deployment != "" {
+					deployments[name] = deployment
+				}
+			}
+			azure.Deployments = deployments
+			e.Azure = &azure
+		}
 		out = append(out, e)
 	}
 	cfg.OpenAICompatibility = out
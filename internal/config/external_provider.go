@@ -0,0 +1,156 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultExternalProviderTimeout bounds one request to an external provider, in seconds.
+const DefaultExternalProviderTimeout = 300
+
+// ExternalProvider is a provider implemented outside the proxy. Requests are sent, translated
+// to the OpenAI chat completions format, to the stdin of an executable started per request, to
+// an HTTP endpoint or to a gRPC endpoint; the reply is a stream of JSON messages. See
+// docs/sdk-advanced.md for the protocol.
+type ExternalProvider struct {
+	// Name is the provider key of the credential. It must not name a built-in provider.
+	Name string `yaml:"name" json:"name"`
+
+	// Command is the executable started for each request. Either Command or URL is required;
+	// Command wins when both are set. Commands are only accepted from the config file on disk;
+	// management API updates that change them are rejected.
+	Command string `yaml:"command,omitempty" json:"command,omitempty"`
+
+	// Args are passed to Command.
+	Args []string `yaml:"args,omitempty" json:"args,omitempty"`
+
+	// Env adds environment variables to those inherited by Command.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+
+	// URL is the HTTP endpoint the requests are POSTed to, or a gRPC endpoint given as
+	// grpc://host:port (plaintext) or grpcs://host:port (TLS).
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	// Headers optionally adds extra HTTP headers, or gRPC metadata, for requests sent to URL.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// APIKey is handed to the provider with each request.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Prefix optionally namespaces the models of this provider (e.g. "scripted/my-model").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Priority controls selection preference when multiple credentials expose a model.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Timeout bounds one request, in seconds. Defaults to DefaultExternalProviderTimeout.
+	Timeout int `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for requests sent to an HTTP URL.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models lists the models the provider serves. External providers are not queried for
+	// their models, so only listed models are exposed.
+	Models []ExternalProviderModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this provider.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// ExternalProviderModel maps a model of an external provider to a client-visible alias.
+type ExternalProviderModel struct {
+	// Name is the model ID handed to the provider.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name.
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+}
+
+func (m ExternalProviderModel) GetName() string  { return m.Name }
+func (m ExternalProviderModel) GetAlias() string { return m.Alias }
+
+// reservedExternalProviderNames are provider keys served by built-in executors.
+var reservedExternalProviderNames = map[string]struct{}{
+	"gemini": {}, "vertex": {}, "gemini-cli": {}, "aistudio": {}, "antigravity": {}, "claude": {},
+	"codex": {}, "bedrock": {}, "kimi": {}, "openrouter": {}, "ollama": {}, "openai-compatibility": {},
+}
+
+// SanitizeExternalProviders normalizes external providers, dropping entries without a name or
+// a command and URL, and entries whose name is reserved or already taken.
+func (cfg *Config) SanitizeExternalProviders() {
+	if cfg == nil {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(cfg.ExternalProviders))
+	out := cfg.ExternalProviders[:0]
+	for i := range cfg.ExternalProviders {
+		entry := cfg.ExternalProviders[i]
+		entry.Name = strings.ToLower(strings.TrimSpace(entry.Name))
+		entry.Command = strings.TrimSpace(entry.Command)
+		entry.URL = strings.TrimSpace(entry.URL)
+		if entry.Name == "" || (entry.Command == "" && entry.URL == "") {
+			continue
+		}
+		if _, reserved := reservedExternalProviderNames[entry.Name]; reserved {
+			log.Warnf("external-providers: %q names a built-in provider, entry ignored", entry.Name)
+			continue
+		}
+		if _, dup := seen[entry.Name]; dup {
+			log.Warnf("external-providers: duplicate name %q, entry ignored", entry.Name)
+			continue
+		}
+		seen[entry.Name] = struct{}{}
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		if entry.Timeout <= 0 {
+			entry.Timeout = DefaultExternalProviderTimeout
+		}
+
+		models := make([]ExternalProviderModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				models = append(models, model)
+			}
+		}
+		entry.Models = models
+		out = append(out, entry)
+	}
+	cfg.ExternalProviders = out
+}
+
+// ExternalProviderCommandsChanged returns the names of the external providers in newCfg whose
+// command, arguments or environment are absent from or differ in oldCfg. Command providers
+// run programs on the host, so such changes are only accepted from the config file itself.
+func ExternalProviderCommandsChanged(oldCfg, newCfg *Config) []string {
+	if newCfg == nil {
+		return nil
+	}
+	previous := make(map[string]*ExternalProvider)
+	if oldCfg != nil {
+		for i := range oldCfg.ExternalProviders {
+			previous[oldCfg.ExternalProviders[i].Name] = &oldCfg.ExternalProviders[i]
+		}
+	}
+	var changed []string
+	for i := range newCfg.ExternalProviders {
+		entry := &newCfg.ExternalProviders[i]
+		if entry.Command == "" {
+			continue
+		}
+		old := previous[entry.Name]
+		if old == nil || old.Command != entry.Command || !slices.Equal(old.Args, entry.Args) || !maps.Equal(old.Env, entry.Env) {
+			changed = append(changed, entry.Name)
+		}
+	}
+	return changed
+}
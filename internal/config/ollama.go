@@ -0,0 +1,85 @@
+package config
+
+import "strings"
+
+const (
+	// DefaultOllamaBaseURL is the address of a local Ollama server with default settings.
+	DefaultOllamaBaseURL = "http://127.0.0.1:11434"
+
+	// DefaultOllamaPrefix namespaces Ollama models when no prefix is configured, e.g. "local/qwen3:8b".
+	DefaultOllamaPrefix = "local"
+)
+
+// OllamaServer represents an Ollama server reached through its OpenAI-compatible API.
+// Models are always exposed under the configured prefix so local models never share
+// routing with hosted providers.
+type OllamaServer struct {
+	// BaseURL is the Ollama server address without the /v1 suffix. Defaults to
+	// DefaultOllamaBaseURL.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// APIKey is an optional bearer token for servers behind an authenticating reverse proxy.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Prefix namespaces the models of this server (e.g. "local/llama3.2"). Defaults to
+	// DefaultOllamaPrefix.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Priority controls selection preference when multiple servers expose a model.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this server if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models optionally restricts the exposed models. When empty, every model installed on
+	// the server is exposed. Listed capabilities are used when the server cannot be queried.
+	Models []OllamaModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers for requests sent to this server.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this server.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+// OllamaModel selects a model installed on an Ollama server.
+type OllamaModel struct {
+	// Name is the Ollama model tag, e.g. "qwen3:8b".
+	Name string `yaml:"name" json:"name"`
+
+	// Thinking declares reasoning support when the server capabilities are unavailable.
+	Thinking bool `yaml:"thinking,omitempty" json:"thinking,omitempty"`
+}
+
+// SanitizeOllama normalizes Ollama servers, applying the default address and prefix.
+func (cfg *Config) SanitizeOllama() {
+	if cfg == nil {
+		return
+	}
+
+	for i := range cfg.Ollama {
+		entry := &cfg.Ollama[i]
+		entry.BaseURL = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(entry.BaseURL), "/"), "/v1")
+		if entry.BaseURL == "" {
+			entry.BaseURL = DefaultOllamaBaseURL
+		}
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		if entry.Prefix == "" {
+			entry.Prefix = DefaultOllamaPrefix
+		}
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]OllamaModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			if model.Name != "" {
+				models = append(models, model)
+			}
+		}
+		entry.Models = models
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import "strings"
+
+// DefaultOpenRouterBaseURL is the OpenRouter API endpoint used when no base URL is configured.
+const DefaultOpenRouterBaseURL = "https://openrouter.ai/api/v1"
+
+// OpenRouterKey represents an OpenRouter API key. Models are taken from the OpenRouter
+// catalog, optionally restricted and renamed through Models.
+type OpenRouterKey struct {
+	// APIKey is the OpenRouter API key.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// Priority controls selection preference when multiple credentials match.
+	// Higher values are preferred; defaults to 0.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Prefix optionally namespaces models for this credential (e.g., "or/anthropic/claude-sonnet-4.5").
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// BaseURL overrides the OpenRouter API endpoint. Defaults to DefaultOpenRouterBaseURL.
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+
+	// ProxyURL overrides the global proxy setting for this credential if provided.
+	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Models maps OpenRouter model IDs (e.g. "anthropic/claude-sonnet-4.5") to client-visible
+	// aliases. When empty, the whole OpenRouter catalog is exposed under the catalog IDs.
+	Models []OpenRouterModel `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Headers optionally adds extra HTTP headers, such as HTTP-Referer and X-Title for
+	// OpenRouter app attribution.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ExcludedModels lists model IDs that should be excluded for this credential.
+	ExcludedModels []string `yaml:"excluded-models,omitempty" json:"excluded-models,omitempty"`
+}
+
+func (k OpenRouterKey) GetAPIKey() string  { return k.APIKey }
+func (k OpenRouterKey) GetBaseURL() string { return k.BaseURL }
+
+// OpenRouterModel maps an OpenRouter model ID to a client-visible alias.
+type OpenRouterModel struct {
+	// Name is the OpenRouter model ID used when issuing requests.
+	Name string `yaml:"name" json:"name"`
+
+	// Alias is the client-facing model name that maps to Name.
+	Alias string `yaml:"alias,omitempty" json:"alias,omitempty"`
+}
+
+func (m OpenRouterModel) GetName() string  { return m.Name }
+func (m OpenRouterModel) GetAlias() string { return m.Alias }
+
+// SanitizeOpenRouterKeys normalizes OpenRouter credentials, applying the default base URL and
+// dropping entries without an API key.
+func (cfg *Config) SanitizeOpenRouterKeys() {
+	if cfg == nil {
+		return
+	}
+
+	out := cfg.OpenRouterKey[:0]
+	for i := range cfg.OpenRouterKey {
+		entry := cfg.OpenRouterKey[i]
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		entry.BaseURL = strings.TrimSuffix(strings.TrimSpace(entry.BaseURL), "/")
+		if entry.BaseURL == "" {
+			entry.BaseURL = DefaultOpenRouterBaseURL
+		}
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
+		entry.Headers = NormalizeHeaders(entry.Headers)
+		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+
+		models := make([]OpenRouterModel, 0, len(entry.Models))
+		for _, model := range entry.Models {
+			model.Name = strings.TrimSpace(model.Name)
+			model.Alias = strings.TrimSpace(model.Alias)
+			if model.Name != "" {
+				models = append(models, model)
+			}
+		}
+		entry.Models = models
+		out = append(out, entry)
+	}
+	cfg.OpenRouterKey = out
+}
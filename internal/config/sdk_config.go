@@ -4,6 +4,14 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -34,16 +42,136 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// ProxyKeys are client API keys with metadata (name, allowed models, rate limit and
+	// expiration), created and rotated through the management API. They authenticate like APIKeys.
+	ProxyKeys []ProxyKey `yaml:"proxy-keys,omitempty" json:"proxy-keys,omitempty"`
+
 	// PassthroughHeaders controls whether upstream response headers are forwarded to downstream clients.
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
 
+	// ModelFallbacks maps a requested model to the ordered models tried when it keeps failing
+	// with rate-limit or overloaded errors after retries, e.g. claude-opus-4-6: [claude-sonnet-4-5, gemini-2.5-pro].
+	// Keys match case-insensitively; the model that served the request is reported in X-Fallback-Model.
+	ModelFallbacks map[string][]string `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
+	// DedupeRequests coalesces concurrent identical non-streaming requests (same client API key,
+	// endpoint format, model and body) into one upstream call whose result is returned to every caller.
+	DedupeRequests bool `yaml:"dedupe-requests,omitempty" json:"dedupe-requests,omitempty"`
+
+	// MCPServer exposes a Model Context Protocol endpoint at /mcp that lists the available models
+	// and usage statistics as resources and serves sampling requests through the proxy.
+	MCPServer bool `yaml:"mcp-server,omitempty" json:"mcp-server,omitempty"`
+
+	// ListThinkingVariants adds a "model(level)" entry to model listings for every thinking level
+	// a listed model supports, so clients without a reasoning setting can pick one by model name.
+	ListThinkingVariants bool `yaml:"list-thinking-variants,omitempty" json:"list-thinking-variants,omitempty"`
+
+	// MultipleChoices controls OpenAI chat requests with n > 1 for providers that return a single
+	// choice (Claude, Codex, Kimi); they are served by fanning out parallel requests.
+	MultipleChoices MultipleChoicesConfig `yaml:"multiple-choices,omitempty" json:"multiple-choices,omitempty"`
+
+	// UnsupportedParams sets, per OpenAI sampling parameter (logit_bias, frequency_penalty,
+	// presence_penalty, seed), what happens when the model's provider cannot honor it:
+	// "drop" (default: remove it and report it in X-Proxy-Dropped-Params), "reject" (400 error)
+	// or "approximate" (penalties only: fold them into the temperature).
+	UnsupportedParams map[string]string `yaml:"unsupported-params,omitempty" json:"unsupported-params,omitempty"`
+
+	// ContextOverflow guards requests whose estimated size, including the requested output
+	// tokens, exceeds the model's context window: "" (default, off), "truncate" (drop the oldest
+	// conversation turns, keeping system prompts and the latest user turn) or "reject" (400
+	// context_length_exceeded). Proxy keys may override it.
+	ContextOverflow string `yaml:"context-overflow,omitempty" json:"context-overflow,omitempty"`
+
+	// SystemPromptRules prepend text to, append text to, or replace the client's system prompt
+	// for matching models and client API keys. Every matching rule applies, in order. Injected
+	// text is placed before cache_control breakpoints are added, so it is part of the cached prefix.
+	SystemPromptRules []SystemPromptRule `yaml:"system-prompt-rules,omitempty" json:"system-prompt-rules,omitempty"`
+
+	// AutoCompact summarizes the older turns of long conversations with a cheap model and
+	// replaces them with the summary before the request is sent upstream.
+	AutoCompact AutoCompactConfig `yaml:"auto-compact,omitempty" json:"auto-compact,omitempty"`
+
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// StripInternalResponseFields removes proxy-internal fields (native_finish_reason,
+	// response_metadata) from OpenAI Chat Completions responses and stream chunks.
+	// Default is false (fields are emitted as-is).
+	StripInternalResponseFields bool `yaml:"strip-internal-response-fields,omitempty" json:"strip-internal-response-fields,omitempty"`
+
+	// StrictOpenAIValidation checks OpenAI Chat Completions requests against the OpenAI schema
+	// (required fields, enum values and tool message ordering) and rejects invalid ones with a
+	// 400 error naming the offending field as a JSON pointer.
+	StrictOpenAIValidation bool `yaml:"strict-openai-validation,omitempty" json:"strict-openai-validation,omitempty"`
+}
+
+// MultipleChoicesConfig configures the fan-out used for OpenAI "n" > 1.
+type MultipleChoicesConfig struct {
+	// Disabled rejects n > 1 for single-choice providers with an invalid_request_error.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// MaxN is the largest n accepted. <= 0 uses the default of 8.
+	MaxN int `yaml:"max-n,omitempty" json:"max-n,omitempty"`
+
+	// Concurrency bounds the parallel upstream requests of one fan-out. <= 0 uses the default of 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+}
+
+// System prompt rule actions.
+const (
+	SystemPromptPrepend = "prepend"
+	SystemPromptAppend  = "append"
+	SystemPromptReplace = "replace"
+)
+
+// SystemPromptRule rewrites the system prompt of matching requests.
+type SystemPromptRule struct {
+	// Models lists model names or wildcard patterns (e.g., "claude-*"); empty matches any model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// APIKeys lists client API keys; empty matches any key.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+	// Action is "prepend" (default), "append" or "replace".
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+	// Text is the system prompt text to inject.
+	Text string `yaml:"text" json:"text"`
+}
+
+// Matches reports whether the rule applies to model requested with apiKey. Model patterns match
+// case-insensitively, with or without the model's thinking suffix.
+func (r *SystemPromptRule) Matches(model, apiKey string) bool {
+	if len(r.APIKeys) > 0 && !slices.Contains(r.APIKeys, apiKey) {
+		return false
+	}
+	if len(r.Models) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	return matchesAnyPattern(r.Models, model) || matchesAnyPattern(r.Models, thinking.ParseSuffix(model).ModelName)
+}
+
+// AutoCompactConfig configures conversation compression through summarization.
+type AutoCompactConfig struct {
+	// Enabled turns on compaction for conversations above Threshold.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// Model is the model used to write summaries; compaction is skipped when it is empty.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+
+	// Threshold is the estimated request size in tokens above which older turns are summarized.
+	// <= 0 uses 75% of the target model's context window.
+	Threshold int `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+
+	// KeepTurns is the number of most recent user turns kept verbatim. <= 0 uses the default of 2.
+	KeepTurns int `yaml:"keep-turns,omitempty" json:"keep-turns,omitempty"`
+
+	// CacheTTL is how long, in seconds, summaries are reused for later requests of the same
+	// conversation. <= 0 uses the default of 3600.
+	CacheTTL int `yaml:"cache-ttl,omitempty" json:"cache-ttl,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -56,4 +184,161 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// KeepAliveMode selects the heartbeat written to OpenAI Chat Completions streams:
+	// "comment" (default, an SSE comment) or "empty-delta" (a chunk with an empty delta, for
+	// clients and proxies that only count data events). Other formats always use comments.
+	KeepAliveMode string `yaml:"keepalive-mode,omitempty" json:"keepalive-mode,omitempty"`
+
+	// IdleTimeoutSeconds aborts a stream that received no upstream data for this long and ends
+	// it with an error event. <= 0 disables the timeout. Default is 0.
+	IdleTimeoutSeconds int `yaml:"idle-timeout-seconds,omitempty" json:"idle-timeout-seconds,omitempty"`
+
+	// PartialRecoveryRetries controls how many times a Claude stream that dies mid-response is
+	// resumed with the text generated so far as an assistant prefill, the continuation being
+	// stitched into the same client stream. <= 0 disables recovery. Default is 0.
+	PartialRecoveryRetries int `yaml:"partial-recovery-retries,omitempty" json:"partial-recovery-retries,omitempty"`
+}
+
+// Streaming keep-alive modes.
+const (
+	StreamingKeepAliveComment    = "comment"
+	StreamingKeepAliveEmptyDelta = "empty-delta"
+)
+
+// ProxyKey is a client API key with its access policy.
+type ProxyKey struct {
+	// ID identifies the key in the management API; it survives rotation.
+	ID string `yaml:"id" json:"id"`
+
+	// Key is the secret clients send as "Authorization: Bearer <key>".
+	Key string `yaml:"key" json:"key"`
+
+	// Name is a human-readable label, e.g. the team or application using the key.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Models lists the model names or wildcard patterns (e.g., "claude-*") the key may use;
+	// empty allows every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// ThinkingSuffixes lists the model suffixes (e.g., "low", "high", "8192") or wildcard
+	// patterns the key may append to a model name; empty allows every suffix.
+	ThinkingSuffixes []string `yaml:"thinking-suffixes,omitempty" json:"thinking-suffixes,omitempty"`
+
+	// ModelAliases maps model names requested with this key to the models actually used, e.g.
+	// default: claude-sonnet-4-5. Alias targets are allowed regardless of Models.
+	ModelAliases map[string]string `yaml:"model-aliases,omitempty" json:"model-aliases,omitempty"`
+
+	// RequestsPerMinute caps the requests per minute accepted from the key, overriding the
+	// rate-limit request limit for it. 0 applies the rate-limit settings.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// ContextOverflow overrides the top-level context-overflow for this key ("off", "truncate"
+	// or "reject"); empty inherits it.
+	ContextOverflow string `yaml:"context-overflow,omitempty" json:"context-overflow,omitempty"`
+
+	// ExpiresAt is when the key stops authenticating; zero never expires.
+	ExpiresAt time.Time `yaml:"expires-at,omitempty" json:"expires-at,omitzero"`
+
+	// Revoked disables the key while keeping it listed.
+	Revoked bool `yaml:"revoked,omitempty" json:"revoked,omitempty"`
+
+	// CreatedAt is when the key was created or last rotated.
+	CreatedAt time.Time `yaml:"created-at,omitempty" json:"created-at,omitzero"`
+}
+
+// Expired reports whether the key has expired at now.
+func (k *ProxyKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+// AllowsModel reports whether the key may use model. Alias targets are always allowed. Other
+// models must match Models with or without their thinking suffix, and the suffix must match
+// ThinkingSuffixes. Patterns match case-insensitively and '*' matches any run of characters.
+func (k *ProxyKey) AllowsModel(model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	parsed := thinking.ParseSuffix(model)
+	aliasTarget := false
+	for _, target := range k.ModelAliases {
+		target = strings.ToLower(target)
+		if target == model {
+			return true
+		}
+		aliasTarget = aliasTarget || target == parsed.ModelName
+	}
+	if parsed.HasSuffix && len(k.ThinkingSuffixes) > 0 && !matchesAnyPattern(k.ThinkingSuffixes, parsed.RawSuffix) {
+		return false
+	}
+	return len(k.Models) == 0 || aliasTarget || matchesAnyPattern(k.Models, model) || matchesAnyPattern(k.Models, parsed.ModelName)
+}
+
+// ResolveAlias returns the model the key's alias for model points to, keeping a thinking suffix
+// of the requested name unless the target carries its own. ok is false when model is not an alias.
+func (k *ProxyKey) ResolveAlias(model string) (target string, ok bool) {
+	if len(k.ModelAliases) == 0 {
+		return "", false
+	}
+	model = strings.TrimSpace(model)
+	parsed := thinking.ParseSuffix(model)
+	for alias, aliasTarget := range k.ModelAliases {
+		if strings.EqualFold(alias, model) {
+			return aliasTarget, true
+		}
+	}
+	if !parsed.HasSuffix {
+		return "", false
+	}
+	for alias, aliasTarget := range k.ModelAliases {
+		if !strings.EqualFold(alias, parsed.ModelName) {
+			continue
+		}
+		if thinking.ParseSuffix(aliasTarget).HasSuffix {
+			return aliasTarget, true
+		}
+		return aliasTarget + "(" + parsed.RawSuffix + ")", true
+	}
+	return "", false
+}
+
+func matchesAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if wildcardMatch(strings.ToLower(pattern), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindProxyKey returns the proxy key entry with the given secret, or nil.
+func (cfg *SDKConfig) FindProxyKey(key string) *ProxyKey {
+	if cfg == nil || key == "" {
+		return nil
+	}
+	for i := range cfg.ProxyKeys {
+		if cfg.ProxyKeys[i].Key == key {
+			return &cfg.ProxyKeys[i]
+		}
+	}
+	return nil
+}
+
+// wildcardMatch matches value against pattern, where '*' matches zero or more characters.
+func wildcardMatch(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+	return strings.HasSuffix(value, last)
 }
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validate reports configuration errors that the Sanitize helpers cannot repair, such as
+// duplicate names or keys that would make routing ambiguous. It runs on a loaded (sanitized)
+// configuration; hot reload rejects a configuration that fails validation and keeps the
+// previous one active.
+func (cfg *Config) Validate() error {
+	if cfg == nil {
+		return errors.New("config is nil")
+	}
+	var errs []error
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port %d is out of range", cfg.Port))
+	}
+
+	claudeKeys := make(map[string]struct{}, len(cfg.ClaudeKey))
+	for i, key := range cfg.ClaudeKey {
+		id := strings.TrimSpace(key.APIKey) + "|" + strings.TrimSpace(key.BaseURL)
+		if _, exists := claudeKeys[id]; exists && strings.TrimSpace(key.APIKey) != "" {
+			errs = append(errs, fmt.Errorf("claude-api-key[%d]: duplicate api-key and base-url", i))
+		}
+		claudeKeys[id] = struct{}{}
+	}
+	codexKeys := make(map[string]struct{}, len(cfg.CodexKey))
+	for i, key := range cfg.CodexKey {
+		id := strings.TrimSpace(key.APIKey) + "|" + key.BaseURL
+		if _, exists := codexKeys[id]; exists && strings.TrimSpace(key.APIKey) != "" {
+			errs = append(errs, fmt.Errorf("codex-api-key[%d]: duplicate api-key and base-url", i))
+		}
+		codexKeys[id] = struct{}{}
+	}
+
+	compatNames := make(map[string]struct{}, len(cfg.OpenAICompatibility))
+	for i, compat := range cfg.OpenAICompatibility {
+		name := strings.ToLower(compat.Name)
+		if name == "" {
+			errs = append(errs, fmt.Errorf("openai-compatibility[%d]: name is required", i))
+			continue
+		}
+		if _, exists := compatNames[name]; exists {
+			errs = append(errs, fmt.Errorf("openai-compatibility[%d]: duplicate name %q", i, compat.Name))
+		}
+		compatNames[name] = struct{}{}
+		aliases := make(map[string]struct{}, len(compat.Models))
+		for _, model := range compat.Models {
+			alias := strings.ToLower(strings.TrimSpace(model.Alias))
+			if alias == "" {
+				continue
+			}
+			if _, exists := aliases[alias]; exists {
+				errs = append(errs, fmt.Errorf("openai-compatibility[%d]: duplicate model alias %q", i, model.Alias))
+			}
+			aliases[alias] = struct{}{}
+		}
+	}
+
+	for channel, aliases := range cfg.OAuthModelAlias {
+		for _, alias := range aliases {
+			if strings.EqualFold(alias.Name, alias.Alias) && !alias.Fork {
+				errs = append(errs, fmt.Errorf("oauth-model-alias.%s: alias %q maps to itself", channel, alias.Alias))
+			}
+		}
+	}
+
+	quotaKeys := make(map[string]struct{}, len(cfg.APIKeyQuota.Keys))
+	for i, entry := range cfg.APIKeyQuota.Keys {
+		if _, exists := quotaKeys[entry.APIKey]; exists {
+			errs = append(errs, fmt.Errorf("api-key-quota.keys[%d]: duplicate api-key", i))
+		}
+		quotaKeys[entry.APIKey] = struct{}{}
+	}
+
+	cacheRules := make(map[string]struct{}, len(cfg.PromptCache.Models))
+	for i, rule := range cfg.PromptCache.Models {
+		name := strings.ToLower(rule.Name)
+		if _, exists := cacheRules[name]; exists {
+			errs = append(errs, fmt.Errorf("prompt-cache.models[%d]: duplicate name %q", i, rule.Name))
+		}
+		cacheRules[name] = struct{}{}
+	}
+	return errors.Join(errs...)
+}
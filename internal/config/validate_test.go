@@ -0,0 +1,42 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := &Config{
+		Port:      8317,
+		ClaudeKey: []ClaudeKey{{APIKey: "a"}, {APIKey: "a", BaseURL: "https://proxy.example"}},
+		OpenAICompatibility: []OpenAICompatibility{
+			{Name: "one", BaseURL: "https://one.example", Models: []OpenAICompatibilityModel{{Name: "m1", Alias: "x"}, {Name: "m2", Alias: "y"}}},
+			{Name: "two", BaseURL: "https://two.example", Models: []OpenAICompatibilityModel{{Name: "m1", Alias: "x"}}},
+		},
+		OAuthModelAlias: map[string][]OAuthModelAlias{"codex": {{Name: "gpt-5", Alias: "gpt-5", Fork: true}}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+
+	invalid := &Config{
+		Port:      70000,
+		ClaudeKey: []ClaudeKey{{APIKey: "a"}, {APIKey: "a"}},
+		OpenAICompatibility: []OpenAICompatibility{
+			{Name: "one", BaseURL: "https://one.example", Models: []OpenAICompatibilityModel{{Name: "m1", Alias: "x"}, {Name: "m2", Alias: "X"}}},
+			{Name: "ONE", BaseURL: "https://two.example"},
+		},
+		OAuthModelAlias: map[string][]OAuthModelAlias{"codex": {{Name: "gpt-5", Alias: "gpt-5"}}},
+		APIKeyQuota:     APIKeyQuotaConfig{Keys: []APIKeyQuotaEntry{{APIKey: "k"}, {APIKey: "k"}}},
+		PromptCache:     PromptCacheConfig{Models: []PromptCacheModelRule{{Name: "claude-*"}, {Name: "claude-*"}}},
+	}
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil, want errors")
+	}
+	for _, want := range []string{"port 70000", "claude-api-key[1]", `duplicate model alias "X"`, `duplicate name "ONE"`, "maps to itself", "api-key-quota.keys[1]", "prompt-cache.models[1]"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error %q does not mention %q", err, want)
+		}
+	}
+}
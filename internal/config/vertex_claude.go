@@ -0,0 +1,82 @@
+package config
+
+import "strings"
+
+// VertexClaudeConfig routes Anthropic Claude models through Vertex AI service account
+// credentials. Listed models are served by the Anthropic publisher endpoints of each
+// matching Vertex project instead of the Gemini ones.
+type VertexClaudeConfig struct {
+	// Projects optionally restricts Claude routing to the Vertex credentials of these GCP
+	// project IDs. When empty, every Vertex service account credential serves the models.
+	Projects []string `yaml:"projects,omitempty" json:"projects,omitempty"`
+
+	// Models maps Vertex Claude model IDs (e.g. "claude-sonnet-4-5@20250929") to the
+	// client-visible aliases.
+	Models []VertexCompatModel `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// AppliesToProject reports whether Claude models should be served by the given project.
+func (c VertexClaudeConfig) AppliesToProject(projectID string) bool {
+	if len(c.Models) == 0 {
+		return false
+	}
+	if len(c.Projects) == 0 {
+		return true
+	}
+	projectID = strings.TrimSpace(projectID)
+	for _, project := range c.Projects {
+		if strings.EqualFold(project, projectID) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpstreamModel resolves a client model name or alias to its Vertex Claude model ID.
+func (c VertexClaudeConfig) UpstreamModel(model string) (string, bool) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return "", false
+	}
+	for _, entry := range c.Models {
+		if strings.EqualFold(entry.Alias, model) || strings.EqualFold(entry.Name, model) {
+			return entry.Name, true
+		}
+	}
+	return "", false
+}
+
+// SanitizeVertexClaude trims the Vertex Claude configuration and drops models without a name.
+// Models without an alias are exposed under their Vertex model ID.
+func (cfg *Config) SanitizeVertexClaude() {
+	if cfg == nil {
+		return
+	}
+	projects := make([]string, 0, len(cfg.VertexClaude.Projects))
+	for _, project := range cfg.VertexClaude.Projects {
+		if project = strings.TrimSpace(project); project != "" {
+			projects = append(projects, project)
+		}
+	}
+	cfg.VertexClaude.Projects = projects
+
+	seen := make(map[string]struct{}, len(cfg.VertexClaude.Models))
+	models := make([]VertexCompatModel, 0, len(cfg.VertexClaude.Models))
+	for _, model := range cfg.VertexClaude.Models {
+		model.Name = strings.TrimSpace(model.Name)
+		model.Alias = strings.TrimSpace(model.Alias)
+		if model.Name == "" {
+			continue
+		}
+		key := strings.ToLower(model.Alias)
+		if key == "" {
+			key = strings.ToLower(model.Name)
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		models = append(models, model)
+	}
+	cfg.VertexClaude.Models = models
+}
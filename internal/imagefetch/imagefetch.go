@@ -0,0 +1,175 @@
+// Package imagefetch downloads remote images referenced by client requests so they can be
+// inlined as base64 data for providers that cannot, or should not, fetch URLs themselves.
+package imagefetch
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultMaxBytes = 5 << 20
+	defaultTimeout  = 10 * time.Second
+)
+
+// Settings configures remote image fetching.
+type Settings struct {
+	// MaxBytes rejects images larger than this.
+	MaxBytes int64
+	// Timeout bounds one download.
+	Timeout time.Duration
+	// AllowedHosts limits fetching to these hosts and their subdomains; empty allows any host.
+	AllowedHosts []string
+	// AllowPrivateNetworks permits loopback, private and link-local addresses.
+	AllowPrivateNetworks bool
+	// Client overrides the HTTP client used for downloads; its transport is used as-is.
+	Client *http.Client
+}
+
+var active atomic.Pointer[Settings]
+
+// SetSettings replaces the active settings; nil disables fetching.
+func SetSettings(settings *Settings) {
+	active.Store(settings)
+}
+
+// Active returns the active settings, or nil when fetching is disabled.
+func Active() *Settings {
+	return active.Load()
+}
+
+// errBlockedAddress is returned when a URL resolves to a non-public address.
+var errBlockedAddress = errors.New("image url resolves to a non-public address")
+
+// Fetch downloads the image at rawURL and returns its media type and base64 data. Only http(s)
+// URLs on allowed hosts are fetched, and the response must be an image within MaxBytes.
+func (s *Settings) Fetch(ctx context.Context, rawURL string) (string, string, error) {
+	parsed, errParse := url.Parse(rawURL)
+	if errParse != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return "", "", fmt.Errorf("unsupported image url %q", rawURL)
+	}
+	if !s.hostAllowed(parsed.Hostname()) {
+		return "", "", fmt.Errorf("image host %s is not allowed", parsed.Hostname())
+	}
+	maxBytes := s.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if errReq != nil {
+		return "", "", errReq
+	}
+	req.Header.Set("Accept", "image/*")
+	client := *s.client()
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		if !s.hostAllowed(next.URL.Hostname()) {
+			return fmt.Errorf("image redirect to host %s is not allowed", next.URL.Hostname())
+		}
+		return nil
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return "", "", fmt.Errorf("image fetch failed: %w", errDo)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return "", "", fmt.Errorf("image fetch failed with status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxBytes {
+		return "", "", fmt.Errorf("image is %d bytes, above the %d byte limit", resp.ContentLength, maxBytes)
+	}
+	data, errRead := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if errRead != nil {
+		return "", "", fmt.Errorf("image read failed: %w", errRead)
+	}
+	if int64(len(data)) > maxBytes {
+		return "", "", fmt.Errorf("image exceeds the %d byte limit", maxBytes)
+	}
+	mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if !strings.HasPrefix(mediaType, "image/") {
+		mediaType, _, _ = strings.Cut(http.DetectContentType(data), ";")
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", "", fmt.Errorf("url did not return an image (content type %s)", mediaType)
+	}
+	return mediaType, base64.StdEncoding.EncodeToString(data), nil
+}
+
+func (s *Settings) hostAllowed(host string) bool {
+	if len(s.AllowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range s.AllowedHosts {
+		allowed = strings.ToLower(strings.TrimPrefix(allowed, "."))
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Settings) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	if s.AllowPrivateNetworks {
+		return anyNetworkClient
+	}
+	return publicNetworkClient
+}
+
+var (
+	anyNetworkClient    = newClient(false)
+	publicNetworkClient = newClient(true)
+)
+
+// newClient builds the download client. With publicOnly, the resolved address is checked at
+// dial time so redirects and DNS tricks cannot reach internal services; no proxy is used so
+// the check applies to the real destination.
+func newClient(publicOnly bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if publicOnly {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, errSplit := net.SplitHostPort(address)
+			if errSplit != nil {
+				return errSplit
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !publicIP(ip) {
+				return errBlockedAddress
+			}
+			return nil
+		}
+	}
+	return &http.Client{Transport: &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}}
+}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast())
+}
@@ -0,0 +1,71 @@
+package imagefetch
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n0000")
+
+func newImageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(pngHeader)
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchInlinesImages(t *testing.T) {
+	server := newImageServer(t)
+	settings := &Settings{AllowPrivateNetworks: true}
+	mediaType, data, err := settings.Fetch(context.Background(), server.URL+"/image.png")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if mediaType != "image/png" || data != base64.StdEncoding.EncodeToString(pngHeader) {
+		t.Fatalf("Fetch = %s %s", mediaType, data)
+	}
+}
+
+func TestFetchRejects(t *testing.T) {
+	server := newImageServer(t)
+	cases := map[string]struct {
+		settings *Settings
+		url      string
+		want     string
+	}{
+		"private address": {&Settings{}, server.URL + "/image.png", "non-public"},
+		"not an image":    {&Settings{AllowPrivateNetworks: true}, server.URL + "/page.html", "did not return an image"},
+		"too large":       {&Settings{AllowPrivateNetworks: true, MaxBytes: 4}, server.URL + "/image.png", "limit"},
+		"host not listed": {&Settings{AllowPrivateNetworks: true, AllowedHosts: []string{"cdn.example.com"}}, server.URL + "/image.png", "not allowed"},
+		"bad scheme":      {&Settings{}, "file:///etc/passwd", "unsupported"},
+	}
+	for name, tc := range cases {
+		if _, _, err := tc.settings.Fetch(context.Background(), tc.url); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want containing %q", name, err, tc.want)
+		}
+	}
+}
+
+func TestHostAllowedMatchesSubdomains(t *testing.T) {
+	settings := &Settings{AllowedHosts: []string{"example.com"}}
+	for host, want := range map[string]bool{"example.com": true, "cdn.example.com": true, "badexample.com": false} {
+		if got := settings.hostAllowed(host); got != want {
+			t.Errorf("hostAllowed(%s) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -144,14 +145,27 @@ func ResolveLogDirectory(cfg *config.Config) string {
 
 // ConfigureLogOutput switches the global log destination between rotating files and stdout.
 // When logsMaxTotalSizeMB > 0, a background cleaner removes the oldest log files in the logs directory
-// until the total size is within the limit.
+// until the total size is within the limit. The same cleaner applies the log-rotation schedule and
+// the request log compression and retention settings.
 func ConfigureLogOutput(cfg *config.Config) error {
 	SetupBaseLogger()
 
 	writerMu.Lock()
 	defer writerMu.Unlock()
 
+	stopLogDirCleanerLocked()
 	logDir := ResolveLogDirectory(cfg)
+	rotation := cfg.LogRotation
+	policy := logDirPolicy{
+		maxBytes: int64(max(cfg.LogsMaxTotalSizeMB, 0)) * 1024 * 1024,
+		retention: requestLogRetention{
+			maxAge:   time.Duration(rotation.MaxAgeDays) * 24 * time.Hour,
+			maxFiles: rotation.RequestLogsMaxFiles,
+		},
+	}
+	if rotation.Compress {
+		policy.retention.compressAfter = time.Duration(max(rotation.CompressAfterMinutes, 1)) * time.Minute
+	}
 
 	protectedPath := ""
 	if cfg.LoggingToFile {
@@ -162,14 +176,22 @@ func ConfigureLogOutput(cfg *config.Config) error {
 			_ = logWriter.Close()
 		}
 		protectedPath = filepath.Join(logDir, "main.log")
+		maxSizeMB := rotation.MaxSizeMB
+		if maxSizeMB <= 0 {
+			maxSizeMB = 10
+		}
 		logWriter = &lumberjack.Logger{
 			Filename:   protectedPath,
-			MaxSize:    10,
-			MaxBackups: 0,
-			MaxAge:     0,
-			Compress:   false,
+			MaxSize:    maxSizeMB,
+			MaxBackups: rotation.MaxBackups,
+			MaxAge:     rotation.MaxAgeDays,
+			Compress:   rotation.Compress,
 		}
 		log.SetOutput(logWriter)
+		if rotation.RotateIntervalHours > 0 {
+			policy.rotateEvery = time.Duration(rotation.RotateIntervalHours) * time.Hour
+			policy.rotate = logWriter.Rotate
+		}
 	} else {
 		if logWriter != nil {
 			_ = logWriter.Close()
@@ -178,7 +200,7 @@ func ConfigureLogOutput(cfg *config.Config) error {
 		log.SetOutput(os.Stdout)
 	}
 
-	configureLogDirCleanerLocked(logDir, cfg.LogsMaxTotalSizeMB, protectedPath)
+	configureLogDirCleanerLocked(logDir, policy, protectedPath)
 	return nil
 }
 
@@ -15,15 +15,26 @@ const logDirCleanerInterval = time.Minute
 
 var logDirCleanerCancel context.CancelFunc
 
-func configureLogDirCleanerLocked(logDir string, maxTotalSizeMB int, protectedPath string) {
-	stopLogDirCleanerLocked()
+// logDirPolicy describes the maintenance performed on the logs directory every cleaner tick.
+type logDirPolicy struct {
+	// maxBytes caps the total size of log files; 0 disables the cap.
+	maxBytes int64
+	// retention compresses and prunes request logs.
+	retention requestLogRetention
+	// rotateEvery rotates the application log on a schedule; 0 disables it.
+	rotateEvery time.Duration
+	// rotate rotates the application log.
+	rotate func() error
+}
 
-	if maxTotalSizeMB <= 0 {
-		return
-	}
+func (p logDirPolicy) active() bool {
+	return p.maxBytes > 0 || p.retention.active() || (p.rotateEvery > 0 && p.rotate != nil)
+}
 
-	maxBytes := int64(maxTotalSizeMB) * 1024 * 1024
-	if maxBytes <= 0 {
+func configureLogDirCleanerLocked(logDir string, policy logDirPolicy, protectedPath string) {
+	stopLogDirCleanerLocked()
+
+	if !policy.active() {
 		return
 	}
 
@@ -34,7 +45,7 @@ func configureLogDirCleanerLocked(logDir string, maxTotalSizeMB int, protectedPa
 
 	ctx, cancel := context.WithCancel(context.Background())
 	logDirCleanerCancel = cancel
-	go runLogDirCleaner(ctx, filepath.Clean(dir), maxBytes, strings.TrimSpace(protectedPath))
+	go runLogDirCleaner(ctx, filepath.Clean(dir), policy, strings.TrimSpace(protectedPath))
 }
 
 func stopLogDirCleanerLocked() {
@@ -45,12 +56,29 @@ func stopLogDirCleanerLocked() {
 	logDirCleanerCancel = nil
 }
 
-func runLogDirCleaner(ctx context.Context, logDir string, maxBytes int64, protectedPath string) {
+func runLogDirCleaner(ctx context.Context, logDir string, policy logDirPolicy, protectedPath string) {
 	ticker := time.NewTicker(logDirCleanerInterval)
 	defer ticker.Stop()
 
+	lastRotate := time.Now()
 	cleanOnce := func() {
-		deleted, errClean := enforceLogDirSizeLimit(logDir, maxBytes, protectedPath)
+		now := time.Now()
+		if policy.rotateEvery > 0 && policy.rotate != nil && now.Sub(lastRotate) >= policy.rotateEvery && ctx.Err() == nil {
+			lastRotate = now
+			if errRotate := policy.rotate(); errRotate != nil {
+				log.WithError(errRotate).Warn("logging: failed to rotate log file")
+			}
+		}
+		if policy.retention.active() {
+			compressed, pruned := policy.retention.apply(logDir, now)
+			if compressed > 0 || pruned > 0 {
+				log.Debugf("logging: compressed %d and removed %d request log file(s)", compressed, pruned)
+			}
+		}
+		if policy.maxBytes <= 0 {
+			return
+		}
+		deleted, errClean := enforceLogDirSizeLimit(logDir, policy.maxBytes, protectedPath)
 		if errClean != nil {
 			log.WithError(errClean).Warn("logging: failed to enforce log directory size limit")
 			return
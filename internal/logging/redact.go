@@ -0,0 +1,229 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// redactedValue replaces redacted header values and JSON fields.
+const redactedValue = "[REDACTED]"
+
+// defaultRedactHeaders are redacted unless the built-in rules are disabled.
+var defaultRedactHeaders = []string{"authorization", "proxy-authorization", "x-api-key", "x-goog-api-key", "cookie"}
+
+// defaultRedactPatterns strip inline base64 image data: data URLs and long base64 strings in
+// "data"/"b64_json" fields (Claude image sources, Gemini inline data, OpenAI image results).
+var defaultRedactPatterns = []config.RedactPattern{
+	{Pattern: `data:([\w.+-]+/[\w.+-]+);base64,[A-Za-z0-9+/=]{64,}`, Replacement: "data:$1;base64," + redactedValue},
+	{Pattern: `("(?:data|b64_json)"\s*:\s*")[A-Za-z0-9+/=]{256,}"`, Replacement: "${1}" + redactedValue + `"`},
+}
+
+type redactPattern struct {
+	re          *regexp.Regexp
+	replacement []byte
+}
+
+// Redactor scrubs request log sections before they are persisted: header values, JSON fields
+// selected by path, regular expression matches, and bodies above a size limit.
+type Redactor struct {
+	headers      map[string]struct{}
+	jsonPaths    [][]string
+	patterns     []redactPattern
+	maxBodyBytes int
+}
+
+// NewRedactor builds a redactor from cfg. It returns nil when redaction is disabled.
+func NewRedactor(cfg config.RedactConfig) *Redactor {
+	if !cfg.Enabled {
+		return nil
+	}
+	r := &Redactor{headers: make(map[string]struct{}), maxBodyBytes: max(cfg.MaxBodyBytes, 0)}
+	headers := cfg.Headers
+	patterns := cfg.Patterns
+	if !cfg.DisableDefaults {
+		headers = append(append([]string(nil), defaultRedactHeaders...), headers...)
+		patterns = append(append([]config.RedactPattern(nil), defaultRedactPatterns...), patterns...)
+	}
+	quoted := make([]string, 0, len(headers))
+	for _, header := range headers {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if header == "" {
+			continue
+		}
+		if _, exists := r.headers[header]; !exists {
+			r.headers[header] = struct{}{}
+			quoted = append(quoted, regexp.QuoteMeta(header))
+		}
+	}
+	if len(quoted) > 0 {
+		// Header lines embedded in text sections, e.g. the upstream request dump.
+		r.patterns = append(r.patterns, redactPattern{
+			re:          regexp.MustCompile(`(?im)^([ \t]*(?:` + strings.Join(quoted, "|") + `)[ \t]*:[ \t]*)\S[^\r\n]*`),
+			replacement: []byte("${1}" + redactedValue),
+		})
+	}
+	for _, pattern := range patterns {
+		re, errCompile := regexp.Compile(pattern.Pattern)
+		if errCompile != nil {
+			log.WithField("value", pattern.Pattern).Warnf("redact: ignoring invalid pattern: %v", errCompile)
+			continue
+		}
+		replacement := pattern.Replacement
+		if replacement == "" {
+			replacement = redactedValue
+		}
+		r.patterns = append(r.patterns, redactPattern{re: re, replacement: []byte(replacement)})
+	}
+	for _, path := range cfg.JSONPaths {
+		if path = strings.Trim(strings.TrimSpace(path), "."); path != "" {
+			r.jsonPaths = append(r.jsonPaths, strings.Split(path, "."))
+		}
+	}
+	return r
+}
+
+// Headers returns a copy of headers with redacted values replaced.
+func (r *Redactor) Headers(headers map[string][]string) map[string][]string {
+	if r == nil || len(headers) == 0 || len(r.headers) == 0 {
+		return headers
+	}
+	out := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		if _, redact := r.headers[strings.ToLower(strings.TrimSpace(key))]; redact {
+			replaced := make([]string, len(values))
+			for i := range replaced {
+				replaced[i] = redactedValue
+			}
+			out[key] = replaced
+			continue
+		}
+		out[key] = values
+	}
+	return out
+}
+
+// Body redacts JSON paths (when body is JSON) and patterns, then applies the size limit.
+func (r *Redactor) Body(body []byte) []byte {
+	if r == nil || len(body) == 0 {
+		return body
+	}
+	return r.truncate(r.Chunk(r.redactJSONPaths(body)))
+}
+
+// Chunk applies the pattern rules only; it is used for streamed fragments where JSON paths and
+// truncation cannot be evaluated per chunk.
+func (r *Redactor) Chunk(chunk []byte) []byte {
+	if r == nil || len(chunk) == 0 {
+		return chunk
+	}
+	for _, pattern := range r.patterns {
+		chunk = pattern.re.ReplaceAll(chunk, pattern.replacement)
+	}
+	return chunk
+}
+
+// MaxBodyBytes returns the body size limit; 0 means unlimited.
+func (r *Redactor) MaxBodyBytes() int {
+	if r == nil {
+		return 0
+	}
+	return r.maxBodyBytes
+}
+
+func (r *Redactor) truncate(body []byte) []byte {
+	if r.maxBodyBytes <= 0 || len(body) <= r.maxBodyBytes {
+		return body
+	}
+	out := make([]byte, 0, r.maxBodyBytes+48)
+	out = append(out, body[:r.maxBodyBytes]...)
+	return append(out, truncationMarker(len(body)-r.maxBodyBytes)...)
+}
+
+func truncationMarker(omitted int) string {
+	return fmt.Sprintf("\n...[truncated %d bytes]\n", omitted)
+}
+
+func (r *Redactor) redactJSONPaths(body []byte) []byte {
+	if len(r.jsonPaths) == 0 || !gjson.ValidBytes(body) {
+		return body
+	}
+	root := gjson.ParseBytes(body)
+	var matches []string
+	for _, segments := range r.jsonPaths {
+		collectJSONPaths(root, segments, "", &matches)
+	}
+	for _, path := range matches {
+		updated, errSet := sjson.SetBytes(body, path, redactedValue)
+		if errSet != nil {
+			continue
+		}
+		body = updated
+	}
+	return body
+}
+
+// collectJSONPaths expands segments against node into concrete sjson paths. A "*" segment
+// matches every array element or object key.
+func collectJSONPaths(node gjson.Result, segments []string, prefix string, out *[]string) {
+	if len(segments) == 0 {
+		if prefix != "" {
+			*out = append(*out, prefix)
+		}
+		return
+	}
+	segment, rest := segments[0], segments[1:]
+	if segment == "*" {
+		index := 0
+		node.ForEach(func(key, value gjson.Result) bool {
+			name := strconv.Itoa(index)
+			if node.IsObject() {
+				name = escapeJSONPathKey(key.String())
+			}
+			collectJSONPaths(value, rest, joinJSONPath(prefix, name), out)
+			index++
+			return true
+		})
+		return
+	}
+	escaped := escapeJSONPathKey(segment)
+	child := node.Get(escaped)
+	if !child.Exists() {
+		return
+	}
+	collectJSONPaths(child, rest, joinJSONPath(prefix, escaped), out)
+}
+
+func joinJSONPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func escapeJSONPathKey(key string) string {
+	var b strings.Builder
+	for _, ch := range key {
+		switch ch {
+		case '.', '*', '?', '#', '@', '|', '!', '=', '<', '>', '%', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
+
+// maskHeaderValue masks a header value for the log unless it was already redacted.
+func maskHeaderValue(key, value string) string {
+	if value == redactedValue {
+		return value
+	}
+	return util.MaskSensitiveHeaderValue(key, value)
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestNewRedactor_Disabled(t *testing.T) {
+	if r := NewRedactor(config.RedactConfig{}); r != nil {
+		t.Fatalf("NewRedactor() = %+v, want nil when disabled", r)
+	}
+	var r *Redactor
+	body := []byte(`{"a":1}`)
+	if got := r.Body(body); string(got) != string(body) {
+		t.Fatalf("nil Body() = %s, want input unchanged", got)
+	}
+}
+
+func TestRedactor_Headers(t *testing.T) {
+	r := NewRedactor(config.RedactConfig{Enabled: true, Headers: []string{"X-Custom-Token"}})
+	got := r.Headers(map[string][]string{
+		"Authorization":  {"Bearer sk-secret"},
+		"X-Custom-Token": {"abc"},
+		"Content-Type":   {"application/json"},
+	})
+	if got["Authorization"][0] != redactedValue || got["X-Custom-Token"][0] != redactedValue {
+		t.Fatalf("headers = %v, want authorization and custom token redacted", got)
+	}
+	if got["Content-Type"][0] != "application/json" {
+		t.Fatalf("content-type = %q, want unchanged", got["Content-Type"][0])
+	}
+
+	text := r.Body([]byte("POST /v1/messages\nAuthorization: Bearer sk-secret\nAccept: */*\n"))
+	if strings.Contains(string(text), "sk-secret") || !strings.Contains(string(text), "Accept: */*") {
+		t.Fatalf("text section = %q, want only the authorization line redacted", text)
+	}
+}
+
+func TestRedactor_JSONPaths(t *testing.T) {
+	r := NewRedactor(config.RedactConfig{
+		Enabled:         true,
+		DisableDefaults: true,
+		JSONPaths:       []string{"metadata.user_id", "messages.*.content"},
+	})
+	got := string(r.Body([]byte(`{"metadata":{"user_id":"u-1"},"messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"yo"}]}`)))
+	want := `{"metadata":{"user_id":"[REDACTED]"},"messages":[{"role":"user","content":"[REDACTED]"},{"role":"assistant","content":"[REDACTED]"}]}`
+	if got != want {
+		t.Fatalf("Body() = %s, want %s", got, want)
+	}
+}
+
+func TestRedactor_PatternsAndDefaults(t *testing.T) {
+	r := NewRedactor(config.RedactConfig{
+		Enabled:  true,
+		Patterns: []config.RedactPattern{{Pattern: `sk-[A-Za-z0-9]{8,}`, Replacement: "sk-***"}},
+	})
+	image := strings.Repeat("A", 300)
+	body := `{"key":"sk-abcdefgh123","source":{"type":"base64","data":"` + image + `"},"url":"data:image/png;base64,` + image + `"}`
+	got := string(r.Body([]byte(body)))
+	if strings.Contains(got, image) {
+		t.Fatalf("Body() kept base64 image data: %s", got)
+	}
+	if !strings.Contains(got, `"key":"sk-***"`) {
+		t.Fatalf("Body() = %s, want custom pattern applied", got)
+	}
+	if !strings.Contains(got, `"url":"data:image/png;base64,[REDACTED]"`) {
+		t.Fatalf("Body() = %s, want data URL redacted", got)
+	}
+}
+
+func TestRedactor_Truncate(t *testing.T) {
+	r := NewRedactor(config.RedactConfig{Enabled: true, DisableDefaults: true, MaxBodyBytes: 4})
+	got := string(r.Body([]byte("abcdefghij")))
+	if got != "abcd"+truncationMarker(6) {
+		t.Fatalf("Body() = %q, want truncated to 4 bytes", got)
+	}
+}
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type replayKey struct{}
+
+// ReplayCapture collects the upstream traffic of a request replayed through the management API.
+// Upstream traffic of replayed requests is captured even when request logging is disabled.
+type ReplayCapture struct {
+	mu               sync.Mutex
+	upstreamRequest  []byte
+	upstreamResponse []byte
+}
+
+// WithReplay marks ctx as a management replay and returns the capture receiving its upstream traffic.
+func WithReplay(ctx context.Context) (context.Context, *ReplayCapture) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	capture := &ReplayCapture{}
+	return context.WithValue(ctx, replayKey{}, capture), capture
+}
+
+// ReplayCaptureFrom returns the replay capture of ctx, or nil when ctx is not a replay.
+func ReplayCaptureFrom(ctx context.Context) *ReplayCapture {
+	if ctx == nil {
+		return nil
+	}
+	capture, _ := ctx.Value(replayKey{}).(*ReplayCapture)
+	return capture
+}
+
+// SetUpstreamRequest replaces the captured upstream request log.
+func (c *ReplayCapture) SetUpstreamRequest(data []byte) {
+	c.mu.Lock()
+	c.upstreamRequest = bytes.Clone(data)
+	c.mu.Unlock()
+}
+
+// SetUpstreamResponse replaces the captured upstream response log.
+func (c *ReplayCapture) SetUpstreamResponse(data []byte) {
+	c.mu.Lock()
+	c.upstreamResponse = bytes.Clone(data)
+	c.mu.Unlock()
+}
+
+// UpstreamRequest returns the captured upstream requests, one section per attempt.
+func (c *ReplayCapture) UpstreamRequest() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.upstreamRequest
+}
+
+// UpstreamResponse returns the captured upstream responses, one section per attempt.
+func (c *ReplayCapture) UpstreamResponse() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.upstreamResponse
+}
+
+// LoggedRequest is the downstream request recorded at the top of a request log file.
+type LoggedRequest struct {
+	URL     string
+	Method  string
+	Headers http.Header
+	Body    []byte
+}
+
+// ErrNoRequestBody reports a request log without a request body section, such as a websocket transcript.
+var ErrNoRequestBody = errors.New("request log has no request body")
+
+// ParseRequestLog reads the request info, headers and body sections written by FileRequestLogger.
+func ParseRequestLog(data []byte) (*LoggedRequest, error) {
+	parsed := &LoggedRequest{Headers: make(http.Header)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	section := ""
+	var body []string
+	hasBody := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "=== ") && strings.HasSuffix(line, " ===") {
+			if section == "REQUEST BODY" {
+				break
+			}
+			section = strings.TrimSuffix(strings.TrimPrefix(line, "=== "), " ===")
+			hasBody = hasBody || section == "REQUEST BODY"
+			continue
+		}
+		switch section {
+		case "REQUEST INFO":
+			if value, ok := strings.CutPrefix(line, "URL: "); ok {
+				parsed.URL = value
+			} else if value, ok = strings.CutPrefix(line, "Method: "); ok {
+				parsed.Method = value
+			}
+		case "HEADERS":
+			if key, value, ok := strings.Cut(line, ": "); ok {
+				parsed.Headers.Add(key, value)
+			}
+		case "REQUEST BODY":
+			body = append(body, line)
+		}
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return nil, errScan
+	}
+	if parsed.URL == "" || parsed.Method == "" {
+		return nil, errors.New("request log has no request info")
+	}
+	if !hasBody {
+		return nil, ErrNoRequestBody
+	}
+	parsed.Body = []byte(strings.TrimRight(strings.Join(body, "\n"), "\n"))
+	return parsed, nil
+}
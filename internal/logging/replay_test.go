@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRequestLog(t *testing.T) {
+	data := []byte("=== REQUEST INFO ===\nVersion: dev\nURL: /v1/chat/completions?key=ab...cd\nMethod: POST\nTimestamp: 2025-01-01T00:00:00Z\n\n" +
+		"=== HEADERS ===\nContent-Type: application/json\nAuthorization: Bearer sk-...abcd\n\n" +
+		"=== REQUEST BODY ===\n{\"model\":\"gpt-4o\",\n\"messages\":[]}\n\n" +
+		"=== API REQUEST 1 ===\nBody:\n{}\n\n=== RESPONSE ===\nStatus: 200\n")
+
+	logged, err := ParseRequestLog(data)
+	if err != nil {
+		t.Fatalf("ParseRequestLog: %v", err)
+	}
+	if logged.URL != "/v1/chat/completions?key=ab...cd" || logged.Method != "POST" {
+		t.Fatalf("unexpected request info: %+v", logged)
+	}
+	if logged.Headers.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers: %v", logged.Headers)
+	}
+	if string(logged.Body) != "{\"model\":\"gpt-4o\",\n\"messages\":[]}" {
+		t.Fatalf("unexpected body: %q", logged.Body)
+	}
+
+	_, err = ParseRequestLog([]byte("=== REQUEST INFO ===\nURL: /v1/responses\nMethod: GET\n\n=== HEADERS ===\n\n=== WEBSOCKET TIMELINE ===\n"))
+	if !errors.Is(err, ErrNoRequestBody) {
+		t.Fatalf("expected ErrNoRequestBody, got %v", err)
+	}
+}
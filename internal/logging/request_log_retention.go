@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// compressedLogSuffix is appended to request logs once they are compressed.
+const compressedLogSuffix = ".gz"
+
+// RequestLogFile describes a request log file in the logs directory.
+type RequestLogFile struct {
+	// Name is the file name inside the logs directory.
+	Name string `json:"name"`
+	// RequestID is the request ID embedded in the file name.
+	RequestID string `json:"request-id"`
+	// Size is the size of the file on disk.
+	Size int64 `json:"size"`
+	// Modified is the last modification time as a Unix timestamp.
+	Modified int64 `json:"modified"`
+	// Compressed reports whether the file is gzipped.
+	Compressed bool `json:"compressed"`
+	// Error reports whether the file is a forced error log.
+	Error bool `json:"error"`
+}
+
+// ListRequestLogs returns the request logs in dir, newest first. A missing directory yields no files.
+func ListRequestLogs(dir string) ([]RequestLogFile, error) {
+	entries, errRead := os.ReadDir(dir)
+	if errRead != nil {
+		if os.IsNotExist(errRead) {
+			return nil, nil
+		}
+		return nil, errRead
+	}
+	files := make([]RequestLogFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !isRequestLogFileName(entry.Name()) {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		name := entry.Name()
+		files = append(files, RequestLogFile{
+			Name:       name,
+			RequestID:  RequestLogID(name),
+			Size:       info.Size(),
+			Modified:   info.ModTime().Unix(),
+			Compressed: strings.HasSuffix(name, compressedLogSuffix),
+			Error:      strings.HasPrefix(name, "error-"),
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Modified > files[j].Modified })
+	return files, nil
+}
+
+// RequestLogID extracts the request ID from a request log file name such as
+// v1-responses-2025-12-23T195811-a1b2c3d4.log or its compressed form.
+func RequestLogID(name string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(name, compressedLogSuffix), ".log")
+	if idx := strings.LastIndex(base, "-"); idx >= 0 {
+		return base[idx+1:]
+	}
+	return ""
+}
+
+// isRequestLogFileName reports whether name is a request log. main.log and its rotated backups
+// are application logs.
+func isRequestLogFileName(name string) bool {
+	return isLogFileName(name) && !strings.HasPrefix(strings.ToLower(name), "main")
+}
+
+// requestLogRetention compresses and prunes request logs.
+type requestLogRetention struct {
+	// compressAfter gzips request logs older than this; 0 disables compression.
+	compressAfter time.Duration
+	// maxAge deletes request logs older than this; 0 keeps them.
+	maxAge time.Duration
+	// maxFiles keeps only the newest request logs; 0 keeps all.
+	maxFiles int
+}
+
+func (r requestLogRetention) active() bool {
+	return r.compressAfter > 0 || r.maxAge > 0 || r.maxFiles > 0
+}
+
+// apply prunes request logs in dir by age and count, then compresses the remaining aged ones.
+func (r requestLogRetention) apply(dir string, now time.Time) (compressed, pruned int) {
+	files, errList := ListRequestLogs(dir)
+	if errList != nil {
+		log.WithError(errList).Warn("logging: failed to list request logs")
+		return 0, 0
+	}
+	kept := files[:0]
+	for i, file := range files {
+		modified := time.Unix(file.Modified, 0)
+		expired := r.maxAge > 0 && now.Sub(modified) > r.maxAge
+		if expired || (r.maxFiles > 0 && i >= r.maxFiles) {
+			if errRemove := os.Remove(filepath.Join(dir, file.Name)); errRemove != nil {
+				log.WithError(errRemove).Warnf("logging: failed to remove request log: %s", file.Name)
+				continue
+			}
+			pruned++
+			continue
+		}
+		kept = append(kept, file)
+	}
+	if r.compressAfter <= 0 {
+		return compressed, pruned
+	}
+	for _, file := range kept {
+		if file.Compressed || now.Sub(time.Unix(file.Modified, 0)) < r.compressAfter {
+			continue
+		}
+		if errCompress := compressLogFile(filepath.Join(dir, file.Name)); errCompress != nil {
+			log.WithError(errCompress).Warnf("logging: failed to compress request log: %s", file.Name)
+			continue
+		}
+		compressed++
+	}
+	return compressed, pruned
+}
+
+// compressLogFile replaces path with a gzipped copy that keeps its modification time.
+func compressLogFile(path string) error {
+	src, errOpen := os.Open(path)
+	if errOpen != nil {
+		return errOpen
+	}
+	defer func() { _ = src.Close() }()
+	info, errStat := src.Stat()
+	if errStat != nil {
+		return errStat
+	}
+
+	tmp, errCreate := os.CreateTemp(filepath.Dir(path), "compress-*.tmp")
+	if errCreate != nil {
+		return errCreate
+	}
+	tmpPath := tmp.Name()
+	zw := gzip.NewWriter(tmp)
+	_, errCopy := io.Copy(zw, src)
+	errGzip := zw.Close()
+	errClose := tmp.Close()
+	_ = src.Close()
+	if errCopy != nil || errGzip != nil || errClose != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("write compressed log: %w", errors.Join(errCopy, errGzip, errClose))
+	}
+	if errChtimes := os.Chtimes(tmpPath, info.ModTime(), info.ModTime()); errChtimes != nil {
+		_ = os.Remove(tmpPath)
+		return errChtimes
+	}
+	if errRename := os.Rename(tmpPath, path+compressedLogSuffix); errRename != nil {
+		_ = os.Remove(tmpPath)
+		return errRename
+	}
+	return os.Remove(path)
+}
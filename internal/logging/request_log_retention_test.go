@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRequestLogRetentionPrunesAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	writeLogFile(t, filepath.Join(dir, "main.log"), 10, now.Add(-72*time.Hour))
+	writeLogFile(t, filepath.Join(dir, "v1-chat-completions-2025-01-01T000000-expired1.log"), 10, now.Add(-72*time.Hour))
+	writeLogFile(t, filepath.Join(dir, "v1-chat-completions-2025-01-02T000000-oldest01.log"), 10, now.Add(-3*time.Hour))
+	writeLogFile(t, filepath.Join(dir, "v1-chat-completions-2025-01-02T010000-aged0001.log"), 10, now.Add(-2*time.Hour))
+	writeLogFile(t, filepath.Join(dir, "error-v1-messages-2025-01-02T020000-fresh001.log"), 10, now.Add(-time.Minute))
+
+	retention := requestLogRetention{compressAfter: time.Hour, maxAge: 48 * time.Hour, maxFiles: 2}
+	compressed, pruned := retention.apply(dir, now)
+	if compressed != 1 || pruned != 2 {
+		t.Fatalf("compressed = %d, pruned = %d; want 1 and 2", compressed, pruned)
+	}
+
+	files, err := ListRequestLogs(dir)
+	if err != nil {
+		t.Fatalf("list request logs: %v", err)
+	}
+	if len(files) != 2 || files[0].RequestID != "fresh001" || !files[0].Error || files[1].RequestID != "aged0001" || !files[1].Compressed {
+		t.Fatalf("unexpected request logs: %+v", files)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "main.log")); err != nil {
+		t.Fatalf("expected main.log to be kept: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, files[1].Name))
+	if err != nil {
+		t.Fatalf("open compressed log: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil || len(data) != 10 {
+		t.Fatalf("decompressed %d bytes, err %v", len(data), err)
+	}
+}
+
+func TestRequestLogID(t *testing.T) {
+	cases := map[string]string{
+		"v1-responses-2025-12-23T195811-a1b2c3d4.log":         "a1b2c3d4",
+		"error-v1-messages-2025-12-23T195811-a1b2c3d4.log.gz": "a1b2c3d4",
+	}
+	for name, want := range cases {
+		if got := RequestLogID(name); got != want {
+			t.Fatalf("RequestLogID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -23,8 +23,8 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
-	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 )
 
 var requestLogID atomic.Uint64
@@ -148,6 +148,9 @@ type FileRequestLogger struct {
 
 	// errorLogsMaxFiles limits the number of error log files retained.
 	errorLogsMaxFiles int
+
+	// redactor scrubs log sections before they are written; nil disables redaction.
+	redactor atomic.Pointer[Redactor]
 }
 
 // NewFileRequestLogger creates a new file-based request logger.
@@ -198,6 +201,11 @@ func (l *FileRequestLogger) SetErrorLogsMaxFiles(maxFiles int) {
 	l.errorLogsMaxFiles = maxFiles
 }
 
+// SetRedaction replaces the redaction rules applied to every log written afterwards.
+func (l *FileRequestLogger) SetRedaction(cfg config.RedactConfig) {
+	l.redactor.Store(NewRedactor(cfg))
+}
+
 // LogRequest logs a complete non-streaming request/response cycle to a file.
 //
 // Parameters:
@@ -231,6 +239,17 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 		return nil
 	}
 
+	redactor := l.redactor.Load()
+	if redactor != nil {
+		requestHeaders = redactor.Headers(requestHeaders)
+		responseHeaders = redactor.Headers(responseHeaders)
+		body = redactor.Body(body)
+		websocketTimeline = redactor.Body(websocketTimeline)
+		apiRequest = redactor.Body(apiRequest)
+		apiResponse = redactor.Body(apiResponse)
+		apiWebsocketTimeline = redactor.Body(apiWebsocketTimeline)
+	}
+
 	// Ensure logs directory exists
 	if errEnsure := l.ensureLogsDir(); errEnsure != nil {
 		return fmt.Errorf("failed to create logs directory: %w", errEnsure)
@@ -260,6 +279,7 @@ func (l *FileRequestLogger) logRequest(url, method string, requestHeaders map[st
 		// If decompression fails, continue with original response and annotate the log output.
 		responseToWrite = response
 	}
+	responseToWrite = redactor.Body(responseToWrite)
 
 	logFile, errOpen := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if errOpen != nil {
@@ -330,6 +350,9 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 	filename := l.generateFilename(url, requestID)
 	filePath := filepath.Join(l.logsDir, filename)
 
+	redactor := l.redactor.Load()
+	headers = redactor.Headers(headers)
+	body = redactor.Body(body)
 	requestHeaders := make(map[string][]string, len(headers))
 	for key, values := range headers {
 		headerValues := make([]string, len(values))
@@ -362,6 +385,7 @@ func (l *FileRequestLogger) LogStreamingRequest(url, method string, headers map[
 		chunkChan:        make(chan []byte, 100), // Buffered channel for async writes
 		closeChan:        make(chan struct{}),
 		errorChan:        make(chan error, 1),
+		redactor:         redactor,
 	}
 
 	// Start async writer goroutine
@@ -480,7 +504,7 @@ func (l *FileRequestLogger) cleanupOldErrorLogs() error {
 			continue
 		}
 		name := entry.Name()
-		if !strings.HasPrefix(name, "error-") || !strings.HasSuffix(name, ".log") {
+		if !strings.HasPrefix(name, "error-") || !isLogFileName(name) {
 			continue
 		}
 		info, errInfo := entry.Info()
@@ -623,7 +647,7 @@ func writeRequestInfoWithBody(
 	}
 	for key, values := range headers {
 		for _, value := range values {
-			masked := util.MaskSensitiveHeaderValue(key, value)
+			masked := maskHeaderValue(key, value)
 			if _, errWrite := io.WriteString(w, fmt.Sprintf("%s: %s\n", key, masked)); errWrite != nil {
 				return errWrite
 			}
@@ -1136,7 +1160,7 @@ func (l *FileRequestLogger) formatRequestInfo(url, method string, headers map[st
 	content.WriteString("=== HEADERS ===\n")
 	for key, values := range headers {
 		for _, value := range values {
-			masked := util.MaskSensitiveHeaderValue(key, value)
+			masked := maskHeaderValue(key, value)
 			content.WriteString(fmt.Sprintf("%s: %s\n", key, masked))
 		}
 	}
@@ -1210,6 +1234,13 @@ type FileStreamingLogWriter struct {
 
 	// apiResponseTimestamp captures when the API response was received.
 	apiResponseTimestamp time.Time
+
+	// redactor scrubs buffered sections and response chunks; nil disables redaction.
+	redactor *Redactor
+
+	// responseBytes and omittedBytes track the spooled response size for truncation.
+	responseBytes int
+	omittedBytes  int
 }
 
 // WriteChunkAsync writes a response chunk asynchronously (non-blocking).
@@ -1224,6 +1255,7 @@ func (w *FileStreamingLogWriter) WriteChunkAsync(chunk []byte) {
 	// Make a copy of the chunk to avoid data races
 	chunkCopy := make([]byte, len(chunk))
 	copy(chunkCopy, chunk)
+	chunkCopy = w.redactor.Chunk(chunkCopy)
 
 	// Non-blocking send
 	select {
@@ -1249,7 +1281,7 @@ func (w *FileStreamingLogWriter) WriteStatus(status int, headers map[string][]st
 	w.responseStatus = status
 	if headers != nil {
 		w.responseHeaders = make(map[string][]string, len(headers))
-		for key, values := range headers {
+		for key, values := range w.redactor.Headers(headers) {
 			headerValues := make([]string, len(values))
 			copy(headerValues, values)
 			w.responseHeaders[key] = headerValues
@@ -1270,7 +1302,7 @@ func (w *FileStreamingLogWriter) WriteAPIRequest(apiRequest []byte) error {
 	if len(apiRequest) == 0 {
 		return nil
 	}
-	w.apiRequest = bytes.Clone(apiRequest)
+	w.apiRequest = w.redactor.Body(bytes.Clone(apiRequest))
 	return nil
 }
 
@@ -1285,7 +1317,7 @@ func (w *FileStreamingLogWriter) WriteAPIResponse(apiResponse []byte) error {
 	if len(apiResponse) == 0 {
 		return nil
 	}
-	w.apiResponse = bytes.Clone(apiResponse)
+	w.apiResponse = w.redactor.Body(bytes.Clone(apiResponse))
 	return nil
 }
 
@@ -1300,7 +1332,7 @@ func (w *FileStreamingLogWriter) WriteAPIWebsocketTimeline(apiWebsocketTimeline
 	if len(apiWebsocketTimeline) == 0 {
 		return nil
 	}
-	w.apiWebsocketTimeline = bytes.Clone(apiWebsocketTimeline)
+	w.apiWebsocketTimeline = w.redactor.Body(bytes.Clone(apiWebsocketTimeline))
 	return nil
 }
 
@@ -1362,10 +1394,20 @@ func (w *FileStreamingLogWriter) Close() error {
 func (w *FileStreamingLogWriter) asyncWriter() {
 	defer close(w.closeChan)
 
+	limit := w.redactor.MaxBodyBytes()
 	for chunk := range w.chunkChan {
 		if w.responseBodyFile == nil {
 			continue
 		}
+		if limit > 0 {
+			keep := min(max(limit-w.responseBytes, 0), len(chunk))
+			w.omittedBytes += len(chunk) - keep
+			chunk = chunk[:keep]
+			if len(chunk) == 0 {
+				continue
+			}
+		}
+		w.responseBytes += len(chunk)
 		if _, errWrite := w.responseBodyFile.Write(chunk); errWrite != nil {
 			select {
 			case w.errorChan <- errWrite:
@@ -1384,6 +1426,14 @@ func (w *FileStreamingLogWriter) asyncWriter() {
 	if w.responseBodyFile == nil {
 		return
 	}
+	if w.omittedBytes > 0 {
+		if _, errWrite := io.WriteString(w.responseBodyFile, truncationMarker(w.omittedBytes)); errWrite != nil {
+			select {
+			case w.errorChan <- errWrite:
+			default:
+			}
+		}
+	}
 	if errClose := w.responseBodyFile.Close(); errClose != nil {
 		select {
 		case w.errorChan <- errClose:
@@ -0,0 +1,248 @@
+// Package moderation runs client prompts through configurable moderation backends (keyword
+// lists, regular expressions and the OpenAI moderation API) before they are proxied upstream.
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// ActionReject rejects flagged requests.
+	ActionReject = "reject"
+	// ActionFlag lets flagged requests through and only records the decision.
+	ActionFlag = "flag"
+
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "omni-moderation-latest"
+	defaultOpenAITimeout = 10 * time.Second
+
+	// maxPromptBytes bounds the text sent to the moderation backend.
+	maxPromptBytes = 64 << 10
+)
+
+// Settings configures the moderation stage.
+type Settings struct {
+	// Action is ActionReject or ActionFlag.
+	Action string
+	// FailOpen lets requests through when a backend fails; otherwise they are rejected.
+	FailOpen bool
+	// Keywords are matched case-insensitively as substrings of the prompt.
+	Keywords []string
+	// Patterns are matched against the prompt.
+	Patterns []*regexp.Regexp
+	// OpenAI enables the OpenAI moderation API backend when non-nil.
+	OpenAI *OpenAISettings
+}
+
+// OpenAISettings configures the OpenAI moderation API backend.
+type OpenAISettings struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	// Categories limits flagging to these categories; empty uses the API's flagged verdict.
+	Categories []string
+	Timeout    time.Duration
+	// Client overrides the HTTP client used for moderation calls.
+	Client *http.Client
+}
+
+// Decision is the outcome of moderating one prompt.
+type Decision struct {
+	Flagged bool
+	// Source names the backend that flagged the prompt: "keyword", "pattern" or "openai".
+	Source string
+	// Categories lists the matched categories.
+	Categories []string
+}
+
+var active atomic.Pointer[Settings]
+
+// SetSettings replaces the active moderation settings; nil disables moderation.
+func SetSettings(settings *Settings) {
+	active.Store(settings)
+}
+
+// Active returns the active settings, or nil when moderation is disabled.
+func Active() *Settings {
+	return active.Load()
+}
+
+// Reject reports whether flagged requests are rejected.
+func (s *Settings) Reject() bool {
+	return s != nil && s.Action != ActionFlag
+}
+
+// Check moderates text with the local lists first and then the OpenAI backend.
+func (s *Settings) Check(ctx context.Context, text string) (Decision, error) {
+	if s == nil || strings.TrimSpace(text) == "" {
+		return Decision{}, nil
+	}
+	lower := strings.ToLower(text)
+	for _, keyword := range s.Keywords {
+		if keyword != "" && strings.Contains(lower, strings.ToLower(keyword)) {
+			return Decision{Flagged: true, Source: "keyword", Categories: []string{"keyword"}}, nil
+		}
+	}
+	for _, pattern := range s.Patterns {
+		if pattern != nil && pattern.MatchString(text) {
+			return Decision{Flagged: true, Source: "pattern", Categories: []string{"pattern"}}, nil
+		}
+	}
+	if s.OpenAI != nil {
+		return s.OpenAI.check(ctx, text)
+	}
+	return Decision{}, nil
+}
+
+func (o *OpenAISettings) check(ctx context.Context, text string) (Decision, error) {
+	if len(text) > maxPromptBytes {
+		text = text[:maxPromptBytes]
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(o.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	model := strings.TrimSpace(o.Model)
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = defaultOpenAITimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	payload, errMarshal := json.Marshal(map[string]string{"model": model, "input": text})
+	if errMarshal != nil {
+		return Decision{}, errMarshal
+	}
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/moderations", bytes.NewReader(payload))
+	if errReq != nil {
+		return Decision{}, errReq
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	}
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return Decision{}, fmt.Errorf("moderation request failed: %w", errDo)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, errRead := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if errRead != nil {
+		return Decision{}, fmt.Errorf("moderation response read failed: %w", errRead)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return Decision{}, fmt.Errorf("moderation request failed with status %d", resp.StatusCode)
+	}
+
+	result := gjson.GetBytes(body, "results.0")
+	if !result.Exists() {
+		return Decision{}, fmt.Errorf("moderation response has no results")
+	}
+	var categories []string
+	result.Get("categories").ForEach(func(key, value gjson.Result) bool {
+		if value.Bool() {
+			categories = append(categories, key.String())
+		}
+		return true
+	})
+	if len(o.Categories) == 0 {
+		if !result.Get("flagged").Bool() {
+			return Decision{}, nil
+		}
+		return Decision{Flagged: true, Source: "openai", Categories: categories}, nil
+	}
+	var matched []string
+	for _, category := range categories {
+		for _, wanted := range o.Categories {
+			if strings.EqualFold(category, wanted) {
+				matched = append(matched, category)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return Decision{}, nil
+	}
+	return Decision{Flagged: true, Source: "openai", Categories: matched}, nil
+}
+
+// PromptText collects the user-authored text of an OpenAI Chat Completions, OpenAI Responses,
+// Claude Messages or Gemini request body. Non-JSON bodies yield "".
+func PromptText(body []byte) string {
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		return ""
+	}
+	var parts []string
+	add := func(value gjson.Result) {
+		collectText(value, &parts)
+	}
+	root := gjson.ParseBytes(body)
+	root.Get("messages").ForEach(func(_, message gjson.Result) bool {
+		if message.Get("role").String() == "user" {
+			add(message.Get("content"))
+		}
+		return true
+	})
+	if input := root.Get("input"); input.Type == gjson.String {
+		add(input)
+	} else {
+		input.ForEach(func(_, item gjson.Result) bool {
+			if role := item.Get("role").String(); role == "" || role == "user" {
+				add(item.Get("content"))
+			}
+			return true
+		})
+	}
+	root.Get("contents").ForEach(func(_, content gjson.Result) bool {
+		if role := content.Get("role").String(); role == "" || role == "user" {
+			content.Get("parts").ForEach(func(_, part gjson.Result) bool {
+				add(part.Get("text"))
+				return true
+			})
+		}
+		return true
+	})
+	add(root.Get("prompt"))
+	return strings.Join(parts, "\n")
+}
+
+// collectText appends the text of a string content or of the text parts of a content array.
+func collectText(value gjson.Result, parts *[]string) {
+	switch {
+	case value.Type == gjson.String:
+		if text := value.String(); text != "" {
+			*parts = append(*parts, text)
+		}
+	case value.IsArray():
+		value.ForEach(func(_, part gjson.Result) bool {
+			if part.Type == gjson.String {
+				collectText(part, parts)
+				return true
+			}
+			switch part.Get("type").String() {
+			case "", "text", "input_text":
+				collectText(part.Get("text"), parts)
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package moderation
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestPromptText(t *testing.T) {
+	tests := map[string]string{
+		"chat":      `{"messages":[{"role":"system","content":"sys"},{"role":"user","content":"hello"},{"role":"user","content":[{"type":"text","text":"part"},{"type":"image_url","image_url":{"url":"x"}}]}]}`,
+		"responses": `{"input":[{"role":"user","content":[{"type":"input_text","text":"hello"}]},{"role":"assistant","content":"skip"}]}`,
+		"gemini":    `{"contents":[{"role":"user","parts":[{"text":"hello"}]},{"role":"model","parts":[{"text":"skip"}]}]}`,
+	}
+	want := map[string]string{"chat": "hello\npart", "responses": "hello", "gemini": "hello"}
+	for name, body := range tests {
+		if got := PromptText([]byte(body)); got != want[name] {
+			t.Errorf("%s: PromptText() = %q, want %q", name, got, want[name])
+		}
+	}
+	if got := PromptText([]byte(`{"input":"plain"}`)); got != "plain" {
+		t.Errorf("PromptText(string input) = %q, want plain", got)
+	}
+	if got := PromptText([]byte("not json")); got != "" {
+		t.Errorf("PromptText(invalid) = %q, want empty", got)
+	}
+}
+
+func TestCheckLocalLists(t *testing.T) {
+	settings := &Settings{Keywords: []string{"Secret Plan"}, Patterns: []*regexp.Regexp{regexp.MustCompile(`\d{3}-\d{2}-\d{4}`)}}
+	if d, _ := settings.Check(context.Background(), "the secret plan is"); !d.Flagged || d.Source != "keyword" {
+		t.Fatalf("keyword decision = %+v, want flagged by keyword", d)
+	}
+	if d, _ := settings.Check(context.Background(), "ssn 123-45-6789"); !d.Flagged || d.Source != "pattern" {
+		t.Fatalf("pattern decision = %+v, want flagged by pattern", d)
+	}
+	if d, _ := settings.Check(context.Background(), "harmless"); d.Flagged {
+		t.Fatalf("decision = %+v, want not flagged", d)
+	}
+}
+
+func TestCheckOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/v1/moderations" || r.Header.Get("Authorization") != "Bearer key" || gjson.GetBytes(body, "model").String() != defaultOpenAIModel {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		flagged := strings.Contains(gjson.GetBytes(body, "input").String(), "bad")
+		if flagged {
+			_, _ = w.Write([]byte(`{"results":[{"flagged":true,"categories":{"violence":true,"harassment":false}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"flagged":false,"categories":{"violence":false}}]}`))
+	}))
+	defer server.Close()
+
+	settings := &Settings{OpenAI: &OpenAISettings{BaseURL: server.URL + "/v1", APIKey: "key"}}
+	d, err := settings.Check(context.Background(), "something bad")
+	if err != nil || !d.Flagged || d.Source != "openai" || len(d.Categories) != 1 || d.Categories[0] != "violence" {
+		t.Fatalf("Check() = %+v, %v; want flagged violence", d, err)
+	}
+	if d, err = settings.Check(context.Background(), "fine"); err != nil || d.Flagged {
+		t.Fatalf("Check() = %+v, %v; want not flagged", d, err)
+	}
+
+	settings.OpenAI.Categories = []string{"harassment"}
+	if d, err = settings.Check(context.Background(), "something bad"); err != nil || d.Flagged {
+		t.Fatalf("Check() = %+v, %v; want not flagged outside configured categories", d, err)
+	}
+
+	settings.OpenAI.APIKey = "wrong"
+	if _, err = settings.Check(context.Background(), "something"); err == nil {
+		t.Fatal("expected error for failed moderation call")
+	}
+}
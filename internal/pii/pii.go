@@ -0,0 +1,353 @@
+// Package pii replaces personal data in client prompts with opaque tokens before they are
+// proxied upstream, and restores the original values in the responses.
+package pii
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Built-in detector names.
+const (
+	DetectorEmail      = "email"
+	DetectorPhone      = "phone"
+	DetectorCreditCard = "credit-card"
+)
+
+// BuiltinDetectors lists the built-in detectors in the order they are applied. Credit cards run
+// before phone numbers so grouped card numbers are not taken for phone numbers.
+var BuiltinDetectors = []string{DetectorEmail, DetectorCreditCard, DetectorPhone}
+
+var builtinPatterns = map[string]*regexp.Regexp{
+	DetectorEmail:      regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	DetectorCreditCard: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+	DetectorPhone:      regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .-]\d{3,4}[ .-]\d{3,4}\b`),
+}
+
+// tokenPattern matches the tokens issued by Scrub.
+var tokenPattern = regexp.MustCompile(`\[PII_[A-Z0-9_]+_\d+\]`)
+
+// maxTokenLength bounds how much trailing output a Restorer holds back waiting for the rest of
+// a token.
+const maxTokenLength = 64
+
+// skippedKeys are request fields that never carry prompt text.
+var skippedKeys = map[string]struct{}{
+	"model": {}, "role": {}, "type": {}, "id": {}, "call_id": {}, "tool_call_id": {}, "tool_use_id": {},
+	"name": {}, "signature": {}, "thoughtSignature": {}, "data": {}, "url": {}, "image_url": {},
+	"file_data": {}, "media_type": {}, "mime_type": {}, "mimeType": {}, "cache_control": {},
+}
+
+// promptRoots are the request fields holding prompt content, across the supported formats.
+var promptRoots = []string{"messages", "input", "instructions", "system", "contents", "systemInstruction", "system_instruction", "request", "prompt"}
+
+// Detector finds one kind of personal data.
+type Detector struct {
+	// Name labels the tokens of the detector, e.g. "email" issues [PII_EMAIL_1].
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate, when set, rejects matches that are not personal data.
+	Validate func(match string) bool
+}
+
+// Settings configures the scrubber.
+type Settings struct {
+	Detectors []Detector
+}
+
+var active atomic.Pointer[Settings]
+
+// SetSettings replaces the active scrubber settings; nil disables scrubbing.
+func SetSettings(settings *Settings) {
+	active.Store(settings)
+}
+
+// Active returns the active settings, or nil when scrubbing is disabled.
+func Active() *Settings {
+	return active.Load()
+}
+
+// NewSettings builds settings for the named built-in detectors (all of them when builtins is
+// empty) followed by the custom patterns, keyed by detector name.
+func NewSettings(builtins []string, custom []Detector) *Settings {
+	if len(builtins) == 0 {
+		builtins = BuiltinDetectors
+	}
+	settings := &Settings{}
+	for _, name := range BuiltinDetectors {
+		if !containsFold(builtins, name) {
+			continue
+		}
+		detector := Detector{Name: name, Pattern: builtinPatterns[name]}
+		if name == DetectorCreditCard {
+			detector.Validate = luhnValid
+		}
+		settings.Detectors = append(settings.Detectors, detector)
+	}
+	settings.Detectors = append(settings.Detectors, custom...)
+	return settings
+}
+
+// Vault maps the tokens issued for one request to the values they replace.
+type Vault struct {
+	byToken map[string]string
+	byValue map[string]string
+	counts  map[string]int
+}
+
+func newVault() *Vault {
+	return &Vault{byToken: make(map[string]string), byValue: make(map[string]string), counts: make(map[string]int)}
+}
+
+// Len returns the number of distinct values replaced.
+func (v *Vault) Len() int {
+	if v == nil {
+		return 0
+	}
+	return len(v.byToken)
+}
+
+func (v *Vault) token(kind, value string) string {
+	if token, ok := v.byValue[value]; ok {
+		return token
+	}
+	v.counts[kind]++
+	token := fmt.Sprintf("[PII_%s_%d]", kind, v.counts[kind])
+	v.byToken[token] = value
+	v.byValue[value] = token
+	return token
+}
+
+// Scrub replaces the personal data found in the prompt fields of a JSON request body with
+// tokens. Equal values share a token. Bodies that are not JSON are returned unchanged.
+func (s *Settings) Scrub(body []byte) ([]byte, *Vault) {
+	vault := newVault()
+	if s == nil || len(s.Detectors) == 0 || !gjson.ValidBytes(body) {
+		return body, vault
+	}
+	type replacement struct {
+		path  string
+		value string
+	}
+	var replacements []replacement
+	var walk func(path string, value gjson.Result)
+	walk = func(path string, value gjson.Result) {
+		switch {
+		case value.Type == gjson.String:
+			if scrubbed, changed := s.scrubText(value.String(), vault); changed {
+				replacements = append(replacements, replacement{path: path, value: scrubbed})
+			}
+		case value.IsArray(), value.IsObject():
+			value.ForEach(func(key, field gjson.Result) bool {
+				if value.IsObject() {
+					if _, skip := skippedKeys[key.String()]; skip {
+						return true
+					}
+				}
+				walk(path+"."+escapePathKey(key.String()), field)
+				return true
+			})
+		}
+	}
+	root := gjson.ParseBytes(body)
+	for _, name := range promptRoots {
+		if field := root.Get(name); field.Exists() {
+			walk(name, field)
+		}
+	}
+	for _, r := range replacements {
+		if out, errSet := sjson.SetBytes(body, r.path, r.value); errSet == nil {
+			body = out
+		}
+	}
+	return body, vault
+}
+
+func (s *Settings) scrubText(text string, vault *Vault) (string, bool) {
+	changed := false
+	for _, detector := range s.Detectors {
+		if detector.Pattern == nil {
+			continue
+		}
+		kind := tokenKind(detector.Name)
+		text = detector.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if detector.Validate != nil && !detector.Validate(match) {
+				return match
+			}
+			changed = true
+			return vault.token(kind, match)
+		})
+	}
+	return text, changed
+}
+
+// Restore replaces the tokens of vault found in data with the original values, escaped for use
+// inside JSON strings.
+func (v *Vault) Restore(data []byte) []byte {
+	if v.Len() == 0 || !bytes.Contains(data, []byte("[PII_")) {
+		return data
+	}
+	return tokenPattern.ReplaceAllFunc(data, func(token []byte) []byte {
+		value, ok := v.byToken[string(token)]
+		if !ok {
+			return token
+		}
+		return jsonEscape(value)
+	})
+}
+
+// restoreText replaces the tokens of vault found in decoded text with the original values.
+func (v *Vault) restoreText(text string) string {
+	if v.Len() == 0 || !strings.Contains(text, "[PII_") {
+		return text
+	}
+	return tokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		if value, ok := v.byToken[token]; ok {
+			return value
+		}
+		return token
+	})
+}
+
+// hasTokenPrefix reports whether prefix starts one of the tokens of vault.
+func (v *Vault) hasTokenPrefix(prefix string) bool {
+	if v == nil {
+		return false
+	}
+	for token := range v.byToken {
+		if strings.HasPrefix(token, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Restorer restores tokens in a response written in chunks, holding back a trailing partial
+// token until the rest of it arrives.
+type Restorer struct {
+	vault   *Vault
+	pending []byte
+}
+
+// NewRestorer returns a Restorer for vault.
+func NewRestorer(vault *Vault) *Restorer {
+	return &Restorer{vault: vault}
+}
+
+// Write returns the restored output that can be emitted for chunk.
+func (r *Restorer) Write(chunk []byte) []byte {
+	data := append(r.pending, chunk...)
+	r.pending = nil
+	if cut := partialTokenStart(data); cut >= 0 {
+		r.pending = append([]byte(nil), data[cut:]...)
+		data = data[:cut]
+	}
+	return r.vault.Restore(data)
+}
+
+// Flush returns the output held back by Write.
+func (r *Restorer) Flush() []byte {
+	data := r.pending
+	r.pending = nil
+	return r.vault.Restore(data)
+}
+
+// partialTokenStart returns where an unterminated token prefix at the end of data starts, or -1.
+func partialTokenStart(data []byte) int {
+	start := max(0, len(data)-maxTokenLength)
+	idx := bytes.LastIndexByte(data[start:], '[')
+	if idx < 0 {
+		return -1
+	}
+	idx += start
+	tail := data[idx:]
+	if bytes.IndexByte(tail, ']') >= 0 {
+		return -1
+	}
+	const prefix = "[PII_"
+	if len(tail) <= len(prefix) {
+		if strings.HasPrefix(prefix, string(tail)) {
+			return idx
+		}
+		return -1
+	}
+	if !bytes.HasPrefix(tail, []byte(prefix)) {
+		return -1
+	}
+	for _, c := range tail[len(prefix):] {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+			return -1
+		}
+	}
+	return idx
+}
+
+// tokenKind turns a detector name into the uppercase label used in its tokens.
+func tokenKind(name string) string {
+	var b strings.Builder
+	for _, c := range strings.ToUpper(name) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "CUSTOM"
+	}
+	return b.String()
+}
+
+// luhnValid reports whether the digits of number pass the Luhn checksum.
+func luhnValid(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func jsonEscape(value string) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if errEncode := encoder.Encode(value); errEncode != nil {
+		return []byte(value)
+	}
+	encoded := bytes.TrimSpace(buf.Bytes())
+	return encoded[1 : len(encoded)-1]
+}
+
+func escapePathKey(key string) string {
+	replacer := strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`, "|", `\|`, "#", `\#`, "@", `\@`)
+	return replacer.Replace(key)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
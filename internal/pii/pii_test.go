@@ -0,0 +1,88 @@
+package pii
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestScrubAndRestore(t *testing.T) {
+	settings := NewSettings(nil, []Detector{{Name: "employee-id", Pattern: regexp.MustCompile(`EMP-\d{6}`)}})
+	body := []byte(`{"model":"m","messages":[` +
+		`{"role":"user","content":[{"type":"text","text":"Card 4111 1111 1111 1111, call +1 415-555-0100, mail a@b.io or a@b.io"}]},` +
+		`{"role":"user","content":"EMP-123456 and order 1234567890123"}]}`)
+
+	out, vault := settings.Scrub(body)
+	first := gjson.GetBytes(out, "messages.0.content.0.text").String()
+	if first != "Card [PII_CREDIT_CARD_1], call [PII_PHONE_1], mail [PII_EMAIL_1] or [PII_EMAIL_1]" {
+		t.Fatalf("scrubbed text = %q", first)
+	}
+	// 1234567890123 fails the Luhn check and is kept.
+	if second := gjson.GetBytes(out, "messages.1.content").String(); second != "[PII_EMPLOYEE_ID_1] and order 1234567890123" {
+		t.Fatalf("scrubbed text = %q", second)
+	}
+	if vault.Len() != 4 {
+		t.Fatalf("vault holds %d values, want 4", vault.Len())
+	}
+	if restored := vault.Restore(out); string(restored) != string(body) {
+		t.Fatalf("restored = %s, want %s", restored, body)
+	}
+}
+
+func TestRestorerHoldsPartialTokens(t *testing.T) {
+	vault := newVault()
+	token := vault.token("EMAIL", `x"y@z.io`)
+	restorer := NewRestorer(vault)
+
+	var out strings.Builder
+	for _, chunk := range []string{"a [", "PII_EMA", token[len("[PII_EMA"):] + " b [not a token"} {
+		out.Write(restorer.Write([]byte(chunk)))
+	}
+	out.Write(restorer.Flush())
+	if got := out.String(); got != `a x\"y@z.io b [not a token` {
+		t.Fatalf("restored stream = %q", got)
+	}
+}
+
+func TestStreamRestorerJoinsTokensAcrossEvents(t *testing.T) {
+	vault := newVault()
+	token := vault.token("EMAIL", `x"y@z.io`)
+	restorer := NewStreamRestorer(vault)
+
+	var out strings.Builder
+	for _, chunk := range []string{
+		`data: {"choices":[{"index":0,"delta":{"content":"a ["}}]}` + "\n\n",
+		`data: {"choices":[{"index":0,"delta":{"content":"PII_EMA"}}]}` + "\n\ndata: ",
+		`{"choices":[{"index":0,"delta":{"content":"` + token[len("[PII_EMA"):] + ` b"}}]}` + "\n\n",
+		`data: [DONE]` + "\n\n",
+	} {
+		out.Write(restorer.Write([]byte(chunk)))
+	}
+	out.Write(restorer.Flush())
+	want := `data: {"choices":[{"index":0,"delta":{"content":"a "}}]}` + "\n\n" +
+		`data: {"choices":[{"index":0,"delta":{"content":""}}]}` + "\n\n" +
+		`data: {"choices":[{"index":0,"delta":{"content":"x\"y@z.io b"}}]}` + "\n\n" +
+		`data: [DONE]` + "\n\n"
+	if got := out.String(); got != want {
+		t.Fatalf("restored stream = %q, want %q", got, want)
+	}
+}
+
+func TestStreamRestorerEmitsHeldTextWhenFieldEnds(t *testing.T) {
+	vault := newVault()
+	vault.token("EMAIL", "a@b.io")
+	restorer := NewStreamRestorer(vault)
+
+	var out strings.Builder
+	out.Write(restorer.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"see [\"}}\n\n")))
+	out.Write(restorer.Write([]byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n")))
+	out.Write(restorer.Flush())
+	want := "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"see \"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"[\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n"
+	if got := out.String(); got != want {
+		t.Fatalf("restored stream = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,260 @@
+package pii
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxPendingEvent bounds how much output a StreamRestorer buffers while waiting for the end of
+// an event; longer runs are restored byte-wise.
+const maxPendingEvent = 1 << 20
+
+// slotFields are top-level event fields that tell apart the content blocks of a stream (Claude
+// "index", Responses "output_index" and "content_index"), so text of different blocks is not
+// joined.
+var slotFields = []string{"index", "output_index", "content_index"}
+
+// StreamRestorer restores tokens in a server-sent event stream. Upstreams stream text as
+// deltas inside JSON events, so a token may be split across events; the restorer decodes each
+// event, joins the string fields of consecutive events that continue the same field, and
+// re-encodes the event with the restored text.
+type StreamRestorer struct {
+	vault   *Vault
+	pending []byte
+	carries []*streamCarry
+}
+
+// streamCarry is the start of a token held back from a string field of an event until the
+// next event continuing that field arrives.
+type streamCarry struct {
+	key  string
+	path string
+	text string
+	// event and data are the event the text came from and its data payload, used to emit the
+	// text on its own when no event continues the field.
+	event [][]byte
+	data  int
+	json  []byte
+}
+
+// NewStreamRestorer returns a StreamRestorer for vault.
+func NewStreamRestorer(vault *Vault) *StreamRestorer {
+	return &StreamRestorer{vault: vault}
+}
+
+// Write returns the restored output of the events completed by chunk.
+func (r *StreamRestorer) Write(chunk []byte) []byte {
+	r.pending = append(r.pending, chunk...)
+	var out []byte
+	for {
+		end := eventEnd(r.pending)
+		if end < 0 {
+			break
+		}
+		out = append(out, r.restoreEvent(r.pending[:end])...)
+		r.pending = r.pending[end:]
+	}
+	if len(r.pending) > maxPendingEvent {
+		out = append(out, r.flushCarries()...)
+		out = append(out, r.vault.Restore(r.pending)...)
+		r.pending = nil
+	}
+	if len(r.pending) == 0 {
+		r.pending = nil
+	}
+	return out
+}
+
+// Flush returns the output held back by Write.
+func (r *StreamRestorer) Flush() []byte {
+	out := r.flushCarries()
+	out = append(out, r.vault.Restore(r.pending)...)
+	r.pending = nil
+	return out
+}
+
+// restoreEvent restores one event, including its terminating blank line.
+func (r *StreamRestorer) restoreEvent(event []byte) []byte {
+	lines := bytes.SplitAfter(event, []byte("\n"))
+	dataLine := -1
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		if dataLine >= 0 {
+			dataLine = -1
+			break
+		}
+		dataLine = i
+	}
+	var payload []byte
+	if dataLine >= 0 {
+		payload = bytes.TrimSpace(lines[dataLine][len("data:"):])
+	}
+	if len(payload) == 0 || payload[0] != '{' || !gjson.ValidBytes(payload) {
+		out := r.flushCarries()
+		return append(out, r.vault.Restore(event)...)
+	}
+
+	root := gjson.ParseBytes(payload)
+	slot := eventSlot(root)
+	type field struct{ path, text string }
+	var fields []field
+	walkStrings(root, "", func(path, text string) {
+		fields = append(fields, field{path: path, text: text})
+	})
+
+	// Held-back text of fields this event does not continue is emitted first, on its own.
+	var out []byte
+	keep := r.carries[:0]
+	for _, carry := range r.carries {
+		continued := false
+		for _, f := range fields {
+			if slot+f.path == carry.key {
+				continued = true
+				break
+			}
+		}
+		if continued {
+			keep = append(keep, carry)
+		} else {
+			out = append(out, r.emitCarry(carry)...)
+		}
+	}
+	r.carries = keep
+
+	restored := payload
+	var held []*streamCarry
+	for _, f := range fields {
+		key := slot + f.path
+		text := f.text
+		if carry := r.takeCarry(key); carry != nil {
+			text = carry.text + text
+		}
+		if cut := partialTokenStart([]byte(text)); cut >= 0 && r.vault.hasTokenPrefix(text[cut:]) {
+			held = append(held, &streamCarry{key: key, path: f.path, text: text[cut:]})
+			text = text[:cut]
+		}
+		text = r.vault.restoreText(text)
+		if text == f.text {
+			continue
+		}
+		if updated, errSet := sjson.SetBytes(restored, f.path, text); errSet == nil {
+			restored = updated
+		}
+	}
+	if len(held) > 0 {
+		template := make([][]byte, len(lines))
+		for i, line := range lines {
+			template[i] = bytes.Clone(line)
+		}
+		for _, carry := range held {
+			carry.event, carry.data, carry.json = template, dataLine, restored
+		}
+	}
+	r.carries = append(r.carries, held...)
+	return append(out, rebuildEvent(lines, dataLine, restored)...)
+}
+
+// takeCarry removes and returns the held-back text of key.
+func (r *StreamRestorer) takeCarry(key string) *streamCarry {
+	for i, carry := range r.carries {
+		if carry.key == key {
+			r.carries = append(r.carries[:i], r.carries[i+1:]...)
+			return carry
+		}
+	}
+	return nil
+}
+
+// flushCarries emits all held-back text.
+func (r *StreamRestorer) flushCarries() []byte {
+	var out []byte
+	for _, carry := range r.carries {
+		out = append(out, r.emitCarry(carry)...)
+	}
+	r.carries = nil
+	return out
+}
+
+// emitCarry emits held-back text as a copy of the event it came from, carrying only that text.
+func (r *StreamRestorer) emitCarry(carry *streamCarry) []byte {
+	data, errSet := sjson.SetBytes(carry.json, carry.path, r.vault.restoreText(carry.text))
+	if errSet != nil {
+		return nil
+	}
+	return rebuildEvent(carry.event, carry.data, data)
+}
+
+// rebuildEvent returns the event lines with the data line replaced by payload.
+func rebuildEvent(lines [][]byte, dataLine int, payload []byte) []byte {
+	var out []byte
+	for i, line := range lines {
+		if i != dataLine {
+			out = append(out, line...)
+			continue
+		}
+		out = append(out, "data: "...)
+		out = append(out, payload...)
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			out = append(out, '\r', '\n')
+		} else if bytes.HasSuffix(line, []byte("\n")) {
+			out = append(out, '\n')
+		}
+	}
+	return out
+}
+
+// eventEnd returns the length of the first complete event in data, or -1.
+func eventEnd(data []byte) int {
+	end := -1
+	if idx := bytes.Index(data, []byte("\n\n")); idx >= 0 {
+		end = idx + 2
+	}
+	if idx := bytes.Index(data, []byte("\r\n\r\n")); idx >= 0 && (end < 0 || idx+4 < end) {
+		end = idx + 4
+	}
+	return end
+}
+
+// eventSlot identifies the content block an event belongs to.
+func eventSlot(root gjson.Result) string {
+	var b strings.Builder
+	for _, name := range slotFields {
+		if value := root.Get(name); value.Exists() {
+			b.WriteString(name)
+			b.WriteByte('=')
+			b.WriteString(value.Raw)
+			b.WriteByte('|')
+		}
+	}
+	return b.String()
+}
+
+// walkStrings calls fn with the path and decoded value of every string in value.
+func walkStrings(value gjson.Result, path string, fn func(path, text string)) {
+	switch {
+	case value.Type == gjson.String:
+		fn(path, value.String())
+	case value.IsArray():
+		for i, item := range value.Array() {
+			walkStrings(item, joinPath(path, strconv.Itoa(i)), fn)
+		}
+	case value.IsObject():
+		value.ForEach(func(key, field gjson.Result) bool {
+			walkStrings(field, joinPath(path, escapePathKey(key.String())), fn)
+			return true
+		})
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,361 @@
+// Package redisclient is a small pooled RESP client shared by the Redis-backed stores. Every
+// keyed command applies the client's key prefix, so stores sharing a database stay in their
+// own namespace.
+package redisclient
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds dialing and each command round trip when no timeout is set.
+	DefaultTimeout = 5 * time.Second
+	// defaultPoolSize is the number of connections a client opens when PoolSize is unset.
+	defaultPoolSize = 8
+)
+
+// Options configures a Redis client.
+type Options struct {
+	// Addr is the host:port of the Redis server.
+	Addr string
+	// Password authenticates the connection when non-empty.
+	Password string
+	// DB selects the logical database.
+	DB int
+	// KeyPrefix is prepended to every key the client sends.
+	KeyPrefix string
+	// Timeout bounds dialing and each command round trip; DefaultTimeout is used when <= 0.
+	Timeout time.Duration
+	// PoolSize caps the connections used concurrently; a default of 8 is used when <= 0.
+	PoolSize int
+}
+
+// Client runs commands on a small pool of connections, each bounded by the configured
+// timeout, so a stalled server fails requests instead of blocking them.
+type Client struct {
+	opts   Options
+	slots  chan struct{}
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+// conn is one pooled connection to the Redis server.
+type conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+// New connects to Redis and returns a client.
+func New(opts Options) (*Client, error) {
+	opts.Addr = strings.TrimSpace(opts.Addr)
+	if opts.Addr == "" {
+		return nil, errors.New("redis: address is empty")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = defaultPoolSize
+	}
+	c := &Client{opts: opts, slots: make(chan struct{}, opts.PoolSize)}
+	cn, errConnect := c.dial()
+	if errConnect != nil {
+		return nil, errConnect
+	}
+	c.release(cn)
+	return c, nil
+}
+
+// Get returns the value stored under key; ok is false when the key does not exist.
+func (c *Client) Get(key string) (value string, ok bool, err error) {
+	reply, errDo := c.do("GET", c.opts.KeyPrefix+key)
+	if errDo != nil {
+		return "", false, errDo
+	}
+	value, ok = reply.(string)
+	return value, ok, nil
+}
+
+// Set stores value under key, expiring it after ttl.
+func (c *Client) Set(key, value string, ttl time.Duration) error {
+	_, errDo := c.do("SET", c.opts.KeyPrefix+key, value, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return errDo
+}
+
+// Del removes the keys.
+func (c *Client) Del(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, c.opts.KeyPrefix+key)
+	}
+	_, errDo := c.do(args...)
+	return errDo
+}
+
+// DeletePrefix removes every key starting with prefix.
+func (c *Client) DeletePrefix(prefix string) error {
+	pattern := globEscape(c.opts.KeyPrefix+prefix) + "*"
+	cursor := "0"
+	for {
+		reply, errDo := c.do("SCAN", cursor, "MATCH", pattern, "COUNT", "200")
+		if errDo != nil {
+			return errDo
+		}
+		parts, ok := reply.([]any)
+		if !ok || len(parts) != 2 {
+			return errors.New("redis: unexpected SCAN reply")
+		}
+		cursor, _ = parts[0].(string)
+		if keys, okKeys := parts[1].([]any); okKeys && len(keys) > 0 {
+			// SCAN returns the keys with the prefix already applied.
+			args := make([]string, 0, len(keys)+1)
+			args = append(args, "DEL")
+			for _, k := range keys {
+				if name, okName := k.(string); okName {
+					args = append(args, name)
+				}
+			}
+			if _, errDel := c.do(args...); errDel != nil {
+				return errDel
+			}
+		}
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// Eval runs a Lua script with the given keys and arguments and returns its decoded reply.
+// Bulk and simple strings become string, integers become int64, arrays become []any and nil
+// replies become nil.
+func (c *Client) Eval(script string, keys []string, args ...string) (any, error) {
+	cmd := make([]string, 0, len(keys)+len(args)+3)
+	cmd = append(cmd, "EVAL", script, strconv.Itoa(len(keys)))
+	for _, key := range keys {
+		cmd = append(cmd, c.opts.KeyPrefix+key)
+	}
+	cmd = append(cmd, args...)
+	return c.do(cmd...)
+}
+
+// Ping checks that the Redis server is reachable.
+func (c *Client) Ping() error {
+	_, errPing := c.do("PING")
+	return errPing
+}
+
+// Close closes the pooled connections. Connections in use are closed when their command
+// completes.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	var errClose error
+	for _, cn := range c.idle {
+		if err := cn.conn.Close(); err != nil && errClose == nil {
+			errClose = err
+		}
+	}
+	c.idle = nil
+	return errClose
+}
+
+// do sends a command on a pooled connection and returns its reply, reconnecting once on
+// connection errors.
+func (c *Client) do(args ...string) (any, error) {
+	timer := time.NewTimer(c.opts.Timeout)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+	case <-timer.C:
+		return nil, errors.New("redis: pool exhausted")
+	}
+	defer func() { <-c.slots }()
+
+	cn, errAcquire := c.acquire()
+	if errAcquire != nil {
+		return nil, errAcquire
+	}
+	reply, errCmd := cn.roundTrip(args, c.opts.Timeout)
+	var replyErr ReplyError
+	if errCmd == nil || errors.As(errCmd, &replyErr) {
+		c.release(cn)
+		return reply, errCmd
+	}
+	// Connection-level failure: reconnect and retry once.
+	_ = cn.conn.Close()
+	cn, errConnect := c.dial()
+	if errConnect != nil {
+		return nil, errConnect
+	}
+	reply, errCmd = cn.roundTrip(args, c.opts.Timeout)
+	if errCmd != nil && !errors.As(errCmd, &replyErr) {
+		_ = cn.conn.Close()
+		return nil, errCmd
+	}
+	c.release(cn)
+	return reply, errCmd
+}
+
+// acquire returns an idle connection, dialing a new one when none is available.
+func (c *Client) acquire() (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, errors.New("redis: client closed")
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+	return c.dial()
+}
+
+// release returns cn to the idle pool, closing it when the client is closed.
+func (c *Client) release(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.opts.PoolSize {
+		_ = cn.conn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+// dial opens and authenticates a new connection.
+func (c *Client) dial() (*conn, error) {
+	netConn, errDial := net.DialTimeout("tcp", c.opts.Addr, c.opts.Timeout)
+	if errDial != nil {
+		return nil, fmt.Errorf("redis: dial %s: %w", c.opts.Addr, errDial)
+	}
+	cn := &conn{conn: netConn, reader: bufio.NewReader(netConn), writer: bufio.NewWriter(netConn)}
+	if c.opts.Password != "" {
+		if _, errAuth := cn.roundTrip([]string{"AUTH", c.opts.Password}, c.opts.Timeout); errAuth != nil {
+			_ = netConn.Close()
+			return nil, fmt.Errorf("redis: auth: %w", errAuth)
+		}
+	}
+	if c.opts.DB > 0 {
+		if _, errSelect := cn.roundTrip([]string{"SELECT", strconv.Itoa(c.opts.DB)}, c.opts.Timeout); errSelect != nil {
+			_ = netConn.Close()
+			return nil, fmt.Errorf("redis: select: %w", errSelect)
+		}
+	}
+	return cn, nil
+}
+
+// roundTrip writes one command and reads its reply within timeout.
+func (c *conn) roundTrip(args []string, timeout time.Duration) (any, error) {
+	if errDeadline := c.conn.SetDeadline(time.Now().Add(timeout)); errDeadline != nil {
+		return nil, errDeadline
+	}
+	if errWrite := WriteCommand(c.writer, args); errWrite != nil {
+		return nil, errWrite
+	}
+	if errFlush := c.writer.Flush(); errFlush != nil {
+		return nil, errFlush
+	}
+	return ReadReply(c.reader)
+}
+
+// ReplyError is an error reply (-ERR ...) returned by the server.
+type ReplyError string
+
+func (e ReplyError) Error() string { return "redis: " + string(e) }
+
+// WriteCommand encodes a command as a RESP array of bulk strings.
+func WriteCommand(w *bufio.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadReply decodes a RESP reply. Bulk and simple strings become string, integers become
+// int64, arrays become []any and nil replies become nil; error replies are returned as
+// ReplyError.
+func ReadReply(r *bufio.Reader) (any, error) {
+	line, errRead := r.ReadString('\n')
+	if errRead != nil {
+		return nil, errRead
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, ReplyError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, errSize := strconv.Atoi(line[1:])
+		if errSize != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, errFull := io.ReadFull(r, buf); errFull != nil {
+			return nil, errFull
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, errCount := strconv.Atoi(line[1:])
+		if errCount != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]any, 0, count)
+		for i := 0; i < count; i++ {
+			item, errItem := ReadReply(r)
+			if errItem != nil {
+				return nil, errItem
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// globEscape escapes glob metacharacters so prefixes match literally in SCAN MATCH.
+func globEscape(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package redisclient
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingRedis is an in-process RESP server that records commands and replies with canned
+// replies by command name.
+type recordingRedis struct {
+	mu       sync.Mutex
+	commands [][]string
+	replies  map[string]string
+}
+
+func startRecordingRedis(t *testing.T, replies map[string]string) (string, *recordingRedis) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	fake := &recordingRedis{replies: replies}
+	go func() {
+		for {
+			conn, errAccept := ln.Accept()
+			if errAccept != nil {
+				return
+			}
+			go fake.serve(conn)
+		}
+	}()
+	return ln.Addr().String(), fake
+}
+
+func (f *recordingRedis) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	for {
+		reply, err := ReadReply(reader)
+		if err != nil {
+			return
+		}
+		parts, _ := reply.([]any)
+		args := make([]string, 0, len(parts))
+		for _, p := range parts {
+			s, _ := p.(string)
+			args = append(args, s)
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, args)
+		out, ok := f.replies[args[0]]
+		f.mu.Unlock()
+		if !ok {
+			out = "+OK\r\n"
+		}
+		if _, errWrite := conn.Write([]byte(out)); errWrite != nil {
+			return
+		}
+	}
+}
+
+func (f *recordingRedis) last() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commands[len(f.commands)-1]
+}
+
+func TestClientPrefixesKeys(t *testing.T) {
+	addr, fake := startRecordingRedis(t, map[string]string{
+		"GET":  "$1\r\nv\r\n",
+		"EVAL": "*1\r\n:1\r\n",
+		"SCAN": "*2\r\n$1\r\n0\r\n*1\r\n$8\r\npre:a:b1\r\n",
+	})
+	client, err := New(Options{Addr: addr, KeyPrefix: "pre:"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if value, ok, errGet := client.Get("k"); errGet != nil || !ok || value != "v" {
+		t.Fatalf("Get = %q, %v, %v", value, ok, errGet)
+	}
+	if got := fake.last(); !reflect.DeepEqual(got, []string{"GET", "pre:k"}) {
+		t.Fatalf("GET command = %q", got)
+	}
+	if errSet := client.Set("k", "v", 2*time.Second); errSet != nil {
+		t.Fatalf("Set: %v", errSet)
+	}
+	if got := fake.last(); !reflect.DeepEqual(got, []string{"SET", "pre:k", "v", "PX", "2000"}) {
+		t.Fatalf("SET command = %q", got)
+	}
+	if _, errEval := client.Eval("return 1", []string{"a", "b"}, "x"); errEval != nil {
+		t.Fatalf("Eval: %v", errEval)
+	}
+	if got := fake.last(); !reflect.DeepEqual(got, []string{"EVAL", "return 1", "2", "pre:a", "pre:b", "x"}) {
+		t.Fatalf("EVAL command = %q", got)
+	}
+	if errDel := client.DeletePrefix("a:"); errDel != nil {
+		t.Fatalf("DeletePrefix: %v", errDel)
+	}
+	if got := fake.last(); !reflect.DeepEqual(got, []string{"DEL", "pre:a:b1"}) {
+		t.Fatalf("DEL command = %q, want the scanned key unchanged", got)
+	}
+}
+
+func TestClientReportsReplyErrors(t *testing.T) {
+	addr, _ := startRecordingRedis(t, map[string]string{"EVAL": "-ERR boom\r\n"})
+	client, err := New(Options{Addr: addr})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	if _, errEval := client.Eval("x", nil); errEval == nil || errEval.Error() != "redis: ERR boom" {
+		t.Fatalf("Eval error = %v, want the server error", errEval)
+	}
+}
+
+func TestClientTimesOutOnUnresponsiveServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	var (
+		connsMu sync.Mutex
+		conns   []net.Conn
+	)
+	t.Cleanup(func() {
+		connsMu.Lock()
+		defer connsMu.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+	go func() {
+		// Accept connections but never reply.
+		for {
+			conn, errAccept := ln.Accept()
+			if errAccept != nil {
+				return
+			}
+			connsMu.Lock()
+			conns = append(conns, conn)
+			connsMu.Unlock()
+		}
+	}()
+
+	client, err := New(Options{Addr: ln.Addr().String(), Timeout: 50 * time.Millisecond, PoolSize: 2})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = client.Ping()
+		}(i)
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected commands to time out promptly, took %v", elapsed)
+	}
+	for i, errPing := range errs {
+		if errPing == nil {
+			t.Fatalf("command %d: expected timeout error", i)
+		}
+	}
+}
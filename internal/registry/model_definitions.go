@@ -25,57 +25,57 @@ type staticModelsJSON struct {
 
 // GetClaudeModels returns the standard Claude model definitions.
 func GetClaudeModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Claude)
+	return applyModelOverrides("claude", cloneModelInfos(getModels().Claude))
 }
 
 // GetGeminiModels returns the standard Gemini model definitions.
 func GetGeminiModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Gemini)
+	return applyModelOverrides("gemini", cloneModelInfos(getModels().Gemini))
 }
 
 // GetGeminiVertexModels returns Gemini model definitions for Vertex AI.
 func GetGeminiVertexModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Vertex)
+	return applyModelOverrides("vertex", cloneModelInfos(getModels().Vertex))
 }
 
 // GetGeminiCLIModels returns Gemini model definitions for the Gemini CLI.
 func GetGeminiCLIModels() []*ModelInfo {
-	return cloneModelInfos(getModels().GeminiCLI)
+	return applyModelOverrides("gemini-cli", cloneModelInfos(getModels().GeminiCLI))
 }
 
 // GetAIStudioModels returns model definitions for AI Studio.
 func GetAIStudioModels() []*ModelInfo {
-	return cloneModelInfos(getModels().AIStudio)
+	return applyModelOverrides("aistudio", cloneModelInfos(getModels().AIStudio))
 }
 
 // GetCodexFreeModels returns model definitions for the Codex free plan tier.
 func GetCodexFreeModels() []*ModelInfo {
-	return WithCodexBuiltins(cloneModelInfos(getModels().CodexFree))
+	return applyModelOverrides("codex", WithCodexBuiltins(cloneModelInfos(getModels().CodexFree)))
 }
 
 // GetCodexTeamModels returns model definitions for the Codex team plan tier.
 func GetCodexTeamModels() []*ModelInfo {
-	return WithCodexBuiltins(cloneModelInfos(getModels().CodexTeam))
+	return applyModelOverrides("codex", WithCodexBuiltins(cloneModelInfos(getModels().CodexTeam)))
 }
 
 // GetCodexPlusModels returns model definitions for the Codex plus plan tier.
 func GetCodexPlusModels() []*ModelInfo {
-	return WithCodexBuiltins(cloneModelInfos(getModels().CodexPlus))
+	return applyModelOverrides("codex", WithCodexBuiltins(cloneModelInfos(getModels().CodexPlus)))
 }
 
 // GetCodexProModels returns model definitions for the Codex pro plan tier.
 func GetCodexProModels() []*ModelInfo {
-	return WithCodexBuiltins(cloneModelInfos(getModels().CodexPro))
+	return applyModelOverrides("codex", WithCodexBuiltins(cloneModelInfos(getModels().CodexPro)))
 }
 
 // GetKimiModels returns the standard Kimi (Moonshot AI) model definitions.
 func GetKimiModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Kimi)
+	return applyModelOverrides("kimi", cloneModelInfos(getModels().Kimi))
 }
 
 // GetAntigravityModels returns the standard Antigravity model definitions.
 func GetAntigravityModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Antigravity)
+	return applyModelOverrides("antigravity", cloneModelInfos(getModels().Antigravity))
 }
 
 // WithCodexBuiltins injects hard-coded Codex-only model definitions that should
@@ -197,6 +197,9 @@ func LookupStaticModelInfo(modelID string) *ModelInfo {
 	if modelID == "" {
 		return nil
 	}
+	if info := lookupModelOverride(modelID); info != nil {
+		return info
+	}
 
 	data := getModels()
 	allModels := [][]*ModelInfo{
@@ -0,0 +1,207 @@
+package registry
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModelOverride adds a model to a provider's static catalog or overrides the metadata of an
+// existing one. Overrides are configured under model-overrides and managed at runtime.
+type ModelOverride struct {
+	// ID is the model identifier clients send.
+	ID string `yaml:"id" json:"id"`
+	// Provider is the static catalog channel (claude, gemini, vertex, gemini-cli, aistudio,
+	// codex, kimi, antigravity).
+	Provider string `yaml:"provider" json:"provider"`
+	// DisplayName replaces the human-readable name when set.
+	DisplayName string `yaml:"display-name,omitempty" json:"display-name,omitempty"`
+	// ContextLength replaces the context window size when set.
+	ContextLength int `yaml:"context-length,omitempty" json:"context-length,omitempty"`
+	// MaxCompletionTokens replaces the maximum completion tokens when set.
+	MaxCompletionTokens int `yaml:"max-completion-tokens,omitempty" json:"max-completion-tokens,omitempty"`
+	// Thinking replaces the thinking capabilities when set.
+	Thinking *ThinkingSupport `yaml:"thinking,omitempty" json:"thinking,omitempty"`
+	// Aliases are alternative IDs that resolve to this model's metadata in LookupModelInfo.
+	// They are not registered as routable models; use oauth-model-alias for upstream renames.
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+}
+
+// modelOverrideProviders lists the channels whose static catalogs accept overrides.
+var modelOverrideProviders = map[string]struct{}{
+	"claude": {}, "gemini": {}, "vertex": {}, "gemini-cli": {}, "aistudio": {}, "codex": {}, "kimi": {}, "antigravity": {},
+}
+
+var modelOverridesStore struct {
+	mu         sync.RWMutex
+	byProvider map[string][]ModelOverride
+}
+
+// IsModelOverrideProvider reports whether provider has a static catalog that accepts overrides.
+func IsModelOverrideProvider(provider string) bool {
+	_, ok := modelOverrideProviders[strings.ToLower(strings.TrimSpace(provider))]
+	return ok
+}
+
+// SetModelOverrides replaces the active model overrides. Providers whose overrides changed are
+// reported through the model refresh callback so existing credentials re-register their models.
+func SetModelOverrides(overrides []ModelOverride) {
+	next := make(map[string][]ModelOverride)
+	for _, override := range overrides {
+		provider := strings.ToLower(strings.TrimSpace(override.Provider))
+		if strings.TrimSpace(override.ID) == "" || !IsModelOverrideProvider(provider) {
+			continue
+		}
+		next[provider] = append(next[provider], override)
+	}
+
+	modelOverridesStore.mu.Lock()
+	previous := modelOverridesStore.byProvider
+	modelOverridesStore.byProvider = next
+	modelOverridesStore.mu.Unlock()
+
+	var changed []string
+	for provider := range modelOverrideProviders {
+		before, _ := json.Marshal(previous[provider])
+		after, _ := json.Marshal(next[provider])
+		if string(before) != string(after) {
+			changed = append(changed, provider)
+		}
+	}
+	sort.Strings(changed)
+	notifyModelRefresh(changed)
+}
+
+func modelOverridesFor(provider string) []ModelOverride {
+	modelOverridesStore.mu.RLock()
+	defer modelOverridesStore.mu.RUnlock()
+	return modelOverridesStore.byProvider[provider]
+}
+
+// applyModelOverrides merges provider's overrides into models, which must already be cloned.
+func applyModelOverrides(provider string, models []*ModelInfo) []*ModelInfo {
+	overrides := modelOverridesFor(provider)
+	if len(overrides) == 0 {
+		return models
+	}
+	for _, override := range overrides {
+		id := strings.TrimSpace(override.ID)
+		merged := false
+		for _, model := range models {
+			if model != nil && strings.EqualFold(model.ID, id) {
+				mergeModelOverride(model, override)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			models = append(models, newOverrideModelInfo(provider, override, models))
+		}
+	}
+	return models
+}
+
+// lookupModelOverride resolves modelID, or one of the override aliases, against the overrides
+// of every provider.
+func lookupModelOverride(modelID string) *ModelInfo {
+	modelOverridesStore.mu.RLock()
+	providers := make([]string, 0, len(modelOverridesStore.byProvider))
+	for provider := range modelOverridesStore.byProvider {
+		providers = append(providers, provider)
+	}
+	modelOverridesStore.mu.RUnlock()
+	sort.Strings(providers)
+
+	for _, provider := range providers {
+		for _, override := range modelOverridesFor(provider) {
+			id := strings.TrimSpace(override.ID)
+			matches := strings.EqualFold(id, modelID)
+			for _, alias := range override.Aliases {
+				matches = matches || strings.EqualFold(strings.TrimSpace(alias), modelID)
+			}
+			if !matches {
+				continue
+			}
+			for _, model := range applyModelOverrides(provider, cloneModelInfos(staticModelsByProvider(provider))) {
+				if model != nil && strings.EqualFold(model.ID, id) {
+					return model
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func mergeModelOverride(model *ModelInfo, override ModelOverride) {
+	if v := strings.TrimSpace(override.DisplayName); v != "" {
+		model.DisplayName = v
+	}
+	if override.ContextLength > 0 {
+		model.ContextLength = override.ContextLength
+		model.InputTokenLimit = override.ContextLength
+	}
+	if override.MaxCompletionTokens > 0 {
+		model.MaxCompletionTokens = override.MaxCompletionTokens
+		model.OutputTokenLimit = override.MaxCompletionTokens
+	}
+	if override.Thinking != nil {
+		thinking := *override.Thinking
+		thinking.Levels = append([]string(nil), override.Thinking.Levels...)
+		model.Thinking = &thinking
+	}
+}
+
+// newOverrideModelInfo builds a catalog entry for a model the provider does not list, taking
+// the owner and type from the provider's existing models.
+func newOverrideModelInfo(provider string, override ModelOverride, siblings []*ModelInfo) *ModelInfo {
+	id := strings.TrimSpace(override.ID)
+	model := &ModelInfo{
+		ID:          id,
+		Object:      "model",
+		Created:     time.Now().Unix(),
+		OwnedBy:     provider,
+		Type:        provider,
+		DisplayName: id,
+	}
+	for _, sibling := range siblings {
+		if sibling == nil || sibling.Type == "" {
+			continue
+		}
+		model.OwnedBy = sibling.OwnedBy
+		model.Type = sibling.Type
+		// Follow the sibling's naming scheme, e.g. "models/<id>" for Gemini.
+		if sibling.Name != "" {
+			model.Name = strings.Replace(sibling.Name, sibling.ID, id, 1)
+		}
+		break
+	}
+	mergeModelOverride(model, override)
+	return model
+}
+
+// staticModelsByProvider returns the catalog section for provider without overrides applied.
+func staticModelsByProvider(provider string) []*ModelInfo {
+	data := getModels()
+	switch provider {
+	case "claude":
+		return data.Claude
+	case "gemini":
+		return data.Gemini
+	case "vertex":
+		return data.Vertex
+	case "gemini-cli":
+		return data.GeminiCLI
+	case "aistudio":
+		return data.AIStudio
+	case "codex":
+		return data.CodexPro
+	case "kimi":
+		return data.Kimi
+	case "antigravity":
+		return data.Antigravity
+	default:
+		return nil
+	}
+}
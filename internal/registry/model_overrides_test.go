@@ -0,0 +1,50 @@
+package registry
+
+import "testing"
+
+func TestSetModelOverrides(t *testing.T) {
+	var notified []string
+	SetModelRefreshCallback(func(changed []string) { notified = append(notified, changed...) })
+	t.Cleanup(func() {
+		SetModelOverrides(nil)
+		SetModelRefreshCallback(nil)
+	})
+
+	existing := GetClaudeModels()[0]
+	SetModelOverrides([]ModelOverride{
+		{ID: existing.ID, Provider: "claude", MaxCompletionTokens: 1234},
+		{ID: "claude-custom-1", Provider: "claude", DisplayName: "Custom", Thinking: &ThinkingSupport{Min: 1024, Max: 4096}, Aliases: []string{"custom-latest"}},
+		{ID: "ignored", Provider: "unknown"},
+	})
+	if len(notified) != 1 || notified[0] != "claude" {
+		t.Fatalf("notified providers = %v, want [claude]", notified)
+	}
+
+	models := GetClaudeModels()
+	if got := findModelInfo(models, existing.ID); got == nil || got.MaxCompletionTokens != 1234 {
+		t.Fatalf("existing model = %+v, want max completion tokens overridden", got)
+	}
+	added := findModelInfo(models, "claude-custom-1")
+	if added == nil || added.DisplayName != "Custom" || added.Type != existing.Type || added.OwnedBy != existing.OwnedBy {
+		t.Fatalf("added model = %+v, want custom claude model", added)
+	}
+
+	info := LookupModelInfo("custom-latest")
+	if info == nil || info.ID != "claude-custom-1" || info.Thinking == nil || info.Thinking.Max != 4096 {
+		t.Fatalf("LookupModelInfo(alias) = %+v, want custom model with thinking", info)
+	}
+
+	notified = nil
+	SetModelOverrides([]ModelOverride{
+		{ID: existing.ID, Provider: "claude", MaxCompletionTokens: 1234},
+		{ID: "claude-custom-1", Provider: "claude", DisplayName: "Custom", Thinking: &ThinkingSupport{Min: 1024, Max: 4096}, Aliases: []string{"custom-latest"}},
+	})
+	if len(notified) != 0 {
+		t.Fatalf("notified providers = %v, want none for unchanged overrides", notified)
+	}
+
+	SetModelOverrides(nil)
+	if findModelInfo(GetClaudeModels(), "claude-custom-1") != nil || LookupModelInfo("custom-latest") != nil {
+		t.Fatal("removed override is still visible")
+	}
+}
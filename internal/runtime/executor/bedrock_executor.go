@@ -0,0 +1,469 @@
+// Package executor provides runtime execution capabilities for various AI service providers.
+// This file implements the AWS Bedrock executor that sends translated Claude Messages bodies
+// to Anthropic models on Bedrock, signing requests with SigV4 or a Bedrock API key.
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// bedrockAnthropicVersion is the anthropic_version body field required by Claude on Bedrock.
+	bedrockAnthropicVersion = "bedrock-2023-05-31"
+	bedrockSigningService   = "bedrock"
+)
+
+// BedrockExecutor is a stateless executor for Anthropic Claude models on AWS Bedrock.
+type BedrockExecutor struct {
+	cfg *config.Config
+}
+
+// NewBedrockExecutor creates a new Bedrock executor instance.
+func NewBedrockExecutor(cfg *config.Config) *BedrockExecutor { return &BedrockExecutor{cfg: cfg} }
+
+// Identifier returns the executor identifier.
+func (e *BedrockExecutor) Identifier() string { return "bedrock" }
+
+// bedrockCredentials is the credential material of a Bedrock auth.
+type bedrockCredentials struct {
+	aws     helps.AWSCredentials
+	apiKey  string
+	region  string
+	baseURL string
+}
+
+// bedrockCreds extracts Bedrock credentials from auth attributes. Auths carrying a secret
+// access key sign with SigV4; otherwise api_key is a Bedrock API key sent as a bearer token.
+func bedrockCreds(a *cliproxyauth.Auth) (bedrockCredentials, error) {
+	var creds bedrockCredentials
+	if a == nil || a.Attributes == nil {
+		return creds, fmt.Errorf("bedrock executor: missing auth attributes")
+	}
+	key := strings.TrimSpace(a.Attributes["api_key"])
+	creds.region = strings.TrimSpace(a.Attributes["region"])
+	creds.baseURL = strings.TrimSuffix(strings.TrimSpace(a.Attributes["base_url"]), "/")
+	if secret := strings.TrimSpace(a.Attributes["secret_access_key"]); secret != "" {
+		creds.aws = helps.AWSCredentials{
+			AccessKeyID:     key,
+			SecretAccessKey: secret,
+			SessionToken:    strings.TrimSpace(a.Attributes["session_token"]),
+		}
+	} else {
+		creds.apiKey = key
+	}
+	if key == "" || creds.region == "" {
+		return creds, fmt.Errorf("bedrock executor: missing credentials or region")
+	}
+	if creds.baseURL == "" {
+		creds.baseURL = fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", creds.region)
+	}
+	return creds, nil
+}
+
+// authorize sets Bedrock authentication on req. It must run after all signed headers are set.
+func (c bedrockCredentials) authorize(req *http.Request, body []byte) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		return
+	}
+	helps.SignAWSRequestV4(req, body, c.aws, c.region, bedrockSigningService, time.Now())
+}
+
+// PrepareRequest injects Bedrock credentials into the outgoing HTTP request.
+func (e *BedrockExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.Auth) error {
+	if req == nil {
+		return nil
+	}
+	creds, errCreds := bedrockCreds(auth)
+	if errCreds != nil {
+		return errCreds
+	}
+	var body []byte
+	if req.GetBody != nil {
+		rc, errBody := req.GetBody()
+		if errBody != nil {
+			return errBody
+		}
+		body, errBody = io.ReadAll(rc)
+		_ = rc.Close()
+		if errBody != nil {
+			return errBody
+		}
+	}
+	creds.authorize(req, body)
+	return nil
+}
+
+// HttpRequest injects Bedrock credentials into the request and executes it.
+func (e *BedrockExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("bedrock executor: request is nil")
+	}
+	if ctx == nil {
+		ctx = req.Context()
+	}
+	httpReq := req.WithContext(ctx)
+	if err := e.PrepareRequest(httpReq, auth); err != nil {
+		return nil, err
+	}
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	return httpClient.Do(httpReq)
+}
+
+// bedrockModelURL builds a Bedrock runtime URL for a model or inference profile ID. Colons
+// in versioned IDs are escaped like the AWS SDKs do, so the SigV4 canonical path matches.
+func bedrockModelURL(baseURL, model, action string) string {
+	return baseURL + "/model/" + strings.ReplaceAll(url.PathEscape(model), ":", "%3A") + "/" + action
+}
+
+// prepareBedrockClaudeBody adapts a Claude Messages body to Bedrock: the model moves to the
+// URL, streaming is selected by the endpoint, betas move to anthropic_beta and
+// anthropic_version is set.
+func prepareBedrockClaudeBody(body []byte) []byte {
+	betas, body := extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.DeleteBytes(body, "stream")
+	if len(betas) > 0 {
+		body, _ = sjson.SetBytes(body, "anthropic_beta", betas)
+	}
+	body, _ = sjson.SetBytes(body, "anthropic_version", bedrockAnthropicVersion)
+	return body
+}
+
+// doRequest sends a Bedrock request and returns the response once it is known to be successful.
+func (e *BedrockExecutor) doRequest(ctx context.Context, auth *cliproxyauth.Auth, creds bedrockCredentials, url string, body []byte, stream bool) (*http.Response, error) {
+	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if errNewReq != nil {
+		return nil, errNewReq
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	creds.authorize(httpReq, body)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, errDo := httpClient.Do(httpReq)
+	if errDo != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
+		return nil, errDo
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b), retryAfter: helps.ParseRetryAfter(httpResp.Header, time.Now())}
+	}
+	return httpResp, nil
+}
+
+// Execute performs a non-streaming request. Translated requests stream upstream to preserve
+// function calling, like the Claude executor, and are aggregated from the decoded events.
+func (e *BedrockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	if opts.Alt == "responses/compact" {
+		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	creds, err := bedrockCreds(auth)
+	if err != nil {
+		return resp, err
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	stream := from != to
+	body, err := buildHostedClaudeBody(ctx, e.cfg, e.Identifier(), req, opts, stream)
+	if err != nil {
+		return resp, err
+	}
+	translationBody, _ := sjson.DeleteBytes(body, "betas")
+	upstreamBody := prepareBedrockClaudeBody(body)
+
+	action := "invoke"
+	if stream {
+		action = "invoke-with-response-stream"
+	}
+	httpResp, err := e.doRequest(ctx, auth, creds, bedrockModelURL(creds.baseURL, baseModel, action), upstreamBody, stream)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+	}()
+
+	var data []byte
+	if stream {
+		var sse bytes.Buffer
+		if err = decodeBedrockEventStream(httpResp.Body, func(line []byte) {
+			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+			sse.Write(line)
+			sse.WriteByte('\n')
+		}); err != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, err)
+			return resp, err
+		}
+		data = sse.Bytes()
+		if err = validateClaudeStreamingResponse(data); err != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, err)
+			return resp, err
+		}
+		var streamUsage helps.ClaudeStreamUsage
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if detail, ok := streamUsage.Parse(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+		}
+	} else {
+		data, err = io.ReadAll(httpResp.Body)
+		if err != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, err)
+			return resp, err
+		}
+		helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+		reporter.Publish(ctx, helps.ParseClaudeUsage(data))
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(withUpstreamCreated(ctx, httpResp.Header), to, from, req.Model, opts.OriginalRequest, translationBody, data, &param)
+	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
+}
+
+// ExecuteStream performs a streaming request and re-emits the Bedrock events as Claude SSE.
+func (e *BedrockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	if opts.Alt == "responses/compact" {
+		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
+	}
+	creds, err := bedrockCreds(auth)
+	if err != nil {
+		return nil, err
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body, err := buildHostedClaudeBody(ctx, e.cfg, e.Identifier(), req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	translationBody, _ := sjson.DeleteBytes(body, "betas")
+	upstreamBody := prepareBedrockClaudeBody(body)
+
+	httpResp, err := e.doRequest(ctx, auth, creds, bedrockModelURL(creds.baseURL, baseModel, "invoke-with-response-stream"), upstreamBody, true)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("bedrock executor: close response body error: %v", errClose)
+			}
+		}()
+		var param any
+		respCtx := withUpstreamCreated(ctx, httpResp.Header)
+		var streamUsage helps.ClaudeStreamUsage
+		errDecode := decodeBedrockEventStream(httpResp.Body, func(line []byte) {
+			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := streamUsage.Parse(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+			// Claude clients receive the SSE stream as-is.
+			if from == to {
+				cloned := make([]byte, len(line)+1)
+				copy(cloned, line)
+				cloned[len(line)] = '\n'
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				return
+			}
+			chunks := sdktranslator.TranslateStream(respCtx, to, from, req.Model, opts.OriginalRequest, translationBody, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+			}
+		})
+		if errDecode != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errDecode)
+			reporter.PublishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errDecode}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// CountTokens counts tokens with the Bedrock CountTokens API, estimating locally when the
+// model or region does not support it.
+func (e *BedrockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	creds, errCreds := bedrockCreds(auth)
+	if errCreds != nil {
+		return cliproxyexecutor.Response{}, errCreds
+	}
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+	if !gjson.GetBytes(body, "max_tokens").Exists() {
+		body, _ = sjson.SetBytes(body, "max_tokens", defaultModelMaxTokens)
+	}
+	body = enforceCacheControlLimit(body, 4)
+	body = normalizeCacheControlTTL(body)
+	upstreamBody := prepareBedrockClaudeBody(body)
+
+	countBody := []byte(`{"input":{"invokeModel":{"body":""}}}`)
+	countBody, _ = sjson.SetBytes(countBody, "input.invokeModel.body", base64.StdEncoding.EncodeToString(upstreamBody))
+	httpResp, err := e.doRequest(ctx, auth, creds, bedrockModelURL(creds.baseURL, baseModel, "count-tokens"), countBody, false)
+	if err != nil {
+		var status statusErr
+		if errors.As(err, &status) && (countTokensUnsupported(status.code) || status.code == http.StatusBadRequest) {
+			count, errCount := countClaudeTokensLocally(body)
+			if errCount != nil {
+				return cliproxyexecutor.Response{}, errCount
+			}
+			out := sdktranslator.TranslateTokenCount(ctx, to, from, count, []byte(fmt.Sprintf(`{"input_tokens":%d}`, count)))
+			return cliproxyexecutor.Response{Payload: out}, nil
+		}
+		return cliproxyexecutor.Response{}, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("bedrock executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return cliproxyexecutor.Response{}, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+	count := gjson.GetBytes(data, "inputTokens").Int()
+	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, []byte(fmt.Sprintf(`{"input_tokens":%d}`, count)))
+	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
+}
+
+// Refresh is a no-op: Bedrock credentials are static.
+func (e *BedrockExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+// decodeBedrockEventStream decodes an invoke-with-response-stream body and emits the Claude
+// events it carries as SSE lines. Exceptions become Claude error events.
+func decodeBedrockEventStream(r io.Reader, emit func(line []byte)) error {
+	decoder := helps.NewAWSEventStreamDecoder(r)
+	for {
+		msg, errNext := decoder.Next()
+		if errors.Is(errNext, io.EOF) {
+			return nil
+		}
+		if errNext != nil {
+			return errNext
+		}
+		for _, line := range bedrockEventToClaudeSSE(msg) {
+			emit(line)
+		}
+	}
+}
+
+// bedrockEventToClaudeSSE converts one Bedrock event stream message to Claude SSE lines.
+func bedrockEventToClaudeSSE(msg helps.AWSEventStreamMessage) [][]byte {
+	var event []byte
+	switch msg.Headers[":message-type"] {
+	case "event":
+		if msg.Headers[":event-type"] != "chunk" {
+			return nil
+		}
+		decoded, errDecode := base64.StdEncoding.DecodeString(gjson.GetBytes(msg.Payload, "bytes").String())
+		if errDecode != nil || !gjson.ValidBytes(decoded) {
+			return nil
+		}
+		event = decoded
+	case "exception", "error":
+		errType := msg.Headers[":exception-type"]
+		if errType == "" {
+			errType = msg.Headers[":error-code"]
+		}
+		message := gjson.GetBytes(msg.Payload, "message").String()
+		if message == "" {
+			message = msg.Headers[":error-message"]
+		}
+		event = []byte(`{"type":"error","error":{"type":"","message":""}}`)
+		event, _ = sjson.SetBytes(event, "error.type", bedrockClaudeErrorType(errType))
+		event, _ = sjson.SetBytes(event, "error.message", message)
+	default:
+		return nil
+	}
+	eventType := gjson.GetBytes(event, "type").String()
+	return [][]byte{
+		[]byte("event: " + eventType),
+		append([]byte("data: "), event...),
+		{},
+	}
+}
+
+// bedrockClaudeErrorType maps Bedrock stream exceptions to Anthropic error types.
+func bedrockClaudeErrorType(exception string) string {
+	switch exception {
+	case "throttlingException":
+		return "rate_limit_error"
+	case "serviceUnavailableException", "modelNotReadyException":
+		return "overloaded_error"
+	case "validationException":
+		return "invalid_request_error"
+	default:
+		return "api_error"
+	}
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// encodeEventStreamMessage builds an AWS event stream frame with string headers.
+func encodeEventStreamMessage(headers map[string]string, payload []byte) []byte {
+	var rawHeaders bytes.Buffer
+	for name, value := range headers {
+		rawHeaders.WriteByte(byte(len(name)))
+		rawHeaders.WriteString(name)
+		rawHeaders.WriteByte(7)
+		_ = binary.Write(&rawHeaders, binary.BigEndian, uint16(len(value)))
+		rawHeaders.WriteString(value)
+	}
+	total := 12 + rawHeaders.Len() + len(payload) + 4
+	frame := make([]byte, 0, total)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(total))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(rawHeaders.Len()))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+	frame = append(frame, rawHeaders.Bytes()...)
+	frame = append(frame, payload...)
+	return binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+}
+
+func bedrockChunk(event string) []byte {
+	payload := []byte(`{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(event)) + `"}`)
+	return encodeEventStreamMessage(map[string]string{":message-type": "event", ":event-type": "chunk"}, payload)
+}
+
+func TestDecodeBedrockEventStream(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(bedrockChunk(`{"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":3}}}`))
+	stream.Write(bedrockChunk(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}`))
+	stream.Write(encodeEventStreamMessage(map[string]string{":message-type": "exception", ":exception-type": "throttlingException"}, []byte(`{"message":"slow down"}`)))
+
+	var lines []string
+	if errDecode := decodeBedrockEventStream(&stream, func(line []byte) { lines = append(lines, string(line)) }); errDecode != nil {
+		t.Fatalf("decodeBedrockEventStream() error = %v", errDecode)
+	}
+	if len(lines) != 9 || lines[0] != "event: message_start" || lines[3] != "event: content_block_delta" || lines[6] != "event: error" {
+		t.Fatalf("unexpected SSE lines %q", lines)
+	}
+	if got := gjson.Get(strings.TrimPrefix(lines[4], "data: "), "delta.text").String(); got != "hi" {
+		t.Fatalf("delta text = %q", got)
+	}
+	if got := gjson.Get(strings.TrimPrefix(lines[7], "data: "), "error.type").String(); got != "rate_limit_error" {
+		t.Fatalf("error type = %q", got)
+	}
+
+	corrupt := bedrockChunk(`{"type":"ping"}`)
+	corrupt[len(corrupt)-1] ^= 0xff
+	if errDecode := decodeBedrockEventStream(bytes.NewReader(corrupt), func([]byte) {}); errDecode == nil {
+		t.Fatal("expected checksum error for corrupt frame")
+	}
+}
+
+func TestPrepareBedrockClaudeBody(t *testing.T) {
+	body := prepareBedrockClaudeBody([]byte(`{"model":"claude-sonnet-4-5","stream":true,"betas":["context-1m-2025-08-07"],"max_tokens":10}`))
+	root := gjson.ParseBytes(body)
+	if root.Get("model").Exists() || root.Get("stream").Exists() || root.Get("betas").Exists() {
+		t.Fatalf("expected model, stream and betas to be removed: %s", body)
+	}
+	if root.Get("anthropic_beta.0").String() != "context-1m-2025-08-07" || root.Get("anthropic_version").String() != bedrockAnthropicVersion {
+		t.Fatalf("unexpected Bedrock fields: %s", body)
+	}
+
+	url := bedrockModelURL("https://bedrock-runtime.us-east-1.amazonaws.com", "us.anthropic.claude-sonnet-4-5-20250929-v1:0", "invoke")
+	if url != "https://bedrock-runtime.us-east-1.amazonaws.com/model/us.anthropic.claude-sonnet-4-5-20250929-v1%3A0/invoke" {
+		t.Fatalf("url = %s", url)
+	}
+}
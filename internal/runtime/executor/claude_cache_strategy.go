@@ -0,0 +1,183 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// cacheControlStrategy places automatic cache_control breakpoints on a Claude payload
+// that carries none from the client.
+type cacheControlStrategy interface {
+	apply(payload []byte) []byte
+}
+
+// defaultCacheStrategy caches tools, system and the conversation up to the second-to-last user turn.
+type defaultCacheStrategy struct{}
+
+func (defaultCacheStrategy) apply(payload []byte) []byte { return ensureCacheControl(payload) }
+
+// systemOnlyCacheStrategy caches the static prefix only: the system prompt, or the tools
+// when the request has no system prompt.
+type systemOnlyCacheStrategy struct{}
+
+func (systemOnlyCacheStrategy) apply(payload []byte) []byte {
+	if system := gjson.GetBytes(payload, "system"); system.Exists() {
+		return injectSystemCacheControl(payload)
+	}
+	return injectToolsCacheControl(payload)
+}
+
+// rollingWindowCacheStrategy caches tools, system and the last two user turns so each
+// turn reads the previous turn's cache entry and writes a new one.
+type rollingWindowCacheStrategy struct{}
+
+func (rollingWindowCacheStrategy) apply(payload []byte) []byte {
+	payload = injectToolsCacheControl(payload)
+	payload = injectSystemCacheControl(payload)
+
+	var userMsgIndices []int
+	gjson.GetBytes(payload, "messages").ForEach(func(index, msg gjson.Result) bool {
+		if msg.Get("role").String() == "user" {
+			userMsgIndices = append(userMsgIndices, int(index.Int()))
+		}
+		return true
+	})
+	start := max(len(userMsgIndices)-2, 0)
+	for _, idx := range userMsgIndices[start:] {
+		payload = setMessageCacheControl(payload, idx)
+	}
+	return payload
+}
+
+// disabledCacheStrategy never injects breakpoints.
+type disabledCacheStrategy struct{}
+
+func (disabledCacheStrategy) apply(payload []byte) []byte { return payload }
+
+// cacheControlStrategyFor returns the strategy registered under name, falling back to the default.
+func cacheControlStrategyFor(name string) cacheControlStrategy {
+	switch name {
+	case "system-only":
+		return systemOnlyCacheStrategy{}
+	case "rolling-window":
+		return rollingWindowCacheStrategy{}
+	case "disabled":
+		return disabledCacheStrategy{}
+	default:
+		return defaultCacheStrategy{}
+	}
+}
+
+// extendedCacheTTLBeta enables the 1h prompt cache lifetime.
+const extendedCacheTTLBeta = "extended-cache-ttl-2025-04-11"
+
+// applyAutoCacheControl injects cache_control breakpoints according to the prompt-cache
+// configuration when the client did not provide any, then applies the configured TTL.
+// model is the requested model name and may carry a "(cache:1h)" suffix.
+func applyAutoCacheControl(cfg *config.Config, model string, payload []byte) []byte {
+	settings := helps.ResolvePromptCacheSettings(cfg, model)
+	if countCacheControls(payload) == 0 {
+		payload = cacheControlStrategyFor(settings.Strategy).apply(payload)
+		if settings.MinContentLength > 0 {
+			payload = dropShortCachePrefixes(payload, settings.MinContentLength)
+		}
+		if settings.Breakpoints > 0 {
+			payload = enforceCacheControlLimit(payload, settings.Breakpoints)
+		}
+	}
+	if settings.TTL == "1h" {
+		payload = applyExtendedCacheTTL(payload)
+	}
+	return payload
+}
+
+// applyExtendedCacheTTL sets ttl "1h" on every cache_control without an explicit ttl and
+// requests the extended-cache-ttl beta through the body betas list.
+func applyExtendedCacheTTL(payload []byte) []byte {
+	var paths []string
+	collect := func(prefix string, blocks gjson.Result) {
+		blocks.ForEach(func(idx, block gjson.Result) bool {
+			if cc := block.Get("cache_control"); cc.Exists() && !cc.Get("ttl").Exists() {
+				paths = append(paths, fmt.Sprintf("%s.%d.cache_control.ttl", prefix, idx.Int()))
+			}
+			return true
+		})
+	}
+	collect("tools", gjson.GetBytes(payload, "tools"))
+	if system := gjson.GetBytes(payload, "system"); system.IsArray() {
+		collect("system", system)
+	}
+	gjson.GetBytes(payload, "messages").ForEach(func(msgIdx, msg gjson.Result) bool {
+		if content := msg.Get("content"); content.IsArray() {
+			collect(fmt.Sprintf("messages.%d.content", msgIdx.Int()), content)
+		}
+		return true
+	})
+	if len(paths) == 0 {
+		return payload
+	}
+	for _, path := range paths {
+		payload, _ = sjson.SetBytes(payload, path, "1h")
+	}
+
+	betas := gjson.GetBytes(payload, "betas")
+	for _, beta := range betas.Array() {
+		if beta.String() == extendedCacheTTLBeta {
+			return payload
+		}
+	}
+	if betas.Exists() && !betas.IsArray() {
+		payload, _ = sjson.SetRawBytes(payload, "betas", []byte(`[`+betas.Raw+`]`))
+	}
+	payload, _ = sjson.SetBytes(payload, "betas.-1", extendedCacheTTLBeta)
+	return payload
+}
+
+// dropShortCachePrefixes removes cache_control from blocks whose cached prefix (everything in
+// tools → system → messages order up to and including the block) is shorter than minLength
+// characters. Anthropic does not cache prefixes below its minimum size, so such breakpoints
+// only consume one of the four allowed slots.
+func dropShortCachePrefixes(payload []byte, minLength int) []byte {
+	prefixLen := 0
+	var paths []string
+	visit := func(path string, block gjson.Result) {
+		prefixLen += len(block.Raw)
+		if block.Get("cache_control").Exists() && prefixLen < minLength {
+			paths = append(paths, path+".cache_control")
+		}
+	}
+
+	gjson.GetBytes(payload, "tools").ForEach(func(idx, tool gjson.Result) bool {
+		visit(fmt.Sprintf("tools.%d", idx.Int()), tool)
+		return true
+	})
+	if system := gjson.GetBytes(payload, "system"); system.IsArray() {
+		system.ForEach(func(idx, block gjson.Result) bool {
+			visit(fmt.Sprintf("system.%d", idx.Int()), block)
+			return true
+		})
+	} else {
+		prefixLen += len(system.Raw)
+	}
+	gjson.GetBytes(payload, "messages").ForEach(func(msgIdx, msg gjson.Result) bool {
+		content := msg.Get("content")
+		if !content.IsArray() {
+			prefixLen += len(content.Raw)
+			return true
+		}
+		content.ForEach(func(blockIdx, block gjson.Result) bool {
+			visit(fmt.Sprintf("messages.%d.content.%d", msgIdx.Int(), blockIdx.Int()), block)
+			return true
+		})
+		return true
+	})
+
+	for _, path := range paths {
+		payload, _ = sjson.DeleteBytes(payload, path)
+	}
+	return payload
+}
@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+const cacheStrategyTestPayload = `{
+	"tools":[{"name":"lookup","input_schema":{"type":"object"}}],
+	"system":[{"type":"text","text":"You are a helpful assistant with a long system prompt."}],
+	"messages":[
+		{"role":"user","content":[{"type":"text","text":"first question"}]},
+		{"role":"assistant","content":[{"type":"text","text":"first answer"}]},
+		{"role":"user","content":[{"type":"text","text":"second question"}]},
+		{"role":"assistant","content":[{"type":"text","text":"second answer"}]},
+		{"role":"user","content":"third question"}
+	]
+}`
+
+func TestApplyAutoCacheControl_Strategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     []string
+		wantNot  []string
+	}{
+		{
+			strategy: "",
+			want:     []string{"tools.0.cache_control", "system.0.cache_control", "messages.2.content.0.cache_control"},
+			wantNot:  []string{"messages.4.content.0.cache_control"},
+		},
+		{
+			strategy: "system-only",
+			want:     []string{"system.0.cache_control"},
+			wantNot:  []string{"tools.0.cache_control", "messages.2.content.0.cache_control"},
+		},
+		{
+			strategy: "rolling-window",
+			want:     []string{"tools.0.cache_control", "system.0.cache_control", "messages.2.content.0.cache_control", "messages.4.content.0.cache_control"},
+			wantNot:  []string{"messages.0.content.0.cache_control"},
+		},
+		{
+			strategy: "disabled",
+			wantNot:  []string{"tools.0.cache_control", "system.0.cache_control", "messages.2.content.0.cache_control"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("strategy="+tt.strategy, func(t *testing.T) {
+			cfg := &config.Config{PromptCache: config.PromptCacheConfig{Strategy: tt.strategy}}
+			out := applyAutoCacheControl(cfg, "claude-sonnet-4-5", []byte(cacheStrategyTestPayload))
+			for _, path := range tt.want {
+				if !gjson.GetBytes(out, path).Exists() {
+					t.Errorf("expected %s to be set", path)
+				}
+			}
+			for _, path := range tt.wantNot {
+				if gjson.GetBytes(out, path).Exists() {
+					t.Errorf("expected %s to be absent", path)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyAutoCacheControl_PerModelOverrideAndBreakpoints(t *testing.T) {
+	cfg := &config.Config{PromptCache: config.PromptCacheConfig{
+		Strategy:    "rolling-window",
+		Breakpoints: 2,
+		Models:      []config.PromptCacheModelRule{{Name: "claude-haiku-*", Strategy: "disabled"}},
+	}}
+
+	out := applyAutoCacheControl(cfg, "claude-haiku-4-5", []byte(cacheStrategyTestPayload))
+	if got := countCacheControls(out); got != 0 {
+		t.Fatalf("expected no breakpoints for disabled model, got %d", got)
+	}
+
+	out = applyAutoCacheControl(cfg, "claude-sonnet-4-5", []byte(cacheStrategyTestPayload))
+	if got := countCacheControls(out); got != 2 {
+		t.Fatalf("expected breakpoints capped at 2, got %d", got)
+	}
+}
+
+func TestApplyAutoCacheControl_MinContentLength(t *testing.T) {
+	cfg := &config.Config{PromptCache: config.PromptCacheConfig{MinContentLength: 120}}
+
+	out := applyAutoCacheControl(cfg, "claude-sonnet-4-5", []byte(cacheStrategyTestPayload))
+	if gjson.GetBytes(out, "tools.0.cache_control").Exists() {
+		t.Fatal("expected breakpoint on short tools prefix to be skipped")
+	}
+	if !gjson.GetBytes(out, "messages.2.content.0.cache_control").Exists() {
+		t.Fatal("expected breakpoint on long conversation prefix to be kept")
+	}
+}
+
+func TestApplyAutoCacheControl_ClientBreakpointsUntouched(t *testing.T) {
+	payload := []byte(`{"system":[{"type":"text","text":"sys","cache_control":{"type":"ephemeral"}}],"messages":[{"role":"user","content":"hi"}]}`)
+	cfg := &config.Config{PromptCache: config.PromptCacheConfig{Strategy: "rolling-window", MinContentLength: 1000}}
+
+	out := applyAutoCacheControl(cfg, "claude-sonnet-4-5", payload)
+	if string(out) != string(payload) {
+		t.Fatalf("expected client-provided breakpoints to be left as-is, got %s", out)
+	}
+}
+
+func TestApplyAutoCacheControl_ExtendedTTL(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   *config.Config
+		model string
+		want  bool
+	}{
+		{name: "default", cfg: &config.Config{}, model: "claude-sonnet-4-5", want: false},
+		{name: "global", cfg: &config.Config{PromptCache: config.PromptCacheConfig{TTL: "1h"}}, model: "claude-sonnet-4-5", want: true},
+		{
+			name: "per model",
+			cfg: &config.Config{PromptCache: config.PromptCacheConfig{
+				Models: []config.PromptCacheModelRule{{Name: "claude-opus-*", TTL: "1h"}},
+			}},
+			model: "claude-opus-4-5",
+			want:  true,
+		},
+		{name: "suffix", cfg: &config.Config{}, model: "claude-sonnet-4-5(cache:1h)", want: true},
+		{name: "suffix overrides config", cfg: &config.Config{PromptCache: config.PromptCacheConfig{TTL: "1h"}}, model: "claude-sonnet-4-5(cache:5m)", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := applyAutoCacheControl(tt.cfg, tt.model, []byte(cacheStrategyTestPayload))
+			gotTTL := gjson.GetBytes(out, "system.0.cache_control.ttl").String() == "1h"
+			hasBeta := false
+			for _, beta := range gjson.GetBytes(out, "betas").Array() {
+				if beta.String() == extendedCacheTTLBeta {
+					hasBeta = true
+				}
+			}
+			if gotTTL != tt.want || hasBeta != tt.want {
+				t.Fatalf("ttl 1h = %v, beta = %v, want %v; payload %s", gotTTL, hasBeta, tt.want, out)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -143,22 +144,33 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	defer reporter.TrackFailure(ctx, &err)
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("claude")
+	prov := claudeProvider()
 	// Use streaming translation to preserve function calling, except for claude.
 	stream := from != to
+	// claude-request.non-stream.upstream "non-stream" uses the non-streaming API instead and
+	// renders the reply as SSE for the stream-based response translators.
+	renderSSE := stream && e.cfg != nil && e.cfg.ClaudeRequest.NonStream.Upstream == config.ClaudeNonStreamUpstreamNonStream
+	if renderSSE {
+		stream = false
+	}
 	originalPayloadSource := req.Payload
 	if len(opts.OriginalRequest) > 0 {
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	originalTranslated := prov.TranslateRequest(from, baseModel, originalPayload, stream)
+	body := prov.TranslateRequest(from, baseModel, req.Payload, stream)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
-	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	body, err = thinking.ApplyThinking(body, helps.StripPromptCacheSuffix(req.Model), from.String(), to.String(), e.Identifier())
 	if err != nil {
 		return resp, err
 	}
 
+	if from != to {
+		body = ensureClientUserID(ctx, body)
+	}
+
 	// Apply cloaking (system prompt injection, fake user ID, sensitive word obfuscation)
 	// based on client type and configuration.
 	body = applyCloaking(ctx, e.cfg, auth, body, baseModel, apiKey)
@@ -167,15 +179,15 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	requestPath := helps.PayloadRequestPath(opts)
 	body = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, requestPath)
 	body = ensureModelMaxTokens(body, baseModel)
+	body = helps.ApplyClaudeMaxTokensPolicy(e.cfg, baseModel, helps.APIKeyFromContext(ctx), originalPayload, body)
 
 	// Disable thinking if tool_choice forces tool use (Anthropic API constraint)
 	body = disableThinkingIfToolChoiceForced(body)
+	body = helps.ApplyClaudeRequestPolicy(e.cfg, from.String(), originalPayload, body)
 	body = normalizeClaudeTemperatureForThinking(body)
 
 	// Auto-inject cache_control if missing (optimization for ClawdBot/clients without caching support)
-	if countCacheControls(body) == 0 {
-		body = ensureCacheControl(body)
-	}
+	body = applyAutoCacheControl(e.cfg, req.Model, body)
 
 	// Enforce Anthropic's cache_control block limit (max 4 breakpoints per request).
 	// Cloaking and ensureCacheControl may push the total over 4 when the client
@@ -189,6 +201,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	// Extract betas from body and convert to header
 	var extraBetas []string
 	extraBetas, body = extractAndRemoveBetas(body)
+	extraBetas = append(extraBetas, helps.ClaudeFeatureBetas(e.cfg, body)...)
 	bodyForTranslation := body
 	bodyForUpstream := body
 	oauthToken := isClaudeOAuthToken(apiKey)
@@ -214,6 +227,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 		return resp, err
 	}
 	applyClaudeHeaders(httpReq, auth, apiKey, false, extraBetas, e.cfg)
+	helps.ApplyClaudeBetaRules(httpReq.Header, e.cfg, baseModel)
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
@@ -248,7 +262,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 			helps.RecordAPIResponseError(ctx, e.cfg, decErr)
 			msg := fmt.Sprintf("failed to decode error response body: %v", decErr)
 			helps.LogWithRequestID(ctx).Warn(msg)
-			return resp, statusErr{code: httpResp.StatusCode, msg: msg}
+			return resp, statusErr{code: httpResp.StatusCode, msg: msg, retryAfter: helps.ParseRetryAfter(httpResp.Header, time.Now())}
 		}
 		b, readErr := io.ReadAll(errBody)
 		if readErr != nil {
@@ -259,7 +273,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 		}
 		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
 		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = prov.TranslateError(httpResp.StatusCode, httpResp.Header, b)
 		if errClose := errBody.Close(); errClose != nil {
 			log.Errorf("response body close error: %v", errClose)
 		}
@@ -278,10 +292,10 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 			log.Errorf("response body close error: %v", errClose)
 		}
 	}()
-	data, err := io.ReadAll(decodedBody)
+	data, err := helps.ReadAssembled(ctx, decodedBody, helps.ClaudeNonStreamLimits(e.cfg, stream), func() { _ = httpResp.Body.Close() })
 	if err != nil {
 		helps.RecordAPIResponseError(ctx, e.cfg, err)
-		return resp, err
+		return resp, assemblyStatusErr(err)
 	}
 	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
 	if stream {
@@ -290,8 +304,9 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 			return resp, errValidate
 		}
 		lines := bytes.Split(data, []byte("\n"))
+		var streamUsage helps.ClaudeStreamUsage
 		for _, line := range lines {
-			if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+			if detail, ok := streamUsage.Parse(line); ok {
 				reporter.Publish(ctx, detail)
 			}
 		}
@@ -305,10 +320,12 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	if isClaudeOAuthToken(apiKey) && oauthToolNamesRemapped {
 		data = reverseRemapOAuthToolNames(data)
 	}
+	if renderSSE {
+		data = helps.ClaudeMessageToSSE(data)
+	}
 	var param any
-	out := sdktranslator.TranslateNonStream(
-		ctx,
-		to,
+	out := prov.TranslateResponse(
+		withUpstreamCreated(ctx, httpResp.Header),
 		from,
 		req.Model,
 		opts.OriginalRequest,
@@ -335,20 +352,25 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	defer reporter.TrackFailure(ctx, &err)
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("claude")
+	prov := claudeProvider()
 	originalPayloadSource := req.Payload
 	if len(opts.OriginalRequest) > 0 {
 		originalPayloadSource = opts.OriginalRequest
 	}
 	originalPayload := originalPayloadSource
-	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, true)
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+	originalTranslated := prov.TranslateRequest(from, baseModel, originalPayload, true)
+	body := prov.TranslateRequest(from, baseModel, req.Payload, true)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
-	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
+	body, err = thinking.ApplyThinking(body, helps.StripPromptCacheSuffix(req.Model), from.String(), to.String(), e.Identifier())
 	if err != nil {
 		return nil, err
 	}
 
+	if from != to {
+		body = ensureClientUserID(ctx, body)
+	}
+
 	// Apply cloaking (system prompt injection, fake user ID, sensitive word obfuscation)
 	// based on client type and configuration.
 	body = applyCloaking(ctx, e.cfg, auth, body, baseModel, apiKey)
@@ -357,15 +379,15 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	requestPath := helps.PayloadRequestPath(opts)
 	body = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, requestPath)
 	body = ensureModelMaxTokens(body, baseModel)
+	body = helps.ApplyClaudeMaxTokensPolicy(e.cfg, baseModel, helps.APIKeyFromContext(ctx), originalPayload, body)
 
 	// Disable thinking if tool_choice forces tool use (Anthropic API constraint)
 	body = disableThinkingIfToolChoiceForced(body)
+	body = helps.ApplyClaudeRequestPolicy(e.cfg, from.String(), originalPayload, body)
 	body = normalizeClaudeTemperatureForThinking(body)
 
 	// Auto-inject cache_control if missing (optimization for ClawdBot/clients without caching support)
-	if countCacheControls(body) == 0 {
-		body = ensureCacheControl(body)
-	}
+	body = applyAutoCacheControl(e.cfg, req.Model, body)
 
 	// Enforce Anthropic's cache_control block limit (max 4 breakpoints per request).
 	body = enforceCacheControlLimit(body, 4)
@@ -376,6 +398,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	// Extract betas from body and convert to header
 	var extraBetas []string
 	extraBetas, body = extractAndRemoveBetas(body)
+	extraBetas = append(extraBetas, helps.ClaudeFeatureBetas(e.cfg, body)...)
 	bodyForTranslation := body
 	bodyForUpstream := body
 	oauthToken := isClaudeOAuthToken(apiKey)
@@ -390,7 +413,9 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		bodyForUpstream, oauthToolNamesRemapped = remapOAuthToolNames(bodyForUpstream)
 	}
 	// Enable cch signing by default for OAuth tokens (not just experimental flag).
-	if oauthToken || experimentalCCHSigningEnabled(e.cfg, auth) {
+	signBody := oauthToken || experimentalCCHSigningEnabled(e.cfg, auth)
+	unsignedUpstream := bodyForUpstream
+	if signBody {
 		bodyForUpstream = signAnthropicMessagesBody(bodyForUpstream)
 	}
 
@@ -400,6 +425,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		return nil, err
 	}
 	applyClaudeHeaders(httpReq, auth, apiKey, true, extraBetas, e.cfg)
+	helps.ApplyClaudeBetaRules(httpReq.Header, e.cfg, baseModel)
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
@@ -434,7 +460,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 			helps.RecordAPIResponseError(ctx, e.cfg, decErr)
 			msg := fmt.Sprintf("failed to decode error response body: %v", decErr)
 			helps.LogWithRequestID(ctx).Warn(msg)
-			return nil, statusErr{code: httpResp.StatusCode, msg: msg}
+			return nil, statusErr{code: httpResp.StatusCode, msg: msg, retryAfter: helps.ParseRetryAfter(httpResp.Header, time.Now())}
 		}
 		b, readErr := io.ReadAll(errBody)
 		if readErr != nil {
@@ -448,7 +474,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		if errClose := errBody.Close(); errClose != nil {
 			log.Errorf("response body close error: %v", errClose)
 		}
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = prov.TranslateError(httpResp.StatusCode, httpResp.Header, b)
 		return nil, err
 	}
 	decodedBody, err := decodeResponseBody(httpResp.Body, httpResp.Header.Get("Content-Encoding"))
@@ -462,61 +488,52 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
+		stream, streamResp := decodedBody, httpResp
 		defer func() {
-			if errClose := decodedBody.Close(); errClose != nil {
+			if errClose := stream.Close(); errClose != nil {
 				log.Errorf("response body close error: %v", errClose)
 			}
 		}()
 
-		// If from == to (Claude → Claude), directly forward the SSE stream without translation
-		if from == to {
-			scanner := bufio.NewScanner(decodedBody)
-			scanner.Buffer(nil, 52_428_800) // 50MB
-			for scanner.Scan() {
-				line := scanner.Bytes()
-				helps.AppendAPIResponseChunk(ctx, e.cfg, line)
-				if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
-					reporter.Publish(ctx, detail)
-				}
-				if isClaudeOAuthToken(apiKey) && !auth.ToolPrefixDisabled() {
-					line = stripClaudeToolPrefixFromStreamLine(line, claudeToolPrefix)
-				}
-				if isClaudeOAuthToken(apiKey) && oauthToolNamesRemapped {
-					line = reverseRemapOAuthToolNamesFromStreamLine(line)
-				}
-				// Forward the line as-is to preserve SSE format
-				cloned := make([]byte, len(line)+1)
-				copy(cloned, line)
-				cloned[len(line)] = '\n'
-				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
-			}
-			if errScan := scanner.Err(); errScan != nil {
-				helps.RecordAPIResponseError(ctx, e.cfg, errScan)
-				reporter.PublishFailure(ctx)
-				out <- cliproxyexecutor.StreamChunk{Err: errScan}
-			}
-			return
+		var recovery *helps.ClaudeStreamRecovery
+		recoveryRetries := 0
+		if e.cfg != nil {
+			recoveryRetries = e.cfg.Streaming.PartialRecoveryRetries
+		}
+		if recoveryRetries > 0 {
+			recovery = helps.NewClaudeStreamRecovery()
 		}
-
-		// For other formats, use translation
-		scanner := bufio.NewScanner(decodedBody)
-		scanner.Buffer(nil, 52_428_800) // 50MB
 		var param any
-		for scanner.Scan() {
-			line := scanner.Bytes()
+		respCtx := withUpstreamCreated(ctx, httpResp.Header)
+		var streamUsage helps.ClaudeStreamUsage
+		// Claude → Claude streams that need no line rewriting are piped through in blocks; only
+		// request logging and usage extraction look at the lines.
+		rewritesLines := isClaudeOAuthToken(apiKey) && ((claudeToolPrefix != "" && !auth.ToolPrefixDisabled()) || oauthToolNamesRemapped)
+		passthrough := from == to && recovery == nil && !rewritesLines
+		tap := func(line []byte) {
 			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
-			if detail, ok := helps.ParseClaudeStreamUsage(line); ok {
+			if detail, ok := streamUsage.Parse(line); ok {
 				reporter.Publish(ctx, detail)
 			}
+		}
+		forward := func(line []byte) {
 			if isClaudeOAuthToken(apiKey) && !auth.ToolPrefixDisabled() {
 				line = stripClaudeToolPrefixFromStreamLine(line, claudeToolPrefix)
 			}
 			if isClaudeOAuthToken(apiKey) && oauthToolNamesRemapped {
 				line = reverseRemapOAuthToolNamesFromStreamLine(line)
 			}
-			chunks := sdktranslator.TranslateStream(
-				ctx,
-				to,
+			// If from == to (Claude → Claude), directly forward the SSE stream without translation
+			if from == to {
+				// Forward the line as-is to preserve SSE format
+				cloned := make([]byte, len(line)+1)
+				copy(cloned, line)
+				cloned[len(line)] = '\n'
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				return
+			}
+			chunks := prov.TranslateStreamChunk(
+				respCtx,
 				from,
 				req.Model,
 				opts.OriginalRequest,
@@ -528,15 +545,143 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
 			}
 		}
-		if errScan := scanner.Err(); errScan != nil {
-			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
-			reporter.PublishFailure(ctx)
-			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+
+		for attempt := 1; ; attempt++ {
+			// Abort the upstream response as soon as the client goes away instead of waiting for
+			// the transport to notice on the next read.
+			upstreamBody := streamResp.Body
+			stopAbort := context.AfterFunc(ctx, func() { _ = upstreamBody.Close() })
+			var errScan error
+			if passthrough {
+				errScan = helps.PipeSSE(stream, tap, func(chunk []byte) {
+					out <- cliproxyexecutor.StreamChunk{Payload: chunk}
+				})
+			} else {
+				scanner := bufio.NewScanner(stream)
+				scanner.Buffer(nil, 52_428_800) // 50MB
+				for scanner.Scan() {
+					line := scanner.Bytes()
+					tap(line)
+					if recovery == nil {
+						forward(line)
+						continue
+					}
+					for _, processed := range recovery.Process(line) {
+						forward(processed)
+					}
+				}
+				errScan = scanner.Err()
+			}
+			stopAbort()
+
+			if recovery != nil && attempt <= recoveryRetries && ctx.Err() == nil {
+				if continuation, ok := recovery.Continuation(unsignedUpstream); ok {
+					helps.LogWithRequestID(ctx).Warnf("claude stream interrupted after %d bytes of text (%v), resuming from partial response (%d/%d)", recovery.GeneratedLength(), errScan, attempt, recoveryRetries)
+					if signBody {
+						continuation = signAnthropicMessagesBody(continuation)
+					}
+					nextResp, nextStream, errResume := e.resumeStream(ctx, auth, apiKey, url, extraBetas, baseModel, continuation)
+					if errResume == nil {
+						if errClose := stream.Close(); errClose != nil {
+							log.Errorf("response body close error: %v", errClose)
+						}
+						stream, streamResp = nextStream, nextResp
+						continue
+					}
+					helps.LogWithRequestID(ctx).Warnf("claude stream recovery failed: %v", errResume)
+				}
+			}
+			if recovery != nil {
+				for _, stashed := range recovery.Flush() {
+					forward(stashed)
+				}
+			}
+			if errScan != nil {
+				helps.RecordAPIResponseError(ctx, e.cfg, errScan)
+				publishClaudeStreamError(ctx, reporter, &streamUsage, errScan)
+				out <- cliproxyexecutor.StreamChunk{Err: errScan}
+			}
+			return
 		}
 	}()
 	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
 }
 
+// assemblyStatusErr maps the errors of helps.ReadAssembled to gateway status errors.
+func assemblyStatusErr(err error) error {
+	switch {
+	case errors.Is(err, helps.ErrAssemblyIdleTimeout), errors.Is(err, helps.ErrAssemblyMaxDuration):
+		return statusErr{code: http.StatusGatewayTimeout, msg: err.Error()}
+	case errors.Is(err, helps.ErrAssemblyTooLarge):
+		return statusErr{code: http.StatusBadGateway, msg: err.Error()}
+	default:
+		return err
+	}
+}
+
+// resumeStream sends the continuation request of an interrupted stream and returns its decoded
+// body once the upstream accepted it.
+func (e *ClaudeExecutor) resumeStream(ctx context.Context, auth *cliproxyauth.Auth, apiKey, url string, extraBetas []string, baseModel string, body []byte) (*http.Response, io.ReadCloser, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	applyClaudeHeaders(httpReq, auth, apiKey, true, extraBetas, e.cfg)
+	helps.ApplyClaudeBetaRules(httpReq.Header, e.cfg, baseModel)
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+	httpResp, err := helps.NewUtlsHTTPClient(e.cfg, auth, 0).Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return nil, nil, err
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		return nil, nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	decodedBody, err := decodeResponseBody(httpResp.Body, httpResp.Header.Get("Content-Encoding"))
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("response body close error: %v", errClose)
+		}
+		return nil, nil, err
+	}
+	return httpResp, decodedBody, nil
+}
+
+// publishClaudeStreamError records the usage of a stream that ended with errScan. A stream
+// aborted because the client disconnected is reported as client-cancelled with the tokens of the
+// last message_delta rather than as a failure.
+func publishClaudeStreamError(ctx context.Context, reporter *helps.UsageReporter, streamUsage *helps.ClaudeStreamUsage, errScan error) {
+	if helps.ClientCancelled(ctx, errScan) {
+		helps.LogWithRequestID(ctx).Debug("claude stream canceled by client")
+		reporter.PublishClientCancelled(ctx, streamUsage.Partial())
+		return
+	}
+	reporter.PublishFailure(ctx)
+}
+
 func validateClaudeStreamingResponse(data []byte) error {
 	scanner := bufio.NewScanner(bytes.NewReader(data))
 	scanner.Buffer(nil, 52_428_800)
@@ -605,9 +750,10 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("claude")
+	prov := claudeProvider()
 	// Use streaming translation to preserve function calling, except for claude.
 	stream := from != to
-	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	body := prov.TranslateRequest(from, baseModel, req.Payload, stream)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 
 	if !strings.HasPrefix(baseModel, "claude-3-5-haiku") {
@@ -621,6 +767,7 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 	// Extract betas from body and convert to header (for count_tokens too)
 	var extraBetas []string
 	extraBetas, body = extractAndRemoveBetas(body)
+	extraBetas = append(extraBetas, helps.ClaudeFeatureBetas(e.cfg, body)...)
 	if isClaudeOAuthToken(apiKey) && !auth.ToolPrefixDisabled() {
 		body = applyClaudeToolPrefix(body, claudeToolPrefix)
 	}
@@ -635,6 +782,7 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 		return cliproxyexecutor.Response{}, err
 	}
 	applyClaudeHeaders(httpReq, auth, apiKey, false, extraBetas, e.cfg)
+	helps.ApplyClaudeBetaRules(httpReq.Header, e.cfg, baseModel)
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
@@ -669,7 +817,7 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 			helps.RecordAPIResponseError(ctx, e.cfg, decErr)
 			msg := fmt.Sprintf("failed to decode error response body: %v", decErr)
 			helps.LogWithRequestID(ctx).Warn(msg)
-			return cliproxyexecutor.Response{}, statusErr{code: resp.StatusCode, msg: msg}
+			return cliproxyexecutor.Response{}, statusErr{code: resp.StatusCode, msg: msg, retryAfter: helps.ParseRetryAfter(resp.Header, time.Now())}
 		}
 		b, readErr := io.ReadAll(errBody)
 		if readErr != nil {
@@ -682,7 +830,16 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 		if errClose := errBody.Close(); errClose != nil {
 			log.Errorf("response body close error: %v", errClose)
 		}
-		return cliproxyexecutor.Response{}, statusErr{code: resp.StatusCode, msg: string(b)}
+		if countTokensUnsupported(resp.StatusCode) {
+			// Claude-compatible providers often lack count_tokens; estimate locally instead.
+			count, errCount := countClaudeTokensLocally(body)
+			if errCount != nil {
+				return cliproxyexecutor.Response{}, errCount
+			}
+			out := translateClaudeTokenCount(ctx, prov, from, count, fmt.Appendf(nil, `{"input_tokens":%d}`, count))
+			return cliproxyexecutor.Response{Payload: out}, nil
+		}
+		return cliproxyexecutor.Response{}, prov.TranslateError(resp.StatusCode, resp.Header, b)
 	}
 	decodedBody, err := decodeResponseBody(resp.Body, resp.Header.Get("Content-Encoding"))
 	if err != nil {
@@ -704,10 +861,29 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 	}
 	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
 	count := gjson.GetBytes(data, "input_tokens").Int()
-	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
+	out := translateClaudeTokenCount(ctx, prov, from, count, data)
 	return cliproxyexecutor.Response{Payload: out, Headers: resp.Header.Clone()}, nil
 }
 
+// countTokensUnsupported reports whether an upstream status means the count_tokens
+// endpoint is not implemented, as opposed to a request or credential failure.
+func countTokensUnsupported(status int) bool {
+	return status == http.StatusNotFound || status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented
+}
+
+// countClaudeTokensLocally estimates the input tokens of a Claude payload with a local tokenizer.
+func countClaudeTokensLocally(body []byte) (int64, error) {
+	enc, err := helps.TokenizerForModel("")
+	if err != nil {
+		return 0, fmt.Errorf("claude executor: tokenizer init failed: %w", err)
+	}
+	count, err := helps.CountClaudeMessagesTokens(enc, body)
+	if err != nil {
+		return 0, fmt.Errorf("claude executor: token counting failed: %w", err)
+	}
+	return count, nil
+}
+
 func (e *ClaudeExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
 	log.Debugf("claude executor: refresh called")
 	if auth == nil {
@@ -763,6 +939,46 @@ func extractAndRemoveBetas(body []byte) ([]string, []byte) {
 	return betas, body
 }
 
+// buildHostedClaudeBody translates a request into a Claude Messages body for Claude models
+// hosted by cloud providers (Vertex AI, Bedrock). It applies the request policies of the
+// Claude executor that do not depend on Anthropic accounts; betas are left in the body.
+func buildHostedClaudeBody(ctx context.Context, cfg *config.Config, providerKey string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) ([]byte, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := sdktranslator.TranslateRequest(from, to, baseModel, originalPayload, stream)
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, stream)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	body, err := thinking.ApplyThinking(body, req.Model, from.String(), to.String(), providerKey)
+	if err != nil {
+		return nil, err
+	}
+	if from != to {
+		body = ensureClientUserID(ctx, body)
+	}
+
+	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
+	requestPath := helps.PayloadRequestPath(opts)
+	body = helps.ApplyPayloadConfigWithRoot(cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, requestPath)
+	if !gjson.GetBytes(body, "max_tokens").Exists() {
+		body, _ = sjson.SetBytes(body, "max_tokens", defaultModelMaxTokens)
+	}
+	body = disableThinkingIfToolChoiceForced(body)
+	body = normalizeClaudeTemperatureForThinking(body)
+	body = enforceCacheControlLimit(body, 4)
+	body = normalizeCacheControlTTL(body)
+	if stream {
+		body, _ = sjson.SetBytes(body, "stream", true)
+	}
+	return body, nil
+}
+
 // disableThinkingIfToolChoiceForced checks if tool_choice forces tool use and disables thinking.
 // Anthropic API does not allow thinking when tool_choice is set to "any" or a specific tool.
 // See: https://docs.anthropic.com/en/docs/build-with-claude/extended-thinking#important-considerations
@@ -1510,6 +1726,17 @@ func getCloakConfigFromAuth(auth *cliproxyauth.Auth) (string, bool, []string, bo
 	return cloakMode, strictMode, sensitiveWords, cacheUserID
 }
 
+// ensureClientUserID sets metadata.user_id of a translated request, unless the client supplied
+// one, to an ID derived from the client API key and session header. Clients then keep a stable
+// identity upstream instead of sharing one, which also partitions prompt caching per conversation.
+func ensureClientUserID(ctx context.Context, payload []byte) []byte {
+	if gjson.GetBytes(payload, "metadata.user_id").String() != "" {
+		return payload
+	}
+	payload, _ = sjson.SetBytes(payload, "metadata.user_id", helps.ClientSessionUserID(ctx))
+	return payload
+}
+
 // injectFakeUserID generates and injects a fake user ID into the request metadata.
 // When useCache is false, a new user ID is generated for every call.
 func injectFakeUserID(payload []byte, apiKey string, useCache bool) []byte {
@@ -2186,15 +2413,21 @@ func injectMessagesCacheControl(payload []byte) []byte {
 	// Get the second-to-last user message index
 	secondToLastUserIdx := userMsgIndices[len(userMsgIndices)-2]
 
+	return setMessageCacheControl(payload, secondToLastUserIdx)
+}
+
+// setMessageCacheControl marks the last content block of the message at msgIdx with an
+// ephemeral cache_control, converting string content to a single text block.
+func setMessageCacheControl(payload []byte, msgIdx int) []byte {
 	// Get the content of this message
-	contentPath := fmt.Sprintf("messages.%d.content", secondToLastUserIdx)
+	contentPath := fmt.Sprintf("messages.%d.content", msgIdx)
 	content := gjson.GetBytes(payload, contentPath)
 
 	if content.IsArray() {
 		// Add cache_control to the last content block of this message
 		contentCount := int(content.Get("#").Int())
 		if contentCount > 0 {
-			cacheControlPath := fmt.Sprintf("messages.%d.content.%d.cache_control", secondToLastUserIdx, contentCount-1)
+			cacheControlPath := fmt.Sprintf("messages.%d.content.%d.cache_control", msgIdx, contentCount-1)
 			result, err := sjson.SetBytes(payload, cacheControlPath, map[string]string{"type": "ephemeral"})
 			if err != nil {
 				log.Warnf("failed to inject cache_control into messages: %v", err)
@@ -2345,3 +2578,16 @@ func ensureModelMaxTokens(body []byte, modelID string) []byte {
 
 	return body
 }
+
+// withUpstreamCreated exposes the upstream response time (from the Date header) to response
+// translators under the "upstream_created" context key as a unix timestamp.
+func withUpstreamCreated(ctx context.Context, header http.Header) context.Context {
+	if header == nil {
+		return ctx
+	}
+	date, errParse := http.ParseTime(header.Get("Date"))
+	if errParse != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, "upstream_created", date.Unix())
+}
@@ -1007,8 +1007,47 @@ func TestClaudeExecutor_ExecuteOpenAINonStreamConvertsValidClaudeStream(t *testi
 	}
 }
 
+func TestClaudeExecutor_ExecuteOpenAINonStreamUsesNonStreamingUpstream(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ClaudeRequest.NonStream.Upstream = config.ClaudeNonStreamUpstreamNonStream
+	body := `{"id":"msg_123","type":"message","role":"assistant","model":"claude-3-5-sonnet-20241022",` +
+		`"content":[{"type":"text","text":"ok"},{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"q":"x"}}],` +
+		`"stop_reason":"tool_use","stop_sequence":null,"usage":{"input_tokens":2,"output_tokens":1}}`
+
+	resp, err := executeOpenAIChatCompletionThroughClaudeWithConfig(t, cfg, body)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "ok" {
+		t.Fatalf("response content = %q, want ok; payload=%s", got, string(resp.Payload))
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.tool_calls.0.function.arguments").String(); got != `{"q":"x"}` {
+		t.Fatalf("tool call arguments = %q; payload=%s", got, string(resp.Payload))
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.total_tokens").Int(); got != 3 {
+		t.Fatalf("usage.total_tokens = %d, want 3", got)
+	}
+}
+
+func TestClaudeExecutor_ExecuteOpenAINonStreamRejectsOversizedResponse(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ClaudeRequest.NonStream.MaxResponseBytes = 16
+	body := `data: {"type":"message_start","message":{"id":"msg_123"}}` + "\n"
+
+	_, err := executeOpenAIChatCompletionThroughClaudeWithConfig(t, cfg, body)
+	if err == nil {
+		t.Fatal("expected an error for a response over max-response-bytes")
+	}
+	assertStatusErr(t, err, http.StatusBadGateway)
+}
+
 func executeOpenAIChatCompletionThroughClaude(t *testing.T, upstreamBody string) (cliproxyexecutor.Response, error) {
 	t.Helper()
+	return executeOpenAIChatCompletionThroughClaudeWithConfig(t, &config.Config{}, upstreamBody)
+}
+
+func executeOpenAIChatCompletionThroughClaudeWithConfig(t *testing.T, cfg *config.Config, upstreamBody string) (cliproxyexecutor.Response, error) {
+	t.Helper()
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/event-stream")
@@ -1016,7 +1055,7 @@ func executeOpenAIChatCompletionThroughClaude(t *testing.T, upstreamBody string)
 	}))
 	defer server.Close()
 
-	executor := NewClaudeExecutor(&config.Config{})
+	executor := NewClaudeExecutor(cfg)
 	auth := &cliproxyauth.Auth{Attributes: map[string]string{
 		"api_key":  "key-123",
 		"base_url": server.URL,
@@ -1251,6 +1290,58 @@ func TestClaudeExecutor_CountTokens_AppliesCacheControlGuards(t *testing.T) {
 	}
 }
 
+func TestClaudeExecutor_CountTokens_OpenAISourceReturnsBothSchemas(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"input_tokens":42}`))
+	}))
+	defer server.Close()
+
+	executor := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"api_key":  "key-123",
+		"base_url": server.URL,
+	}}
+
+	resp, err := executor.CountTokens(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-3-5-haiku-20241022",
+		Payload: []byte(`{"model":"claude-3-5-haiku-20241022","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("CountTokens error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "input_tokens").Int(); got != 42 {
+		t.Fatalf("input_tokens = %d, want 42; payload=%s", got, resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int(); got != 42 {
+		t.Fatalf("usage.prompt_tokens = %d, want 42; payload=%s", got, resp.Payload)
+	}
+}
+
+func TestClaudeExecutor_CountTokens_FallsBackToLocalTokenizer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	executor := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"api_key":  "key-123",
+		"base_url": server.URL,
+	}}
+
+	resp, err := executor.CountTokens(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-3-5-haiku-20241022",
+		Payload: []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"count these words please"}]}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude")})
+	if err != nil {
+		t.Fatalf("CountTokens error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "input_tokens").Int(); got <= 0 {
+		t.Fatalf("input_tokens = %d, want > 0; payload=%s", got, resp.Payload)
+	}
+}
+
 func hasTTLOrderingViolation(payload []byte) bool {
 	seen5m := false
 	violates := false
@@ -1573,6 +1664,144 @@ func TestClaudeExecutor_ExecuteStream_GzipSuccessBodyDecoded(t *testing.T) {
 	}
 }
 
+// TestClaudeExecutor_ExecuteStream_ClaudeSourcePassthrough verifies that native
+// /v1/messages requests stream upstream SSE lines back unchanged while still running
+// the outbound cache_control pipeline.
+func TestClaudeExecutor_ExecuteStream_ClaudeSourcePassthrough(t *testing.T) {
+	upstream := "event: message_start\n" +
+		"data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"usage\":{\"input_tokens\":3,\"output_tokens\":0}}}\n" +
+		"\n" +
+		"event: content_block_delta\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"hi\"}}\n" +
+		"\n" +
+		"event: message_stop\n" +
+		"data: {\"type\":\"message_stop\"}\n"
+
+	var upstreamBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(upstream))
+	}))
+	defer server.Close()
+
+	executor := NewClaudeExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"api_key":  "key-123",
+		"base_url": server.URL,
+	}}
+	payload := []byte(`{"system":[{"type":"text","text":"You are helpful."}],"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)
+
+	result, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-3-5-sonnet-20241022",
+		Payload: payload,
+	}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("claude"),
+	})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+
+	var combined strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("chunk error: %v", chunk.Err)
+		}
+		combined.Write(chunk.Payload)
+	}
+
+	if combined.String() != upstream {
+		t.Fatalf("expected SSE stream forwarded unchanged\n got: %q\nwant: %q", combined.String(), upstream)
+	}
+	if !bytes.Contains(upstreamBody, []byte(`"cache_control"`)) {
+		t.Fatalf("expected cache_control breakpoints in upstream body, got %s", upstreamBody)
+	}
+}
+
+// TestClaudeExecutor_ExecuteStream_ResumesPartialResponse verifies that a stream cut off
+// mid-response is resumed with the partial text as prefill and stitched into one stream.
+func TestClaudeExecutor_ExecuteStream_ResumesPartialResponse(t *testing.T) {
+	truncated := "event: message_start\n" +
+		"data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"usage\":{\"input_tokens\":3,\"output_tokens\":0}}}\n" +
+		"\n" +
+		"event: content_block_start\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n" +
+		"\n" +
+		"event: content_block_delta\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello, \"}}\n" +
+		"\n"
+	continuation := "event: message_start\n" +
+		"data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_2\",\"usage\":{\"input_tokens\":5,\"output_tokens\":0}}}\n" +
+		"\n" +
+		"event: content_block_start\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n" +
+		"\n" +
+		"event: content_block_delta\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" world\"}}\n" +
+		"\n" +
+		"event: content_block_stop\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":0}\n" +
+		"\n" +
+		"event: message_stop\n" +
+		"data: {\"type\":\"message_stop\"}\n"
+
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		if len(bodies) == 1 {
+			_, _ = w.Write([]byte(truncated))
+			return
+		}
+		_, _ = w.Write([]byte(continuation))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.Streaming.PartialRecoveryRetries = 1
+	executor := NewClaudeExecutor(cfg)
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"api_key":  "key-123",
+		"base_url": server.URL,
+	}}
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)
+
+	result, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-3-5-sonnet-20241022",
+		Payload: payload,
+	}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("claude"),
+	})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var combined strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("chunk error: %v", chunk.Err)
+		}
+		combined.Write(chunk.Payload)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("upstream requests = %d, want 2", len(bodies))
+	}
+	if got := gjson.GetBytes(bodies[1], "messages.1").Raw; !strings.Contains(got, `"role":"assistant"`) || !strings.Contains(got, `"text":"Hello,"`) {
+		t.Fatalf("continuation prefill = %s", got)
+	}
+	out := combined.String()
+	if strings.Count(out, "message_start") != 2 || strings.Contains(out, "msg_2") {
+		t.Fatalf("expected only the first message_start event, got %q", out)
+	}
+	if strings.Count(out, "content_block_start") != 2 {
+		t.Fatalf("expected the continuation block to be merged, got %q", out)
+	}
+	if !strings.Contains(out, `"text":"world"`) || !strings.Contains(out, "message_stop") {
+		t.Fatalf("expected the continuation stitched in, got %q", out)
+	}
+}
+
 // TestDecodeResponseBody_MagicByteGzipNoHeader verifies that decodeResponseBody
 // detects gzip-compressed content via magic bytes even when Content-Encoding is absent.
 func TestDecodeResponseBody_MagicByteGzipNoHeader(t *testing.T) {
@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	// The translator packages register the Claude translators the provider uses.
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/gemini"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/gemini-cli"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/openai/chat-completions"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/openai/responses"
+	sdkauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func init() {
+	provider.Register(NewClaudeProvider())
+}
+
+// claudeProvider returns the provider registered for Claude credentials, which replaces the
+// built-in one when a provider is registered under "claude".
+func claudeProvider() provider.Provider {
+	if p, ok := provider.Lookup("claude"); ok {
+		return p
+	}
+	return NewClaudeProvider()
+}
+
+// translateClaudeTokenCount converts a Claude token count to a count-tokens response in format
+// from, returning claudeResponse when p or the format has no translation for it.
+func translateClaudeTokenCount(ctx context.Context, p provider.Provider, from sdktranslator.Format, count int64, claudeResponse []byte) []byte {
+	if from == sdktranslator.FormatClaude {
+		return claudeResponse
+	}
+	if counter, ok := p.(provider.TokenCountTranslator); ok {
+		if out := counter.TranslateTokenCount(ctx, from, count); out != nil {
+			return out
+		}
+	}
+	return claudeResponse
+}
+
+// ClaudeProvider serves Anthropic Claude credentials through the provider interface. The
+// translators between the client formats and the Claude Messages format are registered by the
+// translator packages under internal/translator/claude, so the provider registers none of its
+// own and translates through the translator registry.
+type ClaudeProvider struct{}
+
+// NewClaudeProvider returns the Claude provider.
+func NewClaudeProvider() *ClaudeProvider { return &ClaudeProvider{} }
+
+// Identifier implements provider.Provider.
+func (ClaudeProvider) Identifier() string { return "claude" }
+
+// Capabilities implements provider.Provider.
+func (ClaudeProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		Format:      sdktranslator.FormatClaude,
+		Streaming:   true,
+		CountTokens: true,
+	}
+}
+
+// TranslateRequest implements provider.Provider.
+func (ClaudeProvider) TranslateRequest(from sdktranslator.Format, model string, rawJSON []byte, stream bool) []byte {
+	return sdktranslator.TranslateRequest(from, sdktranslator.FormatClaude, model, rawJSON, stream)
+}
+
+// TranslateStreamChunk implements provider.Provider.
+func (ClaudeProvider) TranslateStreamChunk(ctx context.Context, from sdktranslator.Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+	return sdktranslator.TranslateStream(ctx, sdktranslator.FormatClaude, from, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+}
+
+// TranslateResponse implements provider.Provider.
+func (ClaudeProvider) TranslateResponse(ctx context.Context, from sdktranslator.Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
+	return sdktranslator.TranslateNonStream(ctx, sdktranslator.FormatClaude, from, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+}
+
+// TranslateTokenCount implements provider.TokenCountTranslator. It returns nil for formats
+// without a count-tokens shape of their own; those clients receive the Claude response.
+func (ClaudeProvider) TranslateTokenCount(ctx context.Context, from sdktranslator.Format, count int64) []byte {
+	return sdktranslator.TranslateTokenCount(ctx, sdktranslator.FormatClaude, from, count, nil)
+}
+
+// TranslateError implements provider.Provider. The Anthropic error body is kept as the message
+// so clients in the Claude format receive it unchanged.
+func (ClaudeProvider) TranslateError(status int, headers http.Header, body []byte) error {
+	return statusErr{code: status, msg: string(body), retryAfter: helps.ParseRetryAfter(headers, time.Now())}
+}
+
+// Auth implements provider.Provider.
+func (ClaudeProvider) Auth() provider.Auth { return claudeProviderAuth{} }
+
+type claudeProviderAuth struct{}
+
+func (claudeProviderAuth) NewExecutor(cfg *config.Config) cliproxyauth.ProviderExecutor {
+	return NewClaudeExecutor(cfg)
+}
+
+func (claudeProviderAuth) Authenticator() sdkauth.Authenticator {
+	return sdkauth.NewClaudeAuthenticator()
+}
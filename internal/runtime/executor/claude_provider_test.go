@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// markingClaudeProvider replaces the built-in Claude provider and marks the requests it translates.
+type markingClaudeProvider struct {
+	ClaudeProvider
+}
+
+func (p markingClaudeProvider) TranslateRequest(from sdktranslator.Format, model string, rawJSON []byte, stream bool) []byte {
+	out := p.ClaudeProvider.TranslateRequest(from, model, rawJSON, stream)
+	out, _ = sjson.SetBytes(out, "metadata.translated_by", "override")
+	return out
+}
+
+func TestClaudeExecutor_UsesRegisteredClaudeProvider(t *testing.T) {
+	provider.Register(markingClaudeProvider{})
+	t.Cleanup(func() { provider.Register(NewClaudeProvider()) })
+
+	var seenBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"input_tokens":7}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "key-123", "base_url": server.URL}}
+	_, err := NewClaudeExecutor(&config.Config{}).CountTokens(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-3-5-haiku-20241022",
+		Payload: []byte(`{"model":"claude-3-5-haiku-20241022","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("CountTokens error: %v", err)
+	}
+	if got := gjson.GetBytes(seenBody, "metadata.translated_by").String(); got != "override" {
+		t.Fatalf("request was not translated by the registered provider: %s", seenBody)
+	}
+}
+
+func TestClaudeExecutor_CountTokens_OpenAIResponsesSourceReturnsUpstreamBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"input_tokens":42,"context_management":{"original_input_tokens":50}}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "key-123", "base_url": server.URL}}
+	resp, err := NewClaudeExecutor(&config.Config{}).CountTokens(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "claude-3-5-haiku-20241022",
+		Payload: []byte(`{"model":"claude-3-5-haiku-20241022","input":"hi"}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai-response")})
+	if err != nil {
+		t.Fatalf("CountTokens error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "context_management.original_input_tokens").Int(); got != 50 {
+		t.Fatalf("payload = %s, want the upstream count_tokens body", resp.Payload)
+	}
+}
@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	sdkauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// ExternalProvider serves a provider configured under external-providers through the provider
+// interface. The out-of-process implementation speaks the OpenAI chat completions format, so
+// requests and responses are translated with the built-in OpenAI translators and the provider
+// registers no translators of its own.
+type ExternalProvider struct {
+	name string
+}
+
+// NewExternalProvider returns the provider for the external provider with the given name.
+func NewExternalProvider(name string) *ExternalProvider {
+	return &ExternalProvider{name: strings.ToLower(strings.TrimSpace(name))}
+}
+
+// Identifier implements provider.Provider.
+func (p *ExternalProvider) Identifier() string { return p.name }
+
+// Capabilities implements provider.Provider.
+func (p *ExternalProvider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		Format:      sdktranslator.FormatOpenAI,
+		Streaming:   true,
+		CountTokens: true,
+	}
+}
+
+// TranslateRequest implements provider.Provider.
+func (p *ExternalProvider) TranslateRequest(from sdktranslator.Format, model string, rawJSON []byte, stream bool) []byte {
+	return sdktranslator.TranslateRequest(from, sdktranslator.FormatOpenAI, model, rawJSON, stream)
+}
+
+// TranslateStreamChunk implements provider.Provider. rawJSON is one chunk as an SSE data line.
+func (p *ExternalProvider) TranslateStreamChunk(ctx context.Context, from sdktranslator.Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+	return sdktranslator.TranslateStream(ctx, sdktranslator.FormatOpenAI, from, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+}
+
+// TranslateResponse implements provider.Provider.
+func (p *ExternalProvider) TranslateResponse(ctx context.Context, from sdktranslator.Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
+	return sdktranslator.TranslateNonStream(ctx, sdktranslator.FormatOpenAI, from, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+}
+
+// TranslateTokenCount implements provider.TokenCountTranslator.
+func (p *ExternalProvider) TranslateTokenCount(ctx context.Context, from sdktranslator.Format, count int64) []byte {
+	return sdktranslator.TranslateTokenCount(ctx, sdktranslator.FormatOpenAI, from, count, helps.BuildOpenAIUsageJSON(count))
+}
+
+// TranslateError implements provider.Provider. Failures reported without a status are treated
+// as a bad gateway.
+func (p *ExternalProvider) TranslateError(status int, headers http.Header, body []byte) error {
+	if status == 0 {
+		status = http.StatusBadGateway
+	}
+	return statusErr{code: status, msg: string(body), retryAfter: helps.ParseRetryAfter(headers, time.Now())}
+}
+
+// Auth implements provider.Provider.
+func (p *ExternalProvider) Auth() provider.Auth { return externalProviderAuth{p: p} }
+
+type externalProviderAuth struct {
+	p *ExternalProvider
+}
+
+func (a externalProviderAuth) NewExecutor(cfg *config.Config) cliproxyauth.ProviderExecutor {
+	return newExternalProviderExecutor(a.p, cfg)
+}
+
+// Authenticator returns nil; external provider credentials are configured.
+func (externalProviderAuth) Authenticator() sdkauth.Authenticator { return nil }
@@ -0,0 +1,530 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// externalStderrLimit caps the stderr kept from an external provider command for error messages.
+const externalStderrLimit = 4 << 10
+
+// externalRequest is the message sent to an external provider. Payload is an OpenAI chat
+// completions request for Model.
+type externalRequest struct {
+	Type     string          `json:"type"`
+	Provider string          `json:"provider"`
+	Model    string          `json:"model"`
+	APIKey   string          `json:"api_key,omitempty"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// externalMessage is one line of an external provider reply. "response" carries an OpenAI chat
+// completion, "chunk" an OpenAI chat completion chunk and "error" an upstream failure.
+type externalMessage struct {
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Status  int             `json:"status,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// ExternalProviderExecutor serves providers implemented outside the proxy. Each request is
+// written as one JSON line to the stdin of the configured command, POSTed to the configured
+// URL or sent to the configured gRPC endpoint, and the reply is read as a sequence of JSON
+// messages. Translation and error mapping go through the ExternalProvider.
+type ExternalProviderExecutor struct {
+	p   *ExternalProvider
+	cfg *config.Config
+}
+
+// NewExternalProviderExecutor creates an executor for the external provider with the given name.
+func NewExternalProviderExecutor(provider string, cfg *config.Config) *ExternalProviderExecutor {
+	return newExternalProviderExecutor(NewExternalProvider(provider), cfg)
+}
+
+func newExternalProviderExecutor(p *ExternalProvider, cfg *config.Config) *ExternalProviderExecutor {
+	return &ExternalProviderExecutor{p: p, cfg: cfg}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *ExternalProviderExecutor) Identifier() string { return e.p.Identifier() }
+
+// PrepareRequest is a no-op; external providers receive their credentials in the request message.
+func (e *ExternalProviderExecutor) PrepareRequest(_ *http.Request, _ *cliproxyauth.Auth) error {
+	return nil
+}
+
+// HttpRequest is not supported by external providers.
+func (e *ExternalProviderExecutor) HttpRequest(_ context.Context, _ *cliproxyauth.Auth, _ *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("external provider executor: raw HTTP requests are not supported for %s", e.p.name)
+}
+
+func (e *ExternalProviderExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	entry := e.resolveEntry(auth)
+	if entry == nil {
+		err = statusErr{code: http.StatusServiceUnavailable, msg: fmt.Sprintf("external provider %s is not configured", e.p.name)}
+		return resp, err
+	}
+	from := opts.SourceFormat
+	translated, err := e.translateRequest(entry, req, opts, false)
+	if err != nil {
+		return resp, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(entry.Timeout)*time.Second)
+	reply, err := e.open(ctx, auth, entry, "execute", translated)
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+	// Cancel first so a command that is still running is stopped rather than waited for.
+	defer func() {
+		cancel()
+		reply.close()
+	}()
+
+	var body []byte
+	for {
+		msg, errRead := reply.next()
+		if errRead != nil {
+			err = errRead
+			return resp, err
+		}
+		if msg == nil {
+			break
+		}
+		switch msg.Type {
+		case "response":
+			body = msg.Data
+		case "error":
+			err = e.p.TranslateError(msg.Status, nil, []byte(msg.Message))
+			return resp, err
+		}
+	}
+	if errWait := reply.wait(); errWait != nil && body == nil {
+		err = errWait
+		return resp, err
+	}
+	if body == nil {
+		err = statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("external provider %s returned no response", e.p.name)}
+		return resp, err
+	}
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(body))
+	reporter.EnsurePublished(ctx)
+	var param any
+	out := e.p.TranslateResponse(ctx, from, req.Model, opts.OriginalRequest, translated, body, &param)
+	return cliproxyexecutor.Response{Payload: out}, nil
+}
+
+func (e *ExternalProviderExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	entry := e.resolveEntry(auth)
+	if entry == nil {
+		err = statusErr{code: http.StatusServiceUnavailable, msg: fmt.Sprintf("external provider %s is not configured", e.p.name)}
+		return nil, err
+	}
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated, err := e.translateRequest(entry, req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(entry.Timeout)*time.Second)
+	reply, err := e.open(ctx, auth, entry, "stream", translated)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// Read the first message up front so that a failure before any output reaches the caller as
+	// an error and can be retried on another credential.
+	first, err := reply.next()
+	if err == nil && first == nil {
+		err = reply.wait()
+		if err == nil {
+			err = statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("external provider %s returned no response", e.p.name)}
+		}
+	} else if err == nil && first.Type == "error" {
+		err = e.p.TranslateError(first.Status, nil, []byte(first.Message))
+	}
+	if err != nil {
+		cancel()
+		reply.close()
+		return nil, err
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer reply.close()
+		defer cancel()
+		var param any
+		// OpenAI → OpenAI streams pass the chunks through without the translator.
+		passthrough := from == to
+		emit := func(data []byte) {
+			line := append([]byte("data: "), data...)
+			if detail, ok := helps.ParseOpenAIStreamUsage(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+			if passthrough {
+				out <- cliproxyexecutor.StreamChunk{Payload: data}
+				return
+			}
+			chunks := e.p.TranslateStreamChunk(ctx, from, req.Model, opts.OriginalRequest, translated, line, &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+			}
+		}
+		fail := func(errStream error) {
+			helps.RecordAPIResponseError(ctx, e.cfg, errStream)
+			reporter.PublishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errStream}
+		}
+
+		for msg := first; msg != nil; {
+			switch msg.Type {
+			case "chunk":
+				if len(msg.Data) > 0 {
+					emit(msg.Data)
+				}
+			case "error":
+				fail(e.p.TranslateError(msg.Status, nil, []byte(msg.Message)))
+				return
+			}
+			var errRead error
+			if msg, errRead = reply.next(); errRead != nil {
+				fail(errRead)
+				return
+			}
+		}
+		if errWait := reply.wait(); errWait != nil {
+			fail(errWait)
+			return
+		}
+		if !passthrough {
+			chunks := e.p.TranslateStreamChunk(ctx, from, req.Model, opts.OriginalRequest, translated, []byte("data: [DONE]"), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+			}
+		}
+		reporter.EnsurePublished(ctx)
+	}()
+	return &cliproxyexecutor.StreamResult{Chunks: out}, nil
+}
+
+func (e *ExternalProviderExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	translated := e.p.TranslateRequest(from, baseModel, req.Payload, false)
+
+	enc, err := helps.TokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("external provider executor: tokenizer init failed: %w", err)
+	}
+	count, err := helps.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("external provider executor: token counting failed: %w", err)
+	}
+	return cliproxyexecutor.Response{Payload: e.p.TranslateTokenCount(ctx, from, count)}, nil
+}
+
+// Refresh is a no-op; external provider credentials are configured.
+func (e *ExternalProviderExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *ExternalProviderExecutor) resolveEntry(auth *cliproxyauth.Auth) *config.ExternalProvider {
+	if e.cfg == nil {
+		return nil
+	}
+	name := e.p.name
+	if auth != nil && auth.Attributes != nil {
+		if v := strings.TrimSpace(auth.Attributes["external_provider"]); v != "" {
+			name = v
+		}
+	}
+	for i := range e.cfg.ExternalProviders {
+		if entry := &e.cfg.ExternalProviders[i]; strings.EqualFold(entry.Name, name) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// translateRequest converts the client request to the OpenAI chat payload handed to the
+// provider, with the model alias resolved to the provider's model name.
+func (e *ExternalProviderExecutor) translateRequest(entry *config.ExternalProvider, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) ([]byte, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	originalPayload := req.Payload
+	if len(opts.OriginalRequest) > 0 {
+		originalPayload = opts.OriginalRequest
+	}
+	originalTranslated := e.p.TranslateRequest(from, baseModel, originalPayload, stream)
+	translated := e.p.TranslateRequest(from, baseModel, req.Payload, stream)
+	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
+	requestPath := helps.PayloadRequestPath(opts)
+	translated = helps.ApplyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", translated, originalTranslated, requestedModel, requestPath)
+
+	translated, err := thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	translated, _ = sjson.SetBytes(translated, "model", externalUpstreamModel(entry, baseModel))
+	if stream {
+		translated, _ = sjson.SetBytes(translated, "stream", true)
+	}
+	return translated, nil
+}
+
+// externalUpstreamModel maps a client-visible alias to the provider's model name.
+func externalUpstreamModel(entry *config.ExternalProvider, model string) string {
+	for _, m := range entry.Models {
+		if m.Alias != "" && strings.EqualFold(m.Alias, model) {
+			return m.Name
+		}
+	}
+	return model
+}
+
+// externalReply reads the messages of one external provider reply.
+type externalReply struct {
+	// recv returns the next encoded message, or io.EOF at the end of the reply.
+	recv    func() ([]byte, error)
+	body    io.Closer
+	done    func() error
+	ctx     context.Context
+	cfg     *config.Config
+	waited  bool
+	waitErr error
+}
+
+// next returns the next message, or nil at the end of the reply.
+func (r *externalReply) next() (*externalMessage, error) {
+	for {
+		data, errRecv := r.recv()
+		if errors.Is(errRecv, io.EOF) {
+			return nil, nil
+		}
+		if errRecv != nil {
+			return nil, errRecv
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		helps.AppendAPIResponseChunk(r.ctx, r.cfg, data)
+		var msg externalMessage
+		if errUnmarshal := json.Unmarshal(data, &msg); errUnmarshal != nil {
+			return nil, statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("external provider: invalid reply message: %v", errUnmarshal)}
+		}
+		return &msg, nil
+	}
+}
+
+// wait reports how the provider finished once the reply has been read.
+func (r *externalReply) wait() error {
+	if r.waited {
+		return r.waitErr
+	}
+	r.waited = true
+	if r.done != nil {
+		r.waitErr = r.done()
+	}
+	return r.waitErr
+}
+
+func (r *externalReply) close() {
+	if errClose := r.body.Close(); errClose != nil {
+		log.Debugf("external provider executor: close reply error: %v", errClose)
+	}
+	_ = r.wait()
+}
+
+// open sends the request message to the provider and returns its reply.
+func (e *ExternalProviderExecutor) open(ctx context.Context, auth *cliproxyauth.Auth, entry *config.ExternalProvider, kind string, payload []byte) (*externalReply, error) {
+	apiKey := entry.APIKey
+	if auth != nil && auth.Attributes != nil {
+		if v := strings.TrimSpace(auth.Attributes["api_key"]); v != "" {
+			apiKey = v
+		}
+	}
+	message, errMarshal := json.Marshal(externalRequest{
+		Type:     kind,
+		Provider: entry.Name,
+		Model:    gjson.GetBytes(payload, "model").String(),
+		APIKey:   apiKey,
+		Payload:  payload,
+	})
+	if errMarshal != nil {
+		return nil, errMarshal
+	}
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	target := entry.URL
+	if entry.Command != "" {
+		target = "exec:" + entry.Command
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       target,
+		Method:    http.MethodPost,
+		Body:      payload,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	switch {
+	case entry.Command != "":
+		return e.openCommand(ctx, entry, message)
+	case isExternalGRPCTarget(entry.URL):
+		return e.openGRPC(ctx, auth, entry, message)
+	default:
+		return e.openURL(ctx, auth, entry, message)
+	}
+}
+
+func (e *ExternalProviderExecutor) openCommand(ctx context.Context, entry *config.ExternalProvider, message []byte) (*externalReply, error) {
+	cmd := exec.CommandContext(ctx, entry.Command, entry.Args...)
+	cmd.Stdin = bytes.NewReader(append(message, '\n'))
+	if len(entry.Env) > 0 {
+		keys := make([]string, 0, len(entry.Env))
+		for key := range entry.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		cmd.Env = os.Environ()
+		for _, key := range keys {
+			cmd.Env = append(cmd.Env, key+"="+entry.Env[key])
+		}
+	}
+	stderr := &tailBuffer{limit: externalStderrLimit}
+	cmd.Stderr = stderr
+	stdout, errPipe := cmd.StdoutPipe()
+	if errPipe != nil {
+		return nil, errPipe
+	}
+	if errStart := cmd.Start(); errStart != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errStart)
+		return nil, statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("external provider %s: start command: %v", entry.Name, errStart)}
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, http.StatusOK, nil)
+	done := func() error {
+		errWait := cmd.Wait()
+		if errWait == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		detail := strings.TrimSpace(stderr.String())
+		if detail == "" {
+			detail = errWait.Error()
+		}
+		return statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("external provider %s: %s", entry.Name, detail)}
+	}
+	return newExternalReply(ctx, e.cfg, stdout, done), nil
+}
+
+func (e *ExternalProviderExecutor) openURL(ctx context.Context, auth *cliproxyauth.Auth, entry *config.ExternalProvider, message []byte) (*externalReply, error) {
+	httpReq, errReq := http.NewRequestWithContext(ctx, http.MethodPost, entry.URL, bytes.NewReader(message))
+	if errReq != nil {
+		return nil, errReq
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/x-ndjson")
+	httpReq.Header.Set("User-Agent", "cli-proxy-external-provider")
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, errDo := httpClient.Do(httpReq)
+	if errDo != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
+		return nil, errDo
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("external provider executor: close response body error: %v", errClose)
+		}
+		return nil, e.p.TranslateError(httpResp.StatusCode, httpResp.Header, b)
+	}
+	return newExternalReply(ctx, e.cfg, httpResp.Body, nil), nil
+}
+
+// newExternalReply returns a reply reading newline-delimited JSON messages from body.
+func newExternalReply(ctx context.Context, cfg *config.Config, body io.ReadCloser, done func() error) *externalReply {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, 52_428_800) // 50MB
+	recv := func() ([]byte, error) {
+		if scanner.Scan() {
+			return scanner.Bytes(), nil
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			return nil, errScan
+		}
+		return nil, io.EOF
+	}
+	return &externalReply{recv: recv, body: body, done: done, ctx: ctx, cfg: cfg}
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.limit; over > 0 {
+		b.buf = b.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string { return string(b.buf) }
@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func writeExternalProviderScript(t *testing.T, body string) string {
+	t.Helper()
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("requires /bin/sh")
+	}
+	path := filepath.Join(t.TempDir(), "provider.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return path
+}
+
+func externalProviderTestAuth(name string) *cliproxyauth.Auth {
+	return &cliproxyauth.Auth{ID: "external-" + name, Provider: name, Attributes: map[string]string{"external_provider": name, "api_key": "secret"}}
+}
+
+func TestExternalProviderExecutorCommand(t *testing.T) {
+	dir := t.TempDir()
+	script := writeExternalProviderScript(t, `cat > "$OUT_DIR/request.json"
+echo '{"type":"response","data":{"id":"r1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"scripted"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}}'
+`)
+	cfg := &config.Config{ExternalProviders: []config.ExternalProvider{{
+		Name:    "scripted",
+		Command: script,
+		Env:     map[string]string{"OUT_DIR": dir},
+		Timeout: 10,
+		Models:  []config.ExternalProviderModel{{Name: "upstream-model", Alias: "my-model"}},
+	}}}
+
+	resp, err := NewExternalProviderExecutor("scripted", cfg).Execute(context.Background(), externalProviderTestAuth("scripted"), cliproxyexecutor.Request{
+		Model:   "my-model",
+		Payload: []byte(`{"model":"my-model","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "scripted" {
+		t.Fatalf("content = %q, payload=%s", got, resp.Payload)
+	}
+
+	sent, err := os.ReadFile(filepath.Join(dir, "request.json"))
+	if err != nil {
+		t.Fatalf("read request: %v", err)
+	}
+	if got := gjson.GetBytes(sent, "type").String(); got != "execute" {
+		t.Fatalf("type = %q", got)
+	}
+	if got := gjson.GetBytes(sent, "model").String(); got != "upstream-model" {
+		t.Fatalf("model = %q, want the upstream name", got)
+	}
+	if got := gjson.GetBytes(sent, "payload.model").String(); got != "upstream-model" {
+		t.Fatalf("payload.model = %q", got)
+	}
+	if got := gjson.GetBytes(sent, "api_key").String(); got != "secret" {
+		t.Fatalf("api_key = %q", got)
+	}
+}
+
+func TestExternalProviderExecutorCommandErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		script string
+		status int
+		want   string
+	}{
+		{name: "error message", script: `echo '{"type":"error","status":429,"message":"slow down"}'`, status: http.StatusTooManyRequests, want: "slow down"},
+		{name: "failed command", script: "echo boom >&2\nexit 3", status: http.StatusBadGateway, want: "boom"},
+		{name: "no response", script: "exit 0", status: http.StatusBadGateway, want: "no response"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{ExternalProviders: []config.ExternalProvider{{
+				Name:    "scripted",
+				Command: writeExternalProviderScript(t, "cat > /dev/null\n"+tc.script+"\n"),
+				Timeout: 10,
+			}}}
+			_, err := NewExternalProviderExecutor("scripted", cfg).Execute(context.Background(), externalProviderTestAuth("scripted"), cliproxyexecutor.Request{
+				Model:   "m",
+				Payload: []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`),
+			}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+			se, ok := err.(statusErr)
+			if !ok {
+				t.Fatalf("error = %v, want statusErr", err)
+			}
+			if se.StatusCode() != tc.status || !strings.Contains(se.Error(), tc.want) {
+				t.Fatalf("error = %d %q, want %d containing %q", se.StatusCode(), se.Error(), tc.status, tc.want)
+			}
+		})
+	}
+}
+
+func TestExternalProviderExecutorURLStream(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write([]byte(`{"type":"chunk","data":{"id":"c1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"hel"}}]}}
+{"type":"chunk","data":{"id":"c1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}}
+`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{ExternalProviders: []config.ExternalProvider{{Name: "remote", URL: server.URL, Timeout: 10}}}
+	result, err := NewExternalProviderExecutor("remote", cfg).ExecuteStream(context.Background(), externalProviderTestAuth("remote"), cliproxyexecutor.Request{
+		Model:   "m",
+		Payload: []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"stream":true}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var text strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		text.WriteString(gjson.GetBytes(chunk.Payload, "choices.0.delta.content").String())
+	}
+	if text.String() != "hello" {
+		t.Fatalf("streamed text = %q", text.String())
+	}
+	if got := gjson.GetBytes(gotBody, "type").String(); got != "stream" {
+		t.Fatalf("type = %q", got)
+	}
+	if !gjson.GetBytes(gotBody, "payload.stream").Bool() {
+		t.Fatalf("expected payload.stream, body=%s", gotBody)
+	}
+}
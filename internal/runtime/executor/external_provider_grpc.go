@@ -0,0 +1,207 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// externalGRPCMethod is the server-streaming method external providers serve over gRPC. The
+// request and reply messages are the JSON messages of the line protocol, one per gRPC message,
+// sent with the "json" content subtype so servers need no generated code.
+const externalGRPCMethod = "/cliproxy.external.v1.Provider/Call"
+
+// externalGRPCConns caches one client connection per external provider and URL, so requests
+// share the HTTP/2 connection instead of dialing each time.
+var externalGRPCConns = struct {
+	sync.Mutex
+	byName map[string]*externalGRPCConn
+}{byName: make(map[string]*externalGRPCConn)}
+
+type externalGRPCConn struct {
+	url  string
+	conn *grpc.ClientConn
+}
+
+// externalGRPCClient returns the cached connection of the external provider name, replacing a
+// connection made for another URL.
+func externalGRPCClient(name, rawURL string) (*grpc.ClientConn, error) {
+	externalGRPCConns.Lock()
+	defer externalGRPCConns.Unlock()
+	if cached := externalGRPCConns.byName[name]; cached != nil {
+		if cached.url == rawURL {
+			return cached.conn, nil
+		}
+		_ = cached.conn.Close()
+		delete(externalGRPCConns.byName, name)
+	}
+
+	scheme, target, _ := strings.Cut(rawURL, "://")
+	target = strings.TrimSuffix(target, "/")
+	creds := insecure.NewCredentials()
+	if strings.EqualFold(scheme, "grpcs") {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, errDial := grpc.NewClient(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent("cli-proxy-external-provider"),
+	)
+	if errDial != nil {
+		return nil, errDial
+	}
+	externalGRPCConns.byName[name] = &externalGRPCConn{url: rawURL, conn: conn}
+	return conn, nil
+}
+
+// CloseExternalGRPCConns closes the cached gRPC connections of external providers that cfg no
+// longer configures with the same gRPC URL. A nil cfg closes all of them.
+func CloseExternalGRPCConns(cfg *config.Config) {
+	keep := make(map[string]string)
+	if cfg != nil {
+		for i := range cfg.ExternalProviders {
+			entry := &cfg.ExternalProviders[i]
+			if entry.Command == "" && isExternalGRPCTarget(entry.URL) {
+				keep[entry.Name] = entry.URL
+			}
+		}
+	}
+	externalGRPCConns.Lock()
+	defer externalGRPCConns.Unlock()
+	for name, cached := range externalGRPCConns.byName {
+		if url, ok := keep[name]; ok && url == cached.url {
+			continue
+		}
+		if errClose := cached.conn.Close(); errClose != nil {
+			log.Debugf("external provider executor: close gRPC connection error: %v", errClose)
+		}
+		delete(externalGRPCConns.byName, name)
+	}
+}
+
+// isExternalGRPCTarget reports whether an external provider URL names a gRPC endpoint:
+// grpc://host:port for plaintext and grpcs://host:port for TLS.
+func isExternalGRPCTarget(rawURL string) bool {
+	scheme, _, ok := strings.Cut(rawURL, "://")
+	return ok && (strings.EqualFold(scheme, "grpc") || strings.EqualFold(scheme, "grpcs"))
+}
+
+// externalJSONCodec passes the already encoded JSON messages through gRPC unchanged.
+type externalJSONCodec struct{}
+
+func (externalJSONCodec) Marshal(v any) ([]byte, error) {
+	data, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("external provider: unexpected gRPC message type %T", v)
+	}
+	return data, nil
+}
+
+func (externalJSONCodec) Unmarshal(data []byte, v any) error {
+	out, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("external provider: unexpected gRPC message type %T", v)
+	}
+	// The transport may reuse data once Unmarshal returns.
+	*out = bytes.Clone(data)
+	return nil
+}
+
+func (externalJSONCodec) Name() string { return "json" }
+
+func (e *ExternalProviderExecutor) openGRPC(ctx context.Context, auth *cliproxyauth.Auth, entry *config.ExternalProvider, message []byte) (*externalReply, error) {
+	conn, errDial := externalGRPCClient(entry.Name, entry.URL)
+	if errDial != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errDial)
+		return nil, statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("external provider %s: %v", entry.Name, errDial)}
+	}
+
+	// Custom headers configured for the provider are sent as request metadata.
+	md := metadata.MD{}
+	if auth != nil {
+		for key, value := range auth.Attributes {
+			name, ok := strings.CutPrefix(key, "header:")
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			if ok && name != "" && value != "" {
+				md.Append(name, value)
+			}
+		}
+	}
+	if len(md) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	stream, errStream := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, externalGRPCMethod, grpc.ForceCodec(externalJSONCodec{}))
+	if errStream != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errStream)
+		return nil, e.grpcError(errStream)
+	}
+	// SendMsg reports io.EOF when the server already ended the call; the status is then read
+	// from the reply.
+	if errSend := stream.SendMsg(message); errSend != nil && !errors.Is(errSend, io.EOF) {
+		helps.RecordAPIResponseError(ctx, e.cfg, errSend)
+		return nil, e.grpcError(errSend)
+	}
+	if errClose := stream.CloseSend(); errClose != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errClose)
+		return nil, e.grpcError(errClose)
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, http.StatusOK, nil)
+
+	recv := func() ([]byte, error) {
+		var data []byte
+		if errRecv := stream.RecvMsg(&data); errRecv != nil {
+			if errors.Is(errRecv, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, e.grpcError(errRecv)
+		}
+		return data, nil
+	}
+	// The connection is shared; the call ends with its context, so closing the reply is a no-op.
+	return &externalReply{recv: recv, body: io.NopCloser(nil), ctx: ctx, cfg: e.cfg}, nil
+}
+
+// grpcError converts a gRPC failure to the executor error with the matching HTTP status.
+func (e *ExternalProviderExecutor) grpcError(err error) error {
+	st, ok := grpcstatus.FromError(err)
+	if !ok {
+		return err
+	}
+	code := http.StatusBadGateway
+	switch st.Code() {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		code = http.StatusBadRequest
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.ResourceExhausted:
+		code = http.StatusTooManyRequests
+	case codes.Unimplemented:
+		code = http.StatusNotImplemented
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		code = http.StatusGatewayTimeout
+	}
+	return e.p.TranslateError(code, nil, []byte(st.Message()))
+}
@@ -0,0 +1,160 @@
+package executor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// startExternalGRPCProvider serves the external provider gRPC method with handler and returns
+// the grpc:// URL of the server.
+func startExternalGRPCProvider(t *testing.T, handler func(request []byte, md metadata.MD, stream grpc.ServerStream) error) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer(grpc.ForceServerCodec(externalJSONCodec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "cliproxy.external.v1.Provider",
+		HandlerType: (*any)(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Call",
+			ServerStreams: true,
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				var request []byte
+				if errRecv := stream.RecvMsg(&request); errRecv != nil {
+					return errRecv
+				}
+				md, _ := metadata.FromIncomingContext(stream.Context())
+				return handler(request, md, stream)
+			},
+		}},
+	}, struct{}{})
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(func() {
+		CloseExternalGRPCConns(nil)
+		server.Stop()
+	})
+	return "grpc://" + lis.Addr().String()
+}
+
+func TestExternalProviderExecutorGRPCStream(t *testing.T) {
+	var gotRequest []byte
+	var gotHeader string
+	url := startExternalGRPCProvider(t, func(request []byte, md metadata.MD, stream grpc.ServerStream) error {
+		gotRequest = request
+		if values := md.Get("x-tenant"); len(values) > 0 {
+			gotHeader = values[0]
+		}
+		for _, msg := range []string{
+			`{"type":"chunk","data":{"id":"c1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"hel"}}]}}`,
+			`{"type":"chunk","data":{"id":"c1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}}`,
+		} {
+			if errSend := stream.SendMsg([]byte(msg)); errSend != nil {
+				return errSend
+			}
+		}
+		return nil
+	})
+
+	cfg := &config.Config{ExternalProviders: []config.ExternalProvider{{Name: "remote", URL: url, Timeout: 10}}}
+	auth := externalProviderTestAuth("remote")
+	auth.Attributes["header:X-Tenant"] = "acme"
+	result, err := NewExternalProviderExecutor("remote", cfg).ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "m",
+		Payload: []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"stream":true}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var text strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		text.WriteString(gjson.GetBytes(chunk.Payload, "choices.0.delta.content").String())
+	}
+	if text.String() != "hello" {
+		t.Fatalf("streamed text = %q", text.String())
+	}
+	if got := gjson.GetBytes(gotRequest, "type").String(); got != "stream" {
+		t.Fatalf("type = %q, request=%s", got, gotRequest)
+	}
+	if got := gjson.GetBytes(gotRequest, "api_key").String(); got != "secret" {
+		t.Fatalf("api_key = %q", got)
+	}
+	if gotHeader != "acme" {
+		t.Fatalf("x-tenant metadata = %q", gotHeader)
+	}
+}
+
+func TestExternalProviderExecutorGRPCStatus(t *testing.T) {
+	url := startExternalGRPCProvider(t, func(_ []byte, _ metadata.MD, _ grpc.ServerStream) error {
+		return grpcstatus.Error(codes.ResourceExhausted, "slow down")
+	})
+
+	cfg := &config.Config{ExternalProviders: []config.ExternalProvider{{Name: "remote", URL: url, Timeout: 10}}}
+	_, err := NewExternalProviderExecutor("remote", cfg).Execute(context.Background(), externalProviderTestAuth("remote"), cliproxyexecutor.Request{
+		Model:   "m",
+		Payload: []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	se, ok := err.(statusErr)
+	if !ok {
+		t.Fatalf("error = %v, want statusErr", err)
+	}
+	if se.StatusCode() != http.StatusTooManyRequests || !strings.Contains(se.Error(), "slow down") {
+		t.Fatalf("error = %d %q, want 429 containing %q", se.StatusCode(), se.Error(), "slow down")
+	}
+}
+
+func TestExternalProviderExecutorGRPCReusesConnection(t *testing.T) {
+	url := startExternalGRPCProvider(t, func(_ []byte, _ metadata.MD, stream grpc.ServerStream) error {
+		return stream.SendMsg([]byte(`{"type":"response","data":{"id":"r1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}}`))
+	})
+
+	cfg := &config.Config{ExternalProviders: []config.ExternalProvider{{Name: "remote", URL: url, Timeout: 10}}}
+	exec := NewExternalProviderExecutor("remote", cfg)
+	var conns []*grpc.ClientConn
+	for i := 0; i < 2; i++ {
+		if _, err := exec.Execute(context.Background(), externalProviderTestAuth("remote"), cliproxyexecutor.Request{
+			Model:   "m",
+			Payload: []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}]}`),
+		}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}); err != nil {
+			t.Fatalf("Execute error: %v", err)
+		}
+		externalGRPCConns.Lock()
+		conns = append(conns, externalGRPCConns.byName["remote"].conn)
+		externalGRPCConns.Unlock()
+	}
+	if conns[0] != conns[1] {
+		t.Fatal("expected requests to share the cached connection")
+	}
+
+	CloseExternalGRPCConns(cfg)
+	externalGRPCConns.Lock()
+	_, kept := externalGRPCConns.byName["remote"]
+	externalGRPCConns.Unlock()
+	if !kept {
+		t.Fatal("connection of an unchanged provider was closed")
+	}
+
+	CloseExternalGRPCConns(&config.Config{})
+	externalGRPCConns.Lock()
+	_, kept = externalGRPCConns.byName["remote"]
+	externalGRPCConns.Unlock()
+	if kept {
+		t.Fatal("connection of a removed provider was kept")
+	}
+}
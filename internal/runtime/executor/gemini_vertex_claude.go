@@ -0,0 +1,266 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// vertexAnthropicVersion is the anthropic_version body field required by Claude on Vertex.
+// Claude models listed under vertex-claude are sent as translated Claude Messages bodies to
+// the Anthropic publisher endpoints (rawPredict/streamRawPredict) of the credential's project.
+const vertexAnthropicVersion = "vertex-2023-10-16"
+
+// vertexClaudeModel resolves the Vertex Claude model ID for a request model when the project
+// is selected for Claude routing.
+func (e *GeminiVertexExecutor) vertexClaudeModel(model, projectID string) (string, bool) {
+	if e.cfg == nil || !e.cfg.VertexClaude.AppliesToProject(projectID) {
+		return "", false
+	}
+	return e.cfg.VertexClaude.UpstreamModel(thinking.ParseSuffix(model).ModelName)
+}
+
+// vertexClaudeURL builds an Anthropic publisher endpoint URL. Token counting uses the shared
+// count-tokens model and carries the target model in the body.
+func vertexClaudeURL(projectID, location, model, action string) string {
+	return fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/anthropic/models/%s:%s", vertexBaseURL(location), vertexAPIVersion, projectID, location, model, action)
+}
+
+// prepareVertexClaudeBody adapts a Claude Messages body to Vertex: the model moves to the URL,
+// anthropic_version is set and betas are returned for the anthropic-beta header.
+func prepareVertexClaudeBody(body []byte) ([]string, []byte) {
+	betas, body := extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "model")
+	body, _ = sjson.SetBytes(body, "anthropic_version", vertexAnthropicVersion)
+	return betas, body
+}
+
+// buildVertexClaudeBody translates the request into a Vertex Claude body. It returns the
+// betas, the upstream body and the body to hand to response translators.
+func (e *GeminiVertexExecutor) buildVertexClaudeBody(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, stream bool) ([]string, []byte, []byte, error) {
+	body, err := buildHostedClaudeBody(ctx, e.cfg, e.Identifier(), req, opts, stream)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	betas, upstreamBody := prepareVertexClaudeBody(body)
+	translationBody, _ := sjson.DeleteBytes(body, "betas")
+	return betas, upstreamBody, translationBody, nil
+}
+
+// doVertexClaudeRequest sends a Vertex Claude request and returns the response once it is
+// known to be successful.
+func (e *GeminiVertexExecutor) doVertexClaudeRequest(ctx context.Context, auth *cliproxyauth.Auth, url string, body []byte, betas []string, saJSON []byte) (*http.Response, error) {
+	httpReq, errNewReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if errNewReq != nil {
+		return nil, errNewReq
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if token, errTok := vertexAccessToken(ctx, e.cfg, auth, saJSON); errTok == nil && token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	} else if errTok != nil {
+		log.Errorf("vertex executor: access token error: %v", errTok)
+		return nil, statusErr{code: http.StatusInternalServerError, msg: "internal server error"}
+	}
+	if len(betas) > 0 {
+		httpReq.Header.Set("anthropic-beta", strings.Join(betas, ","))
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, e.cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpResp, errDo := httpClient.Do(httpReq)
+	if errDo != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, errDo)
+		return nil, errDo
+	}
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(httpResp.Body)
+		helps.AppendAPIResponseChunk(ctx, e.cfg, b)
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b), retryAfter: helps.ParseRetryAfter(httpResp.Header, time.Now())}
+	}
+	return httpResp, nil
+}
+
+// executeClaudeWithServiceAccount performs a non-streaming Claude request through Vertex.
+// Translated requests stream upstream to preserve function calling, like the Claude executor.
+func (e *GeminiVertexExecutor) executeClaudeWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location, upstreamModel string, saJSON []byte) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	stream := from != to
+	betas, body, translationBody, err := e.buildVertexClaudeBody(ctx, req, opts, stream)
+	if err != nil {
+		return resp, err
+	}
+	action := "rawPredict"
+	if stream {
+		action = "streamRawPredict"
+	}
+	httpResp, err := e.doVertexClaudeRequest(ctx, auth, vertexClaudeURL(projectID, location, upstreamModel, action), body, betas, saJSON)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return resp, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+	if stream {
+		if errValidate := validateClaudeStreamingResponse(data); errValidate != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errValidate)
+			return resp, errValidate
+		}
+		var streamUsage helps.ClaudeStreamUsage
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if detail, ok := streamUsage.Parse(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+		}
+	} else {
+		reporter.Publish(ctx, helps.ParseClaudeUsage(data))
+	}
+	var param any
+	out := sdktranslator.TranslateNonStream(withUpstreamCreated(ctx, httpResp.Header), to, from, req.Model, opts.OriginalRequest, translationBody, data, &param)
+	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
+}
+
+// executeClaudeStreamWithServiceAccount performs a streaming Claude request through Vertex.
+func (e *GeminiVertexExecutor) executeClaudeStreamWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location, upstreamModel string, saJSON []byte) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	betas, body, translationBody, err := e.buildVertexClaudeBody(ctx, req, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := e.doVertexClaudeRequest(ctx, auth, vertexClaudeURL(projectID, location, upstreamModel, "streamRawPredict"), body, betas, saJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer func() {
+			if errClose := httpResp.Body.Close(); errClose != nil {
+				log.Errorf("vertex executor: close response body error: %v", errClose)
+			}
+		}()
+		scanner := bufio.NewScanner(httpResp.Body)
+		scanner.Buffer(nil, streamScannerBuffer)
+		var param any
+		respCtx := withUpstreamCreated(ctx, httpResp.Header)
+		var streamUsage helps.ClaudeStreamUsage
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+			if detail, ok := streamUsage.Parse(line); ok {
+				reporter.Publish(ctx, detail)
+			}
+			// Claude clients receive the SSE stream as-is.
+			if from == to {
+				cloned := make([]byte, len(line)+1)
+				copy(cloned, line)
+				cloned[len(line)] = '\n'
+				out <- cliproxyexecutor.StreamChunk{Payload: cloned}
+				continue
+			}
+			chunks := sdktranslator.TranslateStream(respCtx, to, from, req.Model, opts.OriginalRequest, translationBody, bytes.Clone(line), &param)
+			for i := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
+			reporter.PublishFailure(ctx)
+			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: httpResp.Header.Clone(), Chunks: out}, nil
+}
+
+// countClaudeTokensWithServiceAccount counts tokens with the Vertex count-tokens model.
+func (e *GeminiVertexExecutor) countClaudeTokensWithServiceAccount(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, projectID, location, upstreamModel string, saJSON []byte) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("claude")
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, from != to)
+	body = enforceCacheControlLimit(body, 4)
+	body = normalizeCacheControlTTL(body)
+	betas, body := extractAndRemoveBetas(body)
+	body, _ = sjson.DeleteBytes(body, "max_tokens")
+	body, _ = sjson.DeleteBytes(body, "stream")
+	body, _ = sjson.SetBytes(body, "model", upstreamModel)
+
+	httpResp, err := e.doVertexClaudeRequest(ctx, auth, vertexClaudeURL(projectID, location, "count-tokens", "rawPredict"), body, betas, saJSON)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("vertex executor: close response body error: %v", errClose)
+		}
+	}()
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, e.cfg, err)
+		return cliproxyexecutor.Response{}, err
+	}
+	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+	count := gjson.GetBytes(data, "input_tokens").Int()
+	out := sdktranslator.TranslateTokenCount(ctx, to, from, count, data)
+	return cliproxyexecutor.Response{Payload: out, Headers: httpResp.Header.Clone()}, nil
+}
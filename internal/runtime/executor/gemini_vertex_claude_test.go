@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestVertexClaudeModelSelection(t *testing.T) {
+	e := NewGeminiVertexExecutor(&config.Config{VertexClaude: config.VertexClaudeConfig{
+		Projects: []string{"claude-project"},
+		Models:   []config.VertexCompatModel{{Name: "claude-sonnet-4-5@20250929", Alias: "claude-sonnet-4-5"}},
+	}})
+
+	if got, ok := e.vertexClaudeModel("claude-sonnet-4-5(high)", "claude-project"); !ok || got != "claude-sonnet-4-5@20250929" {
+		t.Fatalf("vertexClaudeModel() = %q, %v", got, ok)
+	}
+	if _, ok := e.vertexClaudeModel("claude-sonnet-4-5", "other-project"); ok {
+		t.Fatal("expected projects outside the list to keep Gemini routing")
+	}
+	if _, ok := e.vertexClaudeModel("gemini-2.5-pro", "claude-project"); ok {
+		t.Fatal("expected unlisted models to keep Gemini routing")
+	}
+}
+
+func TestVertexClaudeRequestShape(t *testing.T) {
+	url := vertexClaudeURL("p1", "us-east5", "claude-sonnet-4-5@20250929", "streamRawPredict")
+	if want := "https://us-east5-aiplatform.googleapis.com/v1/projects/p1/locations/us-east5/publishers/anthropic/models/claude-sonnet-4-5@20250929:streamRawPredict"; url != want {
+		t.Fatalf("url = %s, want %s", url, want)
+	}
+
+	betas, body := prepareVertexClaudeBody([]byte(`{"model":"claude-sonnet-4-5","betas":["context-1m-2025-08-07"],"max_tokens":10}`))
+	if len(betas) != 1 || betas[0] != "context-1m-2025-08-07" {
+		t.Fatalf("betas = %v", betas)
+	}
+	if gjson.GetBytes(body, "model").Exists() || gjson.GetBytes(body, "betas").Exists() {
+		t.Fatalf("expected model and betas to be removed: %s", body)
+	}
+	if got := gjson.GetBytes(body, "anthropic_version").String(); got != vertexAnthropicVersion {
+		t.Fatalf("anthropic_version = %q", got)
+	}
+}
@@ -247,6 +247,9 @@ func (e *GeminiVertexExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 		if errCreds != nil {
 			return resp, errCreds
 		}
+		if upstreamModel, ok := e.vertexClaudeModel(req.Model, projectID); ok {
+			return e.executeClaudeWithServiceAccount(ctx, auth, req, opts, projectID, location, upstreamModel, saJSON)
+		}
 		return e.executeWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
 	}
 
@@ -268,6 +271,9 @@ func (e *GeminiVertexExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 		if errCreds != nil {
 			return nil, errCreds
 		}
+		if upstreamModel, ok := e.vertexClaudeModel(req.Model, projectID); ok {
+			return e.executeClaudeStreamWithServiceAccount(ctx, auth, req, opts, projectID, location, upstreamModel, saJSON)
+		}
 		return e.executeStreamWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
 	}
 
@@ -286,6 +292,9 @@ func (e *GeminiVertexExecutor) CountTokens(ctx context.Context, auth *cliproxyau
 		if errCreds != nil {
 			return cliproxyexecutor.Response{}, errCreds
 		}
+		if upstreamModel, ok := e.vertexClaudeModel(req.Model, projectID); ok {
+			return e.countClaudeTokensWithServiceAccount(ctx, auth, req, opts, projectID, location, upstreamModel, saJSON)
+		}
 		return e.countTokensWithServiceAccount(ctx, auth, req, opts, projectID, location, saJSON)
 	}
 
@@ -981,6 +990,8 @@ func (e *GeminiVertexExecutor) countTokensWithAPIKey(ctx context.Context, auth *
 }
 
 // vertexCreds extracts project, location and raw service account JSON from auth metadata.
+// Credentials without a service account yield nil JSON and authenticate with Application
+// Default Credentials.
 func vertexCreds(a *cliproxyauth.Auth) (projectID, location string, serviceAccountJSON []byte, err error) {
 	if a == nil || a.Metadata == nil {
 		return "", "", nil, fmt.Errorf("vertex executor: missing auth metadata")
@@ -1007,7 +1018,7 @@ func vertexCreds(a *cliproxyauth.Auth) (projectID, location string, serviceAccou
 		sa = raw
 	}
 	if sa == nil {
-		return "", "", nil, fmt.Errorf("vertex executor: missing service_account in credentials")
+		return projectID, location, nil, nil
 	}
 	normalized, errNorm := vertexauth.NormalizeServiceAccountMap(sa)
 	if errNorm != nil {
@@ -1052,9 +1063,18 @@ func vertexAccessToken(ctx context.Context, cfg *config.Config, auth *cliproxyau
 		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
 	}
 	// Use cloud-platform scope for Vertex AI.
-	creds, errCreds := google.CredentialsFromJSON(ctx, saJSON, "https://www.googleapis.com/auth/cloud-platform")
-	if errCreds != nil {
-		return "", fmt.Errorf("vertex executor: parse service account json failed: %w", errCreds)
+	var creds *google.Credentials
+	var errCreds error
+	if len(saJSON) == 0 {
+		creds, errCreds = google.FindDefaultCredentials(ctx, "https://www.googleapis.com/auth/cloud-platform")
+		if errCreds != nil {
+			return "", fmt.Errorf("vertex executor: application default credentials unavailable: %w", errCreds)
+		}
+	} else {
+		creds, errCreds = google.CredentialsFromJSON(ctx, saJSON, "https://www.googleapis.com/auth/cloud-platform")
+		if errCreds != nil {
+			return "", fmt.Errorf("vertex executor: parse service account json failed: %w", errCreds)
+		}
 	}
 	tok, errTok := creds.TokenSource.Token()
 	if errTok != nil {
@@ -0,0 +1,114 @@
+package helps
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// awsEventStreamMaxMessage bounds a single event stream message to protect against corrupt
+// length prefixes.
+const awsEventStreamMaxMessage = 24 << 20
+
+// AWSEventStreamMessage is a message of the AWS binary event stream encoding
+// (application/vnd.amazon.eventstream). Only string headers are retained.
+type AWSEventStreamMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// AWSEventStreamDecoder reads messages from an AWS event stream.
+type AWSEventStreamDecoder struct {
+	r io.Reader
+}
+
+// NewAWSEventStreamDecoder creates a decoder reading from r.
+func NewAWSEventStreamDecoder(r io.Reader) *AWSEventStreamDecoder {
+	return &AWSEventStreamDecoder{r: r}
+}
+
+// Next returns the next message, or io.EOF once the stream ends cleanly.
+func (d *AWSEventStreamDecoder) Next() (AWSEventStreamMessage, error) {
+	var msg AWSEventStreamMessage
+	prelude := make([]byte, 12)
+	if _, errRead := io.ReadFull(d.r, prelude); errRead != nil {
+		if errRead == io.ErrUnexpectedEOF {
+			return msg, fmt.Errorf("aws event stream: truncated prelude")
+		}
+		return msg, errRead
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return msg, fmt.Errorf("aws event stream: prelude checksum mismatch")
+	}
+	if totalLen < 16 || totalLen > awsEventStreamMaxMessage || uint64(headersLen)+16 > uint64(totalLen) {
+		return msg, fmt.Errorf("aws event stream: invalid message length %d", totalLen)
+	}
+	frame := make([]byte, totalLen)
+	copy(frame, prelude)
+	if _, errRead := io.ReadFull(d.r, frame[12:]); errRead != nil {
+		return msg, fmt.Errorf("aws event stream: truncated message: %w", errRead)
+	}
+	if crc32.ChecksumIEEE(frame[:totalLen-4]) != binary.BigEndian.Uint32(frame[totalLen-4:]) {
+		return msg, fmt.Errorf("aws event stream: message checksum mismatch")
+	}
+	headers, errHeaders := parseAWSEventStreamHeaders(frame[12 : 12+headersLen])
+	if errHeaders != nil {
+		return msg, errHeaders
+	}
+	msg.Headers = headers
+	msg.Payload = frame[12+headersLen : totalLen-4]
+	return msg, nil
+}
+
+func parseAWSEventStreamHeaders(raw []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	r := bytes.NewReader(raw)
+	for r.Len() > 0 {
+		nameLen, _ := r.ReadByte()
+		name := make([]byte, nameLen)
+		if _, errRead := io.ReadFull(r, name); errRead != nil {
+			return nil, fmt.Errorf("aws event stream: truncated header name")
+		}
+		valueType, errType := r.ReadByte()
+		if errType != nil {
+			return nil, fmt.Errorf("aws event stream: truncated header type")
+		}
+		var skip int64
+		switch valueType {
+		case 0, 1: // boolean true/false
+		case 2: // byte
+			skip = 1
+		case 3: // int16
+			skip = 2
+		case 4: // int32
+			skip = 4
+		case 5, 8: // int64, timestamp
+			skip = 8
+		case 9: // uuid
+			skip = 16
+		case 6, 7: // byte array, string
+			var valueLen uint16
+			if errRead := binary.Read(r, binary.BigEndian, &valueLen); errRead != nil {
+				return nil, fmt.Errorf("aws event stream: truncated header length")
+			}
+			value := make([]byte, valueLen)
+			if _, errRead := io.ReadFull(r, value); errRead != nil {
+				return nil, fmt.Errorf("aws event stream: truncated header value")
+			}
+			if valueType == 7 {
+				headers[string(name)] = string(value)
+			}
+		default:
+			return nil, fmt.Errorf("aws event stream: unknown header type %d", valueType)
+		}
+		if skip > int64(r.Len()) {
+			return nil, fmt.Errorf("aws event stream: truncated header value")
+		}
+		_, _ = r.Seek(skip, io.SeekCurrent)
+	}
+	return headers, nil
+}
@@ -0,0 +1,32 @@
+package helps
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestSignAWSRequestV4 checks the get-vanilla case of the AWS SigV4 test suite.
+func TestSignAWSRequestV4(t *testing.T) {
+	req, errReq := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if errReq != nil {
+		t.Fatal(errReq)
+	}
+	creds := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	SignAWSRequestV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %s\nwant %s", got, want)
+	}
+}
+
+func TestAWSCanonicalURIDoubleEncodes(t *testing.T) {
+	req, errReq := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-east-1.amazonaws.com/model/anthropic.claude-v2%3A1/invoke", nil)
+	if errReq != nil {
+		t.Fatal(errReq)
+	}
+	if got := awsCanonicalURI(req.URL); got != "/model/anthropic.claude-v2%253A1/invoke" {
+		t.Fatalf("canonical URI = %s", got)
+	}
+}
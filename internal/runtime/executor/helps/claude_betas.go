@@ -0,0 +1,109 @@
+package helps
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+// ClaudeFeatureBetas returns the anthropic-beta flags needed by the features used in a Claude
+// Messages body: fast mode, the 1h prompt cache, extended or adaptive thinking and context
+// management. claude-request.betas.features overrides the flag of each feature.
+func ClaudeFeatureBetas(cfg *config.Config, body []byte) []string {
+	var features []string
+	if gjson.GetBytes(body, "speed").String() == "fast" {
+		features = append(features, "fast-mode")
+	}
+	if claudeUsesExtendedCacheTTL(body) {
+		features = append(features, "extended-cache-ttl")
+	}
+	switch gjson.GetBytes(body, "thinking.type").String() {
+	case "enabled", "adaptive":
+		features = append(features, "interleaved-thinking")
+	}
+	if gjson.GetBytes(body, "context_management").Exists() {
+		features = append(features, "context-management")
+	}
+
+	var overrides map[string]string
+	if cfg != nil {
+		overrides = cfg.ClaudeRequest.Betas.Features
+	}
+	betas := make([]string, 0, len(features))
+	for _, feature := range features {
+		flag, ok := overrides[feature]
+		if !ok {
+			flag = config.ClaudeBetaFeatureFlags[feature]
+		}
+		if flag != "" {
+			betas = append(betas, flag)
+		}
+	}
+	return betas
+}
+
+// claudeUsesExtendedCacheTTL reports whether any cache_control breakpoint asks for the 1h TTL.
+func claudeUsesExtendedCacheTTL(body []byte) bool {
+	hasTTL := func(blocks gjson.Result) bool {
+		found := false
+		blocks.ForEach(func(_, block gjson.Result) bool {
+			found = block.Get("cache_control.ttl").String() == "1h"
+			return !found
+		})
+		return found
+	}
+	if hasTTL(gjson.GetBytes(body, "tools")) || hasTTL(gjson.GetBytes(body, "system")) {
+		return true
+	}
+	found := false
+	gjson.GetBytes(body, "messages").ForEach(func(_, message gjson.Result) bool {
+		found = hasTTL(message.Get("content"))
+		return !found
+	})
+	return found
+}
+
+// ApplyClaudeBetaRules adds and removes the anthropic-beta flags of the claude-request.betas
+// rules matching model, in order, on an assembled request header.
+func ApplyClaudeBetaRules(header http.Header, cfg *config.Config, model string) {
+	if header == nil || cfg == nil || len(cfg.ClaudeRequest.Betas.Rules) == 0 {
+		return
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	var flags []string
+	for _, flag := range strings.Split(header.Get("Anthropic-Beta"), ",") {
+		if flag = strings.TrimSpace(flag); flag != "" {
+			flags = append(flags, flag)
+		}
+	}
+	matched := false
+	for _, rule := range cfg.ClaudeRequest.Betas.Rules {
+		if len(rule.Models) > 0 && !matchAnyModelPattern(rule.Models, model) {
+			continue
+		}
+		matched = true
+		for _, flag := range rule.Add {
+			if !slices.Contains(flags, flag) {
+				flags = append(flags, flag)
+			}
+		}
+		kept := flags[:0]
+		for _, flag := range flags {
+			if !slices.Contains(rule.Remove, flag) {
+				kept = append(kept, flag)
+			}
+		}
+		flags = kept
+	}
+	if !matched {
+		return
+	}
+	if len(flags) == 0 {
+		header.Del("Anthropic-Beta")
+		return
+	}
+	header.Set("Anthropic-Beta", strings.Join(flags, ","))
+}
@@ -0,0 +1,54 @@
+package helps
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestClaudeFeatureBetas(t *testing.T) {
+	body := []byte(`{"speed":"fast","thinking":{"type":"adaptive"},"messages":[{"role":"user","content":[{"type":"text","text":"hi","cache_control":{"type":"ephemeral","ttl":"1h"}}]}]}`)
+
+	got := ClaudeFeatureBetas(nil, body)
+	want := []string{"fast-mode-2026-02-01", "extended-cache-ttl-2025-04-11", "interleaved-thinking-2025-05-14"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("ClaudeFeatureBetas() = %v, want %v", got, want)
+	}
+
+	cfg := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{Betas: config.ClaudeBetasPolicy{
+		Features: map[string]string{"fast-mode": "fast-mode-2026-06-01", "interleaved-thinking": ""},
+	}}}
+	got = ClaudeFeatureBetas(cfg, body)
+	want = []string{"fast-mode-2026-06-01", "extended-cache-ttl-2025-04-11"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("ClaudeFeatureBetas() with overrides = %v, want %v", got, want)
+	}
+
+	if got = ClaudeFeatureBetas(nil, []byte(`{"messages":[{"role":"user","content":"hi"}]}`)); len(got) != 0 {
+		t.Fatalf("ClaudeFeatureBetas() without features = %v, want none", got)
+	}
+}
+
+func TestApplyClaudeBetaRules(t *testing.T) {
+	cfg := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{Betas: config.ClaudeBetasPolicy{
+		Rules: []config.ClaudeBetaRule{
+			{Models: []string{"claude-sonnet-*"}, Add: []string{"context-1m-2025-08-07"}},
+			{Remove: []string{"redact-thinking-2026-02-12"}},
+		},
+	}}}
+
+	header := http.Header{}
+	header.Set("Anthropic-Beta", "oauth-2025-04-20,redact-thinking-2026-02-12")
+	ApplyClaudeBetaRules(header, cfg, "claude-sonnet-4-5")
+	if got := header.Get("Anthropic-Beta"); got != "oauth-2025-04-20,context-1m-2025-08-07" {
+		t.Fatalf("Anthropic-Beta = %q", got)
+	}
+
+	header.Set("Anthropic-Beta", "redact-thinking-2026-02-12")
+	ApplyClaudeBetaRules(header, cfg, "claude-opus-4-6")
+	if _, ok := header["Anthropic-Beta"]; ok {
+		t.Fatalf("Anthropic-Beta = %q, want header removed", header.Get("Anthropic-Beta"))
+	}
+}
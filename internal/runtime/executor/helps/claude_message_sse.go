@@ -0,0 +1,76 @@
+package helps
+
+import (
+	"bytes"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ClaudeMessageToSSE renders a non-streaming Claude Messages response as the SSE events a
+// streaming response would have carried, so stream-based response translators can convert it.
+func ClaudeMessageToSSE(message []byte) []byte {
+	var out bytes.Buffer
+	writeEvent := func(eventType string, payload []byte) {
+		out.WriteString("event: ")
+		out.WriteString(eventType)
+		out.WriteString("\ndata: ")
+		out.Write(payload)
+		out.WriteString("\n\n")
+	}
+
+	start, _ := sjson.SetRawBytes(message, "content", []byte("[]"))
+	start, _ = sjson.SetRawBytes(start, "stop_reason", []byte("null"))
+	start, _ = sjson.SetRawBytes(start, "stop_sequence", []byte("null"))
+	payload, _ := sjson.SetRawBytes([]byte(`{"type":"message_start"}`), "message", start)
+	writeEvent("message_start", payload)
+
+	for index, block := range gjson.GetBytes(message, "content").Array() {
+		startBlock := []byte(block.Raw)
+		var deltas [][]byte
+		switch block.Get("type").String() {
+		case "text":
+			startBlock = []byte(`{"type":"text","text":""}`)
+			delta, _ := sjson.SetBytes([]byte(`{"type":"text_delta"}`), "text", block.Get("text").String())
+			deltas = append(deltas, delta)
+		case "thinking":
+			startBlock = []byte(`{"type":"thinking","thinking":""}`)
+			delta, _ := sjson.SetBytes([]byte(`{"type":"thinking_delta"}`), "thinking", block.Get("thinking").String())
+			deltas = append(deltas, delta)
+			if signature := block.Get("signature"); signature.Exists() {
+				delta, _ = sjson.SetBytes([]byte(`{"type":"signature_delta"}`), "signature", signature.String())
+				deltas = append(deltas, delta)
+			}
+		case "tool_use", "server_tool_use":
+			startBlock, _ = sjson.SetRawBytes(startBlock, "input", []byte("{}"))
+			if input := block.Get("input"); input.Exists() {
+				delta, _ := sjson.SetBytes([]byte(`{"type":"input_json_delta"}`), "partial_json", input.Raw)
+				deltas = append(deltas, delta)
+			}
+		}
+		payload, _ = sjson.SetBytes([]byte(`{"type":"content_block_start"}`), "index", index)
+		payload, _ = sjson.SetRawBytes(payload, "content_block", startBlock)
+		writeEvent("content_block_start", payload)
+		for _, delta := range deltas {
+			payload, _ = sjson.SetBytes([]byte(`{"type":"content_block_delta"}`), "index", index)
+			payload, _ = sjson.SetRawBytes(payload, "delta", delta)
+			writeEvent("content_block_delta", payload)
+		}
+		payload, _ = sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", index)
+		writeEvent("content_block_stop", payload)
+	}
+
+	payload = []byte(`{"type":"message_delta","delta":{"stop_reason":null,"stop_sequence":null}}`)
+	if stopReason := gjson.GetBytes(message, "stop_reason"); stopReason.Exists() {
+		payload, _ = sjson.SetRawBytes(payload, "delta.stop_reason", []byte(stopReason.Raw))
+	}
+	if stopSequence := gjson.GetBytes(message, "stop_sequence"); stopSequence.Exists() {
+		payload, _ = sjson.SetRawBytes(payload, "delta.stop_sequence", []byte(stopSequence.Raw))
+	}
+	if usage := gjson.GetBytes(message, "usage"); usage.Exists() {
+		payload, _ = sjson.SetRawBytes(payload, "usage", []byte(usage.Raw))
+	}
+	writeEvent("message_delta", payload)
+	writeEvent("message_stop", []byte(`{"type":"message_stop"}`))
+	return out.Bytes()
+}
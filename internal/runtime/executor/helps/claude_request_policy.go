@@ -0,0 +1,266 @@
+package helps
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ApplyClaudeRequestPolicy applies the configured claude-request normalization to a
+// translated Claude Messages payload. from is the inbound source format and original is
+// the client payload before translation, used to detect fields the client explicitly provided.
+func ApplyClaudeRequestPolicy(cfg *config.Config, from string, original, body []byte) []byte {
+	if cfg == nil || len(body) == 0 {
+		return body
+	}
+	policy := cfg.ClaudeRequest
+	body = stripClaudeSystemThinkingBlocks(body)
+	body = applyClaudeDefaultToolChoice(body, original, policy.DefaultToolChoice)
+	if from != "claude" {
+		body = normalizeClaudeTemperatureRange(body, policy.TemperatureMode)
+	}
+	if !policy.KeepSamplingWithThinking {
+		body = stripClaudeSamplingForThinking(body)
+	}
+	body = capClaudeThinkingBudget(body, policy.ThinkingBudgetMaxRatio)
+	return body
+}
+
+// ApplyClaudeMaxTokensPolicy sets max_tokens according to claude-request.max-tokens for the
+// model and the client API key. original is the client payload before translation, used to
+// tell a client-chosen max_tokens from a translator default. A thinking budget that no longer
+// fits below the new max_tokens is lowered, or thinking is disabled when it cannot fit at all.
+func ApplyClaudeMaxTokensPolicy(cfg *config.Config, model, apiKey string, original, body []byte) []byte {
+	if cfg == nil || len(body) == 0 {
+		return body
+	}
+	mode, value := resolveMaxTokensPolicy(cfg.ClaudeRequest.MaxTokens, model, apiKey)
+	current := gjson.GetBytes(body, "max_tokens").Int()
+	clientSet := clientSpecifiedMaxTokens(original)
+	target := current
+	switch mode {
+	case "fixed":
+		target = int64(value)
+	case "clamp-to-model-max":
+		limit := claudeModelMaxTokens(model)
+		if !clientSet {
+			switch {
+			case value > 0:
+				target = int64(value)
+			case limit > 0:
+				target = limit
+			}
+		}
+		if limit > 0 && target > limit {
+			target = limit
+		}
+	default:
+		if !clientSet && value > 0 {
+			target = int64(value)
+		}
+	}
+	if target <= 0 || target == current {
+		return body
+	}
+	log.Debugf("claude request: max_tokens %d -> %d (policy %s, model %s)", current, target, mode, model)
+	body, _ = sjson.SetBytes(body, "max_tokens", target)
+	return fitClaudeThinkingBudget(body, target)
+}
+
+// resolveMaxTokensPolicy returns the mode and value of the first rule matching model and
+// apiKey, or the policy defaults.
+func resolveMaxTokensPolicy(policy config.MaxTokensPolicy, model, apiKey string) (string, int) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, rule := range policy.Rules {
+		if len(rule.Models) > 0 && !matchAnyModelPattern(rule.Models, model) {
+			continue
+		}
+		if len(rule.APIKeys) > 0 && !slices.Contains(rule.APIKeys, apiKey) {
+			continue
+		}
+		return rule.Mode, rule.Value
+	}
+	return policy.Mode, policy.Value
+}
+
+func matchAnyModelPattern(patterns []string, model string) bool {
+	for _, pattern := range patterns {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientSpecifiedMaxTokens reports whether the source payload carries an output token limit
+// in any of the supported inbound formats.
+func clientSpecifiedMaxTokens(original []byte) bool {
+	if len(original) == 0 {
+		return false
+	}
+	for _, path := range []string{"max_tokens", "max_completion_tokens", "max_output_tokens", "generationConfig.maxOutputTokens", "request.generationConfig.maxOutputTokens"} {
+		if gjson.GetBytes(original, path).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+// claudeModelMaxTokens returns the registry's output token maximum for a Claude model, or 0
+// when unknown.
+func claudeModelMaxTokens(model string) int64 {
+	if info := registry.GetGlobalRegistry().GetModelInfo(strings.TrimSpace(model), "claude"); info != nil {
+		return int64(info.MaxCompletionTokens)
+	}
+	return 0
+}
+
+// fitClaudeThinkingBudget keeps thinking.budget_tokens below max_tokens as Anthropic requires.
+func fitClaudeThinkingBudget(body []byte, maxTokens int64) []byte {
+	if gjson.GetBytes(body, "thinking.type").String() != "enabled" {
+		return body
+	}
+	budget := gjson.GetBytes(body, "thinking.budget_tokens").Int()
+	if budget < maxTokens {
+		return body
+	}
+	if maxTokens <= claudeMinThinkingBudget {
+		log.Debugf("claude request: max_tokens %d leaves no room for thinking; disabling it", maxTokens)
+		body, _ = sjson.DeleteBytes(body, "thinking")
+		return body
+	}
+	body, _ = sjson.SetBytes(body, "thinking.budget_tokens", maxTokens-1)
+	return body
+}
+
+// claudeMinThinkingBudget is the smallest budget_tokens value Anthropic accepts.
+const claudeMinThinkingBudget = 1024
+
+// capClaudeThinkingBudget limits thinking.budget_tokens to ratio * max_tokens so the
+// thinking phase cannot consume the whole output allowance. The cap never goes below
+// Anthropic's minimum budget.
+func capClaudeThinkingBudget(body []byte, ratio float64) []byte {
+	if ratio <= 0 || ratio >= 1 {
+		return body
+	}
+	if gjson.GetBytes(body, "thinking.type").String() != "enabled" {
+		return body
+	}
+	budget := gjson.GetBytes(body, "thinking.budget_tokens").Int()
+	maxTokens := gjson.GetBytes(body, "max_tokens").Int()
+	if budget <= 0 || maxTokens <= claudeMinThinkingBudget {
+		return body
+	}
+	limit := max(int64(float64(maxTokens)*ratio), claudeMinThinkingBudget)
+	if budget <= limit {
+		return body
+	}
+	log.Debugf("claude request: capping thinking budget %d to %d (max_tokens %d)", budget, limit, maxTokens)
+	body, _ = sjson.SetBytes(body, "thinking.budget_tokens", limit)
+	return body
+}
+
+// applyClaudeDefaultToolChoice sets tool_choice when tools are present and neither the
+// client nor the translator specified one.
+func applyClaudeDefaultToolChoice(body, original []byte, choice string) []byte {
+	if choice == "" {
+		return body
+	}
+	tools := gjson.GetBytes(body, "tools")
+	if !tools.IsArray() || len(tools.Array()) == 0 {
+		return body
+	}
+	if gjson.GetBytes(body, "tool_choice").Exists() || clientSpecifiedToolChoice(original) {
+		return body
+	}
+	body, _ = sjson.SetRawBytes(body, "tool_choice", []byte(`{"type":"`+choice+`"}`))
+	return body
+}
+
+// clientSpecifiedToolChoice reports whether the source payload carries a tool choice in
+// any of the supported inbound formats (OpenAI/Claude tool_choice, Gemini toolConfig).
+func clientSpecifiedToolChoice(original []byte) bool {
+	if len(original) == 0 {
+		return false
+	}
+	for _, path := range []string{"tool_choice", "toolConfig", "tool_config", "request.toolConfig"} {
+		if gjson.GetBytes(original, path).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeClaudeTemperatureRange maps a temperature expressed on a 0-2 scale onto
+// Anthropic's 0-1 range according to mode ("clamp" or "scale").
+func normalizeClaudeTemperatureRange(body []byte, mode string) []byte {
+	if mode != "clamp" && mode != "scale" {
+		return body
+	}
+	temp := gjson.GetBytes(body, "temperature")
+	if !temp.Exists() || temp.Type != gjson.Number {
+		return body
+	}
+	value := temp.Float()
+	normalized := value
+	switch mode {
+	case "clamp":
+		normalized = min(value, 1)
+	case "scale":
+		normalized = value / 2
+	}
+	normalized = max(normalized, 0)
+	if normalized == value {
+		return body
+	}
+	body, _ = sjson.SetBytes(body, "temperature", normalized)
+	return body
+}
+
+// stripClaudeSamplingForThinking removes top_p and top_k when thinking is active,
+// since Anthropic rejects nucleus/top-k sampling together with extended thinking.
+func stripClaudeSamplingForThinking(body []byte) []byte {
+	switch gjson.GetBytes(body, "thinking.type").String() {
+	case "enabled", "adaptive", "auto":
+	default:
+		return body
+	}
+	for _, path := range []string{"top_p", "top_k"} {
+		if gjson.GetBytes(body, path).Exists() {
+			body, _ = sjson.DeleteBytes(body, path)
+		}
+	}
+	return body
+}
+
+// stripClaudeSystemThinkingBlocks removes thinking/redacted_thinking blocks from the
+// system array. Thinking blocks are only valid inside assistant messages and Anthropic
+// rejects them in system, so misplaced blocks from passthrough clients are dropped.
+func stripClaudeSystemThinkingBlocks(body []byte) []byte {
+	system := gjson.GetBytes(body, "system")
+	if !system.IsArray() {
+		return body
+	}
+	kept := []byte(`[]`)
+	removed := 0
+	system.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "thinking", "redacted_thinking":
+			removed++
+		default:
+			kept, _ = sjson.SetRawBytes(kept, "-1", []byte(block.Raw))
+		}
+		return true
+	})
+	if removed == 0 {
+		return body
+	}
+	log.Warnf("claude request: removed %d thinking block(s) from system prompt", removed)
+	body, _ = sjson.SetRawBytes(body, "system", kept)
+	return body
+}
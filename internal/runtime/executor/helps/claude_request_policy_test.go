@@ -0,0 +1,199 @@
+package helps
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestApplyClaudeRequestPolicy_DefaultToolChoice(t *testing.T) {
+	body := []byte(`{"model":"claude-sonnet-4-5","tools":[{"name":"get_weather","input_schema":{"type":"object"}}],"messages":[]}`)
+	original := []byte(`{"model":"claude-sonnet-4-5","tools":[{"type":"function","function":{"name":"get_weather"}}]}`)
+
+	tests := []struct {
+		name     string
+		choice   string
+		expected string
+	}{
+		{name: "default auto", choice: "auto", expected: "auto"},
+		{name: "default none", choice: "none", expected: "none"},
+		{name: "unset", choice: "", expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{DefaultToolChoice: tt.choice}}
+			out := ApplyClaudeRequestPolicy(cfg, "openai", original, body)
+			if got := gjson.GetBytes(out, "tool_choice.type").String(); got != tt.expected {
+				t.Fatalf("tool_choice.type = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyClaudeRequestPolicy_DefaultToolChoiceRespectsClient(t *testing.T) {
+	cfg := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{DefaultToolChoice: "auto"}}
+	body := []byte(`{"tools":[{"name":"get_weather"}],"messages":[]}`)
+	// OpenAI "none" is dropped by the translator; the policy must not override it.
+	original := []byte(`{"tools":[{"type":"function","function":{"name":"get_weather"}}],"tool_choice":"none"}`)
+
+	out := ApplyClaudeRequestPolicy(cfg, "openai", original, body)
+	if gjson.GetBytes(out, "tool_choice").Exists() {
+		t.Fatalf("expected client tool_choice to be respected, got %s", out)
+	}
+
+	out = ApplyClaudeRequestPolicy(cfg, "openai", nil, []byte(`{"messages":[]}`))
+	if gjson.GetBytes(out, "tool_choice").Exists() {
+		t.Fatalf("expected no tool_choice without tools, got %s", out)
+	}
+}
+
+func TestApplyClaudeRequestPolicy_TemperatureMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		from     string
+		input    float64
+		expected float64
+	}{
+		{name: "scale out of range", mode: "scale", from: "openai", input: 1.5, expected: 0.75},
+		{name: "clamp out of range", mode: "clamp", from: "openai", input: 1.5, expected: 1},
+		{name: "clamp in range unchanged", mode: "clamp", from: "openai", input: 0.7, expected: 0.7},
+		{name: "passthrough", mode: "", from: "openai", input: 1.5, expected: 1.5},
+		{name: "claude source untouched", mode: "scale", from: "claude", input: 0.7, expected: 0.7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{TemperatureMode: tt.mode}}
+			body, _ := sjson.SetBytes([]byte(`{"messages":[]}`), "temperature", tt.input)
+			out := ApplyClaudeRequestPolicy(cfg, tt.from, nil, body)
+			if got := gjson.GetBytes(out, "temperature").Float(); got != tt.expected {
+				t.Fatalf("temperature = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyClaudeRequestPolicy_StripsSamplingWhenThinking(t *testing.T) {
+	body := []byte(`{"thinking":{"type":"enabled","budget_tokens":2048},"top_p":0.9,"top_k":40,"messages":[]}`)
+
+	out := ApplyClaudeRequestPolicy(&config.Config{}, "openai", nil, body)
+	if gjson.GetBytes(out, "top_p").Exists() || gjson.GetBytes(out, "top_k").Exists() {
+		t.Fatalf("expected top_p/top_k to be removed when thinking is enabled, got %s", out)
+	}
+
+	keep := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{KeepSamplingWithThinking: true}}
+	out = ApplyClaudeRequestPolicy(keep, "openai", nil, body)
+	if got := gjson.GetBytes(out, "top_p").Float(); got != 0.9 {
+		t.Fatalf("expected top_p to be kept, got %v", got)
+	}
+
+	disabled := []byte(`{"thinking":{"type":"disabled"},"top_p":0.9,"messages":[]}`)
+	out = ApplyClaudeRequestPolicy(&config.Config{}, "openai", nil, disabled)
+	if got := gjson.GetBytes(out, "top_p").Float(); got != 0.9 {
+		t.Fatalf("expected top_p to be kept without thinking, got %v", got)
+	}
+}
+
+func TestApplyClaudeRequestPolicy_StripsSystemThinkingBlocks(t *testing.T) {
+	body := []byte(`{"system":[{"type":"text","text":"You are helpful."},{"type":"thinking","thinking":"misplaced","signature":"sig"},{"type":"redacted_thinking","data":"abc"}],"messages":[]}`)
+
+	out := ApplyClaudeRequestPolicy(&config.Config{}, "claude", nil, body)
+	system := gjson.GetBytes(out, "system").Array()
+	if len(system) != 1 {
+		t.Fatalf("expected 1 system block after stripping, got %d: %s", len(system), out)
+	}
+	if got := system[0].Get("text").String(); got != "You are helpful." {
+		t.Fatalf("unexpected remaining system block: %s", system[0].Raw)
+	}
+}
+
+func TestApplyClaudeRequestPolicy_CapsThinkingBudget(t *testing.T) {
+	cfg := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{ThinkingBudgetMaxRatio: 0.5}}
+
+	body := []byte(`{"max_tokens":64000,"thinking":{"type":"enabled","budget_tokens":63999}}`)
+	out := ApplyClaudeRequestPolicy(cfg, "openai", nil, body)
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 32000 {
+		t.Fatalf("budget_tokens = %d, want 32000", got)
+	}
+
+	body = []byte(`{"max_tokens":64000,"thinking":{"type":"enabled","budget_tokens":8192}}`)
+	out = ApplyClaudeRequestPolicy(cfg, "openai", nil, body)
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 8192 {
+		t.Fatalf("budget below the cap should be unchanged, got %d", got)
+	}
+
+	body = []byte(`{"max_tokens":1500,"thinking":{"type":"enabled","budget_tokens":1400}}`)
+	out = ApplyClaudeRequestPolicy(cfg, "openai", nil, body)
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 1024 {
+		t.Fatalf("cap should not go below the minimum budget, got %d", got)
+	}
+
+	body = []byte(`{"max_tokens":64000,"thinking":{"type":"enabled","budget_tokens":63999}}`)
+	out = ApplyClaudeRequestPolicy(&config.Config{}, "openai", nil, body)
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 63999 {
+		t.Fatalf("budget should be unchanged without a ratio, got %d", got)
+	}
+}
+
+func TestApplyClaudeMaxTokensPolicy(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	clientID := "test-claude-max-tokens-policy-client"
+	modelID := "test-claude-max-tokens-policy-model"
+	reg.RegisterClient(clientID, "claude", []*registry.ModelInfo{{
+		ID:                  modelID,
+		Type:                "claude",
+		OwnedBy:             "anthropic",
+		Object:              "model",
+		MaxCompletionTokens: 8192,
+		UserDefined:         true,
+	}})
+	defer reg.UnregisterClient(clientID)
+
+	policy := config.MaxTokensPolicy{
+		Mode: "clamp-to-model-max",
+		Rules: []config.MaxTokensRule{
+			{APIKeys: []string{"cursor-key"}, Mode: "fixed", Value: 64000},
+			{Models: []string{"other-*"}, Mode: "honor-client"},
+		},
+	}
+	cfg := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{MaxTokens: policy}}
+	tests := []struct {
+		name       string
+		apiKey     string
+		original   string
+		translated int64
+		expected   int64
+	}{
+		{name: "client value clamped", original: `{"max_tokens":20000}`, translated: 20000, expected: 8192},
+		{name: "client value below max kept", original: `{"max_completion_tokens":1000}`, translated: 1000, expected: 1000},
+		{name: "translator default replaced by model max", original: `{}`, translated: 32000, expected: 8192},
+		{name: "api key rule", apiKey: "cursor-key", original: `{"max_tokens":100}`, translated: 100, expected: 64000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := sjson.SetBytes([]byte(`{"model":"`+modelID+`","messages":[]}`), "max_tokens", tt.translated)
+			out := ApplyClaudeMaxTokensPolicy(cfg, modelID, tt.apiKey, []byte(tt.original), body)
+			if got := gjson.GetBytes(out, "max_tokens").Int(); got != tt.expected {
+				t.Fatalf("max_tokens = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyClaudeMaxTokensPolicy_FitsThinkingBudget(t *testing.T) {
+	cfg := &config.Config{ClaudeRequest: config.ClaudeRequestConfig{MaxTokens: config.MaxTokensPolicy{Mode: "fixed", Value: 4000}}}
+	body := []byte(`{"max_tokens":32000,"thinking":{"type":"enabled","budget_tokens":16000},"messages":[]}`)
+	out := ApplyClaudeMaxTokensPolicy(cfg, "claude-sonnet-4-5", "", nil, body)
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 3999 {
+		t.Fatalf("budget_tokens = %d, want 3999", got)
+	}
+
+	cfg.ClaudeRequest.MaxTokens.Value = 1000
+	out = ApplyClaudeMaxTokensPolicy(cfg, "claude-sonnet-4-5", "", nil, body)
+	if gjson.GetBytes(out, "thinking").Exists() {
+		t.Fatalf("thinking kept with max_tokens 1000: %s", out)
+	}
+}
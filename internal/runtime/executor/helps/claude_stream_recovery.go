@@ -0,0 +1,193 @@
+package helps
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ClaudeStreamRecovery follows a Claude Messages SSE stream so that, when the upstream dies
+// mid-response, the request can be resumed with the text generated so far as an assistant
+// prefill and the continuation stitched into the same client stream.
+//
+// Lines are fed through Process, which returns what to forward downstream. Only responses made
+// of text blocks can be resumed: thinking and tool_use blocks cannot be prefilled.
+type ClaudeStreamRecovery struct {
+	text         strings.Builder
+	started      bool
+	completed    bool
+	unresumable  bool
+	nextIndex    int64
+	openIndex    int64
+	pendingEvent []byte
+	stashed      [][]byte
+
+	// Continuation state, set by Continuation.
+	continuing bool
+	resumeOpen bool
+	offset     int64
+	trimLead   bool
+}
+
+// NewClaudeStreamRecovery returns a tracker for a new stream.
+func NewClaudeStreamRecovery() *ClaudeStreamRecovery {
+	return &ClaudeStreamRecovery{openIndex: -1}
+}
+
+// Process records one upstream SSE line and returns the lines to forward in its place. While a
+// continuation is streamed its message_start is dropped and its content blocks are renumbered to
+// follow the blocks already sent. Error events are held back until Flush, as a resumed stream
+// replaces them.
+func (r *ClaudeStreamRecovery) Process(line []byte) [][]byte {
+	trimmed := bytes.TrimSpace(line)
+	if bytes.HasPrefix(trimmed, []byte("event:")) {
+		r.pendingEvent = bytes.Clone(line)
+		return nil
+	}
+	payload := jsonPayload(line)
+	if payload == nil {
+		return r.withPendingEvent(line)
+	}
+	eventType := gjson.GetBytes(payload, "type").String()
+	if eventType == "error" {
+		r.stashed = append(r.stashed, r.withPendingEvent(bytes.Clone(line))...)
+		return nil
+	}
+	if r.continuing {
+		var keep bool
+		if payload, keep = r.rewriteContinuation(eventType, payload); !keep {
+			r.pendingEvent = nil
+			return nil
+		}
+		line = append([]byte("data: "), payload...)
+	}
+	r.observe(eventType, payload)
+	return r.withPendingEvent(line)
+}
+
+func (r *ClaudeStreamRecovery) withPendingEvent(line []byte) [][]byte {
+	if r.pendingEvent == nil {
+		return [][]byte{line}
+	}
+	lines := [][]byte{r.pendingEvent, line}
+	r.pendingEvent = nil
+	return lines
+}
+
+// rewriteContinuation maps a continuation event onto the client stream; keep is false for
+// events the client already received from the interrupted attempt.
+func (r *ClaudeStreamRecovery) rewriteContinuation(eventType string, payload []byte) ([]byte, bool) {
+	switch eventType {
+	case "message_start":
+		return payload, false
+	case "content_block_start", "content_block_delta", "content_block_stop":
+	default:
+		return payload, true
+	}
+	index := gjson.GetBytes(payload, "index").Int()
+	if eventType == "content_block_start" && index == 0 && r.resumeOpen {
+		return payload, false
+	}
+	if eventType == "content_block_delta" && r.trimLead && gjson.GetBytes(payload, "delta.type").String() == "text_delta" {
+		text := strings.TrimLeftFunc(gjson.GetBytes(payload, "delta.text").String(), unicode.IsSpace)
+		if text == "" {
+			return payload, false
+		}
+		r.trimLead = false
+		payload, _ = sjson.SetBytes(payload, "delta.text", text)
+	}
+	payload, _ = sjson.SetBytes(payload, "index", index+r.offset)
+	return payload, true
+}
+
+func (r *ClaudeStreamRecovery) observe(eventType string, payload []byte) {
+	switch eventType {
+	case "message_start":
+		r.started = true
+	case "content_block_start":
+		if gjson.GetBytes(payload, "content_block.type").String() != "text" {
+			r.unresumable = true
+		}
+		r.openIndex = gjson.GetBytes(payload, "index").Int()
+		r.nextIndex = r.openIndex + 1
+	case "content_block_delta":
+		if gjson.GetBytes(payload, "delta.type").String() == "text_delta" {
+			r.text.WriteString(gjson.GetBytes(payload, "delta.text").String())
+		}
+	case "content_block_stop":
+		r.openIndex = -1
+	case "message_delta":
+		if gjson.GetBytes(payload, "delta.stop_reason").String() != "" {
+			r.completed = true
+		}
+	case "message_stop":
+		r.completed = true
+	}
+}
+
+// Continuation returns the request resuming the interrupted response of body, the Claude
+// Messages request sent upstream, and prepares the stitching of its stream. ok is false when the
+// response completed, never started or cannot be prefilled.
+func (r *ClaudeStreamRecovery) Continuation(body []byte) ([]byte, bool) {
+	if !r.started || r.completed || r.unresumable {
+		return nil, false
+	}
+	switch gjson.GetBytes(body, "thinking.type").String() {
+	case "enabled", "adaptive":
+		return nil, false
+	}
+	generated := r.text.String()
+	// The API rejects a final assistant turn ending with whitespace; the continuation then
+	// usually starts with it, so it is trimmed there instead.
+	prefill := strings.TrimRightFunc(generated, unicode.IsSpace)
+	next := body
+	if prefill != "" {
+		next = appendClaudePrefill(body, prefill)
+	}
+	r.continuing = true
+	r.trimLead = len(prefill) < len(generated)
+	r.resumeOpen = r.openIndex >= 0
+	if r.resumeOpen {
+		r.offset = r.openIndex
+	} else {
+		r.offset = r.nextIndex
+	}
+	r.pendingEvent = nil
+	r.stashed = nil
+	return next, true
+}
+
+// appendClaudePrefill adds text to the final assistant message of body, adding the message
+// when the conversation ends with a user turn.
+func appendClaudePrefill(body []byte, text string) []byte {
+	block := map[string]any{"type": "text", "text": text}
+	messages := gjson.GetBytes(body, "messages").Array()
+	if n := len(messages); n > 0 && messages[n-1].Get("role").String() == "assistant" {
+		path := fmt.Sprintf("messages.%d.content", n-1)
+		content := messages[n-1].Get("content")
+		if content.Type == gjson.String {
+			body, _ = sjson.SetBytes(body, path, []any{map[string]any{"type": "text", "text": content.String()}})
+		}
+		body, _ = sjson.SetBytes(body, path+".-1", block)
+		return body
+	}
+	body, _ = sjson.SetBytes(body, "messages.-1", map[string]any{"role": "assistant", "content": []any{block}})
+	return body
+}
+
+// Flush returns the error events held back during the last attempt, to be forwarded when the
+// stream is not resumed.
+func (r *ClaudeStreamRecovery) Flush() [][]byte {
+	stashed := r.stashed
+	r.stashed = nil
+	return stashed
+}
+
+// GeneratedLength returns the number of bytes of text received so far.
+func (r *ClaudeStreamRecovery) GeneratedLength() int {
+	return r.text.Len()
+}
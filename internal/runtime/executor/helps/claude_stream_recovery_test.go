@@ -0,0 +1,53 @@
+package helps
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func feedClaudeStream(r *ClaudeStreamRecovery, lines ...string) {
+	for _, line := range lines {
+		r.Process([]byte(line))
+	}
+}
+
+func TestClaudeStreamRecoveryContinuationMergesAssistantPrefill(t *testing.T) {
+	r := NewClaudeStreamRecovery()
+	feedClaudeStream(r,
+		`data: {"type":"message_start","message":{"id":"msg_1"}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"one two"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+	)
+	body := []byte(`{"messages":[{"role":"user","content":"count"},{"role":"assistant","content":"zero"}]}`)
+	next, ok := r.Continuation(body)
+	if !ok {
+		t.Fatalf("expected the stream to be resumable")
+	}
+	if got := gjson.GetBytes(next, "messages.1.content.#.text").Raw; got != `["zero","one two"]` {
+		t.Fatalf("assistant content = %s", got)
+	}
+
+	lines := r.Process([]byte(`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`))
+	if len(lines) != 1 || gjson.GetBytes(jsonPayload(lines[0]), "index").Int() != 1 {
+		t.Fatalf("expected the continuation block renumbered after the closed one, got %q", lines)
+	}
+}
+
+func TestClaudeStreamRecoverySkipsToolUseAndErrors(t *testing.T) {
+	r := NewClaudeStreamRecovery()
+	feedClaudeStream(r,
+		`data: {"type":"message_start","message":{"id":"msg_1"}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"t1","name":"f","input":{}}}`,
+	)
+	if lines := r.Process([]byte(`data: {"type":"error","error":{"type":"overloaded_error"}}`)); lines != nil {
+		t.Fatalf("expected the error event to be held back, got %q", lines)
+	}
+	if _, ok := r.Continuation([]byte(`{"messages":[]}`)); ok {
+		t.Fatalf("tool_use responses must not be resumed")
+	}
+	if stashed := r.Flush(); len(stashed) != 1 {
+		t.Fatalf("expected the held error event on flush, got %q", stashed)
+	}
+}
@@ -55,7 +55,7 @@ type upstreamAttempt struct {
 
 // RecordAPIRequest stores the upstream request metadata in Gin context for request logging.
 func RecordAPIRequest(ctx context.Context, cfg *config.Config, info UpstreamRequestLog) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordingEnabled(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -102,7 +102,7 @@ func RecordAPIRequest(ctx context.Context, cfg *config.Config, info UpstreamRequ
 
 // RecordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
 func RecordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordingEnabled(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -128,7 +128,7 @@ func RecordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status i
 
 // RecordAPIResponseError adds an error entry for the latest attempt when no HTTP response is available.
 func RecordAPIResponseError(ctx context.Context, cfg *config.Config, err error) {
-	if cfg == nil || !cfg.RequestLog || err == nil {
+	if !recordingEnabled(ctx, cfg) || err == nil {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -153,7 +153,7 @@ func RecordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 
 // AppendAPIResponseChunk appends an upstream response chunk to Gin context for request logging.
 func AppendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byte) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordingEnabled(ctx, cfg) {
 		return
 	}
 	data := bytes.TrimSpace(chunk)
@@ -195,7 +195,7 @@ func AppendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 
 // RecordAPIWebsocketRequest stores an upstream websocket request event in Gin context.
 func RecordAPIWebsocketRequest(ctx context.Context, cfg *config.Config, info UpstreamRequestLog) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordingEnabled(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -227,7 +227,7 @@ func RecordAPIWebsocketRequest(ctx context.Context, cfg *config.Config, info Ups
 
 // RecordAPIWebsocketHandshake stores the upstream websocket handshake response metadata.
 func RecordAPIWebsocketHandshake(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordingEnabled(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -250,7 +250,7 @@ func RecordAPIWebsocketHandshake(ctx context.Context, cfg *config.Config, status
 
 // RecordAPIWebsocketUpgradeRejection stores a rejected websocket upgrade as an HTTP attempt.
 func RecordAPIWebsocketUpgradeRejection(ctx context.Context, cfg *config.Config, info UpstreamRequestLog, status int, headers http.Header, body []byte) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordingEnabled(ctx, cfg) {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -284,7 +284,7 @@ func WebsocketUpgradeRequestURL(rawURL string) string {
 
 // AppendAPIWebsocketResponse stores an upstream websocket response frame in Gin context.
 func AppendAPIWebsocketResponse(ctx context.Context, cfg *config.Config, payload []byte) {
-	if cfg == nil || !cfg.RequestLog {
+	if !recordingEnabled(ctx, cfg) {
 		return
 	}
 	data := bytes.TrimSpace(payload)
@@ -308,7 +308,7 @@ func AppendAPIWebsocketResponse(ctx context.Context, cfg *config.Config, payload
 
 // RecordAPIWebsocketError stores an upstream websocket error event in Gin context.
 func RecordAPIWebsocketError(ctx context.Context, cfg *config.Config, stage string, err error) {
-	if cfg == nil || !cfg.RequestLog || err == nil {
+	if !recordingEnabled(ctx, cfg) || err == nil {
 		return
 	}
 	ginCtx := ginContextFrom(ctx)
@@ -328,6 +328,16 @@ func RecordAPIWebsocketError(ctx context.Context, cfg *config.Config, stage stri
 	appendAPIWebsocketTimeline(ginCtx, []byte(builder.String()))
 }
 
+// recordingEnabled reports whether upstream traffic is captured: when request logging is enabled
+// or the request is replayed through the management API.
+func recordingEnabled(ctx context.Context, cfg *config.Config) bool {
+	if cfg != nil && cfg.RequestLog {
+		return true
+	}
+	ginCtx := ginContextFrom(ctx)
+	return ginCtx != nil && ginCtx.Request != nil && logging.ReplayCaptureFrom(ginCtx.Request.Context()) != nil
+}
+
 func ginContextFrom(ctx context.Context) *gin.Context {
 	ginCtx, _ := ctx.Value("gin").(*gin.Context)
 	return ginCtx
@@ -379,6 +389,9 @@ func updateAggregatedRequest(ginCtx *gin.Context, attempts []*upstreamAttempt) {
 		builder.WriteString(attempt.request)
 	}
 	ginCtx.Set(apiRequestKey, []byte(builder.String()))
+	if capture := replayCaptureFrom(ginCtx); capture != nil {
+		capture.SetUpstreamRequest([]byte(builder.String()))
+	}
 }
 
 func updateAggregatedResponse(ginCtx *gin.Context, attempts []*upstreamAttempt) {
@@ -403,6 +416,16 @@ func updateAggregatedResponse(ginCtx *gin.Context, attempts []*upstreamAttempt)
 		}
 	}
 	ginCtx.Set(apiResponseKey, []byte(builder.String()))
+	if capture := replayCaptureFrom(ginCtx); capture != nil {
+		capture.SetUpstreamResponse([]byte(builder.String()))
+	}
+}
+
+func replayCaptureFrom(ginCtx *gin.Context) *logging.ReplayCapture {
+	if ginCtx.Request == nil {
+		return nil
+	}
+	return logging.ReplayCaptureFrom(ginCtx.Request.Context())
 }
 
 func appendAPIWebsocketTimeline(ginCtx *gin.Context, chunk []byte) {
@@ -0,0 +1,75 @@
+package helps
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+// PromptCacheSettings is the prompt cache configuration resolved for a single model.
+type PromptCacheSettings struct {
+	Strategy         string
+	Breakpoints      int
+	MinContentLength int
+	TTL              string
+}
+
+// ResolvePromptCacheSettings returns the prompt cache settings for model, applying the
+// first matching per-model override and a "(cache:5m|1h)" model suffix.
+func ResolvePromptCacheSettings(cfg *config.Config, model string) PromptCacheSettings {
+	suffix := thinking.ParseSuffix(model)
+	baseModel := suffix.ModelName
+	var settings PromptCacheSettings
+	if cfg != nil {
+		pc := cfg.PromptCache
+		settings = PromptCacheSettings{
+			Strategy:         pc.Strategy,
+			Breakpoints:      pc.Breakpoints,
+			MinContentLength: pc.MinContentLength,
+			TTL:              pc.TTL,
+		}
+		for _, rule := range pc.Models {
+			if matchModelPattern(rule.Name, baseModel) {
+				settings.Strategy = rule.Strategy
+				if rule.TTL != "" {
+					settings.TTL = rule.TTL
+				}
+				break
+			}
+		}
+	}
+	if suffix.HasSuffix {
+		if ttl, ok := ParsePromptCacheSuffix(suffix.RawSuffix); ok {
+			settings.TTL = ttl
+		}
+	}
+	return settings
+}
+
+// ParsePromptCacheSuffix interprets a model suffix of the form "cache:5m" or "cache:1h".
+func ParsePromptCacheSuffix(rawSuffix string) (string, bool) {
+	value, ok := strings.CutPrefix(strings.ToLower(strings.TrimSpace(rawSuffix)), "cache:")
+	if !ok {
+		return "", false
+	}
+	switch value {
+	case "5m", "1h":
+		return value, true
+	default:
+		return "", false
+	}
+}
+
+// StripPromptCacheSuffix removes a "(cache:...)" suffix from model so the remaining
+// pipeline (e.g. thinking suffix parsing) does not interpret it.
+func StripPromptCacheSuffix(model string) string {
+	suffix := thinking.ParseSuffix(model)
+	if !suffix.HasSuffix {
+		return model
+	}
+	if _, ok := ParsePromptCacheSuffix(suffix.RawSuffix); ok {
+		return suffix.ModelName
+	}
+	return model
+}
@@ -0,0 +1,17 @@
+package helps
+
+import "testing"
+
+func TestStripPromptCacheSuffix(t *testing.T) {
+	tests := map[string]string{
+		"claude-sonnet-4-5(cache:1h)": "claude-sonnet-4-5",
+		"claude-sonnet-4-5(cache:5m)": "claude-sonnet-4-5",
+		"claude-sonnet-4-5(16384)":    "claude-sonnet-4-5(16384)",
+		"claude-sonnet-4-5":           "claude-sonnet-4-5",
+	}
+	for model, want := range tests {
+		if got := StripPromptCacheSuffix(model); got != want {
+			t.Errorf("StripPromptCacheSuffix(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
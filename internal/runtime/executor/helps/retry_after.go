@@ -0,0 +1,53 @@
+package helps
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// anthropicRateLimitKinds lists the anthropic-ratelimit-<kind>-remaining/-reset header pairs.
+var anthropicRateLimitKinds = [...]string{"requests", "tokens", "input-tokens", "output-tokens"}
+
+// ParseRetryAfter derives the wait an upstream asked for from response headers. Retry-After
+// (delta seconds or HTTP date) wins; otherwise the latest anthropic-ratelimit-*-reset of an
+// exhausted limit is used. It returns nil when no positive wait is advertised.
+func ParseRetryAfter(header http.Header, now time.Time) *time.Duration {
+	if header == nil {
+		return nil
+	}
+	if raw := strings.TrimSpace(header.Get("Retry-After")); raw != "" {
+		if seconds, errParse := strconv.ParseFloat(raw, 64); errParse == nil {
+			return positiveDuration(time.Duration(seconds * float64(time.Second)))
+		}
+		if at, errParse := http.ParseTime(raw); errParse == nil {
+			return positiveDuration(at.Sub(now))
+		}
+	}
+	var latest time.Time
+	for _, kind := range anthropicRateLimitKinds {
+		prefix := "Anthropic-Ratelimit-" + kind
+		if strings.TrimSpace(header.Get(prefix+"-Remaining")) != "0" {
+			continue
+		}
+		at, errParse := time.Parse(time.RFC3339, strings.TrimSpace(header.Get(prefix+"-Reset")))
+		if errParse != nil {
+			continue
+		}
+		if at.After(latest) {
+			latest = at
+		}
+	}
+	if latest.IsZero() {
+		return nil
+	}
+	return positiveDuration(latest.Sub(now))
+}
+
+func positiveDuration(d time.Duration) *time.Duration {
+	if d <= 0 {
+		return nil
+	}
+	return &d
+}
@@ -0,0 +1,45 @@
+package helps
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"seconds", http.Header{"Retry-After": {"7"}}, 7 * time.Second},
+		{"http date", http.Header{"Retry-After": {now.Add(30 * time.Second).Format(http.TimeFormat)}}, 30 * time.Second},
+		{"anthropic exhausted limit", http.Header{
+			"Anthropic-Ratelimit-Requests-Remaining": {"5"},
+			"Anthropic-Ratelimit-Requests-Reset":     {now.Add(time.Hour).Format(time.RFC3339)},
+			"Anthropic-Ratelimit-Tokens-Remaining":   {"0"},
+			"Anthropic-Ratelimit-Tokens-Reset":       {now.Add(12 * time.Second).Format(time.RFC3339)},
+		}, 12 * time.Second},
+		{"retry-after wins", http.Header{
+			"Retry-After":                          {"3"},
+			"Anthropic-Ratelimit-Tokens-Remaining": {"0"},
+			"Anthropic-Ratelimit-Tokens-Reset":     {now.Add(time.Minute).Format(time.RFC3339)},
+		}, 3 * time.Second},
+		{"none", http.Header{"Anthropic-Ratelimit-Tokens-Remaining": {"10"}}, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseRetryAfter(tc.header, now)
+			if tc.want == 0 {
+				if got != nil {
+					t.Fatalf("ParseRetryAfter() = %v, want nil", *got)
+				}
+				return
+			}
+			if got == nil || *got != tc.want {
+				t.Fatalf("ParseRetryAfter() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package helps
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// sessionUserIDNamespace seeds the name-based UUIDs of derived user IDs.
+var sessionUserIDNamespace = uuid.NewSHA1(uuid.NameSpaceOID, []byte("cliproxyapi/claude-user-id"))
+
+// SessionUserID derives a stable Claude Code user ID from a client API key and session ID.
+// The user and account parts depend only on the API key, the session part on both, so each
+// client keeps one identity upstream and each of its conversations gets its own session.
+func SessionUserID(apiKey, sessionID string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	account := uuid.NewSHA1(sessionUserIDNamespace, []byte("account\x00"+apiKey))
+	session := uuid.NewSHA1(sessionUserIDNamespace, []byte("session\x00"+apiKey+"\x00"+sessionID))
+	return "user_" + hex.EncodeToString(sum[:]) + "_account_" + account.String() + "_session_" + session.String()
+}
+
+// ClientSessionUserID derives the user ID of the client behind ctx from its API key and its
+// session header, if any. Unauthenticated clients share the empty key.
+func ClientSessionUserID(ctx context.Context) string {
+	return SessionUserID(APIKeyFromContext(ctx), clientSessionID(ctx))
+}
+
+func clientSessionID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx := ginContextFrom(ctx)
+	if ginCtx == nil || ginCtx.Request == nil {
+		return ""
+	}
+	for _, header := range util.SessionHeaders {
+		if value := strings.TrimSpace(ginCtx.Request.Header.Get(header)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package helps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionUserID_StablePerKeyAndSession(t *testing.T) {
+	first := SessionUserID("key-a", "session-1")
+	if !IsValidUserID(first) {
+		t.Fatalf("user_id %q is not valid", first)
+	}
+	if again := SessionUserID("key-a", "session-1"); again != first {
+		t.Fatalf("expected stable user_id, got %q and %q", first, again)
+	}
+
+	otherSession := SessionUserID("key-a", "session-2")
+	if otherSession == first {
+		t.Fatal("expected sessions to get distinct user_ids")
+	}
+	prefix := first[:strings.Index(first, "_session_")]
+	if !strings.HasPrefix(otherSession, prefix+"_session_") {
+		t.Fatalf("expected sessions of one key to share user and account, got %q and %q", first, otherSession)
+	}
+
+	otherKey := SessionUserID("key-b", "session-1")
+	if strings.HasPrefix(otherKey, prefix) {
+		t.Fatalf("expected keys to get distinct identities, got %q and %q", first, otherKey)
+	}
+}
+
+func TestClientSessionUserID_UsesKeyAndSessionHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ginCtx.Request.Header.Set("X-Session-ID", " conv-42 ")
+	ginCtx.Set("apiKey", "client-key")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	if got, want := ClientSessionUserID(ctx), SessionUserID("client-key", "conv-42"); got != want {
+		t.Fatalf("ClientSessionUserID() = %q, want %q", got, want)
+	}
+	if got, want := ClientSessionUserID(context.Background()), SessionUserID("", ""); got != want {
+		t.Fatalf("ClientSessionUserID() without gin context = %q, want %q", got, want)
+	}
+}
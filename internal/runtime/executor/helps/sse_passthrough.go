@@ -0,0 +1,73 @@
+package helps
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+const (
+	sseBlockSize   = 32 * 1024
+	sseMaxLineSize = 52_428_800 // 50MB, the limit of the line scanners
+)
+
+// PipeSSE forwards a same-format SSE stream without parsing it. The body is read in blocks and
+// each block is handed to emit up to its last complete line, so every emitted chunk holds whole
+// lines with their newlines and several lines travel in one chunk. tap sees every line without
+// its line ending, for request logging and usage extraction; it must not retain the line.
+//
+// emit owns the chunks it receives. A final line without a newline gets one, matching the
+// per-line forwarding of the translating paths. PipeSSE returns nil at EOF.
+func PipeSSE(body io.Reader, tap func(line []byte), emit func(chunk []byte)) error {
+	buf := make([]byte, sseBlockSize)
+	pending := 0
+	for {
+		if pending == len(buf) {
+			if len(buf) >= sseMaxLineSize {
+				return bufio.ErrTooLong
+			}
+			grown := make([]byte, min(2*len(buf), sseMaxLineSize))
+			copy(grown, buf[:pending])
+			buf = grown
+		}
+		n, errRead := body.Read(buf[pending:])
+		data := buf[:pending+n]
+		pending = len(data)
+		if last := bytes.LastIndexByte(data, '\n'); last >= 0 {
+			complete := data[:last+1]
+			tapSSELines(complete, tap)
+			emit(bytes.Clone(complete))
+			pending = copy(buf, data[last+1:])
+		}
+		if errRead == nil {
+			continue
+		}
+		if !errors.Is(errRead, io.EOF) {
+			return errRead
+		}
+		if pending > 0 {
+			rest := make([]byte, pending+1)
+			copy(rest, buf[:pending])
+			rest[pending] = '\n'
+			tapSSELines(rest, tap)
+			emit(rest)
+		}
+		return nil
+	}
+}
+
+// tapSSELines calls tap for every newline-terminated line of block.
+func tapSSELines(block []byte, tap func(line []byte)) {
+	if tap == nil {
+		return
+	}
+	for len(block) > 0 {
+		end := bytes.IndexByte(block, '\n')
+		if end < 0 {
+			return
+		}
+		tap(bytes.TrimSuffix(block[:end], []byte("\r")))
+		block = block[end+1:]
+	}
+}
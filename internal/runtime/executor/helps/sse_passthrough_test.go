@@ -0,0 +1,88 @@
+package helps
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPipeSSEForwardsWholeLines(t *testing.T) {
+	upstream := "event: message_start\r\n" +
+		"data: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":3}}}\n" +
+		"\n" +
+		"data: {\"type\":\"message_stop\"}"
+
+	for name, body := range map[string]io.Reader{
+		"one read":   strings.NewReader(upstream),
+		"byte reads": iotest.OneByteReader(strings.NewReader(upstream)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var lines []string
+			var forwarded bytes.Buffer
+			err := PipeSSE(body, func(line []byte) {
+				lines = append(lines, string(line))
+			}, func(chunk []byte) {
+				if len(chunk) == 0 || chunk[len(chunk)-1] != '\n' {
+					t.Fatalf("chunk %q does not end with a complete line", chunk)
+				}
+				forwarded.Write(chunk)
+			})
+			if err != nil {
+				t.Fatalf("PipeSSE error: %v", err)
+			}
+			if want := upstream + "\n"; forwarded.String() != want {
+				t.Fatalf("forwarded %q, want %q", forwarded.String(), want)
+			}
+			want := []string{
+				"event: message_start",
+				`data: {"type":"message_start","message":{"usage":{"input_tokens":3}}}`,
+				"",
+				`data: {"type":"message_stop"}`,
+			}
+			if strings.Join(lines, "|") != strings.Join(want, "|") {
+				t.Fatalf("tapped lines %q, want %q", lines, want)
+			}
+		})
+	}
+}
+
+func TestPipeSSEGrowsForLongLines(t *testing.T) {
+	line := "data: " + strings.Repeat("x", 3*sseBlockSize) + "\n"
+	var forwarded bytes.Buffer
+	if err := PipeSSE(strings.NewReader(line), nil, func(chunk []byte) { forwarded.Write(chunk) }); err != nil {
+		t.Fatalf("PipeSSE error: %v", err)
+	}
+	if forwarded.String() != line {
+		t.Fatalf("forwarded %d bytes, want %d", forwarded.Len(), len(line))
+	}
+}
+
+func TestPipeSSEReturnsReadErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	body := io.MultiReader(strings.NewReader("data: {}\ndata: partial"), iotest.ErrReader(errBoom))
+	var forwarded bytes.Buffer
+	err := PipeSSE(body, nil, func(chunk []byte) { forwarded.Write(chunk) })
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected read error, got %v", err)
+	}
+	if forwarded.String() != "data: {}\n" {
+		t.Fatalf("expected only complete lines before the error, got %q", forwarded.String())
+	}
+}
+
+func TestStreamUsageParsersSkipLinesWithoutUsage(t *testing.T) {
+	if _, ok := ParseOpenAIStreamUsage([]byte(`data: {"choices":[{"delta":{"content":"hi"}}]}`)); ok {
+		t.Fatal("expected no usage for a content delta")
+	}
+	detail, ok := ParseOpenAIStreamUsage([]byte(`data: {"choices":[],"usage":{"prompt_tokens":2,"completion_tokens":3,"total_tokens":5}}`))
+	if !ok || detail.TotalTokens != 5 {
+		t.Fatalf("expected usage with 5 total tokens, got %+v (ok=%v)", detail, ok)
+	}
+	var claudeUsage ClaudeStreamUsage
+	if _, ok := claudeUsage.Parse([]byte(`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"hi"}}`)); ok {
+		t.Fatal("expected no usage for a Claude text delta")
+	}
+}
@@ -0,0 +1,116 @@
+package helps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+var (
+	// ErrAssemblyIdleTimeout reports an assembled stream that went silent for too long.
+	ErrAssemblyIdleTimeout = errors.New("upstream stream idle timeout")
+	// ErrAssemblyMaxDuration reports an assembled stream that ran for too long.
+	ErrAssemblyMaxDuration = errors.New("upstream stream exceeded its maximum duration")
+	// ErrAssemblyTooLarge reports an upstream response beyond the configured size cap.
+	ErrAssemblyTooLarge = errors.New("upstream response exceeds the maximum size")
+)
+
+// StreamAssemblyLimits guards reading an upstream response that is assembled into one
+// non-streaming reply. Zero values disable the corresponding limit.
+type StreamAssemblyLimits struct {
+	IdleTimeout      time.Duration
+	MaxDuration      time.Duration
+	MaxBytes         int64
+	ProgressInterval time.Duration
+}
+
+// ClaudeNonStreamLimits returns the claude-request.non-stream limits. The watchdog timeouts
+// and progress logging only apply when the upstream response is streamed.
+func ClaudeNonStreamLimits(cfg *config.Config, streamed bool) StreamAssemblyLimits {
+	if cfg == nil {
+		return StreamAssemblyLimits{}
+	}
+	settings := cfg.ClaudeRequest.NonStream
+	limits := StreamAssemblyLimits{MaxBytes: settings.MaxResponseBytes}
+	if streamed {
+		limits.IdleTimeout = time.Duration(settings.IdleTimeoutSeconds) * time.Second
+		limits.MaxDuration = time.Duration(settings.MaxDurationSeconds) * time.Second
+		limits.ProgressInterval = time.Duration(settings.ProgressLogSeconds) * time.Second
+	}
+	return limits
+}
+
+// ReadAssembled reads body to the end within limits. When a watchdog fires, abort is called to
+// unblock the pending read, typically by closing the upstream response, and the matching
+// ErrAssembly* error is returned.
+func ReadAssembled(ctx context.Context, body io.Reader, limits StreamAssemblyLimits, abort func()) ([]byte, error) {
+	var (
+		mu      sync.Mutex
+		tripped error
+	)
+	trip := func(reason error) func() {
+		return func() {
+			mu.Lock()
+			if tripped == nil {
+				tripped = reason
+			}
+			mu.Unlock()
+			if abort != nil {
+				abort()
+			}
+		}
+	}
+	trippedErr := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return tripped
+	}
+	if limits.MaxDuration > 0 {
+		deadline := time.AfterFunc(limits.MaxDuration, trip(ErrAssemblyMaxDuration))
+		defer deadline.Stop()
+	}
+	var idle *time.Timer
+	if limits.IdleTimeout > 0 {
+		idle = time.AfterFunc(limits.IdleTimeout, trip(ErrAssemblyIdleTimeout))
+		defer idle.Stop()
+	}
+
+	started := time.Now()
+	lastProgress := started
+	var buf bytes.Buffer
+	chunk := make([]byte, 32*1024)
+	for {
+		n, errRead := body.Read(chunk)
+		if n > 0 {
+			if idle != nil {
+				idle.Reset(limits.IdleTimeout)
+			}
+			buf.Write(chunk[:n])
+			if limits.MaxBytes > 0 && int64(buf.Len()) > limits.MaxBytes {
+				if abort != nil {
+					abort()
+				}
+				return nil, fmt.Errorf("%w (%d bytes)", ErrAssemblyTooLarge, limits.MaxBytes)
+			}
+			if limits.ProgressInterval > 0 && time.Since(lastProgress) >= limits.ProgressInterval {
+				lastProgress = time.Now()
+				LogWithRequestID(ctx).Infof("assembling upstream stream: %d bytes after %s", buf.Len(), lastProgress.Sub(started).Round(time.Second))
+			}
+		}
+		if errRead == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if errRead != nil {
+			if reason := trippedErr(); reason != nil {
+				return nil, fmt.Errorf("%w after %d bytes", reason, buf.Len())
+			}
+			return nil, errRead
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package helps
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadAssembledIdleTimeoutAbortsRead(t *testing.T) {
+	reader, writer := io.Pipe()
+	go func() {
+		_, _ = writer.Write([]byte("data: {}\n"))
+	}()
+	abort := func() { _ = reader.CloseWithError(errors.New("closed")) }
+
+	_, err := ReadAssembled(context.Background(), reader, StreamAssemblyLimits{IdleTimeout: 50 * time.Millisecond}, abort)
+	if !errors.Is(err, ErrAssemblyIdleTimeout) {
+		t.Fatalf("err = %v, want ErrAssemblyIdleTimeout", err)
+	}
+}
+
+func TestReadAssembledMaxBytes(t *testing.T) {
+	data, err := ReadAssembled(context.Background(), strings.NewReader("0123456789"), StreamAssemblyLimits{MaxBytes: 10}, nil)
+	if err != nil || string(data) != "0123456789" {
+		t.Fatalf("ReadAssembled() = %q, %v; want the full body at the cap", data, err)
+	}
+	if _, err = ReadAssembled(context.Background(), strings.NewReader("0123456789a"), StreamAssemblyLimits{MaxBytes: 10}, nil); !errors.Is(err, ErrAssemblyTooLarge) {
+		t.Fatalf("err = %v, want ErrAssemblyTooLarge", err)
+	}
+}
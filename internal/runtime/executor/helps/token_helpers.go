@@ -69,6 +69,66 @@ func CountOpenAIChatTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
 	return int64(count), nil
 }
 
+// CountClaudeMessagesTokens approximates input tokens for Claude Messages payloads.
+// It is used when an upstream does not expose the count_tokens endpoint.
+func CountClaudeMessagesTokens(enc tokenizer.Codec, payload []byte) (int64, error) {
+	if enc == nil {
+		return 0, fmt.Errorf("encoder is nil")
+	}
+	if len(payload) == 0 {
+		return 0, nil
+	}
+
+	root := gjson.ParseBytes(payload)
+	segments := make([]string, 0, 32)
+
+	collectClaudeContent(root.Get("system"), &segments)
+	root.Get("messages").ForEach(func(_, msg gjson.Result) bool {
+		addIfNotEmpty(&segments, msg.Get("role").String())
+		collectClaudeContent(msg.Get("content"), &segments)
+		return true
+	})
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		addIfNotEmpty(&segments, tool.Get("name").String())
+		addIfNotEmpty(&segments, tool.Get("description").String())
+		if schema := tool.Get("input_schema"); schema.Exists() {
+			addIfNotEmpty(&segments, schema.Raw)
+		}
+		return true
+	})
+
+	joined := strings.TrimSpace(strings.Join(segments, "\n"))
+	if joined == "" {
+		return 0, nil
+	}
+	count, err := enc.Count(joined)
+	if err != nil {
+		return 0, err
+	}
+	return int64(count), nil
+}
+
+func collectClaudeContent(content gjson.Result, segments *[]string) {
+	if content.Type == gjson.String {
+		addIfNotEmpty(segments, content.String())
+		return
+	}
+	content.ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			addIfNotEmpty(segments, block.Get("text").String())
+		case "thinking":
+			addIfNotEmpty(segments, block.Get("thinking").String())
+		case "tool_use":
+			addIfNotEmpty(segments, block.Get("name").String())
+			addIfNotEmpty(segments, block.Get("input").Raw)
+		case "tool_result":
+			collectClaudeContent(block.Get("content"), segments)
+		}
+		return true
+	})
+}
+
 // BuildOpenAIUsageJSON returns a minimal usage structure understood by downstream translators.
 func BuildOpenAIUsageJSON(count int64) []byte {
 	return []byte(fmt.Sprintf(`{"usage":{"prompt_tokens":%d,"completion_tokens":0,"total_tokens":%d}}`, count, count))
@@ -3,12 +3,14 @@ package helps
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/scheduler"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/tidwall/gjson"
@@ -24,6 +26,7 @@ type UsageReporter struct {
 	apiKey      string
 	source      string
 	requestedAt time.Time
+	queueTime   time.Duration
 	once        sync.Once
 }
 
@@ -33,6 +36,7 @@ func NewUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		provider:    provider,
 		model:       model,
 		requestedAt: time.Now(),
+		queueTime:   scheduler.QueueTime(ctx),
 		apiKey:      apiKey,
 		source:      resolveUsageSource(auth, apiKey),
 		authType:    resolveUsageAuthType(auth),
@@ -48,6 +52,20 @@ func (r *UsageReporter) Publish(ctx context.Context, detail usage.Detail) {
 	r.publishWithOutcome(ctx, detail, false)
 }
 
+// PublishClientCancelled records a request aborted by the client with the partial usage seen
+// so far. It does not count as a failure.
+func (r *UsageReporter) PublishClientCancelled(ctx context.Context, detail usage.Detail) {
+	if r == nil {
+		return
+	}
+	detail = normalizeUsageDetailTotal(detail)
+	r.once.Do(func() {
+		record := r.buildRecord(detail, false)
+		record.Outcome = usage.OutcomeClientCancelled
+		usage.PublishRecord(ctx, record)
+	})
+}
+
 func (r *UsageReporter) PublishAdditionalModel(ctx context.Context, model string, detail usage.Detail) {
 	record, ok := r.buildAdditionalModelRecord(model, detail)
 	if !ok {
@@ -79,9 +97,25 @@ func (r *UsageReporter) TrackFailure(ctx context.Context, errPtr *error) {
 	if r == nil || errPtr == nil {
 		return
 	}
-	if *errPtr != nil {
-		r.PublishFailure(ctx)
+	if *errPtr == nil {
+		return
+	}
+	if ClientCancelled(ctx, *errPtr) {
+		r.PublishClientCancelled(ctx, usage.Detail{})
+		return
+	}
+	r.PublishFailure(ctx)
+}
+
+// ClientCancelled reports whether err stems from the cancellation of the request context,
+// which happens when the downstream client disconnects. Errors carrying an upstream status
+// still count as failures.
+func ClientCancelled(ctx context.Context, err error) bool {
+	if ctx == nil || !errors.Is(ctx.Err(), context.Canceled) {
+		return false
 	}
+	var statusErr interface{ StatusCode() int }
+	return !errors.As(err, &statusErr)
 }
 
 func (r *UsageReporter) publishWithOutcome(ctx context.Context, detail usage.Detail, failed bool) {
@@ -146,6 +180,7 @@ func (r *UsageReporter) buildRecordForModel(model string, detail usage.Detail, f
 		AuthType:    r.authType,
 		RequestedAt: r.requestedAt,
 		Latency:     r.latency(),
+		QueueTime:   r.queueTime,
 		Failed:      failed,
 		Detail:      detail,
 	}
@@ -291,12 +326,14 @@ func parseOpenAIStyleUsageNode(usageNode gjson.Result) usage.Detail {
 	if reasoning.Exists() {
 		detail.ReasoningTokens = reasoning.Int()
 	}
+	// OpenRouter usage accounting reports the billed cost in credits (USD).
+	detail.Cost = usageNode.Get("cost").Float()
 	return detail
 }
 
 func ParseOpenAIStreamUsage(line []byte) (usage.Detail, bool) {
 	payload := jsonPayload(line)
-	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+	if !mayCarryUsage(payload) || !gjson.ValidBytes(payload) {
 		return usage.Detail{}, false
 	}
 	usageNode := gjson.GetBytes(payload, "usage")
@@ -314,6 +351,7 @@ func ParseOpenAIStreamUsage(line []byte) (usage.Detail, bool) {
 	if reasoning := usageNode.Get("completion_tokens_details.reasoning_tokens"); reasoning.Exists() {
 		detail.ReasoningTokens = reasoning.Int()
 	}
+	detail.Cost = usageNode.Get("cost").Float()
 	return detail, true
 }
 
@@ -323,13 +361,11 @@ func ParseClaudeUsage(data []byte) usage.Detail {
 		return usage.Detail{}
 	}
 	detail := usage.Detail{
-		InputTokens:  usageNode.Get("input_tokens").Int(),
-		OutputTokens: usageNode.Get("output_tokens").Int(),
-		CachedTokens: usageNode.Get("cache_read_input_tokens").Int(),
-	}
-	if detail.CachedTokens == 0 {
-		// fall back to creation tokens when read tokens are absent
-		detail.CachedTokens = usageNode.Get("cache_creation_input_tokens").Int()
+		InputTokens:         usageNode.Get("input_tokens").Int(),
+		OutputTokens:        usageNode.Get("output_tokens").Int(),
+		CachedTokens:        usageNode.Get("cache_read_input_tokens").Int(),
+		CacheCreationTokens: usageNode.Get("cache_creation_input_tokens").Int(),
+		InputExcludesCache:  true,
 	}
 	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
 	return detail
@@ -337,7 +373,7 @@ func ParseClaudeUsage(data []byte) usage.Detail {
 
 func ParseClaudeStreamUsage(line []byte) (usage.Detail, bool) {
 	payload := jsonPayload(line)
-	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+	if !mayCarryUsage(payload) || !gjson.ValidBytes(payload) {
 		return usage.Detail{}, false
 	}
 	usageNode := gjson.GetBytes(payload, "usage")
@@ -345,14 +381,73 @@ func ParseClaudeStreamUsage(line []byte) (usage.Detail, bool) {
 		return usage.Detail{}, false
 	}
 	detail := usage.Detail{
-		InputTokens:  usageNode.Get("input_tokens").Int(),
-		OutputTokens: usageNode.Get("output_tokens").Int(),
-		CachedTokens: usageNode.Get("cache_read_input_tokens").Int(),
+		InputTokens:         usageNode.Get("input_tokens").Int(),
+		OutputTokens:        usageNode.Get("output_tokens").Int(),
+		CachedTokens:        usageNode.Get("cache_read_input_tokens").Int(),
+		CacheCreationTokens: usageNode.Get("cache_creation_input_tokens").Int(),
+		InputExcludesCache:  true,
+	}
+	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+	return detail, true
+}
+
+// ClaudeStreamUsage accumulates the usage of a Claude stream. message_start carries the prompt
+// usage, including cache reads and writes, while message_delta carries the output tokens and may
+// omit the prompt fields.
+type ClaudeStreamUsage struct {
+	start    usage.Detail
+	hasStart bool
+	last     usage.Detail
+	hasLast  bool
+}
+
+// Partial returns the usage of the last message_delta seen, or the message_start prompt usage
+// when none arrived yet. It is published when the client cancels the stream midway.
+func (u *ClaudeStreamUsage) Partial() usage.Detail {
+	if u.hasLast {
+		return u.last
+	}
+	detail := u.start
+	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+	return detail
+}
+
+// Parse returns the usage of a stream line merged with the message_start usage seen before.
+// ok is false for lines without a message_delta style usage object.
+func (u *ClaudeStreamUsage) Parse(line []byte) (usage.Detail, bool) {
+	payload := jsonPayload(line)
+	if !mayCarryUsage(payload) || !gjson.ValidBytes(payload) {
+		return usage.Detail{}, false
+	}
+	if startUsage := gjson.GetBytes(payload, "message.usage"); startUsage.Exists() && gjson.GetBytes(payload, "type").String() == "message_start" {
+		u.start = usage.Detail{
+			InputTokens:         startUsage.Get("input_tokens").Int(),
+			CachedTokens:        startUsage.Get("cache_read_input_tokens").Int(),
+			CacheCreationTokens: startUsage.Get("cache_creation_input_tokens").Int(),
+			InputExcludesCache:  true,
+		}
+		u.hasStart = true
+		return usage.Detail{}, false
+	}
+	detail, ok := ParseClaudeStreamUsage(line)
+	if !ok {
+		return detail, false
+	}
+	if !u.hasStart {
+		u.last, u.hasLast = detail, true
+		return detail, true
+	}
+	if detail.InputTokens == 0 {
+		detail.InputTokens = u.start.InputTokens
 	}
 	if detail.CachedTokens == 0 {
-		detail.CachedTokens = usageNode.Get("cache_creation_input_tokens").Int()
+		detail.CachedTokens = u.start.CachedTokens
+	}
+	if detail.CacheCreationTokens == 0 {
+		detail.CacheCreationTokens = u.start.CacheCreationTokens
 	}
 	detail.TotalTokens = detail.InputTokens + detail.OutputTokens
+	u.last, u.hasLast = detail, true
 	return detail, true
 }
 
@@ -638,6 +733,12 @@ func JSONPayload(line []byte) []byte {
 	return jsonPayload(line)
 }
 
+// mayCarryUsage reports whether payload can hold a usage object. Stream usage parsers run on
+// every line, and most lines are content deltas that this check rejects without validating them.
+func mayCarryUsage(payload []byte) bool {
+	return len(payload) > 0 && bytes.Contains(payload, []byte(`"usage"`))
+}
+
 func jsonPayload(line []byte) []byte {
 	trimmed := bytes.TrimSpace(line)
 	if len(trimmed) == 0 {
@@ -1,6 +1,8 @@
 package helps
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -124,3 +126,56 @@ func TestUsageReporterBuildAdditionalModelRecordSkipsZeroTokens(t *testing.T) {
 		t.Fatalf("expected non-zero cached token usage to be recorded")
 	}
 }
+
+func TestClaudeStreamUsageMergesMessageStart(t *testing.T) {
+	var streamUsage ClaudeStreamUsage
+	start := []byte(`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":12,"cache_read_input_tokens":3000,"cache_creation_input_tokens":500,"output_tokens":1}}}`)
+	if _, ok := streamUsage.Parse(start); ok {
+		t.Fatalf("message_start should not publish usage")
+	}
+	detail, ok := streamUsage.Parse([]byte(`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":40}}`))
+	if !ok {
+		t.Fatalf("message_delta usage not parsed")
+	}
+	if detail.InputTokens != 12 || detail.CachedTokens != 3000 || detail.CacheCreationTokens != 500 || detail.OutputTokens != 40 || !detail.InputExcludesCache {
+		t.Fatalf("unexpected detail %+v", detail)
+	}
+}
+
+func TestClaudeStreamUsagePartial(t *testing.T) {
+	var streamUsage ClaudeStreamUsage
+	streamUsage.Parse([]byte(`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":12,"cache_read_input_tokens":300,"output_tokens":1}}}`))
+	if detail := streamUsage.Partial(); detail.InputTokens != 12 || detail.CachedTokens != 300 || detail.OutputTokens != 0 || detail.TotalTokens != 12 {
+		t.Fatalf("partial before message_delta = %+v", detail)
+	}
+	streamUsage.Parse([]byte(`data: {"type":"message_delta","delta":{},"usage":{"output_tokens":7}}`))
+	if detail := streamUsage.Partial(); detail.InputTokens != 12 || detail.OutputTokens != 7 || detail.TotalTokens != 19 {
+		t.Fatalf("partial after message_delta = %+v", detail)
+	}
+}
+
+type testStatusErr struct{}
+
+func (testStatusErr) Error() string   { return "upstream failed" }
+func (testStatusErr) StatusCode() int { return 500 }
+
+func TestClientCancelled(t *testing.T) {
+	live := context.Background()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	deadline, cancelDeadline := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelDeadline()
+
+	if ClientCancelled(live, context.Canceled) {
+		t.Fatalf("live context reported as client cancelled")
+	}
+	if ClientCancelled(deadline, context.DeadlineExceeded) {
+		t.Fatalf("deadline reported as client cancelled")
+	}
+	if !ClientCancelled(canceled, errors.New("http: read on closed response body")) {
+		t.Fatalf("read error after cancellation not reported as client cancelled")
+	}
+	if ClientCancelled(canceled, testStatusErr{}) {
+		t.Fatalf("upstream status error reported as client cancelled")
+	}
+}
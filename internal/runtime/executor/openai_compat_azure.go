@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+// azureConfig returns the Azure OpenAI settings of the provider serving auth, if any.
+func (e *OpenAICompatExecutor) azureConfig(auth *cliproxyauth.Auth) *config.AzureOpenAIConfig {
+	compat := e.resolveCompatConfig(auth)
+	if compat == nil {
+		return nil
+	}
+	return compat.Azure
+}
+
+// resolveEndpoint returns the upstream URL for an OpenAI endpoint and the payload to send.
+// Azure providers route to the deployment of the payload model, which is rewritten to the
+// deployment name.
+func (e *OpenAICompatExecutor) resolveEndpoint(auth *cliproxyauth.Auth, baseURL, endpoint string, payload []byte) (string, []byte) {
+	azure := e.azureConfig(auth)
+	if azure == nil {
+		return strings.TrimSuffix(baseURL, "/") + endpoint, payload
+	}
+	deployment := azure.Deployment(gjson.GetBytes(payload, "model").String())
+	payload = e.overrideModel(payload, deployment)
+	return azureOpenAIURL(baseURL, azure.APIVersion, endpoint, deployment), payload
+}
+
+// setAPIKey authenticates req with the provider API key: the api-key header for Azure, a
+// bearer token otherwise.
+func (e *OpenAICompatExecutor) setAPIKey(req *http.Request, auth *cliproxyauth.Auth, apiKey string) {
+	if apiKey == "" {
+		return
+	}
+	if e.azureConfig(auth) != nil {
+		req.Header.Set("api-key", apiKey)
+		req.Header.Del("Authorization")
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+}
+
+// azureOpenAIURL maps an OpenAI endpoint onto an Azure OpenAI resource. Deployment-scoped
+// endpoints use /openai/deployments/{deployment}; others, such as the Responses API, live
+// under /openai and select the deployment through the body model.
+func azureOpenAIURL(baseURL, apiVersion, endpoint, deployment string) string {
+	base := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/openai")
+	if apiVersion == "" {
+		apiVersion = config.DefaultAzureOpenAIAPIVersion
+	}
+	path := "/openai" + endpoint
+	switch endpoint {
+	case "/chat/completions", "/completions", "/embeddings":
+		path = "/openai/deployments/" + url.PathEscape(deployment) + endpoint
+	}
+	return base + path + "?api-version=" + url.QueryEscape(apiVersion)
+}
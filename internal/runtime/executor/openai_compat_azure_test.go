@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAICompatExecutorAzureDeployment(t *testing.T) {
+	var gotURL, gotAPIKey, gotAuthorization string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.RequestURI()
+		gotAPIKey = r.Header.Get("api-key")
+		gotAuthorization = r.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{OpenAICompatibility: []config.OpenAICompatibility{{
+		Name:    "azure",
+		BaseURL: server.URL,
+		Azure: &config.AzureOpenAIConfig{
+			APIVersion:  "2024-10-21",
+			Deployments: map[string]string{"gpt-4o": "prod-gpt4o"},
+		},
+	}}}
+	executor := NewOpenAICompatExecutor("azure", cfg)
+	auth := &cliproxyauth.Auth{Provider: "azure", Attributes: map[string]string{
+		"base_url":    server.URL,
+		"api_key":     "azure-key",
+		"compat_name": "azure",
+	}}
+	_, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-4o",
+		Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if gotURL != "/openai/deployments/prod-gpt4o/chat/completions?api-version=2024-10-21" {
+		t.Fatalf("url = %q", gotURL)
+	}
+	if gotAPIKey != "azure-key" || gotAuthorization != "" {
+		t.Fatalf("api-key = %q, Authorization = %q", gotAPIKey, gotAuthorization)
+	}
+	if got := gjson.GetBytes(gotBody, "model").String(); got != "prod-gpt4o" {
+		t.Fatalf("model = %q, want deployment name", got)
+	}
+}
+
+func TestAzureOpenAIURL(t *testing.T) {
+	got := azureOpenAIURL("https://res.openai.azure.com/openai/", "", "/responses/compact", "gpt-5")
+	if want := "https://res.openai.azure.com/openai/responses/compact?api-version=" + config.DefaultAzureOpenAIAPIVersion; got != want {
+		t.Fatalf("url = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// OllamaProvider is the provider key of Ollama servers.
+const OllamaProvider = "ollama"
+
+// ollamaDiscoveryTimeout bounds model discovery so an unreachable server cannot stall
+// model registration.
+const ollamaDiscoveryTimeout = 5 * time.Second
+
+// capabilityModel returns the model ID used to look up thinking capabilities. Ollama models
+// are registered only under their prefixed ID, so the auth prefix stripped during routing is
+// restored for the registry lookup.
+func (e *OpenAICompatExecutor) capabilityModel(auth *cliproxyauth.Auth, model string) string {
+	if e.provider != OllamaProvider || auth == nil {
+		return model
+	}
+	prefix := strings.TrimSpace(auth.Prefix)
+	if prefix == "" || strings.HasPrefix(model, prefix+"/") {
+		return model
+	}
+	return prefix + "/" + model
+}
+
+// FetchOllamaModels lists the models installed on the Ollama server of auth together with
+// their capabilities. Models reporting the "thinking" capability accept reasoning effort;
+// thinking configuration is stripped for all others.
+func FetchOllamaModels(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth) ([]*registry.ModelInfo, error) {
+	if auth == nil || auth.Attributes == nil {
+		return nil, fmt.Errorf("ollama: missing server attributes")
+	}
+	root := strings.TrimSuffix(strings.TrimSpace(auth.Attributes["base_url"]), "/v1")
+	if root == "" {
+		return nil, fmt.Errorf("ollama: missing base url")
+	}
+	ctx, cancel := context.WithTimeout(ctx, ollamaDiscoveryTimeout)
+	defer cancel()
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, cfg, auth, 0)
+
+	tags, errTags := ollamaRequest(ctx, httpClient, auth, http.MethodGet, root+"/api/tags", nil)
+	if errTags != nil {
+		return nil, errTags
+	}
+	now := time.Now().Unix()
+	var models []*registry.ModelInfo
+	for _, entry := range gjson.GetBytes(tags, "models").Array() {
+		name := strings.TrimSpace(entry.Get("name").String())
+		if name == "" {
+			continue
+		}
+		info := &registry.ModelInfo{
+			ID:          name,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     OllamaProvider,
+			Type:        OllamaProvider,
+			DisplayName: name,
+		}
+		body, _ := json.Marshal(map[string]string{"model": name})
+		show, errShow := ollamaRequest(ctx, httpClient, auth, http.MethodPost, root+"/api/show", body)
+		if errShow != nil {
+			log.Debugf("ollama: capabilities of %s unavailable: %v", name, errShow)
+		} else {
+			applyOllamaCapabilities(info, show)
+		}
+		models = append(models, info)
+	}
+	return models, nil
+}
+
+// OllamaThinkingSupport describes the reasoning effort levels accepted by thinking-capable
+// Ollama models.
+func OllamaThinkingSupport() *registry.ThinkingSupport {
+	return &registry.ThinkingSupport{ZeroAllowed: true, Levels: []string{"low", "medium", "high"}}
+}
+
+// applyOllamaCapabilities fills thinking support and context length from an /api/show response.
+func applyOllamaCapabilities(info *registry.ModelInfo, show []byte) {
+	for _, capability := range gjson.GetBytes(show, "capabilities").Array() {
+		if capability.String() == "thinking" {
+			info.Thinking = OllamaThinkingSupport()
+		}
+	}
+	arch := gjson.GetBytes(show, `model_info.general\.architecture`).String()
+	if arch == "" {
+		return
+	}
+	if contextLength := gjson.GetBytes(show, "model_info."+gjson.Escape(arch+".context_length")).Int(); contextLength > 0 {
+		info.ContextLength = int(contextLength)
+	}
+}
+
+func ollamaRequest(ctx context.Context, httpClient *http.Client, auth *cliproxyauth.Auth, method, url string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, errReq := http.NewRequestWithContext(ctx, method, url, reader)
+	if errReq != nil {
+		return nil, errReq
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey := strings.TrimSpace(auth.Attributes["api_key"]); apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, auth.Attributes)
+	httpResp, errDo := httpClient.Do(httpReq)
+	if errDo != nil {
+		return nil, errDo
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("ollama: close response body error: %v", errClose)
+		}
+	}()
+	data, errRead := io.ReadAll(httpResp.Body)
+	if errRead != nil {
+		return nil, errRead
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, nil
+}
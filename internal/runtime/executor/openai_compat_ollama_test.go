@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newOllamaTestServer(t *testing.T, gotBody *[]byte) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/tags":
+			_, _ = w.Write([]byte(`{"models":[{"name":"qwen3:8b"},{"name":"llama3.2:latest"}]}`))
+		case "/api/show":
+			if gjson.GetBytes(body, "model").String() == "qwen3:8b" {
+				_, _ = w.Write([]byte(`{"capabilities":["completion","tools","thinking"],"model_info":{"general.architecture":"qwen3","qwen3.context_length":40960}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"capabilities":["completion"],"model_info":{"general.architecture":"llama","llama.context_length":131072}}`))
+		case "/v1/chat/completions":
+			*gotBody = body
+			_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestFetchOllamaModelsCapabilities(t *testing.T) {
+	server := newOllamaTestServer(t, new([]byte))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{Provider: OllamaProvider, Prefix: "local", Attributes: map[string]string{"base_url": server.URL + "/v1"}}
+	models, err := FetchOllamaModels(context.Background(), &config.Config{}, auth)
+	if err != nil {
+		t.Fatalf("FetchOllamaModels error: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("models = %d, want 2", len(models))
+	}
+	if models[0].ID != "qwen3:8b" || models[0].Thinking == nil || models[0].ContextLength != 40960 {
+		t.Fatalf("unexpected thinking model %+v", models[0])
+	}
+	if models[1].ID != "llama3.2:latest" || models[1].Thinking != nil || models[1].ContextLength != 131072 {
+		t.Fatalf("unexpected model %+v", models[1])
+	}
+}
+
+func TestOllamaExecutorStripsThinkingForModelsWithoutReasoning(t *testing.T) {
+	var gotBody []byte
+	server := newOllamaTestServer(t, &gotBody)
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "ollama-test", Provider: OllamaProvider, Prefix: "local", Attributes: map[string]string{"base_url": server.URL + "/v1"}}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, OllamaProvider, []*registry.ModelInfo{
+		{ID: "local/qwen3:8b", Thinking: OllamaThinkingSupport()},
+		{ID: "local/llama3.2:latest"},
+	})
+	defer reg.UnregisterClient(auth.ID)
+
+	executor := NewOpenAICompatExecutor(OllamaProvider, &config.Config{})
+	for _, tc := range []struct {
+		model      string
+		wantEffort string
+	}{
+		{model: "qwen3:8b", wantEffort: "high"},
+		{model: "llama3.2:latest", wantEffort: ""},
+	} {
+		_, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+			Model:   tc.model,
+			Payload: []byte(`{"model":"` + tc.model + `","reasoning_effort":"high","messages":[{"role":"user","content":"hi"}]}`),
+		}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+		if err != nil {
+			t.Fatalf("Execute(%s) error: %v", tc.model, err)
+		}
+		if got := gjson.GetBytes(gotBody, "reasoning_effort").String(); got != tc.wantEffort {
+			t.Fatalf("%s reasoning_effort = %q, want %q (body=%s)", tc.model, got, tc.wantEffort, gotBody)
+		}
+		if got := gjson.GetBytes(gotBody, "model").String(); got != tc.model {
+			t.Fatalf("upstream model = %q, want %q", got, tc.model)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// OpenRouterProvider is the provider key of OpenRouter API keys.
+const OpenRouterProvider = "openrouter"
+
+const (
+	// openRouterCatalogTTL controls how long a fetched model catalog is reused. The catalog is
+	// shared by every key of the same base URL.
+	openRouterCatalogTTL = time.Hour
+	// openRouterCatalogTimeout bounds catalog fetches so an unreachable endpoint cannot stall
+	// model registration.
+	openRouterCatalogTimeout = 15 * time.Second
+
+	// OpenRouter accepts reasoning.max_tokens budgets in this range.
+	openRouterMinReasoningTokens = 1024
+	openRouterMaxReasoningTokens = 32000
+)
+
+type openRouterCatalogEntry struct {
+	models    []*registry.ModelInfo
+	fetchedAt time.Time
+}
+
+var (
+	openRouterCatalogMu    sync.Mutex
+	openRouterCatalogCache = make(map[string]openRouterCatalogEntry)
+)
+
+// thinkingFormat returns the thinking format applied to requests translated to the "to" format.
+// OpenRouter expects the reasoning object instead of reasoning_effort on chat completions.
+func (e *OpenAICompatExecutor) thinkingFormat(to string) string {
+	if e.provider == OpenRouterProvider && to == "openai" {
+		return OpenRouterProvider
+	}
+	return to
+}
+
+// applyUsageAccounting enables OpenRouter usage accounting so responses report the request cost.
+func (e *OpenAICompatExecutor) applyUsageAccounting(payload []byte) []byte {
+	if e.provider != OpenRouterProvider || gjson.GetBytes(payload, "usage.include").Exists() {
+		return payload
+	}
+	updated, errSet := sjson.SetBytes(payload, "usage.include", true)
+	if errSet != nil {
+		return payload
+	}
+	return updated
+}
+
+// OpenRouterThinkingSupport describes the reasoning controls of OpenRouter reasoning models,
+// which accept both effort levels and max_tokens budgets.
+func OpenRouterThinkingSupport() *registry.ThinkingSupport {
+	return &registry.ThinkingSupport{
+		Min:            openRouterMinReasoningTokens,
+		Max:            openRouterMaxReasoningTokens,
+		ZeroAllowed:    true,
+		DynamicAllowed: true,
+		Levels:         []string{"minimal", "low", "medium", "high"},
+	}
+}
+
+// FetchOpenRouterModels returns the OpenRouter model catalog for the base URL of auth. Models
+// listing the "reasoning" parameter get thinking support; thinking configuration is stripped
+// for all others. Catalogs are cached for openRouterCatalogTTL.
+func FetchOpenRouterModels(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth) ([]*registry.ModelInfo, error) {
+	if auth == nil || auth.Attributes == nil {
+		return nil, fmt.Errorf("openrouter: missing credential attributes")
+	}
+	baseURL := strings.TrimSuffix(strings.TrimSpace(auth.Attributes["base_url"]), "/")
+	if baseURL == "" {
+		baseURL = config.DefaultOpenRouterBaseURL
+	}
+
+	openRouterCatalogMu.Lock()
+	cached, ok := openRouterCatalogCache[baseURL]
+	openRouterCatalogMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < openRouterCatalogTTL {
+		return cloneModelInfos(cached.models), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, openRouterCatalogTimeout)
+	defer cancel()
+	httpReq, errReq := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/models", nil)
+	if errReq != nil {
+		return nil, errReq
+	}
+	if apiKey := strings.TrimSpace(auth.Attributes["api_key"]); apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, auth.Attributes)
+	httpResp, errDo := helps.NewProxyAwareHTTPClient(ctx, cfg, auth, 0).Do(httpReq)
+	if errDo != nil {
+		return nil, errDo
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("openrouter: close response body error: %v", errClose)
+		}
+	}()
+	data, errRead := io.ReadAll(httpResp.Body)
+	if errRead != nil {
+		return nil, errRead
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+
+	models := parseOpenRouterCatalog(data)
+	openRouterCatalogMu.Lock()
+	openRouterCatalogCache[baseURL] = openRouterCatalogEntry{models: models, fetchedAt: time.Now()}
+	openRouterCatalogMu.Unlock()
+	return cloneModelInfos(models), nil
+}
+
+func parseOpenRouterCatalog(data []byte) []*registry.ModelInfo {
+	entries := gjson.GetBytes(data, "data").Array()
+	models := make([]*registry.ModelInfo, 0, len(entries))
+	for _, entry := range entries {
+		id := strings.TrimSpace(entry.Get("id").String())
+		if id == "" {
+			continue
+		}
+		info := &registry.ModelInfo{
+			ID:                  id,
+			Object:              "model",
+			Created:             entry.Get("created").Int(),
+			OwnedBy:             OpenRouterProvider,
+			Type:                OpenRouterProvider,
+			DisplayName:         entry.Get("name").String(),
+			ContextLength:       int(entry.Get("context_length").Int()),
+			MaxCompletionTokens: int(entry.Get("top_provider.max_completion_tokens").Int()),
+		}
+		for _, param := range entry.Get("supported_parameters").Array() {
+			if param.String() == "reasoning" {
+				info.Thinking = OpenRouterThinkingSupport()
+				break
+			}
+		}
+		models = append(models, info)
+	}
+	return models
+}
+
+func cloneModelInfos(models []*registry.ModelInfo) []*registry.ModelInfo {
+	out := make([]*registry.ModelInfo, 0, len(models))
+	for _, model := range models {
+		clone := *model
+		out = append(out, &clone)
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor/helps"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestFetchOpenRouterModelsParsesCatalog(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"deepseek/deepseek-r1","name":"DeepSeek R1","created":1737000000,"context_length":163840,"top_provider":{"max_completion_tokens":65536},"supported_parameters":["max_tokens","reasoning","include_reasoning"]},
+			{"id":"openai/gpt-4o","name":"GPT-4o","context_length":128000,"supported_parameters":["max_tokens","tools"]}
+		]}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{Provider: OpenRouterProvider, Attributes: map[string]string{"base_url": server.URL + "/api/v1", "api_key": "k"}}
+	for range 2 {
+		models, err := FetchOpenRouterModels(context.Background(), &config.Config{}, auth)
+		if err != nil {
+			t.Fatalf("FetchOpenRouterModels error: %v", err)
+		}
+		if len(models) != 2 {
+			t.Fatalf("models = %d, want 2", len(models))
+		}
+		if models[0].Thinking == nil || models[0].ContextLength != 163840 || models[0].MaxCompletionTokens != 65536 {
+			t.Fatalf("unexpected reasoning model %+v", models[0])
+		}
+		if models[1].Thinking != nil {
+			t.Fatalf("gpt-4o should not support thinking")
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("catalog requests = %d, want 1 (cached)", requests)
+	}
+}
+
+func TestOpenRouterExecutorReasoningAndCost(t *testing.T) {
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"gen-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15,"cost":0.00042}}`))
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "openrouter-test", Provider: OpenRouterProvider, Attributes: map[string]string{"base_url": server.URL, "api_key": "k"}}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, OpenRouterProvider, []*registry.ModelInfo{{ID: "deepseek/deepseek-r1", Thinking: OpenRouterThinkingSupport()}})
+	defer reg.UnregisterClient(auth.ID)
+
+	executor := NewOpenAICompatExecutor(OpenRouterProvider, &config.Config{})
+	resp, err := executor.Execute(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "deepseek/deepseek-r1(8192)",
+		Payload: []byte(`{"model":"deepseek/deepseek-r1","messages":[{"role":"user","content":"hi"}]}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := gjson.GetBytes(gotBody, "reasoning.max_tokens").Int(); got != 8192 {
+		t.Fatalf("reasoning.max_tokens = %d, body=%s", got, gotBody)
+	}
+	if !gjson.GetBytes(gotBody, "usage.include").Bool() {
+		t.Fatalf("expected usage accounting, body=%s", gotBody)
+	}
+	if got := helps.ParseOpenAIUsage(resp.Payload).Cost; got != 0.00042 {
+		t.Fatalf("cost = %v, want 0.00042", got)
+	}
+}
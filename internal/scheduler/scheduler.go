@@ -0,0 +1,219 @@
+// Package scheduler bounds the concurrent upstream requests of the proxy, overall and per client
+// API key. Requests over a limit wait in a weighted fair queue: every key has the weight of its
+// priority class, keys of heavier classes are served proportionally more often, so interactive
+// keys overtake queued batch work, and keys of one class share its turns evenly.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueTimeout is returned when a request waited longer than Limits.MaxQueueWait.
+var ErrQueueTimeout = errors.New("timed out waiting for an upstream request slot")
+
+// Limits bounds concurrent upstream requests. Zero disables the corresponding limit.
+type Limits struct {
+	// MaxConcurrent caps the upstream requests in flight across all keys.
+	MaxConcurrent int
+	// MaxConcurrentPerKey caps the upstream requests in flight of one key.
+	MaxConcurrentPerKey int
+	// MaxQueueWait is how long a request may wait for a slot before ErrQueueTimeout; zero waits
+	// until the request is cancelled.
+	MaxQueueWait time.Duration
+}
+
+// KeyPolicy is the scheduling policy of one client API key.
+type KeyPolicy struct {
+	// Weight is the relative share of turns of the key's priority class; values below 1 count
+	// as 1.
+	Weight int
+	// MaxConcurrent overrides Limits.MaxConcurrentPerKey when positive.
+	MaxConcurrent int
+}
+
+// Scheduler admits upstream requests under Limits.
+type Scheduler struct {
+	limits Limits
+	policy func(key string) KeyPolicy
+
+	mu      sync.Mutex
+	running int
+	perKey  map[string]int
+	queue   []*waiter
+	// vtime is the virtual time of the queue: the finish tag of the last admitted waiter.
+	vtime float64
+	// finish holds the finish tag of the latest waiter of each key.
+	finish map[string]float64
+	seq    uint64
+}
+
+type waiter struct {
+	key     string
+	limit   int
+	tag     float64
+	seq     uint64
+	ready   chan struct{}
+	granted bool
+}
+
+// New returns a scheduler enforcing limits. policy resolves the policy of a key; nil gives every
+// key weight 1.
+func New(limits Limits, policy func(key string) KeyPolicy) *Scheduler {
+	if policy == nil {
+		policy = func(string) KeyPolicy { return KeyPolicy{Weight: 1} }
+	}
+	return &Scheduler{
+		limits: limits,
+		policy: policy,
+		perKey: make(map[string]int),
+		finish: make(map[string]float64),
+	}
+}
+
+// Acquire waits for an upstream slot for key and returns the function releasing it along with
+// the time spent waiting. It fails with ErrQueueTimeout or the context error when no slot was
+// granted in time. A nil scheduler admits everything.
+func (s *Scheduler) Acquire(ctx context.Context, key string) (release func(), wait time.Duration, err error) {
+	if s == nil {
+		return func() {}, 0, nil
+	}
+	start := time.Now()
+	policy := s.policy(key)
+	weight := max(policy.Weight, 1)
+	limit := s.limits.MaxConcurrentPerKey
+	if policy.MaxConcurrent > 0 {
+		limit = policy.MaxConcurrent
+	}
+
+	s.mu.Lock()
+	s.seq++
+	w := &waiter{key: key, limit: limit, seq: s.seq, ready: make(chan struct{})}
+	// Weighted fair queueing: a waiter finishes 1/weight after the later of the queue's virtual
+	// time and its key's previous waiter, so heavier keys get more turns and idle keys cannot
+	// bank credit.
+	w.tag = max(s.vtime, s.finish[key]) + 1/float64(weight)
+	s.finish[key] = w.tag
+	s.queue = append(s.queue, w)
+	s.dispatchLocked()
+	granted := w.granted
+	s.mu.Unlock()
+	if granted {
+		return s.releaser(key), 0, nil
+	}
+
+	var timeout <-chan time.Time
+	if s.limits.MaxQueueWait > 0 {
+		timer := time.NewTimer(s.limits.MaxQueueWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-w.ready:
+		return s.releaser(key), time.Since(start), nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrQueueTimeout
+	}
+
+	s.mu.Lock()
+	if w.granted {
+		// The slot was granted while giving up; hand it to the next waiter.
+		s.releaseLocked(key)
+	} else if i := slices.Index(s.queue, w); i >= 0 {
+		s.queue = slices.Delete(s.queue, i, i+1)
+	}
+	s.mu.Unlock()
+	return nil, time.Since(start), err
+}
+
+// Stats returns the number of requests in flight and waiting.
+func (s *Scheduler) Stats() (running, queued int) {
+	if s == nil {
+		return 0, 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running, len(s.queue)
+}
+
+func (s *Scheduler) releaser(key string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.releaseLocked(key)
+			s.mu.Unlock()
+		})
+	}
+}
+
+func (s *Scheduler) releaseLocked(key string) {
+	s.running--
+	if s.perKey[key] <= 1 {
+		delete(s.perKey, key)
+		// A finish tag behind the virtual time no longer affects scheduling.
+		if s.finish[key] <= s.vtime {
+			delete(s.finish, key)
+		}
+	} else {
+		s.perKey[key]--
+	}
+	s.dispatchLocked()
+}
+
+// dispatchLocked admits queued waiters in finish tag order while slots are free, skipping
+// waiters whose key is at its own limit.
+func (s *Scheduler) dispatchLocked() {
+	for s.limits.MaxConcurrent <= 0 || s.running < s.limits.MaxConcurrent {
+		best := -1
+		for i, w := range s.queue {
+			if w.limit > 0 && s.perKey[w.key] >= w.limit {
+				continue
+			}
+			if best < 0 || w.tag < s.queue[best].tag || (w.tag == s.queue[best].tag && w.seq < s.queue[best].seq) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return
+		}
+		w := s.queue[best]
+		s.queue = slices.Delete(s.queue, best, best+1)
+		s.vtime = max(s.vtime, w.tag)
+		s.running++
+		s.perKey[w.key]++
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+var active atomic.Pointer[Scheduler]
+
+// SetActive installs the scheduler used for upstream requests; nil disables scheduling.
+// Requests admitted by a previous scheduler release their slots there.
+func SetActive(s *Scheduler) { active.Store(s) }
+
+// Active returns the installed scheduler, or nil when scheduling is disabled.
+func Active() *Scheduler { return active.Load() }
+
+type queueTimeKey struct{}
+
+// WithQueueTime records the time a request waited for its upstream slot.
+func WithQueueTime(ctx context.Context, wait time.Duration) context.Context {
+	return context.WithValue(ctx, queueTimeKey{}, wait)
+}
+
+// QueueTime returns the time recorded by WithQueueTime, or zero.
+func QueueTime(ctx context.Context) time.Duration {
+	if ctx == nil {
+		return 0
+	}
+	wait, _ := ctx.Value(queueTimeKey{}).(time.Duration)
+	return wait
+}
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSchedulerLimitsConcurrency(t *testing.T) {
+	s := New(Limits{MaxConcurrent: 2, MaxConcurrentPerKey: 1}, nil)
+	ctx := context.Background()
+
+	releaseA, _, errA := s.Acquire(ctx, "a")
+	if errA != nil {
+		t.Fatalf("acquire a: %v", errA)
+	}
+	releaseB, _, errB := s.Acquire(ctx, "b")
+	if errB != nil {
+		t.Fatalf("acquire b: %v", errB)
+	}
+
+	// Both the global and the per-key limit are reached now.
+	shortCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := s.Acquire(shortCtx, "c"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded over the global limit, got %v", err)
+	}
+	releaseB()
+	shortCtx2, cancel2 := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel2()
+	if _, _, err := s.Acquire(shortCtx2, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded over the per-key limit, got %v", err)
+	}
+
+	releaseC, wait, errC := s.Acquire(ctx, "c")
+	if errC != nil || wait != 0 {
+		t.Fatalf("expected immediate slot for c, got wait %v err %v", wait, errC)
+	}
+	releaseA()
+	releaseC()
+	releaseC() // releasing twice is a no-op
+	if running, queued := s.Stats(); running != 0 || queued != 0 {
+		t.Fatalf("expected idle scheduler, got running %d queued %d", running, queued)
+	}
+}
+
+func TestSchedulerQueueTimeout(t *testing.T) {
+	s := New(Limits{MaxConcurrent: 1, MaxQueueWait: 20 * time.Millisecond}, nil)
+	release, _, _ := s.Acquire(context.Background(), "a")
+	defer release()
+	_, wait, err := s.Acquire(context.Background(), "b")
+	if !errors.Is(err, ErrQueueTimeout) {
+		t.Fatalf("expected ErrQueueTimeout, got %v", err)
+	}
+	if wait < 20*time.Millisecond {
+		t.Fatalf("expected the wait to cover the queue timeout, got %v", wait)
+	}
+	if _, queued := s.Stats(); queued != 0 {
+		t.Fatalf("expected timed out waiter to leave the queue, got %d queued", queued)
+	}
+}
+
+func TestSchedulerWeightedFairOrder(t *testing.T) {
+	weights := map[string]int{"interactive": 4, "batch": 1}
+	s := New(Limits{MaxConcurrent: 1}, func(key string) KeyPolicy { return KeyPolicy{Weight: weights[key]} })
+	ctx := context.Background()
+
+	hold, _, _ := s.Acquire(ctx, "holder")
+	order := make(chan string, 10)
+	enqueue := func(key string) {
+		started := make(chan struct{})
+		go func() {
+			close(started)
+			release, _, err := s.Acquire(ctx, key)
+			if err != nil {
+				t.Errorf("acquire %s: %v", key, err)
+				return
+			}
+			order <- key
+			release()
+		}()
+		<-started
+		// Wait until the waiter is queued so the enqueue order is deterministic.
+		for {
+			if _, queued := s.Stats(); queued > 0 && queuedKeys(s)[key] > 0 {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	// Batch work queued first, interactive requests arriving later.
+	for range 3 {
+		enqueue("batch")
+	}
+	for range 3 {
+		enqueue("interactive")
+	}
+	hold()
+
+	var got []string
+	for range 6 {
+		got = append(got, <-order)
+	}
+	// The heavier interactive requests overtake the batch work queued before them.
+	want := []string{"interactive", "interactive", "interactive", "batch", "batch", "batch"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("admission order %v, want %v", got, want)
+		}
+	}
+}
+
+func queuedKeys(s *Scheduler) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, w := range s.queue {
+		counts[w.key]++
+	}
+	return counts
+}
+
+func TestQueueTimeContext(t *testing.T) {
+	if got := QueueTime(context.Background()); got != 0 {
+		t.Fatalf("expected zero queue time, got %v", got)
+	}
+	ctx := WithQueueTime(context.Background(), 150*time.Millisecond)
+	if got := QueueTime(ctx); got != 150*time.Millisecond {
+		t.Fatalf("expected 150ms queue time, got %v", got)
+	}
+}
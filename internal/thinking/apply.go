@@ -18,6 +18,7 @@ var providerAppliers = map[string]ProviderApplier{
 	"codex":       nil,
 	"antigravity": nil,
 	"kimi":        nil,
+	"openrouter":  nil,
 }
 
 // GetProviderApplier returns the ProviderApplier for the given provider name.
@@ -56,7 +57,8 @@ func IsUserDefinedModel(modelInfo *registry.ModelInfo) bool {
 // Suffix Priority: When the model name includes a thinking suffix (e.g., "gemini-2.5-pro(8192)"),
 // the suffix configuration takes priority over any thinking parameters in the request body.
 // This enables users to override thinking settings via the model name without modifying their
-// request payload.
+// request payload. When neither carries a config, the model default set via SetModelDefaults
+// applies.
 //
 // Parameters:
 //   - body: Original request body JSON
@@ -132,8 +134,9 @@ func ApplyThinking(body []byte, model string, fromFormat string, toFormat string
 		return body, nil
 	}
 
-	// 4. Get config: suffix priority over body
+	// 4. Get config: suffix priority over body, body priority over the configured model default
 	var config ThinkingConfig
+	fromDefault := false
 	if suffixResult.HasSuffix {
 		config = parseSuffixToConfig(suffixResult.RawSuffix, providerFormat, model)
 		log.WithFields(log.Fields{
@@ -153,6 +156,14 @@ func ApplyThinking(body []byte, model string, fromFormat string, toFormat string
 				"budget":   config.Budget,
 				"level":    config.Level,
 			}).Debug("thinking: original config from request |")
+		} else if config, fromDefault = modelDefault(baseModel, modelInfo.ID); fromDefault {
+			log.WithFields(log.Fields{
+				"provider": providerFormat,
+				"model":    modelInfo.ID,
+				"mode":     config.Mode,
+				"budget":   config.Budget,
+				"level":    config.Level,
+			}).Debug("thinking: config from model default |")
 		}
 	}
 
@@ -165,7 +176,8 @@ func ApplyThinking(body []byte, model string, fromFormat string, toFormat string
 	}
 
 	// 5. Validate and normalize configuration
-	validated, err := ValidateConfig(config, modelInfo, fromFormat, providerFormat, suffixResult.HasSuffix)
+	// Defaults are format-agnostic like suffixes, so they are clamped rather than rejected.
+	validated, err := ValidateConfig(config, modelInfo, fromFormat, providerFormat, suffixResult.HasSuffix || fromDefault)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"provider": providerFormat,
@@ -260,6 +272,11 @@ func applyUserDefinedModel(body []byte, modelInfo *registry.ModelInfo, fromForma
 		if !hasThinkingConfig(config) && fromFormat != toFormat {
 			config = extractThinkingConfig(body, toFormat)
 		}
+		if !hasThinkingConfig(config) {
+			if defaultConfig, ok := modelDefault(suffixResult.ModelName, modelID); ok {
+				config = defaultConfig
+			}
+		}
 	}
 
 	if !hasThinkingConfig(config) {
@@ -329,6 +346,8 @@ func extractThinkingConfig(body []byte, provider string) ThinkingConfig {
 	case "kimi":
 		// Kimi uses OpenAI-compatible reasoning_effort format
 		return extractOpenAIConfig(body)
+	case "openrouter":
+		return extractOpenRouterConfig(body)
 	default:
 		return ThinkingConfig{}
 	}
@@ -469,6 +488,39 @@ func extractOpenAIConfig(body []byte) ThinkingConfig {
 	return ThinkingConfig{}
 }
 
+// extractOpenRouterConfig extracts thinking configuration from OpenRouter format request body.
+//
+// OpenRouter API format:
+//   - reasoning.enabled: false disables reasoning
+//   - reasoning.max_tokens: integer budget
+//   - reasoning.effort: "minimal", "low", "medium", "high"
+//
+// The OpenAI reasoning_effort field is accepted as a fallback.
+func extractOpenRouterConfig(body []byte) ThinkingConfig {
+	reasoning := gjson.GetBytes(body, "reasoning")
+	if reasoning.Get("enabled").Exists() && !reasoning.Get("enabled").Bool() {
+		return ThinkingConfig{Mode: ModeNone, Budget: 0}
+	}
+	if budget := reasoning.Get("max_tokens"); budget.Exists() {
+		value := int(budget.Int())
+		if value == 0 {
+			return ThinkingConfig{Mode: ModeNone, Budget: 0}
+		}
+		return ThinkingConfig{Mode: ModeBudget, Budget: value}
+	}
+	if effort := reasoning.Get("effort"); effort.Exists() {
+		value := effort.String()
+		if value == "none" {
+			return ThinkingConfig{Mode: ModeNone, Budget: 0}
+		}
+		return ThinkingConfig{Mode: ModeLevel, Level: ThinkingLevel(value)}
+	}
+	if reasoning.Get("enabled").Bool() {
+		return ThinkingConfig{Mode: ModeAuto, Budget: -1}
+	}
+	return extractOpenAIConfig(body)
+}
+
 // extractCodexConfig extracts thinking configuration from Codex format request body.
 //
 // Codex API format (OpenAI Responses API):
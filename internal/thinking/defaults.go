@@ -0,0 +1,75 @@
+package thinking
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+var modelDefaults atomic.Pointer[map[string]ThinkingConfig]
+
+// ParseDefaultConfig converts a configured per-model thinking default to a ThinkingConfig.
+//
+// Mode is one of "none", "auto", "budget" or "level". An empty mode is inferred from the other
+// fields: an effort selects "level" and a positive budget selects "budget". "auto" with an
+// effort yields that level, matching Claude adaptive thinking with output_config.effort.
+// Returns false when the combination is invalid.
+func ParseDefaultConfig(mode, effort string, budget int) (ThinkingConfig, bool) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	level := ThinkingLevel(strings.ToLower(strings.TrimSpace(effort)))
+	if mode == "" {
+		switch {
+		case level != "":
+			mode = "level"
+		case budget > 0:
+			mode = "budget"
+		}
+	}
+	switch mode {
+	case "none":
+		return ThinkingConfig{Mode: ModeNone, Budget: 0}, true
+	case "auto":
+		if level != "" {
+			return ThinkingConfig{Mode: ModeLevel, Level: level}, true
+		}
+		return ThinkingConfig{Mode: ModeAuto, Budget: -1}, true
+	case "budget":
+		if budget <= 0 {
+			return ThinkingConfig{}, false
+		}
+		return ThinkingConfig{Mode: ModeBudget, Budget: budget}, true
+	case "level":
+		if level == "" {
+			return ThinkingConfig{}, false
+		}
+		return ThinkingConfig{Mode: ModeLevel, Level: level}, true
+	default:
+		return ThinkingConfig{}, false
+	}
+}
+
+// SetModelDefaults replaces the per-model thinking defaults that ApplyThinking uses when
+// neither a model suffix nor the request body carries a thinking config. Keys are model
+// names matched case-insensitively.
+func SetModelDefaults(defaults map[string]ThinkingConfig) {
+	normalized := make(map[string]ThinkingConfig, len(defaults))
+	for model, config := range defaults {
+		if key := strings.ToLower(strings.TrimSpace(model)); key != "" {
+			normalized[key] = config
+		}
+	}
+	modelDefaults.Store(&normalized)
+}
+
+// modelDefault returns the configured default for the first of models that has one.
+func modelDefault(models ...string) (ThinkingConfig, bool) {
+	defaults := modelDefaults.Load()
+	if defaults == nil || len(*defaults) == 0 {
+		return ThinkingConfig{}, false
+	}
+	for _, model := range models {
+		if config, ok := (*defaults)[strings.ToLower(strings.TrimSpace(model))]; ok {
+			return config, true
+		}
+	}
+	return ThinkingConfig{}, false
+}
@@ -0,0 +1,73 @@
+package thinking_test
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/thinking/provider/claude"
+	"github.com/tidwall/gjson"
+)
+
+func TestParseDefaultConfig(t *testing.T) {
+	tests := []struct {
+		mode, effort string
+		budget       int
+		want         thinking.ThinkingConfig
+		ok           bool
+	}{
+		{mode: "none", want: thinking.ThinkingConfig{Mode: thinking.ModeNone}, ok: true},
+		{mode: "auto", want: thinking.ThinkingConfig{Mode: thinking.ModeAuto, Budget: -1}, ok: true},
+		{mode: "auto", effort: "max", want: thinking.ThinkingConfig{Mode: thinking.ModeLevel, Level: thinking.LevelMax}, ok: true},
+		{effort: "High", want: thinking.ThinkingConfig{Mode: thinking.ModeLevel, Level: thinking.LevelHigh}, ok: true},
+		{budget: 8192, want: thinking.ThinkingConfig{Mode: thinking.ModeBudget, Budget: 8192}, ok: true},
+		{mode: "budget"},
+		{mode: "level"},
+		{},
+		{mode: "bogus"},
+	}
+	for _, tt := range tests {
+		got, ok := thinking.ParseDefaultConfig(tt.mode, tt.effort, tt.budget)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseDefaultConfig(%q, %q, %d) = %+v, %t; want %+v, %t", tt.mode, tt.effort, tt.budget, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestApplyThinking_ModelDefault(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	clientID := "test-model-default-" + t.Name()
+	modelID := "custom-default-claude"
+	reg.RegisterClient(clientID, "claude", []*registry.ModelInfo{{ID: modelID, UserDefined: true}})
+	thinking.SetModelDefaults(map[string]thinking.ThinkingConfig{
+		"Custom-Default-Claude": {Mode: thinking.ModeBudget, Budget: 4096},
+	})
+	t.Cleanup(func() {
+		thinking.SetModelDefaults(nil)
+		reg.UnregisterClient(clientID)
+	})
+
+	out, err := thinking.ApplyThinking([]byte(`{"max_tokens":16000}`), modelID, "claude", "claude", "claude")
+	if err != nil {
+		t.Fatalf("ApplyThinking() error = %v", err)
+	}
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 4096 {
+		t.Fatalf("thinking.budget_tokens = %d, want default 4096, body=%s", got, out)
+	}
+
+	out, err = thinking.ApplyThinking([]byte(`{"max_tokens":16000,"thinking":{"type":"disabled"}}`), modelID, "claude", "claude", "claude")
+	if err != nil {
+		t.Fatalf("ApplyThinking() error = %v", err)
+	}
+	if got := gjson.GetBytes(out, "thinking.type").String(); got != "disabled" {
+		t.Fatalf("thinking.type = %q, want body config to win over default, body=%s", got, out)
+	}
+
+	out, err = thinking.ApplyThinking([]byte(`{"max_tokens":16000}`), modelID+"(2048)", "claude", "claude", "claude")
+	if err != nil {
+		t.Fatalf("ApplyThinking() error = %v", err)
+	}
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 2048 {
+		t.Fatalf("thinking.budget_tokens = %d, want suffix 2048 to win over default, body=%s", got, out)
+	}
+}
@@ -0,0 +1,88 @@
+// Package openrouter implements thinking configuration for models served through OpenRouter.
+//
+// OpenRouter normalizes reasoning across upstream providers with a "reasoning" object that
+// accepts either an effort level or a max_tokens budget.
+package openrouter
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Applier implements thinking.ProviderApplier for OpenRouter.
+//
+// OpenRouter-specific behavior:
+//   - Budget: reasoning.max_tokens
+//   - Level: reasoning.effort
+//   - Disabled: reasoning.enabled=false
+//   - Auto: reasoning.enabled=true, letting the upstream pick its default effort
+//   - The OpenAI reasoning_effort field is always replaced by the reasoning object
+type Applier struct{}
+
+var _ thinking.ProviderApplier = (*Applier)(nil)
+
+// NewApplier creates a new OpenRouter thinking applier.
+func NewApplier() *Applier {
+	return &Applier{}
+}
+
+func init() {
+	thinking.RegisterProvider("openrouter", NewApplier())
+}
+
+// Apply applies thinking configuration to an OpenRouter chat completions request body.
+//
+// Expected output format:
+//
+//	{
+//	  "reasoning": {"effort": "high"}
+//	}
+//
+// or, for budgets:
+//
+//	{
+//	  "reasoning": {"max_tokens": 8192}
+//	}
+func (a *Applier) Apply(body []byte, config thinking.ThinkingConfig, modelInfo *registry.ModelInfo) ([]byte, error) {
+	if !thinking.IsUserDefinedModel(modelInfo) && modelInfo.Thinking == nil {
+		return body, nil
+	}
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		body = []byte(`{}`)
+	}
+
+	var reasoning string
+	switch config.Mode {
+	case thinking.ModeLevel:
+		if config.Level == "" {
+			return body, nil
+		}
+		reasoning, _ = sjson.Set(`{}`, "effort", string(config.Level))
+	case thinking.ModeBudget:
+		if config.Budget <= 0 {
+			return body, nil
+		}
+		reasoning, _ = sjson.Set(`{}`, "max_tokens", config.Budget)
+	case thinking.ModeNone:
+		// Respect clamped fallback level for models that cannot disable thinking.
+		if config.Level != "" && config.Level != thinking.LevelNone {
+			reasoning, _ = sjson.Set(`{}`, "effort", string(config.Level))
+			break
+		}
+		reasoning = `{"enabled":false}`
+	case thinking.ModeAuto:
+		reasoning = `{"enabled":true}`
+	default:
+		return body, nil
+	}
+
+	result, _ := sjson.DeleteBytes(body, "reasoning_effort")
+	// Keep reasoning options unrelated to the budget, such as exclude.
+	if exclude := gjson.GetBytes(result, "reasoning.exclude"); exclude.Exists() {
+		reasoning, _ = sjson.SetRaw(reasoning, "exclude", exclude.Raw)
+	}
+	result, _ = sjson.SetRawBytes(result, "reasoning", []byte(reasoning))
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package openrouter
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/tidwall/gjson"
+)
+
+func TestApply_MapsToReasoningObject(t *testing.T) {
+	applier := NewApplier()
+	modelInfo := &registry.ModelInfo{
+		ID:       "anthropic/claude-sonnet-4.5",
+		Thinking: &registry.ThinkingSupport{Min: 1024, Max: 32000, ZeroAllowed: true, DynamicAllowed: true, Levels: []string{"low", "medium", "high"}},
+	}
+	tests := []struct {
+		name   string
+		config thinking.ThinkingConfig
+		want   string
+	}{
+		{name: "budget", config: thinking.ThinkingConfig{Mode: thinking.ModeBudget, Budget: 8192}, want: `{"max_tokens":8192,"exclude":true}`},
+		{name: "level", config: thinking.ThinkingConfig{Mode: thinking.ModeLevel, Level: thinking.LevelHigh}, want: `{"effort":"high","exclude":true}`},
+		{name: "none", config: thinking.ThinkingConfig{Mode: thinking.ModeNone}, want: `{"enabled":false,"exclude":true}`},
+		{name: "auto", config: thinking.ThinkingConfig{Mode: thinking.ModeAuto, Budget: -1}, want: `{"enabled":true,"exclude":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`{"model":"anthropic/claude-sonnet-4.5","reasoning_effort":"low","reasoning":{"effort":"low","exclude":true}}`)
+			out, errApply := applier.Apply(body, tt.config, modelInfo)
+			if errApply != nil {
+				t.Fatalf("Apply() error = %v", errApply)
+			}
+			if got := gjson.GetBytes(out, "reasoning").Raw; got != tt.want {
+				t.Fatalf("reasoning = %s, want %s", got, tt.want)
+			}
+			if gjson.GetBytes(out, "reasoning_effort").Exists() {
+				t.Fatalf("reasoning_effort should be removed, body=%s", string(out))
+			}
+		})
+	}
+}
+
+func TestApply_ModelWithoutThinkingPassthrough(t *testing.T) {
+	body := []byte(`{"model":"openai/gpt-4o"}`)
+	out, errApply := NewApplier().Apply(body, thinking.ThinkingConfig{Mode: thinking.ModeLevel, Level: thinking.LevelHigh}, &registry.ModelInfo{ID: "openai/gpt-4o"})
+	if errApply != nil {
+		t.Fatalf("Apply() error = %v", errApply)
+	}
+	if string(out) != string(body) {
+		t.Fatalf("body = %s, want unchanged", out)
+	}
+}
@@ -44,6 +44,8 @@ func StripThinkingConfig(body []byte, provider string) []byte {
 		}
 	case "codex":
 		paths = []string{"reasoning.effort"}
+	case "openrouter":
+		paths = []string{"reasoning", "reasoning_effort"}
 	default:
 		return body
 	}
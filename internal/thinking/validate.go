@@ -89,11 +89,18 @@ func ValidateConfig(config ThinkingConfig, modelInfo *registry.ModelInfo, fromFo
 			if !ok {
 				return nil, NewThinkingError(ErrUnknownLevel, fmt.Sprintf("budget %d cannot be converted to a valid level", config.Budget))
 			}
+			originalBudget := config.Budget
 			// When converting Budget -> Level for level-only models, clamp the derived standard level
 			// to the nearest supported level. Special values (none/auto) are preserved.
 			config.Mode = ModeLevel
 			config.Level = clampLevel(ThinkingLevel(level), modelInfo, toFormat)
 			config.Budget = 0
+			log.WithFields(log.Fields{
+				"provider":       toFormat,
+				"model":          model,
+				"original_value": originalBudget,
+				"converted_to":   config.Level,
+			}).Debug("thinking: budget converted to level for level-only model |")
 		}
 	case CapabilityHybrid:
 	}
@@ -357,7 +364,7 @@ func isGeminiFamily(provider string) bool {
 
 func isOpenAIFamily(provider string) bool {
 	switch provider {
-	case "openai", "openai-response", "codex":
+	case "openai", "openai-response", "codex", "openrouter":
 		return true
 	default:
 		return false
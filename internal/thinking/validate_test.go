@@ -0,0 +1,46 @@
+package thinking_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestValidateConfig_BudgetToLevelLogsClampedLevel(t *testing.T) {
+	logger := log.StandardLogger()
+	previousOutput := logger.Out
+	previousLevel := logger.Level
+	buffer := &bytes.Buffer{}
+	log.SetOutput(buffer)
+	log.SetLevel(log.DebugLevel)
+	t.Cleanup(func() {
+		log.SetOutput(previousOutput)
+		log.SetLevel(previousLevel)
+	})
+
+	modelInfo := &registry.ModelInfo{
+		ID:       "level-only-model",
+		Thinking: &registry.ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+	}
+	config := thinking.ThinkingConfig{Mode: thinking.ModeBudget, Budget: 100000}
+
+	got, err := thinking.ValidateConfig(config, modelInfo, "claude", "openai", false)
+	if err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	if got.Mode != thinking.ModeLevel || got.Level != thinking.LevelHigh {
+		t.Fatalf("ValidateConfig() = %+v, want level %q", got, thinking.LevelHigh)
+	}
+
+	output := buffer.String()
+	if !strings.Contains(output, "converted_to=high") {
+		t.Fatalf("expected log to report the clamped level, got: %q", output)
+	}
+	if !strings.Contains(output, "original_value=100000") {
+		t.Fatalf("expected log to report the original budget, got: %q", output)
+	}
+}
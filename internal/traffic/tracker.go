@@ -0,0 +1,158 @@
+// Package traffic keeps a lightweight, in-memory view of live proxy traffic: requests in
+// flight, active streams, a rolling request rate and the most recent failed requests.
+package traffic
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// rateWindowSeconds is the span of the rolling request counter.
+	rateWindowSeconds = 60
+	// maxRecentErrors bounds the recent error ring.
+	maxRecentErrors = 50
+)
+
+// ErrorRecord describes one failed client request.
+type ErrorRecord struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StatusCode int       `json:"status_code"`
+	Message    string    `json:"message,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// Snapshot is a point-in-time view of the tracker counters.
+type Snapshot struct {
+	InFlight      int64   `json:"in_flight"`
+	ActiveStreams int64   `json:"active_streams"`
+	TotalRequests int64   `json:"total_requests"`
+	TotalErrors   int64   `json:"total_errors"`
+	QPS10s        float64 `json:"qps_10s"`
+	QPS60s        float64 `json:"qps_60s"`
+}
+
+// Tracker aggregates live traffic counters. The zero value is not usable; use NewTracker.
+type Tracker struct {
+	inFlight      atomic.Int64
+	activeStreams atomic.Int64
+	totalRequests atomic.Int64
+	totalErrors   atomic.Int64
+
+	mu      sync.Mutex
+	buckets [rateWindowSeconds]rateBucket
+	errors  []ErrorRecord
+	next    int
+
+	now func() time.Time
+}
+
+type rateBucket struct {
+	second int64
+	count  int64
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{now: time.Now}
+}
+
+var defaultTracker = NewTracker()
+
+// GetTracker returns the process-wide traffic tracker.
+func GetTracker() *Tracker { return defaultTracker }
+
+// BeginRequest counts a new request as in flight and in the rolling rate. Every call must be
+// paired with EndRequest.
+func (t *Tracker) BeginRequest() {
+	t.inFlight.Add(1)
+	t.totalRequests.Add(1)
+	second := t.now().Unix()
+	t.mu.Lock()
+	bucket := &t.buckets[second%rateWindowSeconds]
+	if bucket.second != second {
+		bucket.second, bucket.count = second, 0
+	}
+	bucket.count++
+	t.mu.Unlock()
+}
+
+// EndRequest marks a request as finished and records it as a recent error when its status is
+// 400 or higher.
+func (t *Tracker) EndRequest(method, path string, status int, message string, duration time.Duration) {
+	t.inFlight.Add(-1)
+	if status < 400 {
+		return
+	}
+	t.totalErrors.Add(1)
+	record := ErrorRecord{
+		Time:       t.now(),
+		Method:     method,
+		Path:       path,
+		StatusCode: status,
+		Message:    message,
+		DurationMs: duration.Milliseconds(),
+	}
+	t.mu.Lock()
+	if len(t.errors) < maxRecentErrors {
+		t.errors = append(t.errors, record)
+	} else {
+		t.errors[t.next] = record
+	}
+	t.next = (t.next + 1) % maxRecentErrors
+	t.mu.Unlock()
+}
+
+// BeginStream counts an active streaming response. The returned function ends it and is safe
+// to call more than once.
+func (t *Tracker) BeginStream() func() {
+	t.activeStreams.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { t.activeStreams.Add(-1) })
+	}
+}
+
+// Snapshot returns the current counters and rolling request rates.
+func (t *Tracker) Snapshot() Snapshot {
+	now := t.now().Unix()
+	var last10, last60 int64
+	t.mu.Lock()
+	for _, bucket := range t.buckets {
+		age := now - bucket.second
+		if age < 0 || age >= rateWindowSeconds {
+			continue
+		}
+		last60 += bucket.count
+		if age < 10 {
+			last10 += bucket.count
+		}
+	}
+	t.mu.Unlock()
+	return Snapshot{
+		InFlight:      t.inFlight.Load(),
+		ActiveStreams: t.activeStreams.Load(),
+		TotalRequests: t.totalRequests.Load(),
+		TotalErrors:   t.totalErrors.Load(),
+		QPS10s:        float64(last10) / 10,
+		QPS60s:        float64(last60) / rateWindowSeconds,
+	}
+}
+
+// RecentErrors returns the most recent failed requests, newest first.
+func (t *Tracker) RecentErrors() []ErrorRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]ErrorRecord, 0, len(t.errors))
+	for i := 1; i <= len(t.errors); i++ {
+		out = append(out, t.errors[(t.next-i+len(t.errors))%len(t.errors)])
+	}
+	return out
+}
+
+// ErrorMessageKey is the gin context key handlers set to the error text they returned, so the
+// traffic middleware can attach it to the recent error record.
+const ErrorMessageKey = "TRAFFIC_ERROR_MESSAGE"
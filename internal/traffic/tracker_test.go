@@ -0,0 +1,60 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerCountsRequestsAndStreams(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+
+	for i := 0; i < 20; i++ {
+		tracker.BeginRequest()
+	}
+	now = now.Add(30 * time.Second)
+	for i := 0; i < 10; i++ {
+		tracker.BeginRequest()
+	}
+	endStream := tracker.BeginStream()
+	for i := 0; i < 29; i++ {
+		tracker.EndRequest("POST", "/v1/chat/completions", 200, "", time.Millisecond)
+	}
+
+	snapshot := tracker.Snapshot()
+	if snapshot.InFlight != 1 || snapshot.ActiveStreams != 1 || snapshot.TotalRequests != 30 {
+		t.Fatalf("snapshot = %+v", snapshot)
+	}
+	if snapshot.QPS10s != 1 || snapshot.QPS60s != 0.5 {
+		t.Fatalf("qps = %v/%v, want 1/0.5", snapshot.QPS10s, snapshot.QPS60s)
+	}
+	endStream()
+	endStream()
+	if got := tracker.Snapshot().ActiveStreams; got != 0 {
+		t.Fatalf("active streams = %d, want 0", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if snapshot = tracker.Snapshot(); snapshot.QPS60s != 0 {
+		t.Fatalf("qps_60s after idle = %v, want 0", snapshot.QPS60s)
+	}
+}
+
+func TestTrackerKeepsNewestErrors(t *testing.T) {
+	tracker := NewTracker()
+	for i := 0; i < maxRecentErrors+5; i++ {
+		tracker.BeginRequest()
+		tracker.EndRequest("POST", "/v1/messages", 400+i%100, "", 0)
+	}
+	errors := tracker.RecentErrors()
+	if len(errors) != maxRecentErrors {
+		t.Fatalf("errors = %d, want %d", len(errors), maxRecentErrors)
+	}
+	if errors[0].StatusCode != 400+maxRecentErrors+4 || errors[len(errors)-1].StatusCode != 405 {
+		t.Fatalf("order = %d..%d", errors[0].StatusCode, errors[len(errors)-1].StatusCode)
+	}
+	if got := tracker.Snapshot().TotalErrors; got != maxRecentErrors+5 {
+		t.Fatalf("total errors = %d", got)
+	}
+}
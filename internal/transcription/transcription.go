@@ -0,0 +1,123 @@
+// Package transcription turns audio into text through an OpenAI-compatible speech-to-text
+// endpoint, so audio input can be forwarded to providers that only accept text.
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultBaseURL = "https://api.openai.com/v1"
+	defaultModel   = "whisper-1"
+	defaultTimeout = 60 * time.Second
+)
+
+// Settings configures the speech-to-text backend.
+type Settings struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	// Language is an optional ISO-639-1 hint passed to the backend.
+	Language string
+	Timeout  time.Duration
+	// Client overrides the HTTP client used for transcription calls.
+	Client *http.Client
+}
+
+var active atomic.Pointer[Settings]
+
+// SetSettings replaces the active transcription settings; nil disables transcription.
+func SetSettings(settings *Settings) {
+	active.Store(settings)
+}
+
+// Active returns the active settings, or nil when transcription is disabled.
+func Active() *Settings {
+	return active.Load()
+}
+
+// Transcribe decodes base64 audio in the given format (for example "wav" or "mp3") and returns
+// its transcript from {base-url}/audio/transcriptions.
+func (s *Settings) Transcribe(ctx context.Context, audioBase64, format string) (string, error) {
+	audio, errDecode := base64.StdEncoding.DecodeString(audioBase64)
+	if errDecode != nil {
+		return "", fmt.Errorf("invalid base64 audio: %w", errDecode)
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = "wav"
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(s.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	model := strings.TrimSpace(s.Model)
+	if model == "" {
+		model = defaultModel
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	filePart, errCreate := writer.CreateFormFile("file", "audio."+format)
+	if errCreate != nil {
+		return "", errCreate
+	}
+	if _, errWrite := filePart.Write(audio); errWrite != nil {
+		return "", errWrite
+	}
+	_ = writer.WriteField("model", model)
+	_ = writer.WriteField("response_format", "json")
+	if s.Language != "" {
+		_ = writer.WriteField("language", s.Language)
+	}
+	if errClose := writer.Close(); errClose != nil {
+		return "", errClose
+	}
+
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/audio/transcriptions", &body)
+	if errReq != nil {
+		return "", errReq
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return "", fmt.Errorf("transcription request failed: %w", errDo)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, errRead := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if errRead != nil {
+		return "", fmt.Errorf("transcription response read failed: %w", errRead)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return "", fmt.Errorf("transcription request failed with status %d", resp.StatusCode)
+	}
+	text := gjson.GetBytes(respBody, "text")
+	if !text.Exists() {
+		return "", fmt.Errorf("transcription response has no text")
+	}
+	return strings.TrimSpace(text.String()), nil
+}
@@ -0,0 +1,59 @@
+package transcription
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTranscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			t.Errorf("path = %s, want /v1/audio/transcriptions", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		file, header, errFile := r.FormFile("file")
+		if errFile != nil {
+			t.Errorf("FormFile: %v", errFile)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "audio.mp3" || string(data) != "sound" {
+			t.Errorf("file = %s %q", header.Filename, data)
+		}
+		if got := r.FormValue("model"); got != "whisper-1" {
+			t.Errorf("model = %q, want whisper-1", got)
+		}
+		_, _ = w.Write([]byte(`{"text":" hello there "}`))
+	}))
+	defer server.Close()
+
+	settings := &Settings{BaseURL: server.URL + "/v1/", APIKey: "key"}
+	text, err := settings.Transcribe(context.Background(), base64.StdEncoding.EncodeToString([]byte("sound")), "MP3")
+	if err != nil {
+		t.Fatalf("Transcribe: %v", err)
+	}
+	if text != "hello there" {
+		t.Fatalf("text = %q, want hello there", text)
+	}
+}
+
+func TestTranscribeErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	settings := &Settings{BaseURL: server.URL}
+	if _, err := settings.Transcribe(context.Background(), "!!", "wav"); err == nil {
+		t.Fatal("expected invalid base64 error")
+	}
+	if _, err := settings.Transcribe(context.Background(), base64.StdEncoding.EncodeToString([]byte("x")), "wav"); err == nil {
+		t.Fatal("expected backend status error")
+	}
+}
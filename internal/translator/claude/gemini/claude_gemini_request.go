@@ -7,13 +7,10 @@ package gemini
 
 import (
 	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"math/big"
 	"strings"
 
-	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -21,12 +18,6 @@ import (
 	"github.com/tidwall/sjson"
 )
 
-var (
-	user    = ""
-	account = ""
-	session = ""
-)
-
 // ConvertGeminiRequestToClaude parses and transforms a Gemini API request into Claude Code API format.
 // It extracts the model name, system instruction, message contents, and tool declarations
 // from the raw JSON request and returns them in the format expected by the Claude Code API.
@@ -48,22 +39,8 @@ var (
 func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream bool) []byte {
 	rawJSON := inputRawJSON
 
-	if account == "" {
-		u, _ := uuid.NewRandom()
-		account = u.String()
-	}
-	if session == "" {
-		u, _ := uuid.NewRandom()
-		session = u.String()
-	}
-	if user == "" {
-		sum := sha256.Sum256([]byte(account + session))
-		user = hex.EncodeToString(sum[:])
-	}
-	userID := fmt.Sprintf("user_%s_account_%s_session_%s", user, account, session)
-
 	// Base Claude message payload
-	out := []byte(fmt.Sprintf(`{"model":"","max_tokens":32000,"messages":[],"metadata":{"user_id":"%s"}}`, userID))
+	out := []byte(`{"model":"","max_tokens":32000,"messages":[]}`)
 
 	root := gjson.ParseBytes(rawJSON)
 
@@ -305,10 +282,18 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 						return true
 					}
 
-					// Image content (inline_data) conversion to Claude Code format
-					if inlineData := part.Get("inline_data"); inlineData.Exists() {
+					// Image content (inlineData / inline_data) conversion to Claude Code format
+					inlineData := part.Get("inlineData")
+					if !inlineData.Exists() {
+						inlineData = part.Get("inline_data")
+					}
+					if inlineData.Exists() {
 						imageContent := []byte(`{"type":"image","source":{"type":"base64","media_type":"","data":""}}`)
-						if mimeType := inlineData.Get("mime_type"); mimeType.Exists() {
+						mimeType := inlineData.Get("mimeType")
+						if !mimeType.Exists() {
+							mimeType = inlineData.Get("mime_type")
+						}
+						if mimeType.Exists() {
 							imageContent, _ = sjson.SetBytes(imageContent, "source.media_type", mimeType.String())
 						}
 						if data := inlineData.Get("data"); data.Exists() {
@@ -319,11 +304,23 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 					}
 
 					// File data conversion to text content with file info
-					if fileData := part.Get("file_data"); fileData.Exists() {
+					fileData := part.Get("fileData")
+					if !fileData.Exists() {
+						fileData = part.Get("file_data")
+					}
+					if fileData.Exists() {
 						// For file data, we'll convert to text content with file info
 						textContent := []byte(`{"type":"text","text":""}`)
-						fileInfo := "File: " + fileData.Get("file_uri").String()
-						if mimeType := fileData.Get("mime_type"); mimeType.Exists() {
+						fileURI := fileData.Get("fileUri")
+						if !fileURI.Exists() {
+							fileURI = fileData.Get("file_uri")
+						}
+						fileInfo := "File: " + fileURI.String()
+						mimeType := fileData.Get("mimeType")
+						if !mimeType.Exists() {
+							mimeType = fileData.Get("mime_type")
+						}
+						if mimeType.Exists() {
 							fileInfo += " (Type: " + mimeType.String() + ")"
 						}
 						textContent, _ = sjson.SetBytes(textContent, "text", fileInfo)
@@ -0,0 +1,51 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiRequestToClaude_InlineDataImage(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "camelCase",
+			input: `{"contents":[{"role":"user","parts":[{"text":"describe"},{"inlineData":{"mimeType":"image/png","data":"aGVsbG8="}}]}]}`,
+		},
+		{
+			name:  "snake_case",
+			input: `{"contents":[{"role":"user","parts":[{"text":"describe"},{"inline_data":{"mime_type":"image/png","data":"aGVsbG8="}}]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := ConvertGeminiRequestToClaude("claude-sonnet-4-5", []byte(tt.input), false)
+			image := gjson.GetBytes(out, "messages.0.content.1")
+			if image.Get("type").String() != "image" {
+				t.Fatalf("expected image content part, got %s", gjson.GetBytes(out, "messages").Raw)
+			}
+			if got := image.Get("source.media_type").String(); got != "image/png" {
+				t.Fatalf("media_type = %q, want image/png", got)
+			}
+			if got := image.Get("source.data").String(); got != "aGVsbG8=" {
+				t.Fatalf("data = %q, want aGVsbG8=", got)
+			}
+		})
+	}
+}
+
+func TestConvertGeminiRequestToClaude_FunctionDeclarations(t *testing.T) {
+	input := []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}],"tools":[{"functionDeclarations":[{"name":"get_weather","description":"Weather lookup","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}]}]}`)
+
+	out := ConvertGeminiRequestToClaude("claude-sonnet-4-5", input, false)
+
+	if got := gjson.GetBytes(out, "tools.0.name").String(); got != "get_weather" {
+		t.Fatalf("tool name = %q, want get_weather", got)
+	}
+	if !gjson.GetBytes(out, "tools.0.input_schema.properties.city").Exists() {
+		t.Fatalf("expected parameters mapped to input_schema, got %s", gjson.GetBytes(out, "tools").Raw)
+	}
+}
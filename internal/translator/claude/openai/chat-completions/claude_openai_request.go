@@ -6,26 +6,16 @@
 package chat_completions
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"math/big"
 	"strings"
 
-	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
-var (
-	user    = ""
-	account = ""
-	session = ""
-)
-
 // ConvertOpenAIRequestToClaude parses and transforms an OpenAI Chat Completions API request into Claude Code API format.
 // It extracts the model name, system instruction, message contents, and tool declarations
 // from the raw JSON request and returns them in the format expected by the Claude Code API.
@@ -33,9 +23,12 @@ var (
 // 1. Model name mapping and parameter extraction (max_tokens, temperature, top_p, etc.)
 // 2. Message content conversion from OpenAI to Claude Code format
 // 3. Tool call and tool result handling with proper ID mapping
-// 4. Image data conversion from OpenAI data URLs to Claude Code base64 format
+// 4. Image data conversion from OpenAI data URLs to Claude Code base64 format, and file parts to document blocks
 // 5. Stop sequence and streaming configuration handling
 //
+// The conversion runs the stages of CurrentRequestPipeline, so deployments can disable or
+// replace individual steps.
+//
 // Parameters:
 //   - modelName: The name of the model to use for the request
 //   - rawJSON: The raw JSON request data from the OpenAI API
@@ -44,26 +37,53 @@ var (
 // Returns:
 //   - []byte: The transformed request data in Claude Code API format
 func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream bool) []byte {
-	rawJSON := inputRawJSON
+	return CurrentRequestPipeline().Run(modelName, inputRawJSON, stream)
+}
+
+// convertOpenAIParamsToClaude is the params stage: model, sampling parameters, stop sequences and streaming.
+func convertOpenAIParamsToClaude(s *RequestState) {
+	root, out, modelName, stream := s.Root, s.Out, s.ModelName, s.Stream
+
+	// Model mapping to specify which Claude Code model to use
+	out, _ = sjson.SetBytes(out, "model", modelName)
 
-	if account == "" {
-		u, _ := uuid.NewRandom()
-		account = u.String()
+	// Max tokens configuration with fallback to default value
+	if maxTokens := root.Get("max_tokens"); maxTokens.Exists() {
+		out, _ = sjson.SetBytes(out, "max_tokens", maxTokens.Int())
 	}
-	if session == "" {
-		u, _ := uuid.NewRandom()
-		session = u.String()
+
+	// Temperature setting for controlling response randomness
+	if temp := root.Get("temperature"); temp.Exists() {
+		out, _ = sjson.SetBytes(out, "temperature", temp.Float())
+	} else if topP := root.Get("top_p"); topP.Exists() {
+		// Top P setting for nucleus sampling (filtered out if temperature is set)
+		out, _ = sjson.SetBytes(out, "top_p", topP.Float())
 	}
-	if user == "" {
-		sum := sha256.Sum256([]byte(account + session))
-		user = hex.EncodeToString(sum[:])
+
+	// Stop sequences configuration for custom termination conditions
+	if stop := root.Get("stop"); stop.Exists() {
+		if stop.IsArray() {
+			var stopSequences []string
+			stop.ForEach(func(_, value gjson.Result) bool {
+				stopSequences = append(stopSequences, value.String())
+				return true
+			})
+			if len(stopSequences) > 0 {
+				out, _ = sjson.SetBytes(out, "stop_sequences", stopSequences)
+			}
+		} else {
+			out, _ = sjson.SetBytes(out, "stop_sequences", []string{stop.String()})
+		}
 	}
-	userID := fmt.Sprintf("user_%s_account_%s_session_%s", user, account, session)
 
-	// Base Claude Code API template with default max_tokens value
-	out := []byte(fmt.Sprintf(`{"model":"","max_tokens":32000,"messages":[],"metadata":{"user_id":"%s"}}`, userID))
+	// Stream configuration to enable or disable streaming responses
+	out, _ = sjson.SetBytes(out, "stream", stream)
+	s.Out = out
+}
 
-	root := gjson.ParseBytes(rawJSON)
+// convertOpenAIReasoningToClaude is the thinking stage: reasoning_effort becomes the Claude thinking config.
+func convertOpenAIReasoningToClaude(s *RequestState) {
+	root, out, modelName := s.Root, s.Out, s.ModelName
 
 	// Convert OpenAI reasoning_effort to Claude thinking config.
 	if v := root.Get("reasoning_effort"); v.Exists() {
@@ -113,175 +133,173 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 			}
 		}
 	}
+	s.Out = out
+}
 
-	// Helper for generating tool call IDs in the form: toolu_<alphanum>
-	// This ensures unique identifiers for tool calls in the Claude Code format
-	genToolCallID := func() string {
-		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		var b strings.Builder
-		// 24 chars random suffix for uniqueness
-		for i := 0; i < 24; i++ {
-			n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-			b.WriteByte(letters[n.Int64()])
-		}
-		return "toolu_" + b.String()
+// convertOpenAIMessagesToClaude is the messages stage: system prompts, conversation turns, tool calls
+// and tool results. The system and messages arrays are assembled in buffers and set once, so
+// long conversations are not rewritten for every message.
+func convertOpenAIMessagesToClaude(s *RequestState) {
+	messages := s.Root.Get("messages")
+	if !messages.Exists() || !messages.IsArray() {
+		return
 	}
 
-	// Model mapping to specify which Claude Code model to use
-	out, _ = sjson.SetBytes(out, "model", modelName)
-
-	// Max tokens configuration with fallback to default value
-	if maxTokens := root.Get("max_tokens"); maxTokens.Exists() {
-		out, _ = sjson.SetBytes(out, "max_tokens", maxTokens.Int())
-	}
+	// Tool call IDs are mapped deterministically so resent conversations keep stable IDs.
+	toolIDs := translatorcommon.NewClaudeToolIDs()
+	toolCallPosition := 0
+
+	var system, claudeMessages, content translatorcommon.JSONArray
+	claudeMessages.Grow(len(messages.Raw))
+	messages.ForEach(func(_, message gjson.Result) bool {
+		role := message.Get("role").String()
+		contentResult := message.Get("content")
+
+		switch role {
+		case "system":
+			if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
+				system.Append(translatorcommon.ClaudeTextBlock(contentResult.String()))
+			} else if contentResult.Exists() && contentResult.IsArray() {
+				contentResult.ForEach(func(_, part gjson.Result) bool {
+					if part.Get("type").String() == "text" {
+						if control := part.Get("cache_control"); control.Exists() {
+							s.MarkCacheControl(fmt.Sprintf("system.%d", system.Len()), control)
+						}
+						system.Append(translatorcommon.ClaudeTextBlock(part.Get("text").String()))
+					}
+					return true
+				})
+			}
+		case "user", "assistant":
+			content.Reset()
+
+			// Handle content based on its type (string or array)
+			if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
+				text := contentResult.String()
+				content.AppendFunc(func(dst []byte) []byte { return translatorcommon.AppendClaudeTextBlock(dst, text) })
+			} else if contentResult.Exists() && contentResult.IsArray() {
+				contentResult.ForEach(func(_, part gjson.Result) bool {
+					index := content.Len()
+					if part.Get("type").String() == "text" {
+						text := part.Get("text").String()
+						content.AppendFunc(func(dst []byte) []byte { return translatorcommon.AppendClaudeTextBlock(dst, text) })
+					} else if claudePart := convertOpenAIContentPartToClaudePart(part); claudePart != "" {
+						content.Append([]byte(claudePart))
+					}
+					if control := part.Get("cache_control"); control.Exists() && content.Len() > index {
+						s.MarkCacheControl(fmt.Sprintf("messages.%d.content.%d", claudeMessages.Len(), index), control)
+					}
+					return true
+				})
+			}
 
-	// Temperature setting for controlling response randomness
-	if temp := root.Get("temperature"); temp.Exists() {
-		out, _ = sjson.SetBytes(out, "temperature", temp.Float())
-	} else if topP := root.Get("top_p"); topP.Exists() {
-		// Top P setting for nucleus sampling (filtered out if temperature is set)
-		out, _ = sjson.SetBytes(out, "top_p", topP.Float())
-	}
+			// Handle tool calls (for assistant messages)
+			if toolCalls := message.Get("tool_calls"); toolCalls.Exists() && toolCalls.IsArray() && role == "assistant" {
+				toolCalls.ForEach(func(_, toolCall gjson.Result) bool {
+					if toolCall.Get("type").String() == "function" {
+						function := toolCall.Get("function")
+						name := function.Get("name").String()
+						toolCallID := toolIDs.ToolUse(toolCall.Get("id").String(), name, toolCallPosition)
+						toolCallPosition++
+						content.AppendFunc(func(dst []byte) []byte {
+							return appendClaudeToolUseBlock(dst, toolCallID, translatorcommon.ClaudeToolName(name), function.Get("arguments"))
+						})
+					}
+					return true
+				})
+			}
 
-	// Stop sequences configuration for custom termination conditions
-	if stop := root.Get("stop"); stop.Exists() {
-		if stop.IsArray() {
-			var stopSequences []string
-			stop.ForEach(func(_, value gjson.Result) bool {
-				stopSequences = append(stopSequences, value.String())
-				return true
-			})
-			if len(stopSequences) > 0 {
-				out, _ = sjson.SetBytes(out, "stop_sequences", stopSequences)
+			claudeMessages.AppendFunc(func(dst []byte) []byte { return appendClaudeMessage(dst, role, content.Bytes()) })
+
+		case "tool":
+			// Handle tool result messages conversion
+			toolCallID := message.Get("tool_call_id").String()
+			toolResultContent, toolResultContentRaw := convertOpenAIToolResultContent(contentResult)
+			claudeToolID, known := toolIDs.ToolResult(toolCallID)
+			if !known {
+				// Claude rejects tool results without a matching tool_use, so keep the output as user content.
+				claudeMessages.Append(orphanToolResultMessage(toolCallID, toolResultContent, toolResultContentRaw))
+				break
 			}
-		} else {
-			out, _ = sjson.SetBytes(out, "stop_sequences", []string{stop.String()})
+			claudeMessages.AppendFunc(func(dst []byte) []byte {
+				dst = append(dst, `{"role":"user","content":`...)
+				dst = appendClaudeToolResultBlocks(dst, claudeToolID, toolResultContent, toolResultContentRaw)
+				return append(dst, '}')
+			})
 		}
-	}
-
-	// Stream configuration to enable or disable streaming responses
-	out, _ = sjson.SetBytes(out, "stream", stream)
-
-	// Process messages and transform them to Claude Code format
-	if messages := root.Get("messages"); messages.Exists() && messages.IsArray() {
-		messageIndex := 0
-		messages.ForEach(func(_, message gjson.Result) bool {
-			role := message.Get("role").String()
-			contentResult := message.Get("content")
-
-			switch role {
-			case "system":
-				if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
-					textPart := []byte(`{"type":"text","text":""}`)
-					textPart, _ = sjson.SetBytes(textPart, "text", contentResult.String())
-					out, _ = sjson.SetRawBytes(out, "system.-1", textPart)
-				} else if contentResult.Exists() && contentResult.IsArray() {
-					contentResult.ForEach(func(_, part gjson.Result) bool {
-						if part.Get("type").String() == "text" {
-							textPart := []byte(`{"type":"text","text":""}`)
-							textPart, _ = sjson.SetBytes(textPart, "text", part.Get("text").String())
-							out, _ = sjson.SetRawBytes(out, "system.-1", textPart)
-						}
-						return true
-					})
-				}
-			case "user", "assistant":
-				msg := []byte(`{"role":"","content":[]}`)
-				msg, _ = sjson.SetBytes(msg, "role", role)
-
-				// Handle content based on its type (string or array)
-				if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
-					part := []byte(`{"type":"text","text":""}`)
-					part, _ = sjson.SetBytes(part, "text", contentResult.String())
-					msg, _ = sjson.SetRawBytes(msg, "content.-1", part)
-				} else if contentResult.Exists() && contentResult.IsArray() {
-					contentResult.ForEach(func(_, part gjson.Result) bool {
-						claudePart := convertOpenAIContentPartToClaudePart(part)
-						if claudePart != "" {
-							msg, _ = sjson.SetRawBytes(msg, "content.-1", []byte(claudePart))
-						}
-						return true
-					})
-				}
+		return true
+	})
 
-				// Handle tool calls (for assistant messages)
-				if toolCalls := message.Get("tool_calls"); toolCalls.Exists() && toolCalls.IsArray() && role == "assistant" {
-					toolCalls.ForEach(func(_, toolCall gjson.Result) bool {
-						if toolCall.Get("type").String() == "function" {
-							toolCallID := toolCall.Get("id").String()
-							if toolCallID == "" {
-								toolCallID = genToolCallID()
-							}
-
-							function := toolCall.Get("function")
-							toolUse := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
-							toolUse, _ = sjson.SetBytes(toolUse, "id", toolCallID)
-							toolUse, _ = sjson.SetBytes(toolUse, "name", function.Get("name").String())
-
-							// Parse arguments for the tool call
-							if args := function.Get("arguments"); args.Exists() {
-								argsStr := args.String()
-								if argsStr != "" && gjson.Valid(argsStr) {
-									argsJSON := gjson.Parse(argsStr)
-									if argsJSON.IsObject() {
-										toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte(argsJSON.Raw))
-									} else {
-										toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-									}
-								} else {
-									toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-								}
-							} else {
-								toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-							}
-
-							msg, _ = sjson.SetRawBytes(msg, "content.-1", toolUse)
-						}
-						return true
-					})
-				}
+	// Preserve a minimal conversational turn for system-only inputs.
+	// Claude payloads with top-level system instructions but no messages are risky for downstream validation.
+	if claudeMessages.Len() == 0 && system.Len() > 0 {
+		claudeMessages.Append([]byte(`{"role":"user","content":[{"type":"text","text":""}]}`))
+	}
+	if system.Len() > 0 {
+		s.Out, _ = sjson.SetRawBytes(s.Out, "system", system.Bytes())
+	}
+	if claudeMessages.Len() > 0 {
+		s.Out, _ = sjson.SetRawBytes(s.Out, "messages", claudeMessages.Bytes())
+	}
+}
 
-				out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
-				messageIndex++
-
-			case "tool":
-				// Handle tool result messages conversion
-				toolCallID := message.Get("tool_call_id").String()
-				toolContentResult := message.Get("content")
-
-				msg := []byte(`{"role":"user","content":[{"type":"tool_result","tool_use_id":"","content":""}]}`)
-				msg, _ = sjson.SetBytes(msg, "content.0.tool_use_id", toolCallID)
-				toolResultContent, toolResultContentRaw := convertOpenAIToolResultContent(toolContentResult)
-				if toolResultContentRaw {
-					msg, _ = sjson.SetRawBytes(msg, "content.0.content", []byte(toolResultContent))
-				} else {
-					msg, _ = sjson.SetBytes(msg, "content.0.content", toolResultContent)
-				}
-				out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
-				messageIndex++
-			}
-			return true
-		})
+// appendClaudeMessage appends a Claude message with the given raw content array to dst.
+func appendClaudeMessage(dst []byte, role string, content []byte) []byte {
+	dst = append(dst, `{"role":`...)
+	dst = gjson.AppendJSONString(dst, role)
+	dst = append(dst, `,"content":`...)
+	dst = append(dst, content...)
+	return append(dst, '}')
+}
 
-		// Preserve a minimal conversational turn for system-only inputs.
-		// Claude payloads with top-level system instructions but no messages are risky for downstream validation.
-		if messageIndex == 0 {
-			system := gjson.GetBytes(out, "system")
-			if system.Exists() && system.IsArray() && len(system.Array()) > 0 {
-				fallbackMsg := []byte(`{"role":"user","content":[{"type":"text","text":""}]}`)
-				out, _ = sjson.SetRawBytes(out, "messages.-1", fallbackMsg)
-			}
+// appendClaudeToolUseBlock appends a tool_use block to dst. Arguments that are not a JSON
+// object become an empty input.
+func appendClaudeToolUseBlock(dst []byte, id, name string, arguments gjson.Result) []byte {
+	input := "{}"
+	if argsStr := arguments.String(); argsStr != "" && gjson.Valid(argsStr) {
+		if argsJSON := gjson.Parse(argsStr); argsJSON.IsObject() {
+			input = argsJSON.Raw
 		}
 	}
+	dst = append(dst, `{"type":"tool_use","id":`...)
+	dst = gjson.AppendJSONString(dst, id)
+	dst = append(dst, `,"name":`...)
+	dst = gjson.AppendJSONString(dst, name)
+	dst = append(dst, `,"input":`...)
+	dst = append(dst, input...)
+	return append(dst, '}')
+}
+
+// appendClaudeToolResultBlocks appends a content array holding one tool_result block to dst.
+func appendClaudeToolResultBlocks(dst []byte, toolUseID, content string, raw bool) []byte {
+	dst = append(dst, `[{"type":"tool_result","tool_use_id":`...)
+	dst = gjson.AppendJSONString(dst, toolUseID)
+	dst = append(dst, `,"content":`...)
+	if raw {
+		dst = append(dst, content...)
+	} else {
+		dst = gjson.AppendJSONString(dst, content)
+	}
+	return append(dst, `}]`...)
+}
+
+// convertOpenAIToolsToClaude is the tools stage: tool declarations and tool_choice.
+func convertOpenAIToolsToClaude(s *RequestState) {
+	root, out := s.Root, s.Out
 
 	// Tools mapping: OpenAI tools -> Claude Code tools
-	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() && len(tools.Array()) > 0 {
-		hasAnthropicTools := false
+	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() {
+		var claudeTools translatorcommon.JSONArray
 		tools.ForEach(func(_, tool gjson.Result) bool {
-			if tool.Get("type").String() == "function" {
+			if serverTool, ok := translatorcommon.ClaudeServerToolFromOpenAI(tool); ok {
+				if control := tool.Get("cache_control"); control.Exists() {
+					s.MarkCacheControl(fmt.Sprintf("tools.%d", claudeTools.Len()), control)
+				}
+				claudeTools.Append(serverTool)
+			} else if tool.Get("type").String() == "function" {
 				function := tool.Get("function")
 				anthropicTool := []byte(`{"name":"","description":""}`)
-				anthropicTool, _ = sjson.SetBytes(anthropicTool, "name", function.Get("name").String())
+				anthropicTool, _ = sjson.SetBytes(anthropicTool, "name", translatorcommon.ClaudeToolName(function.Get("name").String()))
 				anthropicTool, _ = sjson.SetBytes(anthropicTool, "description", function.Get("description").String())
 
 				// Convert parameters schema for the tool
@@ -291,14 +309,16 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 					anthropicTool, _ = sjson.SetRawBytes(anthropicTool, "input_schema", []byte(parameters.Raw))
 				}
 
-				out, _ = sjson.SetRawBytes(out, "tools.-1", anthropicTool)
-				hasAnthropicTools = true
+				if control := tool.Get("cache_control"); control.Exists() {
+					s.MarkCacheControl(fmt.Sprintf("tools.%d", claudeTools.Len()), control)
+				}
+				claudeTools.Append(anthropicTool)
 			}
 			return true
 		})
 
-		if !hasAnthropicTools {
-			out, _ = sjson.DeleteBytes(out, "tools")
+		if claudeTools.Len() > 0 {
+			out, _ = sjson.SetRawBytes(out, "tools", claudeTools.Bytes())
 		}
 	}
 
@@ -320,40 +340,43 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 			if toolChoice.Get("type").String() == "function" {
 				functionName := toolChoice.Get("function.name").String()
 				toolChoiceJSON := []byte(`{"type":"tool","name":""}`)
-				toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "name", functionName)
+				toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "name", translatorcommon.ClaudeToolName(functionName))
 				out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoiceJSON)
 			}
 		default:
 		}
 	}
 
-	return out
+	s.Out = translatorcommon.EnsureClaudeServerToolBetas(out)
 }
 
 func convertOpenAIContentPartToClaudePart(part gjson.Result) string {
 	switch part.Get("type").String() {
 	case "text":
-		textPart := []byte(`{"type":"text","text":""}`)
-		textPart, _ = sjson.SetBytes(textPart, "text", part.Get("text").String())
-		return string(textPart)
+		return string(translatorcommon.ClaudeTextBlock(part.Get("text").String()))
 
 	case "image_url":
+		// Some clients send image_url as a bare URL string instead of an object.
+		if imageURL := part.Get("image_url"); imageURL.Type == gjson.String {
+			return convertOpenAIImageURLToClaudePart(imageURL.String())
+		}
 		return convertOpenAIImageURLToClaudePart(part.Get("image_url.url").String())
 
 	case "file":
-		fileData := part.Get("file.file_data").String()
-		if strings.HasPrefix(fileData, "data:") {
-			semicolonIdx := strings.Index(fileData, ";")
-			commaIdx := strings.Index(fileData, ",")
-			if semicolonIdx != -1 && commaIdx != -1 && commaIdx > semicolonIdx {
-				mediaType := strings.TrimPrefix(fileData[:semicolonIdx], "data:")
-				data := fileData[commaIdx+1:]
-				docPart := []byte(`{"type":"document","source":{"type":"base64","media_type":"","data":""}}`)
-				docPart, _ = sjson.SetBytes(docPart, "source.media_type", mediaType)
-				docPart, _ = sjson.SetBytes(docPart, "source.data", data)
-				return string(docPart)
-			}
-		}
+		return string(translatorcommon.ClaudeFilePart(translatorcommon.OpenAIFileRef{
+			FileData: part.Get("file.file_data").String(),
+			FileID:   part.Get("file.file_id").String(),
+			FileURL:  part.Get("file.file_url").String(),
+			Filename: part.Get("file.filename").String(),
+		}))
+
+	case "input_file":
+		return string(translatorcommon.ClaudeFilePart(translatorcommon.OpenAIFileRef{
+			FileData: part.Get("file_data").String(),
+			FileID:   part.Get("file_id").String(),
+			FileURL:  part.Get("file_url").String(),
+			Filename: part.Get("filename").String(),
+		}))
 	}
 
 	return ""
@@ -436,3 +459,18 @@ func convertOpenAIToolResultContent(content gjson.Result) (string, bool) {
 
 	return content.Raw, false
 }
+
+// orphanToolResultMessage renders a tool result that references no known tool call as a plain
+// user message, labelled with the client's call ID.
+func orphanToolResultMessage(toolCallID, content string, raw bool) []byte {
+	var blocks translatorcommon.JSONArray
+	blocks.Append(translatorcommon.ClaudeTextBlock("Result of tool call " + toolCallID + ":"))
+	if raw {
+		for _, part := range gjson.Parse(content).Array() {
+			blocks.Append([]byte(part.Raw))
+		}
+	} else if content != "" {
+		blocks.Append(translatorcommon.ClaudeTextBlock(content))
+	}
+	return appendClaudeMessage(nil, "user", blocks.Bytes())
+}
@@ -0,0 +1,57 @@
+package chat_completions
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// benchmarkConversation builds an OpenAI chat request with the given number of messages,
+// mixing plain turns, multi-part content and tool calls with their results.
+func benchmarkConversation(messages int) []byte {
+	var b strings.Builder
+	b.WriteString(`{"model":"gpt-4.1","stream":true,"messages":[{"role":"system","content":"You are a helpful assistant."}`)
+	text := strings.Repeat("lorem ipsum dolor sit amet ", 20)
+	for i := 1; i < messages; i++ {
+		switch i % 4 {
+		case 1:
+			fmt.Fprintf(&b, `,{"role":"user","content":[{"type":"text","text":"%s %d"},{"type":"text","text":"details"}]}`, text, i)
+		case 2:
+			fmt.Fprintf(&b, `,{"role":"assistant","content":"%s","tool_calls":[{"id":"call_%d","type":"function","function":{"name":"lookup","arguments":"{\"q\":\"%d\"}"}}]}`, text, i, i)
+		case 3:
+			fmt.Fprintf(&b, `,{"role":"tool","tool_call_id":"call_%d","content":"%s"}`, i-1, text)
+		default:
+			fmt.Fprintf(&b, `,{"role":"assistant","content":"%s"}`, text)
+		}
+	}
+	b.WriteString(`],"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object","properties":{"q":{"type":"string"}}}}}]}`)
+	return []byte(b.String())
+}
+
+func BenchmarkConvertOpenAIRequestToClaude200Messages(b *testing.B) {
+	input := benchmarkConversation(200)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for b.Loop() {
+		ConvertOpenAIRequestToClaude("claude-sonnet-4-5", input, true)
+	}
+}
+
+func TestConvertOpenAIRequestToClaudeLargeConversation(t *testing.T) {
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", benchmarkConversation(200), true)
+	if !gjson.ValidBytes(out) {
+		t.Fatalf("invalid JSON output")
+	}
+	result := gjson.ParseBytes(out)
+	if got := len(result.Get("messages").Array()); got != 199 {
+		t.Fatalf("messages = %d, want 199", got)
+	}
+	if got := result.Get("messages.2.content.0.tool_use_id").String(); got != "call_2" || result.Get("messages.1.content.1.id").String() != got {
+		t.Fatalf("tool_result id = %q, message %s", got, result.Get("messages.1").Raw)
+	}
+	if got := result.Get("messages.1.content.1.input.q").String(); got != "2" {
+		t.Fatalf("tool_use input = %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package chat_completions
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/tidwall/gjson"
@@ -136,6 +137,28 @@ func TestConvertOpenAIRequestToClaude_ToolResultURLImageOnly(t *testing.T) {
 	}
 }
 
+func TestConvertOpenAIRequestToClaude_ToolResultStringImageURL(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [
+			{"role": "assistant", "content": "", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "screenshot", "arguments": "{}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": [
+				{"type": "text", "text": "done"},
+				{"type": "image_url", "image_url": "data:image/jpeg;base64,/9j/4AAQ"}
+			]}
+		]
+	}`
+
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+	toolContent := gjson.GetBytes(result, "messages.1.content.0.content")
+	if len(toolContent.Array()) != 2 {
+		t.Fatalf("Expected text and image tool_result blocks, got %s", toolContent.Raw)
+	}
+	if got := toolContent.Get("1.source.media_type").String(); got != "image/jpeg" {
+		t.Fatalf("Expected image/jpeg media type, got %q (%s)", got, toolContent.Raw)
+	}
+}
+
 func TestConvertOpenAIRequestToClaude_SystemRoleBecomesTopLevelSystem(t *testing.T) {
 	inputJSON := `{
 		"model": "gpt-4.1",
@@ -243,3 +266,142 @@ func TestConvertOpenAIRequestToClaude_SystemOnlyInputKeepsFallbackUserMessage(t
 		t.Fatalf("Expected fallback text %q, got %q", "", got)
 	}
 }
+
+func TestConvertOpenAIRequestToClaude_FilePartsBecomeDocuments(t *testing.T) {
+	// "%PDF-1.4" without a declared media type, a text file and a Files API reference.
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{
+			"role": "user",
+			"content": [
+				{"type": "text", "text": "Summarize these"},
+				{"type": "file", "file": {"filename": "report.pdf", "file_data": "JVBERi0xLjQ="}},
+				{"type": "input_file", "filename": "notes.txt", "file_data": "data:text/plain;base64,aGVsbG8="},
+				{"type": "file", "file": {"file_id": "file_011CNha8iCJcU1wXNR6q4V8w"}}
+			]
+		}]
+	}`
+
+	result := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false))
+	content := result.Get("messages.0.content").Array()
+	if len(content) != 4 {
+		t.Fatalf("expected 4 content parts, got %d: %s", len(content), result.Get("messages.0.content").Raw)
+	}
+
+	pdf := content[1]
+	if pdf.Get("type").String() != "document" || pdf.Get("source.media_type").String() != "application/pdf" {
+		t.Fatalf("expected sniffed PDF document, got %s", pdf.Raw)
+	}
+	if pdf.Get("source.data").String() != "JVBERi0xLjQ=" || pdf.Get("title").String() != "report.pdf" {
+		t.Fatalf("unexpected PDF document: %s", pdf.Raw)
+	}
+
+	text := content[2]
+	if text.Get("source.type").String() != "text" || text.Get("source.data").String() != "hello" {
+		t.Fatalf("expected text document, got %s", text.Raw)
+	}
+
+	ref := content[3]
+	if ref.Get("source.type").String() != "file" || ref.Get("source.file_id").String() != "file_011CNha8iCJcU1wXNR6q4V8w" {
+		t.Fatalf("expected Files API document, got %s", ref.Raw)
+	}
+	if betas := result.Get("betas").Array(); len(betas) != 1 || betas[0].String() != "files-api-2025-04-14" {
+		t.Fatalf("expected files API beta, got %s", result.Get("betas").Raw)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_InvalidFileDataIsDropped(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{
+			"role": "user",
+			"content": [
+				{"type": "text", "text": "hi"},
+				{"type": "file", "file": {"file_data": "data:application/pdf;base64,***"}}
+			]
+		}]
+	}`
+
+	result := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false))
+	if got := len(result.Get("messages.0.content").Array()); got != 1 {
+		t.Fatalf("expected invalid file part to be dropped, got %s", result.Get("messages.0.content").Raw)
+	}
+	if result.Get("betas").Exists() {
+		t.Fatalf("unexpected betas: %s", result.Get("betas").Raw)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_ReservedToolNamesBecomeServerTools(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{"role": "user", "content": "hi"}],
+		"tools": [
+			{"type": "function", "function": {"name": "web_search"}},
+			{"type": "function", "function": {"name": "code_execution", "parameters": {"type": "object", "properties": {}}}},
+			{"type": "function", "function": {"name": "bash", "parameters": {"type": "object", "properties": {"cmd": {"type": "string"}}}}},
+			{"type": "web_fetch_20250910", "name": "web_fetch", "max_uses": 2}
+		]
+	}`
+
+	result := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false))
+	tools := result.Get("tools").Array()
+	if len(tools) != 4 {
+		t.Fatalf("expected 4 tools, got %s", result.Get("tools").Raw)
+	}
+	if tools[0].Get("type").String() != "web_search_20250305" || tools[0].Get("name").String() != "web_search" {
+		t.Fatalf("unexpected web_search tool: %s", tools[0].Raw)
+	}
+	if tools[1].Get("type").String() != "code_execution_20250825" {
+		t.Fatalf("unexpected code_execution tool: %s", tools[1].Raw)
+	}
+	if tools[2].Get("type").Exists() || tools[2].Get("input_schema.properties.cmd").Type == gjson.Null {
+		t.Fatalf("expected bash function with its own parameters to stay a client tool: %s", tools[2].Raw)
+	}
+	if tools[3].Get("type").String() != "web_fetch_20250910" || tools[3].Get("max_uses").Int() != 2 {
+		t.Fatalf("expected typed server tool to pass through: %s", tools[3].Raw)
+	}
+	if got := result.Get("betas").Raw; got != `["code-execution-2025-08-25","web-fetch-2025-09-10"]` {
+		t.Fatalf("betas = %s", got)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_MapsToolCallIDs(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [
+			{"role": "user", "content": "run"},
+			{"role": "assistant", "tool_calls": [
+				{"id": "call:1", "type": "function", "function": {"name": "a", "arguments": "{}"}},
+				{"type": "function", "function": {"name": "b", "arguments": "{}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call:1", "content": "A"},
+			{"role": "tool", "content": "B"},
+			{"role": "tool", "tool_call_id": "call_missing", "content": "C"}
+		]
+	}`
+
+	first := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+	second := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+	if string(first) != string(second) {
+		t.Fatalf("expected deterministic tool IDs, got %s and %s", first, second)
+	}
+
+	result := gjson.ParseBytes(first)
+	uses := result.Get("messages.1.content").Array()
+	if len(uses) != 2 {
+		t.Fatalf("expected 2 tool_use blocks, got %s", result.Get("messages.1.content").Raw)
+	}
+	if id := uses[0].Get("id").String(); id == "call:1" || !strings.HasPrefix(id, "call_1_") {
+		t.Fatalf("expected the invalid ID to be sanitized, got %q", id)
+	}
+	if got, want := result.Get("messages.2.content.0.tool_use_id").String(), uses[0].Get("id").String(); got != want {
+		t.Fatalf("tool_result id = %q, want %q", got, want)
+	}
+	if got, want := result.Get("messages.3.content.0.tool_use_id").String(), uses[1].Get("id").String(); got != want || want == "" {
+		t.Fatalf("ID-less tool_result id = %q, want %q", got, want)
+	}
+	orphan := result.Get("messages.4.content")
+	if orphan.Get("#(type==\"tool_result\")").Exists() || orphan.Get("0.text").String() != "Result of tool call call_missing:" || orphan.Get("1.text").String() != "C" {
+		t.Fatalf("expected the orphan tool result as user text, got %s", orphan.Raw)
+	}
+}
@@ -9,9 +9,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
-	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -27,6 +28,80 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	FinishReason string
 	// Tool calls accumulator for streaming
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
+	// IncrementalToolCalls forwards tool call argument fragments as they arrive.
+	IncrementalToolCalls bool
+	// ToolCallCount is the number of tool calls started so far; it assigns OpenAI
+	// tool call indices in incremental mode.
+	ToolCallCount int
+	// ThinkingField is the delta field that carries thinking; empty drops thinking.
+	ThinkingField string
+	// ToolNameMap restores client tool names encoded for Claude.
+	ToolNameMap map[string]string
+
+	// chunkPrefix caches the chunk JSON up to the delta value for the response ID, creation
+	// time and model it was built with.
+	chunkPrefix        []byte
+	chunkPrefixID      string
+	chunkPrefixCreated int64
+	chunkPrefixModel   string
+}
+
+// chunkSuffix closes a chunk after its delta value.
+const chunkSuffix = `,"finish_reason":null}]}`
+
+// streamParams returns the stream state stored in param, creating it on the first event.
+func streamParams(param *any, originalRequestRawJSON []byte) *ConvertAnthropicResponseToOpenAIParams {
+	if p, ok := (*param).(*ConvertAnthropicResponseToOpenAIParams); ok {
+		return p
+	}
+	p := &ConvertAnthropicResponseToOpenAIParams{
+		IncrementalToolCalls: resolveToolCallStreaming(originalRequestRawJSON) == ToolCallStreamingIncremental,
+		ThinkingField:        thinkingField(resolveThinkingOutput(originalRequestRawJSON), ThinkingOutputReasoningContent),
+		ToolNameMap:          openAIToolNameMap(originalRequestRawJSON),
+	}
+	*param = p
+	return p
+}
+
+// prefix returns the chunk JSON up to the delta value, rebuilding it only when the response
+// ID, creation time or model changed.
+func (p *ConvertAnthropicResponseToOpenAIParams) prefix(modelName string) []byte {
+	if p.chunkPrefix != nil && p.chunkPrefixID == p.ResponseID && p.chunkPrefixCreated == p.CreatedAt && p.chunkPrefixModel == modelName {
+		return p.chunkPrefix
+	}
+	prefix := make([]byte, 0, 112+len(p.ResponseID)+len(modelName))
+	prefix = append(prefix, `{"id":`...)
+	prefix = gjson.AppendJSONString(prefix, p.ResponseID)
+	prefix = append(prefix, `,"object":"chat.completion.chunk","created":`...)
+	prefix = strconv.AppendInt(prefix, p.CreatedAt, 10)
+	prefix = append(prefix, `,"model":`...)
+	prefix = gjson.AppendJSONString(prefix, modelName)
+	prefix = append(prefix, `,"choices":[{"index":0,"delta":`...)
+	p.chunkPrefix, p.chunkPrefixID, p.chunkPrefixCreated, p.chunkPrefixModel = prefix, p.ResponseID, p.CreatedAt, modelName
+	return prefix
+}
+
+// chunk returns a new chunk with an empty delta.
+func (p *ConvertAnthropicResponseToOpenAIParams) chunk(modelName string) []byte {
+	prefix := p.prefix(modelName)
+	out := make([]byte, 0, len(prefix)+len(chunkSuffix)+2)
+	out = append(out, prefix...)
+	out = append(out, "{}"...)
+	return append(out, chunkSuffix...)
+}
+
+// deltaChunk returns a new chunk whose delta holds field set to rawValue, a JSON value,
+// without going through sjson.
+func (p *ConvertAnthropicResponseToOpenAIParams) deltaChunk(modelName, field, rawValue string) []byte {
+	prefix := p.prefix(modelName)
+	out := make([]byte, 0, len(prefix)+len(field)+len(rawValue)+len(chunkSuffix)+6)
+	out = append(out, prefix...)
+	out = append(out, `{"`...)
+	out = append(out, field...)
+	out = append(out, `":`...)
+	out = append(out, rawValue...)
+	out = append(out, '}')
+	return append(out, chunkSuffix...)
 }
 
 // ToolCallAccumulator holds the state for accumulating tool call data
@@ -34,6 +109,8 @@ type ToolCallAccumulator struct {
 	ID        string
 	Name      string
 	Arguments strings.Builder
+	// Index is the OpenAI tool call index used in incremental mode.
+	Index int
 }
 
 func calculateClaudeUsageTokens(usage gjson.Result) (promptTokens, completionTokens, totalTokens, cachedTokens int64) {
@@ -61,14 +138,8 @@ func calculateClaudeUsageTokens(usage gjson.Result) (promptTokens, completionTok
 //
 // Returns:
 //   - [][]byte: A slice of OpenAI-compatible JSON responses
-func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
-	if *param == nil {
-		*param = &ConvertAnthropicResponseToOpenAIParams{
-			CreatedAt:    0,
-			ResponseID:   "",
-			FinishReason: "",
-		}
-	}
+func ConvertClaudeResponseToOpenAI(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+	p := streamParams(param, originalRequestRawJSON)
 
 	if !bytes.HasPrefix(rawJSON, dataTag) {
 		return [][]byte{}
@@ -78,42 +149,22 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 	root := gjson.ParseBytes(rawJSON)
 	eventType := root.Get("type").String()
 
-	// Base OpenAI streaming response template
-	template := []byte(`{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{},"finish_reason":null}]}`)
-
-	// Set model
-	if modelName != "" {
-		template, _ = sjson.SetBytes(template, "model", modelName)
-	}
-
-	// Set response ID and creation time
-	if (*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID != "" {
-		template, _ = sjson.SetBytes(template, "id", (*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID)
-	}
-	if (*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt > 0 {
-		template, _ = sjson.SetBytes(template, "created", (*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt)
-	}
-
 	switch eventType {
 	case "message_start":
 		// Initialize response with message metadata when a new message begins
 		if message := root.Get("message"); message.Exists() {
-			(*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID = message.Get("id").String()
-			(*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt = time.Now().Unix()
-
-			template, _ = sjson.SetBytes(template, "id", (*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID)
-			template, _ = sjson.SetBytes(template, "model", modelName)
-			template, _ = sjson.SetBytes(template, "created", (*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt)
-
-			// Set initial role to assistant for the response
-			template, _ = sjson.SetBytes(template, "choices.0.delta.role", "assistant")
+			p.ResponseID = message.Get("id").String()
+			p.CreatedAt = resolveCreatedAt(ctx)
 
 			// Initialize tool calls accumulator for tracking tool call progress
-			if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator == nil {
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
+			if p.ToolCallsAccumulator == nil {
+				p.ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
 			}
+
+			// Set initial role to assistant for the response
+			return [][]byte{p.deltaChunk(modelName, "role", `"assistant"`)}
 		}
-		return [][]byte{template}
+		return [][]byte{p.chunk(modelName)}
 
 	case "content_block_start":
 		// Start of a content block (text, tool use, or reasoning)
@@ -123,50 +174,71 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 			if blockType == "tool_use" {
 				// Start of tool call - initialize accumulator to track arguments
 				toolCallID := contentBlock.Get("id").String()
-				toolName := contentBlock.Get("name").String()
+				toolName := translatorcommon.RestoreClaudeToolName(p.ToolNameMap, contentBlock.Get("name").String())
 				index := int(root.Get("index").Int())
 
-				if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator == nil {
-					(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
+				if p.ToolCallsAccumulator == nil {
+					p.ToolCallsAccumulator = make(map[int]*ToolCallAccumulator)
 				}
 
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index] = &ToolCallAccumulator{
-					ID:   toolCallID,
-					Name: toolName,
+				accumulator := &ToolCallAccumulator{
+					ID:    toolCallID,
+					Name:  toolName,
+					Index: p.ToolCallCount,
+				}
+				p.ToolCallsAccumulator[index] = accumulator
+				p.ToolCallCount++
+
+				if p.IncrementalToolCalls {
+					// Announce the tool call; arguments follow as fragments.
+					template := p.chunk(modelName)
+					template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.index", accumulator.Index)
+					template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.id", toolCallID)
+					template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.type", "function")
+					template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.name", toolName)
+					template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.arguments", "")
+					return [][]byte{template}
 				}
 
 				// Don't output anything yet - wait for complete tool call
 				return [][]byte{}
 			}
+			if annotations := webSearchAnnotations(contentBlock); annotations != nil {
+				return [][]byte{p.deltaChunk(modelName, "annotations", string(annotations))}
+			}
 		}
 		return [][]byte{}
 
 	case "content_block_delta":
 		// Handle content delta (text, tool use arguments, or reasoning content)
-		hasContent := false
 		if delta := root.Get("delta"); delta.Exists() {
 			deltaType := delta.Get("type").String()
 
 			switch deltaType {
 			case "text_delta":
-				// Text content delta - send incremental text updates
+				// Text content delta - send incremental text updates; the upstream JSON string is
+				// copied as-is instead of being decoded and re-encoded.
 				if text := delta.Get("text"); text.Exists() {
-					template, _ = sjson.SetBytes(template, "choices.0.delta.content", text.String())
-					hasContent = true
+					return [][]byte{p.deltaChunk(modelName, "content", jsonStringRaw(text))}
 				}
 			case "thinking_delta":
 				// Accumulate reasoning/thinking content
-				if thinking := delta.Get("thinking"); thinking.Exists() {
-					template, _ = sjson.SetBytes(template, "choices.0.delta.reasoning_content", thinking.String())
-					hasContent = true
+				if thinking := delta.Get("thinking"); thinking.Exists() && p.ThinkingField != "" {
+					return [][]byte{p.deltaChunk(modelName, p.ThinkingField, jsonStringRaw(thinking))}
 				}
 			case "input_json_delta":
 				// Tool use input delta - accumulate arguments for tool calls
 				if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
 					index := int(root.Get("index").Int())
-					if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
-						if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
+					if p.ToolCallsAccumulator != nil {
+						if accumulator, exists := p.ToolCallsAccumulator[index]; exists {
 							accumulator.Arguments.WriteString(partialJSON.String())
+							if p.IncrementalToolCalls && partialJSON.String() != "" {
+								template := p.chunk(modelName)
+								template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.index", accumulator.Index)
+								template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.arguments", partialJSON.String())
+								return [][]byte{template}
+							}
 						}
 					}
 				}
@@ -174,22 +246,31 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				return [][]byte{}
 			}
 		}
-		if hasContent {
-			return [][]byte{template}
-		} else {
-			return [][]byte{}
-		}
+		return [][]byte{}
 
 	case "content_block_stop":
 		// End of content block - output complete tool call if it's a tool_use block
 		index := int(root.Get("index").Int())
-		if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
-			if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
+		if p.ToolCallsAccumulator != nil {
+			if accumulator, exists := p.ToolCallsAccumulator[index]; exists {
+				if p.IncrementalToolCalls {
+					delete(p.ToolCallsAccumulator, index)
+					if accumulator.Arguments.Len() > 0 {
+						return [][]byte{}
+					}
+					// No fragments were streamed; send an empty object so arguments stay valid JSON.
+					template := p.chunk(modelName)
+					template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.index", accumulator.Index)
+					template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.arguments", "{}")
+					return [][]byte{template}
+				}
+
 				// Build complete tool call with accumulated arguments
 				arguments := accumulator.Arguments.String()
 				if arguments == "" {
 					arguments = "{}"
 				}
+				template := p.chunk(modelName)
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.index", index)
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.id", accumulator.ID)
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.type", "function")
@@ -197,7 +278,7 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				template, _ = sjson.SetBytes(template, "choices.0.delta.tool_calls.0.function.arguments", arguments)
 
 				// Clean up the accumulator for this index
-				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
+				delete(p.ToolCallsAccumulator, index)
 
 				return [][]byte{template}
 			}
@@ -206,10 +287,11 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 
 	case "message_delta":
 		// Handle message-level changes including stop reason and usage
+		template := p.chunk(modelName)
 		if delta := root.Get("delta"); delta.Exists() {
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
-				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
-				template, _ = sjson.SetBytes(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
+				p.FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
+				template, _ = sjson.SetBytes(template, "choices.0.finish_reason", p.FinishReason)
 			}
 		}
 
@@ -247,6 +329,38 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 	}
 }
 
+// jsonStringRaw returns a JSON string value as encoded upstream, re-encoding only values that
+// are not JSON strings.
+func jsonStringRaw(value gjson.Result) string {
+	if value.Type == gjson.String && value.Raw != "" {
+		return value.Raw
+	}
+	return string(gjson.AppendJSONString(nil, value.String()))
+}
+
+// webSearchAnnotations converts the results of a web_search_tool_result block into OpenAI
+// url_citation annotations, or returns nil for other blocks. Server tools run upstream, so
+// their server_tool_use blocks are not surfaced as tool_calls the client would have to answer.
+func webSearchAnnotations(block gjson.Result) []byte {
+	if block.Get("type").String() != "web_search_tool_result" {
+		return nil
+	}
+	var annotations []byte
+	for _, result := range block.Get("content").Array() {
+		if result.Get("type").String() != "web_search_result" || result.Get("url").String() == "" {
+			continue
+		}
+		annotation := []byte(`{"type":"url_citation","url_citation":{"url":"","title":""}}`)
+		annotation, _ = sjson.SetBytes(annotation, "url_citation.url", result.Get("url").String())
+		annotation, _ = sjson.SetBytes(annotation, "url_citation.title", result.Get("title").String())
+		if annotations == nil {
+			annotations = []byte(`[]`)
+		}
+		annotations, _ = sjson.SetRawBytes(annotations, "-1", annotation)
+	}
+	return annotations
+}
+
 // mapAnthropicStopReasonToOpenAI maps Anthropic stop reasons to OpenAI stop reasons
 func mapAnthropicStopReasonToOpenAI(anthropicReason string) string {
 	switch anthropicReason {
@@ -276,7 +390,7 @@ func mapAnthropicStopReasonToOpenAI(anthropicReason string) string {
 //
 // Returns:
 //   - []byte: An OpenAI-compatible JSON response containing all message content and metadata
-func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
+func ConvertClaudeResponseToOpenAINonStream(ctx context.Context, _ string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, _ *any) []byte {
 	chunks := make([][]byte, 0)
 
 	lines := bytes.Split(rawJSON, []byte("\n"))
@@ -297,6 +411,7 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	var contentParts []string
 	var reasoningParts []string
 	toolCallsAccumulator := make(map[int]*ToolCallAccumulator)
+	toolNameMap := openAIToolNameMap(originalRequestRawJSON)
 
 	for _, chunk := range chunks {
 		root := gjson.ParseBytes(chunk)
@@ -308,7 +423,7 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 			if message := root.Get("message"); message.Exists() {
 				messageID = message.Get("id").String()
 				model = message.Get("model").String()
-				createdAt = time.Now().Unix()
+				createdAt = resolveCreatedAt(ctx)
 			}
 
 		case "content_block_start":
@@ -323,8 +438,13 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 					index := int(root.Get("index").Int())
 					toolCallsAccumulator[index] = &ToolCallAccumulator{
 						ID:   contentBlock.Get("id").String(),
-						Name: contentBlock.Get("name").String(),
+						Name: translatorcommon.RestoreClaudeToolName(toolNameMap, contentBlock.Get("name").String()),
 					}
+				} else if annotations := webSearchAnnotations(contentBlock); annotations != nil {
+					gjson.ParseBytes(annotations).ForEach(func(_, annotation gjson.Result) bool {
+						out, _ = sjson.SetRawBytes(out, "choices.0.message.annotations.-1", []byte(annotation.Raw))
+						return true
+					})
 				}
 			}
 
@@ -385,15 +505,21 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	out, _ = sjson.SetBytes(out, "created", createdAt)
 	out, _ = sjson.SetBytes(out, "model", model)
 
-	// Set message content by combining all text parts
+	// Set message content by combining all text parts. Claude emits text blocks before the
+	// tool_use blocks of the same turn, so the text and the tool calls below stay together
+	// in a single assistant message with the text preceding the calls.
 	messageContent := strings.Join(contentParts, "")
-	out, _ = sjson.SetBytes(out, "choices.0.message.content", messageContent)
+	if messageContent == "" && len(toolCallsAccumulator) > 0 && CurrentResponseOptions().ToolCallContent == ToolCallContentNull {
+		out, _ = sjson.SetRawBytes(out, "choices.0.message.content", []byte("null"))
+	} else {
+		out, _ = sjson.SetBytes(out, "choices.0.message.content", messageContent)
+	}
 
 	// Add reasoning content if available (following OpenAI reasoning format)
-	if len(reasoningParts) > 0 {
+	if field := thinkingField(resolveThinkingOutput(originalRequestRawJSON), ThinkingOutputReasoning); len(reasoningParts) > 0 && field != "" {
 		reasoningContent := strings.Join(reasoningParts, "")
 		// Add reasoning as a separate field in the message
-		out, _ = sjson.SetBytes(out, "choices.0.message.reasoning", reasoningContent)
+		out, _ = sjson.SetBytes(out, "choices.0.message."+field, reasoningContent)
 	}
 
 	// Set tool calls if any were accumulated during processing
@@ -436,3 +562,20 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 
 	return out
 }
+
+// OpenAITokenCount renders a count_tokens result for OpenAI-format clients. The payload
+// carries the OpenAI usage block alongside the Anthropic-native input_tokens field so
+// clients of either schema can read it.
+func OpenAITokenCount(_ context.Context, count int64) []byte {
+	return []byte(fmt.Sprintf(`{"object":"token_count","input_tokens":%d,"usage":{"prompt_tokens":%d,"completion_tokens":0,"total_tokens":%d}}`, count, count, count))
+}
+
+// openAIToolNameMap maps the Claude tool names of the original request's function tools back to
+// the client names.
+func openAIToolNameMap(originalRequestRawJSON []byte) map[string]string {
+	var names []string
+	for _, name := range gjson.GetBytes(originalRequestRawJSON, "tools.#.function.name").Array() {
+		names = append(names, name.String())
+	}
+	return translatorcommon.ClaudeToolNameMap(names)
+}
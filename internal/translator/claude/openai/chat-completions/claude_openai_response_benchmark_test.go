@@ -0,0 +1,64 @@
+package chat_completions
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// benchmarkStreamEvents returns the SSE lines of a Claude stream with the given number of
+// text deltas followed by one tool call.
+func benchmarkStreamEvents(textDeltas int) [][]byte {
+	events := [][]byte{
+		[]byte(`data: {"type":"message_start","message":{"id":"msg_bench","model":"claude-sonnet-4-5","usage":{"input_tokens":10}}}`),
+		[]byte(`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`),
+	}
+	for i := 0; i < textDeltas; i++ {
+		events = append(events, fmt.Appendf(nil, `data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"token %d with \"quotes\" "}}`, i))
+	}
+	events = append(events,
+		[]byte(`data: {"type":"content_block_stop","index":0}`),
+		[]byte(`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`),
+		[]byte(`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`),
+		[]byte(`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"x\"}"}}`),
+		[]byte(`data: {"type":"content_block_stop","index":1}`),
+		[]byte(`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":120}}`),
+		[]byte(`data: {"type":"message_stop"}`),
+	)
+	return events
+}
+
+func runBenchmarkStream(events [][]byte) {
+	var param any
+	ctx := context.Background()
+	for _, event := range events {
+		ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil, event, &param)
+	}
+}
+
+func BenchmarkConvertClaudeResponseToOpenAIStream(b *testing.B) {
+	events := benchmarkStreamEvents(200)
+	b.ReportAllocs()
+	for b.Loop() {
+		runBenchmarkStream(events)
+	}
+}
+
+// BenchmarkConvertClaudeResponseToOpenAIConcurrentStreams translates whole streams from about
+// 1000 goroutines at once to show the GC pressure of many concurrent streams.
+func BenchmarkConvertClaudeResponseToOpenAIConcurrentStreams(b *testing.B) {
+	events := benchmarkStreamEvents(200)
+	b.ReportAllocs()
+	b.SetParallelism(max(1, 1000/runtime.GOMAXPROCS(0)))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			runBenchmarkStream(events)
+		}
+	})
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.NumGC-before.NumGC), "gc-cycles")
+}
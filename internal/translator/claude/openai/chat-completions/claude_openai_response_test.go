@@ -3,6 +3,7 @@ package chat_completions
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/tidwall/gjson"
 )
@@ -56,3 +57,290 @@ func TestConvertClaudeResponseToOpenAINonStream_UsageIncludesCachedTokens(t *tes
 		t.Fatalf("expected cached_tokens %d, got %d", 22000, gotCachedTokens)
 	}
 }
+
+func TestConvertClaudeResponseToOpenAINonStream_TextThenToolUse(t *testing.T) {
+	rawJSON := []byte("data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4-5\"}}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Let me check the weather.\"}}\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":0}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"get_weather\",\"input\":{}}}\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"city\\\":\\\"Paris\\\"}\"}}\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":1}\n" +
+		"data: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"},\"usage\":{\"input_tokens\":10,\"output_tokens\":5}}\n")
+
+	out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", nil, nil, rawJSON, nil)
+
+	message := gjson.GetBytes(out, "choices.0.message")
+	if got := message.Get("content").String(); got != "Let me check the weather." {
+		t.Fatalf("expected text content to be preserved, got %q", got)
+	}
+	if got := message.Get("tool_calls.0.function.name").String(); got != "get_weather" {
+		t.Fatalf("expected tool call to follow the text, got %s", message.Raw)
+	}
+	if got := message.Get("tool_calls.0.function.arguments").String(); got != `{"city":"Paris"}` {
+		t.Fatalf("unexpected tool call arguments %q", got)
+	}
+	if got := gjson.GetBytes(out, "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("expected finish_reason tool_calls, got %q", got)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAINonStream_ToolOnlyNullContent(t *testing.T) {
+	previous := CurrentResponseOptions()
+	t.Cleanup(func() { SetResponseOptions(previous) })
+
+	rawJSON := []byte("data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4-5\"}}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"get_weather\",\"input\":{}}}\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":0}\n")
+
+	SetResponseOptions(ResponseOptions{ToolCallContent: ToolCallContentText})
+	out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", nil, nil, rawJSON, nil)
+	if content := gjson.GetBytes(out, "choices.0.message.content"); content.Type != gjson.String || content.String() != "" {
+		t.Fatalf("expected empty string content in text mode, got %s", content.Raw)
+	}
+
+	SetResponseOptions(ResponseOptions{ToolCallContent: ToolCallContentNull})
+	out = ConvertClaudeResponseToOpenAINonStream(context.Background(), "", nil, nil, rawJSON, nil)
+	if content := gjson.GetBytes(out, "choices.0.message.content"); content.Type != gjson.Null {
+		t.Fatalf("expected null content in null mode, got %s", content.Raw)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_CreatedSource(t *testing.T) {
+	previous := CurrentResponseOptions()
+	t.Cleanup(func() { SetResponseOptions(previous) })
+
+	const upstreamCreated = int64(1700000000)
+	ctx := context.WithValue(context.Background(), "upstream_created", upstreamCreated)
+	rawJSON := []byte("data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4-5\"}}\n")
+
+	SetResponseOptions(ResponseOptions{CreatedSource: CreatedSourceUpstream})
+	out := ConvertClaudeResponseToOpenAINonStream(ctx, "", nil, nil, rawJSON, nil)
+	if got := gjson.GetBytes(out, "created").Int(); got != upstreamCreated {
+		t.Fatalf("expected upstream created %d, got %d", upstreamCreated, got)
+	}
+	var param any
+	chunks := ConvertClaudeResponseToOpenAI(ctx, "", nil, nil, rawJSON, &param)
+	if len(chunks) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "created").Int(); got != upstreamCreated {
+		t.Fatalf("expected upstream created %d in stream chunk, got %d", upstreamCreated, got)
+	}
+
+	// Without an upstream timestamp the local clock is used.
+	before := time.Now().Unix()
+	out = ConvertClaudeResponseToOpenAINonStream(context.Background(), "", nil, nil, rawJSON, nil)
+	if got := gjson.GetBytes(out, "created").Int(); got < before {
+		t.Fatalf("expected local fallback created >= %d, got %d", before, got)
+	}
+
+	SetResponseOptions(ResponseOptions{CreatedSource: CreatedSourceLocal})
+	out = ConvertClaudeResponseToOpenAINonStream(ctx, "", nil, nil, rawJSON, nil)
+	if got := gjson.GetBytes(out, "created").Int(); got == upstreamCreated || got < before {
+		t.Fatalf("expected local created in local mode, got %d", got)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_IncrementalToolCalls(t *testing.T) {
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5"}}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_2","name":"noop","input":{}}}`,
+		`data: {"type":"content_block_stop","index":2}`,
+	}
+	originalRequest := []byte(`{"tool_call_streaming":"incremental"}`)
+
+	var param any
+	var chunks [][]byte
+	for _, event := range events {
+		chunks = append(chunks, ConvertClaudeResponseToOpenAI(context.Background(), "m", originalRequest, nil, []byte(event), &param)...)
+	}
+	// message_start, header, two fragments, second header, empty-arguments fallback.
+	if len(chunks) != 6 {
+		t.Fatalf("expected 6 chunks, got %d", len(chunks))
+	}
+	header := gjson.GetBytes(chunks[1], "choices.0.delta.tool_calls.0")
+	if header.Get("index").Int() != 0 || header.Get("id").String() != "toolu_1" || header.Get("function.name").String() != "get_weather" {
+		t.Fatalf("unexpected tool call header: %s", header.Raw)
+	}
+	var arguments string
+	for _, chunk := range chunks[2:4] {
+		call := gjson.GetBytes(chunk, "choices.0.delta.tool_calls.0")
+		if call.Get("index").Int() != 0 || call.Get("id").Exists() {
+			t.Fatalf("unexpected fragment chunk: %s", call.Raw)
+		}
+		arguments += call.Get("function.arguments").String()
+	}
+	if arguments != `{"city":"Paris"}` {
+		t.Fatalf("reassembled arguments = %q", arguments)
+	}
+	if got := gjson.GetBytes(chunks[4], "choices.0.delta.tool_calls.0.index").Int(); got != 1 {
+		t.Fatalf("second tool call index = %d, want 1", got)
+	}
+	if got := gjson.GetBytes(chunks[5], "choices.0.delta.tool_calls.0.function.arguments").String(); got != "{}" {
+		t.Fatalf("empty tool call arguments = %q, want {}", got)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_BufferedToolCallsByDefault(t *testing.T) {
+	var param any
+	events := []string{
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"f","input":{}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"a\":1}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+	}
+	var chunks [][]byte
+	for _, event := range events {
+		chunks = append(chunks, ConvertClaudeResponseToOpenAI(context.Background(), "m", nil, nil, []byte(event), &param)...)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("expected a single buffered chunk, got %d", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.tool_calls.0.function.arguments").String(); got != `{"a":1}` {
+		t.Fatalf("arguments = %q", got)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_ThinkingOutputModes(t *testing.T) {
+	previous := CurrentResponseOptions()
+	t.Cleanup(func() { SetResponseOptions(previous) })
+
+	thinkingEvent := []byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}`)
+	nonStream := []byte("data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\"}}\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"thinking_delta\",\"thinking\":\"hmm\"}}\n")
+
+	tests := []struct {
+		name          string
+		configured    string
+		request       []byte
+		streamField   string
+		nonStreamPath string
+	}{
+		{name: "default", streamField: "reasoning_content", nonStreamPath: "reasoning"},
+		{name: "configured reasoning_content", configured: ThinkingOutputReasoningContent, streamField: "reasoning_content", nonStreamPath: "reasoning_content"},
+		{name: "request override", configured: ThinkingOutputReasoningContent, request: []byte(`{"thinking_output":"reasoning"}`), streamField: "reasoning", nonStreamPath: "reasoning"},
+		{name: "hidden", configured: ThinkingOutputHidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetResponseOptions(ResponseOptions{ThinkingOutput: tt.configured})
+
+			var param any
+			chunks := ConvertClaudeResponseToOpenAI(context.Background(), "m", tt.request, nil, thinkingEvent, &param)
+			if tt.streamField == "" {
+				if len(chunks) != 0 {
+					t.Fatalf("expected thinking to be dropped, got %s", chunks[0])
+				}
+			} else if len(chunks) != 1 || gjson.GetBytes(chunks[0], "choices.0.delta."+tt.streamField).String() != "hmm" {
+				t.Fatalf("expected delta.%s, got %q", tt.streamField, chunks)
+			}
+
+			out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", tt.request, nil, nonStream, nil)
+			message := gjson.GetBytes(out, "choices.0.message")
+			for _, field := range []string{"reasoning", "reasoning_content"} {
+				want := field == tt.nonStreamPath
+				if got := message.Get(field).Exists(); got != want {
+					t.Fatalf("message.%s exists = %v, want %v; message=%s", field, got, want, message.Raw)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_WebSearchResultsBecomeAnnotations(t *testing.T) {
+	serverToolUse := "data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"server_tool_use\",\"id\":\"srvtoolu_1\",\"name\":\"web_search\",\"input\":{}}}\n"
+	result := "data: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"web_search_tool_result\",\"tool_use_id\":\"srvtoolu_1\",\"content\":[{\"type\":\"web_search_result\",\"url\":\"https://example.com\",\"title\":\"Example\"}]}}\n"
+
+	var param any
+	if out := ConvertClaudeResponseToOpenAI(context.Background(), "claude-sonnet-4-5", nil, nil, []byte(serverToolUse), &param); len(out) != 0 {
+		t.Fatalf("expected server_tool_use to produce no chunk, got %s", out)
+	}
+	out := ConvertClaudeResponseToOpenAI(context.Background(), "claude-sonnet-4-5", nil, nil, []byte(result), &param)
+	if len(out) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(out))
+	}
+	if got := gjson.GetBytes(out[0], "choices.0.delta.annotations.0.url_citation.url").String(); got != "https://example.com" {
+		t.Fatalf("unexpected annotations chunk %s", out[0])
+	}
+
+	rawJSON := []byte("data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4-5\"}}\n" +
+		serverToolUse +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"query\\\":\\\"example\\\"}\"}}\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":0}\n" +
+		result +
+		"data: {\"type\":\"content_block_stop\",\"index\":1}\n" +
+		"data: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"input_tokens\":10,\"output_tokens\":5}}\n")
+	nonStream := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", nil, nil, rawJSON, nil)
+	message := gjson.GetBytes(nonStream, "choices.0.message")
+	if message.Get("tool_calls").Exists() {
+		t.Fatalf("expected server tool use not to become a client tool call: %s", message.Raw)
+	}
+	if got := message.Get("annotations.0.url_citation.title").String(); got != "Example" {
+		t.Fatalf("unexpected annotations %s", message.Raw)
+	}
+	if got := gjson.GetBytes(nonStream, "choices.0.finish_reason").String(); got != "stop" {
+		t.Fatalf("finish_reason = %q, want stop", got)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_RestoresEncodedToolNames(t *testing.T) {
+	originalRequest := []byte(`{"tools":[{"type":"function","function":{"name":"mcp.obsidian.search"}}]}`)
+	claudeRequest := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", originalRequest, false))
+	encoded := claudeRequest.Get("tools.0.name").String()
+	if encoded != "mcp_obsidian_search" {
+		t.Fatalf("encoded tool name = %q", encoded)
+	}
+
+	var param any
+	events := []string{
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"` + encoded + `","input":{}}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+	}
+	var chunks [][]byte
+	for _, event := range events {
+		chunks = append(chunks, ConvertClaudeResponseToOpenAI(context.Background(), "m", originalRequest, nil, []byte(event), &param)...)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("expected a single tool call chunk, got %d", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.tool_calls.0.function.name").String(); got != "mcp.obsidian.search" {
+		t.Fatalf("tool name = %q", got)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_TextDeltaChunks(t *testing.T) {
+	ctx := context.Background()
+	var param any
+
+	start := ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil, []byte(`data: {"type":"message_start","message":{"id":"msg_\"1","model":"claude-sonnet-4-5"}}`), &param)
+	if len(start) != 1 || gjson.GetBytes(start[0], "choices.0.delta.role").String() != "assistant" {
+		t.Fatalf("expected assistant role chunk, got %q", start)
+	}
+
+	deltas := []string{`"plain"`, `"quote \" and \\ backslash\nnewline"`, `"é中"`, `""`}
+	for _, text := range deltas {
+		out := ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil, []byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":`+text+`}}`), &param)
+		if len(out) != 1 {
+			t.Fatalf("expected 1 chunk for %s, got %d", text, len(out))
+		}
+		if !gjson.ValidBytes(out[0]) {
+			t.Fatalf("invalid chunk JSON: %s", out[0])
+		}
+		chunk := gjson.ParseBytes(out[0])
+		if got, want := chunk.Get("choices.0.delta.content").String(), gjson.Parse(text).String(); got != want {
+			t.Fatalf("content = %q, want %q", got, want)
+		}
+		if chunk.Get("id").String() != `msg_"1` || chunk.Get("model").String() != "claude-sonnet-4-5" || chunk.Get("created").Int() <= 0 {
+			t.Fatalf("unexpected chunk metadata: %s", out[0])
+		}
+		if chunk.Get("object").String() != "chat.completion.chunk" || chunk.Get("choices.0.finish_reason").Type != gjson.Null {
+			t.Fatalf("unexpected chunk shape: %s", out[0])
+		}
+	}
+}
@@ -12,8 +12,9 @@ func init() {
 		Claude,
 		ConvertOpenAIRequestToClaude,
 		interfaces.TranslateResponse{
-			Stream:    ConvertClaudeResponseToOpenAI,
-			NonStream: ConvertClaudeResponseToOpenAINonStream,
+			Stream:     ConvertClaudeResponseToOpenAI,
+			NonStream:  ConvertClaudeResponseToOpenAINonStream,
+			TokenCount: OpenAITokenCount,
 		},
 	)
 }
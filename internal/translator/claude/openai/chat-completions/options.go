@@ -0,0 +1,130 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// ToolCallContentText keeps the assistant text produced alongside tool calls in
+	// message.content (an empty string when Claude emitted no text).
+	ToolCallContentText = "text"
+	// ToolCallContentNull sets message.content to null when the turn only carries
+	// tool calls, matching the OpenAI convention for tool-only assistant messages.
+	ToolCallContentNull = "null"
+
+	// CreatedSourceLocal stamps `created` with the proxy's clock.
+	CreatedSourceLocal = "local"
+	// CreatedSourceUpstream stamps `created` with the upstream response time when the
+	// executor provides one, falling back to the proxy's clock.
+	CreatedSourceUpstream = "upstream"
+
+	// ToolCallStreamingBuffered emits each streamed tool call as one chunk once its
+	// arguments are complete.
+	ToolCallStreamingBuffered = "buffered"
+	// ToolCallStreamingIncremental emits the tool call header when the block starts and
+	// forwards function.arguments fragments as they arrive, like OpenAI streaming.
+	ToolCallStreamingIncremental = "incremental"
+
+	// ThinkingOutputReasoningContent emits thinking in delta.reasoning_content and
+	// message.reasoning_content (DeepSeek/OpenRouter convention).
+	ThinkingOutputReasoningContent = "reasoning_content"
+	// ThinkingOutputReasoning emits thinking in delta.reasoning and message.reasoning.
+	ThinkingOutputReasoning = "reasoning"
+	// ThinkingOutputHidden drops thinking from the response.
+	ThinkingOutputHidden = "hidden"
+
+	// thinkingOutputRequestField lets a request override the configured thinking output
+	// mode. The field is read from the original OpenAI request.
+	thinkingOutputRequestField = "thinking_output"
+
+	// toolCallStreamingRequestField lets a request override the configured tool call
+	// streaming mode. The field is read from the original OpenAI request.
+	toolCallStreamingRequestField = "tool_call_streaming"
+)
+
+// ResponseOptions controls how Claude responses are rendered as OpenAI Chat Completions.
+type ResponseOptions struct {
+	// ToolCallContent selects the message.content representation for turns that contain
+	// tool calls. Supported values: ToolCallContentText (default), ToolCallContentNull.
+	ToolCallContent string
+	// CreatedSource selects where the `created` timestamp comes from.
+	// Supported values: CreatedSourceLocal (default), CreatedSourceUpstream.
+	CreatedSource string
+	// ToolCallStreaming selects how streamed tool calls are emitted.
+	// Supported values: ToolCallStreamingBuffered (default), ToolCallStreamingIncremental.
+	ToolCallStreaming string
+	// ThinkingOutput selects where thinking is emitted. Empty keeps the historical layout
+	// (delta.reasoning_content when streaming, message.reasoning otherwise).
+	// Supported values: ThinkingOutputReasoningContent, ThinkingOutputReasoning, ThinkingOutputHidden.
+	ThinkingOutput string
+}
+
+var responseOptions atomic.Pointer[ResponseOptions]
+
+// SetResponseOptions replaces the process-wide Claude → OpenAI response options.
+func SetResponseOptions(opts ResponseOptions) {
+	responseOptions.Store(&opts)
+}
+
+// CurrentResponseOptions returns the active Claude → OpenAI response options.
+func CurrentResponseOptions() ResponseOptions {
+	if opts := responseOptions.Load(); opts != nil {
+		return *opts
+	}
+	return ResponseOptions{}
+}
+
+// resolveCreatedAt returns the `created` timestamp for a response. In upstream mode the
+// executor passes the upstream response time as a unix timestamp under the
+// "upstream_created" context key.
+func resolveCreatedAt(ctx context.Context) int64 {
+	if ctx != nil && CurrentResponseOptions().CreatedSource == CreatedSourceUpstream {
+		if created, ok := ctx.Value("upstream_created").(int64); ok && created > 0 {
+			return created
+		}
+	}
+	return time.Now().Unix()
+}
+
+// resolveToolCallStreaming returns the tool call streaming mode for a request, preferring
+// the request's tool_call_streaming field over the configured default.
+func resolveToolCallStreaming(originalRequestRawJSON []byte) string {
+	mode := strings.ToLower(strings.TrimSpace(gjson.GetBytes(originalRequestRawJSON, toolCallStreamingRequestField).String()))
+	switch mode {
+	case ToolCallStreamingBuffered, ToolCallStreamingIncremental:
+		return mode
+	}
+	if CurrentResponseOptions().ToolCallStreaming == ToolCallStreamingIncremental {
+		return ToolCallStreamingIncremental
+	}
+	return ToolCallStreamingBuffered
+}
+
+// resolveThinkingOutput returns the thinking output mode for a request, preferring the
+// request's thinking_output field over the configured default. Empty means the historical layout.
+func resolveThinkingOutput(originalRequestRawJSON []byte) string {
+	mode := strings.ToLower(strings.TrimSpace(gjson.GetBytes(originalRequestRawJSON, thinkingOutputRequestField).String()))
+	switch mode {
+	case ThinkingOutputReasoningContent, ThinkingOutputReasoning, ThinkingOutputHidden:
+		return mode
+	}
+	return CurrentResponseOptions().ThinkingOutput
+}
+
+// thinkingField returns the message/delta field that carries thinking for mode, or ""
+// when thinking is hidden. fallback is used when no mode is selected.
+func thinkingField(mode, fallback string) string {
+	switch mode {
+	case ThinkingOutputHidden:
+		return ""
+	case ThinkingOutputReasoningContent, ThinkingOutputReasoning:
+		return mode
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,176 @@
+package chat_completions
+
+import (
+	"slices"
+	"sync/atomic"
+
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Names of the built-in OpenAI → Claude request stages, in their default order.
+const (
+	StageParams       = "params"
+	StageMessages     = "messages"
+	StageTools        = "tools"
+	StageThinking     = "thinking"
+	StageCacheControl = "cache_control"
+)
+
+// RequestState is the conversion state handed from stage to stage.
+type RequestState struct {
+	// ModelName is the Claude model the request is sent to.
+	ModelName string
+	// Root is the parsed OpenAI Chat Completions request.
+	Root gjson.Result
+	// Stream reports whether a streaming response was requested.
+	Stream bool
+	// Out is the Claude Messages request built so far.
+	Out []byte
+
+	cacheMarkers []cacheMarker
+}
+
+type cacheMarker struct {
+	path    string
+	control string
+}
+
+// MarkCacheControl records a client cache_control marker for the Claude block at path (e.g.
+// "messages.2.content.0"). Markers are applied by the cache_control stage.
+func (s *RequestState) MarkCacheControl(path string, control gjson.Result) {
+	if path == "" || !control.IsObject() {
+		return
+	}
+	s.cacheMarkers = append(s.cacheMarkers, cacheMarker{path: path, control: control.Raw})
+}
+
+// RequestStage converts one aspect of an OpenAI request into the Claude request.
+type RequestStage func(s *RequestState)
+
+type namedRequestStage struct {
+	name  string
+	stage RequestStage
+}
+
+// RequestPipeline is an ordered list of named request stages. Its methods return modified
+// copies, so a pipeline can be shared while callers derive their own variants.
+type RequestPipeline struct {
+	stages []namedRequestStage
+}
+
+// DefaultRequestPipeline returns the built-in stages: params, messages, tools, thinking and
+// cache_control.
+func DefaultRequestPipeline() *RequestPipeline {
+	return &RequestPipeline{stages: []namedRequestStage{
+		{StageParams, convertOpenAIParamsToClaude},
+		{StageMessages, convertOpenAIMessagesToClaude},
+		{StageTools, convertOpenAIToolsToClaude},
+		{StageThinking, convertOpenAIReasoningToClaude},
+		{StageCacheControl, applyOpenAICacheControlMarkers},
+	}}
+}
+
+// Stages returns the stage names in execution order.
+func (p *RequestPipeline) Stages() []string {
+	names := make([]string, 0, len(p.stages))
+	for _, s := range p.stages {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+func (p *RequestPipeline) index(name string) int {
+	return slices.IndexFunc(p.stages, func(s namedRequestStage) bool { return s.name == name })
+}
+
+// Replace swaps the implementation of the named stage. Unknown names and nil stages are ignored.
+func (p *RequestPipeline) Replace(name string, stage RequestStage) *RequestPipeline {
+	next := &RequestPipeline{stages: slices.Clone(p.stages)}
+	if i := next.index(name); i >= 0 && stage != nil {
+		next.stages[i].stage = stage
+	}
+	return next
+}
+
+// Disable removes the named stages.
+func (p *RequestPipeline) Disable(names ...string) *RequestPipeline {
+	next := &RequestPipeline{stages: slices.Clone(p.stages)}
+	next.stages = slices.DeleteFunc(next.stages, func(s namedRequestStage) bool { return slices.Contains(names, s.name) })
+	return next
+}
+
+// InsertAfter adds a stage right after the stage named after, or at the end when after is
+// unknown. A stage with the same name is replaced instead.
+func (p *RequestPipeline) InsertAfter(after, name string, stage RequestStage) *RequestPipeline {
+	if stage == nil || name == "" {
+		return p
+	}
+	if p.index(name) >= 0 {
+		return p.Replace(name, stage)
+	}
+	next := &RequestPipeline{stages: slices.Clone(p.stages)}
+	position := len(next.stages)
+	if i := next.index(after); i >= 0 {
+		position = i + 1
+	}
+	next.stages = slices.Insert(next.stages, position, namedRequestStage{name, stage})
+	return next
+}
+
+// Run converts an OpenAI Chat Completions request into a Claude Messages request.
+func (p *RequestPipeline) Run(modelName string, rawJSON []byte, stream bool) []byte {
+	s := &RequestState{
+		ModelName: modelName,
+		Root:      gjson.ParseBytes(rawJSON),
+		Stream:    stream,
+		// Base Claude Code API template with default max_tokens value
+		Out: []byte(`{"model":"","max_tokens":32000,"messages":[]}`),
+	}
+	for _, stage := range p.stages {
+		stage.stage(s)
+	}
+	return translatorcommon.EnsureClaudeFilesAPIBeta(s.Out)
+}
+
+var (
+	requestPipeline       atomic.Pointer[RequestPipeline]
+	disabledRequestStages atomic.Pointer[[]string]
+)
+
+// SetRequestPipeline replaces the process-wide OpenAI → Claude request pipeline; nil restores
+// the default. Stages disabled through SetDisabledRequestStages stay disabled.
+func SetRequestPipeline(p *RequestPipeline) {
+	requestPipeline.Store(p)
+}
+
+// SetDisabledRequestStages sets the stages skipped by ConvertOpenAIRequestToClaude, typically
+// from configuration.
+func SetDisabledRequestStages(names []string) {
+	names = slices.Clone(names)
+	disabledRequestStages.Store(&names)
+}
+
+// CurrentRequestPipeline returns the pipeline ConvertOpenAIRequestToClaude runs.
+func CurrentRequestPipeline() *RequestPipeline {
+	p := requestPipeline.Load()
+	if p == nil {
+		p = DefaultRequestPipeline()
+	}
+	if disabled := disabledRequestStages.Load(); disabled != nil && len(*disabled) > 0 {
+		p = p.Disable(*disabled...)
+	}
+	return p
+}
+
+// applyOpenAICacheControlMarkers is the cache_control stage: it copies the cache_control
+// markers clients put on OpenAI content parts and tools onto the matching Claude blocks.
+func applyOpenAICacheControlMarkers(s *RequestState) {
+	for _, marker := range s.cacheMarkers {
+		if !gjson.GetBytes(s.Out, marker.path).Exists() {
+			continue
+		}
+		s.Out, _ = sjson.SetRawBytes(s.Out, marker.path+".cache_control", []byte(marker.control))
+	}
+}
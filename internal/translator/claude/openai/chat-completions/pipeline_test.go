@@ -0,0 +1,61 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestRequestPipelineCacheControlMarkers(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gpt-4.1",
+		"messages": [
+			{"role": "system", "content": [{"type": "text", "text": "rules", "cache_control": {"type": "ephemeral"}}]},
+			{"role": "user", "content": [{"type": "text", "text": "a"}, {"type": "text", "text": "b", "cache_control": {"type": "ephemeral"}}]}
+		],
+		"tools": [{"type": "function", "function": {"name": "f"}, "cache_control": {"type": "ephemeral"}}]
+	}`)
+
+	out := gjson.ParseBytes(DefaultRequestPipeline().Run("claude-sonnet-4-5", inputJSON, false))
+	for _, path := range []string{"system.0.cache_control.type", "messages.0.content.1.cache_control.type", "tools.0.cache_control.type"} {
+		if out.Get(path).String() != "ephemeral" {
+			t.Fatalf("expected %s to be set, got %s", path, out.Raw)
+		}
+	}
+	if out.Get("messages.0.content.0.cache_control").Exists() {
+		t.Fatalf("unexpected marker on an unmarked part: %s", out.Raw)
+	}
+
+	out = gjson.ParseBytes(DefaultRequestPipeline().Disable(StageCacheControl).Run("claude-sonnet-4-5", inputJSON, false))
+	if out.Get("system.0.cache_control").Exists() || out.Get("tools.0.cache_control").Exists() {
+		t.Fatalf("expected no markers with the cache_control stage disabled, got %s", out.Raw)
+	}
+}
+
+func TestRequestPipelineReplaceAndDisabledStages(t *testing.T) {
+	t.Cleanup(func() {
+		SetRequestPipeline(nil)
+		SetDisabledRequestStages(nil)
+	})
+	inputJSON := []byte(`{"model":"gpt-4.1","reasoning_effort":"high","messages":[{"role":"user","content":"hi"}]}`)
+
+	SetRequestPipeline(DefaultRequestPipeline().InsertAfter(StageParams, "metadata", func(s *RequestState) {
+		s.Out, _ = sjson.SetBytes(s.Out, "metadata.user_id", "u1")
+	}))
+	SetDisabledRequestStages([]string{StageThinking})
+
+	if got := CurrentRequestPipeline().Stages(); len(got) != 5 || got[1] != "metadata" {
+		t.Fatalf("stages = %v", got)
+	}
+	out := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", inputJSON, false))
+	if out.Get("metadata.user_id").String() != "u1" {
+		t.Fatalf("expected the custom stage to run, got %s", out.Raw)
+	}
+	if out.Get("thinking").Exists() {
+		t.Fatalf("expected the thinking stage to be disabled, got %s", out.Raw)
+	}
+	if out.Get("messages.0.content.0.text").String() != "hi" {
+		t.Fatalf("expected messages to be converted, got %s", out.Raw)
+	}
+}
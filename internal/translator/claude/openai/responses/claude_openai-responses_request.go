@@ -1,26 +1,15 @@
 package responses
 
 import (
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
-	"math/big"
 	"strings"
 
-	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
-var (
-	user    = ""
-	account = ""
-	session = ""
-)
-
 // ConvertOpenAIResponsesRequestToClaude transforms an OpenAI Responses API request
 // into a Claude Messages API request using only gjson/sjson for JSON handling.
 // It supports:
@@ -34,22 +23,8 @@ var (
 func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte, stream bool) []byte {
 	rawJSON := inputRawJSON
 
-	if account == "" {
-		u, _ := uuid.NewRandom()
-		account = u.String()
-	}
-	if session == "" {
-		u, _ := uuid.NewRandom()
-		session = u.String()
-	}
-	if user == "" {
-		sum := sha256.Sum256([]byte(account + session))
-		user = hex.EncodeToString(sum[:])
-	}
-	userID := fmt.Sprintf("user_%s_account_%s_session_%s", user, account, session)
-
 	// Base Claude message payload
-	out := []byte(fmt.Sprintf(`{"model":"","max_tokens":32000,"messages":[],"metadata":{"user_id":"%s"}}`, userID))
+	out := []byte(`{"model":"","max_tokens":32000,"messages":[]}`)
 
 	root := gjson.ParseBytes(rawJSON)
 
@@ -102,16 +77,9 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 		}
 	}
 
-	// Helper for generating tool call IDs when missing
-	genToolCallID := func() string {
-		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		var b strings.Builder
-		for i := 0; i < 24; i++ {
-			n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-			b.WriteByte(letters[n.Int64()])
-		}
-		return "toolu_" + b.String()
-	}
+	// Tool call IDs are mapped deterministically so resent conversations keep stable IDs.
+	toolIDs := translatorcommon.NewClaudeToolIDs()
+	toolCallPosition := 0
 
 	// Model
 	out, _ = sjson.SetBytes(out, "model", modelName)
@@ -203,58 +171,21 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 								role = "assistant"
 							}
 						case "input_image":
-							url := part.Get("image_url").String()
-							if url == "" {
-								url = part.Get("url").String()
-							}
-							if url != "" {
-								var contentPart []byte
-								if strings.HasPrefix(url, "data:") {
-									trimmed := strings.TrimPrefix(url, "data:")
-									mediaAndData := strings.SplitN(trimmed, ";base64,", 2)
-									mediaType := "application/octet-stream"
-									data := ""
-									if len(mediaAndData) == 2 {
-										if mediaAndData[0] != "" {
-											mediaType = mediaAndData[0]
-										}
-										data = mediaAndData[1]
-									}
-									if data != "" {
-										contentPart = []byte(`{"type":"image","source":{"type":"base64","media_type":"","data":""}}`)
-										contentPart, _ = sjson.SetBytes(contentPart, "source.media_type", mediaType)
-										contentPart, _ = sjson.SetBytes(contentPart, "source.data", data)
-									}
-								} else {
-									contentPart = []byte(`{"type":"image","source":{"type":"url","url":""}}`)
-									contentPart, _ = sjson.SetBytes(contentPart, "source.url", url)
-								}
-								if len(contentPart) > 0 {
-									partsJSON = append(partsJSON, string(contentPart))
-									if role == "" {
-										role = "user"
-									}
-									hasImage = true
+							if contentPart := convertResponsesImagePartToClaude(part); len(contentPart) > 0 {
+								partsJSON = append(partsJSON, string(contentPart))
+								if role == "" {
+									role = "user"
 								}
+								hasImage = true
 							}
 						case "input_file":
-							fileData := part.Get("file_data").String()
-							if fileData != "" {
-								mediaType := "application/octet-stream"
-								data := fileData
-								if strings.HasPrefix(fileData, "data:") {
-									trimmed := strings.TrimPrefix(fileData, "data:")
-									mediaAndData := strings.SplitN(trimmed, ";base64,", 2)
-									if len(mediaAndData) == 2 {
-										if mediaAndData[0] != "" {
-											mediaType = mediaAndData[0]
-										}
-										data = mediaAndData[1]
-									}
-								}
-								contentPart := []byte(`{"type":"document","source":{"type":"base64","media_type":"","data":""}}`)
-								contentPart, _ = sjson.SetBytes(contentPart, "source.media_type", mediaType)
-								contentPart, _ = sjson.SetBytes(contentPart, "source.data", data)
+							contentPart := translatorcommon.ClaudeFilePart(translatorcommon.OpenAIFileRef{
+								FileData: part.Get("file_data").String(),
+								FileID:   part.Get("file_id").String(),
+								FileURL:  part.Get("file_url").String(),
+								Filename: part.Get("filename").String(),
+							})
+							if len(contentPart) > 0 {
 								partsJSON = append(partsJSON, string(contentPart))
 								if role == "" {
 									role = "user"
@@ -302,16 +233,14 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 
 			case "function_call":
 				// Map to assistant tool_use
-				callID := item.Get("call_id").String()
-				if callID == "" {
-					callID = genToolCallID()
-				}
 				name := item.Get("name").String()
+				callID := toolIDs.ToolUse(item.Get("call_id").String(), name, toolCallPosition)
+				toolCallPosition++
 				argsStr := item.Get("arguments").String()
 
 				toolUse := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
 				toolUse, _ = sjson.SetBytes(toolUse, "id", callID)
-				toolUse, _ = sjson.SetBytes(toolUse, "name", name)
+				toolUse, _ = sjson.SetBytes(toolUse, "name", translatorcommon.ClaudeToolName(name))
 				if argsStr != "" && gjson.Valid(argsStr) {
 					argsJSON := gjson.Parse(argsStr)
 					if argsJSON.IsObject() {
@@ -326,10 +255,33 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 			case "function_call_output":
 				// Map to user tool_result
 				callID := item.Get("call_id").String()
-				outputStr := item.Get("output").String()
+				output := item.Get("output")
+				claudeCallID, known := toolIDs.ToolResult(callID)
+				if !known {
+					// Claude rejects tool results without a matching tool_use, so keep the output as user content.
+					usr := []byte(`{"role":"user","content":[{"type":"text","text":""}]}`)
+					usr, _ = sjson.SetBytes(usr, "content.0.text", "Result of tool call "+callID+":")
+					if output.IsArray() {
+						for _, part := range gjson.ParseBytes(convertResponsesToolOutputToClaude(output)).Array() {
+							usr, _ = sjson.SetRawBytes(usr, "content.-1", []byte(part.Raw))
+						}
+					} else if text := output.String(); text != "" {
+						part := []byte(`{"type":"text","text":""}`)
+						part, _ = sjson.SetBytes(part, "text", text)
+						usr, _ = sjson.SetRawBytes(usr, "content.-1", part)
+					}
+					out, _ = sjson.SetRawBytes(out, "messages.-1", usr)
+					break
+				}
+
 				toolResult := []byte(`{"type":"tool_result","tool_use_id":"","content":""}`)
-				toolResult, _ = sjson.SetBytes(toolResult, "tool_use_id", callID)
-				toolResult, _ = sjson.SetBytes(toolResult, "content", outputStr)
+				toolResult, _ = sjson.SetBytes(toolResult, "tool_use_id", claudeCallID)
+				if output.IsArray() {
+					// Keep text, image and file parts (e.g. screenshots) as tool_result blocks.
+					toolResult, _ = sjson.SetRawBytes(toolResult, "content", convertResponsesToolOutputToClaude(output))
+				} else {
+					toolResult, _ = sjson.SetBytes(toolResult, "content", output.String())
+				}
 
 				usr := []byte(`{"role":"user","content":[]}`)
 				usr, _ = sjson.SetRawBytes(usr, "content.-1", toolResult)
@@ -384,6 +336,7 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 				if mappedName := toolNameMap[fn]; mappedName != "" {
 					fn = mappedName
 				}
+				fn = translatorcommon.ClaudeToolName(fn)
 				if _, ok := includedToolNames[fn]; ok {
 					toolChoiceJSON := []byte(`{"name":"","type":"tool"}`)
 					toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "name", fn)
@@ -395,7 +348,65 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 		}
 	}
 
-	return out
+	return translatorcommon.EnsureClaudeFilesAPIBeta(out)
+}
+
+// convertResponsesImagePartToClaude converts an input_image part to a Claude image block, or
+// returns nil when it carries no usable URL.
+func convertResponsesImagePartToClaude(part gjson.Result) []byte {
+	url := part.Get("image_url").String()
+	if url == "" {
+		url = part.Get("url").String()
+	}
+	if url == "" {
+		return nil
+	}
+	if !strings.HasPrefix(url, "data:") {
+		contentPart := []byte(`{"type":"image","source":{"type":"url","url":""}}`)
+		contentPart, _ = sjson.SetBytes(contentPart, "source.url", url)
+		return contentPart
+	}
+	trimmed := strings.TrimPrefix(url, "data:")
+	mediaAndData := strings.SplitN(trimmed, ";base64,", 2)
+	if len(mediaAndData) != 2 || mediaAndData[1] == "" {
+		return nil
+	}
+	mediaType := "application/octet-stream"
+	if mediaAndData[0] != "" {
+		mediaType = mediaAndData[0]
+	}
+	contentPart := []byte(`{"type":"image","source":{"type":"base64","media_type":"","data":""}}`)
+	contentPart, _ = sjson.SetBytes(contentPart, "source.media_type", mediaType)
+	contentPart, _ = sjson.SetBytes(contentPart, "source.data", mediaAndData[1])
+	return contentPart
+}
+
+// convertResponsesToolOutputToClaude converts a function_call_output output array into Claude
+// tool_result content blocks. Unknown parts are dropped.
+func convertResponsesToolOutputToClaude(output gjson.Result) []byte {
+	content := []byte("[]")
+	output.ForEach(func(_, part gjson.Result) bool {
+		var contentPart []byte
+		switch part.Get("type").String() {
+		case "input_text", "output_text", "text":
+			contentPart = []byte(`{"type":"text","text":""}`)
+			contentPart, _ = sjson.SetBytes(contentPart, "text", part.Get("text").String())
+		case "input_image":
+			contentPart = convertResponsesImagePartToClaude(part)
+		case "input_file":
+			contentPart = translatorcommon.ClaudeFilePart(translatorcommon.OpenAIFileRef{
+				FileData: part.Get("file_data").String(),
+				FileID:   part.Get("file_id").String(),
+				FileURL:  part.Get("file_url").String(),
+				Filename: part.Get("filename").String(),
+			})
+		}
+		if len(contentPart) > 0 {
+			content, _ = sjson.SetRawBytes(content, "-1", contentPart)
+		}
+		return true
+	})
+	return content
 }
 
 func convertResponsesToolToClaudeTools(tool gjson.Result, toolNameMap map[string]string) [][]byte {
@@ -458,7 +469,7 @@ func convertResponsesFunctionToolToClaude(tool gjson.Result, overrideName string
 	}
 
 	tJSON := []byte(`{"name":"","description":"","input_schema":{}}`)
-	tJSON, _ = sjson.SetBytes(tJSON, "name", name)
+	tJSON, _ = sjson.SetBytes(tJSON, "name", translatorcommon.ClaudeToolName(name))
 	if d := responsesToolDescription(tool); d != "" {
 		tJSON, _ = sjson.SetBytes(tJSON, "description", d)
 	}
@@ -489,6 +500,26 @@ func convertResponsesWebSearchToolToClaude(tool gjson.Result) ([]byte, bool) {
 	return tJSON, true
 }
 
+// responsesClaudeToolNameMap maps the Claude tool names of a Responses request's function tools,
+// including namespaced ones, back to the names the client knows.
+func responsesClaudeToolNameMap(rawJSON []byte) map[string]string {
+	var names []string
+	gjson.GetBytes(rawJSON, "tools").ForEach(func(_, tool gjson.Result) bool {
+		switch strings.TrimSpace(tool.Get("type").String()) {
+		case "", "function":
+			names = append(names, responsesToolName(tool))
+		case "namespace":
+			namespaceName := strings.TrimSpace(tool.Get("name").String())
+			tool.Get("tools").ForEach(func(_, child gjson.Result) bool {
+				names = append(names, qualifyResponsesNamespaceToolName(namespaceName, responsesToolName(child)))
+				return true
+			})
+		}
+		return true
+	})
+	return translatorcommon.ClaudeToolNameMap(names)
+}
+
 func responsesToolName(tool gjson.Result) string {
 	if name := strings.TrimSpace(tool.Get("name").String()); name != "" {
 		return name
@@ -0,0 +1,109 @@
+package responses
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIResponsesRequestToClaude_MapsCoreFields(t *testing.T) {
+	input := []byte(`{
+		"model":"claude-sonnet-4-5",
+		"instructions":"Be concise.",
+		"max_output_tokens":2048,
+		"input":[
+			{"type":"message","role":"user","content":[{"type":"input_text","text":"What is the weather?"}]},
+			{"type":"function_call","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Paris\"}"},
+			{"type":"function_call_output","call_id":"call_1","output":"sunny"}
+		],
+		"tools":[{"type":"function","name":"get_weather","description":"Weather lookup","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}]
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToClaude("claude-sonnet-4-5", input, true)
+
+	if got := gjson.GetBytes(out, "max_tokens").Int(); got != 2048 {
+		t.Fatalf("max_tokens = %d, want 2048", got)
+	}
+	if !gjson.GetBytes(out, "stream").Bool() {
+		t.Fatal("expected stream to be true")
+	}
+	if got := gjson.GetBytes(out, "messages.0.content").String(); got != "Be concise." {
+		t.Fatalf("instructions not carried as leading message, got %q", got)
+	}
+	if got := gjson.GetBytes(out, "tools.0.name").String(); got != "get_weather" {
+		t.Fatalf("tool name = %q, want get_weather", got)
+	}
+	if !gjson.GetBytes(out, "tools.0.input_schema.properties.city").Exists() {
+		t.Fatalf("expected parameters mapped to input_schema, got %s", gjson.GetBytes(out, "tools").Raw)
+	}
+
+	var sawToolUse, sawToolResult bool
+	gjson.GetBytes(out, "messages").ForEach(func(_, msg gjson.Result) bool {
+		msg.Get("content").ForEach(func(_, part gjson.Result) bool {
+			switch part.Get("type").String() {
+			case "tool_use":
+				sawToolUse = msg.Get("role").String() == "assistant" && part.Get("id").String() == "call_1" && part.Get("input.city").String() == "Paris"
+			case "tool_result":
+				sawToolResult = msg.Get("role").String() == "user" && part.Get("tool_use_id").String() == "call_1"
+			}
+			return true
+		})
+		return true
+	})
+	if !sawToolUse || !sawToolResult {
+		t.Fatalf("expected function_call/function_call_output mapped to tool_use/tool_result, got %s", gjson.GetBytes(out, "messages").Raw)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToClaude_ToolOutputArrayKeepsImages(t *testing.T) {
+	input := []byte(`{
+		"model":"claude-sonnet-4-5",
+		"input":[
+			{"type":"function_call","call_id":"call_1","name":"screenshot","arguments":"{}"},
+			{"type":"function_call_output","call_id":"call_1","output":[
+				{"type":"input_text","text":"Captured the screen."},
+				{"type":"input_image","image_url":"data:image/png;base64,iVBORw0KGgo="},
+				{"type":"input_image","image_url":"https://example.com/shot.png"}
+			]}
+		]
+	}`)
+
+	out := ConvertOpenAIResponsesRequestToClaude("claude-sonnet-4-5", input, false)
+
+	content := gjson.GetBytes(out, "messages.1.content.0.content")
+	if !content.IsArray() || len(content.Array()) != 3 {
+		t.Fatalf("expected three tool_result blocks, got %s", gjson.GetBytes(out, "messages").Raw)
+	}
+	if got := content.Get("0.text").String(); got != "Captured the screen." {
+		t.Fatalf("text block = %q", got)
+	}
+	if got := content.Get("1.source.media_type").String(); got != "image/png" || content.Get("1.source.data").String() != "iVBORw0KGgo=" {
+		t.Fatalf("base64 image block = %s", content.Get("1").Raw)
+	}
+	if got := content.Get("2.source.url").String(); got != "https://example.com/shot.png" {
+		t.Fatalf("url image block = %s", content.Get("2").Raw)
+	}
+}
+
+func TestConvertOpenAIResponsesRequestToClaude_MapsToolCallIDs(t *testing.T) {
+	input := []byte(`{
+		"model": "claude-sonnet-4-5",
+		"input": [
+			{"type": "function_call", "call_id": "call.1", "name": "a", "arguments": "{}"},
+			{"type": "function_call_output", "call_id": "call.1", "output": "A"},
+			{"type": "function_call_output", "call_id": "call_unknown", "output": "B"}
+		]
+	}`)
+
+	out := gjson.ParseBytes(ConvertOpenAIResponsesRequestToClaude("claude-sonnet-4-5", input, false))
+	toolUseID := out.Get("messages.0.content.0.id").String()
+	if toolUseID == "" || toolUseID == "call.1" {
+		t.Fatalf("expected a sanitized tool_use id, got %q", toolUseID)
+	}
+	if got := out.Get("messages.1.content.0.tool_use_id").String(); got != toolUseID {
+		t.Fatalf("tool_result id = %q, want %q", got, toolUseID)
+	}
+	if got := out.Get("messages.2.content.#.text").Raw; got != `["Result of tool call call_unknown:","B"]` {
+		t.Fatalf("expected the orphan output as user text, got %s", out.Get("messages.2").Raw)
+	}
+}
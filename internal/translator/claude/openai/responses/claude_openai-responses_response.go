@@ -25,6 +25,8 @@ type claudeToResponsesState struct {
 	// function call bookkeeping for output aggregation
 	FuncNames   map[int]string // index -> function name
 	FuncCallIDs map[int]string // index -> call id
+	// ToolNameMap restores client tool names encoded for Claude.
+	ToolNameMap map[string]string
 	// message text aggregation
 	TextBuf        strings.Builder
 	CurrentTextBuf strings.Builder
@@ -59,7 +61,12 @@ func emitEvent(event string, payload []byte) []byte {
 // ConvertClaudeResponseToOpenAIResponses converts Claude SSE to OpenAI Responses SSE events.
 func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
 	if *param == nil {
-		*param = &claudeToResponsesState{FuncArgsBuf: make(map[int]*strings.Builder), FuncNames: make(map[int]string), FuncCallIDs: make(map[int]string)}
+		*param = &claudeToResponsesState{
+			FuncArgsBuf: make(map[int]*strings.Builder),
+			FuncNames:   make(map[int]string),
+			FuncCallIDs: make(map[int]string),
+			ToolNameMap: responsesClaudeToolNameMap(pickRequestJSON(originalRequestRawJSON, requestRawJSON)),
+		}
 	}
 	st := (*param).(*claudeToResponsesState)
 
@@ -144,7 +151,7 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 		} else if typ == "tool_use" {
 			st.InFuncBlock = true
 			st.CurrentFCID = cb.Get("id").String()
-			name := cb.Get("name").String()
+			name := translatorcommon.RestoreClaudeToolName(st.ToolNameMap, cb.Get("name").String())
 			item := []byte(`{"type":"response.output_item.added","sequence_number":0,"output_index":0,"item":{"id":"","type":"function_call","status":"in_progress","arguments":"","call_id":"","name":""}}`)
 			item, _ = sjson.SetBytes(item, "sequence_number", nextSeq())
 			item, _ = sjson.SetBytes(item, "output_index", idx)
@@ -448,6 +455,7 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 
 	// Collect SSE data: lines start with "data: "; ignore others
 	var chunks [][]byte
+	toolNameMap := responsesClaudeToolNameMap(pickRequestJSON(originalRequestRawJSON, requestRawJSON))
 	{
 		// Use a simple scanner to iterate through raw bytes
 		// Note: extremely large responses may require increasing the buffer
@@ -515,7 +523,7 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 				currentMsgID = "msg_" + responseID + "_0"
 			case "tool_use":
 				currentFCID = cb.Get("id").String()
-				name := cb.Get("name").String()
+				name := translatorcommon.RestoreClaudeToolName(toolNameMap, cb.Get("name").String())
 				if toolCalls[idx] == nil {
 					toolCalls[idx] = &toolState{id: currentFCID, name: name}
 				} else {
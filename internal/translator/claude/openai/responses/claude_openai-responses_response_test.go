@@ -0,0 +1,36 @@
+package responses
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestConvertClaudeResponseToOpenAIResponses_StreamEventTypes(t *testing.T) {
+	lines := []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":5,"output_tokens":0}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":1}}`,
+		`data: {"type":"message_stop"}`,
+	}
+
+	var param any
+	var events []string
+	for _, line := range lines {
+		for _, chunk := range ConvertClaudeResponseToOpenAIResponses(context.Background(), "claude-sonnet-4-5", nil, nil, []byte(line), &param) {
+			events = append(events, string(chunk))
+		}
+	}
+	joined := strings.Join(events, "\n")
+
+	for _, event := range []string{"response.created", "response.output_text.delta", "response.output_text.done", "response.completed"} {
+		if !strings.Contains(joined, "event: "+event) {
+			t.Fatalf("expected %s event in stream, got:\n%s", event, joined)
+		}
+	}
+	if !strings.Contains(joined, `"delta":"Hello"`) {
+		t.Fatalf("expected text delta in stream, got:\n%s", joined)
+	}
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"strconv"
 
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
@@ -65,3 +66,72 @@ func AppendSSEEventBytes(out []byte, event string, payload []byte, trailingNewli
 	}
 	return out
 }
+
+// JSONArray builds a JSON array by appending raw values to one buffer, avoiding the full
+// document rewrite each sjson "-1" append performs.
+type JSONArray struct {
+	buf []byte
+	n   int
+}
+
+// Grow reserves room for about size more bytes.
+func (a *JSONArray) Grow(size int) {
+	if size > cap(a.buf)-len(a.buf) {
+		grown := make([]byte, len(a.buf), len(a.buf)+size)
+		copy(grown, a.buf)
+		a.buf = grown
+	}
+}
+
+// Append adds a raw JSON value.
+func (a *JSONArray) Append(raw []byte) {
+	a.separate()
+	a.buf = append(a.buf, raw...)
+}
+
+// AppendFunc adds the value build appends to the array's buffer, avoiding an intermediate copy.
+func (a *JSONArray) AppendFunc(build func(dst []byte) []byte) {
+	a.separate()
+	a.buf = build(a.buf)
+}
+
+func (a *JSONArray) separate() {
+	if a.n == 0 {
+		a.buf = append(a.buf[:0], '[')
+	} else {
+		a.buf = append(a.buf, ',')
+	}
+	a.n++
+}
+
+// Len returns the number of values appended.
+func (a *JSONArray) Len() int {
+	return a.n
+}
+
+// Reset empties the array and keeps its buffer for reuse.
+func (a *JSONArray) Reset() {
+	a.buf = a.buf[:0]
+	a.n = 0
+}
+
+// Bytes returns the array. The result shares the builder's buffer and stays valid until the
+// next Append or Reset.
+func (a *JSONArray) Bytes() []byte {
+	if a.n == 0 {
+		return []byte("[]")
+	}
+	return append(a.buf, ']')
+}
+
+// ClaudeTextBlock returns a Claude text content block.
+func ClaudeTextBlock(text string) []byte {
+	return AppendClaudeTextBlock(make([]byte, 0, len(text)+32), text)
+}
+
+// AppendClaudeTextBlock appends a Claude text content block to dst.
+func AppendClaudeTextBlock(dst []byte, text string) []byte {
+	dst = append(dst, `{"type":"text","text":`...)
+	dst = gjson.AppendJSONString(dst, text)
+	return append(dst, '}')
+}
@@ -0,0 +1,158 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ClaudeFilesAPIBeta is the anthropic-beta flag required for document blocks that reference
+// uploaded files by file_id.
+const ClaudeFilesAPIBeta = "files-api-2025-04-14"
+
+// MaxClaudeDocumentBytes is the largest inline file accepted by the Claude Messages API.
+const MaxClaudeDocumentBytes = 32 << 20
+
+// OpenAIFileRef holds the fields of an OpenAI file content part (chat "file" or responses "input_file").
+type OpenAIFileRef struct {
+	FileData string
+	FileID   string
+	FileURL  string
+	Filename string
+}
+
+// ClaudeFilePart converts an OpenAI file reference into a Claude content block. Inline data may
+// be a data URL or bare base64; its media type is sniffed when missing or generic. PDFs and
+// other files become document blocks, text files become text documents and images become
+// image blocks. Files over MaxClaudeDocumentBytes are replaced by a text note so the model knows
+// content was omitted. It returns nil when the reference holds nothing usable.
+func ClaudeFilePart(ref OpenAIFileRef) []byte {
+	switch {
+	case ref.FileID != "":
+		part := []byte(`{"type":"document","source":{"type":"file","file_id":""}}`)
+		part, _ = sjson.SetBytes(part, "source.file_id", ref.FileID)
+		return withDocumentTitle(part, ref.Filename)
+	case ref.FileData != "":
+		return claudeInlineFilePart(ref.FileData, ref.Filename)
+	case strings.HasPrefix(ref.FileURL, "http://") || strings.HasPrefix(ref.FileURL, "https://"):
+		part := []byte(`{"type":"document","source":{"type":"url","url":""}}`)
+		part, _ = sjson.SetBytes(part, "source.url", ref.FileURL)
+		return withDocumentTitle(part, ref.Filename)
+	}
+	return nil
+}
+
+func claudeInlineFilePart(fileData, filename string) []byte {
+	mediaType, data := "", fileData
+	if strings.HasPrefix(fileData, "data:") {
+		header, payload, found := strings.Cut(strings.TrimPrefix(fileData, "data:"), ",")
+		if !found {
+			return nil
+		}
+		mediaType = strings.TrimSpace(strings.SplitN(header, ";", 2)[0])
+		data = payload
+	}
+	decoded, errDecode := base64.StdEncoding.DecodeString(data)
+	if errDecode != nil {
+		if decoded, errDecode = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "=")); errDecode != nil {
+			log.Warnf("openai->claude: dropping file part %q with invalid base64 data: %v", filename, errDecode)
+			return nil
+		}
+	}
+	if len(decoded) > MaxClaudeDocumentBytes {
+		log.Warnf("openai->claude: file part %q is %d bytes, above the %d byte limit; omitting it", filename, len(decoded), MaxClaudeDocumentBytes)
+		part := []byte(`{"type":"text","text":""}`)
+		part, _ = sjson.SetBytes(part, "text", fmt.Sprintf("[file %q omitted: %d bytes exceeds the %d MB limit]", filename, len(decoded), MaxClaudeDocumentBytes>>20))
+		return part
+	}
+	mediaType = sniffFileMediaType(mediaType, filename, decoded)
+
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		part := []byte(`{"type":"image","source":{"type":"base64","media_type":"","data":""}}`)
+		part, _ = sjson.SetBytes(part, "source.media_type", mediaType)
+		part, _ = sjson.SetBytes(part, "source.data", base64.StdEncoding.EncodeToString(decoded))
+		return part
+	case strings.HasPrefix(mediaType, "text/"):
+		part := []byte(`{"type":"document","source":{"type":"text","media_type":"text/plain","data":""}}`)
+		part, _ = sjson.SetBytes(part, "source.data", string(decoded))
+		return withDocumentTitle(part, filename)
+	default:
+		part := []byte(`{"type":"document","source":{"type":"base64","media_type":"","data":""}}`)
+		part, _ = sjson.SetBytes(part, "source.media_type", mediaType)
+		part, _ = sjson.SetBytes(part, "source.data", base64.StdEncoding.EncodeToString(decoded))
+		return withDocumentTitle(part, filename)
+	}
+}
+
+// sniffFileMediaType keeps a specific declared media type and otherwise derives one from the
+// content, falling back to the filename extension.
+func sniffFileMediaType(declared, filename string, data []byte) string {
+	declared = strings.ToLower(declared)
+	if declared != "" && declared != "application/octet-stream" {
+		return declared
+	}
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "application/pdf"
+	}
+	if detected, _, _ := strings.Cut(http.DetectContentType(data), ";"); detected != "application/octet-stream" {
+		return detected
+	}
+	if byExt, _, _ := strings.Cut(mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))), ";"); byExt != "" {
+		return byExt
+	}
+	return "application/octet-stream"
+}
+
+func withDocumentTitle(part []byte, filename string) []byte {
+	if filename == "" {
+		return part
+	}
+	part, _ = sjson.SetBytes(part, "title", filename)
+	return part
+}
+
+// EnsureClaudeFilesAPIBeta adds ClaudeFilesAPIBeta to the request's "betas" list when any
+// message content, including tool results, references an uploaded file by file_id.
+func EnsureClaudeFilesAPIBeta(body []byte) []byte {
+	if !claudeContentUsesFiles(gjson.GetBytes(body, "messages")) {
+		return body
+	}
+	return addClaudeBeta(body, ClaudeFilesAPIBeta)
+}
+
+// addClaudeBeta appends beta to the body's betas list, which the Claude executor moves into
+// the anthropic-beta header, unless it is already present.
+func addClaudeBeta(body []byte, beta string) []byte {
+	for _, existing := range gjson.GetBytes(body, "betas").Array() {
+		if existing.String() == beta {
+			return body
+		}
+	}
+	if !gjson.GetBytes(body, "betas").IsArray() {
+		body, _ = sjson.SetRawBytes(body, "betas", []byte("[]"))
+	}
+	body, _ = sjson.SetBytes(body, "betas.-1", beta)
+	return body
+}
+
+func claudeContentUsesFiles(node gjson.Result) bool {
+	found := false
+	node.ForEach(func(_, item gjson.Result) bool {
+		if item.Get("source.type").String() == "file" {
+			found = true
+		} else if content := item.Get("content"); content.IsArray() {
+			found = claudeContentUsesFiles(content)
+		}
+		return !found
+	})
+	return found
+}
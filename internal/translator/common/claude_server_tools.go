@@ -0,0 +1,73 @@
+package common
+
+import (
+	"regexp"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// claudeServerTool is an Anthropic-defined tool a client can request by its reserved name.
+type claudeServerTool struct {
+	Type string
+	Name string
+}
+
+// claudeServerTools maps reserved OpenAI function names to Anthropic-defined tools. web_search,
+// web_fetch and code_execution run on Anthropic's side; bash and the text editor are trained
+// client tools whose calls come back as regular tool_use blocks.
+var claudeServerTools = map[string]claudeServerTool{
+	"web_search":                  {Type: "web_search_20250305", Name: "web_search"},
+	"web_fetch":                   {Type: "web_fetch_20250910", Name: "web_fetch"},
+	"code_execution":              {Type: "code_execution_20250825", Name: "code_execution"},
+	"bash":                        {Type: "bash_20250124", Name: "bash"},
+	"str_replace_based_edit_tool": {Type: "text_editor_20250728", Name: "str_replace_based_edit_tool"},
+}
+
+// claudeServerToolBetas lists the anthropic-beta flags required by Anthropic-defined tool types.
+var claudeServerToolBetas = map[string]string{
+	"web_fetch_20250910":      "web-fetch-2025-09-10",
+	"code_execution_20250522": "code-execution-2025-05-22",
+	"code_execution_20250825": "code-execution-2025-08-25",
+	"computer_20250124":       "computer-use-2025-01-24",
+	"computer_20241022":       "computer-use-2024-10-22",
+	"bash_20241022":           "computer-use-2024-10-22",
+	"text_editor_20241022":    "computer-use-2024-10-22",
+}
+
+// claudeServerToolType matches versioned Anthropic tool types such as web_search_20250305.
+var claudeServerToolType = regexp.MustCompile(`^[a-z_]+_\d{8}$`)
+
+// ClaudeServerToolFromOpenAI converts an OpenAI chat tool into an Anthropic-defined tool block.
+// Tools already typed as a versioned Anthropic tool pass through unchanged, and function tools
+// named after a reserved tool are mapped when they declare no parameters of their own, so
+// client functions that merely share a name keep working.
+func ClaudeServerToolFromOpenAI(tool gjson.Result) ([]byte, bool) {
+	toolType := tool.Get("type").String()
+	if claudeServerToolType.MatchString(toolType) {
+		return []byte(tool.Raw), true
+	}
+	if toolType != "function" {
+		return nil, false
+	}
+	function := tool.Get("function")
+	serverTool, ok := claudeServerTools[function.Get("name").String()]
+	if !ok || len(function.Get("parameters.properties").Map()) > 0 {
+		return nil, false
+	}
+	out := []byte(`{"type":"","name":""}`)
+	out, _ = sjson.SetBytes(out, "type", serverTool.Type)
+	out, _ = sjson.SetBytes(out, "name", serverTool.Name)
+	return out, true
+}
+
+// EnsureClaudeServerToolBetas adds the anthropic-beta flags required by the Anthropic-defined
+// tools declared in a Claude request body.
+func EnsureClaudeServerToolBetas(body []byte) []byte {
+	for _, tool := range gjson.GetBytes(body, "tools").Array() {
+		if beta := claudeServerToolBetas[tool.Get("type").String()]; beta != "" {
+			body = addClaudeBeta(body, beta)
+		}
+	}
+	return body
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// claudeToolIDPattern matches the IDs Claude accepts for tool_use blocks.
+var claudeToolIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+var claudeToolIDInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ClaudeToolIDs maps the tool call IDs of one client conversation onto Claude tool_use IDs and
+// resolves the tool results referencing them.
+//
+// The mapping is deterministic, so a conversation resent on every turn maps its IDs the same
+// way each time: IDs valid for Claude are kept, others are sanitized with a hash suffix, calls
+// without an ID get one derived from their position and name, and an ID reused by a later call
+// gets a position suffix. Claude's own IDs are valid OpenAI IDs, so responses need no mapping.
+type ClaudeToolIDs struct {
+	toClaude map[string]string
+	used     map[string]struct{}
+	pending  []string
+}
+
+// NewClaudeToolIDs returns an empty mapping.
+func NewClaudeToolIDs() *ClaudeToolIDs {
+	return &ClaudeToolIDs{toClaude: make(map[string]string), used: make(map[string]struct{})}
+}
+
+// ToolUse registers a tool call found at position in the conversation and returns the ID to
+// send to Claude for it.
+func (m *ClaudeToolIDs) ToolUse(id, name string, position int) string {
+	var claudeID string
+	switch {
+	case id == "":
+		claudeID = "toolu_" + shortToolIDHash(fmt.Sprintf("%d:%s", position, name))
+	case claudeToolIDPattern.MatchString(id):
+		claudeID = id
+	default:
+		claudeID = claudeToolIDInvalidChars.ReplaceAllString(id, "_") + "_" + shortToolIDHash(id)
+	}
+	if _, taken := m.used[claudeID]; taken {
+		claudeID = fmt.Sprintf("%s_%d", claudeID, position)
+	}
+	m.used[claudeID] = struct{}{}
+	if id != "" {
+		m.toClaude[id] = claudeID
+	}
+	m.pending = append(m.pending, claudeID)
+	return claudeID
+}
+
+// ToolResult returns the Claude ID of the call a tool result with id answers. A result without
+// an ID answers the oldest unanswered call. ok is false when no registered call matches, which
+// Claude would reject.
+func (m *ClaudeToolIDs) ToolResult(id string) (claudeID string, ok bool) {
+	if id == "" {
+		if len(m.pending) == 0 {
+			return "", false
+		}
+		claudeID = m.pending[0]
+		m.pending = m.pending[1:]
+		return claudeID, true
+	}
+	claudeID, ok = m.toClaude[id]
+	if !ok {
+		return "", false
+	}
+	if i := slices.Index(m.pending, claudeID); i >= 0 {
+		m.pending = slices.Delete(m.pending, i, i+1)
+	}
+	return claudeID, true
+}
+
+func shortToolIDHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,48 @@
+package common
+
+// claudeToolNameMaxLen is the longest tool name Claude accepts.
+const claudeToolNameMaxLen = 128
+
+// ClaudeToolName returns name in a form Claude accepts for tool names: at most 128 characters
+// from [a-zA-Z0-9_-]. Invalid characters such as the dots of MCP names become underscores, and
+// overlong names are truncated with a hash suffix so distinct names stay distinct. Valid names
+// are returned unchanged.
+func ClaudeToolName(name string) string {
+	if len(name) <= claudeToolNameMaxLen && claudeToolIDPattern.MatchString(name) {
+		return name
+	}
+	encoded := claudeToolIDInvalidChars.ReplaceAllString(name, "_")
+	if len(encoded) > claudeToolNameMaxLen {
+		hash := shortToolIDHash(name)
+		encoded = encoded[:claudeToolNameMaxLen-len(hash)-1] + "_" + hash
+	}
+	return encoded
+}
+
+// ClaudeToolNameMap returns an encoded-name → original-name map for the names ClaudeToolName
+// changes, so tool_use names in Claude responses can be restored. The first name wins when two
+// names encode alike.
+func ClaudeToolNameMap(names []string) map[string]string {
+	var out map[string]string
+	for _, name := range names {
+		encoded := ClaudeToolName(name)
+		if encoded == name {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		if _, exists := out[encoded]; !exists {
+			out[encoded] = name
+		}
+	}
+	return out
+}
+
+// RestoreClaudeToolName returns the client name for a tool name found in a Claude response.
+func RestoreClaudeToolName(toolNameMap map[string]string, name string) string {
+	if original, ok := toolNameMap[name]; ok {
+		return original
+	}
+	return name
+}
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClaudeToolNameEncodesInvalidAndLongNames(t *testing.T) {
+	if got := ClaudeToolName("get_weather"); got != "get_weather" {
+		t.Fatalf("valid name changed to %q", got)
+	}
+	if got := ClaudeToolName("mcp.obsidian.search"); got != "mcp_obsidian_search" {
+		t.Fatalf("dotted name = %q", got)
+	}
+
+	long := strings.Repeat("a", 140)
+	other := strings.Repeat("a", 139) + "b"
+	encoded := ClaudeToolName(long)
+	if len(encoded) != claudeToolNameMaxLen || encoded == ClaudeToolName(other) {
+		t.Fatalf("expected distinct 128-char names, got %q", encoded)
+	}
+
+	names := ClaudeToolNameMap([]string{"get_weather", "mcp.obsidian.search", long})
+	if len(names) != 2 || RestoreClaudeToolName(names, encoded) != long || RestoreClaudeToolName(names, "get_weather") != "get_weather" {
+		t.Fatalf("unexpected name map %v", names)
+	}
+}
@@ -0,0 +1,57 @@
+package chat_completions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToGeminiCLI_WrapsRequest(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-2.5-pro",
+		"messages": [
+			{"role": "system", "content": "be brief"},
+			{"role": "user", "content": "hi"}
+		]
+	}`)
+
+	output := ConvertOpenAIRequestToGeminiCLI("gemini-2.5-pro", inputJSON, false)
+
+	if got := gjson.GetBytes(output, "model").String(); got != "gemini-2.5-pro" {
+		t.Fatalf("Expected model 'gemini-2.5-pro', got '%s'", got)
+	}
+	if got := gjson.GetBytes(output, "request.contents.0.parts.0.text").String(); got != "hi" {
+		t.Fatalf("Expected request.contents.0.parts.0.text 'hi', got '%s'", got)
+	}
+	if got := gjson.GetBytes(output, "request.systemInstruction.parts.0.text").String(); got != "be brief" {
+		t.Fatalf("Expected request.systemInstruction.parts.0.text 'be brief', got '%s'", got)
+	}
+	if gjson.GetBytes(output, "contents").Exists() {
+		t.Fatalf("Expected contents only inside the request envelope, got %s", output)
+	}
+}
+
+func TestConvertCliResponseToOpenAI_UnwrapsResponse(t *testing.T) {
+	var param any
+	chunks := ConvertCliResponseToOpenAI(context.Background(), "gemini-2.5-pro", nil, nil,
+		[]byte(`data: {"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"hello"}]}}],"modelVersion":"gemini-2.5-pro","responseId":"r1"}}`), &param)
+	if len(chunks) != 1 {
+		t.Fatalf("Expected 1 chunk, got %d", len(chunks))
+	}
+	if got := gjson.GetBytes(chunks[0], "choices.0.delta.content").String(); got != "hello" {
+		t.Fatalf("Expected delta content 'hello', got '%s'", got)
+	}
+
+	output := ConvertCliResponseToOpenAINonStream(context.Background(), "gemini-2.5-pro", nil, nil,
+		[]byte(`{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"hello"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":1,"totalTokenCount":4},"modelVersion":"gemini-2.5-pro","responseId":"r1"}}`), &param)
+	if got := gjson.GetBytes(output, "choices.0.message.content").String(); got != "hello" {
+		t.Fatalf("Expected message content 'hello', got '%s'", got)
+	}
+	if got := gjson.GetBytes(output, "choices.0.finish_reason").String(); got != "stop" {
+		t.Fatalf("Expected finish_reason 'stop', got '%s'", got)
+	}
+	if got := gjson.GetBytes(output, "usage.total_tokens").Int(); got != 4 {
+		t.Fatalf("Expected usage.total_tokens 4, got %d", got)
+	}
+}
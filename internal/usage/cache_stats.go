@@ -0,0 +1,90 @@
+package usage
+
+import (
+	"sort"
+	"strings"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// cacheWarnMinRequests is how many requests with cache writes a model needs before a low
+	// cache hit rate is reported.
+	cacheWarnMinRequests = 20
+	// cacheWarnHitRate is the hit rate below which cache writes are considered wasted.
+	cacheWarnHitRate = 0.01
+)
+
+// ModelCacheStats describes the prompt cache effectiveness of one model.
+type ModelCacheStats struct {
+	Model    string `json:"model"`
+	Requests int64  `json:"requests"`
+	// PromptTokens counts all prompt tokens, whether uncached, read from or written to the cache.
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CacheReadTokens  int64 `json:"cache_read_tokens"`
+	CacheWriteTokens int64 `json:"cache_write_tokens"`
+	// HitRate is the share of prompt tokens read from the cache, between 0 and 1.
+	HitRate float64 `json:"hit_rate"`
+	// EstimatedSavings is the USD saved by cache reads compared to uncached input, priced with
+	// the configured model prices.
+	EstimatedSavings float64 `json:"estimated_savings"`
+
+	writeRequests int64
+	warned        bool
+}
+
+// recordCacheLocked adds the prompt cache usage of a record to its model.
+func (s *RequestStatistics) recordCacheLocked(record coreusage.Record) {
+	detail := record.Detail
+	model := strings.TrimSpace(record.Model)
+	if model == "" {
+		return
+	}
+	stats, ok := s.cache[model]
+	if !ok {
+		if detail.CachedTokens == 0 && detail.CacheCreationTokens == 0 {
+			return
+		}
+		if s.cache == nil {
+			s.cache = make(map[string]*ModelCacheStats)
+		}
+		stats = &ModelCacheStats{Model: model}
+		s.cache[model] = stats
+	}
+	prompt := detail.InputTokens
+	if detail.InputExcludesCache {
+		prompt += detail.CachedTokens + detail.CacheCreationTokens
+	}
+	stats.Requests++
+	stats.PromptTokens += prompt
+	stats.CacheReadTokens += detail.CachedTokens
+	stats.CacheWriteTokens += detail.CacheCreationTokens
+	if stats.PromptTokens > 0 {
+		stats.HitRate = float64(stats.CacheReadTokens) / float64(stats.PromptTokens)
+	}
+	if price, okPrice := matchModelPrice(s.prices, model); okPrice {
+		cachedPrice := orDefaultPrice(price.CachedInputPerMillion, price.InputPerMillion)
+		stats.EstimatedSavings += float64(detail.CachedTokens) * (price.InputPerMillion - cachedPrice) / 1_000_000
+	}
+	if detail.CacheCreationTokens > 0 {
+		stats.writeRequests++
+	}
+	if !stats.warned && stats.writeRequests >= cacheWarnMinRequests && stats.HitRate < cacheWarnHitRate {
+		stats.warned = true
+		log.Warnf("prompt cache: %s wrote %d tokens over %d requests but read only %.2f%% of prompt tokens from cache; check prompt-cache breakpoint placement",
+			model, stats.CacheWriteTokens, stats.writeRequests, stats.HitRate*100)
+	}
+}
+
+func (s *RequestStatistics) cacheStatsLocked() []ModelCacheStats {
+	if len(s.cache) == 0 {
+		return nil
+	}
+	out := make([]ModelCacheStats, 0, len(s.cache))
+	for _, stats := range s.cache {
+		out = append(out, *stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
@@ -0,0 +1,228 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	fileStorePrefix = "usage-"
+	fileStoreSuffix = ".jsonl"
+)
+
+// FileStore persists rows as JSON lines in one file per UTC day, so date-range queries only
+// read the relevant days and retention removes whole files.
+type FileStore struct {
+	dir           string
+	retentionDays int
+
+	mu     sync.Mutex
+	day    string
+	file   *os.File
+	writer *bufio.Writer
+	now    func() time.Time
+	closed bool
+}
+
+// NewFileStore opens a file-backed store in dir. Files older than retentionDays are removed;
+// retentionDays <= 0 keeps every file.
+func NewFileStore(dir string, retentionDays int) (*FileStore, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, errors.New("usage store: directory is empty")
+	}
+	if errMkdir := os.MkdirAll(dir, 0o700); errMkdir != nil {
+		return nil, fmt.Errorf("usage store: create directory: %w", errMkdir)
+	}
+	s := &FileStore{dir: dir, retentionDays: retentionDays, now: time.Now}
+	s.pruneLocked(s.now())
+	return s, nil
+}
+
+// Append writes row to the file of its UTC day.
+func (s *FileStore) Append(row RequestRow) error {
+	data, errMarshal := json.Marshal(row)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("usage store: closed")
+	}
+	day := row.Timestamp.UTC().Format(time.DateOnly)
+	if day != s.day || s.file == nil {
+		if errOpen := s.openLocked(day); errOpen != nil {
+			return errOpen
+		}
+	}
+	if _, errWrite := s.writer.Write(append(data, '\n')); errWrite != nil {
+		return errWrite
+	}
+	return s.writer.Flush()
+}
+
+// Query scans the day files covered by the query range, newest first.
+func (s *FileStore) Query(q Query) (QueryResult, error) {
+	q = q.Normalize()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writer != nil {
+		if errFlush := s.writer.Flush(); errFlush != nil {
+			return QueryResult{}, errFlush
+		}
+	}
+	days, errList := s.listDaysLocked()
+	if errList != nil {
+		return QueryResult{}, errList
+	}
+	var page pager
+	page.init(q)
+	for i := len(days) - 1; i >= 0; i-- {
+		if !dayInRange(days[i], q) {
+			continue
+		}
+		rows, errRead := s.readDayLocked(days[i])
+		if errRead != nil {
+			return QueryResult{}, errRead
+		}
+		for j := len(rows) - 1; j >= 0; j-- {
+			if q.Matches(rows[j]) {
+				page.add(rows[j])
+			}
+		}
+	}
+	return page.result(), nil
+}
+
+// Close flushes and closes the current day file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.closeFileLocked()
+}
+
+func (s *FileStore) openLocked(day string) error {
+	if errClose := s.closeFileLocked(); errClose != nil {
+		log.Warnf("usage store: close day file: %v", errClose)
+	}
+	file, errOpen := os.OpenFile(s.pathFor(day), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if errOpen != nil {
+		return fmt.Errorf("usage store: open day file: %w", errOpen)
+	}
+	s.day = day
+	s.file = file
+	s.writer = bufio.NewWriter(file)
+	s.pruneLocked(s.now())
+	return nil
+}
+
+func (s *FileStore) closeFileLocked() error {
+	if s.file == nil {
+		return nil
+	}
+	errFlush := s.writer.Flush()
+	errClose := s.file.Close()
+	s.file = nil
+	s.writer = nil
+	s.day = ""
+	return errors.Join(errFlush, errClose)
+}
+
+func (s *FileStore) pathFor(day string) string {
+	return filepath.Join(s.dir, fileStorePrefix+day+fileStoreSuffix)
+}
+
+// listDaysLocked returns the days that have a file, oldest first.
+func (s *FileStore) listDaysLocked() ([]string, error) {
+	entries, errRead := os.ReadDir(s.dir)
+	if errRead != nil {
+		return nil, fmt.Errorf("usage store: list directory: %w", errRead)
+	}
+	days := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, fileStorePrefix) || !strings.HasSuffix(name, fileStoreSuffix) {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, fileStorePrefix), fileStoreSuffix)
+		if _, errParse := time.Parse(time.DateOnly, day); errParse != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func (s *FileStore) readDayLocked(day string) ([]RequestRow, error) {
+	file, errOpen := os.Open(s.pathFor(day))
+	if errOpen != nil {
+		if errors.Is(errOpen, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("usage store: open day file: %w", errOpen)
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			log.Warnf("usage store: close day file: %v", errClose)
+		}
+	}()
+	var rows []RequestRow
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var row RequestRow
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &row); errUnmarshal != nil {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return nil, fmt.Errorf("usage store: read day file: %w", errScan)
+	}
+	return rows, nil
+}
+
+// pruneLocked removes day files older than the retention window.
+func (s *FileStore) pruneLocked(now time.Time) {
+	if s.retentionDays <= 0 {
+		return
+	}
+	days, errList := s.listDaysLocked()
+	if errList != nil {
+		log.Warnf("%v", errList)
+		return
+	}
+	cutoff := now.UTC().AddDate(0, 0, -s.retentionDays).Format(time.DateOnly)
+	for _, day := range days {
+		if day >= cutoff || day == s.day {
+			continue
+		}
+		if errRemove := os.Remove(s.pathFor(day)); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+			log.Warnf("usage store: remove expired day file: %v", errRemove)
+		}
+	}
+}
+
+// dayInRange reports whether a UTC day file can contain rows inside the query range.
+func dayInRange(day string, q Query) bool {
+	if !q.From.IsZero() && day < q.From.UTC().Format(time.DateOnly) {
+		return false
+	}
+	if !q.To.IsZero() && day > q.To.UTC().Format(time.DateOnly) {
+		return false
+	}
+	return true
+}
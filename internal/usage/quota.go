@@ -0,0 +1,343 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// QuotaLimits bounds the usage of a single client API key. Zero values mean unlimited.
+type QuotaLimits struct {
+	DailyRequests   int64   `json:"daily-requests,omitempty"`
+	MonthlyRequests int64   `json:"monthly-requests,omitempty"`
+	DailyTokens     int64   `json:"daily-tokens,omitempty"`
+	MonthlyTokens   int64   `json:"monthly-tokens,omitempty"`
+	MonthlyBudget   float64 `json:"monthly-budget,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (l QuotaLimits) IsZero() bool {
+	return l.DailyRequests <= 0 && l.MonthlyRequests <= 0 && l.DailyTokens <= 0 && l.MonthlyTokens <= 0 && l.MonthlyBudget <= 0
+}
+
+// ModelPrice is the USD price per million tokens used to compute spend and charge the monthly
+// budget. Cache read and write prices default to InputPerMillion.
+type ModelPrice struct {
+	// Model is an exact model name, or a prefix when it ends with "*".
+	Model                 string
+	InputPerMillion       float64
+	OutputPerMillion      float64
+	CachedInputPerMillion float64
+	CacheWritePerMillion  float64
+}
+
+// QuotaSettings configures the quota engine.
+type QuotaSettings struct {
+	// Default applies to every API key without an entry in Keys.
+	Default QuotaLimits
+	// Keys overrides Default for specific API keys.
+	Keys map[string]QuotaLimits
+	// Prices converts token usage to spend for MonthlyBudget. When empty, the prices set with
+	// SetPrices are used.
+	Prices []ModelPrice
+}
+
+// QuotaUsage holds the consumption of one API key in the current day and month.
+type QuotaUsage struct {
+	Day             string  `json:"day"`
+	Month           string  `json:"month"`
+	DailyRequests   int64   `json:"daily-requests"`
+	MonthlyRequests int64   `json:"monthly-requests"`
+	DailyTokens     int64   `json:"daily-tokens"`
+	MonthlyTokens   int64   `json:"monthly-tokens"`
+	MonthlyCost     float64 `json:"monthly-cost"`
+}
+
+// QuotaRemaining holds the remaining allowance per limit; nil fields are unlimited.
+type QuotaRemaining struct {
+	DailyRequests   *int64   `json:"daily-requests,omitempty"`
+	MonthlyRequests *int64   `json:"monthly-requests,omitempty"`
+	DailyTokens     *int64   `json:"daily-tokens,omitempty"`
+	MonthlyTokens   *int64   `json:"monthly-tokens,omitempty"`
+	MonthlyBudget   *float64 `json:"monthly-budget,omitempty"`
+}
+
+// QuotaStatus describes the limits, usage and remaining allowance of one API key.
+type QuotaStatus struct {
+	APIKey    string         `json:"api-key"`
+	Limits    QuotaLimits    `json:"limits"`
+	Usage     QuotaUsage     `json:"usage"`
+	Remaining QuotaRemaining `json:"remaining"`
+	// Exceeded names the exhausted limit; empty while the key is within quota.
+	Exceeded string `json:"exceeded,omitempty"`
+	// ResetAt is when the exhausted limit frees up again.
+	ResetAt time.Time `json:"reset-at,omitzero"`
+}
+
+// SetQuotas replaces the quota configuration. Recorded usage is kept.
+func (s *RequestStatistics) SetQuotas(settings QuotaSettings) {
+	if s == nil {
+		return
+	}
+	keys := make(map[string]QuotaLimits, len(settings.Keys))
+	for key, limits := range settings.Keys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys[key] = limits
+		}
+	}
+	settings.Keys = keys
+	settings.Prices = append([]ModelPrice(nil), settings.Prices...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quota = settings
+}
+
+// QuotaEnabled reports whether any quota limit is configured.
+func (s *RequestStatistics) QuotaEnabled() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.quotaEnabledLocked()
+}
+
+func (s *RequestStatistics) quotaEnabledLocked() bool {
+	return !s.quota.Default.IsZero() || len(s.quota.Keys) > 0
+}
+
+// CheckQuota returns the quota status of apiKey and whether another request is allowed.
+func (s *RequestStatistics) CheckQuota(apiKey string) (QuotaStatus, bool) {
+	if s == nil {
+		return QuotaStatus{APIKey: apiKey}, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.quotaStatusLocked(apiKey, s.now())
+	return status, status.Exceeded == ""
+}
+
+// QuotaStatuses returns the status of every API key that has limits or recorded usage.
+func (s *RequestStatistics) QuotaStatuses() []QuotaStatus {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	seen := make(map[string]struct{}, len(s.quota.Keys)+len(s.quotaUsage))
+	for key := range s.quota.Keys {
+		seen[key] = struct{}{}
+	}
+	for key := range s.quotaUsage {
+		seen[key] = struct{}{}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]QuotaStatus, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, s.quotaStatusLocked(key, now))
+	}
+	return out
+}
+
+// ResetQuota clears the recorded quota usage of apiKey, or of every key when apiKey is empty.
+// It reports whether any usage was cleared.
+func (s *RequestStatistics) ResetQuota(apiKey string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		cleared := len(s.quotaUsage) > 0
+		s.quotaUsage = nil
+		return cleared
+	}
+	if _, ok := s.quotaUsage[apiKey]; !ok {
+		return false
+	}
+	delete(s.quotaUsage, apiKey)
+	return true
+}
+
+// recordQuotaLocked charges a usage record against the quota of its API key.
+func (s *RequestStatistics) recordQuotaLocked(record coreusage.Record, now time.Time) {
+	apiKey := strings.TrimSpace(record.APIKey)
+	if apiKey == "" || !s.quotaEnabledLocked() {
+		return
+	}
+	if s.quotaUsage == nil {
+		s.quotaUsage = make(map[string]*QuotaUsage)
+	}
+	entry, ok := s.quotaUsage[apiKey]
+	if !ok {
+		entry = &QuotaUsage{}
+		s.quotaUsage[apiKey] = entry
+	}
+	s.rolloverQuotaLocked(entry, now)
+
+	detail := record.Detail
+	tokens := detail.TotalTokens
+	if tokens == 0 {
+		tokens = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
+	}
+	entry.DailyRequests++
+	entry.MonthlyRequests++
+	entry.DailyTokens += tokens
+	entry.MonthlyTokens += tokens
+	prices := s.quota.Prices
+	if len(prices) == 0 {
+		prices = s.prices
+	}
+	entry.MonthlyCost += priceDetail(prices, record.Model, detail)
+}
+
+func (s *RequestStatistics) quotaStatusLocked(apiKey string, now time.Time) QuotaStatus {
+	limits, ok := s.quota.Keys[apiKey]
+	if !ok {
+		limits = s.quota.Default
+	}
+	status := QuotaStatus{APIKey: apiKey, Limits: limits}
+	if entry, okUsage := s.quotaUsage[apiKey]; okUsage {
+		s.rolloverQuotaLocked(entry, now)
+		status.Usage = *entry
+	} else {
+		status.Usage.Day, status.Usage.Month = s.quotaPeriodsLocked(now)
+	}
+
+	used := status.Usage
+	nextDay, nextMonth := s.quotaBoundariesLocked(now)
+	check := func(name string, limit, consumed int64, reset time.Time) *int64 {
+		if limit <= 0 {
+			return nil
+		}
+		remaining := max(limit-consumed, 0)
+		if remaining == 0 && status.Exceeded == "" {
+			status.Exceeded = name
+			status.ResetAt = reset
+		}
+		return &remaining
+	}
+	status.Remaining.DailyRequests = check("daily-requests", limits.DailyRequests, used.DailyRequests, nextDay)
+	status.Remaining.MonthlyRequests = check("monthly-requests", limits.MonthlyRequests, used.MonthlyRequests, nextMonth)
+	status.Remaining.DailyTokens = check("daily-tokens", limits.DailyTokens, used.DailyTokens, nextDay)
+	status.Remaining.MonthlyTokens = check("monthly-tokens", limits.MonthlyTokens, used.MonthlyTokens, nextMonth)
+	if limits.MonthlyBudget > 0 {
+		remaining := max(limits.MonthlyBudget-used.MonthlyCost, 0)
+		if remaining == 0 && status.Exceeded == "" {
+			status.Exceeded = "monthly-budget"
+			status.ResetAt = nextMonth
+		}
+		status.Remaining.MonthlyBudget = &remaining
+	}
+	return status
+}
+
+// ExceededMessage describes the exhausted limit for client-facing errors.
+func (q QuotaStatus) ExceededMessage() string {
+	switch q.Exceeded {
+	case "daily-requests":
+		return fmt.Sprintf("daily request limit of %d reached", q.Limits.DailyRequests)
+	case "monthly-requests":
+		return fmt.Sprintf("monthly request limit of %d reached", q.Limits.MonthlyRequests)
+	case "daily-tokens":
+		return fmt.Sprintf("daily token limit of %d reached", q.Limits.DailyTokens)
+	case "monthly-tokens":
+		return fmt.Sprintf("monthly token limit of %d reached", q.Limits.MonthlyTokens)
+	case "monthly-budget":
+		return fmt.Sprintf("monthly budget of $%.2f reached", q.Limits.MonthlyBudget)
+	default:
+		return ""
+	}
+}
+
+// rolloverQuotaLocked resets the daily and monthly counters when their period has ended.
+func (s *RequestStatistics) rolloverQuotaLocked(entry *QuotaUsage, now time.Time) {
+	day, month := s.quotaPeriodsLocked(now)
+	if entry.Day != day {
+		entry.Day = day
+		entry.DailyRequests = 0
+		entry.DailyTokens = 0
+	}
+	if entry.Month != month {
+		entry.Month = month
+		entry.MonthlyRequests = 0
+		entry.MonthlyTokens = 0
+		entry.MonthlyCost = 0
+	}
+}
+
+// quotaTimeLocked returns now in the zone used for quota periods: local time when the
+// daily reset mode is local, UTC otherwise.
+func (s *RequestStatistics) quotaTimeLocked(now time.Time) time.Time {
+	if s.resetMode == DailyResetLocal {
+		return now.Local()
+	}
+	return now.UTC()
+}
+
+func (s *RequestStatistics) quotaPeriodsLocked(now time.Time) (string, string) {
+	t := s.quotaTimeLocked(now)
+	return t.Format(time.DateOnly), t.Format("2006-01")
+}
+
+func (s *RequestStatistics) quotaBoundariesLocked(now time.Time) (time.Time, time.Time) {
+	t := s.quotaTimeLocked(now)
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location()), time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+}
+
+// priceDetail prices a usage detail with the first matching model price. Costs reported by the
+// upstream take precedence over configured prices.
+func priceDetail(prices []ModelPrice, model string, detail coreusage.Detail) float64 {
+	if detail.Cost > 0 {
+		return detail.Cost
+	}
+	price, ok := matchModelPrice(prices, model)
+	if !ok {
+		return 0
+	}
+	cached := detail.CachedTokens
+	uncached := detail.InputTokens
+	if !detail.InputExcludesCache {
+		cached = min(cached, detail.InputTokens)
+		uncached -= cached
+	}
+	input := float64(uncached) * price.InputPerMillion
+	input += float64(cached) * orDefaultPrice(price.CachedInputPerMillion, price.InputPerMillion)
+	input += float64(detail.CacheCreationTokens) * orDefaultPrice(price.CacheWritePerMillion, price.InputPerMillion)
+	output := float64(detail.OutputTokens+detail.ReasoningTokens) * price.OutputPerMillion
+	return (input + output) / 1_000_000
+}
+
+func orDefaultPrice(price, fallback float64) float64 {
+	if price <= 0 {
+		return fallback
+	}
+	return price
+}
+
+func matchModelPrice(prices []ModelPrice, model string) (ModelPrice, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, price := range prices {
+		pattern := strings.ToLower(strings.TrimSpace(price.Model))
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(model, prefix) {
+				return price, true
+			}
+			continue
+		}
+		if pattern == model {
+			return price, true
+		}
+	}
+	return ModelPrice{}, false
+}
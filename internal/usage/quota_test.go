@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRequestStatisticsQuotaExhaustsAndResetsDaily(t *testing.T) {
+	now := time.Date(2026, 4, 25, 23, 0, 0, 0, time.UTC)
+	stats := NewRequestStatistics()
+	stats.now = func() time.Time { return now }
+	stats.SetQuotas(QuotaSettings{
+		Default: QuotaLimits{DailyRequests: 2},
+		Keys:    map[string]QuotaLimits{"vip": {DailyTokens: 100}},
+	})
+
+	stats.RecordQuota(coreusage.Record{APIKey: "k1"})
+	if _, allowed := stats.CheckQuota("k1"); !allowed {
+		t.Fatal("k1 should be within quota after one request")
+	}
+	stats.RecordQuota(coreusage.Record{APIKey: "k1"})
+	status, allowed := stats.CheckQuota("k1")
+	if allowed || status.Exceeded != "daily-requests" {
+		t.Fatalf("expected daily-requests exhaustion, got allowed=%v status=%+v", allowed, status)
+	}
+	if want := time.Date(2026, 4, 26, 0, 0, 0, 0, time.UTC); !status.ResetAt.Equal(want) {
+		t.Fatalf("reset-at = %v, want %v", status.ResetAt, want)
+	}
+
+	stats.RecordQuota(coreusage.Record{APIKey: "vip", Detail: coreusage.Detail{InputTokens: 60, OutputTokens: 50}})
+	if status, allowed = stats.CheckQuota("vip"); allowed || status.Exceeded != "daily-tokens" {
+		t.Fatalf("expected vip daily-tokens exhaustion, got allowed=%v status=%+v", allowed, status)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, allowed = stats.CheckQuota("k1"); !allowed {
+		t.Fatal("k1 should be allowed again after the day rolled over")
+	}
+	status, _ = stats.CheckQuota("vip")
+	if status.Usage.DailyTokens != 0 || status.Usage.MonthlyTokens != 110 {
+		t.Fatalf("unexpected vip usage after rollover: %+v", status.Usage)
+	}
+}
+
+func TestRequestStatisticsQuotaMonthlyBudget(t *testing.T) {
+	stats := NewRequestStatistics()
+	stats.SetQuotas(QuotaSettings{
+		Default: QuotaLimits{MonthlyBudget: 1},
+		Prices:  []ModelPrice{{Model: "claude-sonnet-*", InputPerMillion: 3, OutputPerMillion: 15}},
+	})
+
+	stats.RecordQuota(coreusage.Record{APIKey: "k", Model: "claude-sonnet-4-5", Detail: coreusage.Detail{InputTokens: 100_000, OutputTokens: 50_000}})
+	status, allowed := stats.CheckQuota("k")
+	if allowed || status.Exceeded != "monthly-budget" {
+		t.Fatalf("expected budget exhaustion, got allowed=%v status=%+v", allowed, status)
+	}
+	if cost := status.Usage.MonthlyCost; cost < 1.049 || cost > 1.051 {
+		t.Fatalf("monthly cost = %f, want 1.05", cost)
+	}
+
+	stats.RecordQuota(coreusage.Record{APIKey: "other", Model: "unpriced", Detail: coreusage.Detail{InputTokens: 1_000_000}})
+	if _, allowed = stats.CheckQuota("other"); !allowed {
+		t.Fatal("unpriced models should not consume the budget")
+	}
+
+	stats.RecordQuota(coreusage.Record{APIKey: "reported", Model: "unpriced", Detail: coreusage.Detail{InputTokens: 10, Cost: 0.25}})
+	if status, _ = stats.CheckQuota("reported"); status.Usage.MonthlyCost != 0.25 {
+		t.Fatalf("reported cost = %f, want 0.25", status.Usage.MonthlyCost)
+	}
+
+	if !stats.ResetQuota("k") {
+		t.Fatal("expected reset to clear recorded usage")
+	}
+	if _, allowed = stats.CheckQuota("k"); !allowed {
+		t.Fatal("k should be allowed after reset")
+	}
+}
@@ -0,0 +1,90 @@
+package usage
+
+import (
+	"sort"
+	"strings"
+)
+
+// ReportItem is the usage of one model or API key in a DailyReport.
+type ReportItem struct {
+	Name string `json:"name"`
+	SpendTotals
+}
+
+// DailyReport summarizes the usage recorded for one day.
+type DailyReport struct {
+	Date   string      `json:"date"`
+	Totals SpendTotals `json:"totals"`
+	// FailureRate is the share of failed requests, between 0 and 1.
+	FailureRate float64 `json:"failure_rate"`
+	// CacheSavings is the USD saved by prompt cache reads compared to uncached input, priced
+	// with the configured model prices.
+	CacheSavings float64      `json:"cache_savings"`
+	TopModels    []ReportItem `json:"top_models"`
+	TopKeys      []ReportItem `json:"top_keys"`
+}
+
+// DailyReport returns the usage of day (YYYY-MM-DD in the quota calendar) with the topN models
+// and API keys by cost, then by requests. topN <= 0 lists all of them.
+func (s *RequestStatistics) DailyReport(day string, topN int) DailyReport {
+	report := DailyReport{Date: day}
+	if s == nil {
+		return report
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	models := make(map[string]*SpendTotals)
+	keys := make(map[string]*SpendTotals)
+	for key, totals := range s.spend {
+		if key.day != day {
+			continue
+		}
+		report.Totals.add(*totals)
+		addReportItem(models, key.model, *totals)
+		addReportItem(keys, key.apiKey, *totals)
+		if price, ok := matchModelPrice(s.prices, key.model); ok {
+			cachedPrice := orDefaultPrice(price.CachedInputPerMillion, price.InputPerMillion)
+			report.CacheSavings += float64(totals.CachedTokens) * (price.InputPerMillion - cachedPrice) / 1_000_000
+		}
+	}
+	if report.Totals.Requests > 0 {
+		report.FailureRate = float64(report.Totals.FailedRequests) / float64(report.Totals.Requests)
+	}
+	report.TopModels = topReportItems(models, topN)
+	report.TopKeys = topReportItems(keys, topN)
+	return report
+}
+
+func addReportItem(items map[string]*SpendTotals, name string, totals SpendTotals) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "unknown"
+	}
+	item, ok := items[name]
+	if !ok {
+		item = &SpendTotals{}
+		items[name] = item
+	}
+	item.add(totals)
+}
+
+func topReportItems(items map[string]*SpendTotals, topN int) []ReportItem {
+	out := make([]ReportItem, 0, len(items))
+	for name, totals := range items {
+		out = append(out, ReportItem{Name: name, SpendTotals: *totals})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost > out[j].Cost
+		}
+		if out[i].Requests != out[j].Requests {
+			return out[i].Requests > out[j].Requests
+		}
+		return out[i].Name < out[j].Name
+	})
+	if topN > 0 && len(out) > topN {
+		out = out[:topN]
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRequestStatisticsDailyReport(t *testing.T) {
+	now := time.Date(2026, 4, 25, 12, 0, 0, 0, time.UTC)
+	stats := NewRequestStatistics()
+	stats.now = func() time.Time { return now }
+	stats.SetPrices([]ModelPrice{{Model: "claude-*", InputPerMillion: 3, OutputPerMillion: 15, CachedInputPerMillion: 0.3}})
+
+	stats.Record(coreusage.Record{APIKey: "key-a", Model: "claude-sonnet-4-5", Detail: coreusage.Detail{InputTokens: 1_000_000, CachedTokens: 1_000_000}})
+	stats.Record(coreusage.Record{APIKey: "key-b", Model: "gpt-5", Failed: true})
+	stats.Record(coreusage.Record{APIKey: "key-b", Model: "gpt-5"})
+	now = now.Add(24 * time.Hour)
+	stats.Record(coreusage.Record{APIKey: "key-c", Model: "gpt-5"})
+
+	report := stats.DailyReport("2026-04-25", 1)
+	if report.Totals.Requests != 3 || math.Abs(report.FailureRate-1.0/3) > 1e-9 {
+		t.Fatalf("unexpected totals %+v failure rate %v", report.Totals, report.FailureRate)
+	}
+	if math.Abs(report.CacheSavings-2.7) > 1e-9 {
+		t.Fatalf("cache savings = %v, want 2.7", report.CacheSavings)
+	}
+	if len(report.TopModels) != 1 || report.TopModels[0].Name != "claude-sonnet-4-5" {
+		t.Fatalf("unexpected top models %+v", report.TopModels)
+	}
+	if len(report.TopKeys) != 1 || report.TopKeys[0].Name != "key-a" {
+		t.Fatalf("unexpected top keys %+v", report.TopKeys)
+	}
+}
+
+func TestRequestStatisticsCacheStats(t *testing.T) {
+	stats := NewRequestStatistics()
+	stats.SetPrices([]ModelPrice{{Model: "claude-*", InputPerMillion: 3, CachedInputPerMillion: 0.3}})
+
+	stats.Record(coreusage.Record{Model: "claude-sonnet-4-5", Detail: coreusage.Detail{InputTokens: 100, CacheCreationTokens: 900_000, InputExcludesCache: true}})
+	stats.Record(coreusage.Record{Model: "claude-sonnet-4-5", Detail: coreusage.Detail{InputTokens: 100, CachedTokens: 1_000_000, InputExcludesCache: true}})
+	stats.Record(coreusage.Record{Model: "gpt-5", Detail: coreusage.Detail{InputTokens: 100}})
+
+	cache := stats.Snapshot().Cache
+	if len(cache) != 1 || cache[0].Model != "claude-sonnet-4-5" || cache[0].Requests != 2 {
+		t.Fatalf("unexpected cache stats %+v", cache)
+	}
+	if cache[0].PromptTokens != 1_900_200 || math.Abs(cache[0].HitRate-1_000_000.0/1_900_200) > 1e-9 {
+		t.Fatalf("unexpected prompt tokens or hit rate %+v", cache[0])
+	}
+	if math.Abs(cache[0].EstimatedSavings-2.7) > 1e-9 {
+		t.Fatalf("estimated savings = %v, want 2.7", cache[0].EstimatedSavings)
+	}
+}
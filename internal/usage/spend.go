@@ -0,0 +1,150 @@
+package usage
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// SpendTotals holds the usage and cost of one API key in one day.
+type SpendTotals struct {
+	Requests            int64   `json:"requests"`
+	FailedRequests      int64   `json:"failed_requests,omitempty"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CachedTokens        int64   `json:"cached_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens,omitempty"`
+	Cost                float64 `json:"cost"`
+}
+
+// SpendEntry is the spend of one API key in one day.
+type SpendEntry struct {
+	Date   string `json:"date"`
+	APIKey string `json:"api_key"`
+	SpendTotals
+}
+
+// SpendReport summarizes the spend of a date range.
+type SpendReport struct {
+	From     string             `json:"from,omitempty"`
+	To       string             `json:"to,omitempty"`
+	APIKey   string             `json:"api_key,omitempty"`
+	Total    float64            `json:"total"`
+	ByAPIKey map[string]float64 `json:"by_api_key"`
+	Entries  []SpendEntry       `json:"entries"`
+}
+
+type spendKey struct {
+	day    string
+	apiKey string
+	model  string
+}
+
+// SetPrices replaces the model prices used to compute spend. Recorded spend is kept.
+func (s *RequestStatistics) SetPrices(prices []ModelPrice) {
+	if s == nil {
+		return
+	}
+	prices = append([]ModelPrice(nil), prices...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices = prices
+}
+
+// SpendReport returns the spend recorded between from and to (inclusive YYYY-MM-DD dates; empty
+// bounds are open), optionally restricted to one API key. Days follow the quota calendar: UTC
+// unless the daily reset mode is local.
+func (s *RequestStatistics) SpendReport(from, to, apiKey string) SpendReport {
+	report := SpendReport{From: from, To: to, APIKey: apiKey, ByAPIKey: map[string]float64{}}
+	if s == nil {
+		return report
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report.Entries = s.spendEntriesLocked(from, to, apiKey)
+	if report.Entries == nil {
+		report.Entries = []SpendEntry{}
+	}
+	for _, entry := range report.Entries {
+		report.Total += entry.Cost
+		report.ByAPIKey[entry.APIKey] += entry.Cost
+	}
+	return report
+}
+
+// recordSpendLocked adds a usage record to the spend of its API key and day.
+func (s *RequestStatistics) recordSpendLocked(record coreusage.Record, cost float64, now time.Time) {
+	day, _ := s.quotaPeriodsLocked(now)
+	key := spendKey{day: day, apiKey: strings.TrimSpace(record.APIKey), model: strings.TrimSpace(record.Model)}
+	if s.spend == nil {
+		s.spend = make(map[spendKey]*SpendTotals)
+	}
+	entry, ok := s.spend[key]
+	if !ok {
+		s.pruneSpendLocked(now)
+		entry = &SpendTotals{}
+		s.spend[key] = entry
+	}
+	detail := record.Detail
+	entry.Requests++
+	if record.Failed {
+		entry.FailedRequests++
+	}
+	entry.InputTokens += detail.InputTokens
+	entry.OutputTokens += detail.OutputTokens
+	entry.CachedTokens += detail.CachedTokens
+	entry.CacheCreationTokens += detail.CacheCreationTokens
+	entry.Cost += cost
+}
+
+// pruneSpendLocked drops spend older than the archive retention.
+func (s *RequestStatistics) pruneSpendLocked(now time.Time) {
+	if s.maxArchive <= 0 {
+		return
+	}
+	cutoff := s.quotaTimeLocked(now).AddDate(0, 0, -s.maxArchive).Format(time.DateOnly)
+	for key := range s.spend {
+		if key.day < cutoff {
+			delete(s.spend, key)
+		}
+	}
+}
+
+func (s *RequestStatistics) spendEntriesLocked(from, to, apiKey string) []SpendEntry {
+	byKey := make(map[spendKey]*SpendTotals)
+	for key, totals := range s.spend {
+		if (from != "" && key.day < from) || (to != "" && key.day > to) || (apiKey != "" && key.apiKey != apiKey) {
+			continue
+		}
+		key.model = ""
+		merged, ok := byKey[key]
+		if !ok {
+			merged = &SpendTotals{}
+			byKey[key] = merged
+		}
+		merged.add(*totals)
+	}
+	var out []SpendEntry
+	for key, totals := range byKey {
+		out = append(out, SpendEntry{Date: key.day, APIKey: key.apiKey, SpendTotals: *totals})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date < out[j].Date
+		}
+		return out[i].APIKey < out[j].APIKey
+	})
+	return out
+}
+
+func (t *SpendTotals) add(other SpendTotals) {
+	t.Requests += other.Requests
+	t.FailedRequests += other.FailedRequests
+	t.InputTokens += other.InputTokens
+	t.OutputTokens += other.OutputTokens
+	t.CachedTokens += other.CachedTokens
+	t.CacheCreationTokens += other.CacheCreationTokens
+	t.Cost += other.Cost
+}
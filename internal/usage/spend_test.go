@@ -0,0 +1,41 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRequestStatisticsTracksSpendPerKeyAndDay(t *testing.T) {
+	now := time.Date(2026, 4, 25, 23, 0, 0, 0, time.UTC)
+	stats := NewRequestStatistics()
+	stats.now = func() time.Time { return now }
+	stats.SetPrices([]ModelPrice{{Model: "claude-sonnet-*", InputPerMillion: 3, OutputPerMillion: 15, CachedInputPerMillion: 0.3, CacheWritePerMillion: 3.75}})
+
+	// 1M input + 1M cache read + 1M cache write + 1M output = 3 + 0.3 + 3.75 + 15.
+	stats.Record(coreusage.Record{APIKey: "a", Model: "claude-sonnet-4-5", Detail: coreusage.Detail{
+		InputTokens: 2_000_000, CachedTokens: 1_000_000, CacheCreationTokens: 1_000_000, OutputTokens: 1_000_000,
+	}})
+	stats.Record(coreusage.Record{APIKey: "b", Model: "openrouter/model", Detail: coreusage.Detail{InputTokens: 10, Cost: 0.5}})
+	stats.Record(coreusage.Record{APIKey: "b", Model: "unpriced", Detail: coreusage.Detail{InputTokens: 10}})
+	now = now.Add(2 * time.Hour)
+	stats.Record(coreusage.Record{APIKey: "a", Model: "openrouter/model", Detail: coreusage.Detail{Cost: 1}})
+
+	snapshot := stats.Snapshot()
+	if math.Abs(snapshot.Current.Cost-23.55) > 1e-9 {
+		t.Fatalf("total cost = %v, want 23.55", snapshot.Current.Cost)
+	}
+	if len(snapshot.Spend) != 3 || snapshot.Spend[0].Date != "2026-04-25" || snapshot.Spend[0].APIKey != "a" {
+		t.Fatalf("unexpected spend entries: %+v", snapshot.Spend)
+	}
+	if snapshot.Spend[1].Requests != 2 || snapshot.Spend[1].Cost != 0.5 {
+		t.Fatalf("unexpected spend of key b: %+v", snapshot.Spend[1])
+	}
+
+	report := stats.SpendReport("2026-04-26", "", "a")
+	if len(report.Entries) != 1 || report.Total != 1 || report.ByAPIKey["a"] != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,283 @@
+// Package usage aggregates usage records emitted by the proxy runtime into in-memory
+// statistics that can be inspected through the management API, and enforces
+// per-API-key quotas on top of them.
+package usage
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DailyResetOff keeps accumulating counters forever.
+	DailyResetOff = ""
+	// DailyResetLocal rolls counters over at local midnight.
+	DailyResetLocal = "local"
+	// DailyResetUTC rolls counters over at UTC midnight.
+	DailyResetUTC = "utc"
+
+	// maxArchivedDays bounds how many previous days are retained after rollover.
+	maxArchivedDays = 30
+)
+
+// Totals holds aggregated request and token counters.
+type Totals struct {
+	Requests        int64 `json:"requests"`
+	FailedRequests  int64 `json:"failed_requests"`
+	InputTokens     int64 `json:"input_tokens"`
+	OutputTokens    int64 `json:"output_tokens"`
+	ReasoningTokens int64 `json:"reasoning_tokens"`
+	CachedTokens    int64 `json:"cached_tokens"`
+	TotalTokens     int64 `json:"total_tokens"`
+	// CacheCreationTokens counts prompt tokens written to provider prompt caches.
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
+	// Cost sums the request costs in USD, as reported by the upstream or priced with model-prices.
+	Cost float64 `json:"cost,omitempty"`
+	// ModerationFlagged counts requests flagged by content moderation, including rejected ones.
+	ModerationFlagged int64 `json:"moderation_flagged,omitempty"`
+	// ModerationRejected counts requests rejected by content moderation.
+	ModerationRejected int64 `json:"moderation_rejected,omitempty"`
+	// DedupeHits counts non-streaming requests answered with the result of an identical in-flight request.
+	DedupeHits int64 `json:"dedupe_hits,omitempty"`
+	// ClientCancelledRequests counts requests aborted by the client; they are not failures.
+	ClientCancelledRequests int64 `json:"client_cancelled_requests,omitempty"`
+	// QueuedRequests counts requests that waited for a request scheduler slot, and QueueTimeMs
+	// sums their waits; MaxQueueTimeMs is the longest wait.
+	QueuedRequests int64 `json:"queued_requests,omitempty"`
+	QueueTimeMs    int64 `json:"queue_time_ms,omitempty"`
+	MaxQueueTimeMs int64 `json:"max_queue_time_ms,omitempty"`
+}
+
+// DailyTotals holds the archived totals of a completed day.
+type DailyTotals struct {
+	Date   string `json:"date"`
+	Totals Totals `json:"totals"`
+}
+
+// Snapshot is a point-in-time copy of the aggregated statistics.
+type Snapshot struct {
+	// Day is the current aggregation day (YYYY-MM-DD); empty when daily reset is disabled.
+	Day     string        `json:"day,omitempty"`
+	Current Totals        `json:"current"`
+	Archive []DailyTotals `json:"archive,omitempty"`
+	// Spend holds the cumulative spend per API key and day, oldest first.
+	Spend []SpendEntry `json:"spend,omitempty"`
+	// Cache reports the prompt cache effectiveness per model for the current day.
+	Cache []ModelCacheStats `json:"cache,omitempty"`
+}
+
+// RequestStatistics aggregates usage records, optionally rolling counters over at a day boundary.
+type RequestStatistics struct {
+	mu         sync.Mutex
+	resetMode  string
+	day        string
+	current    Totals
+	archive    []DailyTotals
+	now        func() time.Time
+	maxArchive int
+
+	quota      QuotaSettings
+	quotaUsage map[string]*QuotaUsage
+
+	prices []ModelPrice
+	spend  map[spendKey]*SpendTotals
+	cache  map[string]*ModelCacheStats
+}
+
+// NewRequestStatistics constructs an empty statistics store that never resets.
+func NewRequestStatistics() *RequestStatistics {
+	return &RequestStatistics{now: time.Now, maxArchive: maxArchivedDays}
+}
+
+// SetDailyReset configures the day boundary used for rollover.
+// Supported modes: DailyResetOff, DailyResetLocal, DailyResetUTC.
+func (s *RequestStatistics) SetDailyReset(mode string) {
+	if s == nil {
+		return
+	}
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case DailyResetOff, DailyResetLocal, DailyResetUTC:
+	default:
+		log.Warnf("usage statistics: unsupported daily reset mode %q; disabling rollover", mode)
+		mode = DailyResetOff
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resetMode == mode {
+		return
+	}
+	s.resetMode = mode
+	s.day = s.dayKey(s.now())
+}
+
+// Record adds a usage record to the current totals, rolling over first when the day changed.
+func (s *RequestStatistics) Record(record coreusage.Record) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.rolloverLocked(now)
+	s.recordQuotaLocked(record, now)
+
+	s.current.Requests++
+	if record.Failed {
+		s.current.FailedRequests++
+	}
+	if record.Outcome == coreusage.OutcomeClientCancelled {
+		s.current.ClientCancelledRequests++
+	}
+	detail := record.Detail
+	s.current.InputTokens += detail.InputTokens
+	s.current.OutputTokens += detail.OutputTokens
+	s.current.ReasoningTokens += detail.ReasoningTokens
+	s.current.CachedTokens += detail.CachedTokens
+	s.current.CacheCreationTokens += detail.CacheCreationTokens
+	cost := priceDetail(s.prices, record.Model, detail)
+	s.current.Cost += cost
+	s.recordSpendLocked(record, cost, now)
+	s.recordCacheLocked(record)
+	total := detail.TotalTokens
+	if total == 0 {
+		total = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
+	}
+	s.current.TotalTokens += total
+}
+
+// RecordModeration counts a request flagged by content moderation.
+func (s *RequestStatistics) RecordModeration(rejected bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked(s.now())
+	s.current.ModerationFlagged++
+	if rejected {
+		s.current.ModerationRejected++
+	}
+}
+
+// RecordDedupeHit counts a request coalesced into an identical in-flight request.
+func (s *RequestStatistics) RecordDedupeHit() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked(s.now())
+	s.current.DedupeHits++
+}
+
+// RecordQueueWait counts a request that waited for a request scheduler slot.
+func (s *RequestStatistics) RecordQueueWait(wait time.Duration) {
+	if s == nil || wait <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked(s.now())
+	waitMs := wait.Milliseconds()
+	s.current.QueuedRequests++
+	s.current.QueueTimeMs += waitMs
+	s.current.MaxQueueTimeMs = max(s.current.MaxQueueTimeMs, waitMs)
+}
+
+// RecordQuota charges a usage record against its API key quota without touching the totals.
+func (s *RequestStatistics) RecordQuota(record coreusage.Record) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordQuotaLocked(record, s.now())
+}
+
+// Snapshot returns a copy of the current totals and archived days.
+func (s *RequestStatistics) Snapshot() Snapshot {
+	if s == nil {
+		return Snapshot{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked(s.now())
+	snapshot := Snapshot{Day: s.day, Current: s.current}
+	if len(s.archive) > 0 {
+		snapshot.Archive = append([]DailyTotals(nil), s.archive...)
+	}
+	snapshot.Spend = s.spendEntriesLocked("", "", "")
+	snapshot.Cache = s.cacheStatsLocked()
+	return snapshot
+}
+
+// rolloverLocked archives the current totals and resets them when the day boundary was crossed.
+func (s *RequestStatistics) rolloverLocked(now time.Time) {
+	if s.resetMode == DailyResetOff {
+		return
+	}
+	day := s.dayKey(now)
+	if day == s.day {
+		return
+	}
+	if s.day != "" {
+		s.archive = append(s.archive, DailyTotals{Date: s.day, Totals: s.current})
+		if s.maxArchive > 0 && len(s.archive) > s.maxArchive {
+			s.archive = s.archive[len(s.archive)-s.maxArchive:]
+		}
+	}
+	s.current = Totals{}
+	s.cache = nil
+	s.day = day
+}
+
+func (s *RequestStatistics) dayKey(now time.Time) string {
+	switch s.resetMode {
+	case DailyResetUTC:
+		return now.UTC().Format(time.DateOnly)
+	case DailyResetLocal:
+		return now.Local().Format(time.DateOnly)
+	default:
+		return ""
+	}
+}
+
+var (
+	defaultStatistics = NewRequestStatistics()
+	statisticsEnabled atomic.Bool
+)
+
+func init() {
+	coreusage.RegisterPlugin(&statisticsPlugin{})
+}
+
+// GetRequestStatistics returns the process-wide statistics store.
+func GetRequestStatistics() *RequestStatistics { return defaultStatistics }
+
+// SetStatisticsEnabled toggles in-memory aggregation (config field `usage-statistics-enabled`).
+func SetStatisticsEnabled(enabled bool) { statisticsEnabled.Store(enabled) }
+
+// StatisticsEnabled reports whether usage records are aggregated in memory.
+func StatisticsEnabled() bool { return statisticsEnabled.Load() }
+
+type statisticsPlugin struct{}
+
+func (p *statisticsPlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	if p == nil {
+		return
+	}
+	if !StatisticsEnabled() {
+		// Quotas are enforced even when aggregated statistics are disabled.
+		defaultStatistics.RecordQuota(record)
+		return
+	}
+	defaultStatistics.Record(record)
+	appendToStore(record)
+}
@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRequestStatisticsRollsOverAtUTCDayBoundary(t *testing.T) {
+	now := time.Date(2026, 4, 25, 23, 59, 0, 0, time.UTC)
+	stats := NewRequestStatistics()
+	stats.now = func() time.Time { return now }
+	stats.SetDailyReset(DailyResetUTC)
+
+	stats.Record(coreusage.Record{Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5}})
+	stats.Record(coreusage.Record{Failed: true, Detail: coreusage.Detail{InputTokens: 3, TotalTokens: 3}})
+
+	snapshot := stats.Snapshot()
+	if snapshot.Day != "2026-04-25" {
+		t.Fatalf("day = %q, want 2026-04-25", snapshot.Day)
+	}
+	if snapshot.Current.Requests != 2 || snapshot.Current.FailedRequests != 1 || snapshot.Current.TotalTokens != 18 {
+		t.Fatalf("unexpected totals before rollover: %+v", snapshot.Current)
+	}
+
+	now = now.Add(2 * time.Minute)
+	stats.Record(coreusage.Record{Detail: coreusage.Detail{OutputTokens: 7}})
+
+	snapshot = stats.Snapshot()
+	if snapshot.Day != "2026-04-26" {
+		t.Fatalf("day after rollover = %q, want 2026-04-26", snapshot.Day)
+	}
+	if snapshot.Current.Requests != 1 || snapshot.Current.TotalTokens != 7 {
+		t.Fatalf("current totals were not reset: %+v", snapshot.Current)
+	}
+	if len(snapshot.Archive) != 1 {
+		t.Fatalf("archive length = %d, want 1", len(snapshot.Archive))
+	}
+	archived := snapshot.Archive[0]
+	if archived.Date != "2026-04-25" || archived.Totals.Requests != 2 || archived.Totals.InputTokens != 13 {
+		t.Fatalf("unexpected archived totals: %+v", archived)
+	}
+}
+
+func TestRequestStatisticsAccumulatesWithoutDailyReset(t *testing.T) {
+	now := time.Date(2026, 4, 25, 23, 59, 0, 0, time.UTC)
+	stats := NewRequestStatistics()
+	stats.now = func() time.Time { return now }
+
+	stats.Record(coreusage.Record{Detail: coreusage.Detail{TotalTokens: 4}})
+	now = now.Add(48 * time.Hour)
+	stats.Record(coreusage.Record{Detail: coreusage.Detail{TotalTokens: 6}})
+
+	snapshot := stats.Snapshot()
+	if snapshot.Current.Requests != 2 || snapshot.Current.TotalTokens != 10 {
+		t.Fatalf("unexpected totals: %+v", snapshot.Current)
+	}
+	if snapshot.Day != "" || len(snapshot.Archive) != 0 {
+		t.Fatalf("expected no rollover, got day %q archive %+v", snapshot.Day, snapshot.Archive)
+	}
+}
+
+func TestRequestStatisticsCountsClientCancelledApart(t *testing.T) {
+	stats := NewRequestStatistics()
+	stats.Record(coreusage.Record{Outcome: coreusage.OutcomeClientCancelled, Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 3}})
+	stats.Record(coreusage.Record{Failed: true})
+
+	current := stats.Snapshot().Current
+	if current.Requests != 2 || current.FailedRequests != 1 || current.ClientCancelledRequests != 1 {
+		t.Fatalf("unexpected request counters: %+v", current)
+	}
+	if current.OutputTokens != 3 || current.TotalTokens != 13 {
+		t.Fatalf("partial tokens not counted: %+v", current)
+	}
+}
+
+func TestRequestStatisticsRecordsQueueWaits(t *testing.T) {
+	stats := NewRequestStatistics()
+	stats.RecordQueueWait(0)
+	stats.RecordQueueWait(120 * time.Millisecond)
+	stats.RecordQueueWait(30 * time.Millisecond)
+
+	current := stats.Snapshot().Current
+	if current.QueuedRequests != 2 || current.QueueTimeMs != 150 || current.MaxQueueTimeMs != 120 {
+		t.Fatalf("unexpected queue totals: %+v", current)
+	}
+	if current.Requests != 0 {
+		t.Fatalf("queue waits must not count as requests, got %d", current.Requests)
+	}
+}
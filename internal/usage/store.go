@@ -0,0 +1,229 @@
+package usage
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultQueryLimit is the page size used when a query does not set one.
+	defaultQueryLimit = 100
+	// maxQueryLimit bounds the page size of a single query.
+	maxQueryLimit = 1000
+	// defaultMemoryStoreRows bounds the rows retained by MemoryStore.
+	defaultMemoryStoreRows = 10000
+)
+
+// RequestRow is the persisted record of one upstream request.
+type RequestRow struct {
+	Timestamp       time.Time `json:"timestamp"`
+	APIKey          string    `json:"api_key,omitempty"`
+	Provider        string    `json:"provider,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	AuthID          string    `json:"auth_id,omitempty"`
+	Source          string    `json:"source,omitempty"`
+	Failed          bool      `json:"failed"`
+	Outcome         string    `json:"outcome,omitempty"`
+	LatencyMs       int64     `json:"latency_ms"`
+	QueueMs         int64     `json:"queue_ms,omitempty"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	ReasoningTokens int64     `json:"reasoning_tokens"`
+	CachedTokens    int64     `json:"cached_tokens"`
+	TotalTokens     int64     `json:"total_tokens"`
+	Cost            float64   `json:"cost,omitempty"`
+}
+
+// RowFromRecord converts a usage record to a persisted row.
+func RowFromRecord(record coreusage.Record) RequestRow {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	detail := record.Detail
+	total := detail.TotalTokens
+	if total == 0 {
+		total = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
+	}
+	return RequestRow{
+		Timestamp:       timestamp.UTC(),
+		APIKey:          record.APIKey,
+		Provider:        record.Provider,
+		Model:           record.Model,
+		AuthID:          record.AuthID,
+		Source:          record.Source,
+		Failed:          record.Failed,
+		Outcome:         record.Outcome,
+		LatencyMs:       record.Latency.Milliseconds(),
+		QueueMs:         record.QueueTime.Milliseconds(),
+		InputTokens:     detail.InputTokens,
+		OutputTokens:    detail.OutputTokens,
+		ReasoningTokens: detail.ReasoningTokens,
+		CachedTokens:    detail.CachedTokens,
+		TotalTokens:     total,
+		Cost:            detail.Cost,
+	}
+}
+
+// Query filters and paginates persisted rows. Zero values disable a filter.
+type Query struct {
+	// From and To bound the request timestamp; From is inclusive, To exclusive.
+	From   time.Time
+	To     time.Time
+	APIKey string
+	Model  string
+	// Offset skips rows of the newest-first result set; Limit caps the page size.
+	Offset int
+	Limit  int
+}
+
+// Normalize clamps pagination values to supported bounds.
+func (q Query) Normalize() Query {
+	q.APIKey = strings.TrimSpace(q.APIKey)
+	q.Model = strings.TrimSpace(q.Model)
+	q.Offset = max(q.Offset, 0)
+	if q.Limit <= 0 {
+		q.Limit = defaultQueryLimit
+	}
+	q.Limit = min(q.Limit, maxQueryLimit)
+	return q
+}
+
+// Matches reports whether row passes the query filters.
+func (q Query) Matches(row RequestRow) bool {
+	if !q.From.IsZero() && row.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !row.Timestamp.Before(q.To) {
+		return false
+	}
+	if q.APIKey != "" && row.APIKey != q.APIKey {
+		return false
+	}
+	if q.Model != "" && !strings.EqualFold(row.Model, q.Model) {
+		return false
+	}
+	return true
+}
+
+// QueryResult is one page of rows, newest first, with the total number of matches.
+type QueryResult struct {
+	Total int          `json:"total"`
+	Rows  []RequestRow `json:"rows"`
+}
+
+// Store persists per-request rows and answers filtered, paginated queries.
+type Store interface {
+	Append(row RequestRow) error
+	Query(q Query) (QueryResult, error)
+	Close() error
+}
+
+// MemoryStore keeps the most recent rows in memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	rows    []RequestRow
+	maxRows int
+}
+
+// NewMemoryStore returns a store retaining at most maxRows rows (defaultMemoryStoreRows when <= 0).
+func NewMemoryStore(maxRows int) *MemoryStore {
+	if maxRows <= 0 {
+		maxRows = defaultMemoryStoreRows
+	}
+	return &MemoryStore{maxRows: maxRows}
+}
+
+// Append stores row, evicting the oldest row when full.
+func (s *MemoryStore) Append(row RequestRow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows = append(s.rows, row)
+	if len(s.rows) > s.maxRows {
+		s.rows = append(s.rows[:0:0], s.rows[len(s.rows)-s.maxRows:]...)
+	}
+	return nil
+}
+
+// Query returns matching rows, newest first.
+func (s *MemoryStore) Query(q Query) (QueryResult, error) {
+	q = q.Normalize()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var page pager
+	page.init(q)
+	for i := len(s.rows) - 1; i >= 0; i-- {
+		if q.Matches(s.rows[i]) {
+			page.add(s.rows[i])
+		}
+	}
+	return page.result(), nil
+}
+
+// Close releases nothing; it exists to satisfy Store.
+func (s *MemoryStore) Close() error { return nil }
+
+// pager collects one page of a newest-first match stream while counting all matches.
+type pager struct {
+	offset int
+	limit  int
+	total  int
+	rows   []RequestRow
+}
+
+func (p *pager) init(q Query) {
+	p.offset = q.Offset
+	p.limit = q.Limit
+	p.rows = make([]RequestRow, 0, min(q.Limit, 64))
+}
+
+func (p *pager) add(row RequestRow) {
+	if p.total >= p.offset && len(p.rows) < p.limit {
+		p.rows = append(p.rows, row)
+	}
+	p.total++
+}
+
+func (p *pager) result() QueryResult {
+	return QueryResult{Total: p.total, Rows: p.rows}
+}
+
+var (
+	storeMu      sync.RWMutex
+	currentStore Store
+)
+
+// SetStore installs the request row store; nil disables row persistence.
+// The previous store is closed.
+func SetStore(store Store) {
+	storeMu.Lock()
+	previous := currentStore
+	currentStore = store
+	storeMu.Unlock()
+	if previous != nil && previous != store {
+		if errClose := previous.Close(); errClose != nil {
+			log.Warnf("usage store: close previous store: %v", errClose)
+		}
+	}
+}
+
+// GetStore returns the installed request row store, or nil when persistence is disabled.
+func GetStore() Store {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return currentStore
+}
+
+func appendToStore(record coreusage.Record) {
+	store := GetStore()
+	if store == nil {
+		return
+	}
+	if errAppend := store.Append(RowFromRecord(record)); errAppend != nil {
+		log.Warnf("usage store: append failed: %v", errAppend)
+	}
+}
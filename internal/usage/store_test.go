@@ -0,0 +1,80 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreQueryFiltersAndPaginates(t *testing.T) {
+	store := NewMemoryStore(3)
+	base := time.Date(2026, 4, 25, 10, 0, 0, 0, time.UTC)
+	for i, model := range []string{"m1", "m2", "m1", "m1"} {
+		if err := store.Append(RequestRow{Timestamp: base.Add(time.Duration(i) * time.Minute), APIKey: "k", Model: model}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	result, err := store.Query(Query{Model: "m1", Limit: 1})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	// The oldest row was evicted, leaving two m1 rows.
+	if result.Total != 2 || len(result.Rows) != 1 {
+		t.Fatalf("total=%d rows=%d, want 2 and 1", result.Total, len(result.Rows))
+	}
+	if !result.Rows[0].Timestamp.Equal(base.Add(3 * time.Minute)) {
+		t.Fatalf("first row = %v, want newest", result.Rows[0].Timestamp)
+	}
+}
+
+func TestFileStoreQueriesAcrossDaysAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	day1 := time.Date(2026, 4, 24, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+	rows := []RequestRow{
+		{Timestamp: day1, APIKey: "a", Model: "m", TotalTokens: 1},
+		{Timestamp: day2, APIKey: "b", Model: "m", TotalTokens: 2},
+		{Timestamp: day2.Add(time.Minute), APIKey: "a", Model: "m", TotalTokens: 3},
+	}
+	for _, row := range rows {
+		if errAppend := store.Append(row); errAppend != nil {
+			t.Fatalf("append: %v", errAppend)
+		}
+	}
+
+	result, err := store.Query(Query{APIKey: "a"})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if result.Total != 2 || result.Rows[0].TotalTokens != 3 || result.Rows[1].TotalTokens != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	result, err = store.Query(Query{From: time.Date(2026, 4, 25, 0, 0, 0, 0, time.UTC), Offset: 1})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if result.Total != 2 || len(result.Rows) != 1 || result.Rows[0].TotalTokens != 2 {
+		t.Fatalf("unexpected paged result: %+v", result)
+	}
+	if errClose := store.Close(); errClose != nil {
+		t.Fatalf("close: %v", errClose)
+	}
+
+	pruned, err := NewFileStore(dir, 1)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = pruned.Close() }()
+	result, err = pruned.Query(Query{})
+	if err != nil {
+		t.Fatalf("query after prune: %v", err)
+	}
+	if result.Total != 0 {
+		t.Fatalf("expected rows older than retention to be pruned, got %d", result.Total)
+	}
+}
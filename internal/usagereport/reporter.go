@@ -0,0 +1,216 @@
+// Package usagereport renders a daily summary of the usage statistics and delivers it to Slack
+// and SMTP targets on a schedule.
+package usagereport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const deliveryTimeout = 30 * time.Second
+
+// DefaultTemplate is the report body used when usage-report.template is empty.
+const DefaultTemplate = `Usage report for {{.Date}}
+Requests: {{.Totals.Requests}} ({{percent .FailureRate}} failed)
+Tokens: {{.Totals.InputTokens}} input, {{.Totals.OutputTokens}} output, {{.Totals.CachedTokens}} cached
+Spend: {{usd .Totals.Cost}} (cache savings {{usd .CacheSavings}})
+
+Top models:
+{{range .TopModels}}- {{.Name}}: {{.Requests}} requests, {{usd .Cost}}
+{{else}}- none
+{{end}}
+Top API keys:
+{{range .TopKeys}}- {{mask .Name}}: {{.Requests}} requests, {{usd .Cost}}
+{{else}}- none
+{{end}}`
+
+var templateFuncs = template.FuncMap{
+	"usd":     func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"mask":    util.HideAPIKey,
+}
+
+var (
+	schedulerMu sync.Mutex
+	stopCurrent context.CancelFunc
+)
+
+// Configure starts the daily reporter for cfg, replacing a running one. The reporter is stopped
+// when the report is disabled or has no delivery target.
+func Configure(cfg *config.Config) {
+	schedulerMu.Lock()
+	defer schedulerMu.Unlock()
+	if stopCurrent != nil {
+		stopCurrent()
+		stopCurrent = nil
+	}
+	if cfg == nil || !cfg.UsageReport.Enabled {
+		return
+	}
+	settings := cfg.UsageReport
+	if settings.SlackWebhookURL == "" && !emailEnabled(settings.Email) {
+		log.Warn("usage-report is enabled without slack-webhook-url or email; not scheduling")
+		return
+	}
+	if _, errTemplate := parseTemplate("body", settings.Template); errTemplate != nil {
+		log.Errorf("usage-report.template is invalid; not scheduling: %v", errTemplate)
+		return
+	}
+	loc := time.UTC
+	if strings.EqualFold(strings.TrimSpace(cfg.UsageStatisticsDailyReset), usage.DailyResetLocal) {
+		loc = time.Local
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopCurrent = cancel
+	go run(ctx, settings, loc)
+}
+
+func run(ctx context.Context, settings config.UsageReportConfig, loc *time.Location) {
+	for {
+		now := time.Now().In(loc)
+		next := NextRun(now, settings.Time)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		day := next.AddDate(0, 0, -1).Format(time.DateOnly)
+		report := usage.GetRequestStatistics().DailyReport(day, settings.TopN)
+		if errSend := Send(ctx, settings, report); errSend != nil {
+			log.Errorf("usage report for %s: %v", day, errSend)
+		}
+	}
+}
+
+// NextRun returns the first occurrence of clock ("HH:MM") strictly after now, in now's location.
+func NextRun(now time.Time, clock string) time.Time {
+	hour, minute := 0, 0
+	if parsed, errParse := time.Parse("15:04", clock); errParse == nil {
+		hour, minute = parsed.Hour(), parsed.Minute()
+	}
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Render renders report with tmpl, or DefaultTemplate when tmpl is empty.
+func Render(tmpl string, report usage.DailyReport) (string, error) {
+	t, errParse := parseTemplate("body", tmpl)
+	if errParse != nil {
+		return "", errParse
+	}
+	var buf bytes.Buffer
+	if errExec := t.Execute(&buf, report); errExec != nil {
+		return "", errExec
+	}
+	return buf.String(), nil
+}
+
+// Send renders report and delivers it to every configured target.
+func Send(ctx context.Context, settings config.UsageReportConfig, report usage.DailyReport) error {
+	body, errRender := Render(settings.Template, report)
+	if errRender != nil {
+		return fmt.Errorf("render: %w", errRender)
+	}
+	var errs []string
+	if settings.SlackWebhookURL != "" {
+		if errSlack := sendSlack(ctx, settings.SlackWebhookURL, body); errSlack != nil {
+			errs = append(errs, "slack: "+errSlack.Error())
+		}
+	}
+	if emailEnabled(settings.Email) {
+		if errEmail := sendEmail(settings.Email, report, body); errEmail != nil {
+			errs = append(errs, "email: "+errEmail.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultTemplate
+	}
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+func emailEnabled(email config.UsageReportEmail) bool {
+	return email.Host != "" && len(email.To) > 0
+}
+
+func sendSlack(ctx context.Context, webhookURL, text string) error {
+	payload, errMarshal := json.Marshal(map[string]string{"text": text})
+	if errMarshal != nil {
+		return errMarshal
+	}
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if errReq != nil {
+		return errReq
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, errDo := http.DefaultClient.Do(req)
+	if errDo != nil {
+		return errDo
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("usage report: close slack response body error: %v", errClose)
+		}
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func sendEmail(email config.UsageReportEmail, report usage.DailyReport, body string) error {
+	subject, errSubject := parseTemplate("subject", email.Subject)
+	if errSubject != nil {
+		return errSubject
+	}
+	var subjectBuf bytes.Buffer
+	if errExec := subject.Execute(&subjectBuf, report); errExec != nil {
+		return errExec
+	}
+	from := email.From
+	if from == "" {
+		from = email.Username
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.TrimSpace(subjectBuf.String()))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if email.Username != "" {
+		auth = smtp.PlainAuth("", email.Username, email.Password, email.Host)
+	}
+	addr := email.Host + ":" + strconv.Itoa(email.Port)
+	return smtp.SendMail(addr, auth, from, email.To, msg.Bytes())
+}
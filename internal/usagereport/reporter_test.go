@@ -0,0 +1,53 @@
+package usagereport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2026, 4, 25, 0, 5, 0, 0, time.UTC)
+	if got := NextRun(now, "00:05"); !got.Equal(time.Date(2026, 4, 26, 0, 5, 0, 0, time.UTC)) {
+		t.Fatalf("NextRun at the scheduled minute = %v", got)
+	}
+	if got := NextRun(now, "09:30"); !got.Equal(time.Date(2026, 4, 25, 9, 30, 0, 0, time.UTC)) {
+		t.Fatalf("NextRun later today = %v", got)
+	}
+}
+
+func TestSendRendersReportToSlack(t *testing.T) {
+	var text string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		text = payload["text"]
+	}))
+	defer server.Close()
+
+	report := usage.DailyReport{
+		Date:        "2026-04-25",
+		Totals:      usage.SpendTotals{Requests: 4, FailedRequests: 1, Cost: 12.5},
+		FailureRate: 0.25,
+		TopModels:   []usage.ReportItem{{Name: "gpt-5", SpendTotals: usage.SpendTotals{Requests: 4, Cost: 12.5}}},
+		TopKeys:     []usage.ReportItem{{Name: "sk-1234567890abcdef", SpendTotals: usage.SpendTotals{Requests: 4, Cost: 12.5}}},
+	}
+	if errSend := Send(context.Background(), config.UsageReportConfig{SlackWebhookURL: server.URL}, report); errSend != nil {
+		t.Fatalf("Send error: %v", errSend)
+	}
+	for _, want := range []string{"Usage report for 2026-04-25", "25.0% failed", "Spend: $12.50", "- gpt-5: 4 requests, $12.50"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("report missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "sk-1234567890abcdef") {
+		t.Fatalf("report leaked the API key:\n%s", text)
+	}
+}
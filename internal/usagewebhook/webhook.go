@@ -0,0 +1,295 @@
+// Package usagewebhook delivers usage records to external accounting systems as signed,
+// batched webhook requests.
+package usagewebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	internallogging "github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SignatureHeader carries "sha256=<hex HMAC>" of "<timestamp>.<body>".
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader carries the Unix timestamp covered by the signature.
+	TimestampHeader = "X-Webhook-Timestamp"
+
+	maxRetryBackoff = 30 * time.Second
+)
+
+// Event is the accounting record sent for one upstream request.
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"request_id,omitempty"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Endpoint  string    `json:"endpoint,omitempty"`
+	APIKey    string    `json:"api_key,omitempty"`
+	AuthIndex string    `json:"auth_index,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	Success   bool      `json:"success"`
+	Tokens    Tokens    `json:"tokens"`
+	// Cost is the upstream-reported cost in USD; zero when the provider does not report it.
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// Tokens is the token breakdown of an Event.
+type Tokens struct {
+	InputTokens         int64 `json:"input_tokens"`
+	OutputTokens        int64 `json:"output_tokens"`
+	ReasoningTokens     int64 `json:"reasoning_tokens"`
+	CachedTokens        int64 `json:"cached_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
+	TotalTokens         int64 `json:"total_tokens"`
+}
+
+func init() {
+	coreusage.RegisterPlugin(&webhookPlugin{})
+}
+
+var (
+	sendersMu sync.RWMutex
+	senders   []*sender
+)
+
+// Configure replaces the active webhooks. Events buffered for removed webhooks are flushed once
+// in the background.
+func Configure(hooks []config.UsageWebhook) {
+	next := make([]*sender, 0, len(hooks))
+	for _, hook := range hooks {
+		next = append(next, newSender(hook))
+	}
+	sendersMu.Lock()
+	previous := senders
+	senders = next
+	sendersMu.Unlock()
+	for _, s := range previous {
+		s.stop()
+	}
+}
+
+type webhookPlugin struct{}
+
+func (p *webhookPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if p == nil {
+		return
+	}
+	sendersMu.RLock()
+	active := senders
+	sendersMu.RUnlock()
+	if len(active) == 0 {
+		return
+	}
+	event := newEvent(ctx, record)
+	for _, s := range active {
+		s.enqueue(event)
+	}
+}
+
+func newEvent(ctx context.Context, record coreusage.Record) Event {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	detail := record.Detail
+	tokens := Tokens{
+		InputTokens:         detail.InputTokens,
+		OutputTokens:        detail.OutputTokens,
+		ReasoningTokens:     detail.ReasoningTokens,
+		CachedTokens:        detail.CachedTokens,
+		CacheCreationTokens: detail.CacheCreationTokens,
+		TotalTokens:         detail.TotalTokens,
+	}
+	if tokens.TotalTokens == 0 {
+		tokens.TotalTokens = tokens.InputTokens + tokens.OutputTokens + tokens.ReasoningTokens
+	}
+	success := !record.Failed
+	if status := internallogging.GetResponseStatus(ctx); success && status >= http.StatusBadRequest {
+		success = false
+	}
+	return Event{
+		Timestamp: timestamp.UTC(),
+		RequestID: strings.TrimSpace(internallogging.GetRequestID(ctx)),
+		Provider:  strings.TrimSpace(record.Provider),
+		Model:     strings.TrimSpace(record.Model),
+		Endpoint:  strings.TrimSpace(internallogging.GetEndpoint(ctx)),
+		APIKey:    strings.TrimSpace(record.APIKey),
+		AuthIndex: record.AuthIndex,
+		Source:    record.Source,
+		LatencyMs: record.Latency.Milliseconds(),
+		Success:   success,
+		Tokens:    tokens,
+		Cost:      detail.Cost,
+	}
+}
+
+// sender buffers the events of one webhook and delivers them in batches from its own goroutine.
+type sender struct {
+	hook   config.UsageWebhook
+	client *http.Client
+
+	mu    sync.Mutex
+	queue []Event
+
+	wake     chan struct{}
+	quit     chan struct{}
+	stopOnce sync.Once
+}
+
+func newSender(hook config.UsageWebhook) *sender {
+	s := &sender{
+		hook:   hook,
+		client: &http.Client{Timeout: time.Duration(hook.Timeout) * time.Second},
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *sender) enqueue(event Event) {
+	s.mu.Lock()
+	if len(s.queue) >= s.hook.QueueSize {
+		dropped := len(s.queue) - s.hook.QueueSize + 1
+		s.queue = s.queue[dropped:]
+		log.Warnf("usage webhook %s: queue full, dropped %d oldest event(s)", s.hook.URL, dropped)
+	}
+	s.queue = append(s.queue, event)
+	full := len(s.queue) >= s.hook.BatchSize
+	s.mu.Unlock()
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *sender) stop() {
+	s.stopOnce.Do(func() { close(s.quit) })
+}
+
+func (s *sender) run() {
+	ticker := time.NewTicker(time.Duration(s.hook.FlushInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.quit:
+			// Flush what is left once, without retries.
+			for batch := s.take(); len(batch) > 0; batch = s.take() {
+				if errSend := s.send(batch); errSend != nil {
+					log.Warnf("usage webhook %s: dropped %d event(s) on shutdown: %v", s.hook.URL, len(batch), errSend)
+					return
+				}
+			}
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+		for batch := s.take(); len(batch) > 0; batch = s.take() {
+			if !s.deliver(batch) {
+				break
+			}
+		}
+	}
+}
+
+// take removes the next batch from the queue.
+func (s *sender) take() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := min(len(s.queue), s.hook.BatchSize)
+	if n == 0 {
+		return nil
+	}
+	batch := append([]Event(nil), s.queue[:n]...)
+	s.queue = s.queue[n:]
+	return batch
+}
+
+// deliver sends batch, retrying with exponential backoff. It reports false when the sender was
+// stopped while retrying; the batch is then put back for the shutdown flush.
+func (s *sender) deliver(batch []Event) bool {
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		errSend := s.send(batch)
+		if errSend == nil {
+			return true
+		}
+		if attempt >= s.hook.MaxRetries {
+			log.Warnf("usage webhook %s: dropped %d event(s) after %d attempt(s): %v", s.hook.URL, len(batch), attempt+1, errSend)
+			return true
+		}
+		log.Debugf("usage webhook %s: attempt %d failed, retrying in %s: %v", s.hook.URL, attempt+1, backoff, errSend)
+		select {
+		case <-s.quit:
+			s.mu.Lock()
+			s.queue = append(batch, s.queue...)
+			s.mu.Unlock()
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+func (s *sender) send(batch []Event) error {
+	body, errMarshal := json.Marshal(struct {
+		Events []Event `json:"events"`
+	}{Events: batch})
+	if errMarshal != nil {
+		return errMarshal
+	}
+	req, errReq := http.NewRequest(http.MethodPost, s.hook.URL, bytes.NewReader(body))
+	if errReq != nil {
+		return errReq
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.hook.Headers {
+		req.Header.Set(key, value)
+	}
+	if s.hook.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(s.hook.Secret, timestamp, body))
+	}
+	resp, errDo := s.client.Do(req)
+	if errDo != nil {
+		return errDo
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("usage webhook: close response body error: %v", errClose)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,73 @@
+package usagewebhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestSenderBatchesSignsAndRetries(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []Event
+		attempts atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(SignatureHeader); got != Sign("secret", r.Header.Get(TimestampHeader), body) {
+			t.Errorf("signature = %q", got)
+		}
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var payload struct {
+			Events []Event `json:"events"`
+		}
+		if errDecode := json.Unmarshal(body, &payload); errDecode != nil {
+			t.Errorf("decode body: %v", errDecode)
+		}
+		mu.Lock()
+		received = append(received, payload.Events...)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	Configure([]config.UsageWebhook{{URL: server.URL, Secret: "secret", BatchSize: 2, FlushInterval: 60, MaxRetries: 2, QueueSize: 10, Timeout: 5}})
+	defer Configure(nil)
+
+	plugin := &webhookPlugin{}
+	plugin.HandleUsage(context.Background(), coreusage.Record{Model: "gpt-5", APIKey: "k", Detail: coreusage.Detail{InputTokens: 3, OutputTokens: 4}})
+	plugin.HandleUsage(context.Background(), coreusage.Record{Model: "gpt-5", Failed: true})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(received)
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || attempts.Load() != 2 {
+		t.Fatalf("received %d events in %d attempts, want 2 events in 2 attempts", len(received), attempts.Load())
+	}
+	if !received[0].Success || received[0].Tokens.TotalTokens != 7 || received[0].APIKey != "k" {
+		t.Fatalf("unexpected first event %+v", received[0])
+	}
+	if received[1].Success {
+		t.Fatalf("failed record reported as success")
+	}
+}
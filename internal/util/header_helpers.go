@@ -5,6 +5,9 @@ import (
 	"strings"
 )
 
+// SessionHeaders are the client headers naming a conversation, in priority order.
+var SessionHeaders = []string{"X-Session-ID", "Session_id", "X-Amp-Thread-Id"}
+
 // ApplyCustomHeadersFromAttrs applies user-defined headers stored in the provided attributes map.
 // Custom headers override built-in defaults when conflicts occur.
 func ApplyCustomHeadersFromAttrs(r *http.Request, attrs map[string]string) {
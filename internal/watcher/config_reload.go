@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -17,6 +18,43 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// ConfigReloadStatus describes the outcome of the most recent config hot reload.
+type ConfigReloadStatus struct {
+	Path    string    `json:"path"`
+	Time    time.Time `json:"time"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+	Changes []string  `json:"changes,omitempty"`
+}
+
+var lastConfigReload struct {
+	mu     sync.RWMutex
+	status *ConfigReloadStatus
+}
+
+// LastConfigReload returns the outcome of the most recent config hot reload, or nil when the
+// config has not been reloaded since startup.
+func LastConfigReload() *ConfigReloadStatus {
+	lastConfigReload.mu.RLock()
+	defer lastConfigReload.mu.RUnlock()
+	if lastConfigReload.status == nil {
+		return nil
+	}
+	status := *lastConfigReload.status
+	status.Changes = append([]string(nil), status.Changes...)
+	return &status
+}
+
+func recordConfigReload(path string, err error, changes []string) {
+	status := &ConfigReloadStatus{Path: path, Time: time.Now(), Success: err == nil, Changes: changes}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	lastConfigReload.mu.Lock()
+	lastConfigReload.status = status
+	lastConfigReload.mu.Unlock()
+}
+
 func (w *Watcher) stopConfigReloadTimer() {
 	w.configReloadMu.Lock()
 	if w.configReloadTimer != nil {
@@ -83,7 +121,13 @@ func (w *Watcher) reloadConfig() bool {
 
 	newConfig, errLoadConfig := config.LoadConfig(w.configPath)
 	if errLoadConfig != nil {
-		log.Errorf("failed to reload config: %v", errLoadConfig)
+		log.WithError(errLoadConfig).WithField("path", w.configPath).Error("failed to reload config")
+		recordConfigReload(w.configPath, errLoadConfig, nil)
+		return false
+	}
+	if errValidate := newConfig.Validate(); errValidate != nil {
+		log.WithError(errValidate).WithField("path", w.configPath).Error("config reload rejected; keeping previous config")
+		recordConfigReload(w.configPath, errValidate, nil)
 		return false
 	}
 
@@ -114,8 +158,9 @@ func (w *Watcher) reloadConfig() bool {
 		log.Debugf("log level updated - debug mode changed from %t to %t", oldConfig.Debug, newConfig.Debug)
 	}
 
+	var details []string
 	if oldConfig != nil {
-		details := diff.BuildConfigChangeDetails(oldConfig, newConfig)
+		details = diff.BuildConfigChangeDetails(oldConfig, newConfig)
 		if len(details) > 0 {
 			log.Debugf("config changes detected:")
 			for _, d := range details {
@@ -128,9 +173,10 @@ func (w *Watcher) reloadConfig() bool {
 
 	authDirChanged := oldConfig == nil || oldConfig.AuthDir != newConfig.AuthDir
 	retryConfigChanged := oldConfig != nil && (oldConfig.RequestRetry != newConfig.RequestRetry || oldConfig.MaxRetryInterval != newConfig.MaxRetryInterval || oldConfig.MaxRetryCredentials != newConfig.MaxRetryCredentials)
-	forceAuthRefresh := oldConfig != nil && (oldConfig.ForceModelPrefix != newConfig.ForceModelPrefix || !reflect.DeepEqual(oldConfig.OAuthModelAlias, newConfig.OAuthModelAlias) || retryConfigChanged)
+	forceAuthRefresh := oldConfig != nil && (oldConfig.ForceModelPrefix != newConfig.ForceModelPrefix || !reflect.DeepEqual(oldConfig.OAuthModelAlias, newConfig.OAuthModelAlias) || !reflect.DeepEqual(oldConfig.OAuthProxies, newConfig.OAuthProxies) || retryConfigChanged)
 
-	log.Infof("config successfully reloaded, triggering client reload")
+	log.WithFields(log.Fields{"path": w.configPath, "changes": len(details)}).Info("config successfully reloaded, triggering client reload")
+	recordConfigReload(w.configPath, nil, details)
 	w.reloadClients(authDirChanged, affectedOAuthProviders, forceAuthRefresh)
 	return true
 }
@@ -21,6 +21,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Port != newCfg.Port {
 		changes = append(changes, fmt.Sprintf("port: %d -> %d", oldCfg.Port, newCfg.Port))
 	}
+	if oldCfg.ShutdownDrainTimeout != newCfg.ShutdownDrainTimeout {
+		changes = append(changes, fmt.Sprintf("shutdown-drain-timeout: %d -> %d", oldCfg.ShutdownDrainTimeout, newCfg.ShutdownDrainTimeout))
+	}
 	if oldCfg.AuthDir != newCfg.AuthDir {
 		changes = append(changes, fmt.Sprintf("auth-dir: %s -> %s", oldCfg.AuthDir, newCfg.AuthDir))
 	}
@@ -39,12 +42,52 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.UsageStatisticsEnabled != newCfg.UsageStatisticsEnabled {
 		changes = append(changes, fmt.Sprintf("usage-statistics-enabled: %t -> %t", oldCfg.UsageStatisticsEnabled, newCfg.UsageStatisticsEnabled))
 	}
+	if oldCfg.UsageStatisticsDailyReset != newCfg.UsageStatisticsDailyReset {
+		changes = append(changes, fmt.Sprintf("usage-statistics-daily-reset: %q -> %q", oldCfg.UsageStatisticsDailyReset, newCfg.UsageStatisticsDailyReset))
+	}
+	if oldCfg.UsageStore != newCfg.UsageStore {
+		changes = append(changes, fmt.Sprintf("usage-store: %s -> %s", oldCfg.UsageStore.Type, newCfg.UsageStore.Type))
+	}
+	if !reflect.DeepEqual(oldCfg.UsageReport, newCfg.UsageReport) {
+		changes = append(changes, fmt.Sprintf("usage-report: enabled=%t time=%s", newCfg.UsageReport.Enabled, newCfg.UsageReport.Time))
+	}
+	if !reflect.DeepEqual(oldCfg.UsageWebhooks, newCfg.UsageWebhooks) {
+		changes = append(changes, fmt.Sprintf("usage-webhooks: %d -> %d endpoints", len(oldCfg.UsageWebhooks), len(newCfg.UsageWebhooks)))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelPrices, newCfg.ModelPrices) {
+		changes = append(changes, fmt.Sprintf("model-prices: %d -> %d entries", len(oldCfg.ModelPrices), len(newCfg.ModelPrices)))
+	}
+	if !reflect.DeepEqual(oldCfg.APIKeyQuota, newCfg.APIKeyQuota) {
+		changes = append(changes, "api-key-quota: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.ClaudeRequest.Betas, newCfg.ClaudeRequest.Betas) {
+		changes = append(changes, fmt.Sprintf("claude-request.betas: %d -> %d rules", len(oldCfg.ClaudeRequest.Betas.Rules), len(newCfg.ClaudeRequest.Betas.Rules)))
+	}
+	if !reflect.DeepEqual(oldCfg.ClaudeRequest.DisabledOpenAIStages, newCfg.ClaudeRequest.DisabledOpenAIStages) {
+		changes = append(changes, fmt.Sprintf("claude-request.disabled-openai-stages: %v -> %v", oldCfg.ClaudeRequest.DisabledOpenAIStages, newCfg.ClaudeRequest.DisabledOpenAIStages))
+	}
+	if oldCfg.ClaudeRequest.NonStream != newCfg.ClaudeRequest.NonStream {
+		changes = append(changes, "claude-request.non-stream: updated")
+	}
+	if oldCfg.PIIScrubber.Enabled != newCfg.PIIScrubber.Enabled {
+		changes = append(changes, fmt.Sprintf("pii-scrubber: enabled %t -> %t", oldCfg.PIIScrubber.Enabled, newCfg.PIIScrubber.Enabled))
+	} else if !reflect.DeepEqual(oldCfg.PIIScrubber, newCfg.PIIScrubber) {
+		changes = append(changes, "pii-scrubber: updated")
+	}
+	if oldCfg.Moderation.Enabled != newCfg.Moderation.Enabled {
+		changes = append(changes, fmt.Sprintf("moderation: enabled %t -> %t", oldCfg.Moderation.Enabled, newCfg.Moderation.Enabled))
+	} else if !reflect.DeepEqual(oldCfg.Moderation, newCfg.Moderation) {
+		changes = append(changes, "moderation: updated")
+	}
 	if oldCfg.RedisUsageQueueRetentionSeconds != newCfg.RedisUsageQueueRetentionSeconds {
 		changes = append(changes, fmt.Sprintf("redis-usage-queue-retention-seconds: %d -> %d", oldCfg.RedisUsageQueueRetentionSeconds, newCfg.RedisUsageQueueRetentionSeconds))
 	}
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
+	if oldCfg.AuthRefreshJitter != newCfg.AuthRefreshJitter {
+		changes = append(changes, fmt.Sprintf("auth-refresh-jitter: %d -> %d", oldCfg.AuthRefreshJitter, newCfg.AuthRefreshJitter))
+	}
 	if oldCfg.DisableImageGeneration != newCfg.DisableImageGeneration {
 		changes = append(changes, fmt.Sprintf("disable-image-generation: %v -> %v", oldCfg.DisableImageGeneration, newCfg.DisableImageGeneration))
 	}
@@ -54,9 +97,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.LogsMaxTotalSizeMB != newCfg.LogsMaxTotalSizeMB {
 		changes = append(changes, fmt.Sprintf("logs-max-total-size-mb: %d -> %d", oldCfg.LogsMaxTotalSizeMB, newCfg.LogsMaxTotalSizeMB))
 	}
+	if oldCfg.LogRotation != newCfg.LogRotation {
+		changes = append(changes, fmt.Sprintf("log-rotation: %+v -> %+v", oldCfg.LogRotation, newCfg.LogRotation))
+	}
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
+	if !reflect.DeepEqual(oldCfg.Redact, newCfg.Redact) {
+		changes = append(changes, fmt.Sprintf("redact: enabled %t -> %t", oldCfg.Redact.Enabled, newCfg.Redact.Enabled))
+	}
 	if oldCfg.RequestRetry != newCfg.RequestRetry {
 		changes = append(changes, fmt.Sprintf("request-retry: %d -> %d", oldCfg.RequestRetry, newCfg.RequestRetry))
 	}
@@ -66,6 +115,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.MaxRetryInterval != newCfg.MaxRetryInterval {
 		changes = append(changes, fmt.Sprintf("max-retry-interval: %d -> %d", oldCfg.MaxRetryInterval, newCfg.MaxRetryInterval))
 	}
+	if !reflect.DeepEqual(oldCfg.RetryBackoff, newCfg.RetryBackoff) {
+		changes = append(changes, fmt.Sprintf("retry-backoff: enabled %t -> %t", oldCfg.RetryBackoff.Enabled, newCfg.RetryBackoff.Enabled))
+	}
+	if oldCfg.CircuitBreaker != newCfg.CircuitBreaker {
+		changes = append(changes, fmt.Sprintf("circuit-breaker: enabled %t -> %t", oldCfg.CircuitBreaker.Enabled, newCfg.CircuitBreaker.Enabled))
+	}
+	if !reflect.DeepEqual(oldCfg.HedgedRequests, newCfg.HedgedRequests) {
+		changes = append(changes, fmt.Sprintf("hedged-requests: enabled %t -> %t, delay-ms %d -> %d", oldCfg.HedgedRequests.Enabled, newCfg.HedgedRequests.Enabled, oldCfg.HedgedRequests.Delay, newCfg.HedgedRequests.Delay))
+	}
+	if !reflect.DeepEqual(oldCfg.UpstreamProbe, newCfg.UpstreamProbe) {
+		changes = append(changes, fmt.Sprintf("upstream-probe: enabled %t -> %t", oldCfg.UpstreamProbe.Enabled, newCfg.UpstreamProbe.Enabled))
+	}
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
@@ -100,6 +161,11 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
 	}
+	if len(oldCfg.ProxyKeys) != len(newCfg.ProxyKeys) {
+		changes = append(changes, fmt.Sprintf("proxy-keys count: %d -> %d", len(oldCfg.ProxyKeys), len(newCfg.ProxyKeys)))
+	} else if !reflect.DeepEqual(oldCfg.ProxyKeys, newCfg.ProxyKeys) {
+		changes = append(changes, "proxy-keys: updated (count unchanged, redacted)")
+	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
 	} else {
@@ -257,6 +323,66 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if entries, _ := DiffOAuthModelAliasChanges(oldCfg.OAuthModelAlias, newCfg.OAuthModelAlias); len(entries) > 0 {
 		changes = append(changes, entries...)
 	}
+	if !reflect.DeepEqual(oldCfg.OAuthProxies, newCfg.OAuthProxies) {
+		changes = append(changes, fmt.Sprintf("oauth-proxies: updated (%d -> %d entries)", len(oldCfg.OAuthProxies), len(newCfg.OAuthProxies)))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelOverrides, newCfg.ModelOverrides) {
+		changes = append(changes, fmt.Sprintf("model-overrides: %d -> %d entries", len(oldCfg.ModelOverrides), len(newCfg.ModelOverrides)))
+	}
+	if !reflect.DeepEqual(oldCfg.ImageFetch, newCfg.ImageFetch) {
+		changes = append(changes, fmt.Sprintf("image-fetch: enabled %t -> %t, %d -> %d allowed hosts", oldCfg.ImageFetch.Enabled, newCfg.ImageFetch.Enabled, len(oldCfg.ImageFetch.AllowedHosts), len(newCfg.ImageFetch.AllowedHosts)))
+	}
+	if oldCfg.AudioTranscription.BaseURL != newCfg.AudioTranscription.BaseURL || oldCfg.AudioTranscription.Model != newCfg.AudioTranscription.Model {
+		changes = append(changes, fmt.Sprintf("audio-transcription: %s (%s) -> %s (%s)", oldCfg.AudioTranscription.BaseURL, oldCfg.AudioTranscription.Model, newCfg.AudioTranscription.BaseURL, newCfg.AudioTranscription.Model))
+	}
+	if oldCfg.AudioTranscription.APIKey != newCfg.AudioTranscription.APIKey {
+		changes = append(changes, "audio-transcription.api-key: updated")
+	}
+	if oldCfg.ResponseCache != newCfg.ResponseCache {
+		changes = append(changes, fmt.Sprintf("response-cache: enabled %t -> %t, type %s -> %s, ttl %d -> %d", oldCfg.ResponseCache.Enabled, newCfg.ResponseCache.Enabled, oldCfg.ResponseCache.Type, newCfg.ResponseCache.Type, oldCfg.ResponseCache.TTL, newCfg.ResponseCache.TTL))
+	}
+	if !reflect.DeepEqual(oldCfg.RequestScheduler, newCfg.RequestScheduler) {
+		changes = append(changes, fmt.Sprintf("request-scheduler: enabled %t -> %t, max-concurrent %d -> %d, max-concurrent-per-key %d -> %d, %d -> %d keys", oldCfg.RequestScheduler.Enabled, newCfg.RequestScheduler.Enabled, oldCfg.RequestScheduler.MaxConcurrent, newCfg.RequestScheduler.MaxConcurrent, oldCfg.RequestScheduler.MaxConcurrentPerKey, newCfg.RequestScheduler.MaxConcurrentPerKey, len(oldCfg.RequestScheduler.Keys), len(newCfg.RequestScheduler.Keys)))
+	}
+	if oldCfg.RateLimit != newCfg.RateLimit {
+		changes = append(changes, fmt.Sprintf("rate-limit: enabled %t -> %t, rpm %d -> %d, tpm %d -> %d, type %s -> %s", oldCfg.RateLimit.Enabled, newCfg.RateLimit.Enabled, oldCfg.RateLimit.RequestsPerMinute, newCfg.RateLimit.RequestsPerMinute, oldCfg.RateLimit.TokensPerMinute, newCfg.RateLimit.TokensPerMinute, oldCfg.RateLimit.Type, newCfg.RateLimit.Type))
+	}
+	if !reflect.DeepEqual(oldCfg.IPAccess, newCfg.IPAccess) {
+		changes = append(changes, fmt.Sprintf("ip-access: allow %d -> %d, deny %d -> %d, trusted-proxies %d -> %d", len(oldCfg.IPAccess.Allow), len(newCfg.IPAccess.Allow), len(oldCfg.IPAccess.Deny), len(newCfg.IPAccess.Deny), len(oldCfg.IPAccess.TrustedProxies), len(newCfg.IPAccess.TrustedProxies)))
+	}
+	if oldCfg.DedupeRequests != newCfg.DedupeRequests {
+		changes = append(changes, fmt.Sprintf("dedupe-requests: %t -> %t", oldCfg.DedupeRequests, newCfg.DedupeRequests))
+	}
+	if oldCfg.MCPServer != newCfg.MCPServer {
+		changes = append(changes, fmt.Sprintf("mcp-server: %t -> %t", oldCfg.MCPServer, newCfg.MCPServer))
+	}
+	if oldCfg.ListThinkingVariants != newCfg.ListThinkingVariants {
+		changes = append(changes, fmt.Sprintf("list-thinking-variants: %t -> %t", oldCfg.ListThinkingVariants, newCfg.ListThinkingVariants))
+	}
+	if oldCfg.StrictOpenAIValidation != newCfg.StrictOpenAIValidation {
+		changes = append(changes, fmt.Sprintf("strict-openai-validation: %t -> %t", oldCfg.StrictOpenAIValidation, newCfg.StrictOpenAIValidation))
+	}
+	if oldCfg.MultipleChoices != newCfg.MultipleChoices {
+		changes = append(changes, fmt.Sprintf("multiple-choices: %+v -> %+v", oldCfg.MultipleChoices, newCfg.MultipleChoices))
+	}
+	if !reflect.DeepEqual(oldCfg.SystemPromptRules, newCfg.SystemPromptRules) {
+		changes = append(changes, fmt.Sprintf("system-prompt-rules: %d -> %d", len(oldCfg.SystemPromptRules), len(newCfg.SystemPromptRules)))
+	}
+	if oldCfg.AutoCompact != newCfg.AutoCompact {
+		changes = append(changes, fmt.Sprintf("auto-compact: %+v -> %+v", oldCfg.AutoCompact, newCfg.AutoCompact))
+	}
+	if oldCfg.ContextOverflow != newCfg.ContextOverflow {
+		changes = append(changes, fmt.Sprintf("context-overflow: %q -> %q", oldCfg.ContextOverflow, newCfg.ContextOverflow))
+	}
+	if !reflect.DeepEqual(oldCfg.UnsupportedParams, newCfg.UnsupportedParams) {
+		changes = append(changes, fmt.Sprintf("unsupported-params: %v -> %v", oldCfg.UnsupportedParams, newCfg.UnsupportedParams))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelFallbacks, newCfg.ModelFallbacks) {
+		changes = append(changes, fmt.Sprintf("model-fallbacks: %d -> %d chains", len(oldCfg.ModelFallbacks), len(newCfg.ModelFallbacks)))
+	}
+	if !reflect.DeepEqual(oldCfg.Thinking.Defaults, newCfg.Thinking.Defaults) {
+		changes = append(changes, fmt.Sprintf("thinking.defaults: %d -> %d models", len(oldCfg.Thinking.Defaults), len(newCfg.Thinking.Defaults)))
+	}
 
 	// Remote management (never print the key)
 	if oldCfg.RemoteManagement.AllowRemote != newCfg.RemoteManagement.AllowRemote {
@@ -327,6 +453,169 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	// Bedrock credentials (do not print key material)
+	if len(oldCfg.BedrockKey) != len(newCfg.BedrockKey) {
+		changes = append(changes, fmt.Sprintf("bedrock-api-key count: %d -> %d", len(oldCfg.BedrockKey), len(newCfg.BedrockKey)))
+	} else {
+		for i := range oldCfg.BedrockKey {
+			o := oldCfg.BedrockKey[i]
+			n := newCfg.BedrockKey[i]
+			if strings.TrimSpace(o.Region) != strings.TrimSpace(n.Region) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].region: %s -> %s", i, strings.TrimSpace(o.Region), strings.TrimSpace(n.Region)))
+			}
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.GetAPIKey() != n.GetAPIKey() || o.SecretAccessKey != n.SecretAccessKey || o.SessionToken != n.SessionToken {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].credentials: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].headers: updated", i))
+			}
+			oldModels := SummarizeClaudeModels(o.Models)
+			newModels := SummarizeClaudeModels(n.Models)
+			if oldModels.hash != newModels.hash {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].models: updated (%d -> %d entries)", i, oldModels.count, newModels.count))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("bedrock[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// OpenRouter keys (do not print key material)
+	if len(oldCfg.OpenRouterKey) != len(newCfg.OpenRouterKey) {
+		changes = append(changes, fmt.Sprintf("openrouter-api-key count: %d -> %d", len(oldCfg.OpenRouterKey), len(newCfg.OpenRouterKey)))
+	} else {
+		for i := range oldCfg.OpenRouterKey {
+			o := oldCfg.OpenRouterKey[i]
+			n := newCfg.OpenRouterKey[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].api-key: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].headers: updated", i))
+			}
+			if ComputeOpenRouterModelsHash(o.Models) != ComputeOpenRouterModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("openrouter[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// Ollama servers
+	if len(oldCfg.Ollama) != len(newCfg.Ollama) {
+		changes = append(changes, fmt.Sprintf("ollama count: %d -> %d", len(oldCfg.Ollama), len(newCfg.Ollama)))
+	} else {
+		for i := range oldCfg.Ollama {
+			o := oldCfg.Ollama[i]
+			n := newCfg.Ollama[i]
+			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
+			}
+			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("ollama[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].api-key: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].headers: updated", i))
+			}
+			if ComputeOllamaModelsHash(o.Models) != ComputeOllamaModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("ollama[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("ollama[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// External providers
+	if len(oldCfg.ExternalProviders) != len(newCfg.ExternalProviders) {
+		changes = append(changes, fmt.Sprintf("external-providers count: %d -> %d", len(oldCfg.ExternalProviders), len(newCfg.ExternalProviders)))
+	} else {
+		for i := range oldCfg.ExternalProviders {
+			o := oldCfg.ExternalProviders[i]
+			n := newCfg.ExternalProviders[i]
+			if o.Name != n.Name {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].name: %s -> %s", i, o.Name, n.Name))
+			}
+			if o.Command != n.Command || !reflect.DeepEqual(o.Args, n.Args) || !equalStringMap(o.Env, n.Env) {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].command: updated", i))
+			}
+			if o.URL != n.URL {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].url: %s -> %s", i, o.URL, n.URL))
+			}
+			if o.ProxyURL != n.ProxyURL {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
+			}
+			if o.APIKey != n.APIKey {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].api-key: updated", i))
+			}
+			if !equalStringMap(o.Headers, n.Headers) {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].headers: updated", i))
+			}
+			if o.Prefix != n.Prefix {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].prefix: %s -> %s", i, o.Prefix, n.Prefix))
+			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if o.Timeout != n.Timeout {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].timeout: %d -> %d", i, o.Timeout, n.Timeout))
+			}
+			if ComputeExternalProviderModelsHash(o.Models) != ComputeExternalProviderModelsHash(n.Models) {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].models: updated (%d -> %d entries)", i, len(o.Models), len(n.Models)))
+			}
+			oldExcluded := SummarizeExcludedModels(o.ExcludedModels)
+			newExcluded := SummarizeExcludedModels(n.ExcludedModels)
+			if oldExcluded.hash != newExcluded.hash {
+				changes = append(changes, fmt.Sprintf("external-providers[%d].excluded-models: updated (%d -> %d entries)", i, oldExcluded.count, newExcluded.count))
+			}
+		}
+	}
+
+	// Vertex Claude routing
+	if !reflect.DeepEqual(trimStrings(oldCfg.VertexClaude.Projects), trimStrings(newCfg.VertexClaude.Projects)) {
+		changes = append(changes, fmt.Sprintf("vertex-claude.projects: %v -> %v", oldCfg.VertexClaude.Projects, newCfg.VertexClaude.Projects))
+	}
+	if oldModels, newModels := SummarizeVertexModels(oldCfg.VertexClaude.Models), SummarizeVertexModels(newCfg.VertexClaude.Models); oldModels.hash != newModels.hash {
+		changes = append(changes, fmt.Sprintf("vertex-claude.models: updated (%d -> %d entries)", oldModels.count, newModels.count))
+	}
+
 	return changes
 }
 
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -86,6 +87,50 @@ func ComputeGeminiModelsHash(models []config.GeminiModel) string {
 	return hashJoined(keys)
 }
 
+// ComputeOpenRouterModelsHash returns a stable hash for OpenRouter model aliases.
+func ComputeOpenRouterModelsHash(models []config.OpenRouterModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeExternalProviderModelsHash returns a stable hash for external provider model aliases.
+func ComputeExternalProviderModelsHash(models []config.ExternalProviderModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			alias := strings.TrimSpace(model.Alias)
+			if name == "" && alias == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strings.ToLower(alias))
+		}
+	})
+	return hashJoined(keys)
+}
+
+// ComputeOllamaModelsHash returns a stable hash for selected Ollama models.
+func ComputeOllamaModelsHash(models []config.OllamaModel) string {
+	keys := normalizeModelPairs(func(out func(key string)) {
+		for _, model := range models {
+			name := strings.TrimSpace(model.Name)
+			if name == "" {
+				continue
+			}
+			out(strings.ToLower(name) + "|" + strconv.FormatBool(model.Thinking))
+		}
+	})
+	return hashJoined(keys)
+}
+
 // ComputeExcludedModelsHash returns a normalized hash for excluded model lists.
 func ComputeExcludedModelsHash(excluded []string) string {
 	if len(excluded) == 0 {
@@ -10,7 +10,7 @@ import (
 )
 
 // ConfigSynthesizer generates Auth entries from configuration API keys.
-// It handles Gemini, Claude, Codex, OpenAI-compat, and Vertex-compat providers.
+// It handles Gemini, Claude, Codex, OpenAI-compat, Vertex-compat, and Bedrock providers.
 type ConfigSynthesizer struct{}
 
 // NewConfigSynthesizer creates a new ConfigSynthesizer instance.
@@ -35,6 +35,14 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
+	// Bedrock
+	out = append(out, s.synthesizeBedrockKeys(ctx)...)
+	// OpenRouter
+	out = append(out, s.synthesizeOpenRouterKeys(ctx)...)
+	// Ollama servers
+	out = append(out, s.synthesizeOllamaServers(ctx)...)
+	// External providers
+	out = append(out, s.synthesizeExternalProviders(ctx)...)
 
 	return out, nil
 }
@@ -323,3 +331,197 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 	}
 	return out
 }
+
+// synthesizeBedrockKeys creates Auth entries for AWS Bedrock credentials.
+func (s *ConfigSynthesizer) synthesizeBedrockKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.BedrockKey))
+	for i := range cfg.BedrockKey {
+		bk := cfg.BedrockKey[i]
+		key := strings.TrimSpace(bk.GetAPIKey())
+		if key == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(bk.Prefix)
+		base := strings.TrimSpace(bk.BaseURL)
+		region := strings.TrimSpace(bk.Region)
+		id, token := idGen.Next("bedrock:apikey", key, region, base)
+		attrs := map[string]string{
+			"source":  fmt.Sprintf("config:bedrock[%s]", token),
+			"api_key": key,
+			"region":  region,
+		}
+		if secret := strings.TrimSpace(bk.SecretAccessKey); secret != "" && strings.TrimSpace(bk.APIKey) == "" {
+			attrs["secret_access_key"] = secret
+			if sessionToken := strings.TrimSpace(bk.SessionToken); sessionToken != "" {
+				attrs["session_token"] = sessionToken
+			}
+		}
+		if bk.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(bk.Priority)
+		}
+		if base != "" {
+			attrs["base_url"] = base
+		}
+		if hash := diff.ComputeClaudeModelsHash(bk.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(bk.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "bedrock",
+			Label:      "bedrock-apikey",
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(bk.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, bk.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeOpenRouterKeys creates Auth entries for OpenRouter API keys.
+func (s *ConfigSynthesizer) synthesizeOpenRouterKeys(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.OpenRouterKey))
+	for i := range cfg.OpenRouterKey {
+		rk := cfg.OpenRouterKey[i]
+		key := strings.TrimSpace(rk.APIKey)
+		if key == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(rk.Prefix)
+		base := strings.TrimSpace(rk.BaseURL)
+		id, token := idGen.Next("openrouter:apikey", key, base)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:openrouter[%s]", token),
+			"api_key":  key,
+			"base_url": base,
+		}
+		if rk.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(rk.Priority)
+		}
+		if hash := diff.ComputeOpenRouterModelsHash(rk.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(rk.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "openrouter",
+			Label:      "openrouter-apikey",
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(rk.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, rk.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeOllamaServers creates Auth entries for Ollama servers. The auth base URL targets
+// the OpenAI-compatible /v1 API of the server.
+func (s *ConfigSynthesizer) synthesizeOllamaServers(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.Ollama))
+	for i := range cfg.Ollama {
+		server := cfg.Ollama[i]
+		base := strings.TrimSpace(server.BaseURL)
+		if base == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(server.Prefix)
+		id, token := idGen.Next("ollama:server", base, prefix)
+		attrs := map[string]string{
+			"source":   fmt.Sprintf("config:ollama[%s]", token),
+			"base_url": base + "/v1",
+		}
+		if key := strings.TrimSpace(server.APIKey); key != "" {
+			attrs["api_key"] = key
+		}
+		if server.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(server.Priority)
+		}
+		if hash := diff.ComputeOllamaModelsHash(server.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(server.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   "ollama",
+			Label:      "ollama",
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(server.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, server.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
+
+// synthesizeExternalProviders creates one Auth entry per external provider. The auth provider
+// key is the provider name; the executor resolves the command or URL from the config.
+func (s *ConfigSynthesizer) synthesizeExternalProviders(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	out := make([]*coreauth.Auth, 0, len(cfg.ExternalProviders))
+	for i := range cfg.ExternalProviders {
+		entry := cfg.ExternalProviders[i]
+		name := strings.TrimSpace(entry.Name)
+		if name == "" {
+			continue
+		}
+		prefix := strings.TrimSpace(entry.Prefix)
+		id, token := idGen.Next("external:"+name, entry.Command, entry.URL, entry.APIKey, prefix)
+		attrs := map[string]string{
+			"source":            fmt.Sprintf("config:external-providers[%s]", token),
+			"external_provider": name,
+		}
+		if key := strings.TrimSpace(entry.APIKey); key != "" {
+			attrs["api_key"] = key
+		}
+		if entry.Priority != 0 {
+			attrs["priority"] = strconv.Itoa(entry.Priority)
+		}
+		if hash := diff.ComputeExternalProviderModelsHash(entry.Models); hash != "" {
+			attrs["models_hash"] = hash
+		}
+		addConfigHeadersToAttrs(entry.Headers, attrs)
+		a := &coreauth.Auth{
+			ID:         id,
+			Provider:   name,
+			Label:      name,
+			Prefix:     prefix,
+			Status:     coreauth.StatusActive,
+			ProxyURL:   strings.TrimSpace(entry.ProxyURL),
+			Attributes: attrs,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		ApplyAuthExcludedModelsMeta(a, cfg, entry.ExcludedModels, "apikey")
+		out = append(out, a)
+	}
+	return out
+}
@@ -102,6 +102,10 @@ func synthesizeFileAuths(ctx *SynthesisContext, fullPath string, data []byte) []
 	if p, ok := metadata["proxy_url"].(string); ok {
 		proxyURL = p
 	}
+	if strings.TrimSpace(proxyURL) == "" {
+		email, _ := metadata["email"].(string)
+		proxyURL = cfg.OAuthProxyURL(provider, email, filepath.Base(fullPath))
+	}
 
 	prefix := ""
 	if rawPrefix, ok := metadata["prefix"].(string); ok {
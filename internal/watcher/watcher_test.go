@@ -1691,3 +1691,47 @@ func TestScheduleProcessEventsStopsOnContextDone(t *testing.T) {
 func hexString(data []byte) string {
 	return strings.ToLower(fmt.Sprintf("%x", data))
 }
+
+func TestReloadConfigRejectsInvalidConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	authDir := filepath.Join(tmpDir, "auth")
+	if err := os.MkdirAll(authDir, 0o755); err != nil {
+		t.Fatalf("failed to create auth dir: %v", err)
+	}
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	invalid := "auth-dir: " + authDir + "\nopenai-compatibility:\n  - name: dup\n    base-url: https://a.example\n  - name: dup\n    base-url: https://b.example\n"
+	if err := os.WriteFile(configPath, []byte(invalid), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	previous := &config.Config{AuthDir: authDir, Port: 8317}
+	reloads := 0
+	w := &Watcher{
+		configPath:     configPath,
+		authDir:        authDir,
+		lastAuthHashes: make(map[string]string),
+		reloadCallback: func(*config.Config) { reloads++ },
+	}
+	w.SetConfig(previous)
+
+	if ok := w.reloadConfig(); ok {
+		t.Fatal("expected reloadConfig to reject duplicate provider names")
+	}
+	if reloads != 0 || w.config != previous {
+		t.Fatalf("expected previous config to stay active, reloads=%d config=%+v", reloads, w.config)
+	}
+	status := LastConfigReload()
+	if status == nil || status.Success || !strings.Contains(status.Error, "duplicate name") || status.Path != configPath {
+		t.Fatalf("unexpected reload status %+v", status)
+	}
+
+	if err := os.WriteFile(configPath, []byte("auth-dir: "+authDir+"\nport: 9090\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if ok := w.reloadConfig(); !ok {
+		t.Fatal("expected valid config to reload")
+	}
+	if status = LastConfigReload(); status == nil || !status.Success || status.Error != "" {
+		t.Fatalf("unexpected reload status after valid config %+v", status)
+	}
+}
@@ -70,6 +70,10 @@ func NewInvalidCredentialError() *AuthError {
 	return newAuthError(AuthErrorCodeInvalidCredential, "Invalid API key", http.StatusUnauthorized, nil)
 }
 
+func NewExpiredCredentialError() *AuthError {
+	return newAuthError(AuthErrorCodeInvalidCredential, "API key expired", http.StatusUnauthorized, nil)
+}
+
 func NewNotHandledError() *AuthError {
 	return newAuthError(AuthErrorCodeNotHandled, "authentication provider did not handle request", 0, nil)
 }
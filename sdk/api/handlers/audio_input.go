@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/transcription"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// textOnlyProviders drop OpenAI input_audio parts when translating requests. Gemini-family and
+// OpenAI-compatible providers receive the audio as-is.
+var textOnlyProviders = map[string]struct{}{
+	"claude": {},
+	"codex":  {},
+	"kimi":   {},
+}
+
+type audioInputPart struct {
+	path   string
+	data   string
+	format string
+}
+
+// prepareAudioInput keeps OpenAI input_audio parts from being silently stripped. Requests with
+// audio are routed to audio-capable providers when the model has any; otherwise each audio part
+// is replaced by its transcript from the configured speech-to-text backend, and the request is
+// rejected when no backend is configured.
+func prepareAudioInput(ctx context.Context, handlerType, modelName string, providers []string, rawJSON []byte) ([]string, []byte, *interfaces.ErrorMessage) {
+	if (handlerType != "openai" && handlerType != "openai-response") || !bytes.Contains(rawJSON, []byte(`input_audio`)) {
+		return providers, rawJSON, nil
+	}
+	parts := findAudioInputParts(rawJSON)
+	if len(parts) == 0 {
+		return providers, rawJSON, nil
+	}
+	capable := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		if _, textOnly := textOnlyProviders[provider]; !textOnly {
+			capable = append(capable, provider)
+		}
+	}
+	if len(capable) > 0 {
+		return capable, rawJSON, nil
+	}
+
+	settings := transcription.Active()
+	if settings == nil {
+		return nil, nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("model %s does not accept audio input and audio-transcription is not configured", modelName),
+		}
+	}
+	textType := "text"
+	if handlerType == "openai-response" {
+		textType = "input_text"
+	}
+	out := rawJSON
+	for _, part := range parts {
+		transcript, errTranscribe := settings.Transcribe(ctx, part.data, part.format)
+		if errTranscribe != nil {
+			return nil, nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusBadGateway,
+				Error:      fmt.Errorf("audio transcription failed: %w", errTranscribe),
+			}
+		}
+		textPart := []byte(`{"type":"","text":""}`)
+		textPart, _ = sjson.SetBytes(textPart, "type", textType)
+		textPart, _ = sjson.SetBytes(textPart, "text", transcript)
+		out, _ = sjson.SetRawBytes(out, part.path, textPart)
+	}
+	return providers, out, nil
+}
+
+// findAudioInputParts lists the input_audio content parts of Chat Completions messages and
+// Responses API input items.
+func findAudioInputParts(rawJSON []byte) []audioInputPart {
+	var parts []audioInputPart
+	for _, root := range []string{"messages", "input"} {
+		gjson.GetBytes(rawJSON, root).ForEach(func(i, message gjson.Result) bool {
+			message.Get("content").ForEach(func(j, content gjson.Result) bool {
+				if content.Get("type").String() != "input_audio" {
+					return true
+				}
+				audio := content.Get("input_audio")
+				if !audio.Exists() {
+					audio = content
+				}
+				if data := audio.Get("data").String(); data != "" {
+					parts = append(parts, audioInputPart{
+						path:   fmt.Sprintf("%s.%d.content.%d", root, i.Int(), j.Int()),
+						data:   data,
+						format: audio.Get("format").String(),
+					})
+				}
+				return true
+			})
+			return true
+		})
+	}
+	return parts
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/transcription"
+	"github.com/tidwall/gjson"
+)
+
+const audioChatRequest = `{"model":"m","messages":[{"role":"user","content":[{"type":"text","text":"listen"},{"type":"input_audio","input_audio":{"data":"c291bmQ=","format":"wav"}}]}]}`
+
+func TestPrepareAudioInputRoutesToAudioCapableProviders(t *testing.T) {
+	providers, body, errMsg := prepareAudioInput(context.Background(), "openai", "m", []string{"claude", "gemini"}, []byte(audioChatRequest))
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if !reflect.DeepEqual(providers, []string{"gemini"}) {
+		t.Fatalf("providers = %v, want [gemini]", providers)
+	}
+	if string(body) != audioChatRequest {
+		t.Fatalf("body changed: %s", body)
+	}
+}
+
+func TestPrepareAudioInputRejectsWithoutTranscription(t *testing.T) {
+	transcription.SetSettings(nil)
+	_, _, errMsg := prepareAudioInput(context.Background(), "openai", "m", []string{"claude"}, []byte(audioChatRequest))
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("errMsg = %+v, want 400", errMsg)
+	}
+}
+
+func TestPrepareAudioInputTranscribesForTextOnlyProviders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"text":"what is the weather"}`))
+	}))
+	defer server.Close()
+	transcription.SetSettings(&transcription.Settings{BaseURL: server.URL})
+	t.Cleanup(func() { transcription.SetSettings(nil) })
+
+	providers, body, errMsg := prepareAudioInput(context.Background(), "openai", "m", []string{"claude"}, []byte(audioChatRequest))
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if !reflect.DeepEqual(providers, []string{"claude"}) {
+		t.Fatalf("providers = %v, want [claude]", providers)
+	}
+	part := gjson.GetBytes(body, "messages.0.content.1")
+	if part.Get("type").String() != "text" || part.Get("text").String() != "what is the weather" {
+		t.Fatalf("audio part = %s, want transcript text", part.Raw)
+	}
+}
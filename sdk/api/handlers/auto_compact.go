@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// contextCompactedHeader reports how many messages auto-compact replaced with a summary.
+const contextCompactedHeader = "X-Proxy-Context-Compacted"
+
+const (
+	defaultAutoCompactKeepTurns = 2
+	defaultAutoCompactCacheTTL  = time.Hour
+	autoCompactCacheEntries     = 4096
+	// autoCompactWindowShare is the share of the context window used as threshold by default.
+	autoCompactWindowShare = 0.75
+)
+
+const autoCompactPrompt = "You compress chat histories. Summarize the conversation transcript you are given so an " +
+	"assistant can continue it without the original messages. Keep facts, decisions, open tasks, file names, " +
+	"identifiers, code and tool results that later turns may rely on; drop small talk. When the transcript " +
+	"starts with a previous summary, merge it with the new turns. Answer with the summary only."
+
+const autoCompactAcknowledgement = "Understood. I will continue from this summary."
+
+// compactSummaries caches summaries by conversation prefix fingerprint.
+var compactSummaries = cache.NewMemoryResponseStore(autoCompactCacheEntries)
+
+// applyAutoCompact replaces the older turns of a conversation above the auto-compact threshold
+// with a summary written by the configured summary model. Summaries are cached per conversation
+// prefix, so later requests of the same conversation reuse them and only summarize new turns.
+// Failures are logged and leave the request unchanged.
+func (h *BaseAPIHandler) applyAutoCompact(ctx context.Context, handlerType, modelName string, rawJSON []byte) []byte {
+	if h.Cfg == nil || !h.Cfg.AutoCompact.Enabled || h.Cfg.AutoCompact.Model == "" {
+		return rawJSON
+	}
+	settings := h.Cfg.AutoCompact
+	format, ok := conversationFormats[handlerType]
+	if !ok {
+		return rawJSON
+	}
+	threshold := int64(settings.Threshold)
+	if threshold <= 0 {
+		threshold = int64(float64(modelContextWindow(modelName)) * autoCompactWindowShare)
+	}
+	if threshold <= 0 || estimateRequestTokens(rawJSON) <= threshold {
+		return rawJSON
+	}
+	keepTurns := settings.KeepTurns
+	if keepTurns <= 0 {
+		keepTurns = defaultAutoCompactKeepTurns
+	}
+	items := gjson.GetBytes(rawJSON, format.path).Array()
+	turnStarts := userTurnStarts(items, format)
+	if len(turnStarts) <= keepTurns {
+		return rawJSON
+	}
+	cutTurn := len(turnStarts) - keepTurns
+	cut := turnStarts[cutTurn]
+
+	// Fingerprint every turn-aligned prefix up to the cut to find the longest summarized one.
+	fingerprints := make([]string, cutTurn+1)
+	digest := sha256.New()
+	digest.Write([]byte(settings.Model))
+	next := 0
+	for turn := 1; turn <= cutTurn; turn++ {
+		for ; next < turnStarts[turn]; next++ {
+			writeFingerprintItem(digest, items[next])
+		}
+		fingerprints[turn] = "compact:" + hex.EncodeToString(digest.Sum(nil))
+	}
+	from, previous := 0, ""
+	for turn := cutTurn; turn >= 1; turn-- {
+		if cached, hit := compactSummaries.Get(fingerprints[turn]); hit {
+			from, previous = turnStarts[turn], string(cached)
+			break
+		}
+	}
+
+	summary := previous
+	if from < cut {
+		transcript := compactTranscript(items[from:cut], format, previous)
+		var errSummary error
+		summary, errSummary = h.summarizeTranscript(ctx, settings.Model, transcript)
+		if errSummary != nil {
+			log.Warnf("auto-compact: summarizing %d message(s) with %s failed: %v", cut-from, settings.Model, errSummary)
+			return rawJSON
+		}
+		ttl := time.Duration(settings.CacheTTL) * time.Second
+		if ttl <= 0 {
+			ttl = defaultAutoCompactCacheTTL
+		}
+		compactSummaries.Set(fingerprints[cutTurn], []byte(summary), ttl)
+	}
+
+	kept := make([]json.RawMessage, 0, len(items)-cut+2)
+	replaced := 0
+	for _, item := range items[:cut] {
+		if format.system(item) {
+			kept = append(kept, json.RawMessage(item.Raw))
+			continue
+		}
+		replaced++
+	}
+	kept = append(kept,
+		json.RawMessage(format.message("user", "Summary of the earlier conversation:\n\n"+summary)),
+		json.RawMessage(format.message("assistant", autoCompactAcknowledgement)),
+	)
+	for _, item := range items[cut:] {
+		kept = append(kept, json.RawMessage(item.Raw))
+	}
+	encoded, errMarshal := json.Marshal(kept)
+	if errMarshal != nil {
+		return rawJSON
+	}
+	out, errSet := sjson.SetRawBytes(rawJSON, format.path, encoded)
+	if errSet != nil {
+		return rawJSON
+	}
+	setResponseHeader(ctx, contextCompactedHeader, strconv.Itoa(replaced))
+	return out
+}
+
+// summarizeTranscript asks model, through the regular execution path, for a summary of
+// transcript.
+func (h *BaseAPIHandler) summarizeTranscript(ctx context.Context, model, transcript string) (string, error) {
+	body := `{"messages":[{"role":"system"},{"role":"user"}]}`
+	body, _ = sjson.Set(body, "model", model)
+	body, _ = sjson.Set(body, "messages.0.content", autoCompactPrompt)
+	body, _ = sjson.Set(body, "messages.1.content", transcript)
+	resp, _, errMsg := h.executeWithFallbacks(ctx, "openai", model, []byte(body), "")
+	if errMsg != nil {
+		return "", errMsg.Error
+	}
+	summary := strings.TrimSpace(gjson.GetBytes(resp, "choices.0.message.content").String())
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
+
+// modelContextWindow returns the context window of modelName, or 0 when it is unknown.
+func modelContextWindow(modelName string) int64 {
+	info := registry.LookupModelInfo(thinking.ParseSuffix(modelName).ModelName)
+	if info == nil {
+		return 0
+	}
+	if info.ContextLength > 0 {
+		return int64(info.ContextLength)
+	}
+	return int64(info.InputTokenLimit)
+}
+
+func writeFingerprintItem(digest hash.Hash, item gjson.Result) {
+	digest.Write([]byte(strconv.Itoa(len(item.Raw))))
+	digest.Write([]byte{':'})
+	digest.Write([]byte(item.Raw))
+}
+
+// compactTranscript renders items as a plain text transcript for the summary model. System
+// items are left out because they are kept in the request.
+func compactTranscript(items []gjson.Result, format conversationFormat, previous string) string {
+	var b strings.Builder
+	if previous != "" {
+		b.WriteString("Previous summary:\n")
+		b.WriteString(previous)
+		b.WriteString("\n\nNew turns:\n")
+	}
+	for _, item := range items {
+		if format.system(item) {
+			continue
+		}
+		label := item.Get("role").String()
+		if label == "" {
+			label = item.Get("type").String()
+		}
+		if label == "" {
+			label = "item"
+		}
+		var parts []string
+		collectTranscriptText(item, &parts)
+		if len(parts) == 0 {
+			continue
+		}
+		b.WriteString(label)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(parts, "\n"))
+		b.WriteString("\n\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// collectTranscriptText appends the readable text of value: message text, tool names, tool call
+// arguments and tool results. Inline media, signatures and identifiers are skipped.
+func collectTranscriptText(value gjson.Result, parts *[]string) {
+	switch {
+	case value.IsArray():
+		for _, element := range value.Array() {
+			collectTranscriptText(element, parts)
+		}
+	case value.IsObject():
+		value.ForEach(func(key, field gjson.Result) bool {
+			switch key.String() {
+			case "text", "content", "output", "arguments", "name", "thinking":
+				if field.Type == gjson.String {
+					if text := strings.TrimSpace(field.String()); text != "" && !inlineMediaPattern.MatchString(text) {
+						*parts = append(*parts, text)
+					}
+					return true
+				}
+				collectTranscriptText(field, parts)
+			case "input", "args", "response", "function", "functionCall", "functionResponse", "tool_calls", "parts":
+				if field.IsObject() && (key.String() == "input" || key.String() == "args" || key.String() == "response") {
+					*parts = append(*parts, field.Raw)
+					return true
+				}
+				collectTranscriptText(field, parts)
+			}
+			return true
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type summaryExecutor struct {
+	mu          sync.Mutex
+	transcripts []string
+}
+
+func (e *summaryExecutor) Identifier() string { return "compact-test" }
+
+func (e *summaryExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.transcripts = append(e.transcripts, gjson.GetBytes(req.Payload, "messages.1.content").String())
+	n := len(e.transcripts)
+	e.mu.Unlock()
+	summary := "summary " + strings.Repeat("I", n)
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":"` + summary + `"}}]}`)}, nil
+}
+
+func (e *summaryExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *summaryExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *summaryExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *summaryExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestApplyAutoCompact_SummarizesAndReusesSummaries(t *testing.T) {
+	executor := &summaryExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "compact-auth", Provider: "compact-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "compact-summary-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{AutoCompact: sdkconfig.AutoCompactConfig{
+		Enabled: true, Model: "compact-summary-model", Threshold: 50, KeepTurns: 1,
+	}}, manager)
+
+	filler := strings.Repeat("x", 100)
+	turns := []string{
+		`{"role":"system","content":"be brief"}`,
+		`{"role":"user","content":"first ` + filler + `"}`,
+		`{"role":"assistant","content":"one"}`,
+		`{"role":"user","content":"second ` + filler + `"}`,
+		`{"role":"assistant","content":"two"}`,
+		`{"role":"user","content":"third"}`,
+	}
+	body := `{"model":"m","messages":[` + strings.Join(turns, ",") + `]}`
+
+	ctx, recorder := fallbackTestContext()
+	out := handler.applyAutoCompact(ctx, "openai", "m", []byte(body))
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 4 || messages[0].Get("role").String() != "system" || messages[3].Get("content").String() != "third" {
+		t.Fatalf("unexpected compacted messages: %s", out)
+	}
+	if got := messages[1].Get("content").String(); !strings.HasSuffix(got, "summary I") {
+		t.Fatalf("summary message = %q", got)
+	}
+	if got := recorder.Header().Get(contextCompactedHeader); got != "4" {
+		t.Fatalf("%s = %q, want 4", contextCompactedHeader, got)
+	}
+
+	// The same conversation reuses the cached summary.
+	ctx, _ = fallbackTestContext()
+	handler.applyAutoCompact(ctx, "openai", "m", []byte(body))
+	if len(executor.transcripts) != 1 {
+		t.Fatalf("summary model called %d times, want 1", len(executor.transcripts))
+	}
+
+	// A longer conversation only summarizes the new turns on top of the cached summary.
+	longer := `{"model":"m","messages":[` + strings.Join(append(turns, `{"role":"assistant","content":"three"}`, `{"role":"user","content":"fourth"}`), ",") + `]}`
+	ctx, _ = fallbackTestContext()
+	out = handler.applyAutoCompact(ctx, "openai", "m", []byte(longer))
+	if len(executor.transcripts) != 2 {
+		t.Fatalf("summary model called %d times, want 2", len(executor.transcripts))
+	}
+	transcript := executor.transcripts[1]
+	if !strings.HasPrefix(transcript, "Previous summary:\nsummary I") || strings.Contains(transcript, "first") || !strings.Contains(transcript, "three") {
+		t.Fatalf("unexpected incremental transcript: %q", transcript)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content").String(); !strings.HasSuffix(got, "summary II") {
+		t.Fatalf("summary message = %q", got)
+	}
+}
@@ -0,0 +1,55 @@
+package claude
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// ClaudeCreateBatch handles POST /v1/messages/batches by forwarding the Message Batches request
+// to a Claude API-key credential that serves the model of the first request in the batch.
+func (h *ClaudeCodeAPIHandler) ClaudeCreateBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	modelName := gjson.GetBytes(rawJSON, "requests.0.params.model").String()
+	h.forwardBatch(c, http.MethodPost, "", "", rawJSON, modelName)
+}
+
+// ClaudeGetBatch handles GET /v1/messages/batches/:id.
+func (h *ClaudeCodeAPIHandler) ClaudeGetBatch(c *gin.Context) {
+	h.forwardBatch(c, http.MethodGet, c.Param("id"), "", nil, "")
+}
+
+// ClaudeBatchResults handles GET /v1/messages/batches/:id/results and returns the JSONL results.
+func (h *ClaudeCodeAPIHandler) ClaudeBatchResults(c *gin.Context) {
+	h.forwardBatch(c, http.MethodGet, c.Param("id"), "/results", nil, "")
+}
+
+// ClaudeCancelBatch handles POST /v1/messages/batches/:id/cancel.
+func (h *ClaudeCodeAPIHandler) ClaudeCancelBatch(c *gin.Context) {
+	h.forwardBatch(c, http.MethodPost, c.Param("id"), "/cancel", nil, "")
+}
+
+func (h *ClaudeCodeAPIHandler) forwardBatch(c *gin.Context, method, batchID, suffix string, body []byte, modelName string) {
+	status, upstreamHeaders, resp, errMsg := h.ClaudeBatchRequest(c.Request.Context(), method, batchID, suffix, body, modelName)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	contentType := upstreamHeaders.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(status, contentType, resp)
+}
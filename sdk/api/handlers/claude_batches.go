@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// ClaudeBatchesPath is the Anthropic Message Batches API collection path.
+	ClaudeBatchesPath = "/v1/messages/batches"
+
+	defaultClaudeBaseURL = "https://api.anthropic.com"
+	claudeAPIVersion     = "2023-06-01"
+)
+
+// claudeBatchOwners maps Anthropic batch IDs to the credential that created them. Batches are
+// scoped to the upstream organization, so follow-up calls must reuse the same credential.
+var claudeBatchOwners sync.Map
+
+// ClaudeBatchRequest sends a Message Batches API call upstream and returns the raw response.
+// An empty batchID creates a batch on a Claude API-key credential that serves model; otherwise
+// the call is routed to the credential that created the batch and suffix (e.g. "/results",
+// "/cancel") is appended to the batch path.
+func (h *BaseAPIHandler) ClaudeBatchRequest(ctx context.Context, method, batchID, suffix string, body []byte, model string) (int, http.Header, []byte, *interfaces.ErrorMessage) {
+	if h == nil || h.AuthManager == nil {
+		return 0, nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: fmt.Errorf("auth manager unavailable")}
+	}
+	var auth *coreauth.Auth
+	if batchID == "" {
+		auth = h.pickClaudeBatchAuth(model)
+		if auth == nil {
+			return 0, nil, nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusServiceUnavailable,
+				Error:      fmt.Errorf("no Claude API key credential available for message batches"),
+			}
+		}
+	} else {
+		authID, ok := claudeBatchOwners.Load(batchID)
+		if ok {
+			auth, _ = h.AuthManager.GetByID(authID.(string))
+		}
+		if auth == nil {
+			return 0, nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: fmt.Errorf("batch %s not found", batchID)}
+		}
+	}
+
+	target := claudeBatchBaseURL(auth) + ClaudeBatchesPath
+	if batchID != "" {
+		target += "/" + url.PathEscape(batchID) + suffix
+	}
+	headers := http.Header{}
+	headers.Set("Anthropic-Version", claudeAPIVersion)
+	if body != nil {
+		headers.Set("Content-Type", "application/json")
+	}
+	httpReq, errRequest := h.AuthManager.NewHttpRequest(ctx, auth, method, target, body, headers)
+	if errRequest != nil {
+		return 0, nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errRequest}
+	}
+	httpResp, errDo := h.AuthManager.HttpRequest(ctx, auth, httpReq)
+	if errDo != nil {
+		return 0, nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errDo}
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	data, errRead := io.ReadAll(httpResp.Body)
+	if errRead != nil {
+		return 0, nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errRead}
+	}
+	if httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		return httpResp.StatusCode, httpResp.Header, data, &interfaces.ErrorMessage{StatusCode: httpResp.StatusCode, Error: fmt.Errorf("%s", data)}
+	}
+	if batchID == "" {
+		if id := gjson.GetBytes(data, "id").String(); id != "" {
+			claudeBatchOwners.Store(id, auth.ID)
+		}
+	}
+	return httpResp.StatusCode, httpResp.Header, data, nil
+}
+
+// pickClaudeBatchAuth returns an available Claude credential configured with an API key that
+// serves model. Message Batches are not available to OAuth subscription tokens.
+func (h *BaseAPIHandler) pickClaudeBatchAuth(model string) *coreauth.Auth {
+	baseModel := strings.TrimSpace(thinking.ParseSuffix(model).ModelName)
+	now := time.Now()
+	var candidates []*coreauth.Auth
+	for _, auth := range h.AuthManager.List() {
+		if auth == nil || auth.Disabled || !strings.EqualFold(auth.Provider, "claude") {
+			continue
+		}
+		if auth.Attributes == nil || strings.TrimSpace(auth.Attributes["api_key"]) == "" {
+			continue
+		}
+		if auth.Unavailable && auth.NextRetryAfter.After(now) {
+			continue
+		}
+		if baseModel != "" && !registry.GetGlobalRegistry().ClientSupportsModel(auth.ID, baseModel) {
+			continue
+		}
+		candidates = append(candidates, auth)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	return candidates[0]
+}
+
+func claudeBatchBaseURL(auth *coreauth.Auth) string {
+	if auth != nil && auth.Attributes != nil {
+		if baseURL := strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/"); baseURL != "" {
+			return baseURL
+		}
+	}
+	return defaultClaudeBaseURL
+}
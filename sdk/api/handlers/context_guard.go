@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/apikeys"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// contextTruncatedHeader reports how many messages the context guard dropped.
+const contextTruncatedHeader = "X-Proxy-Context-Truncated"
+
+// inlineMediaTokens is the estimate used for each inline base64 media payload, which would
+// otherwise be counted at four bytes per token.
+const inlineMediaTokens = 1000
+
+// inlineMediaPattern matches long base64 runs of inline images, audio and documents.
+var inlineMediaPattern = regexp.MustCompile(`[A-Za-z0-9+/]{1000,}={0,2}`)
+
+// conversationFormat describes where a request format keeps its conversation and how turns
+// are recognized.
+type conversationFormat struct {
+	path string
+	// system reports items that are kept regardless of their position.
+	system func(item gjson.Result) bool
+	// userTurn reports items that start a new user turn. Tool results are not user turns, so
+	// they stay with the tool calls they answer.
+	userTurn func(item gjson.Result) bool
+	// message builds a plain text message; role is "user" or "assistant".
+	message func(role, text string) string
+}
+
+var conversationFormats = map[string]conversationFormat{
+	"openai": {
+		path:     "messages",
+		system:   func(item gjson.Result) bool { return isSystemRole(item.Get("role").String()) },
+		userTurn: func(item gjson.Result) bool { return item.Get("role").String() == "user" },
+		message:  roleContentMessage,
+	},
+	"openai-response": {
+		path:   "input",
+		system: func(item gjson.Result) bool { return isSystemRole(item.Get("role").String()) },
+		userTurn: func(item gjson.Result) bool {
+			itemType := item.Get("type").String()
+			return item.Get("role").String() == "user" && (itemType == "" || itemType == "message")
+		},
+		message: roleContentMessage,
+	},
+	"claude": {
+		path:   "messages",
+		system: func(gjson.Result) bool { return false },
+		userTurn: func(item gjson.Result) bool {
+			return item.Get("role").String() == "user" && !allContent(item.Get("content"), "type", "tool_result")
+		},
+		message: roleContentMessage,
+	},
+	"gemini": {
+		path:     "contents",
+		system:   func(gjson.Result) bool { return false },
+		userTurn: geminiUserTurn,
+		message:  geminiMessage,
+	},
+	"gemini-cli": {
+		path:     "request.contents",
+		system:   func(gjson.Result) bool { return false },
+		userTurn: geminiUserTurn,
+		message:  geminiMessage,
+	},
+}
+
+// applyContextGuard enforces the context-overflow mode of the request's proxy key, or the
+// top-level one, for requests whose estimated size exceeds the model's context window.
+func (h *BaseAPIHandler) applyContextGuard(ctx context.Context, handlerType, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	mode := ""
+	if h.Cfg != nil {
+		mode = h.Cfg.ContextOverflow
+	}
+	if key := h.proxyKeyFromContext(ctx); key != nil && key.ContextOverflow != "" {
+		mode = key.ContextOverflow
+	}
+	if mode != "truncate" && mode != "reject" {
+		return rawJSON, nil
+	}
+	window := modelContextWindow(modelName)
+	if window <= 0 {
+		return rawJSON, nil
+	}
+	estimate := estimateRequestTokens(rawJSON)
+	if estimate <= window {
+		return rawJSON, nil
+	}
+	if mode == "truncate" {
+		if format, ok := conversationFormats[handlerType]; ok {
+			if truncated, dropped, fits := truncateConversation(rawJSON, format, window); fits {
+				setResponseHeader(ctx, contextTruncatedHeader, strconv.Itoa(dropped))
+				return truncated, nil
+			}
+		}
+	}
+	return nil, contextLengthExceeded(window, estimate)
+}
+
+// truncateConversation drops the oldest user turns, with the assistant replies and tool calls
+// that follow them, until the request fits window. System items and the latest user turn are
+// always kept. It reports the number of dropped items and whether the result fits.
+func truncateConversation(rawJSON []byte, format conversationFormat, window int64) ([]byte, int, bool) {
+	items := gjson.GetBytes(rawJSON, format.path).Array()
+	turnStarts := userTurnStarts(items, format)
+	if len(turnStarts) < 2 {
+		return rawJSON, 0, false
+	}
+
+	for cut := 1; cut < len(turnStarts); cut++ {
+		kept := make([]json.RawMessage, 0, len(items))
+		dropped := 0
+		for i, item := range items {
+			if i < turnStarts[cut] && !format.system(item) {
+				dropped++
+				continue
+			}
+			kept = append(kept, json.RawMessage(item.Raw))
+		}
+		encoded, errMarshal := json.Marshal(kept)
+		if errMarshal != nil {
+			return rawJSON, 0, false
+		}
+		out, errSet := sjson.SetRawBytes(rawJSON, format.path, encoded)
+		if errSet != nil {
+			return rawJSON, 0, false
+		}
+		if estimateRequestTokens(out) <= window {
+			return out, dropped, true
+		}
+	}
+	return rawJSON, 0, false
+}
+
+// userTurnStarts returns the indexes of the items that start a user turn. The first turn
+// starts at 0 so that items before the first user message belong to it.
+func userTurnStarts(items []gjson.Result, format conversationFormat) []int {
+	var turnStarts []int
+	for i, item := range items {
+		if format.userTurn(item) {
+			turnStarts = append(turnStarts, i)
+		}
+	}
+	if len(turnStarts) > 0 {
+		turnStarts[0] = 0
+	}
+	return turnStarts
+}
+
+// estimateRequestTokens estimates the prompt and requested output tokens of a request, counting
+// each inline media payload as inlineMediaTokens.
+func estimateRequestTokens(rawJSON []byte) int64 {
+	media := int64(0)
+	stripped := inlineMediaPattern.ReplaceAllFunc(rawJSON, func([]byte) []byte {
+		media++
+		return nil
+	})
+	return apikeys.EstimateTokens(stripped) + media*inlineMediaTokens
+}
+
+func contextLengthExceeded(window, estimate int64) *interfaces.ErrorMessage {
+	body, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{
+		Message: fmt.Sprintf("This model's maximum context length is %d tokens. However, your request requires about %d tokens. Please reduce the length of the messages or the requested output tokens.", window, estimate),
+		Type:    "invalid_request_error",
+		Code:    "context_length_exceeded",
+	}})
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("%s", body),
+	}
+}
+
+func isSystemRole(role string) bool {
+	return role == "system" || role == "developer"
+}
+
+func roleContentMessage(role, text string) string {
+	message, _ := sjson.Set(`{}`, "role", role)
+	message, _ = sjson.Set(message, "content", text)
+	return message
+}
+
+func geminiMessage(role, text string) string {
+	if role == "assistant" {
+		role = "model"
+	}
+	message, _ := sjson.Set(`{}`, "role", role)
+	message, _ = sjson.Set(message, "parts.0.text", text)
+	return message
+}
+
+func geminiUserTurn(item gjson.Result) bool {
+	role := item.Get("role").String()
+	return (role == "user" || role == "") && !allContent(item.Get("parts"), "functionResponse", "")
+}
+
+// allContent reports whether content is a non-empty array whose every block has field, or has
+// field equal to value when value is set.
+func allContent(content gjson.Result, field, value string) bool {
+	if !content.IsArray() {
+		return false
+	}
+	blocks := content.Array()
+	if len(blocks) == 0 {
+		return false
+	}
+	for _, block := range blocks {
+		got := block.Get(field)
+		if !got.Exists() || (value != "" && got.String() != value) {
+			return false
+		}
+	}
+	return true
+}
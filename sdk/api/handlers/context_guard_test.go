@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func registerContextGuardModel(t *testing.T) {
+	t.Helper()
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("test-context-guard", "openai", []*registry.ModelInfo{{ID: "context-guard-model", ContextLength: 100}})
+	t.Cleanup(func() { reg.UnregisterClient("test-context-guard") })
+}
+
+func TestApplyContextGuard_TruncatesOldestTurns(t *testing.T) {
+	registerContextGuardModel(t)
+	filler := strings.Repeat("x", 120)
+	tests := []struct {
+		name        string
+		handlerType string
+		body        string
+		path        string
+		wantKept    int
+		wantHeader  string
+	}{
+		{
+			name:        "openai keeps system and tool pairs",
+			handlerType: "openai",
+			body: `{"messages":[{"role":"system","content":"be brief"},` +
+				`{"role":"user","content":"` + filler + `"},` +
+				`{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]},` +
+				`{"role":"tool","tool_call_id":"c1","content":"` + filler + `"},` +
+				`{"role":"assistant","content":"done"},` +
+				`{"role":"user","content":"next"}]}`,
+			path:       "messages",
+			wantKept:   2,
+			wantHeader: "4",
+		},
+		{
+			name:        "claude tool results stay with their turn",
+			handlerType: "claude",
+			body: `{"messages":[{"role":"user","content":"` + filler + `"},` +
+				`{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"f","input":{}}]},` +
+				`{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]},` +
+				`{"role":"user","content":"next"},` +
+				`{"role":"assistant","content":[{"type":"tool_use","id":"t2","name":"f","input":{}}]},` +
+				`{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":"ok"}]}]}`,
+			path:       "messages",
+			wantKept:   3,
+			wantHeader: "3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ContextOverflow: "truncate"}, nil)
+			ctx, recorder := fallbackTestContext()
+
+			out, errMsg := handler.applyContextGuard(ctx, tt.handlerType, "context-guard-model", []byte(tt.body))
+			if errMsg != nil {
+				t.Fatalf("unexpected error: %+v", errMsg)
+			}
+			if got := len(gjson.GetBytes(out, tt.path).Array()); got != tt.wantKept {
+				t.Fatalf("kept %d items, want %d: %s", got, tt.wantKept, out)
+			}
+			if got := recorder.Header().Get(contextTruncatedHeader); got != tt.wantHeader {
+				t.Fatalf("%s = %q, want %q", contextTruncatedHeader, got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestApplyContextGuard_Rejects(t *testing.T) {
+	registerContextGuardModel(t)
+	body := []byte(`{"messages":[{"role":"user","content":"` + strings.Repeat("x", 500) + `"}]}`)
+	ctx, _ := fallbackTestContext()
+
+	for _, mode := range []string{"reject", "truncate"} {
+		handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ContextOverflow: mode}, nil)
+		_, errMsg := handler.applyContextGuard(ctx, "openai", "context-guard-model", body)
+		if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %+v", mode, errMsg)
+		}
+		if code := gjson.Get(errMsg.Error.Error(), "error.code").String(); code != "context_length_exceeded" {
+			t.Fatalf("%s: error code = %q", mode, code)
+		}
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	if out, errMsg := handler.applyContextGuard(ctx, "openai", "context-guard-model", body); errMsg != nil || string(out) != string(body) {
+		t.Fatalf("disabled guard should pass through, got %+v", errMsg)
+	}
+}
@@ -9,19 +9,24 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/traffic"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/transform"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 )
 
@@ -43,10 +48,16 @@ type ErrorDetail struct {
 
 	// Code is a short code identifying the error, if applicable.
 	Code string `json:"code,omitempty"`
+
+	// Param names the request field the error refers to, if applicable.
+	Param string `json:"param,omitempty"`
 }
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// retryCountHeader carries the number of upstream retries a response needed.
+const retryCountHeader = "X-Retry-Count"
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -163,6 +174,23 @@ func StreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// StreamingIdleTimeout returns how long a stream may go without upstream data before it is
+// aborted. Returning 0 disables the timeout (default when unset).
+func StreamingIdleTimeout(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil || cfg.Streaming.IdleTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.Streaming.IdleTimeoutSeconds) * time.Second
+}
+
+// StreamingKeepAliveMode returns the configured keep-alive mode, "comment" or "empty-delta".
+func StreamingKeepAliveMode(cfg *config.SDKConfig) string {
+	if cfg != nil && strings.EqualFold(strings.TrimSpace(cfg.Streaming.KeepAliveMode), config.StreamingKeepAliveEmptyDelta) {
+		return config.StreamingKeepAliveEmptyDelta
+	}
+	return config.StreamingKeepAliveComment
+}
+
 // NonStreamingKeepAliveInterval returns the keep-alive interval for non-streaming responses.
 // Returning 0 disables keep-alives (default when unset).
 func NonStreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
@@ -194,6 +222,18 @@ func PassthroughHeadersEnabled(cfg *config.SDKConfig) bool {
 	return cfg != nil && cfg.PassthroughHeaders
 }
 
+// withRetryCountHeader reports the retries the auth manager performed for this request
+// in the X-Retry-Count response header.
+func withRetryCountHeader(ctx context.Context) context.Context {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ctx
+	}
+	return coreauth.WithRetryObserver(ctx, func(retries int) {
+		ginCtx.Header(retryCountHeader, strconv.Itoa(retries))
+	})
+}
+
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// Only include it if the client explicitly provides it.
@@ -532,12 +572,92 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 }
 
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
-// This path is the only supported execution route.
+// This path is the only supported execution route. Rate-limit and overloaded failures move
+// the request along the model's configured fallback chain, and identical concurrent requests
+// share one upstream call when request deduplication is enabled. Deterministic requests are
+// answered from the response cache when it is enabled.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	modelName, rawJSON = h.resolveModelAlias(ctx, modelName, rawJSON)
+	choices, errChoices := h.multipleChoicesFanOut(handlerType, modelName, rawJSON, false)
+	if errChoices != nil {
+		return nil, nil, errChoices
+	}
+	cacheKey, cacheable := responseCacheKey(ctx, handlerType, modelName, alt, rawJSON)
+	if cacheable {
+		if cached, ok := cache.GetCachedResponse(cacheKey); ok {
+			setProxyCacheHeader(ctx, "HIT")
+			return cached, nil, nil
+		}
+	}
+	var (
+		resp    []byte
+		headers http.Header
+		errMsg  *interfaces.ErrorMessage
+	)
+	switch {
+	case choices > 1:
+		resp, headers, errMsg = h.executeChoices(ctx, handlerType, modelName, rawJSON, alt, choices)
+	case RequestDedupeEnabled(h.Cfg):
+		resp, headers, errMsg = h.executeDeduplicated(ctx, handlerType, modelName, rawJSON, alt)
+	default:
+		resp, headers, errMsg = h.executeWithFallbacks(ctx, handlerType, modelName, rawJSON, alt)
+	}
+	if cacheable && errMsg == nil {
+		cache.CacheResponse(cacheKey, resp)
+		setProxyCacheHeader(ctx, "MISS")
+	}
+	return resp, headers, errMsg
+}
+
+// executeWithFallbacks runs a non-streaming request and walks the model's fallback chain on
+// rate-limit and overloaded failures.
+func (h *BaseAPIHandler) executeWithFallbacks(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	resp, headers, errMsg := h.executeModel(ctx, handlerType, modelName, rawJSON, alt)
+	for _, fallback := range h.modelFallbacks(modelName) {
+		if errMsg == nil || !fallbackEligible(errMsg.Error) {
+			break
+		}
+		if _, _, errDetails := h.getRequestDetails(fallback); errDetails != nil {
+			log.Debugf("skipping fallback model %s: %v", fallback, errDetails.Error)
+			continue
+		}
+		if errAccess := h.checkModelAccess(ctx, fallback); errAccess != nil {
+			log.Debugf("skipping fallback model %s: %v", fallback, errAccess.Error)
+			continue
+		}
+		logModelFallback(modelName, fallback, errMsg.Error)
+		resp, headers, errMsg = h.executeModel(ctx, handlerType, fallback, payloadForFallback(rawJSON, fallback), alt)
+		if errMsg == nil {
+			annotateFallbackModel(ctx, fallback)
+		}
+	}
+	return resp, headers, errMsg
+}
+
+// executeModel runs one non-streaming request for modelName via the core auth manager.
+func (h *BaseAPIHandler) executeModel(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if errAccess := h.checkModelAccess(ctx, modelName); errAccess != nil {
+		return nil, nil, errAccess
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	providers, rawJSON, errMsg = prepareAudioInput(ctx, handlerType, normalizedModel, providers, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	rawJSON, errMsg = h.applyUnsupportedParams(ctx, handlerType, normalizedModel, providers, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	rawJSON = h.applySystemPromptRules(ctx, handlerType, normalizedModel, rawJSON)
+	rawJSON = h.applyAutoCompact(ctx, handlerType, normalizedModel, rawJSON)
+	rawJSON, errMsg = h.applyContextGuard(ctx, handlerType, normalizedModel, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	rawJSON = inlineRemoteImages(ctx, handlerType, rawJSON)
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -556,7 +676,12 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		Headers:         headersFromContext(ctx),
 	}
 	opts.Metadata = reqMeta
-	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	ctx, release, errMsg := acquireUpstreamSlot(ctx)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	defer release()
+	resp, err := h.AuthManager.Execute(withRetryCountHeader(ctx), providers, req, opts)
 	if err != nil {
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		status := http.StatusInternalServerError
@@ -582,6 +707,10 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	modelName, rawJSON = h.resolveModelAlias(ctx, modelName, rawJSON)
+	if errAccess := h.checkModelAccess(ctx, modelName); errAccess != nil {
+		return nil, nil, errAccess
+	}
 	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, nil, errMsg
@@ -604,7 +733,7 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 		Headers:         headersFromContext(ctx),
 	}
 	opts.Metadata = reqMeta
-	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
+	resp, err := h.AuthManager.ExecuteCount(withRetryCountHeader(ctx), providers, req, opts)
 	if err != nil {
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		status := http.StatusInternalServerError
@@ -630,34 +759,57 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 // The returned http.Header carries upstream response headers captured before streaming begins.
+// Rate-limit and overloaded failures before the first payload byte move the request along the
+// model's configured fallback chain.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
-	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	modelName, rawJSON = h.resolveModelAlias(ctx, modelName, rawJSON)
+	_, errMsg := h.multipleChoicesFanOut(handlerType, modelName, rawJSON, true)
+	var (
+		providers       []string
+		normalizedModel string
+		req             coreexecutor.Request
+		opts            coreexecutor.Options
+	)
+	if errMsg == nil {
+		providers, normalizedModel, req, opts, errMsg = h.prepareStreamRequest(ctx, handlerType, modelName, rawJSON, alt)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
 		close(errChan)
 		return nil, nil, errChan
 	}
-	reqMeta := requestExecutionMetadata(ctx)
-	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
-	payload := rawJSON
-	if len(payload) == 0 {
-		payload = nil
+	fallbacks := h.modelFallbacks(modelName)
+	// nextFallback moves the request to the next model of its fallback chain that resolves to providers.
+	nextFallback := func(cause error) bool {
+		for len(fallbacks) > 0 {
+			fallback := fallbacks[0]
+			fallbacks = fallbacks[1:]
+			nextProviders, nextModel, nextReq, nextOpts, errPrepare := h.prepareStreamRequest(ctx, handlerType, fallback, payloadForFallback(rawJSON, fallback), alt)
+			if errPrepare != nil {
+				log.Debugf("skipping fallback model %s: %v", fallback, errPrepare.Error)
+				continue
+			}
+			logModelFallback(modelName, fallback, cause)
+			annotateFallbackModel(ctx, fallback)
+			providers, normalizedModel, req, opts = nextProviders, nextModel, nextReq, nextOpts
+			return true
+		}
+		return false
 	}
-	req := coreexecutor.Request{
-		Model:   normalizedModel,
-		Payload: payload,
+	ctx, release, errMsg := acquireUpstreamSlot(ctx)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
 	}
-	opts := coreexecutor.Options{
-		Stream:          true,
-		Alt:             alt,
-		OriginalRequest: rawJSON,
-		SourceFormat:    sdktranslator.FromString(handlerType),
-		Headers:         headersFromContext(ctx),
+	streamResult, err := h.AuthManager.ExecuteStream(withRetryCountHeader(ctx), providers, req, opts)
+	for err != nil && fallbackEligible(err) && nextFallback(err) {
+		streamResult, err = h.AuthManager.ExecuteStream(withRetryCountHeader(ctx), providers, req, opts)
 	}
-	opts.Metadata = reqMeta
-	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
+		release()
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
@@ -689,12 +841,16 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	chunks := streamResult.Chunks
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
+	endStream := traffic.GetTracker().BeginStream()
 	go func() {
+		defer release()
+		defer endStream()
 		defer close(dataChan)
 		defer close(errChan)
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		transforms := transform.NewChain(ctx, transform.StreamInfo{Format: handlerType, Model: modelName})
 
 		sendErr := func(msg *interfaces.ErrorMessage) bool {
 			if ctx == nil {
@@ -751,6 +907,11 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					chunk, ok = <-chunks
 				}
 				if !ok {
+					for _, tail := range transforms.Close() {
+						if len(tail) > 0 && !sendData(tail) {
+							return
+						}
+					}
 					return
 				}
 				if chunk.Err != nil {
@@ -770,6 +931,17 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							}
 							streamErr = enrichAuthSelectionError(retryErr, providers, normalizedModel)
 						}
+						if fallbackEligible(streamErr) && nextFallback(streamErr) {
+							retryResult, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+							if retryErr == nil {
+								if passthroughHeadersEnabled {
+									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
+								}
+								chunks = retryResult.Chunks
+								continue outer
+							}
+							streamErr = enrichAuthSelectionError(retryErr, providers, normalizedModel)
+						}
 					}
 
 					status := http.StatusInternalServerError
@@ -795,8 +967,13 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 						}
 					}
 					sentPayload = true
-					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
-						return
+					for _, out := range transforms.Chunk(cloneBytes(chunk.Payload)) {
+						if len(out) == 0 {
+							continue
+						}
+						if okSendData := sendData(out); !okSendData {
+							return
+						}
 					}
 				}
 			}
@@ -805,6 +982,51 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	return dataChan, upstreamHeaders, errChan
 }
 
+// prepareStreamRequest resolves providers for modelName and builds the streaming executor request.
+func (h *BaseAPIHandler) prepareStreamRequest(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]string, string, coreexecutor.Request, coreexecutor.Options, *interfaces.ErrorMessage) {
+	if errAccess := h.checkModelAccess(ctx, modelName); errAccess != nil {
+		return nil, "", coreexecutor.Request{}, coreexecutor.Options{}, errAccess
+	}
+	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	if errMsg != nil {
+		return nil, "", coreexecutor.Request{}, coreexecutor.Options{}, errMsg
+	}
+	providers, rawJSON, errMsg = prepareAudioInput(ctx, handlerType, normalizedModel, providers, rawJSON)
+	if errMsg != nil {
+		return nil, "", coreexecutor.Request{}, coreexecutor.Options{}, errMsg
+	}
+	rawJSON, errMsg = h.applyUnsupportedParams(ctx, handlerType, normalizedModel, providers, rawJSON)
+	if errMsg != nil {
+		return nil, "", coreexecutor.Request{}, coreexecutor.Options{}, errMsg
+	}
+	rawJSON = h.applySystemPromptRules(ctx, handlerType, normalizedModel, rawJSON)
+	rawJSON = h.applyAutoCompact(ctx, handlerType, normalizedModel, rawJSON)
+	rawJSON, errMsg = h.applyContextGuard(ctx, handlerType, normalizedModel, rawJSON)
+	if errMsg != nil {
+		return nil, "", coreexecutor.Request{}, coreexecutor.Options{}, errMsg
+	}
+	rawJSON = inlineRemoteImages(ctx, handlerType, rawJSON)
+	reqMeta := requestExecutionMetadata(ctx)
+	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	payload := rawJSON
+	if len(payload) == 0 {
+		payload = nil
+	}
+	req := coreexecutor.Request{
+		Model:   normalizedModel,
+		Payload: payload,
+	}
+	opts := coreexecutor.Options{
+		Stream:          true,
+		Alt:             alt,
+		OriginalRequest: rawJSON,
+		SourceFormat:    sdktranslator.FromString(handlerType),
+		Headers:         headersFromContext(ctx),
+	}
+	opts.Metadata = reqMeta
+	return providers, normalizedModel, req, opts, nil
+}
+
 func validateSSEDataJSON(chunk []byte) error {
 	for _, line := range bytes.Split(chunk, []byte("\n")) {
 		line = bytes.TrimSpace(line)
@@ -991,6 +1213,7 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 		}
 	}
 
+	c.Set(traffic.ErrorMessageKey, errText)
 	body := BuildErrorResponseBody(status, errText)
 	// Append first to preserve upstream response logs, then drop duplicate payloads if already recorded.
 	var previous []byte
@@ -0,0 +1,210 @@
+// Package mcp provides a Model Context Protocol server facade for the proxy.
+// MCP-capable IDEs can register the proxy over the Streamable HTTP transport to browse the
+// available models and usage statistics as resources and to run sampling requests through
+// the regular request translation pipeline.
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+const (
+	// latestProtocolVersion is answered to clients requesting an unknown protocol version.
+	latestProtocolVersion = "2025-06-18"
+	serverName            = "cliproxyapi"
+)
+
+// supportedProtocolVersions are the MCP revisions whose subset used here is compatible.
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC and MCP error codes.
+const (
+	codeParseError       = -32700
+	codeInvalidRequest   = -32600
+	codeMethodNotFound   = -32601
+	codeInvalidParams    = -32602
+	codeInternalError    = -32603
+	codeResourceNotFound = -32002
+)
+
+// MCPAPIHandler contains the handler of the MCP endpoint.
+type MCPAPIHandler struct {
+	*handlers.BaseAPIHandler
+}
+
+// NewMCPAPIHandler creates a new MCP handler instance.
+func NewMCPAPIHandler(apiHandlers *handlers.BaseAPIHandler) *MCPAPIHandler {
+	return &MCPAPIHandler{BaseAPIHandler: apiHandlers}
+}
+
+// HandlerType returns the identifier for this handler implementation. Sampling requests are
+// executed in the OpenAI chat format.
+func (h *MCPAPIHandler) HandlerType() string {
+	return OpenAI
+}
+
+// Models returns the models that sampling requests can target.
+func (h *MCPAPIHandler) Models() []map[string]any {
+	return registry.GetGlobalRegistry().GetAvailableModels("openai")
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Handle serves POST /mcp. It accepts a single JSON-RPC message or a batch and answers with
+// JSON; notifications are acknowledged with 202 Accepted.
+func (h *MCPAPIHandler) Handle(c *gin.Context) {
+	if h.Cfg == nil || !h.Cfg.MCPServer {
+		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "The MCP endpoint is disabled.",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	raw, errRead := io.ReadAll(c.Request.Body)
+	if errRead != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(nil, codeParseError, "failed to read request body"))
+		return
+	}
+	raw = bytes.TrimSpace(raw)
+
+	if bytes.HasPrefix(raw, []byte("[")) {
+		var batch []json.RawMessage
+		if errUnmarshal := json.Unmarshal(raw, &batch); errUnmarshal != nil || len(batch) == 0 {
+			c.JSON(http.StatusBadRequest, newErrorResponse(nil, codeParseError, "invalid JSON-RPC batch"))
+			return
+		}
+		responses := make([]*rpcResponse, 0, len(batch))
+		for _, message := range batch {
+			if resp := h.dispatch(c, message); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			c.Status(http.StatusAccepted)
+			return
+		}
+		c.JSON(http.StatusOK, responses)
+		return
+	}
+
+	resp := h.dispatch(c, raw)
+	if resp == nil {
+		c.Status(http.StatusAccepted)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// MethodNotAllowed answers GET and DELETE on /mcp: the server neither opens server-initiated
+// SSE streams nor keeps sessions.
+func (h *MCPAPIHandler) MethodNotAllowed(c *gin.Context) {
+	c.Header("Allow", http.MethodPost)
+	c.Status(http.StatusMethodNotAllowed)
+}
+
+// dispatch executes one JSON-RPC message and returns its response, or nil for notifications.
+func (h *MCPAPIHandler) dispatch(c *gin.Context, raw json.RawMessage) *rpcResponse {
+	var req rpcRequest
+	if errUnmarshal := json.Unmarshal(raw, &req); errUnmarshal != nil {
+		return newErrorResponse(nil, codeParseError, "invalid JSON-RPC message")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return newErrorResponse(req.ID, codeInvalidRequest, "invalid JSON-RPC request")
+	}
+	result, errRPC := h.call(c, req.Method, req.Params)
+	if len(req.ID) == 0 {
+		return nil
+	}
+	if errRPC != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Error: errRPC}
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func (h *MCPAPIHandler) call(c *gin.Context, method string, params json.RawMessage) (any, *rpcError) {
+	switch method {
+	case "initialize":
+		return h.initialize(params)
+	case "ping":
+		return struct{}{}, nil
+	case "resources/list":
+		return gin.H{"resources": resourceList()}, nil
+	case "resources/read":
+		return h.readResource(c, params)
+	case "tools/list":
+		return gin.H{"tools": []any{chatTool()}}, nil
+	case "tools/call":
+		return h.callTool(c, params)
+	case "sampling/createMessage":
+		return h.createMessage(c, params)
+	default:
+		if strings.HasPrefix(method, "notifications/") {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+func (h *MCPAPIHandler) initialize(params json.RawMessage) (any, *rpcError) {
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if errUnmarshal := json.Unmarshal(params, &init); errUnmarshal != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid initialize params"}
+		}
+	}
+	version := init.ProtocolVersion
+	if !slices.Contains(supportedProtocolVersions, version) {
+		version = latestProtocolVersion
+	}
+	return gin.H{
+		"protocolVersion": version,
+		"capabilities": gin.H{
+			"resources": gin.H{},
+			"tools":     gin.H{},
+		},
+		"serverInfo": gin.H{
+			"name":    serverName,
+			"version": buildinfo.Version,
+		},
+	}, nil
+}
+
+func newErrorResponse(id json.RawMessage, code int, message string) *rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
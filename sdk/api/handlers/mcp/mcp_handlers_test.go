@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func serveMCP(t *testing.T, cfg *sdkconfig.SDKConfig, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/mcp", NewMCPAPIHandler(handlers.NewBaseAPIHandlers(cfg, nil)).Handle)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleRequiresMCPServer(t *testing.T) {
+	rec := serveMCP(t, &sdkconfig.SDKConfig{}, `{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandleLifecycleAndDiscovery(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{MCPServer: true}
+
+	rec := serveMCP(t, cfg, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"ide"}}}`)
+	if got := gjson.Get(rec.Body.String(), "result.protocolVersion").String(); got != "2025-03-26" {
+		t.Fatalf("protocolVersion = %q, body=%s", got, rec.Body.String())
+	}
+	if !gjson.Get(rec.Body.String(), "result.capabilities.resources").Exists() {
+		t.Fatalf("expected resources capability, body=%s", rec.Body.String())
+	}
+
+	if rec = serveMCP(t, cfg, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("notification status = %d, want 202", rec.Code)
+	}
+
+	rec = serveMCP(t, cfg, `[{"jsonrpc":"2.0","id":2,"method":"resources/list"},{"jsonrpc":"2.0","id":3,"method":"tools/list"},{"jsonrpc":"2.0","id":4,"method":"bogus"}]`)
+	body := gjson.Parse(rec.Body.String())
+	if got := body.Get("0.result.resources.#.uri").Raw; got != `["cliproxy://models","cliproxy://usage"]` {
+		t.Fatalf("resources = %s", got)
+	}
+	if got := body.Get("1.result.tools.0.name").String(); got != chatToolName {
+		t.Fatalf("tools = %s", body.Get("1").Raw)
+	}
+	if got := body.Get("2.error.code").Int(); got != codeMethodNotFound {
+		t.Fatalf("unknown method error = %s", body.Get("2").Raw)
+	}
+
+	rec = serveMCP(t, cfg, `{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"cliproxy://usage"}}`)
+	if text := gjson.Get(rec.Body.String(), "result.contents.0.text").String(); !gjson.Get(text, "statistics-enabled").Exists() {
+		t.Fatalf("usage resource = %s", rec.Body.String())
+	}
+}
+
+func TestUsageResourceOnlyExposesCallerSpend(t *testing.T) {
+	previous := usage.StatisticsEnabled()
+	usage.SetStatisticsEnabled(true)
+	t.Cleanup(func() { usage.SetStatisticsEnabled(previous) })
+	stats := usage.GetRequestStatistics()
+	stats.Record(coreusage.Record{APIKey: "caller-key", Model: "m", Detail: coreusage.Detail{InputTokens: 10, Cost: 0.25}})
+	stats.Record(coreusage.Record{APIKey: "other-tenant-key", Model: "m", Detail: coreusage.Detail{InputTokens: 10, Cost: 4}})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { c.Set("apiKey", "caller-key") })
+	engine.POST("/mcp", NewMCPAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{MCPServer: true}, nil)).Handle)
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"cliproxy://usage"}}`))
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	text := gjson.Get(rec.Body.String(), "result.contents.0.text").String()
+	if strings.Contains(text, "other-tenant-key") || strings.Contains(text, "caller-key") {
+		t.Fatalf("usage resource leaked an API key: %s", text)
+	}
+	if got := gjson.Get(text, "spend.total").Float(); got != 0.25 {
+		t.Fatalf("spend.total = %v, want 0.25; text=%s", got, text)
+	}
+	if gjson.Get(text, "totals").Exists() {
+		t.Fatalf("usage resource should not expose proxy-wide totals: %s", text)
+	}
+}
+
+func TestSamplingToOpenAI(t *testing.T) {
+	temperature := 0.2
+	body, errRPC := samplingToOpenAI("claude-sonnet-4-5", samplingRequest{
+		Messages: []samplingMessage{
+			{Role: "user", Content: []byte(`{"type":"text","text":"describe"}`)},
+			{Role: "user", Content: []byte(`[{"type":"image","data":"AAAA","mimeType":"image/png"}]`)},
+		},
+		SystemPrompt:  "be brief",
+		MaxTokens:     64,
+		Temperature:   &temperature,
+		StopSequences: []string{"END"},
+	})
+	if errRPC != nil {
+		t.Fatalf("samplingToOpenAI() error = %+v", errRPC)
+	}
+	root := gjson.ParseBytes(body)
+	if root.Get("messages.0.role").String() != "system" || root.Get("messages.1.content.0.text").String() != "describe" {
+		t.Fatalf("unexpected messages %s", root.Get("messages").Raw)
+	}
+	if got := root.Get("messages.2.content.0.image_url.url").String(); got != "data:image/png;base64,AAAA" {
+		t.Fatalf("image url = %q", got)
+	}
+	if root.Get("max_tokens").Int() != 64 || root.Get("temperature").Float() != 0.2 || root.Get("stop.0").String() != "END" {
+		t.Fatalf("unexpected sampling params %s", body)
+	}
+
+	if _, errRPC = samplingToOpenAI("m", samplingRequest{Messages: []samplingMessage{{Role: "user", Content: []byte(`{"type":"audio"}`)}}}); errRPC == nil || errRPC.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params for audio content, got %+v", errRPC)
+	}
+}
@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+const (
+	modelsResourceURI = "cliproxy://models"
+	usageResourceURI  = "cliproxy://usage"
+	resourceMimeType  = "application/json"
+)
+
+func resourceList() []gin.H {
+	return []gin.H{
+		{
+			"uri":         modelsResourceURI,
+			"name":        "models",
+			"description": "Models available to this API key through the proxy.",
+			"mimeType":    resourceMimeType,
+		},
+		{
+			"uri":         usageResourceURI,
+			"name":        "usage",
+			"description": "Spend and quota status of this API key.",
+			"mimeType":    resourceMimeType,
+		},
+	}
+}
+
+func (h *MCPAPIHandler) readResource(c *gin.Context, params json.RawMessage) (any, *rpcError) {
+	var read struct {
+		URI string `json:"uri"`
+	}
+	if errUnmarshal := json.Unmarshal(params, &read); errUnmarshal != nil || read.URI == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "resources/read requires a uri"}
+	}
+	var content any
+	switch read.URI {
+	case modelsResourceURI:
+		content = gin.H{"models": h.modelSummaries(c)}
+	case usageResourceURI:
+		content = usageSummary(c)
+	default:
+		return nil, &rpcError{Code: codeResourceNotFound, Message: "resource not found", Data: gin.H{"uri": read.URI}}
+	}
+	text, errMarshal := json.Marshal(content)
+	if errMarshal != nil {
+		return nil, &rpcError{Code: codeInternalError, Message: errMarshal.Error()}
+	}
+	return gin.H{"contents": []gin.H{{"uri": read.URI, "mimeType": resourceMimeType, "text": string(text)}}}, nil
+}
+
+// modelSummaries lists the models the client API key may use.
+func (h *MCPAPIHandler) modelSummaries(c *gin.Context) []gin.H {
+	models := h.ListModelsForKey(c, h.Models())
+	summaries := make([]gin.H, 0, len(models))
+	for _, model := range models {
+		summary := gin.H{"id": model["id"]}
+		if ownedBy, exists := model["owned_by"]; exists {
+			summary["owned_by"] = ownedBy
+		}
+		if created, exists := model["created"]; exists {
+			summary["created"] = created
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// usageSummary reports the spend of the calling API key when usage statistics are enabled and
+// its quota status when quotas are configured. Proxy-wide totals and other keys' spend are
+// only available through the management API.
+func usageSummary(c *gin.Context) gin.H {
+	stats := usage.GetRequestStatistics()
+	apiKey := callerAPIKey(c)
+	summary := gin.H{"statistics-enabled": usage.StatisticsEnabled()}
+	if usage.StatisticsEnabled() && apiKey != "" {
+		report := stats.SpendReport("", "", apiKey)
+		for i := range report.Entries {
+			report.Entries[i].APIKey = ""
+		}
+		summary["spend"] = gin.H{"total": report.Total, "entries": report.Entries}
+	}
+	if stats.QuotaEnabled() {
+		status, _ := stats.CheckQuota(apiKey)
+		status.APIKey = ""
+		summary["quota"] = status
+	}
+	return summary
+}
+
+func callerAPIKey(c *gin.Context) string {
+	value, _ := c.Get("apiKey")
+	apiKey, _ := value.(string)
+	return strings.TrimSpace(apiKey)
+}
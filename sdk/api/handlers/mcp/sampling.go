@@ -0,0 +1,233 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const chatToolName = "chat"
+
+// samplingMessage is a message of an MCP sampling request. Content is a single content block
+// or, in newer protocol revisions, a list of blocks.
+type samplingMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+type samplingRequest struct {
+	Messages         []samplingMessage `json:"messages"`
+	ModelPreferences struct {
+		Hints []struct {
+			Name string `json:"name"`
+		} `json:"hints"`
+	} `json:"modelPreferences"`
+	SystemPrompt  string   `json:"systemPrompt"`
+	MaxTokens     int      `json:"maxTokens"`
+	Temperature   *float64 `json:"temperature"`
+	StopSequences []string `json:"stopSequences"`
+}
+
+// samplingResult is the outcome of a sampling request executed through the proxy.
+type samplingResult struct {
+	Model      string
+	Text       string
+	StopReason string
+}
+
+func chatTool() gin.H {
+	return gin.H{
+		"name":        chatToolName,
+		"description": "Send a prompt to a model served by the proxy and return its reply.",
+		"inputSchema": gin.H{
+			"type": "object",
+			"properties": gin.H{
+				"model":      gin.H{"type": "string", "description": "Model ID, see the cliproxy://models resource."},
+				"prompt":     gin.H{"type": "string", "description": "User message."},
+				"system":     gin.H{"type": "string", "description": "Optional system prompt."},
+				"max_tokens": gin.H{"type": "integer", "description": "Optional output token limit."},
+			},
+			"required": []string{"model", "prompt"},
+		},
+	}
+}
+
+func (h *MCPAPIHandler) callTool(c *gin.Context, params json.RawMessage) (any, *rpcError) {
+	var call struct {
+		Name      string `json:"name"`
+		Arguments struct {
+			Model     string `json:"model"`
+			Prompt    string `json:"prompt"`
+			System    string `json:"system"`
+			MaxTokens int    `json:"max_tokens"`
+		} `json:"arguments"`
+	}
+	if errUnmarshal := json.Unmarshal(params, &call); errUnmarshal != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params"}
+	}
+	if call.Name != chatToolName {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + call.Name}
+	}
+	args := call.Arguments
+	if strings.TrimSpace(args.Model) == "" || args.Prompt == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "chat requires model and prompt"}
+	}
+
+	body := []byte(`{"model":"","messages":[]}`)
+	body, _ = sjson.SetBytes(body, "model", strings.TrimSpace(args.Model))
+	if args.System != "" {
+		body, _ = sjson.SetBytes(body, "messages.-1", gin.H{"role": "system", "content": args.System})
+	}
+	body, _ = sjson.SetBytes(body, "messages.-1", gin.H{"role": "user", "content": args.Prompt})
+	if args.MaxTokens > 0 {
+		body, _ = sjson.SetBytes(body, "max_tokens", args.MaxTokens)
+	}
+	result, errText := h.execute(c, body)
+	if errText != "" {
+		return gin.H{"content": []gin.H{{"type": "text", "text": errText}}, "isError": true}, nil
+	}
+	return gin.H{"content": []gin.H{{"type": "text", "text": result.Text}}, "isError": false}, nil
+}
+
+func (h *MCPAPIHandler) createMessage(c *gin.Context, params json.RawMessage) (any, *rpcError) {
+	var req samplingRequest
+	if errUnmarshal := json.Unmarshal(params, &req); errUnmarshal != nil || len(req.Messages) == 0 {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "sampling/createMessage requires messages"}
+	}
+	hints := make([]string, 0, len(req.ModelPreferences.Hints))
+	for _, hint := range req.ModelPreferences.Hints {
+		if name := strings.TrimSpace(hint.Name); name != "" {
+			hints = append(hints, name)
+		}
+	}
+	model := h.resolveModel(c, hints)
+	if model == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "modelPreferences.hints must name a model"}
+	}
+	body, errBody := samplingToOpenAI(model, req)
+	if errBody != nil {
+		return nil, errBody
+	}
+	result, errText := h.execute(c, body)
+	if errText != "" {
+		return nil, &rpcError{Code: codeInternalError, Message: errText}
+	}
+	return gin.H{
+		"role":       "assistant",
+		"content":    gin.H{"type": "text", "text": result.Text},
+		"model":      result.Model,
+		"stopReason": result.StopReason,
+	}, nil
+}
+
+// resolveModel picks the first hint naming an available model exactly, then the first hint
+// contained in an available model ID, as MCP hints are substrings. An unmatched first hint is
+// used verbatim so aliases and prefixes resolved by the pipeline keep working.
+func (h *MCPAPIHandler) resolveModel(c *gin.Context, hints []string) string {
+	if len(hints) == 0 {
+		return ""
+	}
+	summaries := h.modelSummaries(c)
+	ids := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		if id, _ := summary["id"].(string); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	for _, hint := range hints {
+		for _, id := range ids {
+			if strings.EqualFold(id, hint) {
+				return id
+			}
+		}
+	}
+	for _, hint := range hints {
+		for _, id := range ids {
+			if strings.Contains(strings.ToLower(id), strings.ToLower(hint)) {
+				return id
+			}
+		}
+	}
+	return hints[0]
+}
+
+// samplingToOpenAI converts a sampling request into an OpenAI chat completions body.
+func samplingToOpenAI(model string, req samplingRequest) ([]byte, *rpcError) {
+	body := []byte(`{"model":"","messages":[]}`)
+	body, _ = sjson.SetBytes(body, "model", model)
+	if req.SystemPrompt != "" {
+		body, _ = sjson.SetBytes(body, "messages.-1", gin.H{"role": "system", "content": req.SystemPrompt})
+	}
+	for _, message := range req.Messages {
+		if message.Role != "user" && message.Role != "assistant" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "unsupported message role: " + message.Role}
+		}
+		blocks := gjson.ParseBytes(message.Content)
+		if !blocks.IsArray() {
+			blocks = gjson.Parse("[" + blocks.Raw + "]")
+		}
+		parts := []byte(`[]`)
+		for _, block := range blocks.Array() {
+			switch block.Get("type").String() {
+			case "text":
+				parts, _ = sjson.SetBytes(parts, "-1", gin.H{"type": "text", "text": block.Get("text").String()})
+			case "image":
+				url := "data:" + block.Get("mimeType").String() + ";base64," + block.Get("data").String()
+				parts, _ = sjson.SetBytes(parts, "-1", gin.H{"type": "image_url", "image_url": gin.H{"url": url}})
+			default:
+				return nil, &rpcError{Code: codeInvalidParams, Message: "unsupported content type: " + block.Get("type").String()}
+			}
+		}
+		entry := []byte(`{"role":"","content":[]}`)
+		entry, _ = sjson.SetBytes(entry, "role", message.Role)
+		entry, _ = sjson.SetRawBytes(entry, "content", parts)
+		body, _ = sjson.SetRawBytes(body, "messages.-1", entry)
+	}
+	if req.MaxTokens > 0 {
+		body, _ = sjson.SetBytes(body, "max_tokens", req.MaxTokens)
+	}
+	if req.Temperature != nil {
+		body, _ = sjson.SetBytes(body, "temperature", *req.Temperature)
+	}
+	if len(req.StopSequences) > 0 {
+		body, _ = sjson.SetBytes(body, "stop", req.StopSequences)
+	}
+	return body, nil
+}
+
+// execute runs an OpenAI chat completions body through the proxy. It returns the reply, or
+// the error text when the request failed.
+func (h *MCPAPIHandler) execute(c *gin.Context, body []byte) (samplingResult, string) {
+	model := gjson.GetBytes(body, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, _, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), model, body, "")
+	if errMsg != nil {
+		cliCancel(errMsg.Error)
+		if errMsg.Error != nil {
+			return samplingResult{}, errMsg.Error.Error()
+		}
+		return samplingResult{}, "request failed"
+	}
+	cliCancel()
+
+	root := gjson.ParseBytes(resp)
+	result := samplingResult{
+		Model:      root.Get("model").String(),
+		Text:       root.Get("choices.0.message.content").String(),
+		StopReason: "endTurn",
+	}
+	if result.Model == "" {
+		result.Model = model
+	}
+	switch root.Get("choices.0.finish_reason").String() {
+	case "length":
+		result.StopReason = "maxTokens"
+	case "tool_calls":
+		result.StopReason = "toolUse"
+	}
+	return result, ""
+}
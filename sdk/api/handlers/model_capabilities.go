@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+// ModelCapabilities describes what a model accepts so clients can shape requests without
+// hardcoding model knowledge. Vision and Tools are omitted when the registry does not know.
+type ModelCapabilities struct {
+	ID                  string                    `json:"id"`
+	Object              string                    `json:"object"`
+	OwnedBy             string                    `json:"owned_by,omitempty"`
+	Type                string                    `json:"type,omitempty"`
+	DisplayName         string                    `json:"display_name,omitempty"`
+	Providers           []string                  `json:"providers,omitempty"`
+	ContextLength       int                       `json:"context_length,omitempty"`
+	InputTokenLimit     int                       `json:"input_token_limit,omitempty"`
+	OutputTokenLimit    int                       `json:"output_token_limit,omitempty"`
+	MaxCompletionTokens int                       `json:"max_completion_tokens,omitempty"`
+	Thinking            *registry.ThinkingSupport `json:"thinking,omitempty"`
+	Vision              *bool                     `json:"vision,omitempty"`
+	Tools               *bool                     `json:"tools,omitempty"`
+	InputModalities     []string                  `json:"input_modalities,omitempty"`
+	OutputModalities    []string                  `json:"output_modalities,omitempty"`
+	SupportedParameters []string                  `json:"supported_parameters,omitempty"`
+}
+
+// ModelCapabilitiesHandler handles GET /v1/models/{id}/capabilities. Model IDs may contain
+// slashes, so the route is registered as a wildcard and the suffix is matched here. Models the
+// request's proxy key may not use are reported as missing.
+func (h *BaseAPIHandler) ModelCapabilitiesHandler(c *gin.Context) {
+	path := strings.TrimPrefix(c.Param("action"), "/")
+	modelID, ok := strings.CutSuffix(path, "/capabilities")
+	if !ok || modelID == "" {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: ErrorDetail{
+			Message: fmt.Sprintf("Unknown endpoint: %s", c.Request.URL.Path),
+			Type:    "invalid_request_error",
+		}})
+		return
+	}
+
+	capabilities := h.LookupModelCapabilities(c, modelID)
+	if capabilities == nil {
+		h.WriteErrorResponse(c, &interfaces.ErrorMessage{
+			StatusCode: http.StatusNotFound,
+			Error:      fmt.Errorf("The model `%s` does not exist or you do not have access to it.", modelID),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, capabilities)
+}
+
+// LookupModelCapabilities returns the capabilities of modelID as the request's client sees it,
+// resolving proxy-key aliases and thinking suffixes. It returns nil when the model is unknown
+// or not accessible to the client.
+func (h *BaseAPIHandler) LookupModelCapabilities(c *gin.Context, modelID string) *ModelCapabilities {
+	modelID = strings.TrimSpace(modelID)
+	if modelID == "" {
+		return nil
+	}
+	target := modelID
+	if key := h.proxyKeyForRequest(c); key != nil {
+		if resolved, ok := key.ResolveAlias(modelID); ok {
+			target = resolved
+		}
+		if !key.AllowsModel(target) {
+			return nil
+		}
+	}
+	baseModel := thinking.ParseSuffix(target).ModelName
+
+	providers := registry.GetGlobalRegistry().GetModelProviders(baseModel)
+	if len(providers) == 0 {
+		return nil
+	}
+	info := registry.LookupModelInfo(baseModel, providers[0])
+	if info == nil {
+		return nil
+	}
+	return buildModelCapabilities(modelID, info, providers)
+}
+
+func buildModelCapabilities(id string, info *registry.ModelInfo, providers []string) *ModelCapabilities {
+	capabilities := &ModelCapabilities{
+		ID:                  id,
+		Object:              "model.capabilities",
+		OwnedBy:             info.OwnedBy,
+		Type:                info.Type,
+		DisplayName:         info.DisplayName,
+		Providers:           providers,
+		ContextLength:       info.ContextLength,
+		InputTokenLimit:     info.InputTokenLimit,
+		OutputTokenLimit:    info.OutputTokenLimit,
+		MaxCompletionTokens: info.MaxCompletionTokens,
+		Thinking:            info.Thinking,
+		InputModalities:     info.SupportedInputModalities,
+		OutputModalities:    info.SupportedOutputModalities,
+		SupportedParameters: info.SupportedParameters,
+	}
+	if len(info.SupportedInputModalities) > 0 {
+		vision := containsFold(info.SupportedInputModalities, "image")
+		capabilities.Vision = &vision
+	}
+	if len(info.SupportedParameters) > 0 {
+		tools := containsFold(info.SupportedParameters, "tools")
+		capabilities.Tools = &tools
+	}
+	return capabilities
+}
+
+func containsFold(values []string, want string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), want) {
+			return true
+		}
+	}
+	return false
+}
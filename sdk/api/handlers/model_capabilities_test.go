@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+func TestModelCapabilitiesHandler(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("capabilities-test", "openrouter", []*registry.ModelInfo{{
+		ID:                       "vendor/reasoner",
+		OwnedBy:                  "vendor",
+		MaxCompletionTokens:      65536,
+		SupportedParameters:      []string{"reasoning", "tools"},
+		SupportedInputModalities: []string{"TEXT"},
+		Thinking:                 &registry.ThinkingSupport{Min: 1024, Max: 32000, ZeroAllowed: true},
+	}, {ID: "vendor/other"}})
+	defer reg.UnregisterClient("capabilities-test")
+
+	handler := newProxyKeyTestHandler()
+	handler.Cfg.ProxyKeys[0].ModelAliases["reasoner"] = "vendor/reasoner(high)"
+
+	tests := []struct {
+		apiKey string
+		path   string
+		status int
+		id     string
+	}{
+		{apiKey: "open", path: "/vendor/reasoner/capabilities", status: http.StatusOK, id: "vendor/reasoner"},
+		{apiKey: "restricted", path: "/reasoner/capabilities", status: http.StatusOK, id: "reasoner"},
+		{apiKey: "restricted", path: "/vendor/other/capabilities", status: http.StatusNotFound},
+		{apiKey: "open", path: "/unknown-model/capabilities", status: http.StatusNotFound},
+		{apiKey: "open", path: "/vendor/reasoner", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v1/models"+tt.path, nil)
+		c.Params = gin.Params{{Key: "action", Value: tt.path}}
+		c.Set("apiKey", tt.apiKey)
+
+		handler.ModelCapabilitiesHandler(c)
+		if recorder.Code != tt.status {
+			t.Fatalf("%s %s: status = %d, want %d, body=%s", tt.apiKey, tt.path, recorder.Code, tt.status, recorder.Body.String())
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var got ModelCapabilities
+		if errDecode := json.Unmarshal(recorder.Body.Bytes(), &got); errDecode != nil {
+			t.Fatalf("decode response: %v", errDecode)
+		}
+		if got.ID != tt.id || got.MaxCompletionTokens != 65536 || got.Thinking == nil || got.Thinking.Max != 32000 {
+			t.Fatalf("unexpected capabilities %+v", got)
+		}
+		if got.Tools == nil || !*got.Tools || got.Vision == nil || *got.Vision {
+			t.Fatalf("tools/vision = %v/%v, want true/false", got.Tools, got.Vision)
+		}
+	}
+}
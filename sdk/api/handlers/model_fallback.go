@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// fallbackModelHeader reports the model that served a request after its configured fallback chain was used.
+const fallbackModelHeader = "X-Fallback-Model"
+
+// modelFallbacks returns the configured fallback chain for modelName. An exact (case-insensitive)
+// match wins over a match on the model name without its thinking suffix.
+func (h *BaseAPIHandler) modelFallbacks(modelName string) []string {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ModelFallbacks) == 0 {
+		return nil
+	}
+	modelName = strings.TrimSpace(modelName)
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	var baseChain []string
+	for model, chain := range h.Cfg.ModelFallbacks {
+		if strings.EqualFold(model, modelName) {
+			return chain
+		}
+		if baseChain == nil && strings.EqualFold(model, baseModel) {
+			baseChain = chain
+		}
+	}
+	return baseChain
+}
+
+// fallbackEligible reports whether err is a rate-limit or overloaded failure that should move
+// the request on to the next model of its fallback chain.
+func fallbackEligible(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch statusFromError(err) {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, 529:
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "overloaded")
+}
+
+// payloadForFallback rewrites the top-level model field of the client payload, when present,
+// so translators and upstreams see the fallback model.
+func payloadForFallback(rawJSON []byte, model string) []byte {
+	if len(rawJSON) == 0 || !gjson.GetBytes(rawJSON, "model").Exists() {
+		return rawJSON
+	}
+	updated, err := sjson.SetBytes(rawJSON, "model", model)
+	if err != nil {
+		return rawJSON
+	}
+	return updated
+}
+
+// annotateFallbackModel sets the X-Fallback-Model response header on the client response.
+func annotateFallbackModel(ctx context.Context, model string) {
+	setResponseHeader(ctx, fallbackModelHeader, model)
+}
+
+// responseHeaderMu serializes header writes from the parallel requests of an n > 1 fan-out.
+var responseHeaderMu sync.Mutex
+
+// setResponseHeader sets a header on the client response of the gin request carried by ctx.
+func setResponseHeader(ctx context.Context, key, value string) {
+	if ctx == nil {
+		return
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		responseHeaderMu.Lock()
+		ginCtx.Header(key, value)
+		responseHeaderMu.Unlock()
+	}
+}
+
+func logModelFallback(from, to string, err error) {
+	log.WithFields(log.Fields{
+		"model":    from,
+		"fallback": to,
+		"status":   statusFromError(err),
+	}).Warnf("upstream failed, falling back to next model: %v", err)
+}
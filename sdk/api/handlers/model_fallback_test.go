@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type overloadedPrimaryExecutor struct {
+	mu     sync.Mutex
+	models []string
+}
+
+func (e *overloadedPrimaryExecutor) Identifier() string { return "fallback-test" }
+
+func (e *overloadedPrimaryExecutor) record(req coreexecutor.Request) error {
+	e.mu.Lock()
+	e.models = append(e.models, req.Model)
+	e.mu.Unlock()
+	if req.Model == "primary-model" {
+		return &coreauth.Error{Code: "rate_limited", Message: "rate limited", HTTPStatus: http.StatusTooManyRequests}
+	}
+	return nil
+}
+
+func (e *overloadedPrimaryExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if err := e.record(req); err != nil {
+		return coreexecutor.Response{}, err
+	}
+	return coreexecutor.Response{Payload: []byte(gjson.GetBytes(req.Payload, "model").String())}, nil
+}
+
+func (e *overloadedPrimaryExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	if err := e.record(req); err != nil {
+		return nil, err
+	}
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	ch <- coreexecutor.StreamChunk{Payload: []byte(req.Model)}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *overloadedPrimaryExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *overloadedPrimaryExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *overloadedPrimaryExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newFallbackTestHandler(t *testing.T) (*BaseAPIHandler, *overloadedPrimaryExecutor) {
+	t.Helper()
+	executor := &overloadedPrimaryExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "fallback-auth", Provider: "fallback-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "primary-model"}, {ID: "backup-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ModelFallbacks: map[string][]string{"Primary-Model": {"missing-model", "backup-model"}},
+	}, manager)
+	return handler, executor
+}
+
+func fallbackTestContext() (context.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ginCtx, _ := gin.CreateTestContext(recorder)
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	return context.WithValue(context.Background(), "gin", ginCtx), recorder
+}
+
+func TestExecuteWithAuthManager_FallsBackOnRateLimit(t *testing.T) {
+	handler, executor := newFallbackTestHandler(t)
+	ctx, recorder := fallbackTestContext()
+
+	resp, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "primary-model", []byte(`{"model":"primary-model"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if string(resp) != "backup-model" {
+		t.Fatalf("payload model = %q, want backup-model", resp)
+	}
+	if got := recorder.Header().Get(fallbackModelHeader); got != "backup-model" {
+		t.Fatalf("%s = %q, want backup-model", fallbackModelHeader, got)
+	}
+	if last := executor.models[len(executor.models)-1]; last != "backup-model" {
+		t.Fatalf("last executed model = %q, want backup-model", last)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_FallsBackOnRateLimit(t *testing.T) {
+	handler, _ := newFallbackTestHandler(t)
+	ctx, recorder := fallbackTestContext()
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "primary-model", []byte(`{"model":"primary-model"}`), "")
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if string(got) != "backup-model" {
+		t.Fatalf("stream payload = %q, want backup-model", got)
+	}
+	if got := recorder.Header().Get(fallbackModelHeader); got != "backup-model" {
+		t.Fatalf("%s = %q, want backup-model", fallbackModelHeader, got)
+	}
+}
+
+func TestFallbackEligible(t *testing.T) {
+	cases := map[int]bool{
+		http.StatusTooManyRequests:     true,
+		http.StatusServiceUnavailable:  true,
+		529:                            true,
+		http.StatusBadRequest:          false,
+		http.StatusInternalServerError: false,
+	}
+	for status, want := range cases {
+		err := &coreauth.Error{Code: "x", Message: "failed", HTTPStatus: status}
+		if got := fallbackEligible(err); got != want {
+			t.Errorf("fallbackEligible(%d) = %v, want %v", status, got, want)
+		}
+	}
+	if !fallbackEligible(&coreauth.Error{Code: "x", Message: "Overloaded"}) {
+		t.Error("expected overloaded message to be eligible")
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+// defaultThinkingVariantLevels are listed for budget-based models, whose levels are converted
+// to budgets when requested.
+var defaultThinkingVariantLevels = []string{"low", "medium", "high"}
+
+// ListModelsForKey completes a registry model listing for the request's client. Models the proxy
+// key may not use are dropped, the key's model aliases are added under the alias name when their
+// target is listed, and thinking-suffix variants are synthesized when list-thinking-variants is
+// enabled. Listings identify models by their "id" field.
+func (h *BaseAPIHandler) ListModelsForKey(c *gin.Context, models []map[string]any) []map[string]any {
+	out := h.FilterModelsForKey(c, models)
+	listed := make(map[string]struct{}, len(out))
+	for _, model := range out {
+		if id, _ := model["id"].(string); id != "" {
+			listed[strings.ToLower(id)] = struct{}{}
+		}
+	}
+
+	if h.Cfg != nil && h.Cfg.ListThinkingVariants {
+		withVariants := make([]map[string]any, 0, len(out))
+		for _, model := range out {
+			withVariants = append(withVariants, model)
+			withVariants = append(withVariants, h.thinkingVariants(c, model, listed)...)
+		}
+		out = withVariants
+	}
+	return append(out, h.aliasModels(c, models, listed)...)
+}
+
+// aliasModels lists the model aliases of the request's proxy key whose target model is available.
+func (h *BaseAPIHandler) aliasModels(c *gin.Context, models []map[string]any, listed map[string]struct{}) []map[string]any {
+	key := h.proxyKeyForRequest(c)
+	if key == nil || len(key.ModelAliases) == 0 {
+		return nil
+	}
+	byID := make(map[string]map[string]any, len(models))
+	for _, model := range models {
+		if id, _ := model["id"].(string); id != "" {
+			byID[strings.ToLower(id)] = model
+		}
+	}
+	aliases := make([]string, 0, len(key.ModelAliases))
+	for alias := range key.ModelAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var out []map[string]any
+	for _, alias := range aliases {
+		if _, exists := listed[strings.ToLower(alias)]; exists {
+			continue
+		}
+		target := thinking.ParseSuffix(key.ModelAliases[alias]).ModelName
+		model, ok := byID[strings.ToLower(target)]
+		if !ok {
+			continue
+		}
+		entry := maps.Clone(model)
+		entry["id"] = alias
+		listed[strings.ToLower(alias)] = struct{}{}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// thinkingVariants returns a "model(level)" entry per thinking level of model that the request's
+// proxy key may use.
+func (h *BaseAPIHandler) thinkingVariants(c *gin.Context, model map[string]any, listed map[string]struct{}) []map[string]any {
+	id, _ := model["id"].(string)
+	if id == "" || thinking.ParseSuffix(id).HasSuffix {
+		return nil
+	}
+	info := registry.LookupModelInfo(id)
+	if info == nil || info.Thinking == nil {
+		return nil
+	}
+	levels := info.Thinking.Levels
+	if len(levels) == 0 {
+		levels = defaultThinkingVariantLevels
+	}
+	key := h.proxyKeyForRequest(c)
+	var out []map[string]any
+	for _, level := range levels {
+		variantID := id + "(" + level + ")"
+		if _, exists := listed[strings.ToLower(variantID)]; exists {
+			continue
+		}
+		if key != nil && !key.AllowsModel(variantID) {
+			continue
+		}
+		entry := maps.Clone(model)
+		entry["id"] = variantID
+		listed[strings.ToLower(variantID)] = struct{}{}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+const (
+	defaultMultipleChoicesMaxN        = 8
+	defaultMultipleChoicesConcurrency = 4
+)
+
+// singleChoiceProviders ignore OpenAI "n" and always return one choice. Gemini-family and
+// OpenAI-compatible providers honor it natively.
+var singleChoiceProviders = map[string]struct{}{
+	"claude": {},
+	"codex":  {},
+	"kimi":   {},
+}
+
+// multipleChoicesFanOut returns how many parallel requests serve an OpenAI chat request with
+// n > 1, or 0 when the request is passed through as-is. n > 1 is rejected with an
+// invalid_request_error when fan-out is disabled, above the configured maximum or combined
+// with streaming.
+func (h *BaseAPIHandler) multipleChoicesFanOut(handlerType, modelName string, rawJSON []byte, stream bool) (int, *interfaces.ErrorMessage) {
+	if handlerType != "openai" {
+		return 0, nil
+	}
+	n := gjson.GetBytes(rawJSON, "n")
+	if n.Type != gjson.Number || n.Int() <= 1 {
+		return 0, nil
+	}
+	providers, _, errDetails := h.getRequestDetails(modelName)
+	if errDetails != nil {
+		// The regular execution path reports the unknown model.
+		return 0, nil
+	}
+	if !slices.ContainsFunc(providers, func(provider string) bool {
+		_, single := singleChoiceProviders[provider]
+		return single
+	}) {
+		return 0, nil
+	}
+	var settings config.MultipleChoicesConfig
+	if h.Cfg != nil {
+		settings = h.Cfg.MultipleChoices
+	}
+	maxN := settings.MaxN
+	if maxN <= 0 {
+		maxN = defaultMultipleChoicesMaxN
+	}
+	var reason string
+	switch {
+	case settings.Disabled:
+		reason = fmt.Sprintf("n > 1 is not supported for model %s", modelName)
+	case stream:
+		reason = fmt.Sprintf("n > 1 is not supported with stream=true for model %s", modelName)
+	case n.Int() > int64(maxN):
+		reason = fmt.Sprintf("n must be at most %d for model %s", maxN, modelName)
+	default:
+		return int(n.Int()), nil
+	}
+	return 0, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New(reason)}
+}
+
+// executeChoices serves n choices from parallel single-choice requests, at most the configured
+// concurrency at a time, and merges them into one chat completion with summed usage. The first
+// failure cancels the remaining requests and is returned.
+func (h *BaseAPIHandler) executeChoices(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, n int) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	concurrency := defaultMultipleChoicesConcurrency
+	if h.Cfg != nil && h.Cfg.MultipleChoices.Concurrency > 0 {
+		concurrency = h.Cfg.MultipleChoices.Concurrency
+	}
+	payload, _ := sjson.DeleteBytes(rawJSON, "n")
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		failOnce  sync.Once
+		firstErr  *interfaces.ErrorMessage
+		headers   http.Header
+		responses = make([][]byte, n)
+		slots     = make(chan struct{}, concurrency)
+	)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-slots }()
+			if ctx.Err() != nil {
+				return
+			}
+			resp, respHeaders, errMsg := h.executeWithFallbacks(ctx, handlerType, modelName, payload, alt)
+			if errMsg != nil {
+				failOnce.Do(func() {
+					firstErr = errMsg
+					cancel()
+				})
+				return
+			}
+			responses[i] = resp
+			if i == 0 {
+				headers = respHeaders
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	if slices.ContainsFunc(responses, func(resp []byte) bool { return resp == nil }) {
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusRequestTimeout, Error: ctx.Err()}
+	}
+	return mergeChoiceResponses(responses), headers, nil
+}
+
+// mergeChoiceResponses combines single-choice chat completions into one response: choices are
+// re-indexed in order and every numeric usage field is summed.
+func mergeChoiceResponses(responses [][]byte) []byte {
+	out := responses[0]
+	choices := []byte(`[]`)
+	usage := []byte(`{}`)
+	hasUsage := false
+	index := 0
+	for _, resp := range responses {
+		gjson.GetBytes(resp, "choices").ForEach(func(_, choice gjson.Result) bool {
+			merged, _ := sjson.SetBytes([]byte(choice.Raw), "index", index)
+			choices, _ = sjson.SetRawBytes(choices, "-1", merged)
+			index++
+			return true
+		})
+		if respUsage := gjson.GetBytes(resp, "usage"); respUsage.IsObject() {
+			usage = addUsageCounts(usage, respUsage, "")
+			hasUsage = true
+		}
+	}
+	out, _ = sjson.SetRawBytes(out, "choices", choices)
+	if hasUsage {
+		out, _ = sjson.SetRawBytes(out, "usage", usage)
+	}
+	return out
+}
+
+// addUsageCounts adds every numeric field of usage, including nested details, into total.
+func addUsageCounts(total []byte, usage gjson.Result, prefix string) []byte {
+	usage.ForEach(func(key, value gjson.Result) bool {
+		path := prefix + key.String()
+		switch {
+		case value.IsObject():
+			total = addUsageCounts(total, value, path+".")
+		case value.Type == gjson.Number:
+			total, _ = sjson.SetBytes(total, path, gjson.GetBytes(total, path).Int()+value.Int())
+		}
+		return true
+	})
+	return total
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// singleChoiceExecutor answers like a provider that ignores "n".
+type singleChoiceExecutor struct {
+	calls    atomic.Int64
+	sawN     atomic.Bool
+	failCall int64
+}
+
+func (e *singleChoiceExecutor) Identifier() string { return "claude" }
+
+func (e *singleChoiceExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	call := e.calls.Add(1)
+	if gjson.GetBytes(req.Payload, "n").Exists() {
+		e.sawN.Store(true)
+	}
+	if call == e.failCall {
+		return coreexecutor.Response{}, &coreauth.Error{Code: "bad_request", Message: "boom", HTTPStatus: http.StatusBadRequest}
+	}
+	payload := fmt.Sprintf(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"answer %d"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15,"completion_tokens_details":{"reasoning_tokens":2}}}`, call)
+	return coreexecutor.Response{Payload: []byte(payload)}, nil
+}
+
+func (e *singleChoiceExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *singleChoiceExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *singleChoiceExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *singleChoiceExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newMultipleChoicesTestHandler(t *testing.T, cfg *sdkconfig.SDKConfig) (*BaseAPIHandler, *singleChoiceExecutor) {
+	t.Helper()
+	executor := &singleChoiceExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "multiple-choices-auth", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "choices-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(cfg, manager), executor
+}
+
+func TestExecuteWithAuthManager_FansOutMultipleChoices(t *testing.T) {
+	handler, executor := newMultipleChoicesTestHandler(t, &sdkconfig.SDKConfig{})
+	ctx, _ := fallbackTestContext()
+
+	resp, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "choices-model", []byte(`{"model":"choices-model","n":3}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %+v", errMsg)
+	}
+	if executor.calls.Load() != 3 || executor.sawN.Load() {
+		t.Fatalf("calls = %d, upstream saw n = %v", executor.calls.Load(), executor.sawN.Load())
+	}
+	choices := gjson.GetBytes(resp, "choices").Array()
+	if len(choices) != 3 {
+		t.Fatalf("choices = %d, want 3: %s", len(choices), resp)
+	}
+	for i, choice := range choices {
+		if choice.Get("index").Int() != int64(i) || !strings.HasPrefix(choice.Get("message.content").String(), "answer ") {
+			t.Fatalf("choice %d = %s", i, choice.Raw)
+		}
+	}
+	usage := gjson.GetBytes(resp, "usage")
+	if usage.Get("prompt_tokens").Int() != 30 || usage.Get("total_tokens").Int() != 45 || usage.Get("completion_tokens_details.reasoning_tokens").Int() != 6 {
+		t.Fatalf("usage = %s", usage.Raw)
+	}
+}
+
+func TestExecuteWithAuthManager_MultipleChoicesFailure(t *testing.T) {
+	handler, executor := newMultipleChoicesTestHandler(t, &sdkconfig.SDKConfig{MultipleChoices: sdkconfig.MultipleChoicesConfig{Concurrency: 1}})
+	executor.failCall = 2
+	ctx, _ := fallbackTestContext()
+
+	_, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "choices-model", []byte(`{"model":"choices-model","n":4}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("error = %+v, want upstream 400", errMsg)
+	}
+	if calls := executor.calls.Load(); calls != 2 {
+		t.Fatalf("calls = %d, want remaining requests cancelled after the failure", calls)
+	}
+}
+
+func TestMultipleChoicesRejections(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    sdkconfig.MultipleChoicesConfig
+		body   string
+		stream bool
+		want   string
+	}{
+		{name: "disabled", cfg: sdkconfig.MultipleChoicesConfig{Disabled: true}, body: `{"n":2}`, want: "not supported"},
+		{name: "stream", body: `{"n":2}`, stream: true, want: "stream=true"},
+		{name: "above max", cfg: sdkconfig.MultipleChoicesConfig{MaxN: 2}, body: `{"n":3}`, want: "at most 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, executor := newMultipleChoicesTestHandler(t, &sdkconfig.SDKConfig{MultipleChoices: tt.cfg})
+			_, errMsg := handler.multipleChoicesFanOut("openai", "choices-model", []byte(tt.body), tt.stream)
+			if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || !strings.Contains(errMsg.Error.Error(), tt.want) {
+				t.Fatalf("error = %+v, want 400 containing %q", errMsg, tt.want)
+			}
+			if executor.calls.Load() != 0 {
+				t.Fatalf("upstream called %d times", executor.calls.Load())
+			}
+		})
+	}
+}
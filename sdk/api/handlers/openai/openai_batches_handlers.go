@@ -0,0 +1,644 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// batchChatCompletionsEndpoint is the only OpenAI batch endpoint translated to Claude.
+	batchChatCompletionsEndpoint = "/v1/chat/completions"
+	batchCompletionWindow        = "24h"
+	maxBatchFileBytes            = 256 << 20
+)
+
+// claudeCustomIDPattern is the custom_id format accepted by the Anthropic Message Batches API.
+var claudeCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// batchFile is an uploaded or generated file held in memory for the batch endpoints.
+type batchFile struct {
+	ID        string
+	Filename  string
+	Purpose   string
+	CreatedAt int64
+	Data      []byte
+}
+
+// batchRequest is one translated line of an OpenAI batch input file.
+type batchRequest struct {
+	CustomID   string
+	Model      string
+	Original   []byte
+	Translated []byte
+}
+
+// openAIBatch tracks an OpenAI batch submitted upstream as an Anthropic message batch.
+type openAIBatch struct {
+	mu               sync.Mutex
+	ID               string
+	UpstreamID       string
+	InputFileID      string
+	CompletionWindow string
+	Metadata         json.RawMessage
+	CreatedAt        int64
+	// requests is keyed by the custom_id sent upstream.
+	requests       map[string]batchRequest
+	upstream       []byte
+	resultsFetched bool
+	outputFileID   string
+	errorFileID    string
+}
+
+// batchStore keeps batch files and batches in memory; they do not survive a restart.
+type batchStore struct {
+	mu      sync.Mutex
+	files   map[string]*batchFile
+	batches map[string]*openAIBatch
+}
+
+var openAIBatches = &batchStore{files: make(map[string]*batchFile), batches: make(map[string]*openAIBatch)}
+
+func (s *batchStore) putFile(file *batchFile) {
+	s.mu.Lock()
+	s.files[file.ID] = file
+	s.mu.Unlock()
+}
+
+func (s *batchStore) file(id string) *batchFile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.files[id]
+}
+
+func (s *batchStore) deleteFile(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[id]; !ok {
+		return false
+	}
+	delete(s.files, id)
+	return true
+}
+
+func (s *batchStore) putBatch(batch *openAIBatch) {
+	s.mu.Lock()
+	s.batches[batch.ID] = batch
+	s.mu.Unlock()
+}
+
+func (s *batchStore) batch(id string) *openAIBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batches[id]
+}
+
+func (s *batchStore) listBatches() []*openAIBatch {
+	s.mu.Lock()
+	out := make([]*openAIBatch, 0, len(s.batches))
+	for _, batch := range s.batches {
+		out = append(out, batch)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt > out[j].CreatedAt
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+// UploadFile handles POST /v1/files. Only the "batch" purpose is accepted.
+func (h *OpenAIAPIHandler) UploadFile(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchFileBytes)
+	purpose := strings.TrimSpace(c.PostForm("purpose"))
+	if purpose != "batch" {
+		writeBatchError(c, http.StatusBadRequest, "purpose must be \"batch\"")
+		return
+	}
+	header, errFile := c.FormFile("file")
+	if errFile != nil {
+		writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("Invalid file: %v", errFile))
+		return
+	}
+	reader, errOpen := header.Open()
+	if errOpen != nil {
+		writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("Invalid file: %v", errOpen))
+		return
+	}
+	defer func() { _ = reader.Close() }()
+	data, errRead := io.ReadAll(reader)
+	if errRead != nil {
+		writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("Invalid file: %v", errRead))
+		return
+	}
+	file := &batchFile{ID: newBatchObjectID("file-"), Filename: header.Filename, Purpose: purpose, CreatedAt: time.Now().Unix(), Data: data}
+	openAIBatches.putFile(file)
+	c.JSON(http.StatusOK, renderBatchFile(file))
+}
+
+// GetFile handles GET /v1/files/:id.
+func (h *OpenAIAPIHandler) GetFile(c *gin.Context) {
+	file := openAIBatches.file(c.Param("id"))
+	if file == nil {
+		writeBatchError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", c.Param("id")))
+		return
+	}
+	c.JSON(http.StatusOK, renderBatchFile(file))
+}
+
+// GetFileContent handles GET /v1/files/:id/content.
+func (h *OpenAIAPIHandler) GetFileContent(c *gin.Context) {
+	file := openAIBatches.file(c.Param("id"))
+	if file == nil {
+		writeBatchError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", c.Param("id")))
+		return
+	}
+	c.Data(http.StatusOK, "application/jsonl", file.Data)
+}
+
+// DeleteFile handles DELETE /v1/files/:id.
+func (h *OpenAIAPIHandler) DeleteFile(c *gin.Context) {
+	id := c.Param("id")
+	if !openAIBatches.deleteFile(id) {
+		writeBatchError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", id))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// CreateBatch handles POST /v1/batches. Each chat completion in the input file is translated to
+// Claude Messages and the whole file is submitted as one Anthropic message batch.
+func (h *OpenAIAPIHandler) CreateBatch(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if endpoint := gjson.GetBytes(rawJSON, "endpoint").String(); endpoint != batchChatCompletionsEndpoint {
+		writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("unsupported endpoint %q; only %s is supported", endpoint, batchChatCompletionsEndpoint))
+		return
+	}
+	inputFileID := gjson.GetBytes(rawJSON, "input_file_id").String()
+	input := openAIBatches.file(inputFileID)
+	if input == nil {
+		writeBatchError(c, http.StatusNotFound, fmt.Sprintf("No such File object: %s", inputFileID))
+		return
+	}
+	upstreamBody, requests, modelName, errBuild := buildClaudeBatch(input.Data)
+	if errBuild != nil {
+		writeBatchError(c, http.StatusBadRequest, errBuild.Error())
+		return
+	}
+
+	_, _, resp, errMsg := h.ClaudeBatchRequest(c.Request.Context(), http.MethodPost, "", "", upstreamBody, modelName)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	completionWindow := gjson.GetBytes(rawJSON, "completion_window").String()
+	if completionWindow == "" {
+		completionWindow = batchCompletionWindow
+	}
+	batch := &openAIBatch{
+		ID:               newBatchObjectID("batch_"),
+		UpstreamID:       gjson.GetBytes(resp, "id").String(),
+		InputFileID:      inputFileID,
+		CompletionWindow: completionWindow,
+		CreatedAt:        time.Now().Unix(),
+		requests:         requests,
+		upstream:         resp,
+	}
+	if metadata := gjson.GetBytes(rawJSON, "metadata"); metadata.IsObject() {
+		batch.Metadata = json.RawMessage(metadata.Raw)
+	}
+	openAIBatches.putBatch(batch)
+	c.Data(http.StatusOK, "application/json", renderOpenAIBatch(batch))
+}
+
+// GetBatch handles GET /v1/batches/:id. It refreshes the upstream status and, once the batch
+// has ended, converts the Claude results into OpenAI output and error files.
+func (h *OpenAIAPIHandler) GetBatch(c *gin.Context) {
+	batch := openAIBatches.batch(c.Param("id"))
+	if batch == nil {
+		writeBatchError(c, http.StatusNotFound, fmt.Sprintf("No such Batch object: %s", c.Param("id")))
+		return
+	}
+	out, errMsg := h.refreshBatch(c.Request.Context(), batch, http.MethodGet, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", out)
+}
+
+// CancelBatch handles POST /v1/batches/:id/cancel.
+func (h *OpenAIAPIHandler) CancelBatch(c *gin.Context) {
+	batch := openAIBatches.batch(c.Param("id"))
+	if batch == nil {
+		writeBatchError(c, http.StatusNotFound, fmt.Sprintf("No such Batch object: %s", c.Param("id")))
+		return
+	}
+	out, errMsg := h.refreshBatch(c.Request.Context(), batch, http.MethodPost, "/cancel")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", out)
+}
+
+// ListBatches handles GET /v1/batches with the "limit" and "after" cursor parameters. Listed
+// batches report their last known status without contacting upstream.
+func (h *OpenAIAPIHandler) ListBatches(c *gin.Context) {
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		if parsed, errParse := strconv.Atoi(raw); errParse == nil && parsed > 0 {
+			limit = min(parsed, 100)
+		}
+	}
+	batches := openAIBatches.listBatches()
+	if after := c.Query("after"); after != "" {
+		for i, batch := range batches {
+			if batch.ID == after {
+				batches = batches[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(batches) > limit
+	if hasMore {
+		batches = batches[:limit]
+	}
+	out := []byte(`{"object":"list","data":[],"first_id":null,"last_id":null,"has_more":false}`)
+	for _, batch := range batches {
+		batch.mu.Lock()
+		out, _ = sjson.SetRawBytes(out, "data.-1", renderOpenAIBatch(batch))
+		batch.mu.Unlock()
+	}
+	if len(batches) > 0 {
+		out, _ = sjson.SetBytes(out, "first_id", batches[0].ID)
+		out, _ = sjson.SetBytes(out, "last_id", batches[len(batches)-1].ID)
+	}
+	out, _ = sjson.SetBytes(out, "has_more", hasMore)
+	c.Data(http.StatusOK, "application/json", out)
+}
+
+// refreshBatch applies an upstream call to batch, fetches the results once the batch has ended,
+// and returns the rendered OpenAI batch object.
+func (h *OpenAIAPIHandler) refreshBatch(ctx context.Context, batch *openAIBatch, method, suffix string) ([]byte, *interfaces.ErrorMessage) {
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+	_, _, resp, errMsg := h.ClaudeBatchRequest(ctx, method, batch.UpstreamID, suffix, nil, "")
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	batch.upstream = resp
+	if batch.resultsFetched || gjson.GetBytes(resp, "processing_status").String() != "ended" {
+		return renderOpenAIBatch(batch), nil
+	}
+	_, _, results, errMsg := h.ClaudeBatchRequest(ctx, http.MethodGet, batch.UpstreamID, "/results", nil, "")
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	output, errorsOut := translateClaudeBatchResults(ctx, results, batch.requests)
+	now := time.Now().Unix()
+	if len(output) > 0 {
+		file := &batchFile{ID: newBatchObjectID("file-"), Filename: batch.ID + "_output.jsonl", Purpose: "batch_output", CreatedAt: now, Data: output}
+		openAIBatches.putFile(file)
+		batch.outputFileID = file.ID
+	}
+	if len(errorsOut) > 0 {
+		file := &batchFile{ID: newBatchObjectID("file-"), Filename: batch.ID + "_error.jsonl", Purpose: "batch_output", CreatedAt: now, Data: errorsOut}
+		openAIBatches.putFile(file)
+		batch.errorFileID = file.ID
+	}
+	batch.resultsFetched = true
+	return renderOpenAIBatch(batch), nil
+}
+
+// buildClaudeBatch translates an OpenAI batch input file into an Anthropic message batch body.
+// It returns the upstream body, the translated requests keyed by upstream custom_id, and the
+// model of the first request, which selects the credential.
+func buildClaudeBatch(input []byte) ([]byte, map[string]batchRequest, string, error) {
+	body := []byte(`{"requests":[]}`)
+	requests := make(map[string]batchRequest)
+	seen := make(map[string]struct{})
+	firstModel := ""
+	scanner := bufio.NewScanner(bytes.NewReader(input))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBatchFileBytes)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if !gjson.ValidBytes(line) {
+			return nil, nil, "", fmt.Errorf("line %d: invalid JSON", lineNumber)
+		}
+		root := gjson.ParseBytes(line)
+		customID := root.Get("custom_id").String()
+		if customID == "" {
+			return nil, nil, "", fmt.Errorf("line %d: custom_id is required", lineNumber)
+		}
+		if _, duplicate := seen[customID]; duplicate {
+			return nil, nil, "", fmt.Errorf("line %d: duplicate custom_id %q", lineNumber, customID)
+		}
+		seen[customID] = struct{}{}
+		if method := root.Get("method").String(); method != "" && !strings.EqualFold(method, http.MethodPost) {
+			return nil, nil, "", fmt.Errorf("line %d: unsupported method %q", lineNumber, method)
+		}
+		if url := root.Get("url").String(); url != batchChatCompletionsEndpoint {
+			return nil, nil, "", fmt.Errorf("line %d: unsupported url %q", lineNumber, url)
+		}
+		original := []byte(root.Get("body").Raw)
+		modelName := gjson.GetBytes(original, "model").String()
+		if modelName == "" {
+			return nil, nil, "", fmt.Errorf("line %d: body.model is required", lineNumber)
+		}
+		if firstModel == "" {
+			firstModel = modelName
+		}
+		translated := sdktranslator.TranslateRequest(sdktranslator.FormatOpenAI, sdktranslator.FormatClaude, modelName, original, false)
+		translated, _ = sjson.DeleteBytes(translated, "stream")
+
+		upstreamID := customID
+		if _, taken := requests[upstreamID]; taken || !claudeCustomIDPattern.MatchString(upstreamID) {
+			upstreamID = nextBatchCustomID(requests)
+		}
+		requests[upstreamID] = batchRequest{CustomID: customID, Model: modelName, Original: original, Translated: translated}
+
+		entry := []byte(`{"custom_id":"","params":{}}`)
+		entry, _ = sjson.SetBytes(entry, "custom_id", upstreamID)
+		entry, _ = sjson.SetRawBytes(entry, "params", translated)
+		body, _ = sjson.SetRawBytes(body, "requests.-1", entry)
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return nil, nil, "", fmt.Errorf("read input file: %w", errScan)
+	}
+	if len(requests) == 0 {
+		return nil, nil, "", fmt.Errorf("input file contains no requests")
+	}
+	return body, requests, firstModel, nil
+}
+
+// translateClaudeBatchResults converts Anthropic batch results (JSONL) into OpenAI batch output
+// and error file contents.
+func translateClaudeBatchResults(ctx context.Context, results []byte, requests map[string]batchRequest) ([]byte, []byte) {
+	var output, errorsOut bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(results))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBatchFileBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		root := gjson.ParseBytes(line)
+		upstreamID := root.Get("custom_id").String()
+		request, ok := requests[upstreamID]
+		if !ok {
+			request = batchRequest{CustomID: upstreamID}
+		}
+		entry := []byte(`{"id":"","custom_id":"","response":null,"error":null}`)
+		entry, _ = sjson.SetBytes(entry, "id", newBatchObjectID("batch_req_"))
+		entry, _ = sjson.SetBytes(entry, "custom_id", request.CustomID)
+
+		result := root.Get("result")
+		switch result.Get("type").String() {
+		case "succeeded":
+			message := []byte(result.Get("message").Raw)
+			var param any
+			body := sdktranslator.TranslateNonStream(ctx, sdktranslator.FormatClaude, sdktranslator.FormatOpenAI, request.Model, request.Original, request.Translated, claudeMessageToSSE(message), &param)
+			entry, _ = sjson.SetBytes(entry, "response.status_code", http.StatusOK)
+			entry, _ = sjson.SetBytes(entry, "response.request_id", gjson.GetBytes(message, "id").String())
+			entry, _ = sjson.SetRawBytes(entry, "response.body", body)
+			output.Write(entry)
+			output.WriteByte('\n')
+			continue
+		case "errored":
+			upstreamErr := result.Get("error.error")
+			if !upstreamErr.Exists() {
+				upstreamErr = result.Get("error")
+			}
+			status := claudeErrorStatus(upstreamErr.Get("type").String())
+			entry, _ = sjson.SetBytes(entry, "response.status_code", status)
+			entry, _ = sjson.SetRawBytes(entry, "response.body", handlers.BuildErrorResponseBody(status, upstreamErr.Get("message").String()))
+		case "canceled":
+			entry, _ = sjson.SetBytes(entry, "error.code", "batch_cancelled")
+			entry, _ = sjson.SetBytes(entry, "error.message", "The request was cancelled before it was processed.")
+		case "expired":
+			entry, _ = sjson.SetBytes(entry, "error.code", "batch_expired")
+			entry, _ = sjson.SetBytes(entry, "error.message", "The request expired before it was processed.")
+		default:
+			entry, _ = sjson.SetBytes(entry, "error.code", "unknown_result")
+			entry, _ = sjson.SetBytes(entry, "error.message", "Unrecognized batch result: "+result.Raw)
+		}
+		errorsOut.Write(entry)
+		errorsOut.WriteByte('\n')
+	}
+	return output.Bytes(), errorsOut.Bytes()
+}
+
+// claudeMessageToSSE replays a complete Claude message as the streaming events the Claude to
+// OpenAI response translator consumes.
+func claudeMessageToSSE(message []byte) []byte {
+	var buf bytes.Buffer
+	writeEvent := func(event []byte) {
+		buf.WriteString("data: ")
+		buf.Write(event)
+		buf.WriteString("\n\n")
+	}
+
+	start := []byte(`{"type":"message_start","message":{}}`)
+	head, _ := sjson.SetRawBytes(message, "content", []byte(`[]`))
+	start, _ = sjson.SetRawBytes(start, "message", head)
+	writeEvent(start)
+
+	for index, block := range gjson.GetBytes(message, "content").Array() {
+		blockType := block.Get("type").String()
+		var contentBlock, delta []byte
+		switch blockType {
+		case "text":
+			contentBlock = []byte(`{"type":"text","text":""}`)
+			delta, _ = sjson.SetBytes([]byte(`{"type":"text_delta"}`), "text", block.Get("text").String())
+		case "thinking":
+			contentBlock = []byte(`{"type":"thinking","thinking":""}`)
+			delta, _ = sjson.SetBytes([]byte(`{"type":"thinking_delta"}`), "thinking", block.Get("thinking").String())
+		case "tool_use":
+			contentBlock, _ = sjson.SetBytes([]byte(`{"type":"tool_use","input":{}}`), "id", block.Get("id").String())
+			contentBlock, _ = sjson.SetBytes(contentBlock, "name", block.Get("name").String())
+			input := block.Get("input").Raw
+			if input == "" {
+				input = "{}"
+			}
+			delta, _ = sjson.SetBytes([]byte(`{"type":"input_json_delta"}`), "partial_json", input)
+		default:
+			contentBlock = []byte(block.Raw)
+		}
+		event, _ := sjson.SetBytes([]byte(`{"type":"content_block_start"}`), "index", index)
+		event, _ = sjson.SetRawBytes(event, "content_block", contentBlock)
+		writeEvent(event)
+		if delta != nil {
+			event, _ = sjson.SetBytes([]byte(`{"type":"content_block_delta"}`), "index", index)
+			event, _ = sjson.SetRawBytes(event, "delta", delta)
+			writeEvent(event)
+		}
+		event, _ = sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", index)
+		writeEvent(event)
+	}
+
+	messageDelta := []byte(`{"type":"message_delta","delta":{}}`)
+	messageDelta, _ = sjson.SetBytes(messageDelta, "delta.stop_reason", gjson.GetBytes(message, "stop_reason").String())
+	if usage := gjson.GetBytes(message, "usage"); usage.Exists() {
+		messageDelta, _ = sjson.SetRawBytes(messageDelta, "usage", []byte(usage.Raw))
+	}
+	writeEvent(messageDelta)
+	writeEvent([]byte(`{"type":"message_stop"}`))
+	return buf.Bytes()
+}
+
+// renderOpenAIBatch renders batch as an OpenAI batch object from its last known upstream state.
+func renderOpenAIBatch(batch *openAIBatch) []byte {
+	upstream := gjson.ParseBytes(batch.upstream)
+	out := []byte(`{"object":"batch","errors":null,"output_file_id":null,"error_file_id":null,"in_progress_at":null,"expires_at":null,"finalizing_at":null,"completed_at":null,"failed_at":null,"expired_at":null,"cancelling_at":null,"cancelled_at":null,"metadata":null}`)
+	out, _ = sjson.SetBytes(out, "id", batch.ID)
+	out, _ = sjson.SetBytes(out, "endpoint", batchChatCompletionsEndpoint)
+	out, _ = sjson.SetBytes(out, "input_file_id", batch.InputFileID)
+	out, _ = sjson.SetBytes(out, "completion_window", batch.CompletionWindow)
+	out, _ = sjson.SetBytes(out, "created_at", batch.CreatedAt)
+	out, _ = sjson.SetBytes(out, "in_progress_at", batch.CreatedAt)
+	if len(batch.Metadata) > 0 {
+		out, _ = sjson.SetRawBytes(out, "metadata", batch.Metadata)
+	}
+	if batch.outputFileID != "" {
+		out, _ = sjson.SetBytes(out, "output_file_id", batch.outputFileID)
+	}
+	if batch.errorFileID != "" {
+		out, _ = sjson.SetBytes(out, "error_file_id", batch.errorFileID)
+	}
+	if expiresAt := unixFromRFC3339(upstream.Get("expires_at").String()); expiresAt > 0 {
+		out, _ = sjson.SetBytes(out, "expires_at", expiresAt)
+	}
+	cancellingAt := unixFromRFC3339(upstream.Get("cancel_initiated_at").String())
+	if cancellingAt > 0 {
+		out, _ = sjson.SetBytes(out, "cancelling_at", cancellingAt)
+	}
+
+	counts := upstream.Get("request_counts")
+	succeeded := counts.Get("succeeded").Int()
+	failed := counts.Get("errored").Int() + counts.Get("canceled").Int() + counts.Get("expired").Int()
+	total := counts.Get("processing").Int() + succeeded + failed
+	out, _ = sjson.SetBytes(out, "request_counts", map[string]int64{"total": total, "completed": succeeded, "failed": failed})
+
+	status := "in_progress"
+	switch upstream.Get("processing_status").String() {
+	case "canceling":
+		status = "cancelling"
+	case "ended":
+		endedAt := unixFromRFC3339(upstream.Get("ended_at").String())
+		switch {
+		case !batch.resultsFetched:
+			status = "finalizing"
+			out, _ = sjson.SetBytes(out, "finalizing_at", endedAt)
+		case cancellingAt > 0:
+			status = "cancelled"
+			out, _ = sjson.SetBytes(out, "cancelled_at", endedAt)
+		case total > 0 && counts.Get("expired").Int() == total:
+			status = "expired"
+			out, _ = sjson.SetBytes(out, "expired_at", endedAt)
+		default:
+			status = "completed"
+			out, _ = sjson.SetBytes(out, "finalizing_at", endedAt)
+			out, _ = sjson.SetBytes(out, "completed_at", endedAt)
+		}
+	}
+	out, _ = sjson.SetBytes(out, "status", status)
+	return out
+}
+
+func renderBatchFile(file *batchFile) gin.H {
+	return gin.H{
+		"id":         file.ID,
+		"object":     "file",
+		"bytes":      len(file.Data),
+		"created_at": file.CreatedAt,
+		"filename":   file.Filename,
+		"purpose":    file.Purpose,
+	}
+}
+
+// claudeErrorStatus maps an Anthropic error type to its HTTP status code.
+func claudeErrorStatus(errType string) int {
+	switch errType {
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	case "request_too_large":
+		return http.StatusRequestEntityTooLarge
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return 529
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func unixFromRFC3339(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	parsed, errParse := time.Parse(time.RFC3339, value)
+	if errParse != nil {
+		return 0
+	}
+	return parsed.Unix()
+}
+
+// nextBatchCustomID returns an upstream custom_id for a request whose own custom_id cannot be
+// sent to Anthropic as-is.
+func nextBatchCustomID(requests map[string]batchRequest) string {
+	for i := len(requests); ; i++ {
+		id := "request-" + strconv.Itoa(i)
+		if _, taken := requests[id]; !taken {
+			return id
+		}
+	}
+}
+
+func newBatchObjectID(prefix string) string {
+	return prefix + strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+func writeBatchError(c *gin.Context, status int, message string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,119 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildClaudeBatch(t *testing.T) {
+	input := strings.Join([]string{
+		`{"custom_id":"req-1","method":"POST","url":"/v1/chat/completions","body":{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":"hi"}],"max_tokens":64}}`,
+		``,
+		`{"custom_id":"req 2/with spaces","method":"POST","url":"/v1/chat/completions","body":{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":"yo"}]}}`,
+	}, "\n")
+
+	body, requests, model, err := buildClaudeBatch([]byte(input))
+	if err != nil {
+		t.Fatalf("buildClaudeBatch() error = %v", err)
+	}
+	if model != "claude-sonnet-4-5" {
+		t.Fatalf("model = %q, want claude-sonnet-4-5", model)
+	}
+	entries := gjson.GetBytes(body, "requests").Array()
+	if len(entries) != 2 {
+		t.Fatalf("requests = %d, want 2: %s", len(entries), body)
+	}
+	if got := entries[0].Get("custom_id").String(); got != "req-1" {
+		t.Fatalf("first custom_id = %q, want req-1", got)
+	}
+	upstreamID := entries[1].Get("custom_id").String()
+	if upstreamID == "req 2/with spaces" || requests[upstreamID].CustomID != "req 2/with spaces" {
+		t.Fatalf("invalid custom_id was not remapped: %q -> %+v", upstreamID, requests[upstreamID])
+	}
+	params := entries[0].Get("params")
+	if params.Get("stream").Exists() || params.Get("messages.0.role").String() != "user" || params.Get("max_tokens").Int() != 64 {
+		t.Fatalf("params = %s, want translated Claude request without stream", params.Raw)
+	}
+
+	if _, _, _, err = buildClaudeBatch([]byte(`{"custom_id":"a","url":"/v1/embeddings","body":{"model":"m"}}`)); err == nil {
+		t.Fatal("expected unsupported url error")
+	}
+	if _, _, _, err = buildClaudeBatch([]byte(`{"custom_id":"a","url":"/v1/chat/completions","body":{"model":"m"}}` + "\n" + `{"custom_id":"a","url":"/v1/chat/completions","body":{"model":"m"}}`)); err == nil {
+		t.Fatal("expected duplicate custom_id error")
+	}
+}
+
+func TestTranslateClaudeBatchResults(t *testing.T) {
+	requests := map[string]batchRequest{
+		"ok":   {CustomID: "ok", Model: "claude-sonnet-4-5", Original: []byte(`{"model":"claude-sonnet-4-5"}`)},
+		"bad":  {CustomID: "bad", Model: "claude-sonnet-4-5"},
+		"gone": {CustomID: "gone", Model: "claude-sonnet-4-5"},
+	}
+	results := strings.Join([]string{
+		`{"custom_id":"ok","result":{"type":"succeeded","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"Hello"},{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"q":"x"}}],"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}}}`,
+		`{"custom_id":"bad","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens too large"}}}}`,
+		`{"custom_id":"gone","result":{"type":"expired"}}`,
+	}, "\n")
+
+	output, errorsOut := translateClaudeBatchResults(context.Background(), []byte(results), requests)
+	outLines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(outLines) != 1 {
+		t.Fatalf("output lines = %d, want 1: %s", len(outLines), output)
+	}
+	line := gjson.Parse(outLines[0])
+	if line.Get("custom_id").String() != "ok" || line.Get("response.status_code").Int() != http.StatusOK {
+		t.Fatalf("output line = %s", outLines[0])
+	}
+	body := line.Get("response.body")
+	if body.Get("object").String() != "chat.completion" || body.Get("choices.0.message.content").String() != "Hello" {
+		t.Fatalf("response body = %s, want chat completion with text", body.Raw)
+	}
+	if body.Get("choices.0.message.tool_calls.0.function.name").String() != "lookup" || body.Get("choices.0.finish_reason").String() != "tool_calls" {
+		t.Fatalf("response body = %s, want lookup tool call", body.Raw)
+	}
+	if body.Get("usage.total_tokens").Int() != 15 {
+		t.Fatalf("usage = %s, want 15 total tokens", body.Get("usage").Raw)
+	}
+
+	errLines := strings.Split(strings.TrimSpace(string(errorsOut)), "\n")
+	if len(errLines) != 2 {
+		t.Fatalf("error lines = %d, want 2: %s", len(errLines), errorsOut)
+	}
+	if status := gjson.Get(errLines[0], "response.status_code").Int(); status != http.StatusBadRequest {
+		t.Fatalf("errored status = %d, want 400", status)
+	}
+	if code := gjson.Get(errLines[1], "error.code").String(); code != "batch_expired" {
+		t.Fatalf("expired error code = %q, want batch_expired", code)
+	}
+}
+
+func TestRenderOpenAIBatchStatus(t *testing.T) {
+	batch := &openAIBatch{ID: "batch_1", InputFileID: "file-1", CompletionWindow: "24h", CreatedAt: 100}
+
+	batch.upstream = []byte(`{"processing_status":"in_progress","request_counts":{"processing":3,"succeeded":0,"errored":0,"canceled":0,"expired":0}}`)
+	if got := gjson.GetBytes(renderOpenAIBatch(batch), "status").String(); got != "in_progress" {
+		t.Fatalf("status = %q, want in_progress", got)
+	}
+
+	batch.upstream = []byte(`{"processing_status":"ended","ended_at":"2026-01-02T03:04:05Z","request_counts":{"processing":0,"succeeded":2,"errored":1,"canceled":0,"expired":0}}`)
+	if got := gjson.GetBytes(renderOpenAIBatch(batch), "status").String(); got != "finalizing" {
+		t.Fatalf("status = %q, want finalizing before results are fetched", got)
+	}
+	batch.resultsFetched = true
+	batch.outputFileID = "file-out"
+	out := renderOpenAIBatch(batch)
+	if got := gjson.GetBytes(out, "status").String(); got != "completed" {
+		t.Fatalf("status = %q, want completed", got)
+	}
+	if gjson.GetBytes(out, "request_counts.total").Int() != 3 || gjson.GetBytes(out, "request_counts.failed").Int() != 1 {
+		t.Fatalf("request_counts = %s", gjson.GetBytes(out, "request_counts").Raw)
+	}
+	if gjson.GetBytes(out, "output_file_id").String() != "file-out" || gjson.GetBytes(out, "completed_at").Int() == 0 {
+		t.Fatalf("batch = %s, want output file and completed_at", out)
+	}
+}
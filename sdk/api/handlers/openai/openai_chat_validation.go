@@ -0,0 +1,253 @@
+package openai
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// chatValidationError describes the first schema violation of a Chat Completions request.
+type chatValidationError struct {
+	// Pointer is the JSON pointer (RFC 6901) of the offending value.
+	Pointer string
+	Message string
+}
+
+func (e *chatValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pointer, e.Message)
+}
+
+var (
+	chatMessageRoles   = []string{"system", "developer", "user", "assistant", "tool", "function"}
+	chatContentParts   = []string{"text", "image_url", "input_audio", "file", "refusal"}
+	chatToolChoices    = []string{"none", "auto", "required"}
+	chatResponseFormat = []string{"text", "json_object", "json_schema"}
+)
+
+// validateChatCompletionRequest checks a Chat Completions request against the OpenAI schema:
+// required fields, field types, enum values and numeric ranges, and that tool messages answer
+// the tool calls of the assistant message right before them. It returns nil for valid requests.
+func validateChatCompletionRequest(rawJSON []byte) *chatValidationError {
+	if !gjson.ValidBytes(rawJSON) {
+		return &chatValidationError{Pointer: "", Message: "request body is not valid JSON"}
+	}
+	root := gjson.ParseBytes(rawJSON)
+	if !root.IsObject() {
+		return &chatValidationError{Pointer: "", Message: "request body must be a JSON object"}
+	}
+
+	if model := root.Get("model"); !model.Exists() || model.Type != gjson.String || strings.TrimSpace(model.String()) == "" {
+		return &chatValidationError{Pointer: "/model", Message: "model is required and must be a non-empty string"}
+	}
+	if errMessages := validateChatMessages(root.Get("messages")); errMessages != nil {
+		return errMessages
+	}
+	if errParams := validateChatParameters(root); errParams != nil {
+		return errParams
+	}
+	return validateChatTools(root)
+}
+
+func validateChatMessages(messages gjson.Result) *chatValidationError {
+	if !messages.Exists() || !messages.IsArray() || len(messages.Array()) == 0 {
+		return &chatValidationError{Pointer: "/messages", Message: "messages is required and must be a non-empty array"}
+	}
+
+	// pendingToolCalls holds the unanswered tool call IDs of the latest assistant message.
+	var pendingToolCalls []string
+	for i, message := range messages.Array() {
+		pointer := "/messages/" + strconv.Itoa(i)
+		if !message.IsObject() {
+			return &chatValidationError{Pointer: pointer, Message: "message must be an object"}
+		}
+		role := message.Get("role")
+		if role.Type != gjson.String || !slices.Contains(chatMessageRoles, role.String()) {
+			return &chatValidationError{Pointer: pointer + "/role", Message: "role must be one of " + strings.Join(chatMessageRoles, ", ")}
+		}
+
+		if role.String() != "tool" && len(pendingToolCalls) > 0 {
+			return &chatValidationError{Pointer: pointer, Message: fmt.Sprintf("expected tool messages answering tool calls %s", strings.Join(pendingToolCalls, ", "))}
+		}
+
+		switch role.String() {
+		case "assistant":
+			toolCalls := message.Get("tool_calls")
+			if errContent := validateChatContent(message.Get("content"), pointer+"/content", toolCalls.Exists() || message.Get("function_call").Exists()); errContent != nil {
+				return errContent
+			}
+			ids, errToolCalls := validateChatToolCalls(toolCalls, pointer+"/tool_calls")
+			if errToolCalls != nil {
+				return errToolCalls
+			}
+			pendingToolCalls = ids
+		case "tool":
+			toolCallID := message.Get("tool_call_id")
+			if toolCallID.Type != gjson.String || toolCallID.String() == "" {
+				return &chatValidationError{Pointer: pointer + "/tool_call_id", Message: "tool_call_id is required and must be a non-empty string"}
+			}
+			index := slices.Index(pendingToolCalls, toolCallID.String())
+			if index < 0 {
+				return &chatValidationError{Pointer: pointer + "/tool_call_id", Message: fmt.Sprintf("tool_call_id %q does not answer a tool call of the preceding assistant message", toolCallID.String())}
+			}
+			pendingToolCalls = slices.Delete(pendingToolCalls, index, index+1)
+			if errContent := validateChatContent(message.Get("content"), pointer+"/content", false); errContent != nil {
+				return errContent
+			}
+		default:
+			if errContent := validateChatContent(message.Get("content"), pointer+"/content", false); errContent != nil {
+				return errContent
+			}
+		}
+	}
+	if len(pendingToolCalls) > 0 {
+		return &chatValidationError{Pointer: "/messages", Message: fmt.Sprintf("tool calls %s have no tool message answering them", strings.Join(pendingToolCalls, ", "))}
+	}
+	return nil
+}
+
+func validateChatContent(content gjson.Result, pointer string, optional bool) *chatValidationError {
+	if !content.Exists() || content.Type == gjson.Null {
+		if optional {
+			return nil
+		}
+		return &chatValidationError{Pointer: pointer, Message: "content is required"}
+	}
+	if content.Type == gjson.String {
+		return nil
+	}
+	if !content.IsArray() {
+		return &chatValidationError{Pointer: pointer, Message: "content must be a string or an array of content parts"}
+	}
+	for i, part := range content.Array() {
+		partPointer := pointer + "/" + strconv.Itoa(i)
+		partType := part.Get("type")
+		if !part.IsObject() || partType.Type != gjson.String || !slices.Contains(chatContentParts, partType.String()) {
+			return &chatValidationError{Pointer: partPointer + "/type", Message: "type must be one of " + strings.Join(chatContentParts, ", ")}
+		}
+		switch partType.String() {
+		case "text":
+			if part.Get("text").Type != gjson.String {
+				return &chatValidationError{Pointer: partPointer + "/text", Message: "text is required and must be a string"}
+			}
+		case "image_url":
+			if part.Get("image_url.url").Type != gjson.String {
+				return &chatValidationError{Pointer: partPointer + "/image_url/url", Message: "url is required and must be a string"}
+			}
+		case "refusal":
+			if part.Get("refusal").Type != gjson.String {
+				return &chatValidationError{Pointer: partPointer + "/refusal", Message: "refusal is required and must be a string"}
+			}
+		}
+	}
+	return nil
+}
+
+func validateChatToolCalls(toolCalls gjson.Result, pointer string) ([]string, *chatValidationError) {
+	if !toolCalls.Exists() {
+		return nil, nil
+	}
+	if !toolCalls.IsArray() {
+		return nil, &chatValidationError{Pointer: pointer, Message: "tool_calls must be an array"}
+	}
+	var ids []string
+	for i, toolCall := range toolCalls.Array() {
+		callPointer := pointer + "/" + strconv.Itoa(i)
+		id := toolCall.Get("id")
+		if id.Type != gjson.String || id.String() == "" {
+			return nil, &chatValidationError{Pointer: callPointer + "/id", Message: "id is required and must be a non-empty string"}
+		}
+		if toolCall.Get("type").String() != "function" {
+			return nil, &chatValidationError{Pointer: callPointer + "/type", Message: `type must be "function"`}
+		}
+		if name := toolCall.Get("function.name"); name.Type != gjson.String || name.String() == "" {
+			return nil, &chatValidationError{Pointer: callPointer + "/function/name", Message: "name is required and must be a non-empty string"}
+		}
+		if toolCall.Get("function.arguments").Type != gjson.String {
+			return nil, &chatValidationError{Pointer: callPointer + "/function/arguments", Message: "arguments is required and must be a JSON string"}
+		}
+		ids = append(ids, id.String())
+	}
+	return ids, nil
+}
+
+func validateChatParameters(root gjson.Result) *chatValidationError {
+	ranges := []struct {
+		field    string
+		min, max float64
+	}{
+		{"temperature", 0, 2},
+		{"top_p", 0, 1},
+		{"presence_penalty", -2, 2},
+		{"frequency_penalty", -2, 2},
+	}
+	for _, r := range ranges {
+		value := root.Get(r.field)
+		if !value.Exists() || value.Type == gjson.Null {
+			continue
+		}
+		if value.Type != gjson.Number || value.Float() < r.min || value.Float() > r.max {
+			return &chatValidationError{Pointer: "/" + r.field, Message: fmt.Sprintf("%s must be a number between %g and %g", r.field, r.min, r.max)}
+		}
+	}
+	for _, field := range []string{"max_tokens", "max_completion_tokens", "n"} {
+		value := root.Get(field)
+		if !value.Exists() || value.Type == gjson.Null {
+			continue
+		}
+		if value.Type != gjson.Number || value.Float() != float64(value.Int()) || value.Int() < 1 {
+			return &chatValidationError{Pointer: "/" + field, Message: field + " must be a positive integer"}
+		}
+	}
+	if stream := root.Get("stream"); stream.Exists() && stream.Type != gjson.True && stream.Type != gjson.False && stream.Type != gjson.Null {
+		return &chatValidationError{Pointer: "/stream", Message: "stream must be a boolean"}
+	}
+	if format := root.Get("response_format"); format.Exists() && format.Type != gjson.Null {
+		if formatType := format.Get("type"); formatType.Type != gjson.String || !slices.Contains(chatResponseFormat, formatType.String()) {
+			return &chatValidationError{Pointer: "/response_format/type", Message: "type must be one of " + strings.Join(chatResponseFormat, ", ")}
+		}
+	}
+	return nil
+}
+
+func validateChatTools(root gjson.Result) *chatValidationError {
+	if tools := root.Get("tools"); tools.Exists() && tools.Type != gjson.Null {
+		if !tools.IsArray() {
+			return &chatValidationError{Pointer: "/tools", Message: "tools must be an array"}
+		}
+		for i, tool := range tools.Array() {
+			pointer := "/tools/" + strconv.Itoa(i)
+			toolType := tool.Get("type")
+			if toolType.Type != gjson.String || toolType.String() == "" {
+				return &chatValidationError{Pointer: pointer + "/type", Message: "type is required and must be a string"}
+			}
+			if toolType.String() != "function" {
+				continue
+			}
+			if name := tool.Get("function.name"); name.Type != gjson.String || name.String() == "" {
+				return &chatValidationError{Pointer: pointer + "/function/name", Message: "name is required and must be a non-empty string"}
+			}
+		}
+	}
+
+	toolChoice := root.Get("tool_choice")
+	switch {
+	case !toolChoice.Exists() || toolChoice.Type == gjson.Null:
+	case toolChoice.Type == gjson.String:
+		if !slices.Contains(chatToolChoices, toolChoice.String()) {
+			return &chatValidationError{Pointer: "/tool_choice", Message: "tool_choice must be one of " + strings.Join(chatToolChoices, ", ") + " or a function choice"}
+		}
+	case toolChoice.IsObject():
+		if toolChoice.Get("type").String() != "function" {
+			return &chatValidationError{Pointer: "/tool_choice/type", Message: `type must be "function"`}
+		}
+		if name := toolChoice.Get("function.name"); name.Type != gjson.String || name.String() == "" {
+			return &chatValidationError{Pointer: "/tool_choice/function/name", Message: "name is required and must be a non-empty string"}
+		}
+	default:
+		return &chatValidationError{Pointer: "/tool_choice", Message: "tool_choice must be a string or an object"}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestValidateChatCompletionRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		pointer string
+	}{
+		{"valid", `{"model":"m","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":null,"tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]},{"role":"tool","tool_call_id":"c1","content":"ok"}],"temperature":1}`, ""},
+		{"missing model", `{"messages":[{"role":"user","content":"hi"}]}`, "/model"},
+		{"empty messages", `{"model":"m","messages":[]}`, "/messages"},
+		{"bad role", `{"model":"m","messages":[{"role":"bot","content":"hi"}]}`, "/messages/0/role"},
+		{"bad part", `{"model":"m","messages":[{"role":"user","content":[{"type":"image_url","image_url":{}}]}]}`, "/messages/0/content/0/image_url/url"},
+		{"unknown tool result", `{"model":"m","messages":[{"role":"user","content":"hi"},{"role":"tool","tool_call_id":"c9","content":"ok"}]}`, "/messages/1/tool_call_id"},
+		{"unanswered tool call", `{"model":"m","messages":[{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]},{"role":"user","content":"hi"}]}`, "/messages/1"},
+		{"temperature range", `{"model":"m","messages":[{"role":"user","content":"hi"}],"temperature":3}`, "/temperature"},
+		{"tool choice", `{"model":"m","messages":[{"role":"user","content":"hi"}],"tool_choice":"any"}`, "/tool_choice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateChatCompletionRequest([]byte(tt.body))
+			if tt.pointer == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil || err.Pointer != tt.pointer {
+				t.Fatalf("error = %v, want pointer %s", err, tt.pointer)
+			}
+		})
+	}
+}
+
+func TestChatCompletionsStrictValidationRejectsInvalidRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{StrictOpenAIValidation: true}, nil))
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"m","messages":[{"role":"user"}]}`))
+	h.ChatCompletions(c)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", recorder.Code)
+	}
+	if got := gjson.Get(recorder.Body.String(), "error.param").String(); got != "/messages/0/content" {
+		t.Fatalf("param = %q, body %s", got, recorder.Body.String())
+	}
+}
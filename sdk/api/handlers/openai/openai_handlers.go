@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
@@ -19,6 +20,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	responsesconverter "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/openai/openai/responses"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -59,8 +61,8 @@ func (h *OpenAIAPIHandler) Models() []map[string]any {
 // It returns a list of available AI models with their capabilities
 // and specifications in OpenAI-compatible format.
 func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
-	// Get all available models
-	allModels := h.Models()
+	// Get all available models the client API key may use
+	allModels := h.ListModelsForKey(c, h.Models())
 
 	// Filter to only include the 4 required fields: id, object, created, owned_by
 	filteredModels := make([]map[string]any, len(allModels))
@@ -108,6 +110,20 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 		return
 	}
 
+	// Strict mode rejects malformed Chat Completions payloads before they are translated.
+	if h.Cfg != nil && h.Cfg.StrictOpenAIValidation && !shouldTreatAsResponsesFormat(rawJSON) {
+		if errValidate := validateChatCompletionRequest(rawJSON); errValidate != nil {
+			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: fmt.Sprintf("Invalid request: %v", errValidate),
+					Type:    "invalid_request_error",
+					Param:   errValidate.Pointer,
+				},
+			})
+			return
+		}
+	}
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	stream := streamResult.Type == gjson.True
@@ -128,6 +144,46 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 
 }
 
+// ChatCompletionsCountTokens handles the /v1/chat/completions/count_tokens endpoint.
+// It counts the input tokens of an OpenAI chat completions payload without generating a
+// response. The result carries both the OpenAI usage block and the Anthropic-native
+// input_tokens field when the upstream provides them.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ChatCompletionsCountTokens(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	// If data retrieval fails, return a 400 Bad Request error.
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	if shouldTreatAsResponsesFormat(rawJSON) {
+		modelName := gjson.GetBytes(rawJSON, "model").String()
+		rawJSON = responsesconverter.ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName, rawJSON, false)
+	}
+
+	c.Header("Content-Type", "application/json")
+
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteCountWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
 // shouldTreatAsResponsesFormat detects OpenAI Responses-style payloads that are
 // accidentally sent to the Chat Completions endpoint.
 func shouldTreatAsResponsesFormat(rawJSON []byte) bool {
@@ -438,7 +494,7 @@ func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	_, _ = c.Writer.Write(resp)
+	_, _ = c.Writer.Write(h.stripInternalResponseFields(resp))
 	cliCancel()
 }
 
@@ -508,7 +564,7 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			setSSEHeaders()
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
-			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(h.stripInternalResponseFields(chunk)))
 			flusher.Flush()
 
 			// Continue streaming the rest
@@ -658,10 +714,19 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 	}
 }
 func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+	var lastChunk []byte
+	var writeKeepAlive func()
+	if handlers.StreamingKeepAliveMode(h.Cfg) == config.StreamingKeepAliveEmptyDelta {
+		writeKeepAlive = func() {
+			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", emptyDeltaChunk(lastChunk))
+		}
+	}
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
-			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+			lastChunk = chunk
+			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(h.stripInternalResponseFields(chunk)))
 		},
+		WriteKeepAlive: writeKeepAlive,
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
 			if errMsg == nil {
 				return
@@ -682,3 +747,52 @@ func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flush
 		},
 	})
 }
+
+// emptyDeltaChunk returns a Chat Completions chunk without content that reuses the id, model
+// and created time of the last chunk sent, used as a keep-alive data event.
+func emptyDeltaChunk(lastChunk []byte) []byte {
+	chunk := []byte(`{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{}}]}`)
+	last := gjson.ParseBytes(lastChunk)
+	chunk, _ = sjson.SetBytes(chunk, "id", last.Get("id").String())
+	chunk, _ = sjson.SetBytes(chunk, "model", last.Get("model").String())
+	created := last.Get("created").Int()
+	if created == 0 {
+		created = time.Now().Unix()
+	}
+	chunk, _ = sjson.SetBytes(chunk, "created", created)
+	return chunk
+}
+
+// stripInternalResponseFields removes proxy-internal fields from a Chat Completions
+// response or stream chunk when strip-internal-response-fields is enabled.
+func (h *OpenAIAPIHandler) stripInternalResponseFields(payload []byte) []byte {
+	if h == nil || h.Cfg == nil || !h.Cfg.StripInternalResponseFields {
+		return payload
+	}
+	return stripInternalChatCompletionFields(payload)
+}
+
+// stripInternalChatCompletionFields deletes native_finish_reason and response_metadata
+// from every choice (and the top level) of a Chat Completions payload.
+func stripInternalChatCompletionFields(payload []byte) []byte {
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return payload
+	}
+	out := payload
+	if gjson.GetBytes(out, "response_metadata").Exists() {
+		out, _ = sjson.DeleteBytes(out, "response_metadata")
+	}
+	choices := gjson.GetBytes(out, "choices")
+	if !choices.IsArray() {
+		return out
+	}
+	for i := range choices.Array() {
+		prefix := fmt.Sprintf("choices.%d.", i)
+		for _, field := range []string{"native_finish_reason", "response_metadata", "delta.response_metadata", "message.response_metadata"} {
+			if gjson.GetBytes(out, prefix+field).Exists() {
+				out, _ = sjson.DeleteBytes(out, prefix+field)
+			}
+		}
+	}
+	return out
+}
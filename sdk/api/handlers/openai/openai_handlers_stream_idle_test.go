@@ -0,0 +1,48 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestHandleStreamResultEmptyDeltaKeepAliveAndIdleTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{
+		KeepAliveSeconds:   1,
+		KeepAliveMode:      sdkconfig.StreamingKeepAliveEmptyDelta,
+		IdleTimeoutSeconds: 2,
+	}}, nil)
+	h := NewOpenAIAPIHandler(base)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		t.Fatalf("expected gin writer to implement http.Flusher")
+	}
+
+	data := make(chan []byte, 1)
+	data <- []byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"claude-sonnet-4-5","choices":[{"index":0,"delta":{"role":"assistant"}}]}`)
+	errs := make(chan *interfaces.ErrorMessage)
+	var cancelErr error
+	h.handleStreamResult(c, flusher, func(err error) { cancelErr = err }, data, errs)
+
+	body := recorder.Body.String()
+	if !strings.Contains(body, `"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"claude-sonnet-4-5","choices":[{"index":0,"delta":{}}]`) {
+		t.Fatalf("expected empty delta keep-alive chunk, got: %q", body)
+	}
+	if !strings.Contains(body, `"error":{`) || !strings.Contains(body, "stream idle timeout") {
+		t.Fatalf("expected idle timeout error frame, got: %q", body)
+	}
+	if cancelErr == nil {
+		t.Fatal("expected stream to be cancelled with the idle timeout error")
+	}
+}
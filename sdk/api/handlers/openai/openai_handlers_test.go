@@ -0,0 +1,32 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestStripInternalResponseFields(t *testing.T) {
+	chunk := []byte(`{"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hi","response_metadata":{}},"finish_reason":"stop","native_finish_reason":"end_turn"}],"response_metadata":{}}`)
+
+	disabled := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	if out := disabled.stripInternalResponseFields(chunk); string(out) != string(chunk) {
+		t.Fatalf("expected chunk unchanged when stripping is disabled, got %s", out)
+	}
+
+	enabled := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{StripInternalResponseFields: true}, nil))
+	out := enabled.stripInternalResponseFields(chunk)
+	for _, path := range []string{"response_metadata", "choices.0.delta.response_metadata", "choices.0.native_finish_reason"} {
+		if gjson.GetBytes(out, path).Exists() {
+			t.Fatalf("expected %s to be stripped, got %s", path, out)
+		}
+	}
+	if got := gjson.GetBytes(out, "choices.0.delta.content").String(); got != "hi" {
+		t.Fatalf("delta content = %q, want hi", got)
+	}
+	if got := gjson.GetBytes(out, "choices.0.finish_reason").String(); got != "stop" {
+		t.Fatalf("finish_reason = %q, want stop", got)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"golang.org/x/net/context"
+)
+
+// proxyKeyFromContext returns the proxy key the request was authenticated with, or nil.
+func (h *BaseAPIHandler) proxyKeyFromContext(ctx context.Context) *config.ProxyKey {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ProxyKeys) == 0 {
+		return nil
+	}
+	return h.Cfg.FindProxyKey(clientAPIKeyFromContext(ctx))
+}
+
+// proxyKeyForRequest returns the proxy key the gin request was authenticated with, or nil.
+func (h *BaseAPIHandler) proxyKeyForRequest(c *gin.Context) *config.ProxyKey {
+	return h.proxyKeyFromContext(context.WithValue(context.Background(), "gin", c))
+}
+
+// checkModelAccess rejects modelName with an OpenAI-style model_not_found error when the request
+// was authenticated with a proxy key that may not use it or its thinking suffix.
+func (h *BaseAPIHandler) checkModelAccess(ctx context.Context, modelName string) *interfaces.ErrorMessage {
+	key := h.proxyKeyFromContext(ctx)
+	if key == nil || key.AllowsModel(modelName) {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusNotFound,
+		Error:      fmt.Errorf("The model `%s` does not exist or you do not have access to it.", modelName),
+	}
+}
+
+// resolveModelAlias maps modelName through the model aliases of the request's proxy key and
+// rewrites the body's model field to match. Requests without an alias are returned unchanged.
+func (h *BaseAPIHandler) resolveModelAlias(ctx context.Context, modelName string, rawJSON []byte) (string, []byte) {
+	key := h.proxyKeyFromContext(ctx)
+	if key == nil {
+		return modelName, rawJSON
+	}
+	target, ok := key.ResolveAlias(modelName)
+	if !ok {
+		return modelName, rawJSON
+	}
+	return target, payloadForFallback(rawJSON, target)
+}
+
+// FilterModelsForKey drops the models the request's proxy key may not use from a model listing.
+// Models are identified by their "id" or, for Gemini listings, "name" field.
+func (h *BaseAPIHandler) FilterModelsForKey(c *gin.Context, models []map[string]any) []map[string]any {
+	key := h.proxyKeyForRequest(c)
+	if key == nil || len(key.Models) == 0 {
+		return models
+	}
+	filtered := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		id, _ := model["id"].(string)
+		if id == "" {
+			name, _ := model["name"].(string)
+			id = strings.TrimPrefix(name, "models/")
+		}
+		if key.AllowsModel(id) {
+			filtered = append(filtered, model)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/context"
+)
+
+func newProxyKeyTestHandler() *BaseAPIHandler {
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{ProxyKeys: []sdkconfig.ProxyKey{
+		{
+			ID:               "key-a",
+			Key:              "restricted",
+			Models:           []string{"claude-*", "gemini-2.5-pro"},
+			ThinkingSuffixes: []string{"low", "high"},
+			ModelAliases:     map[string]string{"default": "gpt-5", "fast": "gemini-2.5-flash(none)"},
+		},
+		{ID: "key-b", Key: "open"},
+	}}, nil)
+}
+
+func proxyKeyTestContext(apiKey string) context.Context {
+	ctx, _ := fallbackTestContext()
+	ctx.Value("gin").(*gin.Context).Set("apiKey", apiKey)
+	return ctx
+}
+
+func TestCheckModelAccess(t *testing.T) {
+	handler := newProxyKeyTestHandler()
+
+	tests := []struct {
+		apiKey string
+		model  string
+		allow  bool
+	}{
+		{apiKey: "restricted", model: "claude-sonnet-4-5", allow: true},
+		{apiKey: "restricted", model: "Gemini-2.5-Pro", allow: true},
+		{apiKey: "restricted", model: "gemini-2.5-pro(high)", allow: true},
+		{apiKey: "restricted", model: "gemini-2.5-pro(8192)", allow: false},
+		{apiKey: "restricted", model: "gpt-5", allow: true},
+		{apiKey: "restricted", model: "gpt-5(8192)", allow: false},
+		{apiKey: "restricted", model: "gemini-2.5-flash(none)", allow: true},
+		{apiKey: "restricted", model: "gpt-4o", allow: false},
+		{apiKey: "open", model: "gpt-4o(8192)", allow: true},
+		{apiKey: "legacy", model: "gpt-4o", allow: true},
+	}
+	for _, tt := range tests {
+		errMsg := handler.checkModelAccess(proxyKeyTestContext(tt.apiKey), tt.model)
+		if tt.allow && errMsg != nil {
+			t.Fatalf("%s/%s: unexpected error %v", tt.apiKey, tt.model, errMsg.Error)
+		}
+		if !tt.allow && (errMsg == nil || errMsg.StatusCode != http.StatusNotFound) {
+			t.Fatalf("%s/%s: expected 404, got %+v", tt.apiKey, tt.model, errMsg)
+		}
+	}
+	if code := gjson.GetBytes(BuildErrorResponseBody(http.StatusNotFound, "x"), "error.code").String(); code != "model_not_found" {
+		t.Fatalf("error code = %q, want model_not_found", code)
+	}
+}
+
+func TestResolveModelAlias(t *testing.T) {
+	handler := newProxyKeyTestHandler()
+
+	tests := []struct {
+		apiKey    string
+		model     string
+		wantModel string
+	}{
+		{apiKey: "restricted", model: "Default", wantModel: "gpt-5"},
+		{apiKey: "restricted", model: "default(high)", wantModel: "gpt-5(high)"},
+		{apiKey: "restricted", model: "fast(high)", wantModel: "gemini-2.5-flash(none)"},
+		{apiKey: "restricted", model: "claude-sonnet-4-5", wantModel: "claude-sonnet-4-5"},
+		{apiKey: "open", model: "default", wantModel: "default"},
+	}
+	for _, tt := range tests {
+		model, body := handler.resolveModelAlias(proxyKeyTestContext(tt.apiKey), tt.model, []byte(`{"model":"`+tt.model+`"}`))
+		if model != tt.wantModel || gjson.GetBytes(body, "model").String() != tt.wantModel {
+			t.Fatalf("%s/%s: model = %q, body = %s, want %q", tt.apiKey, tt.model, model, body, tt.wantModel)
+		}
+	}
+}
+
+func TestFilterModelsForKey(t *testing.T) {
+	handler := newProxyKeyTestHandler()
+	models := []map[string]any{{"id": "claude-sonnet-4-5"}, {"id": "gpt-4o"}, {"name": "models/gemini-2.5-pro"}}
+
+	recorderCtx := proxyKeyTestContext("restricted").Value("gin").(*gin.Context)
+	if got := handler.FilterModelsForKey(recorderCtx, models); len(got) != 2 {
+		t.Fatalf("restricted key sees %v", got)
+	}
+	openCtx := proxyKeyTestContext("open").Value("gin").(*gin.Context)
+	if got := handler.FilterModelsForKey(openCtx, models); len(got) != 3 {
+		t.Fatalf("open key sees %v", got)
+	}
+}
+
+func TestListModelsForKey(t *testing.T) {
+	handler := newProxyKeyTestHandler()
+	handler.Cfg.ListThinkingVariants = true
+	models := []map[string]any{{"id": "gemini-2.5-pro", "owned_by": "google"}, {"id": "gpt-5"}, {"id": "gpt-4o"}}
+
+	ctx := proxyKeyTestContext("restricted").Value("gin").(*gin.Context)
+	got := handler.ListModelsForKey(ctx, models)
+	ids := make([]string, 0, len(got))
+	for _, model := range got {
+		ids = append(ids, model["id"].(string))
+	}
+	want := "gemini-2.5-pro,gemini-2.5-pro(low),gemini-2.5-pro(high),gpt-5,default"
+	if strings.Join(ids, ",") != want {
+		t.Fatalf("ids = %v, want %s", ids, want)
+	}
+	if got[1]["owned_by"] != "google" {
+		t.Fatalf("variant should keep the model metadata, got %v", got[1])
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/imagefetch"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// maxRemoteImagesPerRequest bounds the downloads one request can trigger.
+const maxRemoteImagesPerRequest = 20
+
+// inlineRemoteImages replaces remote image URLs in OpenAI Chat Completions, OpenAI Responses
+// and Claude Messages requests with base64 data when image fetching is enabled, so translators
+// that only understand inline images keep them. Images that cannot be fetched are left as-is.
+func inlineRemoteImages(ctx context.Context, handlerType string, rawJSON []byte) []byte {
+	settings := imagefetch.Active()
+	if settings == nil || !bytes.Contains(rawJSON, []byte("http")) {
+		return rawJSON
+	}
+	var root, partType string
+	switch handlerType {
+	case "openai":
+		root, partType = "messages", "image_url"
+	case "openai-response":
+		root, partType = "input", "input_image"
+	case "claude":
+		root, partType = "messages", "image"
+	default:
+		return rawJSON
+	}
+
+	out := rawJSON
+	fetched := 0
+	gjson.GetBytes(rawJSON, root).ForEach(func(i, message gjson.Result) bool {
+		message.Get("content").ForEach(func(j, part gjson.Result) bool {
+			if part.Get("type").String() != partType {
+				return true
+			}
+			path := fmt.Sprintf("%s.%d.content.%d", root, i.Int(), j.Int())
+			var imageURL, urlPath string
+			switch {
+			case handlerType == "claude":
+				if part.Get("source.type").String() != "url" {
+					return true
+				}
+				imageURL = part.Get("source.url").String()
+			case part.Get("image_url.url").Exists():
+				imageURL, urlPath = part.Get("image_url.url").String(), path+".image_url.url"
+			default:
+				imageURL, urlPath = part.Get("image_url").String(), path+".image_url"
+			}
+			if imageURL == "" || strings.HasPrefix(imageURL, "data:") {
+				return true
+			}
+			if fetched >= maxRemoteImagesPerRequest {
+				return false
+			}
+			fetched++
+			mediaType, data, errFetch := settings.Fetch(ctx, imageURL)
+			if errFetch != nil {
+				log.Warnf("leaving remote image as url: %v", errFetch)
+				return true
+			}
+			if handlerType == "claude" {
+				source := []byte(`{"type":"base64","media_type":"","data":""}`)
+				source, _ = sjson.SetBytes(source, "media_type", mediaType)
+				source, _ = sjson.SetBytes(source, "data", data)
+				out, _ = sjson.SetRawBytes(out, path+".source", source)
+				return true
+			}
+			out, _ = sjson.SetBytes(out, urlPath, "data:"+mediaType+";base64,"+data)
+			return true
+		})
+		return fetched < maxRemoteImagesPerRequest
+	})
+	return out
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/imagefetch"
+	"github.com/tidwall/gjson"
+)
+
+func TestInlineRemoteImages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+	imagefetch.SetSettings(&imagefetch.Settings{AllowPrivateNetworks: true})
+	t.Cleanup(func() { imagefetch.SetSettings(nil) })
+
+	chat := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"` + server.URL + `/a.png"}}]}]}`)
+	if got := gjson.GetBytes(inlineRemoteImages(context.Background(), "openai", chat), "messages.0.content.0.image_url.url").String(); got != "data:image/png;base64,cG5n" {
+		t.Fatalf("openai image url = %q", got)
+	}
+
+	responses := []byte(`{"input":[{"role":"user","content":[{"type":"input_image","image_url":"` + server.URL + `/a.png"}]}]}`)
+	if got := gjson.GetBytes(inlineRemoteImages(context.Background(), "openai-response", responses), "input.0.content.0.image_url").String(); got != "data:image/png;base64,cG5n" {
+		t.Fatalf("responses image url = %q", got)
+	}
+
+	claude := []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"url","url":"` + server.URL + `/a.png"}}]}]}`)
+	source := gjson.GetBytes(inlineRemoteImages(context.Background(), "claude", claude), "messages.0.content.0.source")
+	if source.Get("type").String() != "base64" || source.Get("media_type").String() != "image/png" || source.Get("data").String() != "cG5n" {
+		t.Fatalf("claude image source = %s", source.Raw)
+	}
+}
+
+func TestInlineRemoteImagesDisabledLeavesRequest(t *testing.T) {
+	imagefetch.SetSettings(nil)
+	body := []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`)
+	if got := inlineRemoteImages(context.Background(), "openai", body); string(got) != string(body) {
+		t.Fatalf("body changed: %s", got)
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"golang.org/x/sync/singleflight"
+)
+
+// requestDedupeTimeout bounds a shared request, which no longer ends with the client that
+// started it.
+const requestDedupeTimeout = 10 * time.Minute
+
+// requestDedupe coalesces identical in-flight non-streaming requests.
+var requestDedupe singleflight.Group
+
+// dedupeKeyHeaders are client headers that change how a request is served upstream, so
+// requests differing in them are not coalesced.
+var dedupeKeyHeaders = append([]string{"Anthropic-Beta"}, util.SessionHeaders...)
+
+// dedupeLogKeys are the gin context keys of the upstream request log, copied to every caller
+// of a shared request.
+var dedupeLogKeys = []string{"API_UPSTREAM_ATTEMPTS", "API_REQUEST", "API_RESPONSE", "API_RESPONSE_ERROR", "API_RESPONSE_TIMESTAMP"}
+
+type dedupeResult struct {
+	resp    []byte
+	headers http.Header
+	errMsg  *interfaces.ErrorMessage
+	// usage and logs are the usage records and request log entries of the shared call.
+	usage []coreusage.Record
+	logs  map[string]any
+}
+
+// RequestDedupeEnabled returns whether identical concurrent non-streaming requests share one upstream call.
+// Default is false.
+func RequestDedupeEnabled(cfg *config.SDKConfig) bool {
+	return cfg != nil && cfg.DedupeRequests
+}
+
+// executeDeduplicated runs the request once per key; concurrent callers with the same key wait
+// for that call and receive copies of its result. The shared call does not end when the client
+// that started it goes away. Callers that joined an in-flight call are counted as dedupe hits
+// in the usage statistics and receive the usage records and request log of the shared call as
+// their own.
+func (h *BaseAPIHandler) executeDeduplicated(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	key := requestDedupeKey(ctx, handlerType, modelName, alt, rawJSON)
+	leader := false
+	value, _, shared := requestDedupe.Do(key, func() (any, error) {
+		leader = true
+		sharedCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), requestDedupeTimeout)
+		defer cancel()
+		sharedCtx, collected := coreusage.WithCapture(sharedCtx)
+		resp, headers, errMsg := h.executeWithFallbacks(sharedCtx, handlerType, modelName, rawJSON, alt)
+		return dedupeResult{resp: resp, headers: headers, errMsg: errMsg, usage: collected(), logs: dedupeLogs(ctx)}, nil
+	})
+	result := value.(dedupeResult)
+	if !leader {
+		usage.GetRequestStatistics().RecordDedupeHit()
+		adoptDedupeResult(ctx, result)
+	}
+	if !shared {
+		return result.resp, result.headers, result.errMsg
+	}
+	return cloneBytes(result.resp), cloneHeader(result.headers), result.errMsg
+}
+
+// dedupeLogs snapshots the request log entries of the caller behind ctx.
+func dedupeLogs(ctx context.Context) map[string]any {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return nil
+	}
+	logs := make(map[string]any, len(dedupeLogKeys))
+	for _, key := range dedupeLogKeys {
+		if value, exists := ginCtx.Get(key); exists {
+			logs[key] = value
+		}
+	}
+	return logs
+}
+
+// adoptDedupeResult records the usage and request log of a shared call for a caller that
+// joined it.
+func adoptDedupeResult(ctx context.Context, result dedupeResult) {
+	apiKey := clientAPIKeyFromContext(ctx)
+	for _, record := range result.usage {
+		record.APIKey = apiKey
+		coreusage.PublishRecord(ctx, record)
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	for key, value := range result.logs {
+		ginCtx.Set(key, value)
+	}
+}
+
+// requestDedupeKey identifies a request by client API key, endpoint format, model, alt, the
+// client headers that affect upstream behavior and body hash.
+func requestDedupeKey(ctx context.Context, handlerType, modelName, alt string, rawJSON []byte) string {
+	parts := []string{clientAPIKeyFromContext(ctx), handlerType, modelName, alt}
+	var header http.Header
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		header = ginCtx.Request.Header
+	}
+	for _, name := range dedupeKeyHeaders {
+		parts = append(parts, name+"="+strings.Join(header.Values(name), ","))
+	}
+	return requestHash(rawJSON, parts...)
+}
+
+// requestHash hashes the request body together with the given identifying parts.
+func requestHash(body []byte, parts ...string) string {
+	hasher := sha256.New()
+	for _, part := range parts {
+		hasher.Write([]byte(part))
+		hasher.Write([]byte{0})
+	}
+	hasher.Write(body)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// clientAPIKeyFromContext returns the authenticated client API key of the request, if any.
+func clientAPIKeyFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if value, exists := ginCtx.Get("apiKey"); exists && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type blockingExecutor struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *blockingExecutor) Identifier() string { return "dedupe-test" }
+
+func (e *blockingExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if e.calls.Add(1) == 1 {
+		close(e.started)
+	}
+	select {
+	case <-e.release:
+	case <-ctx.Done():
+		return coreexecutor.Response{}, ctx.Err()
+	}
+	coreusage.PublishRecord(ctx, coreusage.Record{Provider: "dedupe-test", Model: req.Model, Detail: coreusage.Detail{TotalTokens: 5}})
+	return coreexecutor.Response{Payload: []byte("ok")}, nil
+}
+
+func (e *blockingExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *blockingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *blockingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *blockingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteWithAuthManager_DedupesConcurrentIdenticalRequests(t *testing.T) {
+	executor := &blockingExecutor{started: make(chan struct{}), release: make(chan struct{})}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "dedupe-auth", Provider: "dedupe-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "dedupe-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{DedupeRequests: true}, manager)
+	hitsBefore := usage.GetRequestStatistics().Snapshot().Current.DedupeHits
+	body := []byte(`{"model":"dedupe-model","messages":[]}`)
+
+	results := make([]string, 2)
+	var wg sync.WaitGroup
+	run := func(i int) {
+		defer wg.Done()
+		resp, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "dedupe-model", body, "")
+		if errMsg != nil {
+			t.Errorf("request %d: unexpected error: %+v", i, errMsg)
+			return
+		}
+		results[i] = string(resp)
+	}
+	wg.Add(2)
+	go run(0)
+	<-executor.started
+	go run(1)
+	time.Sleep(50 * time.Millisecond)
+	close(executor.release)
+	wg.Wait()
+
+	if calls := executor.calls.Load(); calls != 1 {
+		t.Fatalf("upstream calls = %d, want 1", calls)
+	}
+	if results[0] != "ok" || results[1] != "ok" {
+		t.Fatalf("results = %q, want both ok", results)
+	}
+	if hits := usage.GetRequestStatistics().Snapshot().Current.DedupeHits - hitsBefore; hits != 1 {
+		t.Fatalf("dedupe hits = %d, want 1", hits)
+	}
+}
+
+func TestRequestDedupeKeySeparatesAPIKeysAndBodies(t *testing.T) {
+	ctxA, _ := fallbackTestContext()
+	ctxB, _ := fallbackTestContext()
+	ctxA.Value("gin").(*gin.Context).Set("apiKey", "key-a")
+	ctxB.Value("gin").(*gin.Context).Set("apiKey", "key-b")
+
+	body := []byte(`{"model":"m"}`)
+	if requestDedupeKey(ctxA, "openai", "m", "", body) == requestDedupeKey(ctxB, "openai", "m", "", body) {
+		t.Fatal("expected different keys for different API keys")
+	}
+	if requestDedupeKey(ctxA, "openai", "m", "", body) == requestDedupeKey(ctxA, "openai", "m", "", []byte(`{"model":"n"}`)) {
+		t.Fatal("expected different keys for different bodies")
+	}
+	if requestDedupeKey(ctxA, "openai", "m", "", body) != requestDedupeKey(ctxA, "openai", "m", "", body) {
+		t.Fatal("expected identical requests to share a key")
+	}
+}
+
+// dedupeUsagePlugin collects the usage records of one model.
+type dedupeUsagePlugin struct {
+	model   string
+	mu      sync.Mutex
+	records []coreusage.Record
+}
+
+func (p *dedupeUsagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	if record.Model != p.model {
+		return
+	}
+	p.mu.Lock()
+	p.records = append(p.records, record)
+	p.mu.Unlock()
+}
+
+func (p *dedupeUsagePlugin) snapshot() []coreusage.Record {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]coreusage.Record(nil), p.records...)
+}
+
+func TestExecuteWithAuthManager_DedupeSurvivesLeaderCancelAndRecordsEachCaller(t *testing.T) {
+	executor := &blockingExecutor{started: make(chan struct{}), release: make(chan struct{})}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "dedupe-cancel-auth", Provider: "dedupe-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "dedupe-cancel-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	plugin := &dedupeUsagePlugin{model: "dedupe-cancel-model"}
+	coreusage.RegisterPlugin(plugin)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{DedupeRequests: true}, manager)
+	body := []byte(`{"model":"dedupe-cancel-model","messages":[]}`)
+
+	leaderBase, _ := fallbackTestContext()
+	leaderCtx, cancelLeader := context.WithCancel(leaderBase)
+	followerCtx, _ := fallbackTestContext()
+	leaderCtx.Value("gin").(*gin.Context).Set("apiKey", "shared-key")
+	followerCtx.Value("gin").(*gin.Context).Set("apiKey", "shared-key")
+
+	errs := make([]string, 2)
+	var wg sync.WaitGroup
+	run := func(i int, ctx context.Context) {
+		defer wg.Done()
+		resp, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "dedupe-cancel-model", body, "")
+		if errMsg != nil {
+			errs[i] = errMsg.Error.Error()
+			return
+		}
+		errs[i] = string(resp)
+	}
+	wg.Add(2)
+	go run(0, leaderCtx)
+	<-executor.started
+	go run(1, followerCtx)
+	time.Sleep(50 * time.Millisecond)
+	cancelLeader()
+	time.Sleep(20 * time.Millisecond)
+	close(executor.release)
+	wg.Wait()
+
+	if errs[0] != "ok" || errs[1] != "ok" {
+		t.Fatalf("results = %q, want both ok after the leader's client went away", errs)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(plugin.snapshot()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	records := plugin.snapshot()
+	if len(records) != 2 {
+		t.Fatalf("usage records = %d, want one per caller", len(records))
+	}
+	for _, record := range records {
+		if record.Detail.TotalTokens != 5 {
+			t.Fatalf("record = %+v, want the shared call's usage", record)
+		}
+	}
+}
+
+func TestRequestDedupeKeySeparatesBehaviorHeaders(t *testing.T) {
+	key := func(header, value string) string {
+		ctx, _ := fallbackTestContext()
+		ginCtx := ctx.Value("gin").(*gin.Context)
+		ginCtx.Set("apiKey", "key-a")
+		if header != "" {
+			ginCtx.Request.Header.Set(header, value)
+		}
+		return requestDedupeKey(ctx, "claude", "m", "", []byte(`{"model":"m"}`))
+	}
+	base := key("", "")
+	for _, header := range []string{"Anthropic-Beta", "X-Session-ID", "Session_id", "X-Amp-Thread-Id"} {
+		if key(header, "v1") == base {
+			t.Fatalf("expected %s to separate dedupe keys", header)
+		}
+	}
+	if key("X-Session-ID", "conv-1") == key("X-Session-ID", "conv-2") {
+		t.Fatal("expected different sessions to use different keys")
+	}
+	if key("User-Agent", "a") != base {
+		t.Fatal("expected unrelated headers to share a key")
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/scheduler"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// acquireUpstreamSlot waits for a slot of the active request scheduler for the client API key of
+// the request. The returned context carries the queue time for usage records, and release frees
+// the slot once the upstream request is done. Requests that waited too long fail with 429.
+func acquireUpstreamSlot(ctx context.Context) (context.Context, func(), *interfaces.ErrorMessage) {
+	s := scheduler.Active()
+	if s == nil {
+		return ctx, func() {}, nil
+	}
+	release, wait, err := s.Acquire(ctx, clientAPIKeyFromContext(ctx))
+	usage.GetRequestStatistics().RecordQueueWait(wait)
+	if err != nil {
+		status := http.StatusRequestTimeout
+		var addon http.Header
+		if errors.Is(err, scheduler.ErrQueueTimeout) {
+			status = http.StatusTooManyRequests
+			addon = http.Header{"Retry-After": {"1"}}
+		}
+		return ctx, nil, &interfaces.ErrorMessage{StatusCode: status, Error: fmt.Errorf("request not scheduled after %s: %w", wait.Round(time.Millisecond), err), Addon: addon}
+	}
+	if wait > 0 {
+		ctx = scheduler.WithQueueTime(ctx, wait)
+	}
+	return ctx, release, nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/scheduler"
+)
+
+func TestAcquireUpstreamSlotRejectsAfterQueueTimeout(t *testing.T) {
+	previous := scheduler.Active()
+	t.Cleanup(func() { scheduler.SetActive(previous) })
+
+	scheduler.SetActive(nil)
+	if _, release, errMsg := acquireUpstreamSlot(context.Background()); errMsg != nil || release == nil {
+		t.Fatalf("expected a free slot without scheduler, got %v", errMsg)
+	}
+
+	s := scheduler.New(scheduler.Limits{MaxConcurrent: 1, MaxQueueWait: 10 * time.Millisecond}, nil)
+	scheduler.SetActive(s)
+	_, release, errMsg := acquireUpstreamSlot(context.Background())
+	if errMsg != nil {
+		t.Fatalf("expected first request to be admitted, got %v", errMsg)
+	}
+
+	_, _, errMsg = acquireUpstreamSlot(context.Background())
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the queue timeout, got %+v", errMsg)
+	}
+	if errMsg.Addon.Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After on queue timeout")
+	}
+
+	release()
+
+	// Without a queue timeout the request waits until a slot frees up.
+	s = scheduler.New(scheduler.Limits{MaxConcurrent: 1}, nil)
+	scheduler.SetActive(s)
+	_, release, _ = acquireUpstreamSlot(context.Background())
+	done := make(chan context.Context, 1)
+	go func() {
+		ctx, releaseQueued, errQueued := acquireUpstreamSlot(context.Background())
+		if errQueued != nil {
+			t.Errorf("expected queued request to be admitted, got %v", errQueued)
+			done <- nil
+			return
+		}
+		releaseQueued()
+		done <- ctx
+	}()
+	for {
+		if _, queued := s.Stats(); queued == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	release()
+	if ctx := <-done; ctx == nil || scheduler.QueueTime(ctx) <= 0 {
+		t.Fatal("expected the queue time on the context of a queued request")
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/context"
+)
+
+// proxyCacheHeader opts a request into ("true") or out of ("false") the response cache, and
+// reports HIT or MISS on cached responses.
+const proxyCacheHeader = "X-Proxy-Cache"
+
+// responseCacheKey returns the response cache key of a non-streaming request and whether the
+// request may be cached: the cache must be enabled and the request must either be deterministic
+// (temperature 0) or opt in with "X-Proxy-Cache: true".
+func responseCacheKey(ctx context.Context, handlerType, modelName, alt string, rawJSON []byte) (string, bool) {
+	if !cache.ResponseCacheEnabled() {
+		return "", false
+	}
+	optIn := ""
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			optIn = strings.ToLower(strings.TrimSpace(ginCtx.GetHeader(proxyCacheHeader)))
+		}
+	}
+	switch optIn {
+	case "true":
+	case "false":
+		return "", false
+	default:
+		if !deterministicRequest(rawJSON) {
+			return "", false
+		}
+	}
+	return requestHash(normalizeRequestJSON(rawJSON), clientAPIKeyFromContext(ctx), handlerType, modelName, alt), true
+}
+
+// deterministicRequest reports whether the request sets a sampling temperature of 0.
+func deterministicRequest(rawJSON []byte) bool {
+	for _, path := range []string{"temperature", "generationConfig.temperature", "request.generationConfig.temperature"} {
+		if temperature := gjson.GetBytes(rawJSON, path); temperature.Exists() {
+			return temperature.Type == gjson.Number && temperature.Float() == 0
+		}
+	}
+	return false
+}
+
+// normalizeRequestJSON re-encodes the body with sorted keys and no insignificant whitespace so
+// equivalent requests share a cache key. Bodies that are not valid JSON are returned unchanged.
+func normalizeRequestJSON(rawJSON []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(rawJSON))
+	decoder.UseNumber()
+	var body any
+	if errDecode := decoder.Decode(&body); errDecode != nil {
+		return rawJSON
+	}
+	normalized, errMarshal := json.Marshal(body)
+	if errMarshal != nil {
+		return rawJSON
+	}
+	return normalized
+}
+
+// setProxyCacheHeader reports the response cache outcome in the X-Proxy-Cache response header.
+func setProxyCacheHeader(ctx context.Context, outcome string) {
+	setResponseHeader(ctx, proxyCacheHeader, outcome)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+)
+
+func TestExecuteWithAuthManager_ServesDeterministicRequestsFromCache(t *testing.T) {
+	handler, executor := newFallbackTestHandler(t)
+	cache.SetResponseCache(cache.NewMemoryResponseStore(10), cache.ResponseCacheSettings{TTL: time.Minute})
+	t.Cleanup(func() { cache.SetResponseCache(nil, cache.ResponseCacheSettings{}) })
+
+	first := []byte(`{"model":"backup-model","temperature":0,"messages":[]}`)
+	reordered := []byte(`{"messages": [], "temperature": 0, "model": "backup-model"}`)
+	for i, body := range [][]byte{first, reordered} {
+		ctx, recorder := fallbackTestContext()
+		resp, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "backup-model", body, "")
+		if errMsg != nil {
+			t.Fatalf("request %d: unexpected error: %+v", i, errMsg)
+		}
+		if string(resp) != "backup-model" {
+			t.Fatalf("request %d: payload = %q", i, resp)
+		}
+		want := []string{"MISS", "HIT"}[i]
+		if got := recorder.Header().Get(proxyCacheHeader); got != want {
+			t.Fatalf("request %d: %s = %q, want %q", i, proxyCacheHeader, got, want)
+		}
+	}
+	if calls := len(executor.models); calls != 1 {
+		t.Fatalf("upstream calls = %d, want 1", calls)
+	}
+}
+
+func TestResponseCacheKeyRequiresDeterministicRequestOrOptIn(t *testing.T) {
+	cache.SetResponseCache(cache.NewMemoryResponseStore(10), cache.ResponseCacheSettings{TTL: time.Minute})
+	t.Cleanup(func() { cache.SetResponseCache(nil, cache.ResponseCacheSettings{}) })
+
+	ctx, _ := fallbackTestContext()
+	if _, ok := responseCacheKey(ctx, "openai", "m", "", []byte(`{"temperature":0.7}`)); ok {
+		t.Fatal("expected sampled request to bypass the cache")
+	}
+	if _, ok := responseCacheKey(ctx, "gemini", "m", "", []byte(`{"generationConfig":{"temperature":0}}`)); !ok {
+		t.Fatal("expected Gemini request with temperature 0 to be cacheable")
+	}
+
+	optIn, _ := fallbackTestContext()
+	optIn.Value("gin").(*gin.Context).Request.Header.Set(proxyCacheHeader, "true")
+	if _, ok := responseCacheKey(optIn, "openai", "m", "", []byte(`{"temperature":0.7}`)); !ok {
+		t.Fatal("expected X-Proxy-Cache: true to opt in")
+	}
+	optOut, _ := fallbackTestContext()
+	optOut.Value("gin").(*gin.Context).Request.Header.Set(proxyCacheHeader, "false")
+	if _, ok := responseCacheKey(optOut, "openai", "m", "", []byte(`{"temperature":0}`)); ok {
+		t.Fatal("expected X-Proxy-Cache: false to opt out")
+	}
+}
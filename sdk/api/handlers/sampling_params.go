@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// droppedParamsHeader lists the OpenAI sampling parameters removed from, or approximated in,
+// the upstream request, e.g. "logit_bias=dropped, frequency_penalty=approximated".
+const droppedParamsHeader = "X-Proxy-Dropped-Params"
+
+// unsupportedSamplingParams are the OpenAI chat parameters, in header order, that translated
+// providers cannot honor.
+var unsupportedSamplingParams = []string{"logit_bias", "frequency_penalty", "presence_penalty", "seed"}
+
+// samplingDroppingProviders translate OpenAI requests into formats without logit_bias,
+// penalties or seed. OpenAI-compatible providers and Kimi receive them as-is.
+var samplingDroppingProviders = map[string]struct{}{
+	"aistudio":    {},
+	"antigravity": {},
+	"claude":      {},
+	"codex":       {},
+	"gemini":      {},
+	"gemini-cli":  {},
+	"vertex":      {},
+}
+
+// applyUnsupportedParams enforces the unsupported-params policy on OpenAI chat requests routed
+// to providers that would silently drop logit_bias, frequency_penalty, presence_penalty or
+// seed. Each parameter is dropped (the default), rejected with a 400 error, or, for penalties,
+// approximated by a temperature adjustment; dropped and approximated parameters are reported
+// in the X-Proxy-Dropped-Params response header. Zero penalties and empty logit_bias maps are
+// removed without being reported since they have no effect.
+func (h *BaseAPIHandler) applyUnsupportedParams(ctx context.Context, handlerType, modelName string, providers []string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	if handlerType != "openai" || !slices.ContainsFunc(providers, func(provider string) bool {
+		_, drops := samplingDroppingProviders[provider]
+		return drops
+	}) {
+		return rawJSON, nil
+	}
+	out := rawJSON
+	var reported []string
+	var penalty float64
+	approximated := false
+	for _, param := range unsupportedSamplingParams {
+		value := gjson.GetBytes(rawJSON, param)
+		if !value.Exists() || value.Type == gjson.Null {
+			continue
+		}
+		out, _ = sjson.DeleteBytes(out, param)
+		if (value.Type == gjson.Number && value.Float() == 0) || (value.IsObject() && len(value.Map()) == 0) {
+			continue
+		}
+		action := "drop"
+		if h.Cfg != nil && h.Cfg.UnsupportedParams[param] != "" {
+			action = h.Cfg.UnsupportedParams[param]
+		}
+		switch {
+		case action == "reject":
+			return nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusBadRequest,
+				Error:      fmt.Errorf("%s is not supported by model %s", param, modelName),
+			}
+		case action == "approximate" && value.Type == gjson.Number:
+			penalty += value.Float()
+			approximated = true
+			reported = append(reported, param+"=approximated")
+		default:
+			reported = append(reported, param+"=dropped")
+		}
+	}
+	if approximated {
+		out = approximatePenalties(out, penalty)
+	}
+	if len(reported) > 0 {
+		setResponseHeader(ctx, droppedParamsHeader, strings.Join(reported, ", "))
+	}
+	return out, nil
+}
+
+// approximatePenalties folds the summed frequency and presence penalties (each -2 to 2) into the
+// temperature: positive penalties discourage repetition, approximated by sampling more broadly,
+// and negative ones by sampling more narrowly. A quarter of the penalty sum is added to the
+// temperature (1 when unset), kept within OpenAI's 0-2 range.
+func approximatePenalties(rawJSON []byte, penalty float64) []byte {
+	temperature := 1.0
+	if current := gjson.GetBytes(rawJSON, "temperature"); current.Type == gjson.Number {
+		temperature = current.Float()
+	}
+	adjusted := math.Round(min(max(temperature+penalty/4, 0), 2)*100) / 100
+	out, _ := sjson.SetBytes(rawJSON, "temperature", adjusted)
+	return out
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyUnsupportedParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     map[string]string
+		providers  []string
+		body       string
+		wantHeader string
+		wantBody   string
+	}{
+		{
+			name:       "drops by default",
+			providers:  []string{"claude"},
+			body:       `{"model":"m","logit_bias":{"50256":-100},"seed":7,"temperature":0.5}`,
+			wantHeader: "logit_bias=dropped, seed=dropped",
+			wantBody:   `{"model":"m","temperature":0.5}`,
+		},
+		{
+			name:      "removes no-op values silently",
+			providers: []string{"gemini"},
+			body:      `{"model":"m","logit_bias":{},"frequency_penalty":0,"presence_penalty":null}`,
+			wantBody:  `{"model":"m","presence_penalty":null}`,
+		},
+		{
+			name:       "approximates penalties with temperature",
+			policy:     map[string]string{"frequency_penalty": "approximate", "presence_penalty": "approximate"},
+			providers:  []string{"codex"},
+			body:       `{"model":"m","frequency_penalty":0.6,"presence_penalty":0.4,"temperature":0.7}`,
+			wantHeader: "frequency_penalty=approximated, presence_penalty=approximated",
+			wantBody:   `{"model":"m","temperature":0.95}`,
+		},
+		{
+			name:       "approximation clamps temperature",
+			policy:     map[string]string{"frequency_penalty": "approximate"},
+			providers:  []string{"claude"},
+			body:       `{"model":"m","frequency_penalty":-2,"temperature":0.2}`,
+			wantHeader: "frequency_penalty=approximated",
+			wantBody:   `{"model":"m","temperature":0}`,
+		},
+		{
+			name:      "passes through for openai-compatible providers",
+			policy:    map[string]string{"seed": "reject"},
+			providers: []string{"openrouter"},
+			body:      `{"model":"m","seed":7,"frequency_penalty":1}`,
+			wantBody:  `{"model":"m","seed":7,"frequency_penalty":1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{UnsupportedParams: tt.policy}, nil)
+			ctx, recorder := fallbackTestContext()
+
+			out, errMsg := handler.applyUnsupportedParams(ctx, "openai", "m", tt.providers, []byte(tt.body))
+			if errMsg != nil {
+				t.Fatalf("unexpected error: %+v", errMsg)
+			}
+			if string(out) != tt.wantBody {
+				t.Fatalf("body = %s, want %s", out, tt.wantBody)
+			}
+			if got := recorder.Header().Get(droppedParamsHeader); got != tt.wantHeader {
+				t.Fatalf("%s = %q, want %q", droppedParamsHeader, got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestApplyUnsupportedParams_Rejects(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{UnsupportedParams: map[string]string{"seed": "reject"}}, nil)
+	ctx, _ := fallbackTestContext()
+
+	_, errMsg := handler.applyUnsupportedParams(ctx, "openai", "m", []string{"claude"}, []byte(`{"model":"m","seed":7}`))
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %+v", errMsg)
+	}
+
+	body := []byte(`{"model":"m","seed":7}`)
+	out, errMsg := handler.applyUnsupportedParams(ctx, "claude", "m", []string{"claude"}, body)
+	if errMsg != nil || !gjson.GetBytes(out, "seed").Exists() {
+		t.Fatalf("non-OpenAI handler should pass through, got %s, %+v", out, errMsg)
+	}
+}
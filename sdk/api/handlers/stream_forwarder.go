@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	log "github.com/sirupsen/logrus"
 )
 
 type StreamForwardOptions struct {
@@ -53,6 +55,7 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 	if opts.KeepAliveInterval != nil {
 		keepAliveInterval = *opts.KeepAliveInterval
 	}
+	// Keep-alives are only written while the stream is idle: the ticker restarts on every chunk.
 	var keepAlive *time.Ticker
 	var keepAliveC <-chan time.Time
 	if keepAliveInterval > 0 {
@@ -60,6 +63,14 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 		defer keepAlive.Stop()
 		keepAliveC = keepAlive.C
 	}
+	idleTimeout := StreamingIdleTimeout(h.Cfg)
+	var idle *time.Timer
+	var idleC <-chan time.Time
+	if idleTimeout > 0 {
+		idle = time.NewTimer(idleTimeout)
+		defer idle.Stop()
+		idleC = idle.C
+	}
 
 	var terminalErr *interfaces.ErrorMessage
 	for {
@@ -96,6 +107,12 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 			}
 			writeChunk(chunk)
 			flusher.Flush()
+			if keepAlive != nil {
+				keepAlive.Reset(keepAliveInterval)
+			}
+			if idle != nil {
+				idle.Reset(idleTimeout)
+			}
 		case errMsg, ok := <-errs:
 			if !ok {
 				continue
@@ -116,6 +133,15 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 		case <-keepAliveC:
 			writeKeepAlive()
 			flusher.Flush()
+		case <-idleC:
+			errIdle := fmt.Errorf("stream idle timeout: no upstream data for %s", idleTimeout)
+			log.WithField("path", c.Request.URL.Path).Warn(errIdle.Error())
+			if opts.WriteTerminalError != nil {
+				opts.WriteTerminalError(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errIdle})
+			}
+			flusher.Flush()
+			cancel(errIdle)
+			return
 		}
 	}
 }
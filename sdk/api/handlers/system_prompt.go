@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// systemPromptEdit is the combined effect of the matching system prompt rules.
+type systemPromptEdit struct {
+	pre        []string
+	post       []string
+	keepClient bool
+}
+
+// applySystemPromptRules rewrites the client's system prompt with the matching
+// system-prompt-rules. It runs on the client format, before translation and before executors add
+// cache_control breakpoints, so prepended text stays a stable prefix of the cached prompt.
+func (h *BaseAPIHandler) applySystemPromptRules(ctx context.Context, handlerType, modelName string, rawJSON []byte) []byte {
+	if h.Cfg == nil || len(h.Cfg.SystemPromptRules) == 0 {
+		return rawJSON
+	}
+	apiKey := clientAPIKeyFromContext(ctx)
+	edit := systemPromptEdit{keepClient: true}
+	matched := false
+	for i := range h.Cfg.SystemPromptRules {
+		rule := &h.Cfg.SystemPromptRules[i]
+		if !rule.Matches(modelName, apiKey) {
+			continue
+		}
+		matched = true
+		switch rule.Action {
+		case config.SystemPromptReplace:
+			edit = systemPromptEdit{pre: []string{rule.Text}}
+		case config.SystemPromptAppend:
+			edit.post = append(edit.post, rule.Text)
+		default:
+			edit.pre = append([]string{rule.Text}, edit.pre...)
+		}
+	}
+	if !matched {
+		return rawJSON
+	}
+	var out []byte
+	switch handlerType {
+	case "openai":
+		out = editOpenAISystemPrompt(rawJSON, edit)
+	case "openai-response":
+		out = editResponsesSystemPrompt(rawJSON, edit)
+	case "claude":
+		out = editClaudeSystemPrompt(rawJSON, edit)
+	case "gemini":
+		out = editGeminiSystemPrompt(rawJSON, "", edit)
+	case "gemini-cli":
+		out = editGeminiSystemPrompt(rawJSON, "request.", edit)
+	default:
+		return rawJSON
+	}
+	if out == nil {
+		return rawJSON
+	}
+	return out
+}
+
+// editOpenAISystemPrompt inserts the prepended text as a system message before the conversation
+// and the appended text after the leading system messages.
+func editOpenAISystemPrompt(rawJSON []byte, edit systemPromptEdit) []byte {
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		return nil
+	}
+	var head, rest []json.RawMessage
+	leading := true
+	for _, message := range messages.Array() {
+		system := isSystemRole(message.Get("role").String())
+		if system && !edit.keepClient {
+			continue
+		}
+		if leading && system {
+			head = append(head, json.RawMessage(message.Raw))
+			continue
+		}
+		leading = false
+		rest = append(rest, json.RawMessage(message.Raw))
+	}
+	out := make([]json.RawMessage, 0, len(head)+len(rest)+2)
+	if len(edit.pre) > 0 {
+		out = append(out, json.RawMessage(roleContentMessage("system", strings.Join(edit.pre, "\n\n"))))
+	}
+	out = append(out, head...)
+	if len(edit.post) > 0 {
+		out = append(out, json.RawMessage(roleContentMessage("system", strings.Join(edit.post, "\n\n"))))
+	}
+	out = append(out, rest...)
+	return setRawJSON(rawJSON, "messages", out)
+}
+
+// editResponsesSystemPrompt rewrites the instructions field of a Responses API request. Replacing
+// also drops system and developer input items.
+func editResponsesSystemPrompt(rawJSON []byte, edit systemPromptEdit) []byte {
+	parts := append([]string(nil), edit.pre...)
+	if edit.keepClient {
+		if instructions := gjson.GetBytes(rawJSON, "instructions").String(); strings.TrimSpace(instructions) != "" {
+			parts = append(parts, instructions)
+		}
+	}
+	parts = append(parts, edit.post...)
+	out, errSet := sjson.SetBytes(rawJSON, "instructions", strings.Join(parts, "\n\n"))
+	if errSet != nil {
+		return nil
+	}
+	input := gjson.GetBytes(out, "input")
+	if edit.keepClient || !input.IsArray() {
+		return out
+	}
+	kept := make([]json.RawMessage, 0, len(input.Array()))
+	for _, item := range input.Array() {
+		if !isSystemRole(item.Get("role").String()) {
+			kept = append(kept, json.RawMessage(item.Raw))
+		}
+	}
+	return setRawJSON(out, "input", kept)
+}
+
+// editClaudeSystemPrompt adds text blocks around the Claude system blocks, converting a string
+// system prompt to a block.
+func editClaudeSystemPrompt(rawJSON []byte, edit systemPromptEdit) []byte {
+	var blocks []json.RawMessage
+	for _, text := range edit.pre {
+		blocks = append(blocks, textBlock("type", "text", text))
+	}
+	if edit.keepClient {
+		system := gjson.GetBytes(rawJSON, "system")
+		switch {
+		case system.IsArray():
+			for _, block := range system.Array() {
+				blocks = append(blocks, json.RawMessage(block.Raw))
+			}
+		case system.Type == gjson.String && system.String() != "":
+			blocks = append(blocks, textBlock("type", "text", system.String()))
+		}
+	}
+	for _, text := range edit.post {
+		blocks = append(blocks, textBlock("type", "text", text))
+	}
+	return setRawJSON(rawJSON, "system", blocks)
+}
+
+// editGeminiSystemPrompt adds text parts around the system instruction parts.
+func editGeminiSystemPrompt(rawJSON []byte, root string, edit systemPromptEdit) []byte {
+	path := root + "systemInstruction"
+	if !gjson.GetBytes(rawJSON, path).Exists() && gjson.GetBytes(rawJSON, root+"system_instruction").Exists() {
+		path = root + "system_instruction"
+	}
+	var parts []json.RawMessage
+	for _, text := range edit.pre {
+		parts = append(parts, textBlock("", "", text))
+	}
+	if edit.keepClient {
+		for _, part := range gjson.GetBytes(rawJSON, path+".parts").Array() {
+			parts = append(parts, json.RawMessage(part.Raw))
+		}
+	}
+	for _, text := range edit.post {
+		parts = append(parts, textBlock("", "", text))
+	}
+	return setRawJSON(rawJSON, path+".parts", parts)
+}
+
+// textBlock returns {"text": text}, with typeKey set to typeValue when typeKey is not empty.
+func textBlock(typeKey, typeValue, text string) json.RawMessage {
+	block := `{}`
+	if typeKey != "" {
+		block, _ = sjson.Set(block, typeKey, typeValue)
+	}
+	block, _ = sjson.Set(block, "text", text)
+	return json.RawMessage(block)
+}
+
+func setRawJSON(rawJSON []byte, path string, value []json.RawMessage) []byte {
+	if value == nil {
+		value = []json.RawMessage{}
+	}
+	encoded, errMarshal := json.Marshal(value)
+	if errMarshal != nil {
+		return nil
+	}
+	out, errSet := sjson.SetRawBytes(rawJSON, path, encoded)
+	if errSet != nil {
+		return nil
+	}
+	return out
+}
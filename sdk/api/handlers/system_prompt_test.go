@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestApplySystemPromptRules(t *testing.T) {
+	rules := []sdkconfig.SystemPromptRule{
+		{Action: "prepend", Text: "P"},
+		{Models: []string{"claude-*"}, Action: "append", Text: "A"},
+		{APIKeys: []string{"key-r"}, Action: "replace", Text: "R"},
+	}
+	tests := []struct {
+		name        string
+		handlerType string
+		model       string
+		apiKey      string
+		body        string
+		want        string
+	}{
+		{
+			name:        "openai prepends before and appends after leading system messages",
+			handlerType: "openai",
+			model:       "claude-sonnet-4-5",
+			body:        `{"messages":[{"role":"system","content":"S"},{"role":"user","content":"hi"}]}`,
+			want:        `{"messages":[{"role":"system","content":"P"},{"role":"system","content":"S"},{"role":"system","content":"A"},{"role":"user","content":"hi"}]}`,
+		},
+		{
+			name:        "claude converts a string system prompt to blocks",
+			handlerType: "claude",
+			model:       "claude-sonnet-4-5(high)",
+			body:        `{"system":"S","messages":[]}`,
+			want:        `{"system":[{"type":"text","text":"P"},{"type":"text","text":"S"},{"type":"text","text":"A"}],"messages":[]}`,
+		},
+		{
+			name:        "responses instructions",
+			handlerType: "openai-response",
+			model:       "gpt-5",
+			body:        `{"instructions":"S","input":"hi"}`,
+			want:        `{"instructions":"P\n\nS","input":"hi"}`,
+		},
+		{
+			name:        "gemini system instruction parts",
+			handlerType: "gemini",
+			model:       "gemini-2.5-pro",
+			body:        `{"contents":[]}`,
+			want:        `{"contents":[],"systemInstruction":{"parts":[{"text":"P"}]}}`,
+		},
+		{
+			name:        "replace discards the client prompt and earlier rules",
+			handlerType: "openai",
+			model:       "gpt-5",
+			apiKey:      "key-r",
+			body:        `{"messages":[{"role":"system","content":"S"},{"role":"user","content":"hi"},{"role":"developer","content":"D"}]}`,
+			want:        `{"messages":[{"role":"system","content":"R"},{"role":"user","content":"hi"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{SystemPromptRules: rules}, nil)
+			ctx, _ := fallbackTestContext()
+			ctx.Value("gin").(*gin.Context).Set("apiKey", tt.apiKey)
+
+			out := handler.applySystemPromptRules(ctx, tt.handlerType, tt.model, []byte(tt.body))
+			if string(out) != tt.want {
+				t.Fatalf("body = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
@@ -100,7 +100,7 @@ func (l *authAutoRefreshLoop) rebuild(now time.Time) {
 
 	l.manager.mu.RLock()
 	for id, auth := range l.manager.auths {
-		next, ok := nextRefreshCheckAt(now, auth, l.interval)
+		next, ok := nextRefreshCheckAt(now, auth, l.interval, l.manager.refreshJitter(auth))
 		if !ok {
 			continue
 		}
@@ -231,7 +231,7 @@ func (l *authAutoRefreshLoop) handleDueAuth(ctx context.Context, now time.Time,
 		manager.mu.RUnlock()
 		return
 	}
-	next, shouldSchedule := nextRefreshCheckAt(now, auth, l.interval)
+	next, shouldSchedule := nextRefreshCheckAt(now, auth, l.interval, l.manager.refreshJitter(auth))
 	shouldRefresh := manager.shouldRefresh(auth, now)
 	exec := manager.executors[auth.Provider]
 	manager.mu.RUnlock()
@@ -254,7 +254,7 @@ func (l *authAutoRefreshLoop) handleDueAuth(ctx context.Context, now time.Time,
 	if !manager.markRefreshPending(authID, now) {
 		manager.mu.RLock()
 		auth = manager.auths[authID]
-		next, shouldSchedule = nextRefreshCheckAt(now, auth, l.interval)
+		next, shouldSchedule = nextRefreshCheckAt(now, auth, l.interval, l.manager.refreshJitter(auth))
 		manager.mu.RUnlock()
 		if shouldSchedule {
 			l.upsert(authID, next)
@@ -280,7 +280,7 @@ func (l *authAutoRefreshLoop) applyDirty(now time.Time) {
 	for _, authID := range dirty {
 		l.manager.mu.RLock()
 		auth := l.manager.auths[authID]
-		next, ok := nextRefreshCheckAt(now, auth, l.interval)
+		next, ok := nextRefreshCheckAt(now, auth, l.interval, l.manager.refreshJitter(auth))
 		l.manager.mu.RUnlock()
 
 		if !ok {
@@ -335,7 +335,9 @@ func (l *authAutoRefreshLoop) remove(authID string) {
 	delete(l.index, authID)
 }
 
-func nextRefreshCheckAt(now time.Time, auth *Auth, interval time.Duration) (time.Time, bool) {
+// nextRefreshCheckAt returns when auth should next be checked for refresh. jitter moves the
+// expiry-based refresh of the credential earlier, see Manager.refreshJitter.
+func nextRefreshCheckAt(now time.Time, auth *Auth, interval, jitter time.Duration) (time.Time, bool) {
 	if auth == nil || auth.Disabled {
 		return time.Time{}, false
 	}
@@ -395,7 +397,7 @@ func nextRefreshCheckAt(now time.Time, auth *Auth, interval time.Duration) (time
 		return time.Time{}, false
 	}
 	if hasExpiry && !expiry.IsZero() {
-		dueAt := expiry.Add(-*lead)
+		dueAt := expiry.Add(-*lead - jitter)
 		if !dueAt.After(now) {
 			return now, true
 		}
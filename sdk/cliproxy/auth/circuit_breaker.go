@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitCooldown         = 30 * time.Second
+
+	// circuitOpenErrorCode marks the error returned when every candidate credential has an open circuit.
+	circuitOpenErrorCode = "circuit_open"
+)
+
+// CircuitState is the state of a credential's circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed lets requests through and counts consecutive failures.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects requests until the cool-down elapses.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe request through to decide whether to close again.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreakerStatus is a snapshot of one credential's circuit breaker.
+type CircuitBreakerStatus struct {
+	AuthID              string       `json:"auth_id"`
+	Provider            string       `json:"provider"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	LastError           string       `json:"last_error,omitempty"`
+	OpenedAt            time.Time    `json:"opened_at,omitzero"`
+	RetryAt             time.Time    `json:"retry_at,omitzero"`
+}
+
+type circuitEntry struct {
+	provider            string
+	state               CircuitState
+	consecutiveFailures int
+	lastError           string
+	openedAt            time.Time
+	retryAt             time.Time
+	probeStartedAt      time.Time
+}
+
+// circuitBreaker tracks consecutive upstream failures per credential. A circuit opens after
+// the configured number of failures, rejects the credential for the cool-down and then admits
+// one probe request; the probe's outcome closes or re-opens the circuit.
+type circuitBreaker struct {
+	mu      sync.Mutex
+	entries map[string]*circuitEntry
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{entries: make(map[string]*circuitEntry)}
+}
+
+// circuitSettings resolves the breaker configuration; ok is false when the breaker is disabled.
+func (m *Manager) circuitSettings() (threshold int, cooldown time.Duration, ok bool) {
+	if m == nil || m.circuit == nil {
+		return 0, 0, false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.CircuitBreaker.Enabled {
+		return 0, 0, false
+	}
+	threshold = cfg.CircuitBreaker.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultCircuitFailureThreshold
+	}
+	cooldown = time.Duration(cfg.CircuitBreaker.Cooldown) * time.Second
+	if cooldown <= 0 {
+		cooldown = defaultCircuitCooldown
+	}
+	return threshold, cooldown, true
+}
+
+// acquireCircuit reports whether a request may use authID. An open circuit whose cool-down has
+// elapsed moves to half-open and admits the caller as its probe; further callers are rejected
+// until the probe reports back or itself times out after one cool-down.
+func (m *Manager) acquireCircuit(authID string, now time.Time) bool {
+	_, cooldown, ok := m.circuitSettings()
+	if !ok {
+		return true
+	}
+	cb := m.circuit
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	entry := cb.entries[authID]
+	if entry == nil {
+		return true
+	}
+	switch entry.state {
+	case CircuitOpen:
+		if now.Before(entry.retryAt) {
+			return false
+		}
+		entry.state = CircuitHalfOpen
+		entry.probeStartedAt = now
+		return true
+	case CircuitHalfOpen:
+		if !entry.probeStartedAt.IsZero() && now.Sub(entry.probeStartedAt) < cooldown {
+			return false
+		}
+		entry.probeStartedAt = now
+		return true
+	default:
+		return true
+	}
+}
+
+// recordCircuitResult updates the breaker for the credential that produced result.
+func (m *Manager) recordCircuitResult(result Result, now time.Time) {
+	threshold, cooldown, ok := m.circuitSettings()
+	if !ok || result.AuthID == "" {
+		return
+	}
+	cb := m.circuit
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	entry := cb.entries[result.AuthID]
+	if result.Success {
+		if entry != nil {
+			delete(cb.entries, result.AuthID)
+		}
+		return
+	}
+	if !countsTowardCircuit(result.Error) {
+		if entry != nil && entry.state == CircuitHalfOpen {
+			// The probe was inconclusive; admit another one.
+			entry.probeStartedAt = time.Time{}
+		}
+		return
+	}
+	if entry == nil {
+		entry = &circuitEntry{state: CircuitClosed}
+		cb.entries[result.AuthID] = entry
+	}
+	entry.provider = result.Provider
+	entry.consecutiveFailures++
+	if result.Error != nil {
+		entry.lastError = result.Error.Message
+	}
+	if entry.state == CircuitHalfOpen || entry.consecutiveFailures >= threshold {
+		entry.state = CircuitOpen
+		entry.openedAt = now
+		entry.retryAt = now.Add(cooldown)
+		entry.probeStartedAt = time.Time{}
+	}
+}
+
+// countsTowardCircuit reports whether a failure reflects the health of the credential rather
+// than the request: auth failures, timeouts, server errors, overload and transport errors.
+func countsTowardCircuit(err *Error) bool {
+	if err == nil {
+		return true
+	}
+	if isModelSupportResultError(err) || isRequestScopedNotFoundResultError(err) {
+		return false
+	}
+	status := err.StatusCode()
+	switch {
+	case status == 0:
+		return true
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusRequestTimeout:
+		return true
+	case status >= http.StatusInternalServerError:
+		return !isRequestInvalidError(err)
+	default:
+		return false
+	}
+}
+
+// circuitOpenError is returned when every candidate credential was skipped by an open circuit.
+func (m *Manager) circuitOpenError(skipped map[string]struct{}) error {
+	var retryAt time.Time
+	if m != nil && m.circuit != nil {
+		m.circuit.mu.Lock()
+		for authID := range skipped {
+			entry := m.circuit.entries[authID]
+			if entry == nil || entry.retryAt.IsZero() {
+				continue
+			}
+			if retryAt.IsZero() || entry.retryAt.Before(retryAt) {
+				retryAt = entry.retryAt
+			}
+		}
+		m.circuit.mu.Unlock()
+	}
+	message := "all matching credentials are failing upstream; circuit breaker is open"
+	if !retryAt.IsZero() {
+		message = fmt.Sprintf("%s, next probe in %s", message, max(time.Until(retryAt), 0).Round(time.Second))
+	}
+	return &Error{Code: circuitOpenErrorCode, Message: message, HTTPStatus: http.StatusServiceUnavailable}
+}
+
+func isCircuitOpenError(err error) bool {
+	var authErr *Error
+	return errors.As(err, &authErr) && authErr != nil && authErr.Code == circuitOpenErrorCode
+}
+
+// CircuitBreakerStatuses returns the credentials whose breaker has recorded failures, sorted by
+// provider and auth ID. Credentials without failures are closed and omitted.
+func (m *Manager) CircuitBreakerStatuses() []CircuitBreakerStatus {
+	if m == nil || m.circuit == nil {
+		return nil
+	}
+	now := time.Now()
+	m.circuit.mu.Lock()
+	out := make([]CircuitBreakerStatus, 0, len(m.circuit.entries))
+	for authID, entry := range m.circuit.entries {
+		state := entry.state
+		if state == CircuitOpen && !now.Before(entry.retryAt) {
+			state = CircuitHalfOpen
+		}
+		out = append(out, CircuitBreakerStatus{
+			AuthID:              authID,
+			Provider:            entry.provider,
+			State:               state,
+			ConsecutiveFailures: entry.consecutiveFailures,
+			LastError:           entry.lastError,
+			OpenedAt:            entry.openedAt,
+			RetryAt:             entry.retryAt,
+		})
+	}
+	m.circuit.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].AuthID < out[j].AuthID
+	})
+	return out
+}
+
+// ResetCircuitBreaker closes the breaker of authID, or of every credential when authID is
+// empty, and returns the number of breakers reset.
+func (m *Manager) ResetCircuitBreaker(authID string) int {
+	if m == nil || m.circuit == nil {
+		return 0
+	}
+	m.circuit.mu.Lock()
+	defer m.circuit.mu.Unlock()
+	if authID == "" {
+		count := len(m.circuit.entries)
+		clear(m.circuit.entries)
+		return count
+	}
+	if _, ok := m.circuit.entries[authID]; !ok {
+		return 0
+	}
+	delete(m.circuit.entries, authID)
+	return 1
+}
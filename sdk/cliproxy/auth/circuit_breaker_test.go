@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type perAuthExecutor struct {
+	mu      sync.Mutex
+	failing map[string]bool
+	calls   map[string]int
+}
+
+func (e *perAuthExecutor) Identifier() string { return "claude" }
+
+func (e *perAuthExecutor) Execute(_ context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls[auth.ID]++
+	if e.failing[auth.ID] {
+		return cliproxyexecutor.Response{}, &Error{HTTPStatus: 529, Message: "overloaded_error"}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *perAuthExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, &Error{HTTPStatus: http.StatusNotImplemented, Message: "not implemented"}
+}
+
+func (e *perAuthExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *perAuthExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusNotImplemented, Message: "not implemented"}
+}
+
+func (e *perAuthExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func (e *perAuthExecutor) setFailing(authID string, failing bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failing[authID] = failing
+}
+
+func (e *perAuthExecutor) Calls(authID string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls[authID]
+}
+
+func newCircuitTestManager(t *testing.T, authCount int) (*Manager, *perAuthExecutor, []string) {
+	t.Helper()
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.SetConfig(&internalconfig.Config{CircuitBreaker: internalconfig.CircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 2,
+		Cooldown:         60,
+	}})
+	executor := &perAuthExecutor{failing: make(map[string]bool), calls: make(map[string]int)}
+	m.RegisterExecutor(executor)
+
+	reg := registry.GetGlobalRegistry()
+	ids := make([]string, 0, authCount)
+	for i := 0; i < authCount; i++ {
+		auth := &Auth{ID: uuid.NewString(), Provider: "claude"}
+		reg.RegisterClient(auth.ID, "claude", []*registry.ModelInfo{{ID: "test-model"}})
+		t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register auth: %v", errRegister)
+		}
+		ids = append(ids, auth.ID)
+	}
+	return m, executor, ids
+}
+
+func TestManager_CircuitBreaker_OpensAndFailsFast(t *testing.T) {
+	m, executor, ids := newCircuitTestManager(t, 1)
+	executor.setFailing(ids[0], true)
+	request := cliproxyexecutor.Request{Model: "test-model"}
+
+	for i := 0; i < 2; i++ {
+		if _, errExecute := m.Execute(context.Background(), []string{"claude"}, request, cliproxyexecutor.Options{}); errExecute == nil {
+			t.Fatalf("attempt %d: expected upstream error", i)
+		}
+	}
+	statuses := m.CircuitBreakerStatuses()
+	if len(statuses) != 1 || statuses[0].State != CircuitOpen || statuses[0].ConsecutiveFailures != 2 {
+		t.Fatalf("statuses = %+v, want one open circuit with 2 failures", statuses)
+	}
+
+	_, errExecute := m.Execute(context.Background(), []string{"claude"}, request, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(errExecute, &authErr) || authErr.Code != circuitOpenErrorCode || authErr.HTTPStatus != http.StatusServiceUnavailable {
+		t.Fatalf("error = %v, want circuit_open 503", errExecute)
+	}
+	if calls := executor.Calls(ids[0]); calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2 (open circuit must not reach upstream)", calls)
+	}
+
+	if reset := m.ResetCircuitBreaker(ids[0]); reset != 1 {
+		t.Fatalf("ResetCircuitBreaker() = %d, want 1", reset)
+	}
+	if statuses := m.CircuitBreakerStatuses(); len(statuses) != 0 {
+		t.Fatalf("statuses after reset = %+v, want none", statuses)
+	}
+}
+
+func TestManager_CircuitBreaker_SkipsToNextCredential(t *testing.T) {
+	m, executor, ids := newCircuitTestManager(t, 2)
+	executor.setFailing(ids[0], true)
+	executor.setFailing(ids[1], true)
+	request := cliproxyexecutor.Request{Model: "test-model"}
+
+	// Two requests each fail on both credentials, opening both circuits.
+	for i := 0; i < 2; i++ {
+		_, _ = m.Execute(context.Background(), []string{"claude"}, request, cliproxyexecutor.Options{})
+	}
+	// Close the second credential's circuit and let it recover upstream.
+	m.ResetCircuitBreaker(ids[1])
+	executor.setFailing(ids[1], false)
+	before := executor.Calls(ids[0])
+
+	resp, errExecute := m.Execute(context.Background(), []string{"claude"}, request, cliproxyexecutor.Options{})
+	if errExecute != nil {
+		t.Fatalf("Execute() error = %v", errExecute)
+	}
+	if string(resp.Payload) != ids[1] {
+		t.Fatalf("served by %q, want %q", resp.Payload, ids[1])
+	}
+	if calls := executor.Calls(ids[0]); calls != before {
+		t.Fatalf("open credential received %d new calls, want 0", calls-before)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	m, _, ids := newCircuitTestManager(t, 1)
+	authID := ids[0]
+	now := time.Now()
+	failure := Result{AuthID: authID, Provider: "claude", Error: &Error{HTTPStatus: http.StatusBadGateway, Message: "bad gateway"}}
+	m.recordCircuitResult(failure, now)
+	m.recordCircuitResult(failure, now)
+
+	if m.acquireCircuit(authID, now.Add(time.Second)) {
+		t.Fatal("open circuit admitted a request before the cool-down")
+	}
+	afterCooldown := now.Add(61 * time.Second)
+	if !m.acquireCircuit(authID, afterCooldown) {
+		t.Fatal("expected a probe after the cool-down")
+	}
+	if m.acquireCircuit(authID, afterCooldown) {
+		t.Fatal("half-open circuit admitted a second concurrent probe")
+	}
+
+	// A failed probe re-opens the circuit immediately.
+	m.recordCircuitResult(failure, afterCooldown)
+	if m.acquireCircuit(authID, afterCooldown.Add(time.Second)) {
+		t.Fatal("failed probe must re-open the circuit")
+	}
+
+	// A successful probe closes it.
+	probeAt := afterCooldown.Add(61 * time.Second)
+	if !m.acquireCircuit(authID, probeAt) {
+		t.Fatal("expected a second probe after the cool-down")
+	}
+	m.recordCircuitResult(Result{AuthID: authID, Provider: "claude", Success: true}, probeAt)
+	if statuses := m.CircuitBreakerStatuses(); len(statuses) != 0 {
+		t.Fatalf("statuses after successful probe = %+v, want none", statuses)
+	}
+}
+
+func TestCountsTowardCircuit(t *testing.T) {
+	cases := []struct {
+		err  *Error
+		want bool
+	}{
+		{&Error{HTTPStatus: http.StatusUnauthorized, Message: "invalid token"}, true},
+		{&Error{HTTPStatus: 529, Message: "overloaded_error"}, true},
+		{&Error{Message: "connection reset by peer"}, true},
+		{&Error{HTTPStatus: http.StatusTooManyRequests, Message: "rate limited"}, false},
+		{&Error{HTTPStatus: http.StatusBadRequest, Message: "invalid_request_error"}, false},
+	}
+	for _, tc := range cases {
+		if got := countsTowardCircuit(tc.err); got != tc.want {
+			t.Errorf("countsTowardCircuit(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
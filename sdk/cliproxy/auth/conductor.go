@@ -156,6 +156,15 @@ type Manager struct {
 	maxRetryCredentials atomic.Int32
 	maxRetryInterval    atomic.Int64
 
+	// circuit tracks consecutive upstream failures per credential.
+	circuit *circuitBreaker
+
+	// probe holds the results of the periodic upstream credential probes.
+	probe *upstreamProbe
+
+	// refreshes counts token refresh outcomes and tracks consecutive failures per credential.
+	refreshes *refreshTracker
+
 	// oauthModelAlias stores global OAuth model alias mappings (alias -> upstream name) keyed by channel.
 	oauthModelAlias atomic.Value
 
@@ -194,6 +203,9 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		auths:            make(map[string]*Auth),
 		providerOffsets:  make(map[string]int),
 		modelPoolOffsets: make(map[string]int),
+		circuit:          newCircuitBreaker(),
+		probe:            newUpstreamProbe(),
+		refreshes:        newRefreshTracker(),
 	}
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
@@ -783,11 +795,14 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, re
 		emit := func(chunk cliproxyexecutor.StreamChunk) bool {
 			if chunk.Err != nil && !failed {
 				failed = true
-				rerr := &Error{Message: chunk.Err.Error()}
-				if se, ok := errors.AsType[cliproxyexecutor.StatusError](chunk.Err); ok && se != nil {
-					rerr.HTTPStatus = se.StatusCode()
+				// A stream aborted because the client went away says nothing about the credential.
+				if ctx == nil || ctx.Err() == nil {
+					rerr := &Error{Message: chunk.Err.Error()}
+					if se, ok := errors.AsType[cliproxyexecutor.StatusError](chunk.Err); ok && se != nil {
+						rerr.HTTPStatus = se.StatusCode()
+					}
+					m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr})
 				}
-				m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr})
 			}
 			if !forward {
 				return false
@@ -969,6 +984,14 @@ func (m *Manager) rebuildAPIKeyModelAliasLocked(cfg *internalconfig.Config) {
 			if entry := resolveVertexAPIKeyConfig(cfg, auth); entry != nil {
 				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
 			}
+		case "bedrock":
+			if entry := resolveBedrockAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
+		case "openrouter":
+			if entry := resolveOpenRouterAPIKeyConfig(cfg, auth); entry != nil {
+				compileAPIKeyModelAliasForModels(byAlias, entry.Models)
+			}
 		default:
 			// OpenAI-compat uses config selection from auth.Attributes.
 			providerKey := ""
@@ -1189,15 +1212,21 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 
 	_, maxRetryCredentials, maxWait := m.retrySettings()
 
+	started := time.Now()
 	var lastErr error
 	for attempt := 0; ; attempt++ {
-		resp, errExec := m.executeMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
+		resp, errExec := m.executeMixedHedged(ctx, normalized, req, opts, maxRetryCredentials)
 		if errExec == nil {
+			notifyRetries(ctx, attempt)
 			return resp, nil
 		}
 		lastErr = errExec
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, normalized, req.Model, maxWait)
 		if !shouldRetry {
+			wait, shouldRetry = m.backoffRetryWait(ctx, errExec, attempt, normalized, started)
+		}
+		if !shouldRetry {
+			notifyRetries(ctx, attempt)
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
@@ -1224,15 +1253,21 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 
 	_, maxRetryCredentials, maxWait := m.retrySettings()
 
+	started := time.Now()
 	var lastErr error
 	for attempt := 0; ; attempt++ {
 		resp, errExec := m.executeCountMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
 		if errExec == nil {
+			notifyRetries(ctx, attempt)
 			return resp, nil
 		}
 		lastErr = errExec
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, normalized, req.Model, maxWait)
 		if !shouldRetry {
+			wait, shouldRetry = m.backoffRetryWait(ctx, errExec, attempt, normalized, started)
+		}
+		if !shouldRetry {
+			notifyRetries(ctx, attempt)
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
@@ -1255,15 +1290,21 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 
 	_, maxRetryCredentials, maxWait := m.retrySettings()
 
+	started := time.Now()
 	var lastErr error
 	for attempt := 0; ; attempt++ {
-		result, errStream := m.executeStreamMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
+		result, errStream := m.executeStreamMixedHedged(ctx, normalized, req, opts, maxRetryCredentials)
 		if errStream == nil {
+			notifyRetries(ctx, attempt)
 			return result, nil
 		}
 		lastErr = errStream
 		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, normalized, req.Model, maxWait)
 		if !shouldRetry {
+			wait, shouldRetry = m.backoffRetryWait(ctx, errStream, attempt, normalized, started)
+		}
+		if !shouldRetry {
+			notifyRetries(ctx, attempt)
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
@@ -1293,6 +1334,9 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	tried := make(map[string]struct{})
 	attempted := make(map[string]struct{})
+	circuitSkipped := make(map[string]struct{})
+	claims := hedgeClaimsFromContext(ctx)
+	var probed probeGate
 	var lastErr error
 	for {
 		if maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
@@ -1303,11 +1347,30 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		}
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
+			if probed.readmit(tried) {
+				continue
+			}
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
 			}
+			if len(circuitSkipped) > 0 {
+				return cliproxyexecutor.Response{}, m.circuitOpenError(circuitSkipped)
+			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		if probed.skip(m, auth.ID, tried) {
+			continue
+		}
+		if !claims.claim(auth.ID) {
+			// Another attempt of this hedged request is using the credential.
+			tried[auth.ID] = struct{}{}
+			continue
+		}
+		if !m.acquireCircuit(auth.ID, time.Now()) {
+			tried[auth.ID] = struct{}{}
+			circuitSkipped[auth.ID] = struct{}{}
+			continue
+		}
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
@@ -1371,6 +1434,8 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	tried := make(map[string]struct{})
 	attempted := make(map[string]struct{})
+	circuitSkipped := make(map[string]struct{})
+	var probed probeGate
 	var lastErr error
 	for {
 		if maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
@@ -1381,11 +1446,25 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		}
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
+			if probed.readmit(tried) {
+				continue
+			}
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
 			}
+			if len(circuitSkipped) > 0 {
+				return cliproxyexecutor.Response{}, m.circuitOpenError(circuitSkipped)
+			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		if probed.skip(m, auth.ID, tried) {
+			continue
+		}
+		if !m.acquireCircuit(auth.ID, time.Now()) {
+			tried[auth.ID] = struct{}{}
+			circuitSkipped[auth.ID] = struct{}{}
+			continue
+		}
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
@@ -1449,6 +1528,9 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	tried := make(map[string]struct{})
 	attempted := make(map[string]struct{})
+	circuitSkipped := make(map[string]struct{})
+	claims := hedgeClaimsFromContext(ctx)
+	var probed probeGate
 	var lastErr error
 	for {
 		if maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
@@ -1459,11 +1541,30 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		}
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
+			if probed.readmit(tried) {
+				continue
+			}
 			if lastErr != nil {
 				return nil, lastErr
 			}
+			if len(circuitSkipped) > 0 {
+				return nil, m.circuitOpenError(circuitSkipped)
+			}
 			return nil, errPick
 		}
+		if probed.skip(m, auth.ID, tried) {
+			continue
+		}
+		if !claims.claim(auth.ID) {
+			// Another attempt of this hedged request is using the credential.
+			tried[auth.ID] = struct{}{}
+			continue
+		}
+		if !m.acquireCircuit(auth.ID, time.Now()) {
+			tried[auth.ID] = struct{}{}
+			circuitSkipped[auth.ID] = struct{}{}
+			continue
+		}
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
@@ -1651,6 +1752,10 @@ func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) strin
 		upstreamModel = resolveUpstreamModelForCodexAPIKey(cfg, auth, requestedModel)
 	case "vertex":
 		upstreamModel = resolveUpstreamModelForVertexAPIKey(cfg, auth, requestedModel)
+	case "bedrock":
+		upstreamModel = resolveUpstreamModelForBedrockAPIKey(cfg, auth, requestedModel)
+	case "openrouter":
+		upstreamModel = resolveUpstreamModelForOpenRouterAPIKey(cfg, auth, requestedModel)
 	default:
 		upstreamModel = resolveUpstreamModelForOpenAICompatAPIKey(cfg, auth, requestedModel)
 	}
@@ -1759,6 +1864,36 @@ func resolveUpstreamModelForCodexAPIKey(cfg *internalconfig.Config, auth *Auth,
 	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
 }
 
+func resolveBedrockAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.BedrockKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.BedrockKey, auth)
+}
+
+func resolveUpstreamModelForBedrockAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveBedrockAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
+func resolveOpenRouterAPIKeyConfig(cfg *internalconfig.Config, auth *Auth) *internalconfig.OpenRouterKey {
+	if cfg == nil {
+		return nil
+	}
+	return resolveAPIKeyConfig(cfg.OpenRouterKey, auth)
+}
+
+func resolveUpstreamModelForOpenRouterAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
+	entry := resolveOpenRouterAPIKeyConfig(cfg, auth)
+	if entry == nil {
+		return ""
+	}
+	return resolveModelAliasFromConfigModels(requestedModel, asModelAliasEntries(entry.Models))
+}
+
 func resolveUpstreamModelForVertexAPIKey(cfg *internalconfig.Config, auth *Auth, requestedModel string) string {
 	entry := resolveVertexAPIKeyConfig(cfg, auth)
 	if entry == nil {
@@ -2014,6 +2149,8 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		return
 	}
 
+	// Retry backoff replaces the transient-error cooldown so the request can retry the same credential.
+	skipTransientCooldown := m.retryBackoffEnabled()
 	shouldResumeModel := false
 	shouldSuspendModel := false
 	suspendReason := ""
@@ -2124,7 +2261,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 								setModelQuota = true
 							}
 						case 408, 500, 502, 503, 504:
-							if disableCooling {
+							if disableCooling || skipTransientCooldown {
 								state.NextRetryAfter = time.Time{}
 							} else {
 								next := now.Add(1 * time.Minute)
@@ -2140,7 +2277,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 					updateAggregatedAvailability(auth, now)
 				}
 			} else {
-				applyAuthFailureState(auth, result.Error, result.RetryAfter, now)
+				applyAuthFailureState(auth, result.Error, result.RetryAfter, skipTransientCooldown, now)
 			}
 		}
 
@@ -2164,6 +2301,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
 
+	m.recordCircuitResult(result, time.Now())
 	m.hook.OnResult(ctx, result)
 }
 
@@ -2483,7 +2621,7 @@ func isRequestInvalidError(err error) bool {
 	}
 }
 
-func applyAuthFailureState(auth *Auth, resultErr *Error, retryAfter *time.Duration, now time.Time) {
+func applyAuthFailureState(auth *Auth, resultErr *Error, retryAfter *time.Duration, skipTransientCooldown bool, now time.Time) {
 	if auth == nil {
 		return
 	}
@@ -2543,7 +2681,7 @@ func applyAuthFailureState(auth *Auth, resultErr *Error, retryAfter *time.Durati
 		auth.NextRetryAfter = next
 	case 408, 500, 502, 503, 504:
 		auth.StatusMessage = "transient upstream error"
-		if disableCooling {
+		if disableCooling || skipTransientCooldown {
 			auth.NextRetryAfter = time.Time{}
 		} else {
 			auth.NextRetryAfter = now.Add(1 * time.Minute)
@@ -3282,7 +3420,7 @@ func (m *Manager) shouldRefresh(a *Auth, now time.Time) bool {
 		return false
 	}
 	if hasExpiry && !expiry.IsZero() {
-		return time.Until(expiry) <= *lead
+		return time.Until(expiry) <= *lead+m.refreshJitter(a)
 	}
 	if !lastRefresh.IsZero() {
 		return now.Sub(lastRefresh) >= *lead
@@ -3483,6 +3621,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	log.Debugf("refreshed %s, %s, %v", auth.Provider, auth.ID, err)
 	now := time.Now()
 	if err != nil {
+		m.recordRefreshFailure(auth, err, now)
 		shouldReschedule := false
 		m.mu.Lock()
 		if current := m.auths[id]; current != nil {
@@ -3508,6 +3647,7 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	if updated.Runtime == nil {
 		updated.Runtime = auth.Runtime
 	}
+	m.recordRefreshSuccess(auth)
 	updated.LastRefreshedAt = now
 	updated.NextRefreshAfter = time.Time{}
 	updated.LastError = nil
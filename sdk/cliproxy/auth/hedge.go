@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const defaultHedgeDelay = 2 * time.Second
+
+// hedgeSettings resolves the hedge delay for a request; ok is false when the request is not
+// hedged. Requests bound to one credential (pinned auths, execution sessions) never are.
+func (m *Manager) hedgeSettings(model string, opts cliproxyexecutor.Options) (delay time.Duration, ok bool) {
+	if m == nil {
+		return 0, false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.HedgedRequests.Matches(model) {
+		return 0, false
+	}
+	if pinnedAuthIDFromMetadata(opts.Metadata) != "" {
+		return 0, false
+	}
+	if session, _ := opts.Metadata[cliproxyexecutor.ExecutionSessionMetadataKey].(string); session != "" {
+		return 0, false
+	}
+	delay = time.Duration(cfg.HedgedRequests.Delay) * time.Millisecond
+	if delay <= 0 {
+		delay = defaultHedgeDelay
+	}
+	return delay, true
+}
+
+// hedgeClaims records the credentials picked by the attempts of one hedged request so that the
+// attempts never share a credential.
+type hedgeClaims struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+type hedgeClaimsKey struct{}
+
+func hedgeClaimsFromContext(ctx context.Context) *hedgeClaims {
+	if ctx == nil {
+		return nil
+	}
+	claims, _ := ctx.Value(hedgeClaimsKey{}).(*hedgeClaims)
+	return claims
+}
+
+// claim reserves authID for the calling attempt and reports false when another attempt of the
+// request already uses it. Requests that are not hedged claim every credential.
+func (c *hedgeClaims) claim(authID string) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, taken := c.ids[authID]; taken {
+		return false
+	}
+	c.ids[authID] = struct{}{}
+	return true
+}
+
+type hedgeOutcome[T any] struct {
+	value T
+	err   error
+	leg   int
+	opts  cliproxyexecutor.Options
+}
+
+// runHedged runs attempt and, when it has not returned after delay, a second attempt on another
+// credential. The first success wins and the other attempt is cancelled; a success that lost
+// the race is handed to discard. A primary failure before the delay is returned as is, and
+// when both attempts fail the primary's error is returned.
+func runHedged[T any](ctx context.Context, delay time.Duration, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, attempt func(context.Context, cliproxyexecutor.Request, cliproxyexecutor.Options) (T, error), discard func(T)) (T, error) {
+	ctx = context.WithValue(ctx, hedgeClaimsKey{}, &hedgeClaims{ids: make(map[string]struct{})})
+	results := make(chan hedgeOutcome[T], 2)
+	var cancels []context.CancelFunc
+	launch := func(legReq cliproxyexecutor.Request) {
+		leg := len(cancels)
+		legCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		legOpts := hedgeLegOptions(opts)
+		go func() {
+			value, err := attempt(legCtx, legReq, legOpts)
+			results <- hedgeOutcome[T]{value: value, err: err, leg: leg, opts: legOpts}
+		}()
+	}
+
+	launch(req)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	pending := 1
+	var primaryErr error
+	for {
+		select {
+		case <-timer.C:
+			// Executors may rewrite the payload in place; the second attempt gets its own copy.
+			hedgeReq := req
+			hedgeReq.Payload = bytes.Clone(req.Payload)
+			launch(hedgeReq)
+			pending++
+			logEntryWithRequestID(ctx).Debugf("hedging request for model %s after %s", req.Model, delay)
+		case outcome := <-results:
+			pending--
+			if outcome.err == nil {
+				for leg, cancel := range cancels {
+					if leg != outcome.leg {
+						cancel()
+					}
+				}
+				if pending > 0 {
+					go func() {
+						if late := <-results; late.err == nil && discard != nil {
+							discard(late.value)
+						}
+					}()
+				}
+				if authID, _ := outcome.opts.Metadata[cliproxyexecutor.SelectedAuthMetadataKey].(string); authID != "" {
+					publishSelectedAuthMetadata(opts.Metadata, authID)
+				}
+				return outcome.value, nil
+			}
+			if outcome.leg == 0 {
+				primaryErr = outcome.err
+			}
+			if pending > 0 {
+				continue
+			}
+			for _, cancel := range cancels {
+				cancel()
+			}
+			if primaryErr == nil {
+				primaryErr = outcome.err
+			}
+			var zero T
+			return zero, primaryErr
+		}
+	}
+}
+
+// hedgeLegOptions gives an attempt its own metadata map. The selected-auth callback is left out
+// and invoked once for the winning attempt.
+func hedgeLegOptions(opts cliproxyexecutor.Options) cliproxyexecutor.Options {
+	if opts.Metadata == nil {
+		return opts
+	}
+	meta := maps.Clone(opts.Metadata)
+	delete(meta, cliproxyexecutor.SelectedAuthCallbackMetadataKey)
+	opts.Metadata = meta
+	return opts
+}
+
+func (m *Manager) executeMixedHedged(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, maxRetryCredentials int) (cliproxyexecutor.Response, error) {
+	delay, ok := m.hedgeSettings(req.Model, opts)
+	if !ok {
+		return m.executeMixedOnce(ctx, providers, req, opts, maxRetryCredentials)
+	}
+	// Settle the metadata map up front so the attempts publish the selected auth into it.
+	opts = ensureRequestedModelMetadata(opts, req.Model)
+	return runHedged(ctx, delay, req, opts, func(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		return m.executeMixedOnce(ctx, providers, req, opts, maxRetryCredentials)
+	}, nil)
+}
+
+func (m *Manager) executeStreamMixedHedged(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, maxRetryCredentials int) (*cliproxyexecutor.StreamResult, error) {
+	delay, ok := m.hedgeSettings(req.Model, opts)
+	if !ok {
+		return m.executeStreamMixedOnce(ctx, providers, req, opts, maxRetryCredentials)
+	}
+	opts = ensureRequestedModelMetadata(opts, req.Model)
+	return runHedged(ctx, delay, req, opts, func(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+		return m.executeStreamMixedOnce(ctx, providers, req, opts, maxRetryCredentials)
+	}, func(result *cliproxyexecutor.StreamResult) {
+		if result != nil {
+			discardStreamChunks(result.Chunks)
+		}
+	})
+}
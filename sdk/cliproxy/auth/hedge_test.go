@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// stallFirstExecutor never answers the first call until it is cancelled and answers every
+// other call immediately.
+type stallFirstExecutor struct {
+	mu        sync.Mutex
+	calls     []string
+	cancelled chan string
+}
+
+func (e *stallFirstExecutor) Identifier() string { return "claude" }
+
+func (e *stallFirstExecutor) start(authID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, authID)
+	return len(e.calls) == 1
+}
+
+func (e *stallFirstExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if e.start(auth.ID) {
+		<-ctx.Done()
+		e.cancelled <- auth.ID
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *stallFirstExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	if e.start(auth.ID) {
+		go func() {
+			<-ctx.Done()
+			e.cancelled <- auth.ID
+			close(ch)
+		}()
+		return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+	}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte(auth.ID)}
+	close(ch)
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *stallFirstExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *stallFirstExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusNotImplemented, Message: "not implemented"}
+}
+
+func (e *stallFirstExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func newHedgeTestManager(t *testing.T) (*Manager, *stallFirstExecutor) {
+	t.Helper()
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	m.SetConfig(&internalconfig.Config{HedgedRequests: internalconfig.HedgedRequestsConfig{
+		Enabled: true,
+		Delay:   10,
+		Models:  []string{"test-*"},
+	}})
+	executor := &stallFirstExecutor{cancelled: make(chan string, 2)}
+	m.RegisterExecutor(executor)
+
+	reg := registry.GetGlobalRegistry()
+	for range 2 {
+		auth := &Auth{ID: uuid.NewString(), Provider: "claude"}
+		reg.RegisterClient(auth.ID, "claude", []*registry.ModelInfo{{ID: "test-model"}})
+		t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register auth: %v", errRegister)
+		}
+	}
+	return m, executor
+}
+
+func TestExecuteHedgesSlowRequestOnAnotherCredential(t *testing.T) {
+	m, executor := newHedgeTestManager(t)
+
+	var selected []string
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{
+		cliproxyexecutor.SelectedAuthCallbackMetadataKey: func(authID string) { selected = append(selected, authID) },
+	}}
+	resp, errExec := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "test-model"}, opts)
+	if errExec != nil {
+		t.Fatalf("Execute error: %v", errExec)
+	}
+
+	cancelled := <-executor.cancelled
+	executor.mu.Lock()
+	calls := append([]string(nil), executor.calls...)
+	executor.mu.Unlock()
+	if len(calls) != 2 || calls[0] == calls[1] {
+		t.Fatalf("expected two attempts on distinct credentials, got %v", calls)
+	}
+	if cancelled != calls[0] || string(resp.Payload) != calls[1] {
+		t.Fatalf("expected the hedge on %s to win over %s, got %q (cancelled %s)", calls[1], calls[0], resp.Payload, cancelled)
+	}
+	if len(selected) != 1 || selected[0] != calls[1] {
+		t.Fatalf("expected one selection callback for the winner, got %v", selected)
+	}
+}
+
+func TestExecuteStreamHedgesSlowRequestOnAnotherCredential(t *testing.T) {
+	m, executor := newHedgeTestManager(t)
+
+	result, errStream := m.ExecuteStream(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "test-model"}, cliproxyexecutor.Options{})
+	if errStream != nil {
+		t.Fatalf("ExecuteStream error: %v", errStream)
+	}
+	var payload []byte
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream chunk error: %v", chunk.Err)
+		}
+		payload = append(payload, chunk.Payload...)
+	}
+	cancelled := <-executor.cancelled
+	if len(payload) == 0 || string(payload) == cancelled {
+		t.Fatalf("expected the stream of the hedge, got %q (cancelled %s)", payload, cancelled)
+	}
+}
+
+func TestHedgedRequestsSkipUnmatchedAndPinnedRequests(t *testing.T) {
+	m, _ := newHedgeTestManager(t)
+	if _, ok := m.hedgeSettings("other-model", cliproxyexecutor.Options{}); ok {
+		t.Fatal("expected no hedging for a model outside the configured patterns")
+	}
+	pinned := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.PinnedAuthMetadataKey: "auth-1"}}
+	if _, ok := m.hedgeSettings("test-model", pinned); ok {
+		t.Fatal("expected no hedging for a request pinned to a credential")
+	}
+	if delay, ok := m.hedgeSettings("test-model(high)", cliproxyexecutor.Options{}); !ok || delay.Milliseconds() != 10 {
+		t.Fatalf("expected a 10ms hedge for a matching model, got %v (ok=%v)", delay, ok)
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// TokenRefreshStatus is a snapshot of the token refresh state of one OAuth credential.
+type TokenRefreshStatus struct {
+	AuthID              string    `json:"auth_id"`
+	Provider            string    `json:"provider"`
+	Label               string    `json:"label,omitempty"`
+	ExpiresAt           time.Time `json:"expires_at,omitzero"`
+	Expired             bool      `json:"expired"`
+	LastRefreshedAt     time.Time `json:"last_refreshed_at,omitzero"`
+	NextRefreshAfter    time.Time `json:"next_refresh_after,omitzero"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastFailureAt       time.Time `json:"last_failure_at,omitzero"`
+}
+
+// TokenRefreshCounters are the refresh outcomes counted since the process started.
+type TokenRefreshCounters struct {
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+}
+
+type refreshFailure struct {
+	consecutive int
+	lastError   string
+	lastAt      time.Time
+}
+
+// refreshTracker counts refresh outcomes and remembers the failures of each credential until
+// its next successful refresh.
+type refreshTracker struct {
+	mu        sync.Mutex
+	failures  map[string]*refreshFailure
+	succeeded atomic.Int64
+	failed    atomic.Int64
+}
+
+func newRefreshTracker() *refreshTracker {
+	return &refreshTracker{failures: make(map[string]*refreshFailure)}
+}
+
+// refreshJitter returns how much earlier than the provider lead the token of a is refreshed:
+// a stable offset within auth-refresh-jitter derived from the credential ID.
+func (m *Manager) refreshJitter(a *Auth) time.Duration {
+	if m == nil || a == nil {
+		return 0
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || cfg.AuthRefreshJitter <= 0 {
+		return 0
+	}
+	window := time.Duration(cfg.AuthRefreshJitter) * time.Second
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(a.ID))
+	return time.Duration(hasher.Sum64() % uint64(window))
+}
+
+// recordRefreshFailure counts a failed refresh and warns, loudly once the token has expired or
+// will expire before the next attempt, since requests then start failing with 401.
+func (m *Manager) recordRefreshFailure(a *Auth, errRefresh error, now time.Time) {
+	if m == nil || m.refreshes == nil || a == nil {
+		return
+	}
+	m.refreshes.failed.Add(1)
+	m.refreshes.mu.Lock()
+	failure := m.refreshes.failures[a.ID]
+	if failure == nil {
+		failure = &refreshFailure{}
+		m.refreshes.failures[a.ID] = failure
+	}
+	failure.consecutive++
+	failure.lastError = errRefresh.Error()
+	failure.lastAt = now
+	consecutive := failure.consecutive
+	m.refreshes.mu.Unlock()
+
+	entry := log.WithFields(log.Fields{
+		"auth_id":              a.ID,
+		"provider":             a.Provider,
+		"consecutive_failures": consecutive,
+	})
+	expiry, hasExpiry := a.ExpirationTime()
+	switch {
+	case hasExpiry && !expiry.IsZero() && !expiry.After(now):
+		entry.Errorf("token refresh failed and the token expired at %s; requests with this credential will fail: %v", expiry.Format(time.RFC3339), errRefresh)
+	case hasExpiry && !expiry.IsZero() && !expiry.After(now.Add(refreshFailureBackoff)):
+		entry.Errorf("token refresh failed and the token expires at %s, before the next attempt: %v", expiry.Format(time.RFC3339), errRefresh)
+	default:
+		entry.Warnf("token refresh failed, retrying in %s: %v", refreshFailureBackoff, errRefresh)
+	}
+}
+
+// recordRefreshSuccess counts a successful refresh and clears the failures of the credential.
+func (m *Manager) recordRefreshSuccess(a *Auth) {
+	if m == nil || m.refreshes == nil || a == nil {
+		return
+	}
+	m.refreshes.succeeded.Add(1)
+	m.refreshes.mu.Lock()
+	failure := m.refreshes.failures[a.ID]
+	delete(m.refreshes.failures, a.ID)
+	m.refreshes.mu.Unlock()
+	if failure != nil {
+		log.WithFields(log.Fields{"auth_id": a.ID, "provider": a.Provider}).Infof("token refresh recovered after %d failure(s)", failure.consecutive)
+	}
+}
+
+// TokenRefreshStatuses returns the token refresh state of every enabled credential with an
+// expiring token, soonest expiry first.
+func (m *Manager) TokenRefreshStatuses() []TokenRefreshStatus {
+	if m == nil {
+		return nil
+	}
+	now := time.Now()
+	var out []TokenRefreshStatus
+	for _, a := range m.List() {
+		if a == nil || a.Disabled {
+			continue
+		}
+		expiry, hasExpiry := a.ExpirationTime()
+		if !hasExpiry || expiry.IsZero() {
+			continue
+		}
+		status := TokenRefreshStatus{
+			AuthID:           a.ID,
+			Provider:         a.Provider,
+			Label:            a.Label,
+			ExpiresAt:        expiry,
+			Expired:          !expiry.After(now),
+			LastRefreshedAt:  a.LastRefreshedAt,
+			NextRefreshAfter: a.NextRefreshAfter,
+		}
+		if m.refreshes != nil {
+			m.refreshes.mu.Lock()
+			if failure := m.refreshes.failures[a.ID]; failure != nil {
+				status.ConsecutiveFailures = failure.consecutive
+				status.LastError = failure.lastError
+				status.LastFailureAt = failure.lastAt
+			}
+			m.refreshes.mu.Unlock()
+		}
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].ExpiresAt.Equal(out[j].ExpiresAt) {
+			return out[i].ExpiresAt.Before(out[j].ExpiresAt)
+		}
+		return out[i].AuthID < out[j].AuthID
+	})
+	return out
+}
+
+// TokenRefreshCounters returns the refresh outcomes counted since the manager was created.
+func (m *Manager) TokenRefreshCounters() TokenRefreshCounters {
+	if m == nil || m.refreshes == nil {
+		return TokenRefreshCounters{}
+	}
+	return TokenRefreshCounters{Succeeded: m.refreshes.succeeded.Load(), Failed: m.refreshes.failed.Load()}
+}
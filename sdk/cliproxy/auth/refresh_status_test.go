@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type failingRefreshExecutor struct {
+	err error
+}
+
+func (e *failingRefreshExecutor) Identifier() string { return "refresh-status" }
+
+func (e *failingRefreshExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusNotImplemented, Message: "not implemented"}
+}
+
+func (e *failingRefreshExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, &Error{HTTPStatus: http.StatusNotImplemented, Message: "not implemented"}
+}
+
+func (e *failingRefreshExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return auth, nil
+}
+
+func (e *failingRefreshExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: http.StatusNotImplemented, Message: "not implemented"}
+}
+
+func (e *failingRefreshExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestManager_RefreshJitter_StableWithinWindow(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	auth := &Auth{ID: "jitter-auth"}
+	if jitter := m.refreshJitter(auth); jitter != 0 {
+		t.Fatalf("refreshJitter() = %s without auth-refresh-jitter, want 0", jitter)
+	}
+
+	m.SetConfig(&internalconfig.Config{AuthRefreshJitter: 600})
+	jitter := m.refreshJitter(auth)
+	if jitter < 0 || jitter >= 10*time.Minute {
+		t.Fatalf("refreshJitter() = %s, want within [0, 10m)", jitter)
+	}
+	if again := m.refreshJitter(auth); again != jitter {
+		t.Fatalf("refreshJitter() = %s then %s, want a stable offset", jitter, again)
+	}
+
+	now := time.Date(2026, 4, 12, 0, 0, 0, 0, time.UTC)
+	expiry := now.Add(time.Hour)
+	lead := 10 * time.Minute
+	setRefreshLeadFactory(t, "provider-lead-jitter", func() *time.Duration {
+		d := lead
+		return &d
+	})
+	auth.Provider = "provider-lead-jitter"
+	auth.Metadata = map[string]any{"expires_at": expiry.Format(time.RFC3339)}
+	got, ok := nextRefreshCheckAt(now, auth, 15*time.Minute, jitter)
+	if want := expiry.Add(-lead - jitter); !ok || !got.Equal(want) {
+		t.Fatalf("nextRefreshCheckAt() = %s, %t; want %s", got, ok, want)
+	}
+}
+
+func TestManager_RefreshAuth_TracksFailures(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &failingRefreshExecutor{err: errors.New("invalid_grant")}
+	m.RegisterExecutor(executor)
+	auth := &Auth{
+		ID:       "refresh-status-auth",
+		Provider: "refresh-status",
+		Metadata: map[string]any{"expires_at": time.Now().Add(-time.Minute).Format(time.RFC3339)},
+	}
+	if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("register auth: %v", errRegister)
+	}
+
+	m.refreshAuth(context.Background(), auth.ID)
+	m.refreshAuth(context.Background(), auth.ID)
+	statuses := m.TokenRefreshStatuses()
+	if len(statuses) != 1 || !statuses[0].Expired || statuses[0].ConsecutiveFailures != 2 || statuses[0].LastError != "invalid_grant" {
+		t.Fatalf("statuses = %+v, want one expired credential with 2 failures", statuses)
+	}
+
+	executor.err = nil
+	m.refreshAuth(context.Background(), auth.ID)
+	statuses = m.TokenRefreshStatuses()
+	if len(statuses) != 1 || statuses[0].ConsecutiveFailures != 0 || statuses[0].LastError != "" {
+		t.Fatalf("statuses after recovery = %+v, want failures cleared", statuses)
+	}
+	if counters := m.TokenRefreshCounters(); counters.Failed != 2 || counters.Succeeded != 1 {
+		t.Fatalf("counters = %+v, want 2 failed and 1 succeeded", counters)
+	}
+}
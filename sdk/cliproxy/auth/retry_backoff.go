@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const (
+	defaultBackoffMaxAttempts = 3
+	defaultBackoffBaseDelay   = 500 * time.Millisecond
+	defaultBackoffMaxDelay    = 10 * time.Second
+
+	// statusOverloaded is the non-standard status Anthropic returns for overloaded_error.
+	statusOverloaded = 529
+)
+
+// backoffPolicy is the resolved retry-backoff budget for one request.
+type backoffPolicy struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+type retryObserverKey struct{}
+
+// WithRetryObserver returns a context whose Execute, ExecuteCount and ExecuteStream calls report
+// the number of retries they performed through observe. observe is not called when the first
+// attempt settles the request.
+func WithRetryObserver(ctx context.Context, observe func(retries int)) context.Context {
+	if ctx == nil || observe == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryObserverKey{}, observe)
+}
+
+func notifyRetries(ctx context.Context, retries int) {
+	if ctx == nil || retries <= 0 {
+		return
+	}
+	if observe, ok := ctx.Value(retryObserverKey{}).(func(int)); ok && observe != nil {
+		observe(retries)
+	}
+}
+
+func (m *Manager) retryBackoffEnabled() bool {
+	if m == nil {
+		return false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	return cfg != nil && cfg.RetryBackoff.Enabled
+}
+
+// backoffPolicyFor resolves the backoff budget for providers. With several providers the most
+// generous budget wins, since any of them may serve the retry.
+func (m *Manager) backoffPolicyFor(providers []string) (backoffPolicy, bool) {
+	if m == nil {
+		return backoffPolicy{}, false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.RetryBackoff.Enabled {
+		return backoffPolicy{}, false
+	}
+	settings := cfg.RetryBackoff
+	policy := backoffPolicy{
+		baseDelay: time.Duration(settings.BaseDelayMs) * time.Millisecond,
+		maxDelay:  time.Duration(settings.MaxDelayMs) * time.Millisecond,
+	}
+	if policy.baseDelay <= 0 {
+		policy.baseDelay = defaultBackoffBaseDelay
+	}
+	if policy.maxDelay <= 0 {
+		policy.maxDelay = defaultBackoffMaxDelay
+	}
+	unlimitedElapsed := false
+	for _, provider := range providers {
+		attempts, elapsed := settings.MaxAttempts, settings.MaxElapsed
+		if override, ok := settings.Providers[strings.ToLower(strings.TrimSpace(provider))]; ok {
+			if override.MaxAttempts > 0 {
+				attempts = override.MaxAttempts
+			}
+			if override.MaxElapsed > 0 {
+				elapsed = override.MaxElapsed
+			}
+		}
+		if attempts <= 0 {
+			attempts = defaultBackoffMaxAttempts
+		}
+		policy.maxAttempts = max(policy.maxAttempts, attempts)
+		if elapsed <= 0 {
+			unlimitedElapsed = true
+		}
+		policy.maxElapsed = max(policy.maxElapsed, time.Duration(elapsed)*time.Second)
+	}
+	if unlimitedElapsed {
+		policy.maxElapsed = 0
+	}
+	return policy, policy.maxAttempts > 0
+}
+
+// backoffRetryWait returns the jittered delay before retrying a transient upstream failure.
+// It reports false when backoff is disabled, the error is not transient, or the attempt or
+// elapsed-time budget is spent.
+func (m *Manager) backoffRetryWait(ctx context.Context, err error, attempt int, providers []string, started time.Time) (time.Duration, bool) {
+	if err == nil || (ctx != nil && ctx.Err() != nil) {
+		return 0, false
+	}
+	if !isTransientUpstreamError(err) {
+		return 0, false
+	}
+	policy, ok := m.backoffPolicyFor(providers)
+	if !ok || attempt+1 >= policy.maxAttempts {
+		return 0, false
+	}
+	delay := backoffDelay(policy.baseDelay, policy.maxDelay, attempt)
+	if policy.maxElapsed > 0 && time.Since(started)+delay > policy.maxElapsed {
+		return 0, false
+	}
+	return delay, true
+}
+
+// backoffDelay doubles base per attempt up to maxDelay and applies equal jitter: half of the
+// delay is fixed and half random, so concurrent retries spread out without firing back to back.
+func backoffDelay(base, maxDelay time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 0; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// isTransientUpstreamError reports whether err is an upstream failure that is likely to succeed
+// when repeated: server errors, Anthropic overload, timeouts and dropped connections.
+func isTransientUpstreamError(err error) bool {
+	if err == nil || isRequestInvalidError(err) || isCircuitOpenError(err) {
+		return false
+	}
+	switch statusCodeFromError(err) {
+	case http.StatusRequestTimeout,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		statusOverloaded:
+		return true
+	case 0:
+	default:
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection reset") || strings.Contains(msg, "unexpected eof")
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type flakyExecutor struct {
+	id       string
+	failures int
+	status   int
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *flakyExecutor) Identifier() string { return e.id }
+
+func (e *flakyExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	if e.calls <= e.failures {
+		return cliproxyexecutor.Response{}, &Error{HTTPStatus: e.status, Message: "overloaded"}
+	}
+	return cliproxyexecutor.Response{Payload: []byte("ok")}, nil
+}
+
+func (e *flakyExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, &Error{HTTPStatus: e.status, Message: "overloaded"}
+}
+
+func (e *flakyExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *flakyExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{HTTPStatus: e.status, Message: "overloaded"}
+}
+
+func (e *flakyExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func (e *flakyExecutor) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calls
+}
+
+func newBackoffTestManager(t *testing.T, backoff internalconfig.RetryBackoffConfig, executor *flakyExecutor) *Manager {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{RetryBackoff: backoff})
+	m.RegisterExecutor(executor)
+
+	auth := &Auth{ID: uuid.NewString(), Provider: executor.id}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, executor.id, []*registry.ModelInfo{{ID: "test-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+	if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("register auth: %v", errRegister)
+	}
+	return m
+}
+
+func TestManager_RetryBackoff_RetriesTransientErrors(t *testing.T) {
+	executor := &flakyExecutor{id: "claude", failures: 2, status: 529}
+	m := newBackoffTestManager(t, internalconfig.RetryBackoffConfig{
+		Enabled:     true,
+		MaxAttempts: 3,
+		BaseDelayMs: 1,
+		MaxDelayMs:  2,
+	}, executor)
+
+	retries := 0
+	ctx := WithRetryObserver(context.Background(), func(n int) { retries = n })
+	resp, errExecute := m.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{Model: "test-model"}, cliproxyexecutor.Options{})
+	if errExecute != nil {
+		t.Fatalf("Execute() error = %v", errExecute)
+	}
+	if string(resp.Payload) != "ok" {
+		t.Fatalf("payload = %q, want ok", resp.Payload)
+	}
+	if calls := executor.Calls(); calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+	if retries != 2 {
+		t.Fatalf("observed retries = %d, want 2", retries)
+	}
+}
+
+func TestManager_RetryBackoff_ProviderOverrideLimitsAttempts(t *testing.T) {
+	executor := &flakyExecutor{id: "claude", failures: 5, status: http.StatusServiceUnavailable}
+	m := newBackoffTestManager(t, internalconfig.RetryBackoffConfig{
+		Enabled:     true,
+		MaxAttempts: 5,
+		BaseDelayMs: 1,
+		MaxDelayMs:  2,
+		Providers:   map[string]internalconfig.RetryBackoffPolicy{"claude": {MaxAttempts: 2}},
+	}, executor)
+
+	if _, errExecute := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "test-model"}, cliproxyexecutor.Options{}); errExecute == nil {
+		t.Fatal("expected error after exhausting attempts")
+	}
+	if calls := executor.Calls(); calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+func TestManager_RetryBackoff_DisabledKeepsSingleAttempt(t *testing.T) {
+	executor := &flakyExecutor{id: "claude", failures: 1, status: http.StatusBadGateway}
+	m := newBackoffTestManager(t, internalconfig.RetryBackoffConfig{}, executor)
+
+	if _, errExecute := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "test-model"}, cliproxyexecutor.Options{}); errExecute == nil {
+		t.Fatal("expected error without backoff")
+	}
+	if calls := executor.Calls(); calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestBackoffDelay_DoublesWithinJitterBounds(t *testing.T) {
+	base := 100 * time.Millisecond
+	maxDelay := time.Second
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		for range 50 {
+			delay := backoffDelay(base, maxDelay, attempt)
+			if delay < want/2 || delay > want {
+				t.Fatalf("attempt %d: delay %v outside [%v, %v]", attempt, delay, want/2, want)
+			}
+		}
+	}
+}
+
+func TestIsTransientUpstreamError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&Error{HTTPStatus: 529, Message: "overloaded_error"}, true},
+		{&Error{HTTPStatus: http.StatusInternalServerError, Message: "boom"}, true},
+		{&Error{HTTPStatus: http.StatusTooManyRequests, Message: "rate"}, false},
+		{&Error{HTTPStatus: http.StatusBadRequest, Message: "bad"}, false},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{context.Canceled, false},
+	}
+	for _, tc := range cases {
+		if got := isTransientUpstreamError(tc.err); got != tc.want {
+			t.Errorf("isTransientUpstreamError(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
@@ -35,6 +35,14 @@ type RoundRobinSelector struct {
 // rolling-window subscription caps (e.g. chat message limits).
 type FillFirstSelector struct{}
 
+// LeastUsedSelector picks the available credential with the fewest requests recorded in
+// the recent-requests window, spreading load by observed usage rather than by turn.
+// Ties rotate so bursts issued before results are recorded still fan out.
+type LeastUsedSelector struct {
+	mu      sync.Mutex
+	cursors map[string]int
+}
+
 type blockReason int
 
 const (
@@ -368,6 +376,55 @@ func (s *FillFirstSelector) Pick(ctx context.Context, provider, model string, op
 	return available[0], nil
 }
 
+// Pick selects the available auth with the fewest recent requests.
+func (s *LeastUsedSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = opts
+	now := time.Now()
+	available, err := getAvailableAuths(auths, provider, model, now)
+	if err != nil {
+		return nil, err
+	}
+	available = preferCodexWebsocketAuths(ctx, provider, available)
+
+	var least []*Auth
+	leastCount := int64(math.MaxInt64)
+	for _, candidate := range available {
+		count := recentRequestTotal(candidate, now)
+		switch {
+		case count < leastCount:
+			leastCount = count
+			least = append(least[:0], candidate)
+		case count == leastCount:
+			least = append(least, candidate)
+		}
+	}
+	if len(least) == 1 {
+		return least[0], nil
+	}
+
+	key := provider + ":" + canonicalModelKey(model)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cursors == nil || len(s.cursors) >= 4096 {
+		s.cursors = make(map[string]int)
+	}
+	index := s.cursors[key]
+	if index >= 2_147_483_640 {
+		index = 0
+	}
+	s.cursors[key] = index + 1
+	return least[index%len(least)], nil
+}
+
+// recentRequestTotal sums the successful and failed requests of auth in the recent window.
+func recentRequestTotal(auth *Auth, now time.Time) int64 {
+	var total int64
+	for _, bucket := range auth.RecentRequestsSnapshot(now) {
+		total += bucket.Success + bucket.Failed
+	}
+	return total
+}
+
 func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, blockReason, time.Time) {
 	if auth == nil {
 		return true, blockReasonOther, time.Time{}
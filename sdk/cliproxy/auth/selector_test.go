@@ -61,6 +61,40 @@ func TestRoundRobinSelectorPick_CyclesDeterministic(t *testing.T) {
 	}
 }
 
+func TestLeastUsedSelectorPick_PrefersFewestRecentRequests(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	busy := &Auth{ID: "a"}
+	idle := &Auth{ID: "b"}
+	busy.recordRecentRequest(now, true)
+	busy.recordRecentRequest(now, false)
+	idle.recordRecentRequest(now, true)
+
+	selector := &LeastUsedSelector{}
+	got, err := selector.Pick(context.Background(), "claude", "", cliproxyexecutor.Options{}, []*Auth{busy, idle})
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "b")
+	}
+
+	// Equal usage rotates across the tied credentials.
+	idle.recordRecentRequest(now, true)
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		got, err = selector.Pick(context.Background(), "claude", "", cliproxyexecutor.Options{}, []*Auth{busy, idle})
+		if err != nil {
+			t.Fatalf("Pick() #%d error = %v", i, err)
+		}
+		seen[got.ID] = true
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Fatalf("expected tied credentials to rotate, saw %v", seen)
+	}
+}
+
 func TestRoundRobinSelectorPick_PriorityBuckets(t *testing.T) {
 	t.Parallel()
 
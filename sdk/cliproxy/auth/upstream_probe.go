@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultProbeInterval = 5 * time.Minute
+	defaultProbeTimeout  = 30 * time.Second
+)
+
+// probePayload is the OpenAI chat request sent through each credential by the upstream probe.
+var probePayload = []byte(`{"messages":[{"role":"user","content":"ping"}],"max_tokens":1,"stream":false}`)
+
+// ProbeStatus is the outcome of the last upstream probe of one credential.
+type ProbeStatus struct {
+	AuthID    string    `json:"auth_id"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Healthy   bool      `json:"healthy"`
+	LastError string    `json:"last_error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// upstreamProbe holds the probe results and the background loop state.
+type upstreamProbe struct {
+	mu       sync.Mutex
+	statuses map[string]ProbeStatus
+	cancel   context.CancelFunc
+}
+
+func newUpstreamProbe() *upstreamProbe {
+	return &upstreamProbe{statuses: make(map[string]ProbeStatus)}
+}
+
+// probeSettings resolves the probe configuration; ok is false when the probe is disabled.
+func (m *Manager) probeSettings() (settings internalconfig.UpstreamProbeConfig, interval, timeout time.Duration, ok bool) {
+	if m == nil || m.probe == nil {
+		return settings, 0, 0, false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return settings, defaultProbeInterval, defaultProbeTimeout, false
+	}
+	settings = cfg.UpstreamProbe
+	interval = time.Duration(settings.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	timeout = time.Duration(settings.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	return settings, interval, timeout, settings.Enabled
+}
+
+// StartUpstreamProbe launches the background loop probing every credential while
+// upstream-probe is enabled. The configuration is re-read before every round.
+func (m *Manager) StartUpstreamProbe(parent context.Context) {
+	if m == nil || m.probe == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(parent)
+	m.probe.mu.Lock()
+	previous := m.probe.cancel
+	m.probe.cancel = cancel
+	m.probe.mu.Unlock()
+	if previous != nil {
+		previous()
+	}
+	go func() {
+		for {
+			_, interval, _, enabled := m.probeSettings()
+			if enabled {
+				m.ProbeUpstreams(ctx)
+			} else {
+				m.clearProbeStatuses()
+			}
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// StopUpstreamProbe stops the background probe loop, if running.
+func (m *Manager) StopUpstreamProbe() {
+	if m == nil || m.probe == nil {
+		return
+	}
+	m.probe.mu.Lock()
+	cancel := m.probe.cancel
+	m.probe.cancel = nil
+	m.probe.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// ProbeUpstreams sends a minimal request through every enabled credential and records whether
+// it succeeded. Credentials without a registered model or executor are not probed.
+func (m *Manager) ProbeUpstreams(ctx context.Context) {
+	settings, _, timeout, _ := m.probeSettings()
+	var wg sync.WaitGroup
+	for _, auth := range m.List() {
+		if auth == nil || auth.Disabled || auth.Status == StatusDisabled {
+			continue
+		}
+		executor := m.executorFor(auth.Provider)
+		if executor == nil {
+			continue
+		}
+		model := m.probeModel(auth, settings.Models[auth.Provider])
+		if model == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(auth *Auth, executor ProviderExecutor, model string) {
+			defer wg.Done()
+			m.probeAuth(ctx, auth, executor, model, timeout)
+		}(auth, executor, model)
+	}
+	wg.Wait()
+	m.pruneProbeStatuses()
+}
+
+func (m *Manager) probeAuth(ctx context.Context, auth *Auth, executor ProviderExecutor, model string, timeout time.Duration) {
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if rt := m.roundTripperFor(auth); rt != nil {
+		probeCtx = context.WithValue(probeCtx, roundTripperContextKey{}, rt)
+		probeCtx = context.WithValue(probeCtx, "cliproxy.roundtripper", rt)
+	}
+	req := cliproxyexecutor.Request{Model: model, Payload: probePayload}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: probePayload}
+	_, errExec := executor.Execute(probeCtx, auth, req, opts)
+	if errExec != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+	status := ProbeStatus{
+		AuthID:    auth.ID,
+		Provider:  auth.Provider,
+		Model:     model,
+		Healthy:   errExec == nil,
+		CheckedAt: time.Now(),
+	}
+	if errExec != nil {
+		status.LastError = errExec.Error()
+		log.WithFields(log.Fields{"auth_id": auth.ID, "provider": auth.Provider, "model": model}).Warnf("upstream probe failed: %v", errExec)
+	}
+	m.probe.mu.Lock()
+	m.probe.statuses[auth.ID] = status
+	m.probe.mu.Unlock()
+}
+
+// probeModel returns the upstream model used to probe auth: the configured model of its
+// provider, or else the first model registered for the credential.
+func (m *Manager) probeModel(auth *Auth, configured string) string {
+	model := configured
+	if model == "" {
+		models := registry.GetGlobalRegistry().GetModelsForClient(auth.ID)
+		if len(models) == 0 || models[0] == nil {
+			return ""
+		}
+		model = models[0].ID
+	}
+	if upstream, _ := m.preparedExecutionModels(auth, model); len(upstream) > 0 {
+		return upstream[0]
+	}
+	return model
+}
+
+// pruneProbeStatuses forgets credentials that no longer exist.
+func (m *Manager) pruneProbeStatuses() {
+	m.probe.mu.Lock()
+	defer m.probe.mu.Unlock()
+	for authID := range m.probe.statuses {
+		if _, ok := m.GetByID(authID); !ok {
+			delete(m.probe.statuses, authID)
+		}
+	}
+}
+
+func (m *Manager) clearProbeStatuses() {
+	m.probe.mu.Lock()
+	clear(m.probe.statuses)
+	m.probe.mu.Unlock()
+}
+
+// ProbeUnhealthy reports whether the last upstream probe of authID failed.
+func (m *Manager) ProbeUnhealthy(authID string) bool {
+	if m == nil || m.probe == nil {
+		return false
+	}
+	if _, _, _, enabled := m.probeSettings(); !enabled {
+		return false
+	}
+	m.probe.mu.Lock()
+	defer m.probe.mu.Unlock()
+	status, ok := m.probe.statuses[authID]
+	return ok && !status.Healthy
+}
+
+// UpstreamProbeStatuses returns the last probe result of every probed credential, sorted by
+// provider and auth ID.
+func (m *Manager) UpstreamProbeStatuses() []ProbeStatus {
+	if m == nil || m.probe == nil {
+		return nil
+	}
+	m.probe.mu.Lock()
+	out := make([]ProbeStatus, 0, len(m.probe.statuses))
+	for _, status := range m.probe.statuses {
+		out = append(out, status)
+	}
+	m.probe.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].AuthID < out[j].AuthID
+	})
+	return out
+}
+
+// probeGate skips credentials marked unhealthy by the upstream probe during one request. When
+// no other credential is left, the skipped ones are admitted again rather than failing the
+// request.
+type probeGate struct {
+	skipped    map[string]struct{}
+	readmitted bool
+}
+
+// skip reports whether authID should be passed over, marking it as tried.
+func (g *probeGate) skip(m *Manager, authID string, tried map[string]struct{}) bool {
+	if g.readmitted || !m.ProbeUnhealthy(authID) {
+		return false
+	}
+	if g.skipped == nil {
+		g.skipped = make(map[string]struct{})
+	}
+	g.skipped[authID] = struct{}{}
+	tried[authID] = struct{}{}
+	return true
+}
+
+// readmit makes the skipped credentials selectable again once, reporting whether any were.
+func (g *probeGate) readmit(tried map[string]struct{}) bool {
+	if g.readmitted || len(g.skipped) == 0 {
+		return false
+	}
+	g.readmitted = true
+	for authID := range g.skipped {
+		delete(tried, authID)
+	}
+	return true
+}
+
+// ReadyAuthCount returns how many credentials can serve requests at now: enabled, not cooling
+// down, with an unexpired token and not marked unhealthy by the upstream probe.
+func (m *Manager) ReadyAuthCount(now time.Time) int {
+	if m == nil {
+		return 0
+	}
+	ready := 0
+	for _, auth := range m.List() {
+		if auth == nil || auth.Disabled || auth.Status == StatusDisabled {
+			continue
+		}
+		if auth.Unavailable && auth.NextRetryAfter.After(now) {
+			continue
+		}
+		if expiry, ok := auth.ExpirationTime(); ok && !expiry.IsZero() && !expiry.After(now) {
+			continue
+		}
+		if m.ProbeUnhealthy(auth.ID) {
+			continue
+		}
+		ready++
+	}
+	return ready
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func newProbeTestManager(t *testing.T, authCount int) (*Manager, *perAuthExecutor, []string) {
+	t.Helper()
+	m, executor, ids := newCircuitTestManager(t, authCount)
+	m.SetConfig(&internalconfig.Config{UpstreamProbe: internalconfig.UpstreamProbeConfig{Enabled: true}})
+	return m, executor, ids
+}
+
+func TestManager_UpstreamProbe_SkipsUnhealthyCredential(t *testing.T) {
+	m, executor, ids := newProbeTestManager(t, 2)
+	executor.setFailing(ids[0], true)
+	m.ProbeUpstreams(context.Background())
+	executor.setFailing(ids[0], false)
+
+	statuses := m.UpstreamProbeStatuses()
+	if len(statuses) != 2 {
+		t.Fatalf("statuses = %+v, want 2", statuses)
+	}
+	if !m.ProbeUnhealthy(ids[0]) || m.ProbeUnhealthy(ids[1]) {
+		t.Fatalf("statuses = %+v, want only %s unhealthy", statuses, ids[0])
+	}
+	if ready := m.ReadyAuthCount(time.Now()); ready != 1 {
+		t.Fatalf("ReadyAuthCount() = %d, want 1", ready)
+	}
+
+	before := executor.Calls(ids[0])
+	resp, errExecute := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "test-model"}, cliproxyexecutor.Options{})
+	if errExecute != nil {
+		t.Fatalf("Execute() error = %v", errExecute)
+	}
+	if string(resp.Payload) != ids[1] {
+		t.Fatalf("served by %s, want healthy %s", resp.Payload, ids[1])
+	}
+	if calls := executor.Calls(ids[0]); calls != before {
+		t.Fatalf("unhealthy credential called %d time(s), want 0", calls-before)
+	}
+}
+
+func TestManager_UpstreamProbe_FallsBackWhenAllUnhealthy(t *testing.T) {
+	m, executor, ids := newProbeTestManager(t, 1)
+	executor.setFailing(ids[0], true)
+	m.ProbeUpstreams(context.Background())
+	executor.setFailing(ids[0], false)
+
+	resp, errExecute := m.Execute(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: "test-model"}, cliproxyexecutor.Options{})
+	if errExecute != nil {
+		t.Fatalf("Execute() error = %v", errExecute)
+	}
+	if string(resp.Payload) != ids[0] {
+		t.Fatalf("served by %s, want %s", resp.Payload, ids[0])
+	}
+
+	m.SetConfig(&internalconfig.Config{})
+	if m.ProbeUnhealthy(ids[0]) {
+		t.Fatal("ProbeUnhealthy() = true with the probe disabled")
+	}
+}
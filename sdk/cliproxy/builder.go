@@ -225,6 +225,8 @@ func (b *Builder) Build() (*Service, error) {
 		switch strategy {
 		case "fill-first", "fillfirst", "ff":
 			selector = &coreauth.FillFirstSelector{}
+		case "least-used", "leastused", "lu":
+			selector = &coreauth.LeastUsedSelector{}
 		default:
 			selector = &coreauth.RoundRobinSelector{}
 		}
@@ -0,0 +1,163 @@
+// Package provider defines the interface an upstream provider implements to be compiled into
+// the proxy without changes to internal packages. A registered provider contributes its
+// translators to the translator registry, the executor serving its credentials and, optionally,
+// the login flow for them.
+package provider
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	sdkauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// Capabilities describes what a provider supports.
+type Capabilities struct {
+	// Format is the payload schema the provider's upstream speaks.
+	Format sdktranslator.Format
+	// SourceFormats lists the client schemas the provider translates from. Requests in Format
+	// itself are passed through.
+	SourceFormats []sdktranslator.Format
+	// Streaming reports whether the upstream supports streamed responses.
+	Streaming bool
+	// CountTokens reports whether the executor implements token counting.
+	CountTokens bool
+}
+
+// Auth describes how a provider uses its credentials.
+type Auth interface {
+	// NewExecutor returns the executor sending requests with the provider's credentials.
+	NewExecutor(cfg *config.Config) coreauth.ProviderExecutor
+	// Authenticator returns the login flow for the provider, or nil when its credentials are
+	// only configured.
+	Authenticator() sdkauth.Authenticator
+}
+
+// Provider is an upstream provider. Credentials whose provider matches Identifier are served
+// by the executor of Auth.
+type Provider interface {
+	// Identifier returns the provider key of the credentials the provider serves.
+	Identifier() string
+	// Capabilities describes the provider's formats and features.
+	Capabilities() Capabilities
+	// TranslateRequest converts a client request in format from to the provider's format.
+	TranslateRequest(from sdktranslator.Format, model string, rawJSON []byte, stream bool) []byte
+	// TranslateStreamChunk converts one upstream stream chunk back to format from. param
+	// carries state across the chunks of one stream.
+	TranslateStreamChunk(ctx context.Context, from sdktranslator.Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte
+	// TranslateResponse converts a complete upstream response back to format from.
+	TranslateResponse(ctx context.Context, from sdktranslator.Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte
+	// TranslateError converts an upstream error response to the error returned by the
+	// executor. The error should implement StatusCode() int so the status reaches the client.
+	TranslateError(status int, headers http.Header, body []byte) error
+	// Auth returns the provider's credential handling.
+	Auth() Auth
+}
+
+// TokenCountTranslator is implemented by providers that translate token counts back to the
+// client formats.
+type TokenCountTranslator interface {
+	// TranslateTokenCount converts a token count to a count-tokens response in format from.
+	TranslateTokenCount(ctx context.Context, from sdktranslator.Format, count int64) []byte
+}
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]Provider)
+)
+
+// Register makes p available to the proxy, replacing a provider with the same identifier. The
+// translators from each source format to the provider's format are registered with the
+// default translator registry, and a login flow, when present, supplies the refresh lead of
+// the provider's credentials.
+func Register(p Provider) {
+	if p == nil {
+		return
+	}
+	key := normalizeKey(p.Identifier())
+	if key == "" {
+		return
+	}
+	caps := p.Capabilities()
+	counter, _ := p.(TokenCountTranslator)
+	for _, from := range caps.SourceFormats {
+		if from == caps.Format {
+			continue
+		}
+		response := sdktranslator.ResponseTransform{
+			Stream: func(ctx context.Context, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) [][]byte {
+				return p.TranslateStreamChunk(ctx, from, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			},
+			NonStream: func(ctx context.Context, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) []byte {
+				return p.TranslateResponse(ctx, from, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			},
+		}
+		if counter != nil {
+			response.TokenCount = func(ctx context.Context, count int64) []byte {
+				return counter.TranslateTokenCount(ctx, from, count)
+			}
+		}
+		sdktranslator.Register(from, caps.Format, func(model string, rawJSON []byte, stream bool) []byte {
+			return p.TranslateRequest(from, model, rawJSON, stream)
+		}, response)
+	}
+	if auth := p.Auth(); auth != nil {
+		if authenticator := auth.Authenticator(); authenticator != nil {
+			coreauth.RegisterRefreshLeadProvider(key, authenticator.RefreshLead)
+		}
+	}
+
+	mu.Lock()
+	providers[key] = p
+	mu.Unlock()
+}
+
+// Lookup returns the provider registered for the provider key.
+func Lookup(identifier string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[normalizeKey(identifier)]
+	return p, ok
+}
+
+// Registered returns the identifiers of all registered providers, sorted.
+func Registered() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return sortedKeysLocked()
+}
+
+// Authenticators returns the login flows of the registered providers, for building an auth
+// manager.
+func Authenticators() []sdkauth.Authenticator {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]sdkauth.Authenticator, 0, len(providers))
+	for _, key := range sortedKeysLocked() {
+		if auth := providers[key].Auth(); auth != nil {
+			if authenticator := auth.Authenticator(); authenticator != nil {
+				out = append(out, authenticator)
+			}
+		}
+	}
+	return out
+}
+
+func sortedKeysLocked() []string {
+	keys := make([]string, 0, len(providers))
+	for key := range providers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func normalizeKey(identifier string) string {
+	return strings.ToLower(strings.TrimSpace(identifier))
+}
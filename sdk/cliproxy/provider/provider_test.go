@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	sdkauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+const fakeFormat = sdktranslator.Format("fake.chat")
+
+type fakeProvider struct{}
+
+func (fakeProvider) Identifier() string { return " Fake " }
+
+func (fakeProvider) Capabilities() Capabilities {
+	return Capabilities{Format: fakeFormat, SourceFormats: []sdktranslator.Format{sdktranslator.FormatOpenAI, fakeFormat}}
+}
+
+func (fakeProvider) TranslateRequest(from sdktranslator.Format, _ string, rawJSON []byte, _ bool) []byte {
+	return append([]byte(string(from)+":"), rawJSON...)
+}
+
+func (fakeProvider) TranslateStreamChunk(_ context.Context, from sdktranslator.Format, _ string, _, _, rawJSON []byte, _ *any) [][]byte {
+	return [][]byte{append([]byte(string(from)+"<"), rawJSON...)}
+}
+
+func (fakeProvider) TranslateResponse(_ context.Context, from sdktranslator.Format, _ string, _, _, rawJSON []byte, _ *any) []byte {
+	return append([]byte(string(from)+"<<"), rawJSON...)
+}
+
+func (fakeProvider) TranslateTokenCount(_ context.Context, from sdktranslator.Format, _ int64) []byte {
+	return []byte(string(from) + "#")
+}
+
+func (fakeProvider) TranslateError(status int, _ http.Header, body []byte) error {
+	return errors.New(string(body))
+}
+
+func (fakeProvider) Auth() Auth { return fakeAuth{} }
+
+type fakeAuth struct{}
+
+func (fakeAuth) NewExecutor(*config.Config) coreauth.ProviderExecutor { return nil }
+
+func (fakeAuth) Authenticator() sdkauth.Authenticator { return fakeAuthenticator{} }
+
+type fakeAuthenticator struct{}
+
+func (fakeAuthenticator) Provider() string { return "fake" }
+
+func (fakeAuthenticator) Login(context.Context, *config.Config, *sdkauth.LoginOptions) (*coreauth.Auth, error) {
+	return nil, nil
+}
+
+func (fakeAuthenticator) RefreshLead() *time.Duration { return new(time.Hour) }
+
+func TestRegisterWiresTranslatorsAndLookup(t *testing.T) {
+	Register(fakeProvider{})
+
+	if _, ok := Lookup("FAKE"); !ok {
+		t.Fatalf("expected provider lookup to ignore case, registered %v", Registered())
+	}
+	if len(Authenticators()) == 0 {
+		t.Fatal("expected the provider's authenticator to be listed")
+	}
+
+	ctx := context.Background()
+	if got := string(sdktranslator.TranslateRequest(sdktranslator.FormatOpenAI, fakeFormat, "m", []byte("req"), false)); got != "openai:req" {
+		t.Fatalf("request translation = %q", got)
+	}
+	if got := sdktranslator.TranslateStream(ctx, fakeFormat, sdktranslator.FormatOpenAI, "m", nil, nil, []byte("chunk"), nil); len(got) != 1 || string(got[0]) != "openai<chunk" {
+		t.Fatalf("stream translation = %q", got)
+	}
+	if got := string(sdktranslator.TranslateNonStream(ctx, fakeFormat, sdktranslator.FormatOpenAI, "m", nil, nil, []byte("resp"), nil)); got != "openai<<resp" {
+		t.Fatalf("response translation = %q", got)
+	}
+	if got := string(sdktranslator.TranslateTokenCount(ctx, fakeFormat, sdktranslator.FormatOpenAI, 3, nil)); got != "openai#" {
+		t.Fatalf("token count translation = %q", got)
+	}
+	// The provider's own format is passed through rather than registered.
+	if got := string(sdktranslator.TranslateRequest(fakeFormat, fakeFormat, "", []byte("req"), false)); got != "req" {
+		t.Fatalf("same-format request = %q", got)
+	}
+}
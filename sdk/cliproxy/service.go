@@ -16,11 +16,13 @@ import (
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/redisqueue"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
@@ -406,6 +408,16 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewOpenAICompatExecutor(compatProviderKey, s.cfg))
 		return
 	}
+	// External providers are registered as providers when their config entry is bound, and are
+	// then served like any other registered provider.
+	if name := strings.TrimSpace(a.Attributes["external_provider"]); name != "" {
+		provider.Register(executor.NewExternalProvider(name))
+	}
+	// Providers registered through the provider package bring their own executor.
+	if p, ok := provider.Lookup(a.Provider); ok && p.Auth() != nil {
+		s.coreManager.RegisterExecutor(p.Auth().NewExecutor(s.cfg))
+		return
+	}
 	switch strings.ToLower(a.Provider) {
 	case "gemini":
 		s.coreManager.RegisterExecutor(executor.NewGeminiExecutor(s.cfg))
@@ -420,8 +432,8 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		return
 	case "antigravity":
 		s.coreManager.RegisterExecutor(executor.NewAntigravityExecutor(s.cfg))
-	case "claude":
-		s.coreManager.RegisterExecutor(executor.NewClaudeExecutor(s.cfg))
+	case "bedrock":
+		s.coreManager.RegisterExecutor(executor.NewBedrockExecutor(s.cfg))
 	case "kimi":
 		s.coreManager.RegisterExecutor(executor.NewKimiExecutor(s.cfg))
 	default:
@@ -481,9 +493,10 @@ func (s *Service) Run(ctx context.Context) error {
 
 	usage.StartDefault(ctx)
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
 	defer func() {
+		// Allow the stream drain on top of the regular shutdown budget.
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second+s.shutdownDrainTimeout())
+		defer shutdownCancel()
 		if err := s.Shutdown(shutdownCtx); err != nil {
 			log.Errorf("service shutdown returned error: %v", err)
 		}
@@ -494,6 +507,7 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	registry.SetModelOverrides(s.cfg.ModelOverrides)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
@@ -634,6 +648,8 @@ func (s *Service) Run(ctx context.Context) error {
 			switch strategy {
 			case "fill-first", "fillfirst", "ff":
 				return "fill-first"
+			case "least-used", "leastused", "lu":
+				return "least-used"
 			default:
 				return "round-robin"
 			}
@@ -653,6 +669,8 @@ func (s *Service) Run(ctx context.Context) error {
 			switch nextStrategy {
 			case "fill-first":
 				selector = &coreauth.FillFirstSelector{}
+			case "least-used":
+				selector = &coreauth.LeastUsedSelector{}
 			default:
 				selector = &coreauth.RoundRobinSelector{}
 			}
@@ -685,6 +703,8 @@ func (s *Service) Run(ctx context.Context) error {
 			s.coreManager.SetConfig(newCfg)
 			s.coreManager.SetOAuthModelAlias(newCfg.OAuthModelAlias)
 		}
+		registry.SetModelOverrides(newCfg.ModelOverrides)
+		executor.CloseExternalGRPCConns(newCfg)
 		s.rebindExecutors()
 	}
 
@@ -711,6 +731,7 @@ func (s *Service) Run(ctx context.Context) error {
 		interval := 15 * time.Minute
 		s.coreManager.StartAutoRefresh(context.Background(), interval)
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
+		s.coreManager.StartUpstreamProbe(context.Background())
 	}
 
 	select {
@@ -741,6 +762,21 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			ctx = context.Background()
 		}
 
+		// Let in-flight requests and streams finish while auth, watcher and usage still run.
+		drainTimedOut := false
+		if s.server != nil {
+			drainTimeout := s.shutdownDrainTimeout()
+			drainCtx, drainCancel := context.WithTimeout(ctx, drainTimeout)
+			if drainTimeout > 0 {
+				log.Infof("draining in-flight requests for up to %s...", drainTimeout)
+			}
+			if remaining := s.server.Drain(drainCtx); remaining > 0 {
+				log.Warnf("shutdown drain timed out with %d request(s) still running", remaining)
+				drainTimedOut = true
+			}
+			drainCancel()
+		}
+
 		// legacy refresh loop removed; only stopping core auth manager below
 
 		if s.watcherCancel != nil {
@@ -748,6 +784,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopUpstreamProbe()
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -755,6 +792,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 				shutdownErr = err
 			}
 		}
+		executor.CloseExternalGRPCConns(nil)
 		if s.wsGateway != nil {
 			if err := s.wsGateway.Stop(ctx); err != nil {
 				log.Errorf("failed to stop websocket gateway: %v", err)
@@ -775,10 +813,19 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			}
 		}
 
-		// no legacy clients to persist
+		// Flush usage records of drained requests before the listeners go away.
+		if errFlush := usage.StopAndWaitDefault(ctx); errFlush != nil {
+			log.Warnf("usage queue not fully flushed on shutdown: %v", errFlush)
+		}
+		internalusage.SetStore(nil)
 
 		if s.server != nil {
-			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			stopTimeout := 30 * time.Second
+			if drainTimedOut {
+				// The drain budget is spent; close remaining connections right away.
+				stopTimeout = time.Second
+			}
+			shutdownCtx, cancel := context.WithTimeout(ctx, stopTimeout)
 			defer cancel()
 			if err := s.server.Stop(shutdownCtx); err != nil {
 				log.Errorf("error stopping API server: %v", err)
@@ -788,11 +835,20 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			}
 		}
 
-		usage.StopDefault()
 	})
 	return shutdownErr
 }
 
+// shutdownDrainTimeout returns how long shutdown waits for in-flight requests to finish.
+func (s *Service) shutdownDrainTimeout() time.Duration {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	if s.cfg == nil || s.cfg.ShutdownDrainTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(s.cfg.ShutdownDrainTimeout) * time.Second
+}
+
 func (s *Service) ensureAuthDir() error {
 	info, err := os.Stat(s.cfg.AuthDir)
 	if err != nil {
@@ -853,6 +909,18 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			excluded = strings.Split(val, ",")
 		}
 	}
+	if strings.TrimSpace(a.Attributes["external_provider"]) != "" {
+		var models []*ModelInfo
+		if entry := s.resolveConfigExternalProvider(a); entry != nil {
+			models = applyExcludedModels(buildConfigModels(entry.Models, entry.Name, "external"), excluded)
+		}
+		if len(models) == 0 {
+			GlobalModelRegistry().UnregisterClient(a.ID)
+			return
+		}
+		s.registerResolvedModelsForAuth(a, provider, applyModelPrefixes(models, a.Prefix, s.cfg.ForceModelPrefix))
+		return
+	}
 	var models []*ModelInfo
 	switch provider {
 	case "gemini":
@@ -877,6 +945,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 				excluded = entry.ExcludedModels
 			}
 		}
+		if authKind != "apikey" {
+			models = append(models, s.buildVertexClaudeModels(a)...)
+		}
 		models = applyExcludedModels(models, excluded)
 	case "gemini-cli":
 		models = registry.GetGeminiCLIModels()
@@ -898,6 +969,23 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			}
 		}
 		models = applyExcludedModels(models, excluded)
+	case "bedrock":
+		if entry := s.resolveConfigBedrockKey(a); entry != nil {
+			models = buildConfigModels(entry.Models, "anthropic", "claude")
+			excluded = entry.ExcludedModels
+		}
+		models = applyExcludedModels(models, excluded)
+	case executor.OpenRouterProvider:
+		models = applyExcludedModels(s.buildOpenRouterModels(a), excluded)
+	case executor.OllamaProvider:
+		// Ollama models are only reachable through their prefix, regardless of force-model-prefix.
+		models = applyExcludedModels(s.buildOllamaModels(a), excluded)
+		if len(models) == 0 {
+			GlobalModelRegistry().UnregisterClient(a.ID)
+			return
+		}
+		s.registerResolvedModelsForAuth(a, provider, applyModelPrefixes(models, a.Prefix, true))
+		return
 	case "codex":
 		codexPlanType := ""
 		if a.Attributes != nil {
@@ -1079,6 +1167,64 @@ func (s *Service) latestAuthForModelRegistration(authID string) (*coreauth.Auth,
 	return auth, true
 }
 
+func (s *Service) resolveConfigBedrockKey(auth *coreauth.Auth) *config.BedrockKey {
+	if auth == nil || s.cfg == nil || auth.Attributes == nil {
+		return nil
+	}
+	attrKey := strings.TrimSpace(auth.Attributes["api_key"])
+	attrRegion := strings.TrimSpace(auth.Attributes["region"])
+	for i := range s.cfg.BedrockKey {
+		entry := &s.cfg.BedrockKey[i]
+		if strings.EqualFold(entry.GetAPIKey(), attrKey) && strings.EqualFold(entry.Region, attrRegion) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigOpenRouterKey(auth *coreauth.Auth) *config.OpenRouterKey {
+	if auth == nil || s.cfg == nil || auth.Attributes == nil {
+		return nil
+	}
+	attrKey := strings.TrimSpace(auth.Attributes["api_key"])
+	attrBase := strings.TrimSpace(auth.Attributes["base_url"])
+	for i := range s.cfg.OpenRouterKey {
+		entry := &s.cfg.OpenRouterKey[i]
+		if entry.APIKey == attrKey && strings.EqualFold(entry.BaseURL, attrBase) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigExternalProvider(auth *coreauth.Auth) *config.ExternalProvider {
+	if auth == nil || s.cfg == nil || auth.Attributes == nil {
+		return nil
+	}
+	name := strings.TrimSpace(auth.Attributes["external_provider"])
+	for i := range s.cfg.ExternalProviders {
+		entry := &s.cfg.ExternalProviders[i]
+		if strings.EqualFold(entry.Name, name) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (s *Service) resolveConfigOllamaServer(auth *coreauth.Auth) *config.OllamaServer {
+	if auth == nil || s.cfg == nil || auth.Attributes == nil {
+		return nil
+	}
+	attrBase := strings.TrimSuffix(strings.TrimSpace(auth.Attributes["base_url"]), "/v1")
+	for i := range s.cfg.Ollama {
+		entry := &s.cfg.Ollama[i]
+		if strings.EqualFold(entry.BaseURL, attrBase) && strings.EqualFold(entry.Prefix, auth.Prefix) {
+			return entry
+		}
+	}
+	return nil
+}
+
 func (s *Service) resolveConfigClaudeKey(auth *coreauth.Auth) *config.ClaudeKey {
 	if auth == nil || s.cfg == nil {
 		return nil
@@ -1395,6 +1541,96 @@ func buildVertexCompatConfigModels(entry *config.VertexCompatKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "google", "vertex")
 }
 
+// buildVertexClaudeModels lists the vertex-claude models served by a Vertex service account
+// credential whose project is selected for Claude routing.
+func (s *Service) buildVertexClaudeModels(a *coreauth.Auth) []*ModelInfo {
+	if s == nil || s.cfg == nil || a == nil {
+		return nil
+	}
+	projectID := ""
+	if a.Metadata != nil {
+		projectID, _ = a.Metadata["project_id"].(string)
+	}
+	if !s.cfg.VertexClaude.AppliesToProject(projectID) {
+		return nil
+	}
+	return buildConfigModels(s.cfg.VertexClaude.Models, "anthropic", "claude")
+}
+
+// buildOpenRouterModels lists the OpenRouter models of a credential. Configured models are
+// registered under their aliases with the catalog capabilities; without configured models the
+// whole catalog is exposed. When the catalog is unavailable, configured models are registered
+// as user-defined so thinking settings pass through unchanged.
+func (s *Service) buildOpenRouterModels(a *coreauth.Auth) []*ModelInfo {
+	entry := s.resolveConfigOpenRouterKey(a)
+	catalog, errFetch := executor.FetchOpenRouterModels(context.Background(), s.cfg, a)
+	if errFetch != nil {
+		log.Warnf("openrouter: model catalog sync failed for %s: %v", a.ID, errFetch)
+	}
+	if entry == nil || len(entry.Models) == 0 {
+		return catalog
+	}
+	byID := make(map[string]*ModelInfo, len(catalog))
+	for _, model := range catalog {
+		byID[strings.ToLower(model.ID)] = model
+	}
+	configured := buildConfigModels(entry.Models, executor.OpenRouterProvider, executor.OpenRouterProvider)
+	for i, model := range configured {
+		// buildConfigModels keeps the upstream model ID as the display name.
+		upstream, ok := byID[strings.ToLower(model.DisplayName)]
+		if !ok {
+			continue
+		}
+		info := *upstream
+		info.ID = model.ID
+		configured[i] = &info
+	}
+	return configured
+}
+
+// buildOllamaModels lists the models of an Ollama server with their discovered capabilities.
+// When the server cannot be queried, the configured models are registered with the declared
+// thinking support so requests still route once the server comes up.
+func (s *Service) buildOllamaModels(a *coreauth.Auth) []*ModelInfo {
+	entry := s.resolveConfigOllamaServer(a)
+	discovered, errFetch := executor.FetchOllamaModels(context.Background(), s.cfg, a)
+	if errFetch != nil {
+		log.Warnf("ollama: model discovery failed for %s: %v", a.ID, errFetch)
+	}
+	if entry == nil || len(entry.Models) == 0 {
+		return discovered
+	}
+	byName := make(map[string]*ModelInfo, len(discovered))
+	for _, model := range discovered {
+		byName[strings.ToLower(model.ID)] = model
+	}
+	now := time.Now().Unix()
+	models := make([]*ModelInfo, 0, len(entry.Models))
+	for _, selected := range entry.Models {
+		if model, ok := byName[strings.ToLower(selected.Name)]; ok {
+			models = append(models, model)
+			continue
+		}
+		if errFetch == nil {
+			log.Warnf("ollama: model %s is not installed on %s", selected.Name, entry.BaseURL)
+			continue
+		}
+		model := &ModelInfo{
+			ID:          selected.Name,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     executor.OllamaProvider,
+			Type:        executor.OllamaProvider,
+			DisplayName: selected.Name,
+		}
+		if selected.Thinking {
+			model.Thinking = executor.OllamaThinkingSupport()
+		}
+		models = append(models, model)
+	}
+	return models
+}
+
 func buildGeminiConfigModels(entry *config.GeminiKey) []*ModelInfo {
 	if entry == nil {
 		return nil
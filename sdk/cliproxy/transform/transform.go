@@ -0,0 +1,164 @@
+// Package transform lets embedders rewrite translated streaming response chunks before they
+// are written to clients, e.g. to strip markers, add watermarks or enforce content filters.
+package transform
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// StreamInfo describes the response stream being transformed.
+type StreamInfo struct {
+	// Format is the client-facing response format ("openai", "openai-response", "claude", "gemini", ...).
+	Format string
+	// Model is the model requested by the client.
+	Model string
+}
+
+// Stream transforms the chunks of one response stream. Each chunk is a translated payload in
+// the client format, typically one or more complete SSE events.
+type Stream interface {
+	// Chunk returns the chunks to emit in place of chunk. Returning nil drops the chunk and
+	// returning several chunks injects new ones.
+	Chunk(chunk []byte) [][]byte
+	// Close returns chunks to append after the upstream stream ends without an error.
+	Close() [][]byte
+}
+
+// Plugin creates a Stream for each streaming response. Stream may return nil to leave the
+// response untouched.
+type Plugin interface {
+	Stream(ctx context.Context, info StreamInfo) Stream
+}
+
+// ChunkFunc adapts a stateless chunk transformation to Plugin.
+type ChunkFunc func(ctx context.Context, info StreamInfo, chunk []byte) [][]byte
+
+// Stream implements Plugin.
+func (f ChunkFunc) Stream(ctx context.Context, info StreamInfo) Stream {
+	if f == nil {
+		return nil
+	}
+	return chunkFuncStream{fn: f, ctx: ctx, info: info}
+}
+
+type chunkFuncStream struct {
+	fn   ChunkFunc
+	ctx  context.Context
+	info StreamInfo
+}
+
+func (s chunkFuncStream) Chunk(chunk []byte) [][]byte { return s.fn(s.ctx, s.info, chunk) }
+
+func (s chunkFuncStream) Close() [][]byte { return nil }
+
+var plugins struct {
+	mu   sync.RWMutex
+	list []Plugin
+}
+
+// RegisterPlugin appends plugin to the chain applied to every streaming response. Plugins run
+// in registration order, each seeing the output of the previous one.
+func RegisterPlugin(plugin Plugin) {
+	if plugin == nil {
+		return
+	}
+	plugins.mu.Lock()
+	plugins.list = append(plugins.list, plugin)
+	plugins.mu.Unlock()
+}
+
+// Chain applies the registered plugins to one response stream.
+type Chain struct {
+	streams []Stream
+}
+
+// NewChain starts the registered plugins for one response stream. It returns nil when no
+// plugin handles the stream; a nil Chain passes chunks through unchanged.
+func NewChain(ctx context.Context, info StreamInfo) *Chain {
+	plugins.mu.RLock()
+	list := append([]Plugin(nil), plugins.list...)
+	plugins.mu.RUnlock()
+	return newChain(ctx, info, list)
+}
+
+func newChain(ctx context.Context, info StreamInfo, list []Plugin) *Chain {
+	var streams []Stream
+	for _, plugin := range list {
+		if stream := safeStream(plugin, ctx, info); stream != nil {
+			streams = append(streams, stream)
+		}
+	}
+	if len(streams) == 0 {
+		return nil
+	}
+	return &Chain{streams: streams}
+}
+
+// Chunk runs chunk through every plugin and returns the chunks to write.
+func (c *Chain) Chunk(chunk []byte) [][]byte {
+	if c == nil {
+		return [][]byte{chunk}
+	}
+	return applyStreams(c.streams, [][]byte{chunk})
+}
+
+// Close flushes every plugin in order. Chunks emitted by a plugin on close still pass through
+// the plugins after it.
+func (c *Chain) Close() [][]byte {
+	if c == nil {
+		return nil
+	}
+	var out [][]byte
+	for i, stream := range c.streams {
+		out = append(out, applyStreams(c.streams[i+1:], safeClose(stream))...)
+	}
+	return out
+}
+
+func applyStreams(streams []Stream, chunks [][]byte) [][]byte {
+	for _, stream := range streams {
+		if len(chunks) == 0 {
+			return nil
+		}
+		var next [][]byte
+		for _, chunk := range chunks {
+			next = append(next, safeChunk(stream, chunk)...)
+		}
+		chunks = next
+	}
+	return chunks
+}
+
+func safeStream(plugin Plugin, ctx context.Context, info StreamInfo) (stream Stream) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("stream transform plugin panic: %v", r)
+			stream = nil
+		}
+	}()
+	return plugin.Stream(ctx, info)
+}
+
+// safeChunk passes chunk through unchanged when the plugin panics.
+func safeChunk(stream Stream, chunk []byte) (out [][]byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("stream transform plugin panic: %v", r)
+			out = [][]byte{chunk}
+		}
+	}()
+	return stream.Chunk(chunk)
+}
+
+func safeClose(stream Stream) (out [][]byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("stream transform plugin panic: %v", r)
+			out = nil
+		}
+	}()
+	return stream.Close()
+}
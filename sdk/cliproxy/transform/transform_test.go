@@ -0,0 +1,70 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type suffixStream struct{ chunks int }
+
+func (s *suffixStream) Chunk(chunk []byte) [][]byte {
+	s.chunks++
+	return [][]byte{chunk}
+}
+
+func (s *suffixStream) Close() [][]byte { return [][]byte{[]byte("tail")} }
+
+type suffixPlugin struct{}
+
+func (suffixPlugin) Stream(context.Context, StreamInfo) Stream { return &suffixStream{} }
+
+func TestChainDropModifyInject(t *testing.T) {
+	upper := ChunkFunc(func(_ context.Context, _ StreamInfo, chunk []byte) [][]byte {
+		switch string(chunk) {
+		case "drop":
+			return nil
+		case "split":
+			return [][]byte{[]byte("a"), []byte("b")}
+		default:
+			return [][]byte{bytes.ToUpper(chunk)}
+		}
+	})
+	panicky := ChunkFunc(func(context.Context, StreamInfo, []byte) [][]byte { panic("boom") })
+	chain := newChain(context.Background(), StreamInfo{Format: "openai"}, []Plugin{suffixPlugin{}, upper, panicky})
+
+	var got []string
+	for _, in := range []string{"hello", "drop", "split"} {
+		for _, out := range chain.Chunk([]byte(in)) {
+			got = append(got, string(out))
+		}
+	}
+	for _, out := range chain.Close() {
+		got = append(got, string(out))
+	}
+	want := []string{"HELLO", "a", "b", "TAIL"}
+	if len(got) != len(want) {
+		t.Fatalf("chunks = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("chunks = %q, want %q", got, want)
+		}
+	}
+	if seen := chain.streams[0].(*suffixStream).chunks; seen != 3 {
+		t.Fatalf("first plugin saw %d chunks, want 3", seen)
+	}
+}
+
+func TestNilChainPassesThrough(t *testing.T) {
+	var chain *Chain
+	if out := chain.Chunk([]byte("x")); len(out) != 1 || string(out[0]) != "x" {
+		t.Fatalf("nil chain Chunk() = %q, want [x]", out)
+	}
+	if out := chain.Close(); out != nil {
+		t.Fatalf("nil chain Close() = %q, want nil", out)
+	}
+	if chain = newChain(context.Background(), StreamInfo{}, []Plugin{ChunkFunc(nil)}); chain != nil {
+		t.Fatal("expected nil chain when no plugin handles the stream")
+	}
+}
@@ -19,17 +19,33 @@ type Record struct {
 	Source      string
 	RequestedAt time.Time
 	Latency     time.Duration
-	Failed      bool
-	Detail      Detail
+	// QueueTime is how long the request waited for an upstream slot of the request scheduler.
+	QueueTime time.Duration
+	Failed    bool
+	// Outcome qualifies how the request ended when it neither succeeded nor failed upstream,
+	// e.g. OutcomeClientCancelled; empty otherwise.
+	Outcome string
+	Detail  Detail
 }
 
+// OutcomeClientCancelled marks a request aborted because the client went away. Its Detail holds
+// the tokens the upstream reported before the cancellation.
+const OutcomeClientCancelled = "client_cancelled"
+
 // Detail holds the token usage breakdown.
 type Detail struct {
 	InputTokens     int64
 	OutputTokens    int64
 	ReasoningTokens int64
 	CachedTokens    int64
-	TotalTokens     int64
+	// CacheCreationTokens counts prompt tokens written to the provider's prompt cache.
+	CacheCreationTokens int64
+	// InputExcludesCache reports that InputTokens does not include CachedTokens and
+	// CacheCreationTokens, as with Claude. Otherwise cached tokens are part of InputTokens.
+	InputExcludesCache bool
+	TotalTokens        int64
+	// Cost is the upstream-reported request cost in USD; zero when the provider does not report it.
+	Cost float64
 }
 
 // Plugin consumes usage records emitted by the proxy runtime.
@@ -47,6 +63,7 @@ type Manager struct {
 	once     sync.Once
 	stopOnce sync.Once
 	cancel   context.CancelFunc
+	done     chan struct{}
 
 	mu     sync.Mutex
 	cond   *sync.Cond
@@ -75,7 +92,14 @@ func (m *Manager) Start(ctx context.Context) {
 		}
 		var workerCtx context.Context
 		workerCtx, m.cancel = context.WithCancel(ctx)
-		go m.run(workerCtx)
+		done := make(chan struct{})
+		m.mu.Lock()
+		m.done = done
+		m.mu.Unlock()
+		go func() {
+			defer close(done)
+			m.run(workerCtx)
+		}()
 	})
 }
 
@@ -105,12 +129,63 @@ func (m *Manager) Register(plugin Plugin) {
 	m.pluginsMu.Unlock()
 }
 
+// StopAndWait stops the dispatcher and blocks until every queued record has been delivered
+// or ctx ends.
+func (m *Manager) StopAndWait(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	m.Stop()
+	m.mu.Lock()
+	done := m.done
+	m.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// captureKey is the context key of the records collected by WithCapture.
+type captureKey struct{}
+
+type recordCapture struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// WithCapture returns a context under which published records are also collected, and a
+// function returning the records collected so far.
+func WithCapture(ctx context.Context) (context.Context, func() []Record) {
+	c := &recordCapture{}
+	collected := func() []Record {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return append([]Record(nil), c.records...)
+	}
+	return context.WithValue(ctx, captureKey{}, c), collected
+}
+
 // Publish enqueues a usage record for processing. If no plugin is registered
 // the record will be discarded downstream.
 func (m *Manager) Publish(ctx context.Context, record Record) {
 	if m == nil {
 		return
 	}
+	if ctx != nil {
+		if c, ok := ctx.Value(captureKey{}).(*recordCapture); ok {
+			c.mu.Lock()
+			c.records = append(c.records, record)
+			c.mu.Unlock()
+		}
+	}
 	// ensure worker is running even if Start was not called explicitly
 	m.Start(context.Background())
 	m.mu.Lock()
@@ -181,3 +256,6 @@ func StartDefault(ctx context.Context) { DefaultManager().Start(ctx) }
 
 // StopDefault stops the default manager's dispatcher.
 func StopDefault() { DefaultManager().Stop() }
+
+// StopAndWaitDefault stops the default manager and waits for its queue to drain.
+func StopAndWaitDefault(ctx context.Context) error { return DefaultManager().StopAndWait(ctx) }
@@ -0,0 +1,40 @@
+package usage
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingPlugin struct{ handled atomic.Int64 }
+
+func (p *countingPlugin) HandleUsage(context.Context, Record) {
+	time.Sleep(time.Millisecond)
+	p.handled.Add(1)
+}
+
+func TestStopAndWaitDeliversQueuedRecords(t *testing.T) {
+	manager := NewManager(0)
+	plugin := &countingPlugin{}
+	manager.Register(plugin)
+	for i := 0; i < 20; i++ {
+		manager.Publish(context.Background(), Record{})
+	}
+	if err := manager.StopAndWait(context.Background()); err != nil {
+		t.Fatalf("StopAndWait: %v", err)
+	}
+	if got := plugin.handled.Load(); got != 20 {
+		t.Fatalf("handled = %d, want 20", got)
+	}
+	manager.Publish(context.Background(), Record{})
+	if got := plugin.handled.Load(); got != 20 {
+		t.Fatalf("record accepted after stop: handled = %d", got)
+	}
+}
+
+func TestStopAndWaitWithoutStart(t *testing.T) {
+	if err := NewManager(0).StopAndWait(context.Background()); err != nil {
+		t.Fatalf("StopAndWait: %v", err)
+	}
+}
@@ -11,7 +11,12 @@ type SDKConfig = internalconfig.SDKConfig
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type MultipleChoicesConfig = internalconfig.MultipleChoicesConfig
+type AutoCompactConfig = internalconfig.AutoCompactConfig
+type SystemPromptRule = internalconfig.SystemPromptRule
+type ProxyKey = internalconfig.ProxyKey
 type TLSConfig = internalconfig.TLSConfig
+type IPAccessConfig = internalconfig.IPAccessConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
 type OAuthModelAlias = internalconfig.OAuthModelAlias
@@ -23,8 +28,16 @@ type PayloadModelRule = internalconfig.PayloadModelRule
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
 type ClaudeKey = internalconfig.ClaudeKey
+type BedrockKey = internalconfig.BedrockKey
+type OpenRouterKey = internalconfig.OpenRouterKey
+type OpenRouterModel = internalconfig.OpenRouterModel
+type OllamaServer = internalconfig.OllamaServer
+type OllamaModel = internalconfig.OllamaModel
+type ExternalProvider = internalconfig.ExternalProvider
+type ExternalProviderModel = internalconfig.ExternalProviderModel
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
+type VertexClaudeConfig = internalconfig.VertexClaudeConfig
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
@@ -33,6 +46,13 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
+
+	SystemPromptPrepend = internalconfig.SystemPromptPrepend
+	SystemPromptAppend  = internalconfig.SystemPromptAppend
+	SystemPromptReplace = internalconfig.SystemPromptReplace
+
+	StreamingKeepAliveComment    = internalconfig.StreamingKeepAliveComment
+	StreamingKeepAliveEmptyDelta = internalconfig.StreamingKeepAliveEmptyDelta
 )
 
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
@@ -0,0 +1,35 @@
+package builtin
+
+import (
+	claudeopenai "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/openai/chat-completions"
+)
+
+// OpenAIToClaudeRequestPipeline is the ordered list of named stages that converts OpenAI Chat
+// Completions requests into Claude Messages requests.
+type OpenAIToClaudeRequestPipeline = claudeopenai.RequestPipeline
+
+// OpenAIToClaudeRequestStage converts one aspect of an OpenAI request into the Claude request.
+type OpenAIToClaudeRequestStage = claudeopenai.RequestStage
+
+// OpenAIToClaudeRequestState is the conversion state handed from stage to stage.
+type OpenAIToClaudeRequestState = claudeopenai.RequestState
+
+// Built-in OpenAI → Claude request stage names.
+const (
+	OpenAIToClaudeStageParams       = claudeopenai.StageParams
+	OpenAIToClaudeStageMessages     = claudeopenai.StageMessages
+	OpenAIToClaudeStageTools        = claudeopenai.StageTools
+	OpenAIToClaudeStageThinking     = claudeopenai.StageThinking
+	OpenAIToClaudeStageCacheControl = claudeopenai.StageCacheControl
+)
+
+// DefaultOpenAIToClaudeRequestPipeline returns the built-in OpenAI → Claude request stages.
+func DefaultOpenAIToClaudeRequestPipeline() *OpenAIToClaudeRequestPipeline {
+	return claudeopenai.DefaultRequestPipeline()
+}
+
+// SetOpenAIToClaudeRequestPipeline installs the pipeline used for OpenAI → Claude request
+// conversion; nil restores the default. Stages disabled in the configuration stay disabled.
+func SetOpenAIToClaudeRequestPipeline(p *OpenAIToClaudeRequestPipeline) {
+	claudeopenai.SetRequestPipeline(p)
+}